@@ -0,0 +1,216 @@
+// Package accounts はユーザーアカウント（登録とパスワードの確認）とログインのセッションを管理します
+// タスクはユーザーごとに別のサーバ（保存先）で扱うため、このパッケージはユーザーを ID で見分けるだけで、タスクには触れません
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// ErrInvalidCredentials は名前かパスワードが誤っていることを表します（どちらが誤っているかは知らせません）
+var ErrInvalidCredentials = errors.New("invalid name or password")
+
+// パスワードの長さの範囲です（最小は PasswordPolicy.MinLength で変えられます）
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
+// namePattern はユーザー名に使える文字列です（英小文字・数字・_ . - の 3〜32 文字）
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,31}$`)
+
+// User は1人のユーザーです
+// ID: タスクの保存先を分けるための番号（1から、再利用しません）
+// Name: ログインに使う名前
+// Email / EmailVerified: 登録したメールアドレス（省略できます）と、確認用のリンクで確かめたかどうか
+// Disabled: ID プロバイダ（SCIM）で無効にしたユーザー。ログインもセッションや API キーの利用もできません
+type User struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified,omitempty"`
+	Disabled      bool      `json:"disabled,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// account はファイルに保存するユーザーとパスワードのハッシュ、メールアドレスの確認の状態です
+// PasswordHistory は使い回しを確かめるための、前のパスワードのハッシュです（新しい順）
+type account struct {
+	User
+	PasswordHash    string        `json:"password_hash"`
+	PasswordHistory []string      `json:"password_history,omitempty"`
+	Verification    *verification `json:"verification,omitempty"`
+}
+
+// Store は登録したユーザーを保持し、path が空でなければ JSON ファイルに保存します
+// Iterations: 新しく登録するパスワードのハッシュの繰り返しの回数（既定は DefaultIterations）。登録済みのハッシュは登録時の回数で確かめます
+// VerificationTTL: メールアドレスの確認用のリンクの有効期間（既定は DefaultVerificationTTL）
+// Policy: 登録とパスワードの変更で求めるパスワードの条件（既定は DefaultPasswordPolicy）
+type Store struct {
+	Iterations      int
+	VerificationTTL time.Duration
+	Policy          PasswordPolicy
+
+	path     string
+	now      func() time.Time
+	mutex    sync.Mutex
+	accounts []account
+}
+
+// NewStore は path のファイルからユーザーを読み込んで Store を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければ空の Store から始めます
+func NewStore(path string) (*Store, error) {
+	s := &Store{Iterations: DefaultIterations, VerificationTTL: DefaultVerificationTTL, Policy: DefaultPasswordPolicy(), path: path, now: time.Now}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.accounts); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	sort.Slice(s.accounts, func(i, j int) bool { return s.accounts[i].ID < s.accounts[j].ID })
+	return s, nil
+}
+
+// ValidateName は name がユーザー名として使えるかを確かめます
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: name must be 3-32 lowercase letters, digits, '_', '.' or '-'", models.ErrValidation)
+	}
+	return nil
+}
+
+// Register はメールアドレスのないユーザーを登録します（RegisterWithEmail を参照）
+func (s *Store) Register(name, password string) (User, error) {
+	return s.RegisterWithEmail(name, password, "")
+}
+
+// RegisterWithEmail はユーザーを登録します。名前は小文字にそろえます。email は空でも構いません（確かめる前の状態で登録します）
+// 名前かパスワードかメールアドレスが不正なら ErrValidation を、パスワードが漏洩していれば ErrPasswordBreached を、
+// 同じ名前のユーザーがいれば ErrConflict を返します
+func (s *Store) RegisterWithEmail(name, password, email string) (User, error) {
+	return s.register(name, password, email, false)
+}
+
+// register はユーザーを登録します。verified ならメールアドレスを確認済みにします
+func (s *Store) register(name, password, email string, verified bool) (User, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	email = strings.TrimSpace(email)
+	if err := ValidateName(name); err != nil {
+		return User{}, err
+	}
+	if email != "" {
+		if err := ValidateEmail(email); err != nil {
+			return User{}, err
+		}
+	}
+	if err := s.Policy.check(name, password); err != nil {
+		return User{}, err
+	}
+	// ハッシュの計算は時間がかかるため、ロックの外で行います
+	hash, err := HashPassword(password, s.Iterations)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.find(name); ok {
+		return User{}, fmt.Errorf("%w: user %q already exists", models.ErrConflict, name)
+	}
+	id := 1
+	if n := len(s.accounts); n > 0 {
+		id = s.accounts[n-1].ID + 1
+	}
+	user := User{ID: id, Name: name, Email: email, EmailVerified: verified && email != "", CreatedAt: s.now().UTC()}
+	s.accounts = append(s.accounts, account{User: user, PasswordHash: hash})
+	if err := s.save(); err != nil {
+		s.accounts = s.accounts[:len(s.accounts)-1]
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Authenticate は name と password が登録済みのユーザーと一致するかを確かめ、一致すればそのユーザーを返します
+// 一致しなければ ErrInvalidCredentials を返します。存在しない名前でも同じだけ時間をかけ、名前の有無を応答時間から分からないようにします
+// 無効にしたユーザーは、パスワードが一致したときだけ ErrUserDisabled を返します
+func (s *Store) Authenticate(name, password string) (User, error) {
+	s.mutex.Lock()
+	a, ok := s.find(strings.ToLower(strings.TrimSpace(name)))
+	s.mutex.Unlock()
+	if !ok {
+		CheckPassword(dummyHash(s.Iterations), password)
+		return User{}, ErrInvalidCredentials
+	}
+	if !CheckPassword(a.PasswordHash, password) {
+		return User{}, ErrInvalidCredentials
+	}
+	if a.Disabled {
+		return User{}, ErrUserDisabled
+	}
+	return a.User, nil
+}
+
+// Lookup は id のユーザーを返します。なければ false を返します
+func (s *Store) Lookup(id int) (User, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a, ok := s.lookup(id)
+	return a.User, ok
+}
+
+// lookup は id のアカウントを返します。ロック中に呼び出します
+func (s *Store) lookup(id int) (account, bool) {
+	for _, a := range s.accounts {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return account{}, false
+}
+
+// find は name のアカウントを返します。ロック中に呼び出します
+func (s *Store) find(name string) (account, bool) {
+	for _, a := range s.accounts {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return account{}, false
+}
+
+// save はアカウントを一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+// パスワードのハッシュを含むため、ファイルは所有者だけが読めるようにします
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
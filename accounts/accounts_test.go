@@ -0,0 +1,245 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// newTestStore は繰り返しの回数を減らした Store を作成します
+func newTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	s.Iterations = 1000
+	return s
+}
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914 の 11 節のテストベクタ
+	got := hex.EncodeToString(pbkdf2([]byte("passwd"), []byte("salt"), 1, 64))
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse", 1000)
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$1000$") {
+		t.Errorf("Unexpected hash format: %q", hash)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Expected the password to match its hash")
+	}
+	if CheckPassword(hash, "battery staple") {
+		t.Error("Expected a different password not to match")
+	}
+	if other, _ := HashPassword("correct horse", 1000); other == hash {
+		t.Error("Expected a random salt for each hash")
+	}
+	for _, invalid := range []string{"", "bcrypt$10$x$y", "pbkdf2-sha256$0$AAAA$AAAA", "pbkdf2-sha256$1$!$AAAA", "pbkdf2-sha256$1$AAAA$"} {
+		if CheckPassword(invalid, "correct horse") {
+			t.Errorf("Expected malformed hash %q not to match", invalid)
+		}
+	}
+	if _, err := HashPassword("x", 0); err == nil {
+		t.Error("Expected an error for zero iterations")
+	}
+}
+
+func TestRegisterAndAuthenticate(t *testing.T) {
+	s := newTestStore(t, "")
+
+	alice, err := s.Register(" Alice ", "correct horse")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if alice.ID != 1 || alice.Name != "alice" {
+		t.Errorf("Unexpected user: %+v", alice)
+	}
+	bob, _ := s.Register("bob", "battery staple")
+	if bob.ID != 2 {
+		t.Errorf("Expected the next ID to be 2, got %d", bob.ID)
+	}
+
+	if _, err := s.Register("alice", "another password"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected a conflict for a duplicate name, got %v", err)
+	}
+	for _, tc := range []struct{ name, password string }{
+		{"al", "correct horse"},
+		{"Alice Smith", "correct horse"},
+		{"carol", "short"},
+		{"carol", strings.Repeat("x", MaxPasswordLength+1)},
+	} {
+		if _, err := s.Register(tc.name, tc.password); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Register(%q, %q): expected a validation error, got %v", tc.name, tc.password, err)
+		}
+	}
+
+	if user, err := s.Authenticate("ALICE", "correct horse"); err != nil || user != alice {
+		t.Errorf("Expected alice to log in, got %+v %v", user, err)
+	}
+	if _, err := s.Authenticate("alice", "battery staple"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a wrong password to be rejected, got %v", err)
+	}
+	if _, err := s.Authenticate("nobody", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected an unknown name to be rejected, got %v", err)
+	}
+
+	if user, ok := s.Lookup(2); !ok || user != bob {
+		t.Errorf("Expected to look up bob, got %+v %v", user, ok)
+	}
+	if _, ok := s.Lookup(3); ok {
+		t.Error("Expected no user with ID 3")
+	}
+}
+
+func TestStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s := newTestStore(t, path)
+	alice, err := s.Register("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the users to be saved: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be readable only by its owner, got %v", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "correct horse") {
+		t.Error("Expected the password not to be saved in plain text")
+	}
+
+	reloaded := newTestStore(t, path)
+	if user, err := reloaded.Authenticate("alice", "correct horse"); err != nil || user.ID != alice.ID {
+		t.Errorf("Expected the user to survive a reload, got %+v %v", user, err)
+	}
+	if bob, _ := reloaded.Register("bob", "battery staple"); bob.ID != 2 {
+		t.Errorf("Expected IDs to continue after a reload, got %d", bob.ID)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0600)
+	if _, err := NewStore(path); err == nil {
+		t.Error("Expected an error for a corrupt file")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSessions()
+	s.TTL = time.Hour
+	s.now = func() time.Time { return now }
+
+	token, expires, err := s.Create(1, "192.0.2.1", "curl/8.0")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(token) < 40 || !expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("Unexpected session: %q %v", token, expires)
+	}
+	if other, _, _ := s.Create(1, "192.0.2.1", "curl/8.0"); other == token {
+		t.Error("Expected a new token for each session")
+	}
+	if id, ok := s.Lookup(token); !ok || id != 1 {
+		t.Errorf("Expected the session of user 1, got %d %v", id, ok)
+	}
+	if _, ok := s.Lookup("unknown"); ok {
+		t.Error("Expected an unknown token to be rejected")
+	}
+
+	s.Delete(token)
+	if _, ok := s.Lookup(token); ok {
+		t.Error("Expected a deleted session to be rejected")
+	}
+
+	token, _, _ = s.Create(2, "192.0.2.1", "curl/8.0")
+	now = now.Add(time.Hour)
+	if _, ok := s.Lookup(token); ok {
+		t.Error("Expected an expired session to be rejected")
+	}
+}
+
+func TestSessionsList(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSessions()
+	s.now = func() time.Time { return now }
+
+	laptop, _, _ := s.Create(1, "192.0.2.1", "Firefox")
+	phone, _, _ := s.Create(1, "198.51.100.2", strings.Repeat("x", 300))
+	s.Create(2, "203.0.113.3", "curl/8.0")
+	now = now.Add(time.Minute)
+	s.Lookup(laptop)
+
+	list := s.List(1, phone)
+	if len(list) != 2 || list[0].ID != 1 || list[0].IP != "192.0.2.1" || list[0].UserAgent != "Firefox" || list[0].Current {
+		t.Fatalf("Unexpected sessions %+v", list)
+	}
+	if !list[0].LastSeenAt.Equal(now) || !list[1].LastSeenAt.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected the last use to be recorded, got %v %v", list[0].LastSeenAt, list[1].LastSeenAt)
+	}
+	if !list[1].Current || len(list[1].UserAgent) != maxUserAgent {
+		t.Errorf("Unexpected current session %+v", list[1])
+	}
+
+	if s.Revoke(2, list[0].ID) {
+		t.Error("Expected other users not to revoke the session")
+	}
+	if !s.Revoke(1, list[0].ID) {
+		t.Error("Expected the session to be revoked")
+	}
+	if _, ok := s.Lookup(laptop); ok {
+		t.Error("Expected a revoked session to be rejected")
+	}
+
+	s.Create(1, "192.0.2.1", "Firefox")
+	if revoked := s.RevokeAll(1); revoked != 2 {
+		t.Errorf("Expected 2 sessions to be revoked, got %d", revoked)
+	}
+	if _, ok := s.Lookup(phone); ok || len(s.List(1, "")) != 0 || len(s.List(2, "")) != 1 {
+		t.Error("Expected only user 1's sessions to be revoked")
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	created := 0
+	h := NewHandlers(func(user User) (http.Handler, error) {
+		if user.ID == 0 {
+			return nil, errors.New("no user")
+		}
+		created++
+		return http.NotFoundHandler(), nil
+	})
+
+	first, err := h.Get(User{ID: 1})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	second, _ := h.Get(User{ID: 1})
+	if created != 1 || first == nil || second == nil {
+		t.Errorf("Expected the handler to be created once, got %d", created)
+	}
+	h.Get(User{ID: 2})
+	if created != 2 {
+		t.Errorf("Expected a separate handler for each user, got %d", created)
+	}
+	if _, err := h.Get(User{}); err == nil {
+		t.Error("Expected the error of the handler function")
+	}
+}
@@ -0,0 +1,305 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"todo-app/models"
+)
+
+// APIKeyPrefix は API キーの先頭に付ける文字列です。ログや設定ファイルで API キーだと分かるようにします
+const APIKeyPrefix = "todo_"
+
+// maxAPIKeyName は API キーの名前の最大の長さ（文字数）です
+const maxAPIKeyName = 100
+
+// lastUsedResolution は API キーを最後に使った日時を記録し直す間隔です。使うたびにファイルへ書き込まないようにします
+const lastUsedResolution = time.Minute
+
+// 使用量を数える期間の書式です。日と月の区切りは UTC です
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+// APIKey はスクリプトなどからブラウザのセッションの代わりに使う API キーです
+// キーそのものは作成したときに一度だけ返し、保存するのは SHA-256 のハッシュだけです
+// Hint: キーを見分けるための先頭の数文字
+// LastUsedAt: 最後に使った日時（1分単位。一度も使っていなければ省略）
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// KeyUsage は API キーのその日とその月（UTC）のリクエスト数と上限（0 なら無制限）です
+type KeyUsage struct {
+	Day             string `json:"day"`
+	DailyRequests   int    `json:"daily_requests"`
+	DailyLimit      int    `json:"daily_limit"`
+	Month           string `json:"month"`
+	MonthlyRequests int    `json:"monthly_requests"`
+	MonthlyLimit    int    `json:"monthly_limit"`
+}
+
+// keyCounter は API キーごとのリクエスト数です。Day / Month が変わると数え直します
+type keyCounter struct {
+	Day     string `json:"day,omitempty"`
+	Daily   int    `json:"daily,omitempty"`
+	Month   string `json:"month,omitempty"`
+	Monthly int    `json:"monthly,omitempty"`
+}
+
+// storedKey はファイルに保存する API キーとそのハッシュ、リクエスト数です
+type storedKey struct {
+	APIKey
+	Hash  string     `json:"hash"`
+	Usage keyCounter `json:"usage"`
+}
+
+// APIKeys はユーザーの API キーを保持し、path が空でなければ JSON ファイルに保存します
+// DailyLimit / MonthlyLimit: キーごとに1日（UTC）と1か月に受け付けるリクエスト数の上限（0 なら無制限）
+type APIKeys struct {
+	DailyLimit   int
+	MonthlyLimit int
+
+	path    string
+	now     func() time.Time
+	mutex   sync.Mutex
+	keys    []storedKey
+	nextID  int
+	savedAt time.Time
+}
+
+// NewAPIKeys は path のファイルから API キーを読み込んで APIKeys を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければ空の APIKeys から始めます
+func NewAPIKeys(path string) (*APIKeys, error) {
+	k := &APIKeys{path: path, now: time.Now, nextID: 1}
+	if path == "" {
+		return k, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		NextID int         `json:"next_id"`
+		Keys   []storedKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	k.keys = file.Keys
+	if file.NextID > k.nextID {
+		k.nextID = file.NextID
+	}
+	return k, nil
+}
+
+// hashToken は API キーやメールアドレスの確認用のトークンのハッシュを返します
+// どちらも推測できない長いランダムな値のため、パスワードと違って繰り返しのない SHA-256 で十分です
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create は userID のユーザーの API キーを作成し、キーの情報とキーそのものを返します
+// キーそのものはここでしか分からないため、呼び出し側で利用者に一度だけ見せます
+func (k *APIKeys) Create(userID int, name string) (APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyName {
+		return APIKey{}, "", fmt.Errorf("%w: name must be 1 to %d characters", models.ErrValidation, maxAPIKeyName)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return APIKey{}, "", err
+	}
+	token := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key := APIKey{
+		ID:        k.nextID,
+		UserID:    userID,
+		Name:      name,
+		Hint:      token[:len(APIKeyPrefix)+4],
+		CreatedAt: k.now().UTC(),
+	}
+	k.keys = append(k.keys, storedKey{APIKey: key, Hash: hashToken(token)})
+	k.nextID++
+	if err := k.save(); err != nil {
+		k.keys = k.keys[:len(k.keys)-1]
+		k.nextID--
+		return APIKey{}, "", err
+	}
+	return key, token, nil
+}
+
+// List は userID のユーザーの API キーを作成した順に返します
+func (k *APIKeys) List(userID int) []APIKey {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	keys := []APIKey{}
+	for _, stored := range k.keys {
+		if stored.UserID == userID {
+			keys = append(keys, stored.APIKey)
+		}
+	}
+	return keys
+}
+
+// Revoke は userID のユーザーの API キー id を取り消します
+// ほかのユーザーのキーは取り消せず、存在しないときと同じく false を返します
+func (k *APIKeys) Revoke(userID, id int) (bool, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for i, stored := range k.keys {
+		if stored.ID == id && stored.UserID == userID {
+			k.keys = append(k.keys[:i:i], k.keys[i+1:]...)
+			return true, k.save()
+		}
+	}
+	return false, nil
+}
+
+// Authenticate は token の API キーを返します。取り消したか存在しないキーなら false を返します
+// 最後に使った日時は1分ごとにだけ記録し直します
+func (k *APIKeys) Authenticate(token string) (APIKey, bool) {
+	if !strings.HasPrefix(token, APIKeyPrefix) {
+		return APIKey{}, false
+	}
+	hash := hashToken(token)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for i := range k.keys {
+		stored := &k.keys[i]
+		if stored.Hash != hash {
+			continue
+		}
+		now := k.now().UTC().Truncate(lastUsedResolution)
+		if stored.LastUsedAt == nil || now.After(*stored.LastUsedAt) {
+			stored.LastUsedAt = &now
+			// 記録できなくても認証には影響しないため、エラーは無視します
+			k.save()
+		}
+		return stored.APIKey, true
+	}
+	return APIKey{}, false
+}
+
+// Use は API キー id のリクエストを1件数えます
+// その日かその月の上限に達していれば数えずに false と、上限が戻る（次の日か次の月の始まりの）までの時間を返します
+// リクエスト数は1分ごとにだけファイルに書き込むため、止まる直前の1分間の分は数え直しになることがあります
+func (k *APIKeys) Use(id int) (retryAfter time.Duration, ok bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	stored := k.find(id)
+	if stored == nil {
+		return 0, true
+	}
+	now := k.now().UTC()
+	usage := stored.current(now)
+	if k.MonthlyLimit > 0 && usage.Monthly >= k.MonthlyLimit {
+		next := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		return next.Sub(now), false
+	}
+	if k.DailyLimit > 0 && usage.Daily >= k.DailyLimit {
+		next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return next.Sub(now), false
+	}
+	usage.Daily++
+	usage.Monthly++
+	stored.Usage = usage
+	if now.Sub(k.savedAt) >= lastUsedResolution {
+		// 記録できなくてもリクエストには影響しないため、エラーは無視します
+		k.save()
+	}
+	return 0, true
+}
+
+// Usage は userID のユーザーの API キー id の使用量を返します
+// ほかのユーザーのキーは返さず、存在しないときと同じく false を返します
+func (k *APIKeys) Usage(userID, id int) (KeyUsage, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	stored := k.find(id)
+	if stored == nil || stored.UserID != userID {
+		return KeyUsage{}, false
+	}
+	now := k.now().UTC()
+	usage := stored.current(now)
+	return KeyUsage{
+		Day:             usage.Day,
+		DailyRequests:   usage.Daily,
+		DailyLimit:      k.DailyLimit,
+		Month:           usage.Month,
+		MonthlyRequests: usage.Monthly,
+		MonthlyLimit:    k.MonthlyLimit,
+	}, true
+}
+
+// find は id の API キーを返します（なければ nil）。ロック中に呼び出します
+func (k *APIKeys) find(id int) *storedKey {
+	for i := range k.keys {
+		if k.keys[i].ID == id {
+			return &k.keys[i]
+		}
+	}
+	return nil
+}
+
+// current は now の日と月のリクエスト数を返します。前の日や月の数は捨てます
+func (s *storedKey) current(now time.Time) keyCounter {
+	usage := s.Usage
+	if day := now.Format(dayLayout); usage.Day != day {
+		usage.Day, usage.Daily = day, 0
+	}
+	if month := now.Format(monthLayout); usage.Month != month {
+		usage.Month, usage.Monthly = month, 0
+	}
+	return usage
+}
+
+// save は API キーを一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+func (k *APIKeys) save() error {
+	if k.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"next_id": k.nextID,
+		"keys":    k.keys,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0755); err != nil {
+		return err
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		return err
+	}
+	k.savedAt = k.now().UTC()
+	return nil
+}
@@ -0,0 +1,137 @@
+package accounts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestAPIKeys(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 30, 0, time.UTC)
+	k, _ := NewAPIKeys("")
+	k.now = func() time.Time { return now }
+
+	key, token, err := k.Create(1, " cron ")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if key.ID != 1 || key.UserID != 1 || key.Name != "cron" || !strings.HasPrefix(token, APIKeyPrefix) || !strings.HasPrefix(token, key.Hint) {
+		t.Errorf("Unexpected key: %+v %q", key, token)
+	}
+	if _, _, err := k.Create(1, " "); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected a validation error for an empty name, got %v", err)
+	}
+	other, _, _ := k.Create(2, "backup")
+
+	got, ok := k.Authenticate(token)
+	if !ok || got.ID != key.ID {
+		t.Fatalf("Expected the token to authenticate, got %+v %v", got, ok)
+	}
+	if got.LastUsedAt == nil || !got.LastUsedAt.Equal(now.Truncate(time.Minute)) {
+		t.Errorf("Expected the last use to be recorded, got %v", got.LastUsedAt)
+	}
+	for _, invalid := range []string{"", "todo_", token + "x", strings.TrimPrefix(token, APIKeyPrefix)} {
+		if _, ok := k.Authenticate(invalid); ok {
+			t.Errorf("Expected %q not to authenticate", invalid)
+		}
+	}
+
+	if keys := k.List(1); len(keys) != 1 || keys[0].ID != key.ID {
+		t.Errorf("Expected only the user's own keys, got %+v", keys)
+	}
+	if revoked, _ := k.Revoke(1, other.ID); revoked {
+		t.Error("Expected a user not to revoke another user's key")
+	}
+	if revoked, err := k.Revoke(1, key.ID); !revoked || err != nil {
+		t.Errorf("Expected the key to be revoked, got %v %v", revoked, err)
+	}
+	if _, ok := k.Authenticate(token); ok {
+		t.Error("Expected a revoked key not to authenticate")
+	}
+}
+
+func TestAPIKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	k, err := NewAPIKeys(path)
+	if err != nil {
+		t.Fatalf("NewAPIKeys failed: %v", err)
+	}
+	first, token, _ := k.Create(1, "cron")
+	k.Revoke(1, first.ID)
+	_, token, _ = k.Create(1, "cron")
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), token) {
+		t.Error("Expected the key not to be saved in plain text")
+	}
+
+	reloaded, err := NewAPIKeys(path)
+	if err != nil {
+		t.Fatalf("NewAPIKeys failed: %v", err)
+	}
+	if key, ok := reloaded.Authenticate(token); !ok || key.ID != 2 {
+		t.Errorf("Expected the key to survive a reload, got %+v %v", key, ok)
+	}
+	// 取り消したキーの ID は再利用しません
+	if key, _, _ := reloaded.Create(1, "another"); key.ID != 3 {
+		t.Errorf("Expected IDs not to be reused, got %d", key.ID)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0600)
+	if _, err := NewAPIKeys(path); err == nil {
+		t.Error("Expected an error for a corrupt file")
+	}
+}
+
+func TestAPIKeysUse(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	k, _ := NewAPIKeys(path)
+	k.now = func() time.Time { return now }
+	k.DailyLimit = 2
+	k.MonthlyLimit = 3
+	key, _, _ := k.Create(1, "cron")
+
+	for i := 0; i < 2; i++ {
+		if _, ok := k.Use(key.ID); !ok {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	if retryAfter, ok := k.Use(key.ID); ok || retryAfter != time.Hour {
+		t.Errorf("Expected the daily limit until midnight, got %s %v", retryAfter, ok)
+	}
+	usage, ok := k.Usage(1, key.ID)
+	if !ok || usage != (KeyUsage{Day: "2025-01-31", DailyRequests: 2, DailyLimit: 2, Month: "2025-01", MonthlyRequests: 2, MonthlyLimit: 3}) {
+		t.Errorf("Unexpected usage %+v %v", usage, ok)
+	}
+	if _, ok := k.Usage(2, key.ID); ok {
+		t.Error("Expected other users not to see the usage")
+	}
+
+	// 日が変わると数え直し、月の上限は月が変わるまで続きます
+	now = now.Add(30 * time.Minute)
+	k.Use(key.ID)
+	now = time.Date(2025, 2, 1, 0, 30, 0, 0, time.UTC)
+	if _, ok := k.Use(key.ID); !ok {
+		t.Error("Expected the counters to reset in a new month")
+	}
+	now = time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC)
+	k.keys[0].Usage = keyCounter{Day: "2025-01-30", Daily: 2, Month: "2025-01", Monthly: 3}
+	if retryAfter, ok := k.Use(key.ID); ok || retryAfter != time.Minute {
+		t.Errorf("Expected the monthly limit until the next month, got %s %v", retryAfter, ok)
+	}
+
+	// 使用量はファイルにも残ります
+	now = time.Date(2025, 2, 1, 1, 0, 0, 0, time.UTC)
+	k.Use(key.ID)
+	reloaded, _ := NewAPIKeys(path)
+	reloaded.now = func() time.Time { return now }
+	if usage, _ := reloaded.Usage(1, key.ID); usage.DailyRequests != 1 || usage.MonthlyRequests != 1 {
+		t.Errorf("Expected the usage to survive a reload, got %+v", usage)
+	}
+}
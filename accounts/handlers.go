@@ -0,0 +1,39 @@
+package accounts
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// HandlerFunc はユーザーのリクエストを処理する http.Handler（そのユーザーのタスクだけを扱うサーバ）を作る関数です
+type HandlerFunc func(User) (http.Handler, error)
+
+// Handlers はユーザーごとのハンドラを初めて使うときに作り、以降は同じハンドラを返します
+type Handlers struct {
+	newHandler HandlerFunc
+
+	mutex    sync.Mutex
+	handlers map[int]http.Handler
+}
+
+// NewHandlers はユーザーごとのハンドラを newHandler で作る Handlers を作成します
+func NewHandlers(newHandler HandlerFunc) *Handlers {
+	return &Handlers{newHandler: newHandler, handlers: make(map[int]http.Handler)}
+}
+
+// Get は user のハンドラを返します。まだなければ作成します
+// 作成は同じユーザーについて一度だけ行うため、ロックを持ったまま作成します
+func (h *Handlers) Get(user User) (http.Handler, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if handler, ok := h.handlers[user.ID]; ok {
+		return handler, nil
+	}
+	handler, err := h.newHandler(user)
+	if err != nil {
+		return nil, fmt.Errorf("user %d: %w", user.ID, err)
+	}
+	h.handlers[user.ID] = handler
+	return handler, nil
+}
@@ -0,0 +1,201 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// InviteCodePrefix は招待コードの先頭に付ける文字列です
+const InviteCodePrefix = "inv_"
+
+// ErrInvalidInvite は招待コードが誤っているか、期限切れか、使える回数を使い切ったことを表します（どれかは知らせません）
+var ErrInvalidInvite = errors.New("invalid or expired invite code")
+
+// Invite は管理者が発行するユーザー登録の招待コードです
+// コードそのものは発行したときに一度だけ返し、保存するのは SHA-256 のハッシュだけです
+// Hint: コードを見分けるための先頭の数文字 / MaxUses: 登録に使える回数 / Uses: 登録に使った回数
+// ExpiresAt: 有効期限（なければ期限なし）
+type Invite struct {
+	ID        int        `json:"id"`
+	Hint      string     `json:"hint"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// storedInvite はファイルに保存する招待コードとそのハッシュです
+type storedInvite struct {
+	Invite
+	Hash string `json:"hash"`
+}
+
+// Invites は招待コードを保持し、path が空でなければ JSON ファイルに保存します
+type Invites struct {
+	path    string
+	now     func() time.Time
+	mutex   sync.Mutex
+	invites []storedInvite
+	nextID  int
+}
+
+// NewInvites は path のファイルから招待コードを読み込んで Invites を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければ空の Invites から始めます
+func NewInvites(path string) (*Invites, error) {
+	v := &Invites{path: path, now: time.Now, nextID: 1}
+	if path == "" {
+		return v, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		NextID  int            `json:"next_id"`
+		Invites []storedInvite `json:"invites"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	v.invites = file.Invites
+	if file.NextID > v.nextID {
+		v.nextID = file.NextID
+	}
+	return v, nil
+}
+
+// Create は maxUses 回まで使える招待コードを発行し、コードの情報とコードそのものを返します
+// expiresAt が nil なら期限はありません。過ぎた日時は ErrValidation です
+func (v *Invites) Create(maxUses int, expiresAt *time.Time) (Invite, string, error) {
+	if maxUses < 1 {
+		return Invite{}, "", fmt.Errorf("%w: max_uses must be at least 1", models.ErrValidation)
+	}
+	now := v.now().UTC()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return Invite{}, "", fmt.Errorf("%w: expires_at must be in the future", models.ErrValidation)
+		}
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return Invite{}, "", err
+	}
+	code := InviteCodePrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	invite := Invite{
+		ID:        v.nextID,
+		Hint:      code[:len(InviteCodePrefix)+4],
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	v.invites = append(v.invites, storedInvite{Invite: invite, Hash: hashToken(code)})
+	v.nextID++
+	if err := v.save(); err != nil {
+		v.invites = v.invites[:len(v.invites)-1]
+		v.nextID--
+		return Invite{}, "", err
+	}
+	return invite, code, nil
+}
+
+// List は招待コードを発行した順に返します（期限切れや使い切ったものも含みます）
+func (v *Invites) List() []Invite {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	invites := make([]Invite, 0, len(v.invites))
+	for _, stored := range v.invites {
+		invites = append(invites, stored.Invite)
+	}
+	return invites
+}
+
+// Revoke は招待コード id を取り消します。存在しなければ false を返します
+func (v *Invites) Revoke(id int) (bool, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for i, stored := range v.invites {
+		if stored.ID == id {
+			v.invites = append(v.invites[:i:i], v.invites[i+1:]...)
+			return true, v.save()
+		}
+	}
+	return false, nil
+}
+
+// Redeem は code の招待コードを1回使い、その招待コードを返します
+// 誤っているか、期限切れか、使い切ったコードなら ErrInvalidInvite を返します
+// 使った後に登録できなかったときは Refund で回数を戻します
+func (v *Invites) Redeem(code string) (Invite, error) {
+	hash := hashToken(code)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for i := range v.invites {
+		stored := &v.invites[i]
+		if stored.Hash != hash {
+			continue
+		}
+		if stored.Uses >= stored.MaxUses || stored.ExpiresAt != nil && !v.now().Before(*stored.ExpiresAt) {
+			return Invite{}, ErrInvalidInvite
+		}
+		stored.Uses++
+		if err := v.save(); err != nil {
+			stored.Uses--
+			return Invite{}, err
+		}
+		return stored.Invite, nil
+	}
+	return Invite{}, ErrInvalidInvite
+}
+
+// Refund は Redeem で使った招待コード id の回数を1回戻します（取り消したコードなら何もしません）
+func (v *Invites) Refund(id int) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for i := range v.invites {
+		if stored := &v.invites[i]; stored.ID == id && stored.Uses > 0 {
+			stored.Uses--
+			return v.save()
+		}
+	}
+	return nil
+}
+
+// save は招待コードを一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+func (v *Invites) save() error {
+	if v.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"next_id": v.nextID,
+		"invites": v.invites,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0755); err != nil {
+		return err
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
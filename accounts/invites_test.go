@@ -0,0 +1,78 @@
+package accounts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestInvites(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "invites.json")
+	v, _ := NewInvites(path)
+	v.now = func() time.Time { return now }
+
+	expires := now.Add(time.Hour)
+	invite, code, err := v.Create(2, &expires)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if invite.ID != 1 || invite.MaxUses != 2 || !strings.HasPrefix(code, InviteCodePrefix) || !strings.HasPrefix(code, invite.Hint) {
+		t.Errorf("Unexpected invite %+v %q", invite, code)
+	}
+	if _, _, err := v.Create(0, nil); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected max_uses 0 to be rejected, got %v", err)
+	}
+	past := now.Add(-time.Minute)
+	if _, _, err := v.Create(1, &past); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected a past expiry to be rejected, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), code) {
+		t.Error("Expected the code not to be saved in plain text")
+	}
+
+	if _, err := v.Redeem(code); err != nil {
+		t.Fatalf("Redeem failed: %v", err)
+	}
+	used, _ := v.Redeem(code)
+	if used.Uses != 2 {
+		t.Errorf("Expected 2 uses, got %+v", used)
+	}
+	if _, err := v.Redeem(code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Expected a used up code to be rejected, got %v", err)
+	}
+	// 登録できなかった分は戻せます
+	v.Refund(used.ID)
+	if _, err := v.Redeem(code); err != nil {
+		t.Errorf("Expected a refunded use to be available, got %v", err)
+	}
+	if _, err := v.Redeem("inv_wrong"); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Expected a wrong code to be rejected, got %v", err)
+	}
+
+	other, otherCode, _ := v.Create(5, &expires)
+	now = expires
+	if _, err := v.Redeem(otherCode); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Expected an expired code to be rejected, got %v", err)
+	}
+
+	reloaded, err := NewInvites(path)
+	if err != nil {
+		t.Fatalf("NewInvites failed: %v", err)
+	}
+	if list := reloaded.List(); len(list) != 2 || list[0].Uses != 2 || list[1].ID != other.ID {
+		t.Errorf("Expected the invites to survive a reload, got %+v", list)
+	}
+	if revoked, err := reloaded.Revoke(other.ID); !revoked || err != nil {
+		t.Errorf("Expected the invite to be revoked, got %v %v", revoked, err)
+	}
+	if invite, _, _ := reloaded.Create(1, nil); invite.ID != 3 {
+		t.Errorf("Expected IDs not to be reused, got %d", invite.ID)
+	}
+}
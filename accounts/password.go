@@ -0,0 +1,116 @@
+package accounts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultIterations は PBKDF2-HMAC-SHA256 の繰り返しの回数の既定値です（OWASP の推奨値）
+// bcrypt は標準ライブラリにないため、標準ライブラリの HMAC と SHA-256 で PBKDF2 を計算します
+const DefaultIterations = 600000
+
+// パスワードのハッシュの形式は "pbkdf2-sha256${繰り返しの回数}${salt}${hash}"（salt と hash は base64）です
+const (
+	hashScheme = "pbkdf2-sha256"
+	saltLength = 16
+	keyLength  = 32
+)
+
+// HashPassword はランダムな salt を付けて password のハッシュを計算し、確かめるのに必要な値とまとめた文字列を返します
+func HashPassword(password string, iterations int) (string, error) {
+	if iterations < 1 {
+		return "", fmt.Errorf("iterations must be positive, got %d", iterations)
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2([]byte(password), salt, iterations, keyLength)
+	return strings.Join([]string{
+		hashScheme,
+		strconv.Itoa(iterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// CheckPassword は password が HashPassword で計算した encoded と一致するかを返します
+// 形式が正しくないハッシュとは一致しません
+func CheckPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got := pbkdf2([]byte(password), salt, iterations, len(want))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+var (
+	dummyMutex  sync.Mutex
+	dummyHashes = map[int]string{}
+)
+
+// dummyHash は存在しないユーザーのパスワードを確かめるときに使う、iterations 回のハッシュを返します
+func dummyHash(iterations int) string {
+	dummyMutex.Lock()
+	defer dummyMutex.Unlock()
+	if hash, ok := dummyHashes[iterations]; ok {
+		return hash
+	}
+	hash, err := HashPassword("dummy password", iterations)
+	if err != nil {
+		return ""
+	}
+	dummyHashes[iterations] = hash
+	return hash
+}
+
+// pbkdf2 は RFC 8018 の PBKDF2 で、HMAC-SHA256 を使って password と salt から keyLen バイトの鍵を導出します
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+
+	key := make([]byte, 0, blocks*size)
+	u := make([]byte, size)
+	t := make([]byte, size)
+	var counter [4]byte
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
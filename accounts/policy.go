@@ -0,0 +1,134 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo-app/models"
+)
+
+// パスワードのポリシーに合わないことを表すエラーです
+// ErrPasswordBreached: 漏洩したパスワードの一覧に含まれていること
+// ErrPasswordReused: 今のパスワードか、最近使ったパスワードと同じであること
+var (
+	ErrPasswordBreached = errors.New("password has appeared in a data breach")
+	ErrPasswordReused   = errors.New("password was used recently")
+)
+
+// DefaultPasswordHistory は使い回しを禁止する、直前までのパスワードの数の既定値です（今のパスワードを含みます）
+const DefaultPasswordHistory = 5
+
+// breachTimeout は漏洩したパスワードの確認を待つ時間です
+const breachTimeout = 5 * time.Second
+
+// BreachChecker はパスワードが漏洩したパスワードの一覧に含まれているかを確かめます（pwned.Checker が満たします）
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// PasswordPolicy は登録とパスワードの変更で求めるパスワードの条件です
+// MinLength: 最小のバイト数（1 未満なら MinPasswordLength。最大は常に MaxPasswordLength です）
+// History: 変更で使えない、直前までのパスワードの数（今のパスワードを含みます。0 なら確かめません）
+// Breached: 設定すると、漏洩したパスワードの一覧に含まれるパスワードを断ります
+// 問い合わせに失敗したときは、登録や変更ができなくならないよう確かめずに受け付けます
+type PasswordPolicy struct {
+	MinLength int
+	History   int
+	Breached  BreachChecker
+}
+
+// DefaultPasswordPolicy は NewStore が使う既定のポリシーです（漏洩したパスワードは確かめません）
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: MinPasswordLength, History: DefaultPasswordHistory}
+}
+
+// minLength は最小のバイト数を返します
+func (p PasswordPolicy) minLength() int {
+	if p.MinLength < 1 {
+		return MinPasswordLength
+	}
+	return p.MinLength
+}
+
+// check は password が長さの範囲に入っていて、ユーザー名と同じではなく、漏洩していないことを確かめます
+func (p PasswordPolicy) check(name, password string) error {
+	if len(password) < p.minLength() || len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: password must be %d to %d bytes", models.ErrValidation, p.minLength(), MaxPasswordLength)
+	}
+	if password == name {
+		return fmt.Errorf("%w: password must not be the same as the name", models.ErrValidation)
+	}
+	if p.Breached == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), breachTimeout)
+	defer cancel()
+	if breached, err := p.Breached.Breached(ctx, password); err == nil && breached {
+		return ErrPasswordBreached
+	}
+	return nil
+}
+
+// ChangePassword は id のユーザーのパスワードを current から next に変更します
+// current が誤っていれば ErrInvalidCredentials を、next がポリシーに合わなければ ErrValidation・ErrPasswordBreached を、
+// 今のパスワードか直前の Policy.History 個までのパスワードと同じなら ErrPasswordReused を返します
+func (s *Store) ChangePassword(id int, current, next string) error {
+	s.mutex.Lock()
+	a, ok := s.lookup(id)
+	s.mutex.Unlock()
+	if !ok {
+		return ErrInvalidCredentials
+	}
+	if !CheckPassword(a.PasswordHash, current) {
+		return ErrInvalidCredentials
+	}
+	if err := s.Policy.check(a.Name, next); err != nil {
+		return err
+	}
+	if s.Policy.History > 0 {
+		recent := append([]string{a.PasswordHash}, a.PasswordHistory...)
+		if len(recent) > s.Policy.History {
+			recent = recent[:s.Policy.History]
+		}
+		for _, hash := range recent {
+			if CheckPassword(hash, next) {
+				return ErrPasswordReused
+			}
+		}
+	}
+	// ハッシュの計算は時間がかかるため、ロックの外で行います
+	hash, err := HashPassword(next, s.Iterations)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.accounts {
+		stored := &s.accounts[i]
+		if stored.ID != id {
+			continue
+		}
+		// 確かめている間にほかのリクエストが変更していたら、今のパスワードをもう一度確かめてもらいます
+		if stored.PasswordHash != a.PasswordHash {
+			return ErrInvalidCredentials
+		}
+		previous := *stored
+		stored.PasswordHistory = nil
+		if s.Policy.History > 1 {
+			stored.PasswordHistory = append([]string{stored.PasswordHash}, previous.PasswordHistory...)
+			if len(stored.PasswordHistory) > s.Policy.History-1 {
+				stored.PasswordHistory = stored.PasswordHistory[:s.Policy.History-1]
+			}
+		}
+		stored.PasswordHash = hash
+		if err := s.save(); err != nil {
+			*stored = previous
+			return err
+		}
+		return nil
+	}
+	return ErrInvalidCredentials
+}
@@ -0,0 +1,83 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"todo-app/models"
+)
+
+// fakeBreaches は breached のパスワードだけを漏洩したものとして扱います
+type fakeBreaches struct {
+	breached string
+	err      error
+}
+
+func (f fakeBreaches) Breached(ctx context.Context, password string) (bool, error) {
+	return password == f.breached, f.err
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	s := newTestStore(t, "")
+	s.Policy = PasswordPolicy{MinLength: 12, Breached: fakeBreaches{breached: "password1234"}}
+
+	if _, err := s.Register("alice", "short pass"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected a password shorter than MinLength to be rejected, got %v", err)
+	}
+	if _, err := s.Register("alice.smith1", "alice.smith1"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected the name as the password to be rejected, got %v", err)
+	}
+	if _, err := s.Register("alice", "password1234"); !errors.Is(err, ErrPasswordBreached) {
+		t.Errorf("Expected a breached password to be rejected, got %v", err)
+	}
+	if _, err := s.Register("alice", "correct horse battery"); err != nil {
+		t.Errorf("Register failed: %v", err)
+	}
+
+	// 確かめられないときは受け付けます
+	s.Policy.Breached = fakeBreaches{breached: "password1234", err: errors.New("unavailable")}
+	if _, err := s.Register("bob", "password1234"); err != nil {
+		t.Errorf("Expected the password to be accepted when the check fails, got %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s := newTestStore(t, path)
+	s.Policy.History = 3
+	user, _ := s.Register("alice", "password one")
+
+	if err := s.ChangePassword(user.ID, "wrong password", "password two"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a wrong current password to be rejected, got %v", err)
+	}
+	if err := s.ChangePassword(user.ID, "password one", "short"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected a short password to be rejected, got %v", err)
+	}
+	if err := s.ChangePassword(user.ID, "password one", "password one"); !errors.Is(err, ErrPasswordReused) {
+		t.Errorf("Expected the current password to be rejected, got %v", err)
+	}
+	for _, change := range [][2]string{{"password one", "password two"}, {"password two", "password three"}} {
+		if err := s.ChangePassword(user.ID, change[0], change[1]); err != nil {
+			t.Fatalf("ChangePassword failed: %v", err)
+		}
+	}
+	if _, err := s.Authenticate("alice", "password three"); err != nil {
+		t.Errorf("Expected the new password to work, got %v", err)
+	}
+	if _, err := s.Authenticate("alice", "password two"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected the old password not to work, got %v", err)
+	}
+
+	// 直前の 3 個（今のパスワードを含みます）は使えず、それより前のパスワードは使えます
+	reloaded := newTestStore(t, path)
+	reloaded.Policy.History = 3
+	if err := reloaded.ChangePassword(user.ID, "password three", "password one"); !errors.Is(err, ErrPasswordReused) {
+		t.Errorf("Expected a recent password to be rejected after a reload, got %v", err)
+	}
+	reloaded.ChangePassword(user.ID, "password three", "password four")
+	if err := reloaded.ChangePassword(user.ID, "password four", "password one"); err != nil {
+		t.Errorf("Expected an older password to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ID プロバイダからのユーザーの管理（SCIM）のエラーです
+// ErrUserNotFound: ユーザーが見つからないこと
+// ErrUserDisabled: ユーザーが無効にされていること
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+// Provision は ID プロバイダから作成するユーザーを登録します。email は ID プロバイダが確かめたものとして確認済みにします
+// password が空ならランダムなパスワードにします（ユーザーはパスワードでログインできません）
+// 名前・パスワード・メールアドレスの誤りと名前の重複は RegisterWithEmail と同じエラーを返します
+func (s *Store) Provision(name, password, email string) (User, error) {
+	if password == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return User{}, err
+		}
+		password = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return s.register(name, password, email, true)
+}
+
+// SetDisabled は id のユーザーを無効にするか（disabled）、有効に戻します。見つからなければ ErrUserNotFound を返します
+// セッションと API キーは呼び出し側で取り消します（無効なユーザーのものは使えませんが、有効に戻すとまた使えるため）
+func (s *Store) SetDisabled(id int, disabled bool) (User, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.accounts {
+		a := &s.accounts[i]
+		if a.ID != id {
+			continue
+		}
+		if a.Disabled != disabled {
+			a.Disabled = disabled
+			if err := s.save(); err != nil {
+				a.Disabled = !disabled
+				return User{}, err
+			}
+		}
+		return a.User, nil
+	}
+	return User{}, ErrUserNotFound
+}
+
+// List は登録したユーザーを ID の順に返します
+func (s *Store) List() []User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	users := make([]User, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		users = append(users, a.User)
+	}
+	return users
+}
+
+// LookupName は name（大文字と小文字を区別しません）のユーザーを返します。なければ false を返します
+func (s *Store) LookupName(name string) (User, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a, ok := s.find(strings.ToLower(strings.TrimSpace(name)))
+	return a.User, ok
+}
@@ -0,0 +1,55 @@
+package accounts
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestProvision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s := newTestStore(t, path)
+
+	alice, err := s.Provision("alice", "", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	if !alice.EmailVerified || alice.Disabled {
+		t.Errorf("Expected a verified, enabled user, got %+v", alice)
+	}
+	bob, _ := s.Provision("bob", "correct horse", "")
+	if _, err := s.Authenticate("bob", "correct horse"); err != nil {
+		t.Errorf("Expected bob to log in with the given password, got %v", err)
+	}
+
+	if user, ok := s.LookupName(" ALICE "); !ok || user.ID != alice.ID {
+		t.Errorf("Expected to look up alice by name, got %+v %v", user, ok)
+	}
+	if users := s.List(); len(users) != 2 || users[0].ID != alice.ID || users[1].ID != bob.ID {
+		t.Errorf("Expected alice and bob in ID order, got %+v", users)
+	}
+
+	if user, err := s.SetDisabled(bob.ID, true); err != nil || !user.Disabled {
+		t.Fatalf("Expected bob to be disabled, got %+v %v", user, err)
+	}
+	if _, err := s.Authenticate("bob", "correct horse"); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("Expected a disabled user not to log in, got %v", err)
+	}
+	if _, err := s.Authenticate("bob", "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a wrong password to be rejected before the disabled check, got %v", err)
+	}
+	if _, err := s.SetDisabled(99, true); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	reopened := newTestStore(t, path)
+	if user, ok := reopened.Lookup(bob.ID); !ok || !user.Disabled {
+		t.Errorf("Expected bob to stay disabled after reopening, got %+v %v", user, ok)
+	}
+	if _, err := reopened.SetDisabled(bob.ID, false); err != nil {
+		t.Fatalf("SetDisabled failed: %v", err)
+	}
+	if _, err := reopened.Authenticate("bob", "correct horse"); err != nil {
+		t.Errorf("Expected bob to log in after being enabled, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshTTL は「ログインしたままにする」の refresh token の有効期間の既定値です（最後に使った時点から数えます）
+const DefaultRefreshTTL = 90 * 24 * time.Hour
+
+// rotationGrace は入れ替えた直後の前のトークンを、盗まれたものとみなさない時間です
+// 同じブラウザの複数のタブが同時に入れ替えたとき、後から届いたタブのためにその端末を取り消さないようにします
+const rotationGrace = 30 * time.Second
+
+// refresh token のエラーです
+// ErrInvalidRefresh: トークンが誤っているか、期限切れか、取り消されたか、ほかの端末（User-Agent）から送られたこと
+// ErrRefreshReused: 入れ替えて使えなくなったトークンがもう一度使われたこと（盗まれたとみなし、その端末を取り消します）
+var (
+	ErrInvalidRefresh = errors.New("invalid or expired refresh token")
+	ErrRefreshReused  = errors.New("refresh token was reused")
+)
+
+// device は「ログインしたままにする」でログインした1つの端末と、その refresh token のハッシュです
+// トークンは使うたびに入れ替え、Hash に今のトークンを、PreviousHash に1つ前のトークンを保存します
+type device struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+	RotatedAt    time.Time `json:"rotated_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Hash         string    `json:"hash"`
+	PreviousHash string    `json:"previous_hash,omitempty"`
+}
+
+// RefreshTokens は端末ごとの refresh token を保持し、path が空でなければ JSON ファイルに保存します
+// セッションはメモリ上にだけあるため、サーバを再起動しても、セッションが期限切れになっても、refresh token で新しいセッションを始められます
+// TTL: refresh token の有効期間（入れ替えるたびに延長します）
+type RefreshTokens struct {
+	TTL time.Duration
+
+	path    string
+	now     func() time.Time
+	mutex   sync.Mutex
+	devices []device
+	nextID  int
+}
+
+// NewRefreshTokens は path のファイルから端末を読み込んで RefreshTokens を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければ端末のない状態から始めます
+func NewRefreshTokens(path string) (*RefreshTokens, error) {
+	t := &RefreshTokens{TTL: DefaultRefreshTTL, path: path, now: time.Now, nextID: 1}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		NextID  int      `json:"next_id"`
+		Devices []device `json:"devices"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	t.devices = file.Devices
+	if file.NextID > t.nextID {
+		t.nextID = file.NextID
+	}
+	return t, nil
+}
+
+// Issue は userID のユーザーの新しい端末を登録し、端末の ID と refresh token とその有効期限を返します
+// 端末は userAgent に結び付け、ほかの User-Agent から送られたトークンは使えません
+func (t *RefreshTokens) Issue(userID int, ip, userAgent string) (int, string, time.Time, error) {
+	token, err := newRefreshToken()
+	if err != nil {
+		return 0, "", time.Time{}, err
+	}
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	t.expire(now)
+	d := device{
+		ID:        t.nextID,
+		UserID:    userID,
+		IP:        ip,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		RotatedAt: now,
+		ExpiresAt: now.Add(t.TTL),
+		Hash:      hashToken(token),
+	}
+	t.devices = append(t.devices, d)
+	t.nextID++
+	if err := t.save(); err != nil {
+		t.devices = t.devices[:len(t.devices)-1]
+		t.nextID--
+		return 0, "", time.Time{}, err
+	}
+	return d.ID, token, d.ExpiresAt, nil
+}
+
+// Rotate は token を使えなくして同じ端末の新しいトークンを発行し、ユーザーと端末の ID、新しいトークンとその有効期限を返します
+// 入れ替えて使えなくなったトークンが送られたら、盗まれたとみなして端末を取り消し、ErrRefreshReused を返します
+func (t *RefreshTokens) Rotate(token, userAgent string) (int, int, string, time.Time, error) {
+	next, err := newRefreshToken()
+	if err != nil {
+		return 0, 0, "", time.Time{}, err
+	}
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+	hash := hashToken(token)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	t.expire(now)
+	for i := range t.devices {
+		d := &t.devices[i]
+		switch hash {
+		case d.Hash:
+		case d.PreviousHash:
+			if now.Sub(d.RotatedAt) < rotationGrace {
+				return 0, 0, "", time.Time{}, ErrInvalidRefresh
+			}
+			t.devices = append(t.devices[:i:i], t.devices[i+1:]...)
+			t.save()
+			return 0, 0, "", time.Time{}, ErrRefreshReused
+		default:
+			continue
+		}
+		if d.UserAgent != userAgent {
+			return 0, 0, "", time.Time{}, ErrInvalidRefresh
+		}
+		previous := *d
+		d.PreviousHash = d.Hash
+		d.Hash = hashToken(next)
+		d.RotatedAt = now
+		d.ExpiresAt = now.Add(t.TTL)
+		if err := t.save(); err != nil {
+			*d = previous
+			return 0, 0, "", time.Time{}, err
+		}
+		return d.UserID, d.ID, next, d.ExpiresAt, nil
+	}
+	return 0, 0, "", time.Time{}, ErrInvalidRefresh
+}
+
+// Revoke は userID のユーザーの端末 id を取り消します。ほかのユーザーの端末や存在しない端末なら false を返します
+func (t *RefreshTokens) Revoke(userID, id int) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, d := range t.devices {
+		if d.ID == id && d.UserID == userID {
+			t.devices = append(t.devices[:i:i], t.devices[i+1:]...)
+			t.save()
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeToken は token（今のトークン）の端末を取り消します（ログアウト）
+func (t *RefreshTokens) RevokeToken(token string) {
+	hash := hashToken(token)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, d := range t.devices {
+		if d.Hash == hash {
+			t.devices = append(t.devices[:i:i], t.devices[i+1:]...)
+			t.save()
+			return
+		}
+	}
+}
+
+// RevokeAll は userID のユーザーのすべての端末を取り消し、その数を返します
+func (t *RefreshTokens) RevokeAll(userID int) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	kept := t.devices[:0]
+	for _, d := range t.devices {
+		if d.UserID != userID {
+			kept = append(kept, d)
+		}
+	}
+	revoked := len(t.devices) - len(kept)
+	t.devices = kept
+	if revoked > 0 {
+		t.save()
+	}
+	return revoked
+}
+
+// expire は期限の切れた端末を取り除きます（ファイルには次に保存するときに反映します）。ロック中に呼び出します
+func (t *RefreshTokens) expire(now time.Time) {
+	kept := t.devices[:0]
+	for _, d := range t.devices {
+		if now.Before(d.ExpiresAt) {
+			kept = append(kept, d)
+		}
+	}
+	t.devices = kept
+}
+
+// save は端末を一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+func (t *RefreshTokens) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"next_id": t.nextID,
+		"devices": t.devices,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// newRefreshToken は推測できない refresh token を作成します
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
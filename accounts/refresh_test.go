@@ -0,0 +1,118 @@
+package accounts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokensRotate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "refresh_tokens.json")
+	tokens, _ := NewRefreshTokens(path)
+	tokens.now = func() time.Time { return now }
+
+	device, first, expires, err := tokens.Issue(1, "192.0.2.1", "Firefox")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if device != 1 || !expires.Equal(now.Add(DefaultRefreshTTL)) {
+		t.Errorf("Unexpected device %d expiring at %s", device, expires)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), first) {
+		t.Error("Expected the token not to be saved in plain text")
+	}
+
+	// ほかの User-Agent からは使えません
+	if _, _, _, _, err := tokens.Rotate(first, "Chrome"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected another user agent to be rejected, got %v", err)
+	}
+	now = now.Add(time.Hour)
+	userID, rotatedDevice, second, expires, err := tokens.Rotate(first, "Firefox")
+	if err != nil || userID != 1 || rotatedDevice != device || second == first {
+		t.Fatalf("Unexpected rotation %d %d %v", userID, rotatedDevice, err)
+	}
+	if !expires.Equal(now.Add(DefaultRefreshTTL)) {
+		t.Errorf("Expected the expiry to be extended, got %s", expires)
+	}
+
+	// 入れ替えた直後なら、ほかのタブからの古いトークンは断るだけです
+	if _, _, _, _, err := tokens.Rotate(first, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected the old token to be rejected within the grace period, got %v", err)
+	}
+	reloaded, _ := NewRefreshTokens(path)
+	reloaded.now = func() time.Time { return now.Add(time.Minute) }
+	if _, _, _, _, err := reloaded.Rotate(first, "Firefox"); !errors.Is(err, ErrRefreshReused) {
+		t.Errorf("Expected the old token to be detected as reused, got %v", err)
+	}
+	// 使い回されたら端末ごと取り消すため、新しいトークンも使えません
+	if _, _, _, _, err := reloaded.Rotate(second, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected the device to be revoked, got %v", err)
+	}
+}
+
+func TestRefreshTokensExpireAndRevoke(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokens, _ := NewRefreshTokens("")
+	tokens.TTL = time.Hour
+	tokens.now = func() time.Time { return now }
+
+	_, expiring, _, _ := tokens.Issue(1, "", "Firefox")
+	now = now.Add(time.Hour)
+	if _, _, _, _, err := tokens.Rotate(expiring, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+
+	device, token, _, _ := tokens.Issue(1, "", "Firefox")
+	if tokens.Revoke(2, device) {
+		t.Error("Expected another user not to revoke the device")
+	}
+	if !tokens.Revoke(1, device) {
+		t.Error("Expected the device to be revoked")
+	}
+	if _, _, _, _, err := tokens.Rotate(token, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected a revoked token to be rejected, got %v", err)
+	}
+
+	_, token, _, _ = tokens.Issue(1, "", "Firefox")
+	tokens.RevokeToken(token)
+	if _, _, _, _, err := tokens.Rotate(token, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected a logged out token to be rejected, got %v", err)
+	}
+
+	tokens.Issue(1, "", "Firefox")
+	tokens.Issue(1, "", "Safari")
+	_, other, _, _ := tokens.Issue(2, "", "Firefox")
+	if revoked := tokens.RevokeAll(1); revoked != 2 {
+		t.Errorf("Expected 2 devices to be revoked, got %d", revoked)
+	}
+	if _, _, _, _, err := tokens.Rotate(other, "Firefox"); err != nil {
+		t.Errorf("Expected another user's device to remain, got %v", err)
+	}
+}
+
+func TestSessionsRevokeDevice(t *testing.T) {
+	s := NewSessions()
+	device, refresh, _, _ := s.RefreshTokens.Issue(1, "", "Firefox")
+	token, _, _ := s.CreateOnDevice(1, device, "", "Firefox")
+
+	list := s.List(1, token)
+	if len(list) != 1 || !list[0].Remembered {
+		t.Fatalf("Expected a remembered session, got %+v", list)
+	}
+	s.Revoke(1, list[0].ID)
+	if _, _, _, _, err := s.RefreshTokens.Rotate(refresh, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected revoking the session to revoke the device, got %v", err)
+	}
+
+	device, refresh, _, _ = s.RefreshTokens.Issue(1, "", "Firefox")
+	token, _, _ = s.CreateOnDevice(1, device, "", "Firefox")
+	s.Delete(token)
+	if _, _, _, _, err := s.RefreshTokens.Rotate(refresh, "Firefox"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected logging out to revoke the device, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSessionTTL はログインのセッションの有効期間の既定値です
+const DefaultSessionTTL = 30 * 24 * time.Hour
+
+// maxUserAgent は記録する User-Agent の最大の長さ（バイト数）です
+const maxUserAgent = 256
+
+// Sessions はログイン中のセッション（推測できないトークンとユーザーの ID）をメモリ上に保持します
+// サーバを再起動するとセッションは消え、もう一度ログインが必要になります（「ログインしたままにする」端末は RefreshTokens で続けられます）
+// TTL: セッションの有効期間（ログインした時点から数えます）
+// RefreshTokens: 「ログインしたままにする」端末の refresh token（既定はメモリ上）。セッションを取り消すと、その端末のトークンも取り消します
+type Sessions struct {
+	TTL           time.Duration
+	RefreshTokens *RefreshTokens
+
+	now      func() time.Time
+	mutex    sync.Mutex
+	sessions map[string]*session
+	nextID   int
+}
+
+// Session はログイン中のセッション（端末）の情報です。トークンは含みません
+// IP / UserAgent: ログインしたときの接続元と User-Agent
+// LastSeenAt: 最後にセッションを使った日時 / Current: 一覧を求めたリクエストのセッションかどうか
+// Remembered: 「ログインしたままにする」端末のセッションかどうか（取り消すと端末の refresh token も使えなくなります）
+type Session struct {
+	ID         int       `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	Remembered bool      `json:"remembered"`
+}
+
+// session は1つのセッションです。device は「ログインしたままにする」端末の ID です（なければ 0）
+type session struct {
+	Session
+	userID int
+	device int
+}
+
+// NewSessions は空の Sessions を作成します
+func NewSessions() *Sessions {
+	refresh, _ := NewRefreshTokens("")
+	return &Sessions{TTL: DefaultSessionTTL, RefreshTokens: refresh, now: time.Now, sessions: make(map[string]*session), nextID: 1}
+}
+
+// Create は userID のセッションを作成し、そのトークンと有効期限を返します。ip と userAgent は一覧に表示するために記録します
+// 期限の切れたセッションもここで取り除きます
+func (s *Sessions) Create(userID int, ip, userAgent string) (string, time.Time, error) {
+	return s.CreateOnDevice(userID, 0, ip, userAgent)
+}
+
+// CreateOnDevice は RefreshTokens の端末 device に結び付けた userID のセッションを作成します（Create を参照）
+func (s *Sessions) CreateOnDevice(userID, device int, ip, userAgent string) (string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for t, sess := range s.sessions {
+		if !now.Before(sess.ExpiresAt) {
+			delete(s.sessions, t)
+		}
+	}
+	expires := now.Add(s.TTL)
+	s.sessions[token] = &session{
+		Session: Session{ID: s.nextID, IP: ip, UserAgent: userAgent, CreatedAt: now, LastSeenAt: now, ExpiresAt: expires, Remembered: device != 0},
+		userID:  userID,
+		device:  device,
+	}
+	s.nextID++
+	return token, expires, nil
+}
+
+// Lookup は token のセッションのユーザーの ID を返し、最後に使った日時を記録します。ないか期限が切れていれば false を返します
+func (s *Sessions) Lookup(token string) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sess, ok := s.sessions[token]
+	if !ok {
+		return 0, false
+	}
+	now := s.now()
+	if !now.Before(sess.ExpiresAt) {
+		delete(s.sessions, token)
+		return 0, false
+	}
+	sess.LastSeenAt = now
+	return sess.userID, true
+}
+
+// List は userID のユーザーの期限の切れていないセッションをログインした順に返します
+// current（リクエストのトークン）のセッションには Current を付けます
+func (s *Sessions) List(userID int, current string) []Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	list := []Session{}
+	for token, sess := range s.sessions {
+		if sess.userID != userID || !now.Before(sess.ExpiresAt) {
+			continue
+		}
+		info := sess.Session
+		info.Current = token == current
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Revoke は userID のユーザーのセッション id を取り除きます。「ログインしたままにする」端末なら、その refresh token も取り消します
+// ほかのユーザーのセッションは取り除けず、存在しないときと同じく false を返します
+func (s *Sessions) Revoke(userID, id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for token, sess := range s.sessions {
+		if sess.ID == id && sess.userID == userID {
+			delete(s.sessions, token)
+			s.forgetDevice(userID, sess.device)
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeAll は userID のユーザーのすべてのセッションと「ログインしたままにする」端末を取り除き、セッションの数を返します（すべての端末からのログアウト）
+func (s *Sessions) RevokeAll(userID int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	revoked := 0
+	for token, sess := range s.sessions {
+		if sess.userID == userID {
+			delete(s.sessions, token)
+			revoked++
+		}
+	}
+	s.RefreshTokens.RevokeAll(userID)
+	return revoked
+}
+
+// Delete は token のセッションを取り除きます（ログアウト）。「ログインしたままにする」端末なら、その refresh token も取り消します
+func (s *Sessions) Delete(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if sess, ok := s.sessions[token]; ok {
+		delete(s.sessions, token)
+		s.forgetDevice(sess.userID, sess.device)
+	}
+}
+
+// forgetDevice は端末 device の refresh token を取り消し、同じ端末のほかのセッションも取り除きます。ロック中に呼び出します
+func (s *Sessions) forgetDevice(userID, device int) {
+	if device == 0 {
+		return
+	}
+	s.RefreshTokens.Revoke(userID, device)
+	for token, sess := range s.sessions {
+		if sess.device == device {
+			delete(s.sessions, token)
+		}
+	}
+}
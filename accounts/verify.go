@@ -0,0 +1,117 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"todo-app/models"
+)
+
+// DefaultVerificationTTL はメールアドレスの確認用のリンクの有効期間の既定値です
+const DefaultVerificationTTL = 24 * time.Hour
+
+// resendInterval は確認メールを送り直せるようになるまでの間隔です。送り直しを繰り返してメールを大量に送らせないようにします
+const resendInterval = time.Minute
+
+// maxEmailLength はメールアドレスの最大の長さ（バイト数）です
+const maxEmailLength = 254
+
+// メールアドレスの確認のエラーです
+// ErrInvalidVerification: 確認用のトークンが誤っているか、期限が切れていること
+// ErrEmailNotVerified: メールアドレスをまだ確かめていないこと
+// ErrResendTooSoon: 前に確認メールを送ってから resendInterval が経っていないこと
+var (
+	ErrInvalidVerification = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified    = errors.New("email address not verified")
+	ErrResendTooSoon       = errors.New("verification email was sent recently")
+)
+
+// verification はメールアドレスを確かめるトークンのハッシュ（SHA-256）と期限、送った日時です
+type verification struct {
+	Hash      string    `json:"hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// ValidateEmail は email がメールアドレスとして使えるか（表示名や <> のない addr-spec か）を確かめます
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return fmt.Errorf("%w: email must be a valid address of at most %d bytes", models.ErrValidation, maxEmailLength)
+	}
+	return nil
+}
+
+// StartVerification は name のユーザーのメールアドレスを確かめるトークンを発行し、ユーザーとトークンを返します
+// 前に発行したトークンは使えなくなります。呼び出し側でトークンを入れたリンクをメールで送ります
+// ユーザーがいないかメールアドレスがなければ ErrValidation を、確認済みなら ErrConflict を、送ったばかりなら ErrResendTooSoon を返します
+func (s *Store) StartVerification(name string) (User, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return User{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := s.index(strings.ToLower(strings.TrimSpace(name)))
+	if i < 0 || s.accounts[i].Email == "" {
+		return User{}, "", fmt.Errorf("%w: no email address to verify", models.ErrValidation)
+	}
+	a := &s.accounts[i]
+	if a.EmailVerified {
+		return User{}, "", fmt.Errorf("%w: email address already verified", models.ErrConflict)
+	}
+	now := s.now().UTC()
+	if a.Verification != nil && now.Sub(a.Verification.SentAt) < resendInterval {
+		return User{}, "", ErrResendTooSoon
+	}
+	previous := a.Verification
+	a.Verification = &verification{Hash: hashToken(token), ExpiresAt: now.Add(s.VerificationTTL), SentAt: now}
+	if err := s.save(); err != nil {
+		a.Verification = previous
+		return User{}, "", err
+	}
+	return a.User, token, nil
+}
+
+// VerifyEmail は token を発行したユーザーのメールアドレスを確認済みにして、ユーザーを返します
+// トークンが誤っているか期限が切れていれば ErrInvalidVerification を返します。使ったトークンはもう使えません
+func (s *Store) VerifyEmail(token string) (User, error) {
+	hash := hashToken(token)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.accounts {
+		a := &s.accounts[i]
+		if a.Verification == nil || a.Verification.Hash != hash {
+			continue
+		}
+		if !s.now().Before(a.Verification.ExpiresAt) {
+			return User{}, ErrInvalidVerification
+		}
+		previous := a.Verification
+		a.EmailVerified, a.Verification = true, nil
+		if err := s.save(); err != nil {
+			a.EmailVerified, a.Verification = false, previous
+			return User{}, err
+		}
+		return a.User, nil
+	}
+	return User{}, ErrInvalidVerification
+}
+
+// index は name のアカウントの位置を返します（なければ -1）。ロック中に呼び出します
+func (s *Store) index(name string) int {
+	for i, a := range s.accounts {
+		if a.Name == name {
+			return i
+		}
+	}
+	return -1
+}
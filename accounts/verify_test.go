@@ -0,0 +1,91 @@
+package accounts
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestValidateEmail(t *testing.T) {
+	if err := ValidateEmail("alice@example.com"); err != nil {
+		t.Errorf("Expected a valid address, got %v", err)
+	}
+	for _, email := range []string{"alice", "Alice <alice@example.com>", "<alice@example.com>", "alice@"} {
+		if err := ValidateEmail(email); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("ValidateEmail(%q): expected a validation error, got %v", email, err)
+		}
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "users.json")
+	s := newTestStore(t, path)
+	s.now = func() time.Time { return now }
+
+	alice, err := s.RegisterWithEmail("alice", "correct horse", " alice@example.com ")
+	if err != nil {
+		t.Fatalf("RegisterWithEmail failed: %v", err)
+	}
+	if alice.Email != "alice@example.com" || alice.EmailVerified {
+		t.Errorf("Unexpected user %+v", alice)
+	}
+	if _, err := s.RegisterWithEmail("bob", "battery staple", "not an address"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected an invalid address to be rejected, got %v", err)
+	}
+	s.Register("carol", "correct horse")
+	if _, _, err := s.StartVerification("carol"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected a user without an address to be rejected, got %v", err)
+	}
+
+	_, first, err := s.StartVerification("alice")
+	if err != nil {
+		t.Fatalf("StartVerification failed: %v", err)
+	}
+	if _, _, err := s.StartVerification("alice"); !errors.Is(err, ErrResendTooSoon) {
+		t.Errorf("Expected a resend to be throttled, got %v", err)
+	}
+
+	// 送り直すと前のトークンは使えなくなります
+	now = now.Add(time.Minute)
+	_, second, _ := s.StartVerification("alice")
+	if _, err := s.VerifyEmail(first); !errors.Is(err, ErrInvalidVerification) {
+		t.Errorf("Expected the previous token to be rejected, got %v", err)
+	}
+
+	// 確認済みの状態はファイルにも残ります
+	user, err := s.VerifyEmail(second)
+	if err != nil || !user.EmailVerified {
+		t.Fatalf("Expected the address to be verified, got %+v %v", user, err)
+	}
+	if _, err := s.VerifyEmail(second); !errors.Is(err, ErrInvalidVerification) {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+	reloaded := newTestStore(t, path)
+	if user, _ := reloaded.Authenticate("alice", "correct horse"); !user.EmailVerified {
+		t.Errorf("Expected the verification to survive a reload, got %+v", user)
+	}
+	if _, _, err := s.StartVerification("alice"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected a verified address not to be sent again, got %v", err)
+	}
+}
+
+func TestVerifyEmailExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestStore(t, "")
+	s.now = func() time.Time { return now }
+	s.RegisterWithEmail("alice", "correct horse", "alice@example.com")
+
+	_, token, _ := s.StartVerification("alice")
+	now = now.Add(DefaultVerificationTTL)
+	if _, err := s.VerifyEmail(token); !errors.Is(err, ErrInvalidVerification) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+	_, token, _ = s.StartVerification("alice")
+	if user, err := s.VerifyEmail(token); err != nil || !user.EmailVerified {
+		t.Errorf("Expected a resent token to work, got %+v %v", user, err)
+	}
+}
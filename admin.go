@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// adminUsage は `todo-app admin` の使い方です
+const adminUsage = `使い方: todo-app admin <command>
+
+TODO_GIT_DIR のタスクの保存先を直接操作します。サーバを止めてから実行してください。
+
+  verify    タスクのファイルの不整合（読めない JSON、ID の食い違い、未コミットの変更など）を調べる
+  compact   リポジトリの履歴をまとめて小さくする（git gc）。vacuum でも実行できます
+  purge-trash  ごみ箱のタスクを保持する期間に関わらずすべて完全に削除する
+`
+
+// unavailableAdminCommands は保存先にまだ対象のデータがないため実行できないコマンドと、その理由です
+var unavailableAdminCommands = map[string]string{
+	"rebuild-index":  "検索インデックスはありません（絞り込みは毎回タスクを走査します）",
+	"reset-password": "ユーザーアカウントはまだありません",
+}
+
+// runAdmin は `todo-app admin <command>` を実行し、終了コードを返します
+// 不整合が見つかった場合や操作に失敗した場合は 1 を、使い方の誤りや実行できないコマンドは 2 を返します
+func runAdmin(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, adminUsage)
+		return 2
+	}
+	command := args[0]
+	if command == "help" || command == "-h" || command == "--help" {
+		fmt.Fprint(stdout, adminUsage)
+		return 0
+	}
+	if reason, ok := unavailableAdminCommands[command]; ok {
+		fmt.Fprintf(stderr, "admin %s: 実行できません: %s\n", command, reason)
+		return 2
+	}
+	if command != "verify" && command != "compact" && command != "vacuum" && command != "purge-trash" {
+		fmt.Fprintf(stderr, "admin: 不明なコマンド %q\n\n%s", command, adminUsage)
+		return 2
+	}
+
+	dir := os.Getenv("TODO_GIT_DIR")
+	if dir == "" {
+		fmt.Fprintf(stderr, "admin %s: TODO_GIT_DIR が設定されていません（メモリ上の保存先には操作するデータがありません）\n", command)
+		return 1
+	}
+
+	return runGitAdmin(command, dir, stdout, stderr)
+}
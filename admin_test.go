@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runAdminForTest(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := runAdmin(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunAdminUsage(t *testing.T) {
+	if code, _, stderr := runAdminForTest(); code != 2 || !strings.Contains(stderr, "使い方") {
+		t.Errorf("Expected usage with exit code 2, got %d %q", code, stderr)
+	}
+	if code, stdout, _ := runAdminForTest("help"); code != 0 || !strings.Contains(stdout, "verify") {
+		t.Errorf("Expected help with exit code 0, got %d %q", code, stdout)
+	}
+	if code, _, stderr := runAdminForTest("explode"); code != 2 || !strings.Contains(stderr, "不明なコマンド") {
+		t.Errorf("Expected an unknown command error, got %d %q", code, stderr)
+	}
+	for command := range unavailableAdminCommands {
+		if code, _, stderr := runAdminForTest(command); code != 2 || !strings.Contains(stderr, "実行できません") {
+			t.Errorf("Expected %s to be unavailable, got %d %q", command, code, stderr)
+		}
+	}
+
+	t.Setenv("TODO_GIT_DIR", "")
+	if code, _, stderr := runAdminForTest("verify"); code != 1 || !strings.Contains(stderr, "TODO_GIT_DIR") {
+		t.Errorf("Expected an error without TODO_GIT_DIR, got %d %q", code, stderr)
+	}
+}
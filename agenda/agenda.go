@@ -0,0 +1,76 @@
+// Package agenda は「今日」の画面や週の振り返りに表示するタスクを、期限・予定日・作成日時・完了日時から選び出します
+package agenda
+
+import (
+	"sort"
+	"time"
+
+	"todo-app/models"
+)
+
+// Agenda は今日やるべきタスクをセクションごとにまとめたものです
+// Date: 利用者のタイムゾーンでの今日の日付（YYYY-MM-DD）
+// TimeZone: 日付の計算に使ったタイムゾーン
+// Overdue: 期限が今日より前で、まだ完了していないタスク
+// DueToday: 期限が今日のタスク（完了済みも含みます）
+// Scheduled: 今日取りかかる予定のタスク。前の日に予定していて終わっていないものも含みます
+type Agenda struct {
+	Date      string        `json:"date"`
+	TimeZone  string        `json:"timezone"`
+	Overdue   []models.Task `json:"overdue"`
+	DueToday  []models.Task `json:"due_today"`
+	Scheduled []models.Task `json:"scheduled"`
+}
+
+// Build は now の時点の tasks から Agenda を作成します
+// 今日の日付は now をタイムゾーン loc に変換して求めます
+// 期限と予定日は日付として扱うため、保存されているタイムゾーンの年月日で比較します
+// 1件のタスクは、期限切れ・今日が期限・今日の予定の順に最初に当てはまるセクションにだけ入ります
+func Build(tasks []models.Task, now time.Time, loc *time.Location) Agenda {
+	now = now.In(loc)
+	today := dateOf(now)
+	agenda := Agenda{
+		Date:      now.Format("2006-01-02"),
+		TimeZone:  loc.String(),
+		Overdue:   []models.Task{},
+		DueToday:  []models.Task{},
+		Scheduled: []models.Task{},
+	}
+
+	for _, task := range tasks {
+		switch {
+		case task.DueDate != nil && !task.Completed && dateOf(*task.DueDate).Before(today):
+			agenda.Overdue = append(agenda.Overdue, task)
+		case task.DueDate != nil && dateOf(*task.DueDate).Equal(today):
+			agenda.DueToday = append(agenda.DueToday, task)
+		case task.ScheduledDate != nil && isScheduledToday(task, today):
+			agenda.Scheduled = append(agenda.Scheduled, task)
+		}
+	}
+
+	sortByDate(agenda.Overdue, func(task models.Task) time.Time { return *task.DueDate })
+	sortByDate(agenda.Scheduled, func(task models.Task) time.Time { return *task.ScheduledDate })
+	return agenda
+}
+
+// isScheduledToday は予定日が今日か、前の日に予定したまま終わっていないかを返します
+func isScheduledToday(task models.Task, today time.Time) bool {
+	scheduled := dateOf(*task.ScheduledDate)
+	return scheduled.Equal(today) || (scheduled.Before(today) && !task.Completed)
+}
+
+// dateOf は t の年月日だけを UTC の0時として返します
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// sortByDate は date の日付が古い順、同じ日ならID順にタスクを並べます
+func sortByDate(tasks []models.Task, date func(task models.Task) time.Time) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := dateOf(date(tasks[i])), dateOf(date(tasks[j]))
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
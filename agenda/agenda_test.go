@@ -0,0 +1,90 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func ids(tasks []models.Task) []int {
+	result := make([]int, len(tasks))
+	for i, task := range tasks {
+		result[i] = task.ID
+	}
+	return result
+}
+
+func assertIDs(t *testing.T, section string, tasks []models.Task, want ...int) {
+	t.Helper()
+	got := ids(tasks)
+	if len(got) != len(want) {
+		t.Errorf("%s: expected IDs %v, got %v", section, want, got)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: expected IDs %v, got %v", section, want, got)
+			return
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "No dates"},
+		{ID: 2, Title: "Overdue", DueDate: date(2025, 3, 8)},
+		{ID: 3, Title: "Overdue but done", DueDate: date(2025, 3, 1), Completed: true},
+		{ID: 4, Title: "Due today", DueDate: date(2025, 3, 10)},
+		{ID: 5, Title: "Due today and done", DueDate: date(2025, 3, 10), Completed: true},
+		{ID: 6, Title: "Due tomorrow", DueDate: date(2025, 3, 11)},
+		{ID: 7, Title: "Scheduled today", ScheduledDate: date(2025, 3, 10)},
+		{ID: 8, Title: "Scheduled earlier", ScheduledDate: date(2025, 3, 5)},
+		{ID: 9, Title: "Scheduled earlier and done", ScheduledDate: date(2025, 3, 5), Completed: true},
+		{ID: 10, Title: "Scheduled tomorrow", ScheduledDate: date(2025, 3, 11)},
+		{ID: 11, Title: "Scheduled today, due today", DueDate: date(2025, 3, 10), ScheduledDate: date(2025, 3, 10)},
+		{ID: 12, Title: "Long overdue", DueDate: date(2025, 2, 1)},
+	}
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	agenda := Build(tasks, now, time.UTC)
+
+	if agenda.Date != "2025-03-10" || agenda.TimeZone != "UTC" {
+		t.Errorf("Unexpected date %q in %q", agenda.Date, agenda.TimeZone)
+	}
+	assertIDs(t, "overdue", agenda.Overdue, 12, 2)
+	assertIDs(t, "due today", agenda.DueToday, 4, 5, 11)
+	assertIDs(t, "scheduled", agenda.Scheduled, 8, 7)
+}
+
+func TestBuildUsesTimeZone(t *testing.T) {
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	tasks := []models.Task{
+		{ID: 1, Title: "Due on the 10th", DueDate: date(2025, 3, 10)},
+		{ID: 2, Title: "Due on the 11th", DueDate: date(2025, 3, 11)},
+	}
+	// UTC ではまだ10日ですが、東京ではすでに11日です
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	utc := Build(tasks, now, time.UTC)
+	assertIDs(t, "UTC due today", utc.DueToday, 1)
+	assertIDs(t, "UTC overdue", utc.Overdue)
+
+	local := Build(tasks, now, tokyo)
+	if local.Date != "2025-03-11" || local.TimeZone != "Asia/Tokyo" {
+		t.Errorf("Unexpected date %q in %q", local.Date, local.TimeZone)
+	}
+	assertIDs(t, "Tokyo due today", local.DueToday, 2)
+	assertIDs(t, "Tokyo overdue", local.Overdue, 1)
+}
+
+func TestBuildEmpty(t *testing.T) {
+	agenda := Build(nil, time.Now(), time.UTC)
+	if agenda.Overdue == nil || agenda.DueToday == nil || agenda.Scheduled == nil {
+		t.Errorf("Expected empty sections instead of nil: %+v", agenda)
+	}
+}
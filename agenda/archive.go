@@ -0,0 +1,113 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"todo-app/models"
+)
+
+// 履歴の1ページのタスクの数の既定値と上限です
+const (
+	DefaultArchivePageSize = 50
+	MaxArchivePageSize     = 200
+)
+
+// Archive は完了したタスクの履歴の1ページです
+// Page / PerPage: ページ番号（1から）と1ページのタスクの数
+// Total: 完了したタスクの数、Pages: ページの数（完了したタスクがなくても 1）
+// Days: このページのタスクを、完了した日ごとに新しい順にまとめたもの
+type Archive struct {
+	TimeZone string       `json:"timezone"`
+	Page     int          `json:"page"`
+	PerPage  int          `json:"per_page"`
+	Total    int          `json:"total"`
+	Pages    int          `json:"pages"`
+	Days     []ArchiveDay `json:"days"`
+}
+
+// ArchiveDay は履歴の1日です
+// Date: 完了した日付（YYYY-MM-DD）。完了日時を記録する前に完了したタスクは空で、最後のページの最後に置きます
+// Tasks: その日に完了したタスク（完了日時の新しい順）
+type ArchiveDay struct {
+	Date  string        `json:"date"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// ParsePage は ?page= と ?per_page= を読み取ります。空ならそれぞれ 1 と DefaultArchivePageSize にします
+func ParsePage(page, perPage string) (int, int, error) {
+	p, size := 1, DefaultArchivePageSize
+	if page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("%w: page must be a positive integer", models.ErrValidation)
+		}
+		p = n
+	}
+	if perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 || n > MaxArchivePageSize {
+			return 0, 0, fmt.Errorf("%w: per_page must be between 1 and %d", models.ErrValidation, MaxArchivePageSize)
+		}
+		size = n
+	}
+	return p, size, nil
+}
+
+// BuildArchive は tasks のうち完了したものを完了日時の新しい順に並べ、page ページ目を loc での日付ごとにまとめます
+// 完了日時が同じならIDの大きい順です。ページが範囲外なら Days は空です
+func BuildArchive(tasks []models.Task, loc *time.Location, page, perPage int) Archive {
+	var completed []models.Task
+	for _, task := range tasks {
+		if task.Completed {
+			completed = append(completed, task)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		a, b := completed[i].CompletedAt, completed[j].CompletedAt
+		switch {
+		case a == nil || b == nil:
+			if (a == nil) != (b == nil) {
+				return b == nil
+			}
+		case !a.Equal(*b):
+			return a.After(*b)
+		}
+		return completed[i].ID > completed[j].ID
+	})
+
+	archive := Archive{
+		TimeZone: loc.String(),
+		Page:     page,
+		PerPage:  perPage,
+		Total:    len(completed),
+		Pages:    (len(completed) + perPage - 1) / perPage,
+		Days:     []ArchiveDay{},
+	}
+	if archive.Pages == 0 {
+		archive.Pages = 1
+	}
+
+	start := (page - 1) * perPage
+	if start >= len(completed) {
+		return archive
+	}
+	end := start + perPage
+	if end > len(completed) {
+		end = len(completed)
+	}
+	for _, task := range completed[start:end] {
+		date := ""
+		if task.CompletedAt != nil {
+			date = task.CompletedAt.In(loc).Format("2006-01-02")
+		}
+		if n := len(archive.Days); n == 0 || archive.Days[n-1].Date != date {
+			archive.Days = append(archive.Days, ArchiveDay{Date: date})
+		}
+		day := &archive.Days[len(archive.Days)-1]
+		day.Tasks = append(day.Tasks, task)
+	}
+	return archive
+}
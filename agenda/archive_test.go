@@ -0,0 +1,82 @@
+package agenda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestBuildArchive(t *testing.T) {
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	tasks := []models.Task{
+		{ID: 1, Title: "古い完了", Completed: true},
+		{ID: 2, Title: "3/10 朝", Completed: true, CompletedAt: at(10, 1)},
+		{ID: 3, Title: "未完了"},
+		{ID: 4, Title: "3/10 夜", Completed: true, CompletedAt: at(10, 20)},
+		{ID: 5, Title: "3/9", Completed: true, CompletedAt: at(9, 12)},
+		{ID: 6, Title: "3/10 夜（同時）", Completed: true, CompletedAt: at(10, 20)},
+		{ID: 7, Title: "記録なし", Completed: true},
+	}
+
+	archive := BuildArchive(tasks, time.UTC, 1, 50)
+	if archive.Total != 6 || archive.Pages != 1 || archive.TimeZone != "UTC" {
+		t.Errorf("Unexpected archive %+v", archive)
+	}
+	want := []struct {
+		date string
+		ids  []int
+	}{
+		{"2025-03-10", []int{6, 4, 2}},
+		{"2025-03-09", []int{5}},
+		{"", []int{7, 1}},
+	}
+	if len(archive.Days) != len(want) {
+		t.Fatalf("Expected %d days, got %+v", len(want), archive.Days)
+	}
+	for i, w := range want {
+		day := archive.Days[i]
+		if day.Date != w.date || len(day.Tasks) != len(w.ids) {
+			t.Errorf("Day %d: expected %s with %v, got %+v", i, w.date, w.ids, day)
+			continue
+		}
+		for j, id := range w.ids {
+			if day.Tasks[j].ID != id {
+				t.Errorf("Day %d task %d: expected %d, got %d", i, j, id, day.Tasks[j].ID)
+			}
+		}
+	}
+
+	// 東京では 3/10 20:00 UTC は 3/11 になります
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	if archive := BuildArchive(tasks, tokyo, 1, 2); archive.Pages != 3 || len(archive.Days) != 1 || archive.Days[0].Date != "2025-03-11" {
+		t.Errorf("Expected the first page in Tokyo to be 3/11, got %+v", archive)
+	}
+	if archive := BuildArchive(tasks, time.UTC, 2, 2); len(archive.Days) != 2 || archive.Days[0].Tasks[0].ID != 2 || archive.Days[1].Tasks[0].ID != 5 {
+		t.Errorf("Unexpected second page %+v", archive.Days)
+	}
+	if archive := BuildArchive(tasks, time.UTC, 9, 2); len(archive.Days) != 0 || archive.Days == nil {
+		t.Errorf("Expected an empty page, got %#v", archive.Days)
+	}
+	if archive := BuildArchive(nil, time.UTC, 1, 10); archive.Pages != 1 || archive.Total != 0 {
+		t.Errorf("Expected one empty page, got %+v", archive)
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	if page, perPage, err := ParsePage("", ""); err != nil || page != 1 || perPage != DefaultArchivePageSize {
+		t.Errorf("Expected the defaults, got %d %d %v", page, perPage, err)
+	}
+	if page, perPage, err := ParsePage("3", "20"); err != nil || page != 3 || perPage != 20 {
+		t.Errorf("Expected 3 and 20, got %d %d %v", page, perPage, err)
+	}
+	for _, c := range [][2]string{{"0", ""}, {"x", ""}, {"", "0"}, {"", "201"}} {
+		if _, _, err := ParsePage(c[0], c[1]); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("ParsePage(%q, %q): expected a validation error, got %v", c[0], c[1], err)
+		}
+	}
+}
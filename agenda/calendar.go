@@ -0,0 +1,88 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"todo-app/models"
+)
+
+// Calendar は1か月の日ごとに、その日が期限のタスクをまとめたものです
+// Month: 年月（2025-03 など）
+// Today: 利用者のタイムゾーンでの今日の日付（YYYY-MM-DD）
+// Days: 月の1日から月末までのすべての日
+type Calendar struct {
+	Month    string        `json:"month"`
+	TimeZone string        `json:"timezone"`
+	Today    string        `json:"today"`
+	Days     []CalendarDay `json:"days"`
+}
+
+// CalendarDay はカレンダーの1日です
+// Tasks: 期限がその日のタスク（完了済みも含み、ID の順）
+// Open: Tasks のうち未完了のタスクの数
+type CalendarDay struct {
+	Date  string        `json:"date"`
+	Tasks []models.Task `json:"tasks"`
+	Open  int           `json:"open"`
+}
+
+// ParseMonth は "2025-03" 形式の年月を読み取り、loc でのその月の1日の0時を返します
+// 空なら now を含む月を返します
+func ParseMonth(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		today := now.In(loc)
+		return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc), nil
+	}
+	month, err := time.ParseInLocation("2006-01", s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid month %q (for example 2025-03)", models.ErrValidation, s)
+	}
+	return month, nil
+}
+
+// BuildCalendar は first から始まる1か月の Calendar を tasks から作成します
+// first は ParseMonth で求めた月の1日で、そのタイムゾーンで今日の日付を決めます
+// 日付だけの期限は保存されている年月日のまま、時刻を含む期限は first のタイムゾーンに変換した日付で数えます
+func BuildCalendar(tasks []models.Task, first, now time.Time) Calendar {
+	loc := first.Location()
+	next := first.AddDate(0, 1, 0)
+	calendar := Calendar{
+		Month:    first.Format("2006-01"),
+		TimeZone: loc.String(),
+		Today:    now.In(loc).Format("2006-01-02"),
+		Days:     []CalendarDay{},
+	}
+	for day := first; day.Before(next); day = day.AddDate(0, 0, 1) {
+		calendar.Days = append(calendar.Days, CalendarDay{Date: day.Format("2006-01-02"), Tasks: []models.Task{}})
+	}
+
+	start := dateOf(first)
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		i := int(dueDay(*task.DueDate, loc).Sub(start).Hours() / 24)
+		if i < 0 || i >= len(calendar.Days) {
+			continue
+		}
+		calendar.Days[i].Tasks = append(calendar.Days[i].Tasks, task)
+		if !task.Completed {
+			calendar.Days[i].Open++
+		}
+	}
+	for _, day := range calendar.Days {
+		sort.SliceStable(day.Tasks, func(i, j int) bool { return day.Tasks[i].ID < day.Tasks[j].ID })
+	}
+	return calendar
+}
+
+// dueDay は期限 t の日付を UTC の0時として返します
+// 0時ちょうどの期限は日付だけを指定したものとしてそのまま、それ以外は loc での日付にします
+func dueDay(t time.Time, loc *time.Location) time.Time {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return dateOf(t)
+	}
+	return dateOf(t.In(loc))
+}
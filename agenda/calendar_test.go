@@ -0,0 +1,62 @@
+package agenda
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestParseMonth(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	first, err := ParseMonth("2025-03", time.Now(), tokyo)
+	if err != nil || !first.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("ParseMonth = %v, %v", first, err)
+	}
+
+	// 省略すると、そのタイムゾーンで今日を含む月です（UTC の 2025-03-31 15:00 は東京では 4月1日）
+	first, _ = ParseMonth("", time.Date(2025, 3, 31, 15, 0, 0, 0, time.UTC), tokyo)
+	if first.Format("2006-01") != "2025-04" {
+		t.Errorf("Expected the current month in Tokyo, got %v", first)
+	}
+
+	for _, invalid := range []string{"2025-3", "2025-13", "03-2025", "2025-03-01", "march"} {
+		if _, err := ParseMonth(invalid, time.Now(), time.UTC); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestBuildCalendar(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	tasks := []models.Task{
+		{ID: 3, Title: "Pay rent", DueDate: at(2025, 2, 28, 0)},
+		{ID: 2, Title: "Report", DueDate: at(2025, 2, 1, 0), Completed: true},
+		{ID: 1, Title: "Review", DueDate: at(2025, 2, 1, 0)},
+		// UTC の 1月31日 20:00 は東京では 2月1日です
+		{ID: 4, Title: "Call", DueDate: at(2025, 1, 31, 20)},
+		{ID: 5, Title: "Next month", DueDate: at(2025, 3, 1, 0)},
+		{ID: 6, Title: "No due date"},
+	}
+	first, _ := ParseMonth("2025-02", time.Now(), tokyo)
+	calendar := BuildCalendar(tasks, first, time.Date(2025, 2, 10, 16, 0, 0, 0, time.UTC))
+
+	if calendar.Month != "2025-02" || calendar.TimeZone != "Asia/Tokyo" || calendar.Today != "2025-02-11" || len(calendar.Days) != 28 {
+		t.Fatalf("Unexpected calendar %+v", calendar)
+	}
+	firstDay := calendar.Days[0]
+	if firstDay.Date != "2025-02-01" || firstDay.Open != 2 || len(firstDay.Tasks) != 3 {
+		t.Fatalf("Unexpected first day %+v", firstDay)
+	}
+	for i, id := range []int{1, 2, 4} {
+		if firstDay.Tasks[i].ID != id {
+			t.Errorf("Expected tasks in ID order, got %+v", firstDay.Tasks)
+		}
+	}
+	if last := calendar.Days[27]; last.Date != "2025-02-28" || len(last.Tasks) != 1 || last.Open != 1 {
+		t.Errorf("Unexpected last day %+v", last)
+	}
+	if day := calendar.Days[9]; day.Tasks == nil || len(day.Tasks) != 0 {
+		t.Errorf("Expected an empty list for a day without tasks, got %+v", day)
+	}
+}
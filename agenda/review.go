@@ -0,0 +1,101 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/models"
+)
+
+// Review は1週間の振り返り（GTD のウィークリーレビュー）に使うタスクをまとめたものです
+// Week: ISO 8601 の週（2025-W07 など）
+// From / To: 週の最初（月曜日）と最後（日曜日）の日付
+// Completed: その週に完了したタスク（完了日時の順）
+// CarriedOver: 週の前からあり、週の終わりにもまだ終わっていないタスク
+// Created: その週に作成したタスク（作成日時の順）
+type Review struct {
+	Week        string        `json:"week"`
+	TimeZone    string        `json:"timezone"`
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Completed   []models.Task `json:"completed"`
+	CarriedOver []models.Task `json:"carried_over"`
+	Created     []models.Task `json:"created"`
+}
+
+// ParseWeek は "2025-W07" 形式の ISO 週を読み取り、loc でのその週の月曜日の0時を返します
+// 空なら now を含む週を返します
+func ParseWeek(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		today := now.In(loc)
+		day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	}
+
+	invalid := fmt.Errorf("%w: invalid week %q (for example 2025-W07)", models.ErrValidation, s)
+	parts := strings.SplitN(s, "-W", 2)
+	if len(parts) != 2 || len(parts[0]) != 4 || len(parts[1]) != 2 {
+		return time.Time{}, invalid
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, invalid
+	}
+	week, err := strconv.Atoi(parts[1])
+	if err != nil || week < 1 || week > 53 {
+		return time.Time{}, invalid
+	}
+
+	// 1月4日を含む週がその年の第1週です
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7+7*(week-1))
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, invalid
+	}
+	return monday, nil
+}
+
+// BuildReview は monday から始まる1週間の Review を tasks から作成します
+// monday は ParseWeek で求めた週の最初の日で、そのタイムゾーンで週の区切りを決めます
+// 作成日時を記録していないタスクは週の前からあったものとして扱います
+func BuildReview(tasks []models.Task, monday time.Time) Review {
+	end := monday.AddDate(0, 0, 7)
+	year, week := monday.ISOWeek()
+	review := Review{
+		Week:        fmt.Sprintf("%04d-W%02d", year, week),
+		TimeZone:    monday.Location().String(),
+		From:        monday.Format("2006-01-02"),
+		To:          end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Completed:   []models.Task{},
+		CarriedOver: []models.Task{},
+		Created:     []models.Task{},
+	}
+
+	within := func(t *time.Time) bool {
+		return t != nil && !t.Before(monday) && t.Before(end)
+	}
+	for _, task := range tasks {
+		if task.Completed && within(task.CompletedAt) {
+			review.Completed = append(review.Completed, task)
+		}
+		if within(task.CreatedAt) {
+			review.Created = append(review.Created, task)
+		}
+		createdBefore := task.CreatedAt == nil || task.CreatedAt.Before(monday)
+		openAtEnd := !task.Completed || (task.CompletedAt != nil && !task.CompletedAt.Before(end))
+		if createdBefore && openAtEnd {
+			review.CarriedOver = append(review.CarriedOver, task)
+		}
+	}
+
+	sort.SliceStable(review.Completed, func(i, j int) bool {
+		return review.Completed[i].CompletedAt.Before(*review.Completed[j].CompletedAt)
+	})
+	sort.SliceStable(review.Created, func(i, j int) bool {
+		return review.Created[i].CreatedAt.Before(*review.Created[j].CreatedAt)
+	})
+	return review
+}
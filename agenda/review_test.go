@@ -0,0 +1,66 @@
+package agenda
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func at(year int, month time.Month, day, hour int) *time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParseWeek(t *testing.T) {
+	testCases := []struct {
+		week   string
+		monday string
+	}{
+		{"2025-W07", "2025-02-10"},
+		{"2025-W01", "2024-12-30"},
+		{"2020-W53", "2020-12-28"},
+		{"2021-W01", "2021-01-04"},
+	}
+	for _, tc := range testCases {
+		monday, err := ParseWeek(tc.week, time.Now(), time.UTC)
+		if err != nil || monday.Format("2006-01-02") != tc.monday {
+			t.Errorf("ParseWeek(%q) = %v, %v; expected %s", tc.week, monday, err, tc.monday)
+		}
+	}
+
+	for _, invalid := range []string{"2025-07", "2025-W00", "2025-W54", "2025-W53", "25-W07", "2025-W7", "abcd-W07", "2025-Wxx"} {
+		if _, err := ParseWeek(invalid, time.Now(), time.UTC); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", invalid, err)
+		}
+	}
+
+	// 省略すると今日を含む週の月曜日です（2025-03-13 は木曜日）
+	monday, _ := ParseWeek("", time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC), time.UTC)
+	if monday.Format("2006-01-02") != "2025-03-10" {
+		t.Errorf("Expected the current week to start on 2025-03-10, got %v", monday)
+	}
+}
+
+func TestBuildReview(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Old and open", CreatedAt: at(2025, 2, 1, 9)},
+		{ID: 2, Title: "Old, done this week", Completed: true, CreatedAt: at(2025, 2, 1, 9), CompletedAt: at(2025, 2, 12, 9)},
+		{ID: 3, Title: "New and done", Completed: true, CreatedAt: at(2025, 2, 11, 9), CompletedAt: at(2025, 2, 11, 12)},
+		{ID: 4, Title: "New and open", CreatedAt: at(2025, 2, 10, 9)},
+		{ID: 5, Title: "Old, done next week", Completed: true, CreatedAt: at(2025, 2, 1, 9), CompletedAt: at(2025, 2, 20, 9)},
+		{ID: 6, Title: "Done last week", Completed: true, CreatedAt: at(2025, 2, 1, 9), CompletedAt: at(2025, 2, 5, 9)},
+		{ID: 7, Title: "Created next week", CreatedAt: at(2025, 2, 17, 9)},
+		{ID: 8, Title: "No timestamps"},
+	}
+	monday, _ := ParseWeek("2025-W07", time.Now(), time.UTC)
+
+	review := BuildReview(tasks, monday)
+
+	if review.Week != "2025-W07" || review.From != "2025-02-10" || review.To != "2025-02-16" || review.TimeZone != "UTC" {
+		t.Errorf("Unexpected review header: %+v", review)
+	}
+	assertIDs(t, "completed", review.Completed, 3, 2)
+	assertIDs(t, "carried over", review.CarriedOver, 1, 5, 8)
+	assertIDs(t, "created", review.Created, 4, 3)
+}
@@ -0,0 +1,145 @@
+package agenda
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"todo-app/models"
+)
+
+// 提案するタスクの数の既定値と上限です
+const (
+	DefaultSuggestions = 5
+	MaxSuggestions     = 50
+)
+
+// スコアの重みです。合計が 100 になるようにしています
+// 期限が近いこと・優先度が高いこと・長く放置されていること・すぐに終わること（見積もりが短いこと）の順に重く見ます
+const (
+	dueWeight      = 40
+	priorityWeight = 30
+	ageWeight      = 15
+	effortWeight   = 15
+)
+
+// dueHorizonDays は期限の点数を付け始める日数です。期限がこれより先のタスクは期限の点数が 0 になります
+const dueHorizonDays = 14
+
+// ageHorizonDays は作成からの日数の点数が満点になる日数です
+const ageHorizonDays = 30
+
+// 提案の理由です。画面でバッジを出すのに使います
+const (
+	ReasonOverdue      = "overdue"
+	ReasonDueToday     = "due_today"
+	ReasonDueSoon      = "due_soon"
+	ReasonHighPriority = "high_priority"
+	ReasonOld          = "old"
+	ReasonQuickWin     = "quick_win"
+)
+
+// Suggestion は提案する1件のタスクです
+// Score: 0〜100 の点数（高いほど先に取りかかるとよいタスク）
+// Reasons: 点数が高くなった主な理由
+type Suggestion struct {
+	Task    models.Task `json:"task"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+}
+
+// ParseLimit は ?limit= の提案する数を読み取ります。空なら DefaultSuggestions にします
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultSuggestions, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > MaxSuggestions {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrValidation, MaxSuggestions)
+	}
+	return limit, nil
+}
+
+// Suggest は未完了のタスクを期限の近さ・優先度・作成からの日数・見積もり時間で点数を付け、点数の高い順に最大 limit 件返します
+// 今日の日付は now をタイムゾーン loc に変換して求めます。点数が同じならID順です
+func Suggest(tasks []models.Task, now time.Time, loc *time.Location, limit int) []Suggestion {
+	today := dateOf(now.In(loc))
+	suggestions := []Suggestion{}
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		suggestions = append(suggestions, score(task, now, today))
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Task.ID < suggestions[j].Task.ID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// score は1件のタスクの点数と理由を求めます
+func score(task models.Task, now, today time.Time) Suggestion {
+	suggestion := Suggestion{Task: task, Reasons: []string{}}
+	var total float64
+
+	if task.DueDate != nil {
+		days := int(dateOf(*task.DueDate).Sub(today).Hours() / 24)
+		switch {
+		case days < 0:
+			total += dueWeight
+			suggestion.Reasons = append(suggestion.Reasons, ReasonOverdue)
+		case days == 0:
+			total += dueWeight * 0.9
+			suggestion.Reasons = append(suggestion.Reasons, ReasonDueToday)
+		case days < dueHorizonDays:
+			total += dueWeight * 0.8 * float64(dueHorizonDays-days) / dueHorizonDays
+			if days <= 3 {
+				suggestion.Reasons = append(suggestion.Reasons, ReasonDueSoon)
+			}
+		}
+	}
+
+	// 優先度を設定していないタスクは中と低の間として扱います
+	switch task.Priority {
+	case models.PriorityHigh:
+		total += priorityWeight
+		suggestion.Reasons = append(suggestion.Reasons, ReasonHighPriority)
+	case models.PriorityMedium:
+		total += priorityWeight * 0.6
+	case models.PriorityLow:
+		total += priorityWeight * 0.2
+	default:
+		total += priorityWeight * 0.4
+	}
+
+	if task.CreatedAt != nil {
+		days := now.Sub(*task.CreatedAt).Hours() / 24
+		if days >= ageHorizonDays {
+			total += ageWeight
+			suggestion.Reasons = append(suggestion.Reasons, ReasonOld)
+		} else if days > 0 {
+			total += ageWeight * days / ageHorizonDays
+		}
+	}
+
+	// 見積もりが短いほど高くし（30分で 2/3、1時間で 1/2）、見積もりがなければ1時間のタスクとして扱います
+	minutes := task.EstimateMinutes
+	if minutes <= 0 {
+		minutes = 60
+	} else if minutes <= 30 {
+		suggestion.Reasons = append(suggestion.Reasons, ReasonQuickWin)
+	}
+	total += effortWeight * 60 / float64(60+minutes)
+
+	suggestion.Score = math.Round(total*10) / 10
+	return suggestion
+}
@@ -0,0 +1,82 @@
+package agenda
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestSuggest(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := func(d int) *time.Time {
+		t := time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	yesterday, longAgo := now.AddDate(0, 0, -1), now.AddDate(0, 0, -40)
+	tasks := []models.Task{
+		{ID: 1, Title: "期限切れ", DueDate: day(8), CreatedAt: &yesterday},
+		{ID: 2, Title: "今日が期限", DueDate: day(10), Priority: models.PriorityHigh, CreatedAt: &longAgo, EstimateMinutes: 15},
+		{ID: 3, Title: "来週が期限", DueDate: day(17), Priority: models.PriorityLow, EstimateMinutes: 120},
+		{ID: 4, Title: "完了済み", Completed: true, DueDate: day(1), Priority: models.PriorityHigh},
+		{ID: 5, Title: "明後日が期限", DueDate: day(12), Priority: models.PriorityMedium},
+		{ID: 6, Title: "いつか"},
+	}
+
+	got := Suggest(tasks, now, time.UTC, 10)
+	type result struct {
+		id      int
+		score   float64
+		reasons []string
+	}
+	want := []result{
+		{2, 93, []string{ReasonDueToday, ReasonHighPriority, ReasonOld, ReasonQuickWin}},
+		{1, 60, []string{ReasonOverdue}},
+		{5, 52.9, []string{ReasonDueSoon}},
+		{3, 27, []string{}},
+		{6, 19.5, []string{}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d suggestions, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Task.ID != w.id || got[i].Score != w.score || !reflect.DeepEqual(got[i].Reasons, w.reasons) {
+			t.Errorf("Suggestion %d: expected %+v, got id %d score %v reasons %v", i, w, got[i].Task.ID, got[i].Score, got[i].Reasons)
+		}
+	}
+
+	if got := Suggest(tasks, now, time.UTC, 2); len(got) != 2 || got[1].Task.ID != 1 {
+		t.Errorf("Expected the top 2 suggestions, got %+v", got)
+	}
+	// 東京ではすでに 3/11 のため、タスク 2 は期限切れになります
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	if got := Suggest(tasks, now.Add(13*time.Hour), tokyo, 1); got[0].Reasons[0] != ReasonOverdue {
+		t.Errorf("Expected the day to follow the time zone, got %v", got[0].Reasons)
+	}
+	if got := Suggest(nil, now, time.UTC, 5); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list, got %#v", got)
+	}
+}
+
+func TestSuggestTiesByID(t *testing.T) {
+	got := Suggest([]models.Task{{ID: 3}, {ID: 1}, {ID: 2}}, time.Now(), time.UTC, 5)
+	if got[0].Task.ID != 1 || got[1].Task.ID != 2 || got[2].Task.ID != 3 {
+		t.Errorf("Expected ties in ID order, got %+v", got)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	if limit, err := ParseLimit(""); err != nil || limit != DefaultSuggestions {
+		t.Errorf("Expected the default limit, got %d, %v", limit, err)
+	}
+	if limit, err := ParseLimit("12"); err != nil || limit != 12 {
+		t.Errorf("Expected 12, got %d, %v", limit, err)
+	}
+	for _, s := range []string{"0", "51", "ten"} {
+		if _, err := ParseLimit(s); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("ParseLimit(%q): expected a validation error, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	"todo-app/models"
+)
+
+// maxBurndownDays は1回に集計できる日数の上限です
+const maxBurndownDays = 366
+
+// defaultBurndownDays は期間を省略したときに今日までさかのぼる日数です（2週間のスプリント）
+const defaultBurndownDays = 14
+
+// BurndownPoint は1日の終わりの時点で残っている未完了のタスク数です
+// Remaining はまだ来ていない日には null になります
+type BurndownPoint struct {
+	Date      string `json:"date"`
+	Remaining *int   `json:"remaining"`
+}
+
+// Burndown は期間内の未完了タスク数の推移です
+type Burndown struct {
+	TimeZone string          `json:"timezone"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Days     []BurndownPoint `json:"days"`
+}
+
+// ParsePeriod は "2006-01-02" 形式の最初と最後の日を loc の日付として読み取ります
+// 省略した場合は今日までの2週間にします。逆順や1年を超える期間は ErrValidation を返します
+func ParsePeriod(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	today := BucketDay.start(now.In(loc))
+	end := today
+	if to != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date %q (YYYY-MM-DD)", models.ErrValidation, to)
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, 1-defaultBurndownDays)
+	if from != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date %q (YYYY-MM-DD)", models.ErrValidation, from)
+		}
+		start = parsed
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from %s is after to %s", models.ErrValidation, from, to)
+	}
+	if start.AddDate(0, 0, maxBurndownDays).Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period is longer than %d days", models.ErrValidation, maxBurndownDays)
+	}
+	return start, end, nil
+}
+
+// CountBurndown は from から to までの各日の終わりに残っている未完了のタスク数を数えます
+// from と to は ParsePeriod で読み取った loc の日付で、now より後の日は数えません
+// 作成日時を記録していないタスクや、削除したタスクは数えません
+func CountBurndown(tasks []models.Task, now time.Time, from, to time.Time) Burndown {
+	loc := from.Location()
+	result := Burndown{
+		TimeZone: loc.String(),
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Days:     []BurndownPoint{},
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		point := BurndownPoint{Date: day.Format("2006-01-02")}
+		end := day.AddDate(0, 0, 1)
+		if !day.After(now) {
+			remaining := 0
+			for _, task := range tasks {
+				if isOpenAt(task, end) {
+					remaining++
+				}
+			}
+			point.Remaining = &remaining
+		}
+		result.Days = append(result.Days, point)
+	}
+	return result
+}
+
+// isOpenAt は t の直前の時点でタスクが作成済みかつ未完了だったかを返します
+func isOpenAt(task models.Task, t time.Time) bool {
+	if task.CreatedAt == nil || !task.CreatedAt.Before(t) {
+		return false
+	}
+	if !task.Completed {
+		return true
+	}
+	return task.CompletedAt != nil && !task.CompletedAt.Before(t)
+}
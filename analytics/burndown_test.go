@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestCountBurndown(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Open from the start", CreatedAt: at(2025, 3, 1, 9)},
+		{ID: 2, Title: "Done on the 3rd", Completed: true, CreatedAt: at(2025, 3, 1, 9), CompletedAt: at(2025, 3, 3, 12)},
+		{ID: 3, Title: "Added on the 2nd", CreatedAt: at(2025, 3, 2, 9)},
+		{ID: 4, Title: "No timestamps"},
+		{ID: 5, Title: "Done without time", Completed: true, CreatedAt: at(2025, 3, 1, 9)},
+	}
+	now := time.Date(2025, 3, 3, 18, 0, 0, 0, time.UTC)
+	from, to, err := ParsePeriod("2025-03-01", "2025-03-04", now, time.UTC)
+	if err != nil {
+		t.Fatalf("ParsePeriod failed: %v", err)
+	}
+
+	got := CountBurndown(tasks, now, from, to)
+
+	if got.From != "2025-03-01" || got.To != "2025-03-04" || got.TimeZone != "UTC" || len(got.Days) != 4 {
+		t.Fatalf("Unexpected burndown: %+v", got)
+	}
+	want := []int{2, 3, 2}
+	for i, remaining := range want {
+		if got.Days[i].Remaining == nil || *got.Days[i].Remaining != remaining {
+			t.Errorf("Day %s: expected %d remaining, got %v", got.Days[i].Date, remaining, got.Days[i].Remaining)
+		}
+	}
+	if got.Days[3].Remaining != nil {
+		t.Errorf("Expected no count for a future day, got %d", *got.Days[3].Remaining)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	from, to, err := ParsePeriod("", "", now, time.UTC)
+	if err != nil || from.Format("2006-01-02") != "2025-02-25" || to.Format("2006-01-02") != "2025-03-10" {
+		t.Errorf("Expected the last two weeks by default, got %v to %v (%v)", from, to, err)
+	}
+
+	for _, period := range [][2]string{
+		{"2025-03-10", "2025-03-01"},
+		{"2024-01-01", "2025-03-01"},
+		{"March 1", ""},
+		{"", "2025-13-01"},
+	} {
+		if _, _, err := ParsePeriod(period[0], period[1], now, time.UTC); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %v, got %v", period, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package analytics はタスクの日時や作業時間から、作成数と完了数・未完了のタスク数の推移や見積もりと実績の差を集計します
+package analytics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"todo-app/models"
+)
+
+// Bucket は集計の単位です
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+// ParseBucket は集計の単位を読み取ります。空なら日ごとにします
+func ParseBucket(s string) (Bucket, error) {
+	switch bucket := Bucket(s); bucket {
+	case "":
+		return BucketDay, nil
+	case BucketDay, BucketWeek, BucketMonth:
+		return bucket, nil
+	default:
+		return "", fmt.Errorf("%w: unknown bucket %q (day, week or month)", models.ErrValidation, s)
+	}
+}
+
+// start は t を含む集計単位の最初の日（週は月曜日）を返します
+func (b Bucket) start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch b {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// next は集計単位の最初の日 t の次の単位の最初の日を返します
+func (b Bucket) next(t time.Time) time.Time {
+	switch b {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Range は今日までさかのぼって集計する期間の長さです（30d・12w・6m のように日・週・月で指定します）
+type Range struct {
+	count int
+	unit  byte
+}
+
+// maxRange は単位ごとに指定できる期間の上限です（およそ1年）
+var maxRange = map[byte]int{'d': 366, 'w': 53, 'm': 12}
+
+// ParseRange は "30d" のような期間を読み取ります。空なら30日にします
+func ParseRange(s string) (Range, error) {
+	if s == "" {
+		return Range{count: 30, unit: 'd'}, nil
+	}
+	unit := s[len(s)-1]
+	limit, ok := maxRange[unit]
+	count, err := strconv.Atoi(s[:len(s)-1])
+	if !ok || err != nil || count < 1 || count > limit {
+		return Range{}, fmt.Errorf("%w: invalid range %q (for example 30d, 12w or 6m, up to one year)", models.ErrValidation, s)
+	}
+	return Range{count: count, unit: unit}, nil
+}
+
+// String は "30d" の形式で期間を返します
+func (r Range) String() string {
+	return strconv.Itoa(r.count) + string(r.unit)
+}
+
+// from は today を最後の日とする期間の最初の日を返します
+func (r Range) from(today time.Time) time.Time {
+	switch r.unit {
+	case 'w':
+		return today.AddDate(0, 0, 1-7*r.count)
+	case 'm':
+		return today.AddDate(0, -r.count, 1)
+	default:
+		return today.AddDate(0, 0, 1-r.count)
+	}
+}
+
+// Point は1つの集計単位での作成数と完了数です
+// Start: 集計単位の最初の日（YYYY-MM-DD）
+type Point struct {
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// Completions は期間内の作成数と完了数の推移です
+// From / To: 集計した期間の最初と最後の日（YYYY-MM-DD）
+type Completions struct {
+	Range    string  `json:"range"`
+	Bucket   Bucket  `json:"bucket"`
+	TimeZone string  `json:"timezone"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Buckets  []Point `json:"buckets"`
+}
+
+// CountCompletions は now の時点から r だけさかのぼった期間の作成数と完了数を bucket ごとに数えます
+// 日付の区切りはタイムゾーン loc で決め、期間の外の日は数えません。最初の集計単位は週や月の途中でも期間の最初の日から始めます
+// （2w を水曜日に集計すると、最初の単位は2週間前の木曜日から日曜日までです）
+// 作成日時を記録していないタスクや、削除したタスクは数えません
+func CountCompletions(tasks []models.Task, now time.Time, loc *time.Location, r Range, bucket Bucket) Completions {
+	now = now.In(loc)
+	today := BucketDay.start(now)
+	from := r.from(today)
+
+	result := Completions{
+		Range:    r.String(),
+		Bucket:   bucket,
+		TimeZone: loc.String(),
+		From:     from.Format("2006-01-02"),
+		To:       today.Format("2006-01-02"),
+		Buckets:  []Point{},
+	}
+	index := make(map[string]int)
+	for start := from; !start.After(today); start = bucket.next(bucket.start(start)) {
+		key := start.Format("2006-01-02")
+		index[key] = len(result.Buckets)
+		result.Buckets = append(result.Buckets, Point{Start: key})
+	}
+
+	find := func(t *time.Time) (int, bool) {
+		if t == nil {
+			return 0, false
+		}
+		day := BucketDay.start(t.In(loc))
+		if day.Before(from) || day.After(today) {
+			return 0, false
+		}
+		start := bucket.start(day)
+		if start.Before(from) {
+			start = from
+		}
+		i, ok := index[start.Format("2006-01-02")]
+		return i, ok
+	}
+	for _, task := range tasks {
+		if i, ok := find(task.CreatedAt); ok {
+			result.Buckets[i].Created++
+		}
+		if i, ok := find(task.CompletedAt); ok && task.Completed {
+			result.Buckets[i].Completed++
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func at(year int, month time.Month, day, hour int) *time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestCountCompletionsByDay(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Created and done", Completed: true, CreatedAt: at(2025, 3, 8, 9), CompletedAt: at(2025, 3, 10, 9)},
+		{ID: 2, Title: "Created", CreatedAt: at(2025, 3, 8, 12)},
+		{ID: 3, Title: "Too old", CreatedAt: at(2025, 3, 1, 9)},
+		{ID: 4, Title: "Old task done recently", Completed: true, CreatedAt: at(2025, 2, 1, 9), CompletedAt: at(2025, 3, 9, 9)},
+		{ID: 5, Title: "No timestamps", Completed: true},
+		{ID: 6, Title: "Reopened", CreatedAt: at(2025, 3, 10, 8), CompletedAt: at(2025, 3, 10, 9)},
+	}
+	now := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	r, _ := ParseRange("3d")
+
+	got := CountCompletions(tasks, now, time.UTC, r, BucketDay)
+
+	if got.Range != "3d" || got.Bucket != BucketDay || got.TimeZone != "UTC" || got.From != "2025-03-08" || got.To != "2025-03-10" {
+		t.Errorf("Unexpected header: %+v", got)
+	}
+	want := []Point{
+		{Start: "2025-03-08", Created: 2, Completed: 0},
+		{Start: "2025-03-09", Created: 0, Completed: 1},
+		{Start: "2025-03-10", Created: 1, Completed: 1},
+	}
+	if len(got.Buckets) != len(want) {
+		t.Fatalf("Expected %d buckets, got %+v", len(want), got.Buckets)
+	}
+	for i := range want {
+		if got.Buckets[i] != want[i] {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want[i], got.Buckets[i])
+		}
+	}
+}
+
+func TestCountCompletionsByWeekAndMonth(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Monday", CreatedAt: at(2025, 3, 3, 9)},
+		{ID: 2, Title: "Sunday", CreatedAt: at(2025, 3, 9, 9)},
+		{ID: 3, Title: "Next Monday", CreatedAt: at(2025, 3, 10, 9)},
+		{ID: 4, Title: "February", CreatedAt: at(2025, 2, 14, 9)},
+		{ID: 5, Title: "Same week, before the range", CreatedAt: at(2025, 2, 25, 9)},
+		{ID: 6, Title: "Same month, before the range", CreatedAt: at(2025, 2, 5, 9)},
+	}
+	now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+
+	// 2週間は 2/27（木）からのため、最初の単位は月曜日の 2/24 ではなく 2/27 から始まり、2/25 のタスクは数えません
+	weeks, _ := ParseRange("2w")
+	got := CountCompletions(tasks, now, time.UTC, weeks, BucketWeek)
+	if got.From != "2025-02-27" || len(got.Buckets) != 3 || got.Buckets[0] != (Point{Start: "2025-02-27"}) ||
+		got.Buckets[1] != (Point{Start: "2025-03-03", Created: 2}) ||
+		got.Buckets[2] != (Point{Start: "2025-03-10", Created: 1}) {
+		t.Errorf("Unexpected weekly buckets: %+v", got.Buckets)
+	}
+
+	// 1か月は 2/13 からのため、2/5 のタスクは数えず、2/14 と 2/25 のタスクを数えます
+	months, _ := ParseRange("1m")
+	got = CountCompletions(tasks, now, time.UTC, months, BucketMonth)
+	if len(got.Buckets) != 2 ||
+		got.Buckets[0] != (Point{Start: "2025-02-13", Created: 2}) ||
+		got.Buckets[1] != (Point{Start: "2025-03-01", Created: 3}) {
+		t.Errorf("Unexpected monthly buckets: %+v", got.Buckets)
+	}
+}
+
+func TestCountCompletionsUsesTimeZone(t *testing.T) {
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	// UTC では10日の20時ですが、東京ではすでに11日です
+	tasks := []models.Task{{ID: 1, Title: "Late", CreatedAt: at(2025, 3, 10, 20)}}
+	now := time.Date(2025, 3, 10, 22, 0, 0, 0, time.UTC)
+	r, _ := ParseRange("2d")
+
+	got := CountCompletions(tasks, now, tokyo, r, BucketDay)
+	if got.To != "2025-03-11" || got.Buckets[1] != (Point{Start: "2025-03-11", Created: 1}) {
+		t.Errorf("Expected the task to count on the 11th in Tokyo, got %+v", got)
+	}
+}
+
+func TestParseRangeAndBucket(t *testing.T) {
+	for _, valid := range []string{"", "1d", "30d", "366d", "12w", "12m"} {
+		if _, err := ParseRange(valid); err != nil {
+			t.Errorf("Expected %q to be a valid range, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"d", "0d", "-1d", "367d", "54w", "13m", "30", "30y", "1.5d"} {
+		if _, err := ParseRange(invalid); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for range %q, got %v", invalid, err)
+		}
+	}
+	if r, _ := ParseRange(""); r.String() != "30d" {
+		t.Errorf("Expected default range 30d, got %s", r)
+	}
+
+	if bucket, _ := ParseBucket(""); bucket != BucketDay {
+		t.Errorf("Expected default bucket day, got %s", bucket)
+	}
+	if bucket, err := ParseBucket("week"); err != nil || bucket != BucketWeek {
+		t.Errorf("Expected week bucket, got %s (%v)", bucket, err)
+	}
+	if _, err := ParseBucket("year"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected ErrValidation for unknown bucket, got %v", err)
+	}
+}
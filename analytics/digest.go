@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"todo-app/models"
+)
+
+// StaleDigest は放置されているタスクの一覧を定期的に URL へ JSON で送ります
+// 送信する内容は {"generated_at": ..., "older_than_days": 30, "tasks": [StaleTask...]} です
+type StaleDigest struct {
+	URL        string
+	OlderThan  time.Duration
+	HTTPClient *http.Client
+	now        func() time.Time
+}
+
+// NewStaleDigest は url へ送る StaleDigest を作成します
+// httpClient が nil の場合はタイムアウト10秒のクライアントを使います
+func NewStaleDigest(url string, olderThan time.Duration, httpClient *http.Client) *StaleDigest {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &StaleDigest{URL: url, OlderThan: olderThan, HTTPClient: httpClient, now: time.Now}
+}
+
+// Send は store の放置されているタスクを送り、送った件数を返します。該当するタスクがなければ送りません
+func (d *StaleDigest) Send(ctx context.Context, store models.TaskStore) (int, error) {
+	now := d.now()
+	stale := FindStale(store.GetTasks(ctx), now, d.OlderThan)
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"generated_at":    now,
+		"older_than_days": int(d.OlderThan / (24 * time.Hour)),
+		"tasks":           stale,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("stale digest: unexpected status %s", resp.Status)
+	}
+	return len(stale), nil
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに Send を呼び出します
+func (d *StaleDigest) Run(ctx context.Context, store models.TaskStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := d.Send(ctx, store); err != nil {
+				slog.Error("stale digest failed", "err", err)
+			} else if n > 0 {
+				slog.Info("stale digest sent", "tasks", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"todo-app/models"
+)
+
+// accurateMargin は見積もりどおりとみなす実績のずれの割合です（±25%）
+const accurateMargin = 0.25
+
+// EstimateGroup はグループごとの見積もりと実績の集計です
+// Tasks: 見積もりのあるタスクの数
+// Completed: そのうち完了したタスクの数
+// EstimatedSeconds / TrackedSeconds: 見積もりのあるタスクの見積もり時間と記録した作業時間の合計
+// Ratio: 完了したタスクの実績 / 見積もり（1 より大きければ見積もりより時間がかかっています。完了したタスクがなければ 0）
+// Accurate: 完了したタスクのうち、実績が見積もりの ±25% に収まった数
+// Accuracy: Accurate / Completed（完了したタスクがなければ 0）
+type EstimateGroup struct {
+	Group            string  `json:"group"`
+	Tasks            int     `json:"tasks"`
+	Completed        int     `json:"completed"`
+	EstimatedSeconds int64   `json:"estimated_seconds"`
+	TrackedSeconds   int64   `json:"tracked_seconds"`
+	Ratio            float64 `json:"ratio"`
+	Accurate         int     `json:"accurate"`
+	Accuracy         float64 `json:"accuracy"`
+
+	completedEstimated int64
+	completedTracked   int64
+}
+
+// EstimateReport は見積もりと実績の比較です
+// GroupBy: グループ分けに使った項目
+// Total: すべてのタスクの集計
+type EstimateReport struct {
+	GroupBy string          `json:"group_by"`
+	Groups  []EstimateGroup `json:"groups"`
+	Total   EstimateGroup   `json:"total"`
+}
+
+// ParseGroupBy はグループ分けの項目を読み取ります。いまは優先度（priority）だけで、空でも優先度にします
+func ParseGroupBy(s string) (string, error) {
+	if s == "" || s == "priority" {
+		return "priority", nil
+	}
+	return "", fmt.Errorf("%w: unknown group %q (priority)", models.ErrValidation, s)
+}
+
+// CompareEstimates は見積もりのあるタスクを優先度ごとにまとめ、見積もりと記録した作業時間を比べます
+// 優先度のないタスクは "none" のグループに入ります
+func CompareEstimates(tasks []models.Task) EstimateReport {
+	groups := make(map[string]*EstimateGroup)
+	total := EstimateGroup{Group: "total"}
+	for _, task := range tasks {
+		if task.EstimateMinutes <= 0 {
+			continue
+		}
+		name := string(task.Priority)
+		if name == "" {
+			name = "none"
+		}
+		group, ok := groups[name]
+		if !ok {
+			group = &EstimateGroup{Group: name}
+			groups[name] = group
+		}
+		group.add(task)
+		total.add(task)
+	}
+
+	report := EstimateReport{GroupBy: "priority", Groups: []EstimateGroup{}}
+	for _, group := range groups {
+		group.finish()
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return priorityOrder(report.Groups[i].Group) < priorityOrder(report.Groups[j].Group)
+	})
+	total.finish()
+	report.Total = total
+	return report
+}
+
+// add はタスクを集計に加えます
+func (g *EstimateGroup) add(task models.Task) {
+	estimated := int64(task.EstimateMinutes) * 60
+	g.Tasks++
+	g.EstimatedSeconds += estimated
+	g.TrackedSeconds += task.TrackedSeconds
+	if !task.Completed {
+		return
+	}
+	g.Completed++
+	g.completedEstimated += estimated
+	g.completedTracked += task.TrackedSeconds
+	diff := float64(task.TrackedSeconds - estimated)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff <= float64(estimated)*accurateMargin {
+		g.Accurate++
+	}
+}
+
+// finish は比率を計算します
+func (g *EstimateGroup) finish() {
+	if g.Completed == 0 {
+		return
+	}
+	g.Ratio = float64(g.completedTracked) / float64(g.completedEstimated)
+	g.Accuracy = float64(g.Accurate) / float64(g.Completed)
+}
+
+// priorityOrder は優先度の高い順にグループを並べるための順位です
+func priorityOrder(name string) int {
+	switch models.Priority(name) {
+	case models.PriorityHigh:
+		return 0
+	case models.PriorityMedium:
+		return 1
+	case models.PriorityLow:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// WriteCSV はグループごとの集計と合計の行を CSV で書き出します
+func (report EstimateReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{report.GroupBy, "tasks", "completed", "estimated_seconds", "tracked_seconds", "ratio", "accurate", "accuracy"})
+	rows := append(append([]EstimateGroup{}, report.Groups...), report.Total)
+	for _, group := range rows {
+		writer.Write([]string{
+			group.Group,
+			strconv.Itoa(group.Tasks),
+			strconv.Itoa(group.Completed),
+			strconv.FormatInt(group.EstimatedSeconds, 10),
+			strconv.FormatInt(group.TrackedSeconds, 10),
+			strconv.FormatFloat(group.Ratio, 'f', 2, 64),
+			strconv.Itoa(group.Accurate),
+			strconv.FormatFloat(group.Accuracy, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
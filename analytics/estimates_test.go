@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestCompareEstimates(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "On target", Completed: true, Priority: models.PriorityHigh, EstimateMinutes: 60, TrackedSeconds: 70 * 60},
+		{ID: 2, Title: "Overrun", Completed: true, Priority: models.PriorityHigh, EstimateMinutes: 30, TrackedSeconds: 60 * 60},
+		{ID: 3, Title: "In progress", Priority: models.PriorityHigh, EstimateMinutes: 120, TrackedSeconds: 30 * 60},
+		{ID: 4, Title: "No priority", Completed: true, EstimateMinutes: 10, TrackedSeconds: 10 * 60},
+		{ID: 5, Title: "No estimate", Completed: true, Priority: models.PriorityLow, TrackedSeconds: 600},
+	}
+
+	report := CompareEstimates(tasks)
+
+	if report.GroupBy != "priority" || len(report.Groups) != 2 {
+		t.Fatalf("Unexpected groups: %+v", report.Groups)
+	}
+	high := report.Groups[0]
+	if high.Group != "high" || high.Tasks != 3 || high.Completed != 2 || high.EstimatedSeconds != 210*60 || high.TrackedSeconds != 160*60 {
+		t.Errorf("Unexpected high group: %+v", high)
+	}
+	// 完了した2件は見積もり90分に対して実績130分、見積もりどおりは1件です
+	if high.Accurate != 1 || high.Accuracy != 0.5 || high.Ratio < 1.44 || high.Ratio > 1.45 {
+		t.Errorf("Unexpected accuracy for the high group: %+v", high)
+	}
+	if none := report.Groups[1]; none.Group != "none" || none.Accuracy != 1 || none.Ratio != 1 {
+		t.Errorf("Unexpected group without priority: %+v", none)
+	}
+	if report.Total.Tasks != 4 || report.Total.Completed != 3 || report.Total.Accurate != 2 {
+		t.Errorf("Unexpected total: %+v", report.Total)
+	}
+}
+
+func TestCompareEstimatesWithoutCompletedTasks(t *testing.T) {
+	report := CompareEstimates([]models.Task{{ID: 1, Title: "Open", EstimateMinutes: 30}})
+	if report.Total.Ratio != 0 || report.Total.Accuracy != 0 || report.Total.Tasks != 1 {
+		t.Errorf("Expected zero ratios without completed tasks, got %+v", report.Total)
+	}
+}
+
+func TestEstimateReportWriteCSV(t *testing.T) {
+	report := CompareEstimates([]models.Task{
+		{ID: 1, Title: "Done", Completed: true, Priority: models.PriorityLow, EstimateMinutes: 60, TrackedSeconds: 45 * 60},
+	})
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"priority,tasks,completed,estimated_seconds,tracked_seconds,ratio,accurate,accuracy",
+		"low,1,1,3600,2700,0.75,1,1.00",
+		"total,1,1,3600,2700,0.75,1,1.00",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	if group, err := ParseGroupBy(""); err != nil || group != "priority" {
+		t.Errorf("Expected priority by default, got %q (%v)", group, err)
+	}
+	if _, err := ParseGroupBy("tag"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown group, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"todo-app/models"
+)
+
+// heatmapLevels は色の濃さの段階の数です（0 は完了なし、1〜4 は多いほど濃くします）
+const heatmapLevels = 4
+
+// HeatmapDay は1日に完了したタスクの数です
+// Level: その年で最も多い日を 4 とした色の濃さ（0〜4）
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+	Level int    `json:"level"`
+}
+
+// Heatmap は1年の日ごとの完了数です（GitHub のコントリビューションのグラフのように描画します）
+// Total / Max: 年間の完了数と、1日の完了数の最大
+// Days: 1月1日から12月31日までのすべての日
+type Heatmap struct {
+	Year     int          `json:"year"`
+	TimeZone string       `json:"timezone"`
+	Total    int          `json:"total"`
+	Max      int          `json:"max"`
+	Days     []HeatmapDay `json:"days"`
+}
+
+// ParseYear は "2025" のような西暦の年を読み取ります。空なら now の loc での年にします
+func ParseYear(s string, now time.Time, loc *time.Location) (int, error) {
+	if s == "" {
+		return now.In(loc).Year(), nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil || len(s) != 4 || year < 1970 {
+		return 0, fmt.Errorf("%w: invalid year %q (for example 2025)", models.ErrValidation, s)
+	}
+	return year, nil
+}
+
+// CountHeatmap は year の年に完了したタスクの数を日ごとに数えます
+// 日付の区切りはタイムゾーン loc で決めます。完了日時を記録していないタスクや、未完了に戻したタスクは数えません
+func CountHeatmap(tasks []models.Task, year int, loc *time.Location) Heatmap {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	next := first.AddDate(1, 0, 0)
+	heatmap := Heatmap{Year: year, TimeZone: loc.String(), Days: []HeatmapDay{}}
+	index := make(map[string]int)
+	for day := first; day.Before(next); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		index[key] = len(heatmap.Days)
+		heatmap.Days = append(heatmap.Days, HeatmapDay{Date: key})
+	}
+
+	for _, task := range tasks {
+		if !task.Completed || task.CompletedAt == nil {
+			continue
+		}
+		if i, ok := index[task.CompletedAt.In(loc).Format("2006-01-02")]; ok {
+			heatmap.Days[i].Count++
+			heatmap.Total++
+			if heatmap.Days[i].Count > heatmap.Max {
+				heatmap.Max = heatmap.Days[i].Count
+			}
+		}
+	}
+	if heatmap.Max > 0 {
+		for i, day := range heatmap.Days {
+			// 1件でも完了した日は 1 以上にし、最も多い日を 4 にします
+			heatmap.Days[i].Level = (day.Count*heatmapLevels + heatmap.Max - 1) / heatmap.Max
+		}
+	}
+	return heatmap
+}
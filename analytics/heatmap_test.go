@@ -0,0 +1,60 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestParseYear(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if year, err := ParseYear("2025", time.Now(), time.UTC); err != nil || year != 2025 {
+		t.Errorf("ParseYear = %d, %v", year, err)
+	}
+	// 省略すると、そのタイムゾーンでの今年です（UTC の 2024-12-31 18:00 は東京では 2025年）
+	if year, _ := ParseYear("", time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC), tokyo); year != 2025 {
+		t.Errorf("Expected the current year in Tokyo, got %d", year)
+	}
+	for _, invalid := range []string{"25", "1969", "20255", "+2025", "year"} {
+		if _, err := ParseYear(invalid, time.Now(), time.UTC); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestCountHeatmap(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	tasks := []models.Task{
+		{ID: 1, Completed: true, CompletedAt: at(2024, 3, 1, 1)},
+		{ID: 2, Completed: true, CompletedAt: at(2024, 3, 1, 2)},
+		{ID: 3, Completed: true, CompletedAt: at(2024, 3, 1, 3)},
+		{ID: 4, Completed: true, CompletedAt: at(2024, 3, 1, 4)},
+		{ID: 5, Completed: true, CompletedAt: at(2024, 3, 2, 1)},
+		// UTC の 2023-12-31 20:00 は東京では 2024年1月1日です
+		{ID: 6, Completed: true, CompletedAt: at(2023, 12, 31, 20)},
+		{ID: 7, Completed: true, CompletedAt: at(2025, 1, 1, 1)},
+		{ID: 8, CompletedAt: at(2024, 3, 1, 5)},
+		{ID: 9, Completed: true},
+	}
+
+	got := CountHeatmap(tasks, 2024, tokyo)
+	if got.Year != 2024 || got.TimeZone != "Asia/Tokyo" || got.Total != 6 || got.Max != 4 || len(got.Days) != 366 {
+		t.Fatalf("Unexpected header: %+v", got)
+	}
+	want := map[int]HeatmapDay{
+		0:  {Date: "2024-01-01", Count: 1, Level: 1},
+		1:  {Date: "2024-01-02", Count: 0, Level: 0},
+		60: {Date: "2024-03-01", Count: 4, Level: 4},
+		61: {Date: "2024-03-02", Count: 1, Level: 1},
+	}
+	for i, day := range want {
+		if got.Days[i] != day {
+			t.Errorf("Day %d: expected %+v, got %+v", i, day, got.Days[i])
+		}
+	}
+
+	if empty := CountHeatmap(nil, 2025, time.UTC); len(empty.Days) != 365 || empty.Max != 0 || empty.Days[0].Level != 0 {
+		t.Errorf("Unexpected empty heatmap: %+v", empty.Days[0])
+	}
+}
@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"todo-app/models"
+)
+
+// maxStaleDays は放置された期間として指定できる最大の日数です（約10年）
+const maxStaleDays = 3650
+
+// ParseAge は "30d" や "2w" のような日・週単位の期間を読み取ります。空なら30日にします
+func ParseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 30 * 24 * time.Hour, nil
+	}
+	unit := s[len(s)-1]
+	count, err := strconv.Atoi(s[:len(s)-1])
+	days := count
+	if unit == 'w' {
+		days = count * 7
+	}
+	if err != nil || (unit != 'd' && unit != 'w') || count < 1 || count > maxStaleDays || days > maxStaleDays {
+		return 0, fmt.Errorf("%w: invalid age %q (for example 30d or 2w)", models.ErrValidation, s)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// StaleTask は放置されている未完了のタスクです
+// LastTouched: 最後に作成・変更した日時
+// AgeDays: LastTouched から経過した日数（切り捨て）
+type StaleTask struct {
+	Task        models.Task `json:"task"`
+	LastTouched time.Time   `json:"last_touched"`
+	AgeDays     int         `json:"age_days"`
+}
+
+// FindStale は now の時点で olderThan 以上変更されていない未完了のタスクを、放置された期間の長い順に返します
+// 変更日時を記録していないタスクは作成日時を使い、どちらもなければ対象にしません
+func FindStale(tasks []models.Task, now time.Time, olderThan time.Duration) []StaleTask {
+	stale := []StaleTask{}
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		touched := task.UpdatedAt
+		if touched == nil {
+			touched = task.CreatedAt
+		}
+		if touched == nil {
+			continue
+		}
+		age := now.Sub(*touched)
+		if age < olderThan {
+			continue
+		}
+		stale = append(stale, StaleTask{
+			Task:        task,
+			LastTouched: *touched,
+			AgeDays:     int(age / (24 * time.Hour)),
+		})
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].LastTouched.Before(stale[j].LastTouched)
+	})
+	return stale
+}
@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+func TestFindStale(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Touched recently", CreatedAt: at(2025, 1, 1, 9), UpdatedAt: at(2025, 3, 5, 9)},
+		{ID: 2, Title: "Forgotten", CreatedAt: at(2025, 1, 1, 9), UpdatedAt: at(2025, 2, 1, 9)},
+		{ID: 3, Title: "Older and forgotten", CreatedAt: at(2024, 12, 1, 9)},
+		{ID: 4, Title: "Done long ago", Completed: true, CreatedAt: at(2024, 1, 1, 9), UpdatedAt: at(2024, 1, 2, 9)},
+		{ID: 5, Title: "No timestamps"},
+	}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	stale := FindStale(tasks, now, 30*24*time.Hour)
+
+	if len(stale) != 2 || stale[0].Task.ID != 3 || stale[1].Task.ID != 2 {
+		t.Fatalf("Expected tasks 3 and 2, got %+v", stale)
+	}
+	if stale[1].AgeDays != 37 || !stale[1].LastTouched.Equal(*at(2025, 2, 1, 9)) {
+		t.Errorf("Unexpected age for task 2: %+v", stale[1])
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"":    30 * 24 * time.Hour,
+		"1d":  24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"90d": 90 * 24 * time.Hour,
+	}
+	for s, want := range testCases {
+		if got, err := ParseAge(s); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; expected %v", s, got, err, want)
+		}
+	}
+	for _, invalid := range []string{"d", "0d", "-3d", "30", "1m", "3651d", "999999999999999999w", "x7d"} {
+		if _, err := ParseAge(invalid); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestStaleDigestSend(t *testing.T) {
+	var received struct {
+		OlderThanDays int         `json:"older_than_days"`
+		Tasks         []StaleTask `json:"tasks"`
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	store := storetest.NewFake(models.Task{ID: 1, Title: "Forgotten", CreatedAt: at(2025, 1, 1, 9)})
+	digest := NewStaleDigest(server.URL, 7*24*time.Hour, nil)
+	digest.now = func() time.Time { return time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC) }
+
+	n, err := digest.Send(context.Background(), store)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 task to be sent, got %d (%v)", n, err)
+	}
+	if received.OlderThanDays != 7 || len(received.Tasks) != 1 || received.Tasks[0].Task.Title != "Forgotten" {
+		t.Errorf("Unexpected digest: %+v", received)
+	}
+
+	// 放置されているタスクがなければ送りません
+	digest.now = func() time.Time { return time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC) }
+	if n, err := digest.Send(context.Background(), store); err != nil || n != 0 || requests != 1 {
+		t.Errorf("Expected nothing to be sent, got %d (%v) after %d requests", n, err, requests)
+	}
+}
+
+func TestStaleDigestSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := storetest.NewFake(models.Task{ID: 1, Title: "Forgotten", CreatedAt: at(2025, 1, 1, 9)})
+	if _, err := NewStaleDigest(server.URL, time.Hour, nil).Send(context.Background(), store); err == nil {
+		t.Error("Expected an error for a failed delivery")
+	}
+}
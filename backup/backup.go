@@ -0,0 +1,237 @@
+// Package backup はタスクのスナップショットを暗号化して外部の保存先へ定期的にアップロードします
+package backup
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// バックアップファイル名の接頭辞と拡張子
+const (
+	filePrefix = "todo-backup-"
+	fileSuffix = ".json.enc"
+	timeLayout = "20060102T150405Z"
+)
+
+// ErrInvalidName はバックアップとして扱えないファイル名を指定したことを表します
+var ErrInvalidName = errors.New("backup: invalid backup name")
+
+// Destination はバックアップのアップロード先です
+type Destination interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Snapshot はある時点のタスク一覧です
+type Snapshot struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Tasks     []models.Task `json:"tasks"`
+}
+
+// ParseKey は base64 でエンコードされた 32 バイトの暗号鍵を読み込みます
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("backup: key must be base64: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("backup: key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// Encrypt は AES-256-GCM でデータを暗号化します（先頭にノンスを付けます）
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt は Encrypt で暗号化したデータを復号します
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("backup: ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.New("backup: failed to decrypt (wrong key or corrupted data)")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Manager はバックアップの作成・世代管理・復元を行います
+// Retention: 保持するバックアップの数（0 以下なら削除しない）
+type Manager struct {
+	store       models.TaskStore
+	destination Destination
+	key         []byte
+	Retention   int
+
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// NewManager は Manager を作成します
+func NewManager(store models.TaskStore, destination Destination, key []byte, retention int) *Manager {
+	return &Manager{
+		store:       store,
+		destination: destination,
+		key:         key,
+		Retention:   retention,
+		now:         time.Now,
+	}
+}
+
+// Backup は現在のタスクを暗号化してアップロードし、古い世代を削除します
+// 作成したバックアップの名前を返します
+func (m *Manager) Backup(ctx context.Context) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	createdAt := m.now().UTC()
+	data, err := json.Marshal(Snapshot{Version: 1, CreatedAt: createdAt, Tasks: m.store.GetTasks(ctx)})
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := Encrypt(m.key, data)
+	if err != nil {
+		return "", err
+	}
+
+	name := filePrefix + createdAt.Format(timeLayout) + fileSuffix
+	if err := m.destination.Put(ctx, name, encrypted); err != nil {
+		return "", err
+	}
+	return name, m.prune(ctx)
+}
+
+// List はアップロード済みのバックアップを新しい順に返します
+func (m *Manager) List(ctx context.Context) ([]string, error) {
+	names, err := m.destination.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if validName(name) {
+			backups = append(backups, name)
+		}
+	}
+	// 名前に UTC の日時が入っているため、文字列の降順がそのまま新しい順になります
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// Restore は指定したバックアップを復号し、タスク一覧をその内容に置き換えます
+func (m *Manager) Restore(ctx context.Context, name string) (Snapshot, error) {
+	if !validName(name) {
+		return Snapshot{}, ErrInvalidName
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	encrypted, err := m.destination.Get(ctx, name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	data, err := Decrypt(m.key, encrypted)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, err
+	}
+
+	// ダウンロード中にキャンセルされていたら、タスクを置き換えずに終えます
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	if err := m.store.ReplaceTasks(ctx, snapshot.Tasks); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// prune は保持数を超えた古いバックアップを削除します
+func (m *Manager) prune(ctx context.Context) error {
+	if m.Retention <= 0 {
+		return nil
+	}
+	backups, err := m.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := m.Retention; i < len(backups); i++ {
+		if err := m.destination.Delete(ctx, backups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run は ctx がキャンセルされるまで interval ごとにバックアップを作成します
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if name, err := m.Backup(ctx); err != nil {
+				slog.Error("backup failed", "err", err)
+			} else {
+				slog.Info("backup uploaded", "name", name)
+			}
+		}
+	}
+}
+
+// validName はこのパッケージが作成したバックアップの名前かどうかを返します
+func validName(name string) bool {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	_, err := time.Parse(timeLayout, stamp)
+	return err == nil
+}
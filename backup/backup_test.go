@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey("BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc="); err != nil {
+		t.Errorf("Expected valid key, got %v", err)
+	}
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("Expected error for short key")
+	}
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("Expected error for invalid base64")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypted, err := Encrypt(testKey, []byte("secret tasks"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if bytes.Contains(encrypted, []byte("secret tasks")) {
+		t.Error("Expected ciphertext not to contain the plaintext")
+	}
+
+	plaintext, err := Decrypt(testKey, encrypted)
+	if err != nil || string(plaintext) != "secret tasks" {
+		t.Errorf("Unexpected decrypt result: %q, %v", plaintext, err)
+	}
+
+	if _, err := Decrypt(bytes.Repeat([]byte{8}, 32), encrypted); err == nil {
+		t.Error("Expected error when decrypting with another key")
+	}
+	if _, err := Decrypt(testKey, []byte("short")); err == nil {
+		t.Error("Expected error for truncated ciphertext")
+	}
+}
+
+func newTestManager(t *testing.T, store models.TaskStore, retention int) (*Manager, *LocalDir) {
+	dir := &LocalDir{Dir: t.TempDir()}
+	manager := NewManager(store, dir, testKey, retention)
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time {
+		clock = clock.Add(time.Hour)
+		return clock
+	}
+	return manager, dir
+}
+
+func TestBackupRetention(t *testing.T) {
+	manager, dir := newTestManager(t, models.NewTodoApp(), 2)
+	ctx := context.Background()
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name, err := manager.Backup(ctx)
+		if err != nil {
+			t.Fatalf("Backup failed: %v", err)
+		}
+		names = append(names, name)
+	}
+	if names[0] != "todo-backup-20250101T010000Z.json.enc" {
+		t.Errorf("Unexpected backup name: %s", names[0])
+	}
+
+	// バックアップ以外のファイルは一覧・削除の対象外
+	dir.Put(ctx, "notes.txt", []byte("keep"))
+
+	backups, err := manager.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(backups) != 2 || backups[0] != names[2] || backups[1] != names[1] {
+		t.Errorf("Expected the two newest backups, got %v", backups)
+	}
+	if _, err := dir.Get(ctx, "notes.txt"); err != nil {
+		t.Error("Expected unrelated file to be kept")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	app := models.NewTodoApp()
+	app.AddTask(ctx, "Buy milk")
+	task, _ := app.AddTask(ctx, "Call mom")
+	app.ToggleTask(ctx, task.ID)
+
+	manager, _ := newTestManager(t, app, 0)
+	name, err := manager.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	app.DeleteTask(ctx, 1)
+	app.AddTask(ctx, "Added later")
+
+	snapshot, err := manager.Restore(ctx, name)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	tasks := app.GetTasks(ctx)
+	if len(snapshot.Tasks) != 2 || len(tasks) != 2 || tasks[0].Title != "Buy milk" || !tasks[1].Completed {
+		t.Errorf("Unexpected tasks after restore: %+v", tasks)
+	}
+	if next, _ := app.AddTask(ctx, "Next"); next.ID != 3 {
+		t.Errorf("Expected next ID 3 after restore, got %d", next.ID)
+	}
+}
+
+func TestRestoreCancelled(t *testing.T) {
+	app := models.NewTodoApp()
+	app.AddTask(context.Background(), "Keep me")
+	manager, _ := newTestManager(t, app, 0)
+	name, err := manager.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	app.AddTask(context.Background(), "Added later")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := manager.Restore(ctx, name); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if tasks := app.GetTasks(context.Background()); len(tasks) != 2 {
+		t.Errorf("Expected tasks to be left untouched, got %+v", tasks)
+	}
+}
+
+func TestRestoreInvalidName(t *testing.T) {
+	manager, _ := newTestManager(t, models.NewTodoApp(), 0)
+
+	for _, name := range []string{"../etc/passwd", "notes.txt", "todo-backup-yesterday.json.enc"} {
+		if _, err := manager.Restore(context.Background(), name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected ErrInvalidName for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	manager, dir := newTestManager(t, models.NewTodoApp(), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		manager.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		names, _ := dir.List(ctx)
+		if len(names) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected Run to create a backup")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
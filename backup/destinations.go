@@ -0,0 +1,336 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials は保存先ごとの認証情報です
+// S3: AccessKeyID / SecretAccessKey / Region（Endpoint で S3 互換サービスも指定できます）
+// WebDAV: Username / Password
+// Dropbox: Token
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+	Endpoint        string
+	Username        string
+	Password        string
+	Token           string
+}
+
+// NewDestination は URL 形式の指定から保存先を作成します
+//
+//	file:///var/backups/todo            ローカルディレクトリ
+//	s3://bucket/prefix                  Amazon S3（または S3 互換サービス）
+//	webdav+https://host/remote.php/dav  WebDAV
+//	dropbox:///Apps/todo                Dropbox
+func NewDestination(rawURL string, creds Credentials, httpClient *http.Client) (Destination, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &LocalDir{Dir: u.Path}, nil
+	case "s3":
+		return &S3{
+			Bucket:     u.Host,
+			Prefix:     strings.Trim(u.Path, "/"),
+			Creds:      creds,
+			HTTPClient: httpClient,
+		}, nil
+	case "webdav+http", "webdav+https":
+		u.Scheme = strings.TrimPrefix(u.Scheme, "webdav+")
+		return &WebDAV{BaseURL: strings.TrimRight(u.String(), "/"), Creds: creds, HTTPClient: httpClient}, nil
+	case "dropbox":
+		return &Dropbox{Folder: "/" + strings.Trim(u.Host+u.Path, "/"), Creds: creds, HTTPClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("backup: unsupported destination %q", u.Scheme)
+	}
+}
+
+// Prefixed は同じ保存先に、ワークスペースやユーザーごとのバックアップを名前の接頭辞 Prefix で分けて置く保存先です
+// List は Prefix で始まる名前だけを、Prefix を取り除いて返すため、ほかの接頭辞のバックアップを世代管理で消しません
+type Prefixed struct {
+	Destination
+	Prefix string
+}
+
+func (d *Prefixed) Put(ctx context.Context, name string, data []byte) error {
+	return d.Destination.Put(ctx, d.Prefix+name, data)
+}
+
+func (d *Prefixed) Get(ctx context.Context, name string) ([]byte, error) {
+	return d.Destination.Get(ctx, d.Prefix+name)
+}
+
+func (d *Prefixed) List(ctx context.Context) ([]string, error) {
+	names, err := d.Destination.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, d.Prefix) {
+			scoped = append(scoped, strings.TrimPrefix(name, d.Prefix))
+		}
+	}
+	return scoped, nil
+}
+
+func (d *Prefixed) Delete(ctx context.Context, name string) error {
+	return d.Destination.Delete(ctx, d.Prefix+name)
+}
+
+// LocalDir はローカルディレクトリ（ネットワークドライブのマウント先など）への保存先です
+type LocalDir struct {
+	Dir string
+}
+
+func (d *LocalDir) Put(ctx context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(d.Dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(d.Dir, name), data, 0600)
+}
+
+func (d *LocalDir) Get(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.Dir, name))
+}
+
+func (d *LocalDir) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d *LocalDir) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(d.Dir, name))
+}
+
+// WebDAV は WebDAV サーバ（Nextcloud など）のコレクションへの保存先です
+type WebDAV struct {
+	BaseURL    string
+	Creds      Credentials
+	HTTPClient *http.Client
+}
+
+func (d *WebDAV) request(ctx context.Context, method, name string, body []byte, headers map[string]string) (*http.Response, error) {
+	target := d.BaseURL + "/"
+	if name != "" {
+		target += url.PathEscape(name)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if d.Creds.Username != "" {
+		req.SetBasicAuth(d.Creds.Username, d.Creds.Password)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("backup: webdav %s %s: %s", method, name, resp.Status)
+	}
+	return resp, nil
+}
+
+func (d *WebDAV) Put(ctx context.Context, name string, data []byte) error {
+	resp, err := d.request(ctx, http.MethodPut, name, data, map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (d *WebDAV) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := d.request(ctx, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (d *WebDAV) List(ctx context.Context) ([]string, error) {
+	body := []byte(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`)
+	resp, err := d.request(ctx, "PROPFIND", "", body, map[string]string{"Depth": "1", "Content-Type": "application/xml"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var multistatus struct {
+		Responses []struct {
+			Href       string `xml:"href"`
+			Collection *struct {
+			} `xml:"propstat>prop>resourcetype>collection"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(multistatus.Responses))
+	for _, r := range multistatus.Responses {
+		if r.Collection != nil {
+			continue
+		}
+		href, err := url.PathUnescape(r.Href)
+		if err != nil {
+			continue
+		}
+		names = append(names, path.Base(href))
+	}
+	return names, nil
+}
+
+func (d *WebDAV) Delete(ctx context.Context, name string) error {
+	resp, err := d.request(ctx, http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Dropbox は Dropbox のフォルダへの保存先です（API v2）
+// APIURL / ContentURL はテスト用に差し替えられます
+type Dropbox struct {
+	Folder     string
+	Creds      Credentials
+	HTTPClient *http.Client
+	APIURL     string
+	ContentURL string
+}
+
+func (d *Dropbox) urls() (string, string) {
+	api, content := d.APIURL, d.ContentURL
+	if api == "" {
+		api = "https://api.dropboxapi.com/2"
+	}
+	if content == "" {
+		content = "https://content.dropboxapi.com/2"
+	}
+	return api, content
+}
+
+// call は Dropbox API を呼び出します
+// arg が nil でなければ Dropbox-API-Arg ヘッダ（コンテンツ系）に、body は本文になります
+func (d *Dropbox) call(ctx context.Context, endpoint string, arg interface{}, body []byte, contentType string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.Creds.Token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if arg != nil {
+		encoded, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Dropbox-API-Arg", string(encoded))
+	}
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backup: dropbox %s: %s: %s", path.Base(endpoint), resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func (d *Dropbox) Put(ctx context.Context, name string, data []byte) error {
+	_, content := d.urls()
+	arg := map[string]interface{}{"path": path.Join(d.Folder, name), "mode": "overwrite", "mute": true}
+	_, err := d.call(ctx, content+"/files/upload", arg, data, "application/octet-stream")
+	return err
+}
+
+func (d *Dropbox) Get(ctx context.Context, name string) ([]byte, error) {
+	_, content := d.urls()
+	return d.call(ctx, content+"/files/download", map[string]string{"path": path.Join(d.Folder, name)}, nil, "")
+}
+
+func (d *Dropbox) List(ctx context.Context) ([]string, error) {
+	api, _ := d.urls()
+	body, _ := json.Marshal(map[string]string{"path": d.Folder})
+	endpoint := api + "/files/list_folder"
+
+	var names []string
+	for {
+		data, err := d.call(ctx, endpoint, nil, body, "application/json")
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Entries []struct {
+				Tag  string `json:".tag"`
+				Name string `json:"name"`
+			} `json:"entries"`
+			Cursor  string `json:"cursor"`
+			HasMore bool   `json:"has_more"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			if entry.Tag == "file" {
+				names = append(names, entry.Name)
+			}
+		}
+		if !page.HasMore {
+			return names, nil
+		}
+		endpoint = api + "/files/list_folder/continue"
+		body, _ = json.Marshal(map[string]string{"cursor": page.Cursor})
+	}
+}
+
+func (d *Dropbox) Delete(ctx context.Context, name string) error {
+	api, _ := d.urls()
+	body, _ := json.Marshal(map[string]string{"path": path.Join(d.Folder, name)})
+	_, err := d.call(ctx, api+"/files/delete_v2", nil, body, "application/json")
+	return err
+}
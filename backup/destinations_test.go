@@ -0,0 +1,270 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"todo-app/models"
+)
+
+func TestNewDestination(t *testing.T) {
+	creds := Credentials{Token: "token"}
+
+	tests := []struct {
+		raw   string
+		check func(Destination) bool
+	}{
+		{"file:///var/backups/todo", func(d Destination) bool {
+			local, ok := d.(*LocalDir)
+			return ok && local.Dir == "/var/backups/todo"
+		}},
+		{"s3://my-bucket/todo/backups/", func(d Destination) bool {
+			s3, ok := d.(*S3)
+			return ok && s3.Bucket == "my-bucket" && s3.Prefix == "todo/backups"
+		}},
+		{"webdav+https://cloud.example.com/dav/files/", func(d Destination) bool {
+			dav, ok := d.(*WebDAV)
+			return ok && dav.BaseURL == "https://cloud.example.com/dav/files"
+		}},
+		{"dropbox:///Apps/todo", func(d Destination) bool {
+			dropbox, ok := d.(*Dropbox)
+			return ok && dropbox.Folder == "/Apps/todo" && dropbox.Creds.Token == "token"
+		}},
+	}
+	for _, tt := range tests {
+		destination, err := NewDestination(tt.raw, creds, nil)
+		if err != nil {
+			t.Errorf("NewDestination(%q) failed: %v", tt.raw, err)
+			continue
+		}
+		if !tt.check(destination) {
+			t.Errorf("Unexpected destination for %q: %+v", tt.raw, destination)
+		}
+	}
+
+	if _, err := NewDestination("ftp://example.com/backups", creds, nil); err == nil {
+		t.Error("Expected error for unsupported scheme")
+	}
+}
+
+// testDestination は Put / List / Get / Delete の一連の操作を確認します
+func testDestination(t *testing.T, destination Destination) {
+	ctx := context.Background()
+	if err := destination.Put(ctx, "a.json.enc", []byte("first")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := destination.Put(ctx, "b.json.enc", []byte("second")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	names, err := destination.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a.json.enc,b.json.enc" {
+		t.Errorf("Unexpected list: %v", names)
+	}
+
+	data, err := destination.Get(ctx, "b.json.enc")
+	if err != nil || string(data) != "second" {
+		t.Errorf("Unexpected Get result: %q, %v", data, err)
+	}
+
+	if err := destination.Delete(ctx, "a.json.enc"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	names, _ = destination.List(ctx)
+	if len(names) != 1 || names[0] != "b.json.enc" {
+		t.Errorf("Unexpected list after delete: %v", names)
+	}
+
+	if _, err := destination.Get(ctx, "missing.json.enc"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestLocalDir(t *testing.T) {
+	testDestination(t, &LocalDir{Dir: t.TempDir() + "/nested"})
+
+	empty := &LocalDir{Dir: t.TempDir() + "/missing"}
+	if names, err := empty.List(context.Background()); err != nil || len(names) != 0 {
+		t.Errorf("Expected empty list for missing directory, got %v, %v", names, err)
+	}
+}
+
+func TestPrefixed(t *testing.T) {
+	dir := &LocalDir{Dir: t.TempDir()}
+	testDestination(t, &Prefixed{Destination: dir, Prefix: "workspaces-family."})
+
+	// 接頭辞のないルートのバックアップと混ざらないこと
+	ctx := context.Background()
+	root := NewManager(models.NewTodoApp(), dir, make([]byte, 32), 1)
+	scoped := NewManager(models.NewTodoApp(), &Prefixed{Destination: dir, Prefix: "users-1."}, make([]byte, 32), 1)
+	if _, err := scoped.Backup(ctx); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if _, err := root.Backup(ctx); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if names, _ := scoped.List(ctx); len(names) != 1 {
+		t.Errorf("Expected the scoped backup to survive the root retention, got %v", names)
+	}
+	if names, _ := root.List(ctx); len(names) != 1 || strings.HasPrefix(names[0], "users-1.") {
+		t.Errorf("Expected only the root backup in the root list, got %v", names)
+	}
+}
+
+// fakeFiles はテスト用のサーバが保持するファイルです
+type fakeFiles struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func newFakeFiles() *fakeFiles {
+	return &fakeFiles{files: map[string][]byte{}}
+}
+
+func (f *fakeFiles) names(prefix string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var names []string
+	for name := range f.files {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWebDAV(t *testing.T) {
+	files := newFakeFiles()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		files.mutex.Lock()
+		defer files.mutex.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			files.files[r.URL.Path] = body
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			body, ok := files.files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		case http.MethodDelete:
+			delete(files.files, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case "PROPFIND":
+			if r.Header.Get("Depth") != "1" {
+				t.Errorf("Expected Depth: 1, got %q", r.Header.Get("Depth"))
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+			fmt.Fprint(w, `<d:response><d:href>/dav/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>`)
+			for name := range files.files {
+				fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype/></d:prop></d:propstat></d:response>`, name)
+			}
+			fmt.Fprint(w, `</d:multistatus>`)
+		}
+	}))
+	defer server.Close()
+
+	destination, err := NewDestination("webdav+"+server.URL+"/dav", Credentials{Username: "alice", Password: "secret"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewDestination failed: %v", err)
+	}
+	testDestination(t, destination)
+
+	unauthorized := &WebDAV{BaseURL: server.URL + "/dav", HTTPClient: server.Client()}
+	if err := unauthorized.Put(context.Background(), "a.json.enc", nil); err == nil {
+		t.Error("Expected error without credentials")
+	}
+}
+
+func TestDropbox(t *testing.T) {
+	files := newFakeFiles()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var arg struct {
+			Path   string `json:"path"`
+			Cursor string `json:"cursor"`
+		}
+		if header := r.Header.Get("Dropbox-API-Arg"); header != "" {
+			json.Unmarshal([]byte(header), &arg)
+		} else {
+			json.NewDecoder(r.Body).Decode(&arg)
+		}
+
+		switch r.URL.Path {
+		case "/content/files/upload":
+			body, _ := io.ReadAll(r.Body)
+			files.mutex.Lock()
+			files.files[arg.Path] = body
+			files.mutex.Unlock()
+			fmt.Fprint(w, `{}`)
+		case "/content/files/download":
+			files.mutex.Lock()
+			body, ok := files.files[arg.Path]
+			files.mutex.Unlock()
+			if !ok {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"error_summary": "path/not_found/"}`)
+				return
+			}
+			w.Write(body)
+		case "/api/files/list_folder", "/api/files/list_folder/continue":
+			// 1 ページに 1 件ずつ返してページングを確認します
+			names := files.names("/Apps/todo/")
+			index := 0
+			if arg.Cursor != "" {
+				fmt.Sscanf(arg.Cursor, "%d", &index)
+			}
+			entries := []map[string]string{{".tag": "folder", "name": "archive"}}
+			if index < len(names) {
+				entries = append(entries, map[string]string{".tag": "file", "name": path.Base(names[index])})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"entries":  entries,
+				"cursor":   fmt.Sprint(index + 1),
+				"has_more": index+1 < len(names),
+			})
+		case "/api/files/delete_v2":
+			files.mutex.Lock()
+			delete(files.files, arg.Path)
+			files.mutex.Unlock()
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	destination := &Dropbox{
+		Folder:     "/Apps/todo",
+		Creds:      Credentials{Token: "token"},
+		HTTPClient: server.Client(),
+		APIURL:     server.URL + "/api",
+		ContentURL: server.URL + "/content",
+	}
+	testDestination(t, destination)
+}
@@ -0,0 +1,409 @@
+// Package board はタスクをカンバンのカラムに並べたボードを扱います
+//
+// カラムの構成と、ドラッグ＆ドロップでタスクを置いた位置だけを保持し、タスクそのものはストアから受け取ります
+// 完了のカラム（Done）に置いたタスクは完了、それ以外のカラムのタスクは未完了として扱い、
+// 一覧の画面などで完了状態を変えたタスクは、状態に合うカラムへ自動的に移ります
+// すべてのタスクのボード（Store）のほかに、リストごとに別のカラムを持つボード（Boards）を作れます
+package board
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"todo-app/models"
+)
+
+// ErrColumnNotFound は指定した ID のカラムがないときのエラーです
+var ErrColumnNotFound = errors.New("column not found")
+
+// maxColumns はボードに作れるカラムの最大数です
+const maxColumns = 20
+
+// maxColumnName はカラムの名前の最大の長さです
+const maxColumnName = 50
+
+// Column はボードのカラムです
+// Done: 完了のカラムか。ここに置いたタスクは完了にし、完了したタスクはここに並べます
+type Column struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// ColumnUpdate はカラムの変更です。nil の項目は変えません
+// Position: カラムの並びの中の位置（0 が先頭。範囲外なら端に置きます）
+type ColumnUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	Done     *bool   `json:"done,omitempty"`
+	Position *int    `json:"position,omitempty"`
+}
+
+// Swimlane はボードを横に区切る行の分け方です
+type Swimlane string
+
+const (
+	// SwimlaneNone は区切らず、すべてのタスクを1行に並べます
+	SwimlaneNone Swimlane = ""
+	// SwimlaneAssignee はタスクを担当している人（claimed_by）ごとに分けます
+	SwimlaneAssignee Swimlane = "assignee"
+	// SwimlanePriority は優先度ごとに分けます
+	SwimlanePriority Swimlane = "priority"
+	// SwimlaneTag はタグごとに分けます。複数のタグが付いたタスクはそれぞれのタグの行に並べます
+	SwimlaneTag Swimlane = "tag"
+)
+
+// ParseSwimlane は分け方の名前を読み取ります。知らない名前なら ErrValidation を返します
+func ParseSwimlane(name string) (Swimlane, error) {
+	switch Swimlane(name) {
+	case SwimlaneNone, SwimlaneAssignee, SwimlanePriority, SwimlaneTag:
+		return Swimlane(name), nil
+	}
+	return "", fmt.Errorf("%w: unknown swimlane %q (expected assignee, priority or tag)", models.ErrValidation, name)
+}
+
+// Board はカラムとスイムレーンの格子に並べたタスクです
+type Board struct {
+	Columns   []Column `json:"columns"`
+	Swimlanes []Lane   `json:"swimlanes"`
+}
+
+// Lane はスイムレーンの1行です
+// Key: 行を区別する値（担当する人の名前・優先度・タグ。値のないタスクの行は空）
+// Cells: Columns と同じ順に並べた、カラムごとのタスク
+type Lane struct {
+	Key   string `json:"key"`
+	Cells []Cell `json:"cells"`
+}
+
+// Cell は1つのカラムと行に並ぶタスクです
+type Cell struct {
+	ColumnID int           `json:"column_id"`
+	Tasks    []models.Task `json:"tasks"`
+}
+
+// Store はボードのカラムとタスクを置いた位置を保持します
+type Store struct {
+	mutex   sync.Mutex
+	columns []Column
+	nextID  int
+	// placed はカラムごとに、ドラッグ＆ドロップで置いたタスクの ID を並び順に持ちます
+	placed map[int][]int
+}
+
+// NewStore は「未着手」と「完了」のカラムを持つ Store を作成します
+func NewStore() *Store {
+	return &Store{
+		columns: []Column{{ID: 1, Name: "未着手"}, {ID: 2, Name: "完了", Done: true}},
+		nextID:  3,
+		placed:  map[int][]int{},
+	}
+}
+
+// Columns はカラムを並び順に返します
+func (s *Store) Columns() []Column {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Column{}, s.columns...)
+}
+
+// Column は id のカラムを返します。なければ ErrColumnNotFound を返します
+func (s *Store) Column(id int) (Column, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return Column{}, fmt.Errorf("%w: id %d", ErrColumnNotFound, id)
+	}
+	return s.columns[i], nil
+}
+
+// AddColumn は name のカラムを update.Position の位置（省略時は最後）に追加します
+func (s *Store) AddColumn(name string, update ColumnUpdate) (Column, error) {
+	name = strings.TrimSpace(name)
+	if err := validateName(name); err != nil {
+		return Column{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.columns) >= maxColumns {
+		return Column{}, fmt.Errorf("%w: a board can have at most %d columns", models.ErrConflict, maxColumns)
+	}
+	column := Column{ID: s.nextID, Name: name}
+	if update.Done != nil {
+		column.Done = *update.Done
+	}
+	s.nextID++
+	position := len(s.columns)
+	if update.Position != nil {
+		position = *update.Position
+	}
+	s.columns = insertColumn(s.columns, column, position)
+	return column, nil
+}
+
+// UpdateColumn は id のカラムの名前・完了のカラムか・位置を変えます
+func (s *Store) UpdateColumn(id int, update ColumnUpdate) (Column, error) {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if err := validateName(name); err != nil {
+			return Column{}, err
+		}
+		update.Name = &name
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return Column{}, fmt.Errorf("%w: id %d", ErrColumnNotFound, id)
+	}
+	column := s.columns[i]
+	if update.Name != nil {
+		column.Name = *update.Name
+	}
+	if update.Done != nil {
+		column.Done = *update.Done
+	}
+	s.columns[i] = column
+	if update.Position != nil {
+		s.columns = insertColumn(append(s.columns[:i:i], s.columns[i+1:]...), column, *update.Position)
+	}
+	return column, nil
+}
+
+// DeleteColumn は id のカラムを削除します。置いていたタスクは状態に合う別のカラムに並びます
+// 最後の1つは削除できません（ErrConflict）
+func (s *Store) DeleteColumn(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return fmt.Errorf("%w: id %d", ErrColumnNotFound, id)
+	}
+	if len(s.columns) == 1 {
+		return fmt.Errorf("%w: a board needs at least one column", models.ErrConflict)
+	}
+	s.columns = append(s.columns[:i], s.columns[i+1:]...)
+	delete(s.placed, id)
+	return nil
+}
+
+// Place はタスクを columnID のカラムの position の位置（0 が先頭。範囲外なら最後）に置きます
+// タスクの完了状態をカラムに合わせるのは呼び出し元です
+func (s *Store) Place(taskID, columnID, position int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.indexOf(columnID) < 0 {
+		return fmt.Errorf("%w: id %d", ErrColumnNotFound, columnID)
+	}
+	for id, tasks := range s.placed {
+		s.placed[id] = removeID(tasks, taskID)
+	}
+	tasks := s.placed[columnID]
+	if position < 0 || position > len(tasks) {
+		position = len(tasks)
+	}
+	tasks = append(tasks, 0)
+	copy(tasks[position+1:], tasks[position:])
+	tasks[position] = taskID
+	s.placed[columnID] = tasks
+	return nil
+}
+
+// Grid は tasks をカラムと lane で分けた行の格子に並べます
+// 置いたカラムの完了状態がタスクと合わなければ、状態に合う最初のカラムに並べます
+// 置いていないタスクは状態に合う最初のカラムの最後に、ID の順で並べます
+// 行は値の順（担当する人とタグは名前の順、優先度は高い順）で、値のないタスクの行を最後にします
+// タグで分けると、複数のタグが付いたタスクは当てはまるすべての行に並びます
+func (s *Store) Grid(tasks []models.Task, lane Swimlane) Board {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exists := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		exists[task.ID] = true
+	}
+	position := map[int]int{}
+	columnOf := map[int]int{}
+	for columnID, ids := range s.placed {
+		kept := ids[:0]
+		for _, id := range ids {
+			if exists[id] {
+				kept = append(kept, id)
+			}
+		}
+		// 削除されたタスクの位置はここで忘れます
+		s.placed[columnID] = kept
+		for i, id := range kept {
+			position[id] = i
+			columnOf[id] = columnID
+		}
+	}
+
+	// 完了状態が変わって別のカラムに並ぶタスクは、置いた位置を使わずに並べます
+	for _, task := range tasks {
+		if placed, ok := columnOf[task.ID]; ok && s.columns[s.columnFor(task, placed)].ID != placed {
+			delete(position, task.ID)
+		}
+	}
+
+	sorted := append([]models.Task{}, tasks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, iok := position[sorted[i].ID]
+		pj, jok := position[sorted[j].ID]
+		if iok != jok {
+			return iok
+		}
+		if iok && pi != pj {
+			return pi < pj
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	board := Board{Columns: append([]Column{}, s.columns...), Swimlanes: []Lane{}}
+	lanes := map[string]*Lane{}
+	for _, key := range laneKeys(sorted, lane) {
+		l := Lane{Key: key, Cells: make([]Cell, len(s.columns))}
+		for i, column := range s.columns {
+			l.Cells[i] = Cell{ColumnID: column.ID, Tasks: []models.Task{}}
+		}
+		board.Swimlanes = append(board.Swimlanes, l)
+	}
+	for i := range board.Swimlanes {
+		lanes[board.Swimlanes[i].Key] = &board.Swimlanes[i]
+	}
+	for _, task := range sorted {
+		i := s.columnFor(task, columnOf[task.ID])
+		for _, key := range laneKeysOf(task, lane) {
+			l := lanes[key]
+			l.Cells[i].Tasks = append(l.Cells[i].Tasks, task)
+		}
+	}
+	return board
+}
+
+// columnFor は task を並べるカラムの位置を返します。placed は置いたカラムの ID（置いていなければ 0）です
+func (s *Store) columnFor(task models.Task, placed int) int {
+	if i := s.indexOf(placed); i >= 0 && s.columns[i].Done == task.Completed {
+		return i
+	}
+	for i, column := range s.columns {
+		if column.Done == task.Completed {
+			return i
+		}
+	}
+	if i := s.indexOf(placed); i >= 0 {
+		return i
+	}
+	return 0
+}
+
+func (s *Store) indexOf(id int) int {
+	for i, column := range s.columns {
+		if column.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// laneKeysOf は task が入る行の値を返します（タグで分けるときだけ複数になることがあります）
+func laneKeysOf(task models.Task, lane Swimlane) []string {
+	switch lane {
+	case SwimlaneAssignee:
+		return []string{task.ClaimedBy}
+	case SwimlanePriority:
+		return []string{string(task.Priority)}
+	case SwimlaneTag:
+		if len(task.Tags) > 0 {
+			return task.Tags
+		}
+	}
+	return []string{""}
+}
+
+// laneKeys は tasks を並べる行の値を、行の順に返します（区切らないときも1行は返します）
+func laneKeys(tasks []models.Task, lane Swimlane) []string {
+	if lane == SwimlaneNone {
+		return []string{""}
+	}
+	if lane == SwimlanePriority {
+		return []string{string(models.PriorityHigh), string(models.PriorityMedium), string(models.PriorityLow), ""}
+	}
+	seen := map[string]bool{}
+	var keys []string
+	for _, task := range tasks {
+		for _, key := range laneKeysOf(task, lane) {
+			if key != "" && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return append(keys, "")
+}
+
+func validateName(name string) error {
+	if name == "" || len([]rune(name)) > maxColumnName {
+		return fmt.Errorf("%w: column name must be 1 to %d characters", models.ErrValidation, maxColumnName)
+	}
+	return nil
+}
+
+// insertColumn は columns の position の位置に column を入れます（範囲外なら端に置きます）
+func insertColumn(columns []Column, column Column, position int) []Column {
+	if position < 0 {
+		position = 0
+	}
+	if position > len(columns) {
+		position = len(columns)
+	}
+	columns = append(columns, Column{})
+	copy(columns[position+1:], columns[position:])
+	columns[position] = column
+	return columns
+}
+
+func removeID(ids []int, id int) []int {
+	for i, v := range ids {
+		if v == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+// Boards はリストごとのボードを保持します。ボードは初めて使ったときに「未着手」と「完了」のカラムで作ります
+type Boards struct {
+	mutex  sync.Mutex
+	boards map[int]*Store
+}
+
+// NewBoards はリストごとのボードのない Boards を作成します
+func NewBoards() *Boards {
+	return &Boards{boards: map[int]*Store{}}
+}
+
+// Get は listID のリストのボードを返します。まだなければ作ります
+func (b *Boards) Get(listID int) *Store {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	s, ok := b.boards[listID]
+	if !ok {
+		s = NewStore()
+		b.boards[listID] = s
+	}
+	return s
+}
+
+// Delete は listID のリストのボードを忘れます（リストを削除したときに使います）
+func (b *Boards) Delete(listID int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.boards, listID)
+}
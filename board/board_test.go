@@ -0,0 +1,230 @@
+package board
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"todo-app/models"
+)
+
+func intPtr(v int) *int          { return &v }
+func boolPtr(v bool) *bool       { return &v }
+func stringPtr(v string) *string { return &v }
+
+// cellIDs は行 lane のカラムごとのタスクの ID を返します
+func cellIDs(lane Lane) [][]int {
+	ids := make([][]int, len(lane.Cells))
+	for i, cell := range lane.Cells {
+		ids[i] = []int{}
+		for _, task := range cell.Tasks {
+			ids[i] = append(ids[i], task.ID)
+		}
+	}
+	return ids
+}
+
+func columnIDs(columns []Column) []int {
+	ids := []int{}
+	for _, column := range columns {
+		ids = append(ids, column.ID)
+	}
+	return ids
+}
+
+func TestColumns(t *testing.T) {
+	s := NewStore()
+	if got := s.Columns(); len(got) != 2 || got[0].Done || !got[1].Done {
+		t.Fatalf("expected the default columns, got %+v", got)
+	}
+
+	doing, err := s.AddColumn(" 作業中 ", ColumnUpdate{Position: intPtr(1)})
+	if err != nil || doing.ID != 3 || doing.Name != "作業中" {
+		t.Fatalf("unexpected column %+v, %v", doing, err)
+	}
+	review, _ := s.AddColumn("レビュー", ColumnUpdate{})
+	if got := columnIDs(s.Columns()); !reflect.DeepEqual(got, []int{1, 3, 2, 4}) {
+		t.Errorf("expected the new column in the middle, got %v", got)
+	}
+
+	updated, err := s.UpdateColumn(review.ID, ColumnUpdate{Name: stringPtr("確認"), Done: boolPtr(true), Position: intPtr(-5)})
+	if err != nil || updated.Name != "確認" || !updated.Done {
+		t.Fatalf("unexpected update %+v, %v", updated, err)
+	}
+	if got := columnIDs(s.Columns()); !reflect.DeepEqual(got, []int{4, 1, 3, 2}) {
+		t.Errorf("expected the column to move to the front, got %v", got)
+	}
+	s.UpdateColumn(review.ID, ColumnUpdate{Position: intPtr(99)})
+	if got := columnIDs(s.Columns()); !reflect.DeepEqual(got, []int{1, 3, 2, 4}) {
+		t.Errorf("expected the column to move to the end, got %v", got)
+	}
+	if got, _ := s.Column(review.ID); got.Name != "確認" {
+		t.Errorf("expected Column to return the renamed column, got %+v", got)
+	}
+
+	if _, err := s.Column(99); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+	if _, err := s.UpdateColumn(99, ColumnUpdate{}); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+	if _, err := s.UpdateColumn(1, ColumnUpdate{Name: stringPtr(" ")}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected an empty name to be rejected, got %v", err)
+	}
+	if _, err := s.AddColumn("", ColumnUpdate{}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected an empty name to be rejected, got %v", err)
+	}
+	for i := len(s.Columns()); i < maxColumns; i++ {
+		s.AddColumn("more", ColumnUpdate{})
+	}
+	if _, err := s.AddColumn("too many", ColumnUpdate{}); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected the column limit to be enforced, got %v", err)
+	}
+
+	for _, column := range s.Columns()[1:] {
+		if err := s.DeleteColumn(column.ID); err != nil {
+			t.Fatalf("DeleteColumn failed: %v", err)
+		}
+	}
+	if err := s.DeleteColumn(1); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected the last column to be kept, got %v", err)
+	}
+	if err := s.DeleteColumn(99); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+}
+
+func TestGridPlacesTasks(t *testing.T) {
+	s := NewStore()
+	doing, _ := s.AddColumn("作業中", ColumnUpdate{Position: intPtr(1)})
+	tasks := []models.Task{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4, Completed: true}, {ID: 5}}
+
+	board := s.Grid(tasks, SwimlaneNone)
+	if len(board.Swimlanes) != 1 || !reflect.DeepEqual(cellIDs(board.Swimlanes[0]), [][]int{{1, 2, 3, 5}, {}, {4}}) {
+		t.Fatalf("expected unplaced tasks in the first matching column, got %+v", board)
+	}
+
+	s.Place(3, doing.ID, 0)
+	s.Place(1, doing.ID, 0)
+	s.Place(5, 1, 0)
+	board = s.Grid(tasks, SwimlaneNone)
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{5, 2}, {1, 3}, {4}}) {
+		t.Errorf("expected placed tasks in order, got %v", got)
+	}
+
+	// 置き直すと前のカラムから外れ、範囲外の位置は最後になります
+	s.Place(5, doing.ID, 99)
+	board = s.Grid(tasks, SwimlaneNone)
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{2}, {1, 3, 5}, {4}}) {
+		t.Errorf("expected the task to move, got %v", got)
+	}
+
+	// 完了したタスクは完了のカラムへ、削除したタスクは並びから消えます
+	tasks = []models.Task{{ID: 1, Completed: true}, {ID: 2}, {ID: 3}, {ID: 4, Completed: true}}
+	board = s.Grid(tasks, SwimlaneNone)
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{2}, {3}, {1, 4}}) {
+		t.Errorf("expected completed tasks in the done column, got %v", got)
+	}
+	if got := s.placed[doing.ID]; !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("expected deleted tasks to be forgotten, got %v", got)
+	}
+
+	// カラムを消すと、置いていたタスクは状態に合うカラムに並びます
+	s.DeleteColumn(doing.ID)
+	board = s.Grid(tasks, SwimlaneNone)
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{2, 3}, {1, 4}}) {
+		t.Errorf("expected tasks of the deleted column to fall back, got %v", got)
+	}
+
+	// 完了のカラムがなければ、完了したタスクは置いたカラムか最初のカラムに並びます
+	s.DeleteColumn(2)
+	s.Place(2, 1, 0)
+	if got := cellIDs(s.Grid(tasks, SwimlaneNone).Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{2, 1, 3, 4}}) {
+		t.Errorf("expected every task in the only column, got %v", got)
+	}
+	if err := s.Place(1, 99, 0); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("expected ErrColumnNotFound, got %v", err)
+	}
+}
+
+func TestGridSwimlanes(t *testing.T) {
+	s := NewStore()
+	tasks := []models.Task{
+		{ID: 1, ClaimedBy: "bob", Priority: models.PriorityLow},
+		{ID: 2, ClaimedBy: "alice", Priority: models.PriorityHigh},
+		{ID: 3},
+		{ID: 4, ClaimedBy: "bob", Completed: true, Priority: models.PriorityHigh},
+	}
+
+	board := s.Grid(tasks, SwimlaneAssignee)
+	var keys []string
+	for _, lane := range board.Swimlanes {
+		keys = append(keys, lane.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"alice", "bob", ""}) {
+		t.Fatalf("unexpected assignee lanes %v", keys)
+	}
+	if got := cellIDs(board.Swimlanes[1]); !reflect.DeepEqual(got, [][]int{{1}, {4}}) {
+		t.Errorf("unexpected cells for bob %v", got)
+	}
+	if board.Swimlanes[2].Cells[1].ColumnID != 2 {
+		t.Errorf("expected cells to carry the column ID, got %+v", board.Swimlanes[2].Cells)
+	}
+
+	board = s.Grid(tasks, SwimlanePriority)
+	keys = nil
+	for _, lane := range board.Swimlanes {
+		keys = append(keys, lane.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"high", "medium", "low", ""}) {
+		t.Fatalf("unexpected priority lanes %v", keys)
+	}
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{2}, {4}}) {
+		t.Errorf("unexpected cells for high priority %v", got)
+	}
+	tasks[0].Tags = []string{"ui", "bug"}
+	tasks[1].Tags = []string{"ui"}
+	board = s.Grid(tasks, SwimlaneTag)
+	keys = nil
+	for _, lane := range board.Swimlanes {
+		keys = append(keys, lane.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"bug", "ui", ""}) {
+		t.Fatalf("unexpected tag lanes %v", keys)
+	}
+	if got := cellIDs(board.Swimlanes[1]); !reflect.DeepEqual(got, [][]int{{1, 2}, {}}) {
+		t.Errorf("unexpected cells for ui %v", got)
+	}
+	if got := cellIDs(board.Swimlanes[0]); !reflect.DeepEqual(got, [][]int{{1}, {}}) {
+		t.Errorf("unexpected cells for bug %v", got)
+	}
+	if got := s.Grid(nil, SwimlaneAssignee); len(got.Swimlanes) != 1 || len(got.Swimlanes[0].Cells[0].Tasks) != 0 {
+		t.Errorf("expected an empty unassigned lane, got %+v", got)
+	}
+}
+
+func TestParseSwimlane(t *testing.T) {
+	for _, name := range []string{"", "assignee", "priority", "tag"} {
+		if got, err := ParseSwimlane(name); err != nil || string(got) != name {
+			t.Errorf("ParseSwimlane(%q) = %q, %v", name, got, err)
+		}
+	}
+	for _, name := range []string{"tags", "owner"} {
+		if _, err := ParseSwimlane(name); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("ParseSwimlane(%q): expected ErrValidation, got %v", name, err)
+		}
+	}
+}
+
+func TestBoards(t *testing.T) {
+	b := NewBoards()
+	work := b.Get(1)
+	work.AddColumn("作業中", ColumnUpdate{})
+	if b.Get(1) != work || len(b.Get(2).Columns()) != 2 {
+		t.Fatal("expected a separate board for each list")
+	}
+	b.Delete(1)
+	if got := len(b.Get(1).Columns()); got != 2 {
+		t.Errorf("expected a fresh board after Delete, got %d columns", got)
+	}
+}
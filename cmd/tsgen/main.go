@@ -0,0 +1,475 @@
+// tsgen は API の TypeScript の型定義とクライアント（frontend/api.ts）と、OpenAPI の文書（handlers/openapi.json）を生成するコマンドです
+//
+//	go run ./cmd/tsgen -o frontend/api.ts -openapi handlers/openapi.json
+//
+// 型は Go の struct のタグから作るので、Task などにフィールドを足したら go generate ./... で作り直してください
+package main
+
+//go:generate go run . -o ../../frontend/api.ts -openapi ../../handlers/openapi.json
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"todo-app/accounts"
+	"todo-app/agenda"
+	"todo-app/board"
+	"todo-app/crdt"
+	"todo-app/e2ee"
+	"todo-app/lists"
+	"todo-app/models"
+	"todo-app/pomodoro"
+	"todo-app/rules"
+	"todo-app/timeline"
+	"todo-app/tsgen"
+	"todo-app/webhooks"
+)
+
+// header は生成したファイルの先頭のコメントです
+const header = `Code generated by go run ./cmd/tsgen; DO NOT EDIT.
+
+todo-app の API の型定義と fetch クライアントです。Go の struct から生成しています。`
+
+// OpenAPI の文書の info です。version は API のバージョン（API-Version ヘッダや /api/v2/ で選ぶもの）の最新です
+const (
+	openAPITitle       = "todo-app API"
+	openAPIVersion     = "2"
+	openAPIDescription = `タスクを管理する API です。応答はすべて {"success": true, ...} か、失敗したときの {"success": false, "error": {...}} の JSON です。
+
+API-Version: 2 ヘッダか /api/v2/ で始まるパスでバージョン 2 を選ぶと、値に誤りのある入力を 400 ではなく 422（unprocessable）で返します。`
+)
+
+// success は {"success": true} だけを返す API のレスポンスです
+type success struct {
+	Success bool `json:"success"`
+}
+
+// columnResponse はカンバンのカラムを1つ返す API のレスポンスです
+type columnResponse struct {
+	success
+	Column board.Column `json:"column"`
+}
+
+// claimRequest は /api/tasks/{id}/claim の本文です
+type claimRequest struct {
+	Claimant string `json:"claimant"`
+}
+
+// dependenciesResponse は /api/tasks/{id}/dependencies のレスポンスです
+type dependenciesResponse struct {
+	success
+	TaskID    int   `json:"task_id"`
+	DependsOn []int `json:"depends_on"`
+}
+
+// syncResponse は /api/sync のレスポンスです
+type syncResponse struct {
+	success
+	Replica string     `json:"replica"`
+	Docs    []crdt.Doc `json:"docs"`
+}
+
+// generator は API で使う型とエンドポイントを登録した Generator を返します
+func generator() *tsgen.Generator {
+	g := tsgen.New()
+	g.Enum("Priority", models.PriorityLow, models.PriorityMedium, models.PriorityHigh)
+	g.Enum("EventType", models.EventTaskCreated, models.EventTaskUpdated, models.EventTaskDeleted, models.EventTaskReminder)
+	g.Enum("PomodoroStatus", pomodoro.StatusRunning, pomodoro.StatusCompleted, pomodoro.StatusStopped)
+	g.Enum("Trigger", rules.TriggerCreated, rules.TriggerUpdated, rules.TriggerCompleted)
+	g.Enum("OperationKind", models.OperationAdd, models.OperationToggle, models.OperationEdit, models.OperationDelete)
+	g.Enum("Frequency", models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly)
+	g.Type("TimeEntry", models.TimeEntry{})
+	g.Type("Recurrence", models.Recurrence{})
+	g.Type("Progress", models.Progress{})
+	g.Type("Comment", models.Comment{})
+	g.Type("HistoryEntry", models.HistoryEntry{})
+	g.Type("Task", models.Task{})
+	g.Type("Operation", models.Operation{})
+	g.Type("SearchResult", models.SearchResult{})
+	g.Type("List", lists.List{})
+	g.Type("PomodoroSession", pomodoro.Session{})
+	g.Type("Agenda", agenda.Agenda{})
+	g.Type("Review", agenda.Review{})
+	g.Type("CalendarDay", agenda.CalendarDay{})
+	g.Type("Calendar", agenda.Calendar{})
+	g.Type("ArchiveDay", agenda.ArchiveDay{})
+	g.Type("Archive", agenda.Archive{})
+	g.Type("SuggestedTask", agenda.Suggestion{})
+	g.Type("Webhook", webhooks.Webhook{})
+	g.Type("Condition", rules.Condition{})
+	g.Type("Action", rules.Action{})
+	g.Type("Rule", rules.Rule{})
+	g.Type("E2EKeys", e2ee.Keys{})
+	g.Type("BoardColumn", board.Column{})
+	g.Type("BoardCell", board.Cell{})
+	g.Type("BoardLane", board.Lane{})
+	g.Type("Board", board.Board{})
+	g.Type("TimelineBar", timeline.Bar{})
+	g.Type("Timeline", timeline.Timeline{})
+	g.Type("SyncStamp", crdt.Stamp{})
+	g.Type("SyncRegister", crdt.Register{})
+	g.Type("SyncTagSet", crdt.ORSet{})
+	g.Type("SyncDoc", crdt.Doc{})
+	g.Type("User", accounts.User{})
+	g.Type("APIKey", accounts.APIKey{})
+	g.Type("KeyUsage", accounts.KeyUsage{})
+	g.Type("Session", accounts.Session{})
+
+	type taskResponse struct {
+		success
+		Task models.Task `json:"task"`
+	}
+	type timeEntryResponse struct {
+		success
+		Task  models.Task       `json:"task"`
+		Entry *models.TimeEntry `json:"entry,omitempty"`
+	}
+	type sessionsResponse struct {
+		success
+		Sessions  []pomodoro.Session `json:"sessions"`
+		Completed int                `json:"completed"`
+	}
+	type credentials struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Email    string `json:"email,omitempty"`
+		Captcha  string `json:"captcha,omitempty"`
+		Invite   string `json:"invite,omitempty"`
+		Remember bool   `json:"remember,omitempty"`
+	}
+	type userResponse struct {
+		success
+		User accounts.User `json:"user"`
+	}
+	type listRequest struct {
+		Name string `json:"name"`
+	}
+	type listResponse struct {
+		success
+		List lists.List `json:"list"`
+	}
+	type sessionResponse struct {
+		success
+		Session pomodoro.Session `json:"session"`
+	}
+
+	endpoints := []tsgen.Endpoint{
+		{Name: "listTasks", Method: "GET", Path: "/api/tasks", Query: []string{"q", "index", "priority", "list", "tag", "sort", "limit", "offset"}, Response: []models.Task{}},
+		{Name: "getTask", Method: "GET", Path: "/api/tasks/{id}", Query: []string{"render"}, Response: struct {
+			success
+			Task            models.Task `json:"task"`
+			DescriptionHTML string      `json:"description_html,omitempty"`
+		}{}},
+		{Name: "addTask", Method: "POST", Path: "/api/tasks", Body: struct {
+			Title       string             `json:"title"`
+			Description string             `json:"description,omitempty"`
+			Index       []string           `json:"index,omitempty"`
+			DueDate     string             `json:"due_date,omitempty"`
+			Priority    models.Priority    `json:"priority,omitempty"`
+			ListID      int                `json:"list_id,omitempty"`
+			Tags        []string           `json:"tags,omitempty"`
+			Recurrence  *models.Recurrence `json:"recurrence,omitempty"`
+		}{}, Response: taskResponse{}},
+		{Name: "updateTask", Method: "PUT", Path: "/api/tasks/{id}", Body: struct {
+			Title       string             `json:"title"`
+			Description string             `json:"description,omitempty"`
+			Index       []string           `json:"index,omitempty"`
+			DueDate     string             `json:"due_date,omitempty"`
+			Priority    models.Priority    `json:"priority,omitempty"`
+			ListID      int                `json:"list_id,omitempty"`
+			Tags        []string           `json:"tags,omitempty"`
+			Recurrence  *models.Recurrence `json:"recurrence,omitempty"`
+		}{}, Response: taskResponse{}},
+		// due_date と recurrence は省略できて null も送れる（外す）ため、ポインタのポインタで表します
+		{Name: "patchTask", Method: "PATCH", Path: "/api/tasks/{id}", Body: struct {
+			Title       *string             `json:"title,omitempty"`
+			Description *string             `json:"description,omitempty"`
+			Index       []string            `json:"index,omitempty"`
+			Completed   *bool               `json:"completed,omitempty"`
+			DueDate     **string            `json:"due_date,omitempty"`
+			Priority    *models.Priority    `json:"priority,omitempty"`
+			ListID      *int                `json:"list_id,omitempty"`
+			Tags        []string            `json:"tags,omitempty"`
+			Recurrence  **models.Recurrence `json:"recurrence,omitempty"`
+			ParentID    *int                `json:"parent_id,omitempty"`
+		}{}, Response: taskResponse{}},
+		{Name: "listLists", Method: "GET", Path: "/api/lists", Response: struct {
+			success
+			Lists []lists.List `json:"lists"`
+		}{}},
+		{Name: "createList", Method: "POST", Path: "/api/lists", Body: listRequest{}, Response: listResponse{}},
+		{Name: "getList", Method: "GET", Path: "/api/lists/{id}", Response: listResponse{}},
+		{Name: "renameList", Method: "PUT", Path: "/api/lists/{id}", Body: listRequest{}, Response: listResponse{}},
+		{Name: "deleteList", Method: "DELETE", Path: "/api/lists/{id}", Response: success{}},
+		{Name: "duplicateList", Method: "POST", Path: "/api/lists/{id}/duplicate", Body: struct {
+			Name         string `json:"name,omitempty"`
+			IncludeTasks bool   `json:"include_tasks,omitempty"`
+		}{}, Response: struct {
+			success
+			List  lists.List    `json:"list"`
+			Tasks []models.Task `json:"tasks"`
+		}{}},
+		{Name: "getListTimeline", Method: "GET", Path: "/api/lists/{id}/timeline", Response: struct {
+			success
+			Timeline timeline.Timeline `json:"timeline"`
+		}{}},
+		{Name: "toggleTask", Method: "PUT", Path: "/api/tasks/{id}/toggle", Response: success{}},
+		{Name: "deleteTask", Method: "DELETE", Path: "/api/tasks/{id}", Response: success{}},
+		{Name: "listTrash", Method: "GET", Path: "/api/trash", Response: struct {
+			success
+			Tasks []models.Task `json:"tasks"`
+		}{}},
+		{Name: "restoreTask", Method: "POST", Path: "/api/tasks/{id}/restore", Response: taskResponse{}},
+		{Name: "undo", Method: "POST", Path: "/api/undo", Response: struct {
+			success
+			Operation models.Operation `json:"operation"`
+			Task      models.Task      `json:"task"`
+		}{}},
+		{Name: "claimTask", Method: "POST", Path: "/api/tasks/{id}/claim", Body: claimRequest{}, Response: taskResponse{}},
+		{Name: "releaseTask", Method: "DELETE", Path: "/api/tasks/{id}/claim", Body: claimRequest{}, Response: taskResponse{}},
+		{Name: "setReminder", Method: "PUT", Path: "/api/tasks/{id}/reminder", Body: struct {
+			RemindAt time.Time `json:"remind_at"`
+		}{}, Response: taskResponse{}},
+		{Name: "dismissReminder", Method: "DELETE", Path: "/api/tasks/{id}/reminder", Response: taskResponse{}},
+		{Name: "snoozeReminder", Method: "POST", Path: "/api/tasks/{id}/reminder/snooze", Body: struct {
+			Minutes int `json:"minutes,omitempty"`
+		}{}, Response: taskResponse{}},
+		{Name: "listSubtasks", Method: "GET", Path: "/api/tasks/{id}/subtasks", Response: struct {
+			success
+			Subtasks []models.Task   `json:"subtasks"`
+			Progress models.Progress `json:"progress"`
+		}{}},
+		{Name: "addSubtask", Method: "POST", Path: "/api/tasks/{id}/subtasks", Body: struct {
+			Title string   `json:"title"`
+			Index []string `json:"index,omitempty"`
+		}{}, Response: struct {
+			success
+			Task     models.Task     `json:"task"`
+			Progress models.Progress `json:"progress"`
+		}{}},
+		{Name: "listComments", Method: "GET", Path: "/api/tasks/{id}/comments", Response: struct {
+			success
+			Comments []models.Comment `json:"comments"`
+		}{}},
+		{Name: "addComment", Method: "POST", Path: "/api/tasks/{id}/comments", Body: struct {
+			Author string `json:"author,omitempty"`
+			Body   string `json:"body"`
+		}{}, Response: struct {
+			success
+			Comment models.Comment `json:"comment"`
+		}{}},
+		{Name: "deleteComment", Method: "DELETE", Path: "/api/tasks/{id}/comments/{commentID}", Response: success{}},
+		{Name: "getTaskHistory", Method: "GET", Path: "/api/tasks/{id}/history", Response: struct {
+			success
+			History []models.HistoryEntry `json:"history"`
+		}{}},
+		{Name: "setEstimate", Method: "PUT", Path: "/api/tasks/{id}/estimate", Body: struct {
+			Minutes int `json:"minutes"`
+		}{}, Response: success{}},
+		{Name: "setPriority", Method: "PATCH", Path: "/api/tasks/{id}/priority", Body: struct {
+			Priority models.Priority `json:"priority"`
+		}{}, Response: taskResponse{}},
+		{Name: "addTag", Method: "POST", Path: "/api/tasks/{id}/tags", Body: struct {
+			Tag string `json:"tag"`
+		}{}, Response: taskResponse{}},
+		{Name: "removeTag", Method: "DELETE", Path: "/api/tasks/{id}/tags/{tag}", Response: taskResponse{}},
+		{Name: "createShortLink", Method: "POST", Path: "/api/tasks/{id}/shortlink", Response: struct {
+			success
+			Shortcode string `json:"shortcode"`
+			URL       string `json:"url"`
+		}{}},
+		{Name: "startTimer", Method: "POST", Path: "/api/tasks/{id}/timer/start", Response: timeEntryResponse{}},
+		{Name: "stopTimer", Method: "POST", Path: "/api/tasks/{id}/timer/stop", Response: timeEntryResponse{}},
+		{Name: "listTimeEntries", Method: "GET", Path: "/api/tasks/{id}/time-entries", Response: struct {
+			success
+			Entries        []models.TimeEntry `json:"entries"`
+			TrackedSeconds int64              `json:"tracked_seconds"`
+		}{}},
+		{Name: "editTimeEntry", Method: "PUT", Path: "/api/tasks/{id}/time-entries/{entryID}", Body: struct {
+			Start string  `json:"start"`
+			End   *string `json:"end,omitempty"`
+		}{}, Response: timeEntryResponse{}},
+		{Name: "deleteTimeEntry", Method: "DELETE", Path: "/api/tasks/{id}/time-entries/{entryID}", Response: timeEntryResponse{}},
+		{Name: "listTaskPomodoros", Method: "GET", Path: "/api/tasks/{id}/pomodoros", Response: sessionsResponse{}},
+		{Name: "startPomodoro", Method: "POST", Path: "/api/tasks/{id}/pomodoros", Body: struct {
+			Minutes int `json:"minutes,omitempty"`
+		}{}, BodyOptional: true, Response: sessionResponse{}},
+		{Name: "stopPomodoro", Method: "POST", Path: "/api/pomodoros/{id}/stop", Response: sessionResponse{}},
+		{Name: "completePomodoro", Method: "POST", Path: "/api/pomodoros/{id}/complete", Response: sessionResponse{}},
+		{Name: "dailyPomodoros", Method: "GET", Path: "/api/pomodoros", Query: []string{"date", "tz"}, Response: struct {
+			sessionsResponse
+			Date string `json:"date"`
+		}{}},
+		{Name: "getAgenda", Method: "GET", Path: "/api/agenda", Query: []string{"tz"}, Response: struct {
+			success
+			Agenda agenda.Agenda `json:"agenda"`
+		}{}},
+		{Name: "searchTasks", Method: "GET", Path: "/api/tasks/search", Query: []string{"q", "limit"}, Response: struct {
+			success
+			Results []models.SearchResult `json:"results"`
+		}{}},
+		{Name: "listSuggestedTasks", Method: "GET", Path: "/api/tasks/suggested", Query: []string{"limit", "tz"}, Response: struct {
+			success
+			Suggestions []agenda.Suggestion `json:"suggestions"`
+		}{}},
+		{Name: "getReview", Method: "GET", Path: "/api/review", Query: []string{"week", "tz"}, Response: struct {
+			success
+			Review agenda.Review `json:"review"`
+		}{}},
+		{Name: "getCalendar", Method: "GET", Path: "/api/calendar", Query: []string{"month", "tz"}, Response: struct {
+			success
+			Calendar agenda.Calendar `json:"calendar"`
+		}{}},
+		{Name: "getArchive", Method: "GET", Path: "/api/archive", Query: []string{"page", "per_page", "tz"}, Response: struct {
+			success
+			Archive agenda.Archive `json:"archive"`
+		}{}},
+		{Name: "listWebhooks", Method: "GET", Path: "/api/webhooks", Response: []webhooks.Webhook{}},
+		{Name: "addWebhook", Method: "POST", Path: "/api/webhooks", Body: webhooks.Webhook{}, BodyOmit: []string{"id"}, Response: struct {
+			success
+			Webhook webhooks.Webhook `json:"webhook"`
+		}{}},
+		{Name: "deleteWebhook", Method: "DELETE", Path: "/api/webhooks/{id}", Response: success{}},
+		{Name: "listRules", Method: "GET", Path: "/api/rules", Response: struct {
+			success
+			Rules []rules.Rule `json:"rules"`
+		}{}},
+		{Name: "addRule", Method: "POST", Path: "/api/rules", Body: rules.Rule{}, BodyOmit: []string{"id"}, Response: struct {
+			success
+			Rule rules.Rule `json:"rule"`
+		}{}},
+		{Name: "deleteRule", Method: "DELETE", Path: "/api/rules/{id}", Response: success{}},
+		{Name: "getE2E", Method: "GET", Path: "/api/e2e", Response: struct {
+			success
+			Enabled bool       `json:"enabled"`
+			Keys    *e2ee.Keys `json:"keys"`
+		}{}},
+		{Name: "setE2EKeys", Method: "PUT", Path: "/api/e2e/keys", Body: e2ee.Keys{}, BodyOmit: []string{"created_at"}, Response: struct {
+			success
+			Keys e2ee.Keys `json:"keys"`
+		}{}},
+		{Name: "getBoard", Method: "GET", Path: "/api/board", Query: []string{"swimlanes", "list"}, Response: struct {
+			success
+			Board board.Board `json:"board"`
+		}{}},
+		{Name: "listBoardColumns", Method: "GET", Path: "/api/board/columns", Query: []string{"list"}, Response: struct {
+			success
+			Columns []board.Column `json:"columns"`
+		}{}},
+		{Name: "addBoardColumn", Method: "POST", Path: "/api/board/columns", Query: []string{"list"}, Body: struct {
+			Name     string `json:"name"`
+			Done     bool   `json:"done,omitempty"`
+			Position *int   `json:"position,omitempty"`
+		}{}, Response: columnResponse{}},
+		{Name: "updateBoardColumn", Method: "PUT", Path: "/api/board/columns/{id}", Query: []string{"list"}, Body: board.ColumnUpdate{}, Response: columnResponse{}},
+		{Name: "deleteBoardColumn", Method: "DELETE", Path: "/api/board/columns/{id}", Query: []string{"list"}, Response: success{}},
+		{Name: "moveBoardTask", Method: "PUT", Path: "/api/board/tasks/{id}", Query: []string{"list"}, Body: struct {
+			ColumnID int  `json:"column_id"`
+			Position *int `json:"position,omitempty"`
+		}{}, Response: taskResponse{}},
+		{Name: "getTimeline", Method: "GET", Path: "/api/timeline", Response: struct {
+			success
+			Timeline timeline.Timeline `json:"timeline"`
+		}{}},
+		{Name: "getDependencies", Method: "GET", Path: "/api/tasks/{id}/dependencies", Response: dependenciesResponse{}},
+		{Name: "setDependencies", Method: "PUT", Path: "/api/tasks/{id}/dependencies", Body: struct {
+			DependsOn []int `json:"depends_on"`
+		}{}, Response: dependenciesResponse{}},
+		{Name: "getFeatures", Method: "GET", Path: "/api/features", Response: struct {
+			success
+			Search   bool `json:"search"`
+			LiveSync bool `json:"live_sync"`
+		}{}},
+		{Name: "getSuggestions", Method: "GET", Path: "/api/suggestions", Response: struct {
+			success
+			Enabled bool `json:"enabled"`
+		}{}},
+		{Name: "suggestSubtasks", Method: "POST", Path: "/api/tasks/{id}/suggest-subtasks", Response: struct {
+			success
+			TaskID      int      `json:"task_id"`
+			Suggestions []string `json:"suggestions"`
+		}{}},
+		{Name: "acceptSubtasks", Method: "POST", Path: "/api/tasks/{id}/suggest-subtasks/accept", Body: struct {
+			Subtasks []string `json:"subtasks"`
+		}{}, Response: struct {
+			success
+			Tasks []models.Task `json:"tasks"`
+		}{}},
+		{Name: "getSyncDocs", Method: "GET", Path: "/api/sync", Response: syncResponse{}},
+		{Name: "sync", Method: "POST", Path: "/api/sync", Body: struct {
+			Docs []crdt.Doc `json:"docs"`
+		}{}, Response: syncResponse{}},
+		{Name: "register", Method: "POST", Path: "/api/auth/register", Body: credentials{}, Response: userResponse{}},
+		{Name: "login", Method: "POST", Path: "/api/auth/login", Body: credentials{}, Response: userResponse{}},
+		{Name: "resendVerification", Method: "POST", Path: "/api/auth/verify/resend", Body: struct {
+			Name string `json:"name"`
+		}{}, Response: success{}},
+		{Name: "logout", Method: "POST", Path: "/api/auth/logout", Response: success{}},
+		{Name: "refreshSession", Method: "POST", Path: "/api/auth/refresh", Response: userResponse{}},
+		{Name: "getCurrentUser", Method: "GET", Path: "/api/auth/me", Response: userResponse{}},
+		{Name: "changePassword", Method: "POST", Path: "/api/auth/password", Body: struct {
+			Password    string `json:"password"`
+			NewPassword string `json:"new_password"`
+		}{}, Response: userResponse{}},
+		{Name: "listAPIKeys", Method: "GET", Path: "/api/keys", Response: struct {
+			success
+			Keys []accounts.APIKey `json:"keys"`
+		}{}},
+		{Name: "createAPIKey", Method: "POST", Path: "/api/keys", Body: struct {
+			Name string `json:"name"`
+		}{}, Response: struct {
+			success
+			Key   accounts.APIKey `json:"key"`
+			Token string          `json:"token"`
+		}{}},
+		{Name: "revokeAPIKey", Method: "DELETE", Path: "/api/keys/{id}", Response: success{}},
+		{Name: "getAPIKeyUsage", Method: "GET", Path: "/api/keys/{id}/usage", Response: struct {
+			success
+			Usage accounts.KeyUsage `json:"usage"`
+		}{}},
+		{Name: "listSessions", Method: "GET", Path: "/api/sessions", Response: struct {
+			success
+			Sessions []accounts.Session `json:"sessions"`
+		}{}},
+		{Name: "logoutEverywhere", Method: "DELETE", Path: "/api/sessions", Response: struct {
+			success
+			Revoked int `json:"revoked"`
+		}{}},
+		{Name: "revokeSession", Method: "DELETE", Path: "/api/sessions/{id}", Response: success{}},
+	}
+	for _, e := range endpoints {
+		g.Endpoint(e)
+	}
+	return g
+}
+
+func main() {
+	output := flag.String("o", "frontend/api.ts", "書き出すファイル")
+	openAPIOutput := flag.String("openapi", "", "OpenAPI の文書を書き出すファイル（空なら書き出しません）")
+	flag.Parse()
+
+	g := generator()
+	source, err := g.Generate(header)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, source, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *openAPIOutput == "" {
+		return
+	}
+	document, err := g.OpenAPI(openAPITitle, openAPIVersion, openAPIDescription)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*openAPIOutput, append(document, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
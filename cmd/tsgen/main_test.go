@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeneratedClientIsUpToDate は frontend/api.ts が Go の型と揃っていることを確認します
+// 失敗したら go generate ./cmd/tsgen で作り直してください
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	want, err := generator().Generate(header)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "frontend", "api.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read the generated client: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("frontend/api.ts is out of date; run go generate ./cmd/tsgen")
+	}
+}
+
+// TestGeneratedOpenAPIIsUpToDate は handlers/openapi.json が Go の型と揃っていることを確認します
+func TestGeneratedOpenAPIIsUpToDate(t *testing.T) {
+	want, err := generator().OpenAPI(openAPITitle, openAPIVersion, openAPIDescription)
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "handlers", "openapi.json"))
+	if err != nil {
+		t.Fatalf("Failed to read the generated document: %v", err)
+	}
+	if !bytes.Equal(got, append(want, '\n')) {
+		t.Error("handlers/openapi.json is out of date; run go generate ./cmd/tsgen")
+	}
+}
+
+func TestMainWritesClient(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "api.ts")
+	openAPI := filepath.Join(t.TempDir(), "openapi.json")
+	os.Args = []string{"tsgen", "-o", output, "-openapi", openAPI}
+	main()
+
+	data, err := os.ReadFile(output)
+	if err != nil || !bytes.Contains(data, []byte("export interface Task {")) {
+		t.Errorf("Expected the client to be written, got %v", err)
+	}
+	data, err = os.ReadFile(openAPI)
+	if err != nil || !bytes.Contains(data, []byte(`"operationId": "listTasks"`)) {
+		t.Errorf("Expected the OpenAPI document to be written, got %v", err)
+	}
+}
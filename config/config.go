@@ -0,0 +1,677 @@
+// Package config はサーバの設定を、既定値・YAML の設定ファイル・環境変数・コマンドラインのフラグの順に重ねて読み込みます
+// 後のものほど優先します（フラグが最優先）。外部サービス連携やバックアップの設定（JIRA_* など）も、これまでの環境変数の名前のまま読み込みます
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"todo-app/accounts"
+	"todo-app/leader"
+	"todo-app/logging"
+	"todo-app/trash"
+)
+
+// ログの詳しさです（log_level）
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// Config はサーバの設定です
+// Listen / SocketMode: 待ち受けるアドレス（":8080" や "unix:/run/todo.sock"）と Unix ドメインソケットの権限（8進数）
+// Dev: 開発モード。テンプレートと静的ファイルをリクエストごとに読み込み直し、キャッシュを無効にします
+// StaticDir: 開発モードで CSS や JavaScript などの静的ファイルを読み込むディレクトリ（通常はバイナリに埋め込んだファイルを返します）
+// PublicURL / AdminToken: 絶対 URL の起点と、管理用エンドポイントの Bearer トークン
+// Store: タスクの保存先
+// ListsFile / UsersFile: リストとユーザーの情報を保存するファイル（空ならリストはメモリ上だけ、ユーザーアカウントは無効）
+// InviteOnly: ユーザー登録に、管理者が /api/admin/invites で発行した招待コードを求めます
+// SCIMToken: ID プロバイダからユーザーとグループを同期する SCIM（/scim/v2/）の Bearer トークン（空なら SCIM は無効）
+// Workspaces: 起動時に作るワークスペース（"family:うちの家族, team" のようにカンマ区切り）
+// E2EKeyFile / SyncStateFile / WebhookQueueFile: 暗号化の鍵の情報・端末との同期の状態・Webhook の配信のキューを保存するファイル
+// LogLevel / LogFormat: ログの詳しさ（debug・info・warn・error）と形式（key=value の text か、1行1つの JSON の json）
+// Features: 機能ごとのオン・オフ
+// WebhookRetryInterval / IntegrationInterval: 失敗した Webhook を再送する間隔と、外部サービスと同期する間隔
+// TrashRetention: 削除したタスクをごみ箱に残す期間。過ぎたものは完全に削除します
+// TrustedProxies: X-Forwarded-For と X-Real-IP を信頼するリバースプロキシ（"10.0.0.0/8, unix" のようにカンマ区切りの CIDR か IP アドレス。unix は Unix ドメインソケット）
+// Jira / Google / Notion / StaleDigest / LLM: 外部サービス連携（それぞれ必要な値がなければ連携しません）
+// Backup / SMTP / Leader: 定期バックアップ・メールの送信・複数のインスタンスからの leader の選出
+// ExecHooksFile: タスクの変更で実行するコマンドフックの設定（JSON）のファイル
+type Config struct {
+	Listen           string
+	SocketMode       string
+	Dev              bool
+	StaticDir        string
+	PublicURL        string
+	AdminToken       string
+	TrustedProxies   string
+	Store            Store
+	ListsFile        string
+	UsersFile        string
+	VerifyEmail      bool
+	InviteOnly       bool
+	SCIMToken        string
+	Workspaces       string
+	E2EKeyFile       string
+	SyncStateFile    string
+	WebhookQueueFile string
+	LogLevel         string
+	LogFormat        string
+	Features         Features
+	APIKeys          APIKeys
+	Password         Password
+	ExecHooksFile    string
+
+	Jira        Jira
+	Google      Google
+	Notion      Notion
+	StaleDigest StaleDigest
+	LLM         LLM
+	Backup      Backup
+	SMTP        SMTP
+	Captcha     Captcha
+	Leader      Leader
+
+	WebhookRetryInterval time.Duration
+	IntegrationInterval  time.Duration
+	TrashRetention       time.Duration
+}
+
+// Store はタスクの保存先の設定です
+// Driver / DSN: 保存先のドライバ（memory・file・git など）と、その保存先（file ならファイルのパス）
+// GitRemote / GitBranch: git のとき、コミットのたびに push するリモートとブランチ
+// MaxTasks: 保持できるタスクの件数の上限（0 なら無制限）
+type Store struct {
+	Driver    string
+	DSN       string
+	GitRemote string
+	GitBranch string
+	MaxTasks  int
+}
+
+// Jira は Jira の課題を取り込む連携の設定です。JQL がなければ連携しません
+// CredentialsFile: ユーザーごとの認証情報を保存する JSON ファイル（キーは "default" かユーザーアカウントの ID）
+// BaseURL / Email / APIToken: "default" ユーザー（全体のタスク）の認証情報
+// DoneTransition: タスクを完了したときに実行する課題の遷移の名前
+type Jira struct {
+	JQL             string
+	CredentialsFile string
+	BaseURL         string
+	Email           string
+	APIToken        string
+	DoneTransition  string
+}
+
+// Google は Google Tasks と Google カレンダーの連携の設定です。RefreshToken がなければどちらも連携しません
+// TokenURL / TasksURL / CalendarURL: API のエンドポイント（テスト用、省略可）
+// TasksList: 同期するタスクリストの名前（"@default" で既定のリスト、空なら Google Tasks とは同期しません）
+// TasksConflict: 競合したときに優先する側（local・remote・completed）
+// CalendarName: 期限付きのタスクを予定にするカレンダーの名前（空なら Google カレンダーには反映しません）
+type Google struct {
+	ClientID      string
+	ClientSecret  string
+	RefreshToken  string
+	TokenURL      string
+	TasksList     string
+	TasksConflict string
+	TasksURL      string
+	CalendarName  string
+	CalendarURL   string
+}
+
+// Notion は Notion のデータベースへの書き出しの設定です。Token と DatabaseID がなければ書き出せません
+// Properties: プロパティ名の対応（JSON、例: {"title":"Task","completed":"Done"}）
+// ListName: List プロパティに書き込むリストの名前 / URL: API のエンドポイント（テスト用、省略可）
+type Notion struct {
+	Token      string
+	DatabaseID string
+	Properties string
+	ListName   string
+	URL        string
+}
+
+// StaleDigest は放置されているタスクの一覧を定期的に送る設定です。URL がなければ送りません
+// OlderThan: 放置されているとみなす期間（例: 2w、空なら 30d）/ Interval: 送る間隔
+type StaleDigest struct {
+	URL       string
+	OlderThan string
+	Interval  time.Duration
+}
+
+// LLM はタスクを小さな作業に分ける案を作る、OpenAI 互換の API の設定です。URL も APIKey もなければ使いません
+type LLM struct {
+	URL    string
+	APIKey string
+	Model  string
+}
+
+// Backup は定期バックアップの設定です。Destination がなければバックアップしません
+// Destination: 保存先（s3://bucket/prefix、webdav+https://host/path、dropbox:///path、file:///dir）
+// Key: base64 でエンコードした 32 バイトの暗号鍵 / Retention: 保持する世代数（0 なら削除しません）/ Interval: バックアップの間隔
+// AWS* / S3Endpoint・WebDAV*・DropboxToken: 保存先ごとの認証情報
+type Backup struct {
+	Destination        string
+	Key                string
+	Retention          int
+	Interval           time.Duration
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSRegion          string
+	S3Endpoint         string
+	WebDAVUsername     string
+	WebDAVPassword     string
+	DropboxToken       string
+}
+
+// SMTP はメールを送る SMTP サーバの設定です。Addr がなければメールは送りません
+// Username / Password: 設定したときだけ認証します
+type SMTP struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Captcha はログインで求める CAPTCHA の設定です。VerifyURL がなければ求めません
+// VerifyURL / Secret: 応答を確かめる siteverify API（reCAPTCHA・hCaptcha・Turnstile）の URL とシークレットキー
+// After: IP アドレスかユーザー名が続けてこの回数ログインに失敗すると CAPTCHA を求めます（0 なら毎回）
+type Captcha struct {
+	VerifyURL string
+	Secret    string
+	After     int
+}
+
+// Leader は複数のインスタンスから定期的な処理を動かす leader を選ぶ設定です。LockFile がなければ選ばず、すべての処理を動かします
+// LockFile: すべてのインスタンスから読み書きできる共有のファイルシステム上のリースのファイル
+// ID: インスタンスの ID（空ならホスト名とプロセス ID）/ TTL: リースの有効期間
+type Leader struct {
+	LockFile string
+	ID       string
+	TTL      time.Duration
+}
+
+// APIKeys はユーザーの API キーの設定です
+// DailyLimit / MonthlyLimit: キーごとに1日（UTC）と1か月に受け付けるリクエスト数の上限（0 なら無制限）
+type APIKeys struct {
+	DailyLimit   int
+	MonthlyLimit int
+}
+
+// Password はユーザー登録とパスワードの変更で求めるパスワードの条件です
+// MinLength: 最小のバイト数（最大は 128 バイト）/ History: 変更で使えない、直前までのパスワードの数（今のパスワードを含みます。0 なら確かめません）
+// BreachCheck / BreachURL: 漏洩したパスワードを k-匿名性の range API で断るかどうかと、その URL（空なら Pwned Passwords）
+type Password struct {
+	MinLength   int
+	History     int
+	BreachCheck bool
+	BreachURL   string
+}
+
+// Features は止められる機能です。どれも既定では有効です
+// Search: キーワード検索（GET /api/tasks/search）
+// LiveSync: 変更の通知（/ws の WebSocket と /api/events の Server-Sent Events）
+type Features struct {
+	Search   bool
+	LiveSync bool
+}
+
+// Default は既定の設定を返します
+func Default() Config {
+	return Config{
+		Listen:               ":8080",
+		SocketMode:           "0660",
+		StaticDir:            "static",
+		Store:                Store{Driver: "memory"},
+		LogLevel:             LogInfo,
+		LogFormat:            logging.FormatText,
+		Features:             Features{Search: true, LiveSync: true},
+		WebhookRetryInterval: 10 * time.Second,
+		IntegrationInterval:  5 * time.Minute,
+		TrashRetention:       trash.DefaultRetention,
+		StaleDigest:          StaleDigest{Interval: 7 * 24 * time.Hour},
+		Backup:               Backup{Retention: 7, Interval: 24 * time.Hour},
+		Password:             Password{MinLength: accounts.MinPasswordLength, History: accounts.DefaultPasswordHistory},
+		Captcha:              Captcha{After: 3},
+		Leader:               Leader{TTL: leader.DefaultTTL},
+	}
+}
+
+// setting は1つの設定の読み込み方です
+// key: 設定ファイルのキー（入れ子は . でつなぎます。空なら設定ファイルでは指定できません）
+// env / flag: 環境変数とフラグの名前（空ならそれでは指定できません）
+// unless: この環境変数があるときは env を使いません（古い環境変数との互換のため）
+// boolFlag: -dev のように値なしで指定できるフラグかどうか
+// apply: 文字列の値を Config に入れます
+type setting struct {
+	key      string
+	env      string
+	flag     string
+	usage    string
+	unless   string
+	boolFlag bool
+	apply    func(c *Config, value string) error
+}
+
+// settings はすべての設定です
+var settings = []setting{
+	{key: "listen", env: "TODO_LISTEN", flag: "listen", usage: "待ち受けるアドレス（:8080 のような TCP か unix:/run/todo.sock のような Unix ドメインソケット）",
+		apply: stringValue(func(c *Config) *string { return &c.Listen })},
+	{env: "PORT", unless: "TODO_LISTEN",
+		apply: func(c *Config, value string) error { c.Listen = ":" + value; return nil }},
+	{key: "socket_mode", env: "TODO_SOCKET_MODE", flag: "socket-mode", usage: "Unix ドメインソケットの権限（8進数）",
+		apply: stringValue(func(c *Config) *string { return &c.SocketMode })},
+	{key: "dev", env: "TODO_DEV", flag: "dev", boolFlag: true, usage: "テンプレートと静的ファイルをリクエストごとに読み込み直し、キャッシュを無効にする",
+		apply: boolValue(func(c *Config) *bool { return &c.Dev })},
+	{key: "static_dir", env: "TODO_STATIC_DIR", flag: "static-dir", usage: "開発モードで CSS や JavaScript などの静的ファイルを読み込むディレクトリ",
+		apply: stringValue(func(c *Config) *string { return &c.StaticDir })},
+	{key: "public_url", env: "PUBLIC_URL",
+		apply: stringValue(func(c *Config) *string { return &c.PublicURL })},
+	{key: "admin_token", env: "ADMIN_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.AdminToken })},
+	{key: "trusted_proxies", env: "TODO_TRUSTED_PROXIES", flag: "trusted-proxies", usage: "X-Forwarded-For を信頼するリバースプロキシ（カンマ区切りの CIDR か IP アドレス、unix は Unix ドメインソケット）",
+		apply: stringValue(func(c *Config) *string { return &c.TrustedProxies })},
+	{key: "store.driver", env: "TODO_STORE", flag: "store", usage: "タスクの保存先のドライバ（memory・file・git など）",
+		apply: stringValue(func(c *Config) *string { return &c.Store.Driver })},
+	{key: "store.dsn", env: "TODO_STORE_DSN", flag: "store-dsn", usage: "タスクの保存先（file ならファイルのパス、git ならリポジトリのディレクトリ）",
+		apply: stringValue(func(c *Config) *string { return &c.Store.DSN })},
+	{env: "TODO_GIT_DIR", unless: "TODO_STORE",
+		apply: func(c *Config, value string) error { c.Store.Driver, c.Store.DSN = "git", value; return nil }},
+	{key: "store.git_remote", env: "TODO_GIT_REMOTE",
+		apply: stringValue(func(c *Config) *string { return &c.Store.GitRemote })},
+	{key: "store.git_branch", env: "TODO_GIT_BRANCH",
+		apply: stringValue(func(c *Config) *string { return &c.Store.GitBranch })},
+	{key: "store.max_tasks", env: "TODO_MAX_TASKS",
+		apply: intValue(func(c *Config) *int { return &c.Store.MaxTasks })},
+	{key: "lists_file", env: "TODO_LISTS_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.ListsFile })},
+	{key: "users_file", env: "TODO_USERS_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.UsersFile })},
+	{key: "verify_email", env: "TODO_VERIFY_EMAIL",
+		apply: boolValue(func(c *Config) *bool { return &c.VerifyEmail })},
+	{key: "invite_only", env: "TODO_INVITE_ONLY",
+		apply: boolValue(func(c *Config) *bool { return &c.InviteOnly })},
+	{key: "scim_token", env: "TODO_SCIM_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.SCIMToken })},
+	{key: "workspaces", env: "TODO_WORKSPACES",
+		apply: stringValue(func(c *Config) *string { return &c.Workspaces })},
+	{key: "e2e_key_file", env: "E2E_KEY_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.E2EKeyFile })},
+	{key: "sync_state_file", env: "SYNC_STATE_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.SyncStateFile })},
+	{key: "webhook_queue_file", env: "WEBHOOK_QUEUE_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.WebhookQueueFile })},
+	{key: "log_level", env: "TODO_LOG_LEVEL", flag: "log-level", usage: "ログの詳しさ（debug・info・warn・error）",
+		apply: stringValue(func(c *Config) *string { return &c.LogLevel })},
+	{key: "log_format", env: "TODO_LOG_FORMAT", flag: "log-format", usage: "ログの形式（text・json）",
+		apply: stringValue(func(c *Config) *string { return &c.LogFormat })},
+	{key: "features.search", env: "TODO_FEATURE_SEARCH",
+		apply: boolValue(func(c *Config) *bool { return &c.Features.Search })},
+	{key: "features.live_sync", env: "TODO_FEATURE_LIVE_SYNC",
+		apply: boolValue(func(c *Config) *bool { return &c.Features.LiveSync })},
+	{key: "webhook_retry_interval", env: "WEBHOOK_RETRY_INTERVAL",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.WebhookRetryInterval })},
+	{key: "integration_interval", env: "INTEGRATION_INTERVAL",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.IntegrationInterval })},
+	{key: "trash_retention", env: "TODO_TRASH_RETENTION",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.TrashRetention })},
+	{key: "api_keys.daily_limit", env: "TODO_API_KEY_DAILY_LIMIT",
+		apply: intValue(func(c *Config) *int { return &c.APIKeys.DailyLimit })},
+	{key: "api_keys.monthly_limit", env: "TODO_API_KEY_MONTHLY_LIMIT",
+		apply: intValue(func(c *Config) *int { return &c.APIKeys.MonthlyLimit })},
+	{key: "password.min_length", env: "TODO_PASSWORD_MIN_LENGTH",
+		apply: intValue(func(c *Config) *int { return &c.Password.MinLength })},
+	{key: "password.history", env: "TODO_PASSWORD_HISTORY",
+		apply: intValue(func(c *Config) *int { return &c.Password.History })},
+	{key: "password.breach_check", env: "TODO_PASSWORD_BREACH_CHECK",
+		apply: boolValue(func(c *Config) *bool { return &c.Password.BreachCheck })},
+	{key: "password.breach_url", env: "TODO_PASSWORD_BREACH_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Password.BreachURL })},
+	{key: "exec_hooks_file", env: "EXEC_HOOKS_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.ExecHooksFile })},
+
+	{key: "jira.jql", env: "JIRA_JQL",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.JQL })},
+	{key: "jira.credentials_file", env: "JIRA_CREDENTIALS_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.CredentialsFile })},
+	{key: "jira.base_url", env: "JIRA_BASE_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.BaseURL })},
+	{key: "jira.email", env: "JIRA_EMAIL",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.Email })},
+	{key: "jira.api_token", env: "JIRA_API_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.APIToken })},
+	{key: "jira.done_transition", env: "JIRA_DONE_TRANSITION",
+		apply: stringValue(func(c *Config) *string { return &c.Jira.DoneTransition })},
+
+	{key: "google.client_id", env: "GOOGLE_CLIENT_ID",
+		apply: stringValue(func(c *Config) *string { return &c.Google.ClientID })},
+	{key: "google.client_secret", env: "GOOGLE_CLIENT_SECRET",
+		apply: stringValue(func(c *Config) *string { return &c.Google.ClientSecret })},
+	{key: "google.refresh_token", env: "GOOGLE_REFRESH_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.Google.RefreshToken })},
+	{key: "google.token_url", env: "GOOGLE_TOKEN_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Google.TokenURL })},
+	{key: "google.tasks_list", env: "GOOGLE_TASKS_LIST",
+		apply: stringValue(func(c *Config) *string { return &c.Google.TasksList })},
+	{key: "google.tasks_conflict", env: "GOOGLE_TASKS_CONFLICT",
+		apply: stringValue(func(c *Config) *string { return &c.Google.TasksConflict })},
+	{key: "google.tasks_url", env: "GOOGLE_TASKS_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Google.TasksURL })},
+	{key: "google.calendar_name", env: "GOOGLE_CALENDAR_NAME",
+		apply: stringValue(func(c *Config) *string { return &c.Google.CalendarName })},
+	{key: "google.calendar_url", env: "GOOGLE_CALENDAR_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Google.CalendarURL })},
+
+	{key: "notion.token", env: "NOTION_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.Notion.Token })},
+	{key: "notion.database_id", env: "NOTION_DATABASE_ID",
+		apply: stringValue(func(c *Config) *string { return &c.Notion.DatabaseID })},
+	{key: "notion.properties", env: "NOTION_PROPERTIES",
+		apply: stringValue(func(c *Config) *string { return &c.Notion.Properties })},
+	{key: "notion.list_name", env: "NOTION_LIST_NAME",
+		apply: stringValue(func(c *Config) *string { return &c.Notion.ListName })},
+	{key: "notion.url", env: "NOTION_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Notion.URL })},
+
+	{key: "stale_digest.url", env: "STALE_DIGEST_URL",
+		apply: stringValue(func(c *Config) *string { return &c.StaleDigest.URL })},
+	{key: "stale_digest.older_than", env: "STALE_DIGEST_OLDER_THAN",
+		apply: stringValue(func(c *Config) *string { return &c.StaleDigest.OlderThan })},
+	{key: "stale_digest.interval", env: "STALE_DIGEST_INTERVAL",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.StaleDigest.Interval })},
+
+	{key: "llm.url", env: "LLM_URL",
+		apply: stringValue(func(c *Config) *string { return &c.LLM.URL })},
+	{key: "llm.api_key", env: "LLM_API_KEY",
+		apply: stringValue(func(c *Config) *string { return &c.LLM.APIKey })},
+	{key: "llm.model", env: "LLM_MODEL",
+		apply: stringValue(func(c *Config) *string { return &c.LLM.Model })},
+
+	{key: "backup.destination", env: "BACKUP_DESTINATION",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.Destination })},
+	{key: "backup.key", env: "BACKUP_KEY",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.Key })},
+	{key: "backup.retention", env: "BACKUP_RETENTION",
+		apply: intValue(func(c *Config) *int { return &c.Backup.Retention })},
+	{key: "backup.interval", env: "BACKUP_INTERVAL",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.Backup.Interval })},
+	{key: "backup.aws_access_key_id", env: "AWS_ACCESS_KEY_ID",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.AWSAccessKeyID })},
+	{key: "backup.aws_secret_access_key", env: "AWS_SECRET_ACCESS_KEY",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.AWSSecretAccessKey })},
+	{key: "backup.aws_region", env: "AWS_REGION",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.AWSRegion })},
+	{key: "backup.s3_endpoint", env: "S3_ENDPOINT",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.S3Endpoint })},
+	{key: "backup.webdav_username", env: "WEBDAV_USERNAME",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.WebDAVUsername })},
+	{key: "backup.webdav_password", env: "WEBDAV_PASSWORD",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.WebDAVPassword })},
+	{key: "backup.dropbox_token", env: "DROPBOX_TOKEN",
+		apply: stringValue(func(c *Config) *string { return &c.Backup.DropboxToken })},
+
+	{key: "smtp.addr", env: "SMTP_ADDR",
+		apply: stringValue(func(c *Config) *string { return &c.SMTP.Addr })},
+	{key: "smtp.from", env: "SMTP_FROM",
+		apply: stringValue(func(c *Config) *string { return &c.SMTP.From })},
+	{key: "smtp.username", env: "SMTP_USERNAME",
+		apply: stringValue(func(c *Config) *string { return &c.SMTP.Username })},
+	{key: "smtp.password", env: "SMTP_PASSWORD",
+		apply: stringValue(func(c *Config) *string { return &c.SMTP.Password })},
+
+	{key: "captcha.verify_url", env: "CAPTCHA_VERIFY_URL",
+		apply: stringValue(func(c *Config) *string { return &c.Captcha.VerifyURL })},
+	{key: "captcha.secret", env: "CAPTCHA_SECRET",
+		apply: stringValue(func(c *Config) *string { return &c.Captcha.Secret })},
+	{key: "captcha.after", env: "CAPTCHA_AFTER",
+		apply: intValue(func(c *Config) *int { return &c.Captcha.After })},
+
+	{key: "leader.lock_file", env: "LEADER_LOCK_FILE",
+		apply: stringValue(func(c *Config) *string { return &c.Leader.LockFile })},
+	{key: "leader.id", env: "LEADER_ID",
+		apply: stringValue(func(c *Config) *string { return &c.Leader.ID })},
+	{key: "leader.ttl", env: "LEADER_TTL",
+		apply: durationValue(func(c *Config) *time.Duration { return &c.Leader.TTL })},
+}
+
+// Load は fs に設定のフラグ（と設定ファイルを指定する -config）を登録して args を読み取り、設定を読み込みます
+// 設定ファイルは -config か環境変数 TODO_CONFIG で指定します（どちらもなければ読みません）
+// fs にほかのフラグが登録されていれば、それらも一緒に読み取ります
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	path := fs.String("config", "", "設定ファイル（YAML）のパス（環境変数 TODO_CONFIG でも指定できます）")
+	flags := map[string]string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			fs.Var(&flagValue{name: s.flag, boolFlag: s.boolFlag, values: flags}, s.flag, s.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *path == "" {
+		*path = getenv("TODO_CONFIG")
+	}
+	c := Default()
+	if *path != "" {
+		if err := c.loadFile(*path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := c.loadEnv(getenv); err != nil {
+		return Config{}, err
+	}
+	for _, s := range settings {
+		value, ok := flags[s.flag]
+		if s.flag == "" || !ok {
+			continue
+		}
+		if err := s.apply(&c, value); err != nil {
+			return Config{}, fmt.Errorf("-%s: %w", s.flag, err)
+		}
+	}
+	return c, c.Validate()
+}
+
+// FromEnv は既定値に環境変数だけを重ねた設定を返します（設定ファイルとフラグは使いません）
+func FromEnv(getenv func(string) string) (Config, error) {
+	c := Default()
+	if err := c.loadEnv(getenv); err != nil {
+		return Config{}, err
+	}
+	return c, c.Validate()
+}
+
+// loadFile は path の YAML の設定ファイルの値を c に入れます。知らないキーはエラーにします
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	values, err := parseYAML(data)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byKey := map[string]setting{}
+	for _, s := range settings {
+		if s.key != "" {
+			byKey[s.key] = s
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return values[keys[i]].line < values[keys[j]].line })
+	for _, key := range keys {
+		s, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("config file %s: line %d: unknown setting %q", path, values[key].line, key)
+		}
+		if err := s.apply(c, values[key].value); err != nil {
+			return fmt.Errorf("config file %s: line %d: %s: %w", path, values[key].line, key, err)
+		}
+	}
+	return nil
+}
+
+// loadEnv は設定されている（空でない）環境変数の値を c に入れます
+func (c *Config) loadEnv(getenv func(string) string) error {
+	for _, s := range settings {
+		value := getenv(s.env)
+		if s.env == "" || value == "" || s.unless != "" && getenv(s.unless) != "" {
+			continue
+		}
+		if err := s.apply(c, value); err != nil {
+			return fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+	return nil
+}
+
+// Validate は設定の誤りを返します
+func (c Config) Validate() error {
+	switch {
+	case c.Listen == "":
+		return errors.New("listen must not be empty")
+	case c.Store.Driver == "":
+		return errors.New("store.driver must not be empty")
+	case c.Store.MaxTasks < 0:
+		return fmt.Errorf("store.max_tasks must not be negative, got %d", c.Store.MaxTasks)
+	case c.WebhookRetryInterval <= 0:
+		return fmt.Errorf("webhook_retry_interval must be positive, got %s", c.WebhookRetryInterval)
+	case c.IntegrationInterval <= 0:
+		return fmt.Errorf("integration_interval must be positive, got %s", c.IntegrationInterval)
+	case c.TrashRetention <= 0:
+		return fmt.Errorf("trash_retention must be positive, got %s", c.TrashRetention)
+	case c.StaleDigest.Interval <= 0:
+		return fmt.Errorf("stale_digest.interval must be positive, got %s", c.StaleDigest.Interval)
+	case c.Backup.Retention < 0:
+		return fmt.Errorf("backup.retention must not be negative, got %d", c.Backup.Retention)
+	case c.Backup.Interval <= 0:
+		return fmt.Errorf("backup.interval must be positive, got %s", c.Backup.Interval)
+	case c.VerifyEmail && c.SMTP.Addr == "":
+		return errors.New("verify_email requires smtp.addr")
+	case c.InviteOnly && (c.UsersFile == "" || c.AdminToken == ""):
+		return errors.New("invite_only requires users_file and admin_token")
+	case c.SCIMToken != "" && c.UsersFile == "":
+		return errors.New("scim_token requires users_file")
+	case c.APIKeys.DailyLimit < 0 || c.APIKeys.MonthlyLimit < 0:
+		return fmt.Errorf("api_keys limits must not be negative, got %d and %d", c.APIKeys.DailyLimit, c.APIKeys.MonthlyLimit)
+	case c.Password.MinLength < 1 || c.Password.MinLength > accounts.MaxPasswordLength:
+		return fmt.Errorf("password.min_length must be 1 to %d, got %d", accounts.MaxPasswordLength, c.Password.MinLength)
+	case c.Password.History < 0:
+		return fmt.Errorf("password.history must not be negative, got %d", c.Password.History)
+	case c.Captcha.After < 0:
+		return fmt.Errorf("captcha.after must not be negative, got %d", c.Captcha.After)
+	case c.Leader.TTL <= 0:
+		return fmt.Errorf("leader.ttl must be positive, got %s", c.Leader.TTL)
+	}
+	switch c.LogLevel {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		return fmt.Errorf("log_level must be %s, %s, %s or %s, got %q", LogDebug, LogInfo, LogWarn, LogError, c.LogLevel)
+	}
+	if c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJSON {
+		return fmt.Errorf("log_format must be %s or %s, got %q", logging.FormatText, logging.FormatJSON, c.LogFormat)
+	}
+	return nil
+}
+
+// Redacted は AdminToken や外部サービスの API キー・パスワードなどの秘密の値を伏せた設定を返します（ログに出すため）
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{
+		&c.AdminToken,
+		&c.SCIMToken,
+		&c.Jira.APIToken,
+		&c.Google.ClientSecret,
+		&c.Google.RefreshToken,
+		&c.Notion.Token,
+		&c.LLM.APIKey,
+		&c.Backup.Key,
+		&c.Backup.AWSSecretAccessKey,
+		&c.Backup.WebDAVPassword,
+		&c.Backup.DropboxToken,
+		&c.SMTP.Password,
+		&c.Captcha.Secret,
+	} {
+		if *secret != "" {
+			*secret = "********"
+		}
+	}
+	return c
+}
+
+// flagValue は設定のフラグです。指定された値を values に記録し、設定ファイルと環境変数の後で Config に入れます
+type flagValue struct {
+	name     string
+	boolFlag bool
+	values   map[string]string
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.values == nil {
+		return ""
+	}
+	return f.values[f.name]
+}
+
+func (f *flagValue) Set(value string) error {
+	f.values[f.name] = value
+	return nil
+}
+
+// IsBoolFlag は -dev のように値なしで指定できるフラグかどうかを flag パッケージに知らせます
+func (f *flagValue) IsBoolFlag() bool {
+	return f.boolFlag
+}
+
+// stringValue は文字列の設定を入れる apply を返します
+func stringValue(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+// boolValue は true / false の設定を入れる apply を返します
+func boolValue(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// intValue は整数の設定を入れる apply を返します
+func intValue(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// durationValue は "30s" や "5m" のような時間の設定を入れる apply を返します
+func durationValue(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration (for example 30s or 5m)", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
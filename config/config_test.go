@@ -0,0 +1,196 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env は map の環境変数を読む getenv です
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+// load は args と環境変数 values で設定を読み込みます
+func load(args []string, values map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("todo-app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args, env(values))
+}
+
+// writeConfig は data を設定ファイルとして書き込み、そのパスを返します
+func writeConfig(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "todo.yaml")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write the config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefault(t *testing.T) {
+	c, err := load(nil, nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c != Default() {
+		t.Errorf("Expected the default config, got %+v", c)
+	}
+	if c.Listen != ":8080" || c.Store.Driver != "memory" || c.LogLevel != LogInfo || !c.Features.Search || !c.Features.LiveSync {
+		t.Errorf("Unexpected default config %+v", c)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+listen: ":9000"
+log_level: warn
+store:
+  driver: file
+  dsn: /var/lib/todo/tasks.json
+  max_tasks: 100
+features:
+  search: false
+integration_interval: 1m
+trash_retention: 168h
+`)
+
+	c, err := load([]string{"-config", path}, nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Listen != ":9000" || c.LogLevel != LogWarn || c.Store.Driver != "file" || c.Store.MaxTasks != 100 || c.Features.Search || !c.Features.LiveSync || c.IntegrationInterval != time.Minute || c.TrashRetention != 7*24*time.Hour {
+		t.Errorf("Expected the values from the config file, got %+v", c)
+	}
+
+	// 環境変数は設定ファイルより、フラグは環境変数より優先します
+	values := map[string]string{"TODO_CONFIG": path, "TODO_LISTEN": ":9001", "TODO_LOG_LEVEL": "debug", "TODO_FEATURE_SEARCH": "true"}
+	c, err = load([]string{"-listen", ":9002", "-dev"}, values)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Listen != ":9002" || c.LogLevel != LogDebug || !c.Features.Search || !c.Dev || c.Store.Driver != "file" {
+		t.Errorf("Expected flags over environment variables over the config file, got %+v", c)
+	}
+
+	// 空の環境変数は設定していないものとして扱います
+	c, err = load([]string{"-config", path}, map[string]string{"TODO_LISTEN": ""})
+	if err != nil || c.Listen != ":9000" {
+		t.Errorf("Expected an empty environment variable to be ignored, got %q %v", c.Listen, err)
+	}
+}
+
+func TestLoadIntegrations(t *testing.T) {
+	path := writeConfig(t, `
+jira:
+  jql: project = OPS
+  base_url: https://example.atlassian.net
+backup:
+  destination: file:///var/backups/todo
+  retention: 3
+stale_digest:
+  interval: 24h
+leader:
+  lock_file: /shared/todo-leader.json
+`)
+
+	// 外部サービス連携の設定も、これまでの環境変数の名前で設定ファイルより優先します
+	values := map[string]string{"TODO_CONFIG": path, "JIRA_API_TOKEN": "token", "BACKUP_INTERVAL": "6h", "LEADER_TTL": "1m"}
+	c, err := load(nil, values)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Jira.JQL != "project = OPS" || c.Jira.BaseURL != "https://example.atlassian.net" || c.Jira.APIToken != "token" {
+		t.Errorf("Unexpected Jira settings %+v", c.Jira)
+	}
+	if c.Backup.Destination != "file:///var/backups/todo" || c.Backup.Retention != 3 || c.Backup.Interval != 6*time.Hour {
+		t.Errorf("Unexpected backup settings %+v", c.Backup)
+	}
+	if c.StaleDigest.Interval != 24*time.Hour || c.Leader.LockFile != "/shared/todo-leader.json" || c.Leader.TTL != time.Minute {
+		t.Errorf("Unexpected stale digest or leader settings %+v %+v", c.StaleDigest, c.Leader)
+	}
+}
+
+func TestLoadListenFromEnv(t *testing.T) {
+	c, _ := load(nil, map[string]string{"PORT": "3000"})
+	if c.Listen != ":3000" {
+		t.Errorf("Expected PORT to be used, got %q", c.Listen)
+	}
+	c, _ = load(nil, map[string]string{"PORT": "3000", "TODO_LISTEN": "unix:/run/todo.sock"})
+	if c.Listen != "unix:/run/todo.sock" {
+		t.Errorf("Expected TODO_LISTEN to take precedence over PORT, got %q", c.Listen)
+	}
+}
+
+func TestLoadLegacyGitDir(t *testing.T) {
+	c, _ := FromEnv(env(map[string]string{"TODO_GIT_DIR": "/var/lib/todo"}))
+	if c.Store.Driver != "git" || c.Store.DSN != "/var/lib/todo" {
+		t.Errorf("Expected TODO_GIT_DIR to select the git driver, got %+v", c.Store)
+	}
+	c, _ = FromEnv(env(map[string]string{"TODO_GIT_DIR": "/var/lib/todo", "TODO_STORE": "file", "TODO_STORE_DSN": "/tmp/tasks.json"}))
+	if c.Store.Driver != "file" || c.Store.DSN != "/tmp/tasks.json" {
+		t.Errorf("Expected TODO_STORE to take precedence over TODO_GIT_DIR, got %+v", c.Store)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{"unknown key", "listen: :8080\nstorage:\n  driver: file", nil, nil, "line 3: unknown setting \"storage.driver\""},
+		{"bad value in file", "store:\n  max_tasks: many", nil, nil, "line 2: store.max_tasks: \"many\" is not an integer"},
+		{"bad env", "", nil, map[string]string{"TODO_FEATURE_LIVE_SYNC": "maybe"}, "TODO_FEATURE_LIVE_SYNC: \"maybe\" is not true or false"},
+		{"bad log level", "", []string{"-log-level", "verbose"}, nil, "log_level must be"},
+		{"bad log format", "log_format: xml", nil, nil, "log_format must be"},
+		{"negative max tasks", "", nil, map[string]string{"TODO_MAX_TASKS": "-1"}, "store.max_tasks must not be negative"},
+		{"zero trash retention", "trash_retention: 0s", nil, nil, "trash_retention must be positive"},
+		{"bad interval", "webhook_retry_interval: 0s", nil, nil, "webhook_retry_interval must be positive"},
+		{"negative backup retention", "", nil, map[string]string{"BACKUP_RETENTION": "-1"}, "backup.retention must not be negative"},
+		{"bad backup interval", "", nil, map[string]string{"BACKUP_INTERVAL": "soon"}, "BACKUP_INTERVAL: \"soon\" is not a duration"},
+		{"zero leader ttl", "leader:\n  ttl: 0s", nil, nil, "leader.ttl must be positive"},
+		{"verify email without smtp", "verify_email: true", nil, nil, "verify_email requires smtp.addr"},
+		{"invite only without admin token", "users_file: users.json\ninvite_only: true", nil, nil, "invite_only requires users_file and admin_token"},
+		{"scim without users file", "scim_token: secret", nil, nil, "scim_token requires users_file"},
+		{"negative api key limit", "api_keys:\n  daily_limit: -1", nil, nil, "api_keys limits must not be negative"},
+		{"zero password length", "password:\n  min_length: 0", nil, nil, "password.min_length must be 1 to 128"},
+		{"negative password history", "", nil, map[string]string{"TODO_PASSWORD_HISTORY": "-1"}, "password.history must not be negative"},
+		{"negative captcha after", "", nil, map[string]string{"CAPTCHA_AFTER": "-1"}, "captcha.after must not be negative"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := tc.args
+			if tc.file != "" {
+				args = append([]string{"-config", writeConfig(t, tc.file)}, args...)
+			}
+			if _, err := load(args, tc.env); err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	if _, err := load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, nil); err == nil {
+		t.Error("Expected an error for a missing config file")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := Default()
+	c.AdminToken = "secret"
+	c.Jira.APIToken = "secret"
+	c.Backup.Key = "secret"
+	c.SMTP.Password = "secret"
+	c.Captcha.Secret = "secret"
+	if got := c.Redacted(); got.AdminToken == "secret" || got.Jira.APIToken == "secret" || got.Backup.Key == "secret" || got.SMTP.Password == "secret" || got.Captcha.Secret == "secret" {
+		t.Errorf("Expected the secrets to be hidden, got %+v", got)
+	}
+	if c.AdminToken != "secret" {
+		t.Error("Expected Redacted not to change the original config")
+	}
+}
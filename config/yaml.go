@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlValue は YAML の1つの値と、書かれていた行の番号です
+type yamlValue struct {
+	value string
+	line  int
+}
+
+// parseYAML は設定ファイルに使う YAML の一部（key: value と、字下げで入れ子にした key: だけの行）を読み取ります
+// 入れ子のキーは "store.driver" のように . でつなぎます。値は引用符（"..." か '...'）で囲めます
+// リスト・複数行の値・アンカーなどには対応していないため、使うと行の番号を付けたエラーを返します
+func parseYAML(data []byte) (map[string]yamlValue, error) {
+	values := map[string]yamlValue{}
+	// parents は字下げの深さごとの親のキーです
+	type parent struct {
+		indent int
+		key    string
+	}
+	var parents []parent
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		line := i + 1
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(text) == "" || text == "---" {
+			continue
+		}
+		if lead := text[:len(text)-len(strings.TrimLeft(text, " \t"))]; strings.Contains(lead, "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", line)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		text = strings.TrimSpace(text)
+		if strings.HasPrefix(text, "- ") || text == "-" {
+			return nil, fmt.Errorf("line %d: lists are not supported", line)
+		}
+
+		colon := strings.Index(text, ":")
+		if colon <= 0 || colon+1 < len(text) && text[colon+1] != ' ' {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+		key := strings.TrimSpace(text[:colon])
+		value := strings.TrimSpace(text[colon+1:])
+
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		if len(parents) == 0 && indent > 0 {
+			return nil, fmt.Errorf("line %d: unexpected indent", line)
+		}
+		full := key
+		if len(parents) > 0 {
+			full = parents[len(parents)-1].key + "." + key
+		}
+
+		if value == "" {
+			parents = append(parents, parent{indent: indent, key: full})
+			continue
+		}
+		unquoted, err := unquoteYAML(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if _, ok := values[full]; ok {
+			return nil, fmt.Errorf("line %d: %s is set twice", line, full)
+		}
+		values[full] = yamlValue{value: unquoted, line: line}
+	}
+	return values, nil
+}
+
+// stripComment は行の # から後ろ（引用符の中は除く）を取り除きます
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML は引用符で囲んだ値の引用符を外します。"..." ではエスケープ（\n など）も読み取ります
+func unquoteYAML(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") ||
+		strings.HasPrefix(value, "&") || strings.HasPrefix(value, "*"):
+		return "", fmt.Errorf("unsupported value %s (quote it to use it as a string)", value)
+	}
+	return value, nil
+}
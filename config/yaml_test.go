@@ -0,0 +1,56 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := `# サーバの設定
+listen: ":9090"
+store:
+  driver: file
+  dsn: '/var/lib/todo/it''s.json'   # コメント
+features:
+  live_sync: false
+admin_token: "a#b"
+`
+	values, err := parseYAML([]byte(data))
+	if err != nil {
+		t.Fatalf("parseYAML failed: %v", err)
+	}
+	expected := map[string]yamlValue{
+		"listen":             {":9090", 2},
+		"store.driver":       {"file", 4},
+		"store.dsn":          {"/var/lib/todo/it's.json", 5},
+		"features.live_sync": {"false", 7},
+		"admin_token":        {"a#b", 8},
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, got %+v", len(expected), values)
+	}
+	for key, want := range expected {
+		if got := values[key]; got != want {
+			t.Errorf("%s: expected %+v, got %+v", key, want, got)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	testCases := []struct {
+		data, expected string
+	}{
+		{"listen :8080", "line 1: expected"},
+		{"store:\n\tdriver: file", "line 2: indent with spaces"},
+		{"workspaces:\n  - family", "line 2: lists are not supported"},
+		{"listen: a\nlisten: b", "line 2: listen is set twice"},
+		{"  listen: :8080", "line 1: unexpected indent"},
+		{"workspaces: [family, team]", "line 1: unsupported value"},
+		{`admin_token: "secret`, "line 1: invalid quoted value"},
+	}
+	for _, tc := range testCases {
+		if _, err := parseYAML([]byte(tc.data)); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("parseYAML(%q): expected an error containing %q, got %v", tc.data, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package crdt は複数の端末がオフラインで編集したタスクを、衝突の確認なしに同じ結果へまとめるための型（CRDT）です
+//
+// タスクは Doc で表し、フィールドごとに最後に書いたものが勝つレジスタ（LWW-register）を持ちます
+// どの順番で何度マージしても同じ結果になる（可換・結合的・冪等）ため、端末とサーバは
+// 持っている Doc を送り合ってマージするだけで同じ状態にそろいます
+package crdt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"todo-app/models"
+)
+
+// Doc のフィールドの名前です。値の JSON は Task の同じ名前のフィールドと同じ形式です
+const (
+	FieldTitle         = "title"
+	FieldCompleted     = "completed"
+	FieldDueDate       = "due_date"
+	FieldScheduledDate = "scheduled_date"
+	FieldPriority      = "priority"
+	FieldEstimate      = "estimate_minutes"
+)
+
+// Fields は Doc が持てるフィールドの名前の一覧です
+var Fields = []string{FieldTitle, FieldCompleted, FieldDueDate, FieldScheduledDate, FieldPriority, FieldEstimate}
+
+// maxUIDLength は Doc の UID の最大の長さです
+const maxUIDLength = 128
+
+// Stamp は書き込みの順番を決める印です
+// Wall: 書き込んだ時刻（Unix ミリ秒）。端末の時計がずれていても、時刻が同じでも順番が決まるよう Replica で比べます
+// Replica: 書き込んだ端末（サーバは "server"）を表す文字列
+type Stamp struct {
+	Wall    int64  `json:"wall"`
+	Replica string `json:"replica"`
+}
+
+// Less は s が o より前の書き込みかを返します
+func (s Stamp) Less(o Stamp) bool {
+	if s.Wall != o.Wall {
+		return s.Wall < o.Wall
+	}
+	return s.Replica < o.Replica
+}
+
+// validate は Stamp に時刻と端末が入っていることを確認します
+func (s Stamp) validate() error {
+	if s.Wall <= 0 {
+		return fmt.Errorf("%w: stamp wall must be positive", models.ErrValidation)
+	}
+	if s.Replica == "" || len(s.Replica) > maxUIDLength {
+		return fmt.Errorf("%w: stamp replica must be 1 to %d characters", models.ErrValidation, maxUIDLength)
+	}
+	return nil
+}
+
+// Register は最後に書いたものが勝つレジスタです
+type Register struct {
+	Value json.RawMessage `json:"value"`
+	Stamp Stamp           `json:"stamp"`
+}
+
+// Merge は r と o のうち後に書いたほうを返します
+// Stamp まで同じときは値の JSON を比べ、どちらからマージしても同じ値を選びます
+func (r Register) Merge(o Register) Register {
+	if r.Stamp.Less(o.Stamp) {
+		return o
+	}
+	if o.Stamp.Less(r.Stamp) {
+		return r
+	}
+	if bytes.Compare(o.Value, r.Value) > 0 {
+		return o
+	}
+	return r
+}
+
+// ORSet は同じ要素を別々の端末で追加・削除してもマージできる集合（observed-remove set）です
+// 追加のたびに一意なタグを付け、削除はそれまでに見えていたタグだけを消すため、
+// 削除と同時に別の端末で追加した要素は残ります（追加が優先）
+// Adds: 要素ごとの追加のタグ
+// Removed: 削除したタグ
+type ORSet struct {
+	Adds    map[string][]string `json:"adds,omitempty"`
+	Removed []string            `json:"removed,omitempty"`
+}
+
+// Add は element を tag で追加します。tag は端末の ID と通し番号を組み合わせるなどして一意にしてください
+func (s *ORSet) Add(element, tag string) {
+	if s.Adds == nil {
+		s.Adds = map[string][]string{}
+	}
+	s.Adds[element] = addTag(s.Adds[element], tag)
+}
+
+// Remove は element のこれまでに見えている追加をすべて取り消します
+func (s *ORSet) Remove(element string) {
+	for _, tag := range s.Adds[element] {
+		s.Removed = addTag(s.Removed, tag)
+	}
+}
+
+// Merge は o の追加と削除を s に取り込みます
+func (s *ORSet) Merge(o ORSet) {
+	for element, tags := range o.Adds {
+		for _, tag := range tags {
+			s.Add(element, tag)
+		}
+	}
+	for _, tag := range o.Removed {
+		s.Removed = addTag(s.Removed, tag)
+	}
+}
+
+// Elements は削除されていない追加が残っている要素を名前の順に返します
+func (s ORSet) Elements() []string {
+	removed := make(map[string]bool, len(s.Removed))
+	for _, tag := range s.Removed {
+		removed[tag] = true
+	}
+	elements := []string{}
+	for element, tags := range s.Adds {
+		for _, tag := range tags {
+			if !removed[tag] {
+				elements = append(elements, element)
+				break
+			}
+		}
+	}
+	sort.Strings(elements)
+	return elements
+}
+
+// addTag は並んだ tags に tag を重複なく加えます
+func addTag(tags []string, tag string) []string {
+	i := sort.SearchStrings(tags, tag)
+	if i < len(tags) && tags[i] == tag {
+		return tags
+	}
+	tags = append(tags, "")
+	copy(tags[i+1:], tags[i:])
+	tags[i] = tag
+	return tags
+}
+
+// Doc は1件のタスクの CRDT です
+// UID: タスクを作った端末が付ける一意な ID（サーバで作ったタスクは "server:{id}"）
+// TaskID: サーバのタスクの ID。サーバが割り当て、端末から送られた値は使いません
+// Fields: フィールドごとのレジスタ
+// Tags: タグの集合（タスクのタグ。端末ごとに付け外ししてもマージできるよう、フィールドとは別に持ちます）
+// Deleted: 削除したか。一度削除したタスクは、ほかの端末の編集とマージしても削除したままです
+type Doc struct {
+	UID     string              `json:"uid"`
+	TaskID  int                 `json:"task_id,omitempty"`
+	Fields  map[string]Register `json:"fields"`
+	Tags    ORSet               `json:"tags"`
+	Deleted bool                `json:"deleted,omitempty"`
+}
+
+// Merge は o を d に取り込み、d が変わったかを返します
+func (d *Doc) Merge(o Doc) bool {
+	before, _ := json.Marshal(d)
+	if d.Fields == nil {
+		d.Fields = map[string]Register{}
+	}
+	for name, register := range o.Fields {
+		if current, ok := d.Fields[name]; ok {
+			register = current.Merge(register)
+		}
+		d.Fields[name] = register
+	}
+	d.Tags.Merge(o.Tags)
+	d.Deleted = d.Deleted || o.Deleted
+	after, _ := json.Marshal(d)
+	return !bytes.Equal(before, after)
+}
+
+// Set は name のフィールドに value を stamp で書き込みます。すでに後の書き込みがあれば変わりません
+func (d *Doc) Set(name string, value interface{}, stamp Stamp) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	d.Merge(Doc{Fields: map[string]Register{name: {Value: data, Stamp: stamp}}})
+	return nil
+}
+
+// Validate は UID とフィールドの名前・値・Stamp を確認し、値の JSON を Task と同じ形式に整えます
+func (d *Doc) Validate() error {
+	if d.UID == "" || len(d.UID) > maxUIDLength {
+		return fmt.Errorf("%w: uid must be 1 to %d characters", models.ErrValidation, maxUIDLength)
+	}
+	for name, register := range d.Fields {
+		if err := register.Stamp.validate(); err != nil {
+			return fmt.Errorf("%s: %s: %w", d.UID, name, err)
+		}
+		value, err := decodeField(name, register.Value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s: %v", models.ErrValidation, d.UID, name, err)
+		}
+		if register.Value, err = json.Marshal(value); err != nil {
+			return err
+		}
+		d.Fields[name] = register
+	}
+	for element := range d.Tags.Adds {
+		if tag, err := models.NormalizeTag(element); err != nil || tag != element {
+			return fmt.Errorf("%w: %s: tag %q must be a lowercase tag name", models.ErrValidation, d.UID, element)
+		}
+	}
+	return nil
+}
+
+// decodeField は name のフィールドの値を Task のフィールドと同じ型で読み込み、値を確認します
+func decodeField(name string, data json.RawMessage) (interface{}, error) {
+	switch name {
+	case FieldTitle:
+		var title string
+		if err := json.Unmarshal(data, &title); err != nil {
+			return nil, err
+		}
+		if title == "" {
+			return nil, fmt.Errorf("title is required")
+		}
+		return title, nil
+	case FieldCompleted:
+		var completed bool
+		err := json.Unmarshal(data, &completed)
+		return completed, err
+	case FieldDueDate, FieldScheduledDate:
+		var date *time.Time
+		err := json.Unmarshal(data, &date)
+		return date, err
+	case FieldPriority:
+		var priority models.Priority
+		if err := json.Unmarshal(data, &priority); err != nil {
+			return nil, err
+		}
+		return priority, priority.Validate()
+	case FieldEstimate:
+		var minutes int
+		if err := json.Unmarshal(data, &minutes); err != nil {
+			return nil, err
+		}
+		if minutes < 0 {
+			return nil, fmt.Errorf("estimate must not be negative")
+		}
+		return minutes, nil
+	}
+	return nil, fmt.Errorf("unknown field")
+}
+
+// Task は d のフィールドの値を書き込んだ task のコピーを返します（ID などフィールドにないものは task のままです）
+// タグは Tags に残っている要素（名前の順）に置き換えます
+func (d Doc) Task(task models.Task) models.Task {
+	task.Tags = nil
+	if tags := d.Tags.Elements(); len(tags) > 0 {
+		task.Tags = tags
+	}
+	for name, register := range d.Fields {
+		switch name {
+		case FieldTitle:
+			json.Unmarshal(register.Value, &task.Title)
+		case FieldCompleted:
+			json.Unmarshal(register.Value, &task.Completed)
+		case FieldDueDate:
+			task.DueDate = nil
+			json.Unmarshal(register.Value, &task.DueDate)
+		case FieldScheduledDate:
+			task.ScheduledDate = nil
+			json.Unmarshal(register.Value, &task.ScheduledDate)
+		case FieldPriority:
+			json.Unmarshal(register.Value, &task.Priority)
+		case FieldEstimate:
+			json.Unmarshal(register.Value, &task.EstimateMinutes)
+		}
+	}
+	return task
+}
+
+// fieldValues は task のフィールドの値を Doc のフィールドの名前ごとに返します
+func fieldValues(task models.Task) map[string]interface{} {
+	return map[string]interface{}{
+		FieldTitle:         task.Title,
+		FieldCompleted:     task.Completed,
+		FieldDueDate:       task.DueDate,
+		FieldScheduledDate: task.ScheduledDate,
+		FieldPriority:      task.Priority,
+		FieldEstimate:      task.EstimateMinutes,
+	}
+}
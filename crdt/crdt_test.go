@@ -0,0 +1,188 @@
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func register(value string, wall int64, replica string) Register {
+	return Register{Value: json.RawMessage(value), Stamp: Stamp{Wall: wall, Replica: replica}}
+}
+
+func TestStampLess(t *testing.T) {
+	tests := []struct {
+		a, b Stamp
+		want bool
+	}{
+		{Stamp{1, "a"}, Stamp{2, "a"}, true},
+		{Stamp{2, "a"}, Stamp{1, "b"}, false},
+		{Stamp{1, "a"}, Stamp{1, "b"}, true},
+		{Stamp{1, "b"}, Stamp{1, "a"}, false},
+		{Stamp{1, "a"}, Stamp{1, "a"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Less(tt.b); got != tt.want {
+			t.Errorf("%v.Less(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterMergeIsCommutative(t *testing.T) {
+	tests := []struct {
+		a, b Register
+		want string
+	}{
+		{register(`"old"`, 1, "a"), register(`"new"`, 2, "b"), `"new"`},
+		{register(`"a"`, 5, "a"), register(`"b"`, 5, "b"), `"b"`},
+		{register(`"x"`, 5, "a"), register(`"y"`, 5, "a"), `"y"`},
+	}
+	for _, tt := range tests {
+		if got := string(tt.a.Merge(tt.b).Value); got != tt.want {
+			t.Errorf("a.Merge(b) = %s, want %s", got, tt.want)
+		}
+		if got := string(tt.b.Merge(tt.a).Value); got != tt.want {
+			t.Errorf("b.Merge(a) = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestORSet(t *testing.T) {
+	var a ORSet
+	a.Add("home", "a:1")
+	a.Add("work", "a:2")
+
+	b := ORSet{}
+	b.Merge(a)
+	b.Remove("home")
+	a.Add("home", "a:3") // b が見ていない追加
+
+	a.Merge(b)
+	b.Merge(a)
+	if got := a.Elements(); !reflect.DeepEqual(got, []string{"home", "work"}) {
+		t.Errorf("expected the concurrent add to win, got %v", got)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected both sets to converge, got %+v and %+v", a, b)
+	}
+
+	a.Remove("home")
+	a.Merge(a)
+	if got := a.Elements(); !reflect.DeepEqual(got, []string{"work"}) {
+		t.Errorf("expected home to be removed, got %v", got)
+	}
+	if got := (ORSet{}).Elements(); len(got) != 0 {
+		t.Errorf("expected an empty set, got %v", got)
+	}
+}
+
+func TestDocMerge(t *testing.T) {
+	a := Doc{UID: "phone:1"}
+	a.Set(FieldTitle, "Buy milk", Stamp{10, "phone"})
+	a.Set(FieldPriority, models.PriorityLow, Stamp{10, "phone"})
+
+	b := Doc{UID: "phone:1"}
+	b.Merge(a)
+	b.Set(FieldTitle, "Buy oat milk", Stamp{20, "laptop"})
+	a.Set(FieldPriority, models.PriorityHigh, Stamp{30, "phone"})
+	b.Tags.Add("shopping", "laptop:1")
+
+	ab, ba := a, b
+	ab.Fields, ba.Fields = copyFields(a.Fields), copyFields(b.Fields)
+	if !ab.Merge(b) || !ba.Merge(a) {
+		t.Fatal("expected Merge to report a change")
+	}
+	if !reflect.DeepEqual(ab, ba) {
+		t.Fatalf("expected merges in either order to converge, got %+v and %+v", ab, ba)
+	}
+	task := ab.Task(models.Task{ID: 3})
+	if task.ID != 3 || task.Title != "Buy oat milk" || task.Priority != models.PriorityHigh {
+		t.Errorf("unexpected merged task %+v", task)
+	}
+	if ab.Merge(b) {
+		t.Error("expected merging the same doc again to change nothing")
+	}
+
+	if err := ab.Set(FieldTitle, "Stale", Stamp{1, "phone"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ab.Task(models.Task{}).Title; got != "Buy oat milk" {
+		t.Errorf("expected an older write to lose, got %q", got)
+	}
+	if err := ab.Set(FieldTitle, func() {}, Stamp{99, "phone"}); err == nil {
+		t.Error("expected Set to reject a value that cannot be encoded")
+	}
+
+	deleted := Doc{UID: "phone:1", Deleted: true}
+	ab.Merge(deleted)
+	ab.Set(FieldTitle, "Revived", Stamp{100, "laptop"})
+	if !ab.Deleted {
+		t.Error("expected a deletion to survive later edits")
+	}
+}
+
+func copyFields(fields map[string]Register) map[string]Register {
+	copied := make(map[string]Register, len(fields))
+	for name, register := range fields {
+		copied[name] = register
+	}
+	return copied
+}
+
+func TestDocTask(t *testing.T) {
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	doc := Doc{UID: "a"}
+	doc.Set(FieldTitle, "Report", Stamp{1, "a"})
+	doc.Set(FieldCompleted, true, Stamp{1, "a"})
+	doc.Set(FieldDueDate, due, Stamp{1, "a"})
+	doc.Set(FieldScheduledDate, nil, Stamp{1, "a"})
+	doc.Set(FieldEstimate, 45, Stamp{1, "a"})
+
+	scheduled := due.AddDate(0, 0, -1)
+	task := doc.Task(models.Task{ScheduledDate: &scheduled, Priority: models.PriorityMedium})
+	if task.Title != "Report" || !task.Completed || task.DueDate == nil || !task.DueDate.Equal(due) ||
+		task.ScheduledDate != nil || task.EstimateMinutes != 45 || task.Priority != models.PriorityMedium {
+		t.Errorf("unexpected task %+v", task)
+	}
+}
+
+func TestDocValidate(t *testing.T) {
+	valid := Doc{UID: "phone:1", Fields: map[string]Register{
+		FieldTitle:    register(`"Tea"`, 1, "phone"),
+		FieldDueDate:  register(`"2026-10-20T09:00:00.000+09:00"`, 1, "phone"),
+		FieldPriority: register(`"high"`, 1, "phone"),
+	}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected a valid doc, got %v", err)
+	}
+	if got := string(valid.Fields[FieldDueDate].Value); got != `"2026-10-20T09:00:00+09:00"` {
+		t.Errorf("expected the date to be normalized, got %s", got)
+	}
+
+	tests := []struct {
+		name string
+		doc  Doc
+	}{
+		{"empty uid", Doc{}},
+		{"unknown field", Doc{UID: "a", Fields: map[string]Register{"owner": register(`"me"`, 1, "a")}}},
+		{"empty title", Doc{UID: "a", Fields: map[string]Register{FieldTitle: register(`""`, 1, "a")}}},
+		{"wrong type", Doc{UID: "a", Fields: map[string]Register{FieldCompleted: register(`"yes"`, 1, "a")}}},
+		{"bad date", Doc{UID: "a", Fields: map[string]Register{FieldDueDate: register(`"tomorrow"`, 1, "a")}}},
+		{"bad priority", Doc{UID: "a", Fields: map[string]Register{FieldPriority: register(`"urgent"`, 1, "a")}}},
+		{"negative estimate", Doc{UID: "a", Fields: map[string]Register{FieldEstimate: register(`-5`, 1, "a")}}},
+		{"bad estimate", Doc{UID: "a", Fields: map[string]Register{FieldEstimate: register(`"5"`, 1, "a")}}},
+		{"bad title", Doc{UID: "a", Fields: map[string]Register{FieldTitle: register(`5`, 1, "a")}}},
+		{"no wall", Doc{UID: "a", Fields: map[string]Register{FieldTitle: register(`"x"`, 0, "a")}}},
+		{"no replica", Doc{UID: "a", Fields: map[string]Register{FieldTitle: register(`"x"`, 1, "")}}},
+		{"empty tag", Doc{UID: "a", Tags: ORSet{Adds: map[string][]string{"": {"a:1"}}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.doc.Validate(); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package crdt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// ServerReplica はサーバが書き込んだ値の Stamp の Replica です
+const ServerReplica = "server"
+
+// Syncer はストアのタスクと、端末から届いた Doc をマージして保持します
+// 同期のたびに次の順で処理し、端末とストアの両方を同じ状態にそろえます
+//  1. 前回の同期からストアで変わったフィールドを、変更日時とサーバの Stamp で Doc に書き込む
+//  2. 端末から届いた Doc をマージする
+//  3. マージで変わった Doc をストアのタスクに反映する（作成・更新・削除）
+//
+// 端末は返された Doc を自分の Doc とマージすれば、サーバと同じ状態になります
+type Syncer struct {
+	path  string
+	store models.TaskStore
+	now   func() time.Time
+
+	mutex sync.Mutex
+	docs  map[string]*Doc
+}
+
+// NewSyncer は store と同期する Syncer を作成します
+// path が空ならメモリ上だけに保持し、指定したファイルがあれば読み込みます
+func NewSyncer(path string, store models.TaskStore) (*Syncer, error) {
+	s := &Syncer{path: path, store: store, now: time.Now, docs: map[string]*Doc{}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var docs []Doc
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range docs {
+		s.docs[docs[i].UID] = &docs[i]
+	}
+	return s, nil
+}
+
+// Docs はストアの変更を取り込んだうえで、すべての Doc を UID の順に返します
+func (s *Syncer) Docs(ctx context.Context) ([]Doc, error) {
+	return s.Sync(ctx, nil)
+}
+
+// Sync は docs をマージしてストアに反映し、マージした後のすべての Doc を UID の順に返します
+// docs の値が不正なら何もマージせずに ErrValidation を返します
+func (s *Syncer) Sync(ctx context.Context, docs []Doc) ([]Doc, error) {
+	for i := range docs {
+		if err := docs[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changed := s.observe(ctx)
+	for _, doc := range docs {
+		current, ok := s.docs[doc.UID]
+		if !ok {
+			if _, ok := doc.Fields[FieldTitle]; !ok && !doc.Deleted {
+				return nil, fmt.Errorf("%w: %s: a new task needs a title", models.ErrValidation, doc.UID)
+			}
+			current = &Doc{UID: doc.UID}
+			s.docs[doc.UID] = current
+		}
+		if current.Merge(doc) {
+			changed = true
+			if err := s.apply(ctx, current); err != nil {
+				s.save()
+				return nil, err
+			}
+		}
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	return s.list(), nil
+}
+
+// observe はストアのタスクを Doc に取り込み、Doc が変わったかを返します
+// 前回から値が変わったフィールドは、タスクの変更日時（Doc の値より前にはしません）とサーバの Stamp で書き込みます
+// ストアからなくなったタスクの Doc は削除したことにします
+func (s *Syncer) observe(ctx context.Context) bool {
+	byTaskID := make(map[int]*Doc, len(s.docs))
+	for _, doc := range s.docs {
+		if doc.TaskID != 0 && !doc.Deleted {
+			byTaskID[doc.TaskID] = doc
+		}
+	}
+
+	changed := false
+	for _, task := range s.store.GetTasks(ctx) {
+		doc, ok := byTaskID[task.ID]
+		if !ok {
+			// バックアップから戻したタスクが削除済みの Doc と同じ ID を使うこともあるため、UID が重ならないようにします
+			uid := ServerReplica + ":" + strconv.Itoa(task.ID)
+			for n := 2; s.docs[uid] != nil; n++ {
+				uid = fmt.Sprintf("%s:%d.%d", ServerReplica, task.ID, n)
+			}
+			doc = &Doc{UID: uid, TaskID: task.ID}
+			s.docs[doc.UID] = doc
+			changed = true
+		}
+		delete(byTaskID, task.ID)
+
+		wall := s.now().UnixMilli()
+		if task.UpdatedAt != nil {
+			wall = task.UpdatedAt.UnixMilli()
+		}
+		for name, value := range fieldValues(task) {
+			data, _ := json.Marshal(value)
+			current, ok := doc.Fields[name]
+			if ok && bytes.Equal(current.Value, data) {
+				continue
+			}
+			stamp := Stamp{Wall: wall, Replica: ServerReplica}
+			if ok && !current.Stamp.Less(stamp) {
+				stamp.Wall = current.Stamp.Wall + 1
+			}
+			doc.Set(name, value, stamp)
+			changed = true
+		}
+		if observeTags(doc, task) {
+			changed = true
+		}
+	}
+	for _, doc := range byTaskID {
+		doc.Deleted = true
+		changed = true
+	}
+	return changed
+}
+
+// observeTags はストアのタスクに付け外ししたタグを doc の Tags に取り込み、変わったかを返します
+// サーバが付けたタグには、それまでの追加の数とタグの名前から作る一意なタグ（"server:{n}:{tag}"）を使います
+// 追加の数は増えるだけのため、同じタグを外してから付け直しても前の追加とは別のタグになります
+func observeTags(doc *Doc, task models.Task) bool {
+	current := doc.Tags.Elements()
+	changed := false
+	for _, tag := range task.Tags {
+		if !containsString(current, tag) {
+			n := len(doc.Tags.Adds[tag]) + 1
+			doc.Tags.Add(tag, ServerReplica+":"+strconv.Itoa(n)+":"+tag)
+			changed = true
+		}
+	}
+	for _, tag := range current {
+		if !task.HasTag(tag) {
+			doc.Tags.Remove(tag)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// sameTags は a と b が順序を除いて同じタグを持つかを返します
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, tag := range a {
+		if !containsString(b, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// apply は doc をストアのタスクに反映します。まだタスクがなければ作成し、削除した Doc のタスクは削除します
+func (s *Syncer) apply(ctx context.Context, doc *Doc) error {
+	if doc.Deleted {
+		if doc.TaskID == 0 {
+			return nil
+		}
+		if err := s.store.DeleteTask(ctx, doc.TaskID); err != nil && !errors.Is(err, models.ErrTaskNotFound) {
+			return err
+		}
+		return nil
+	}
+
+	var task models.Task
+	if doc.TaskID == 0 {
+		created, err := s.store.AddTask(ctx, doc.Task(models.Task{}).Title)
+		if err != nil {
+			return err
+		}
+		doc.TaskID = created.ID
+		task = created
+	} else {
+		found := false
+		for _, t := range s.store.GetTasks(ctx) {
+			if t.ID == doc.TaskID {
+				task, found = t, true
+				break
+			}
+		}
+		if !found {
+			doc.Deleted = true
+			return nil
+		}
+	}
+
+	want := doc.Task(task)
+	var err error
+	if want.Title != task.Title {
+		err = s.store.SetTitle(ctx, task.ID, want.Title)
+	}
+	if err == nil && want.Completed != task.Completed {
+		err = s.store.ToggleTask(ctx, task.ID)
+	}
+	if err == nil && !equalTime(want.DueDate, task.DueDate) {
+		err = s.store.SetDueDate(ctx, task.ID, want.DueDate)
+	}
+	if err == nil && !equalTime(want.ScheduledDate, task.ScheduledDate) {
+		err = s.store.SetScheduledDate(ctx, task.ID, want.ScheduledDate)
+	}
+	if err == nil && want.Priority != task.Priority {
+		err = s.store.SetPriority(ctx, task.ID, want.Priority)
+	}
+	if err == nil && want.EstimateMinutes != task.EstimateMinutes {
+		err = s.store.SetEstimate(ctx, task.ID, want.EstimateMinutes)
+	}
+	if err == nil && !sameTags(want.Tags, task.Tags) {
+		tags := want.Tags
+		err = s.store.UpdateTask(ctx, task.ID, models.TaskUpdate{Tags: &tags})
+	}
+	return err
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// list はすべての Doc のコピーを UID の順に返します
+func (s *Syncer) list() []Doc {
+	docs := make([]Doc, 0, len(s.docs))
+	for _, doc := range s.docs {
+		var copied Doc
+		data, _ := json.Marshal(doc)
+		json.Unmarshal(data, &copied)
+		docs = append(docs, copied)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].UID < docs[j].UID })
+	return docs
+}
+
+// save は Doc をファイルへ書き出します。path が空なら何もしません
+// 書き出しの途中で止まっても壊れたファイルを残さないよう、一時ファイルを書いてから置き換えます
+func (s *Syncer) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sync-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,312 @@
+package crdt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// clientDoc は端末 replica が wall の時刻に作った Doc を返します
+func clientDoc(uid string, wall int64, replica string, values map[string]interface{}) Doc {
+	doc := Doc{UID: uid}
+	for name, value := range values {
+		doc.Set(name, value, Stamp{Wall: wall, Replica: replica})
+	}
+	return doc
+}
+
+func findDoc(docs []Doc, uid string) (Doc, bool) {
+	for _, doc := range docs {
+		if doc.UID == uid {
+			return doc, true
+		}
+	}
+	return Doc{}, false
+}
+
+func findTask(store models.TaskStore, id int) (models.Task, bool) {
+	for _, task := range store.GetTasks(context.Background()) {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	return models.Task{}, false
+}
+
+func TestSyncerDocsIncludesStoreTasks(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Existing")
+	store.SetPriority(ctx, task.ID, models.PriorityHigh)
+
+	syncer, _ := NewSyncer("", store)
+	docs, err := syncer.Docs(ctx)
+	if err != nil {
+		t.Fatalf("Docs failed: %v", err)
+	}
+	if len(docs) != 1 || docs[0].UID != "server:1" || docs[0].TaskID != task.ID {
+		t.Fatalf("expected one server doc, got %+v", docs)
+	}
+	if got := docs[0].Fields[FieldPriority]; string(got.Value) != `"high"` || got.Stamp.Replica != ServerReplica {
+		t.Errorf("unexpected priority register %+v", got)
+	}
+}
+
+func TestSyncerCreatesTasksFromClients(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	syncer, _ := NewSyncer("", store)
+
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	docs, err := syncer.Sync(ctx, []Doc{clientDoc("phone:1", 100, "phone", map[string]interface{}{
+		FieldTitle:     "Written offline",
+		FieldCompleted: true,
+		FieldDueDate:   due,
+		FieldPriority:  models.PriorityLow,
+		FieldEstimate:  30,
+	})})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	doc, _ := findDoc(docs, "phone:1")
+	if doc.TaskID == 0 || len(docs) != 1 {
+		t.Fatalf("expected the doc to get a task ID, got %+v", docs)
+	}
+	task, ok := findTask(store, doc.TaskID)
+	if !ok || task.Title != "Written offline" || !task.Completed || task.DueDate == nil || !task.DueDate.Equal(due) ||
+		task.Priority != models.PriorityLow || task.EstimateMinutes != 30 {
+		t.Errorf("unexpected task %+v", task)
+	}
+
+	// 反映したタスクは次の同期でサーバの変更として扱いません
+	again, _ := syncer.Docs(ctx)
+	if got, _ := findDoc(again, "phone:1"); got.Fields[FieldTitle].Stamp.Replica != "phone" {
+		t.Errorf("expected the client's write to be kept, got %+v", got.Fields[FieldTitle])
+	}
+}
+
+func TestSyncerConcurrentEditsConverge(t *testing.T) {
+	ctx := context.Background()
+	for _, order := range [][]string{{"phone", "laptop"}, {"laptop", "phone"}} {
+		store := models.NewTodoApp()
+		task, _ := store.AddTask(ctx, "Plan trip")
+		syncer, _ := NewSyncer("", store)
+		base, _ := syncer.Docs(ctx)
+		wall := base[0].Fields[FieldTitle].Stamp.Wall
+
+		edits := map[string]Doc{}
+		for replica, title := range map[string]string{"phone": "Plan trip to Kyoto", "laptop": "Plan trip to Osaka"} {
+			doc := base[0]
+			doc.Fields = copyFields(doc.Fields)
+			offset := int64(1)
+			if replica == "laptop" {
+				offset = 2
+				doc.Set(FieldPriority, models.PriorityHigh, Stamp{Wall: wall + offset, Replica: replica})
+			}
+			doc.Set(FieldTitle, title, Stamp{Wall: wall + offset, Replica: replica})
+			edits[replica] = doc
+		}
+
+		var docs []Doc
+		for _, replica := range order {
+			var err error
+			if docs, err = syncer.Sync(ctx, []Doc{edits[replica]}); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+		}
+		got, _ := findTask(store, task.ID)
+		if got.Title != "Plan trip to Osaka" || got.Priority != models.PriorityHigh {
+			t.Errorf("%v: expected the later edit to win, got %+v", order, got)
+		}
+		if title := docs[0].Task(models.Task{}).Title; title != got.Title {
+			t.Errorf("%v: expected the returned doc to match the store, got %q", order, title)
+		}
+	}
+}
+
+func TestSyncerServerEditsWinOverOlderClientEdits(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Draft")
+	syncer, _ := NewSyncer("", store)
+	base, _ := syncer.Docs(ctx)
+	stale := base[0]
+	stale.Fields = copyFields(stale.Fields)
+	stale.Set(FieldEstimate, 15, Stamp{Wall: 1, Replica: "phone"})
+
+	time.Sleep(2 * time.Millisecond)
+	store.SetTitle(ctx, task.ID, "Edited on the web")
+	if _, err := syncer.Sync(ctx, []Doc{stale}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := findTask(store, task.ID); got.Title != "Edited on the web" {
+		t.Errorf("expected the server edit to survive an older client doc, got %q", got.Title)
+	}
+
+	// 時計が進んでいる端末の値より前の時刻で変更しても、サーバの変更は後の書き込みになります
+	ahead := clientDoc(base[0].UID, time.Now().Add(time.Hour).UnixMilli(), "phone", map[string]interface{}{FieldTitle: "From the future"})
+	syncer.Sync(ctx, []Doc{ahead})
+	store.SetTitle(ctx, task.ID, "Fixed on the web")
+	docs, _ := syncer.Docs(ctx)
+	if got := docs[0].Fields[FieldTitle]; got.Stamp.Replica != ServerReplica || got.Stamp.Wall != ahead.Fields[FieldTitle].Stamp.Wall+1 {
+		t.Errorf("expected the server write to follow the client's stamp, got %+v", got)
+	}
+}
+
+func TestSyncerTags(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Buy milk")
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{AddTags: []string{"shopping", "errand"}})
+	syncer, _ := NewSyncer("", store)
+	docs, _ := syncer.Docs(ctx)
+	if got := docs[0].Tags.Elements(); len(got) != 2 || got[0] != "errand" || got[1] != "shopping" {
+		t.Fatalf("expected the store's tags in the doc, got %v", got)
+	}
+
+	// 端末で外したタグと付けたタグをタスクに反映します
+	edit := Doc{UID: docs[0].UID}
+	edit.Tags.Merge(docs[0].Tags)
+	edit.Tags.Remove("errand")
+	edit.Tags.Add("dairy", "phone:1")
+	if _, err := syncer.Sync(ctx, []Doc{edit}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := findTask(store, task.ID); !sameTags(got.Tags, []string{"shopping", "dairy"}) {
+		t.Errorf("expected the client's tag changes in the store, got %v", got.Tags)
+	}
+
+	// サーバで付け直したタグは、それを見ていない端末が外しても残ります（追加が優先）
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{AddTags: []string{"errand"}})
+	stale := Doc{UID: docs[0].UID}
+	stale.Tags.Merge(edit.Tags)
+	stale.Tags.Remove("shopping")
+	syncer.Sync(ctx, []Doc{stale})
+	if got, _ := findTask(store, task.ID); !sameTags(got.Tags, []string{"dairy", "errand"}) {
+		t.Errorf("expected the re-added tag to survive, got %v", got.Tags)
+	}
+
+	invalid := Doc{UID: docs[0].UID}
+	invalid.Tags.Add("Two Words", "phone:2")
+	if _, err := syncer.Sync(ctx, []Doc{invalid}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an invalid tag, got %v", err)
+	}
+}
+
+func TestSyncerDeletes(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	first, _ := store.AddTask(ctx, "Delete from phone")
+	second, _ := store.AddTask(ctx, "Delete on the web")
+	syncer, _ := NewSyncer("", store)
+	docs, _ := syncer.Docs(ctx)
+
+	deleted := docs[0]
+	deleted.Deleted = true
+	if _, err := syncer.Sync(ctx, []Doc{deleted}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, ok := findTask(store, first.ID); ok {
+		t.Error("expected the task to be deleted")
+	}
+
+	store.DeleteTask(ctx, second.ID)
+	docs, _ = syncer.Docs(ctx)
+	if doc, _ := findDoc(docs, "server:2"); !doc.Deleted {
+		t.Errorf("expected a task deleted on the server to be marked deleted, got %+v", doc)
+	}
+
+	// 削除済みのタスクへの編集はタスクを戻しません
+	edit := docs[1]
+	edit.Fields = copyFields(edit.Fields)
+	edit.Deleted = false
+	edit.Set(FieldTitle, "Edited offline", Stamp{Wall: time.Now().Add(time.Hour).UnixMilli(), Replica: "phone"})
+	syncer.Sync(ctx, []Doc{edit, {UID: "phone:9", Deleted: true}})
+	if tasks := store.GetTasks(ctx); len(tasks) != 0 {
+		t.Errorf("expected no tasks to come back, got %+v", tasks)
+	}
+}
+
+func TestSyncerRestoredTaskGetsNewUID(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Restored later")
+	syncer, _ := NewSyncer("", store)
+	syncer.Docs(ctx)
+
+	store.DeleteTask(ctx, task.ID)
+	syncer.Docs(ctx)
+	store.ReplaceTasks(ctx, []models.Task{task})
+	docs, _ := syncer.Docs(ctx)
+	if len(docs) != 2 || !docs[0].Deleted || docs[1].UID != "server:1.2" || docs[1].Deleted {
+		t.Errorf("expected the restored task to get a new doc, got %+v", docs)
+	}
+}
+
+func TestSyncerRejectsInvalidDocs(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	syncer, _ := NewSyncer("", store)
+
+	invalid := []Doc{
+		clientDoc("phone:1", 1, "phone", map[string]interface{}{FieldPriority: "urgent"}),
+		clientDoc("phone:2", 1, "phone", map[string]interface{}{FieldPriority: models.PriorityHigh}),
+	}
+	for _, doc := range invalid {
+		if _, err := syncer.Sync(ctx, []Doc{doc}); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", doc.UID, err)
+		}
+	}
+	if len(store.GetTasks(ctx)) != 0 {
+		t.Error("expected nothing to be created")
+	}
+}
+
+func TestSyncerReturnsStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp(models.WithMaxTasks(1))
+	store.AddTask(ctx, "Only one")
+	syncer, _ := NewSyncer("", store)
+
+	doc := clientDoc("phone:1", 1, "phone", map[string]interface{}{FieldTitle: "One too many"})
+	if _, err := syncer.Sync(ctx, []Doc{doc}); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected the store's ErrConflict, got %v", err)
+	}
+}
+
+func TestSyncerPersistsDocs(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sync.json")
+	store := models.NewTodoApp()
+	syncer, err := NewSyncer(path, store)
+	if err != nil {
+		t.Fatalf("NewSyncer failed: %v", err)
+	}
+	syncer.Sync(ctx, []Doc{clientDoc("phone:1", 1, "phone", map[string]interface{}{FieldTitle: "Persisted"})})
+
+	reloaded, err := NewSyncer(path, store)
+	if err != nil {
+		t.Fatalf("NewSyncer failed to reload: %v", err)
+	}
+	docs, _ := reloaded.Docs(ctx)
+	if len(docs) != 1 || docs[0].UID != "phone:1" || docs[0].TaskID != 1 {
+		t.Errorf("expected the doc to be reloaded, got %+v", docs)
+	}
+
+	os.WriteFile(path, []byte("{"), 0644)
+	if _, err := NewSyncer(path, store); err == nil {
+		t.Error("expected a broken state file to be rejected")
+	}
+	if _, err := NewSyncer(t.TempDir(), store); err == nil {
+		t.Error("expected a directory to be rejected")
+	}
+	broken := &Syncer{path: filepath.Join(t.TempDir(), "missing", "sync.json"), store: store, now: time.Now, docs: map[string]*Doc{}}
+	if _, err := broken.Docs(ctx); err == nil {
+		t.Error("expected a save error to be returned")
+	}
+}
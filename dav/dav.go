@@ -0,0 +1,833 @@
+// Package dav はタスクを WebDAV のファイルとして公開し、ファイルマネージャや rclone からタスクを読み書きできるようにします
+// リストはコレクション（フォルダ）、タスクは1件1つの Markdown ファイルで、作成・編集・削除はタスクの操作になります
+// コレクションの作成（MKCOL）と名前の変更はリストの作成と名前の変更に、コレクションをまたぐ移動はタスクのリストの変更になります
+package dav
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/export"
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+// ListName はどのリストにも入っていないタスクを置くコレクションの名前です
+// 同じ名前のリストはこのコレクションに隠れて見えません
+const ListName = export.DefaultListName
+
+// maxFileSize は PUT で受け付けるファイルの大きさの上限です
+const maxFileSize = 64 << 10
+
+// taskLine は Obsidian Tasks 形式の1行（- [x] タイトル 📅 2025-03-01 ^task-1）です
+var taskLine = regexp.MustCompile(`^- \[([ xX])\] (.*?)(?: 📅 (\d{4}-\d{2}-\d{2}))?(?: \^task-\d+)?$`)
+
+// scratchFile はタスクではない一時ファイル（エディタの保存途中のファイルなど）です
+type scratchFile struct {
+	data     []byte
+	modified time.Time
+}
+
+// scratchKey は一時ファイルを置いたコレクション（リストの ID）と名前です
+type scratchKey struct {
+	listID int
+	name   string
+}
+
+// Handler はタスクを WebDAV で公開する http.Handler です
+// ファイル名は ID から付けた "{id}.md" ですが、WebDAV で作成したタスクは作成したときの名前のまま見せます（メモリ上にだけ覚えます）
+// .md 以外や "." / "~" で始まる名前のファイルはタスクにせず、一時ファイルとしてメモリ上に置きます
+type Handler struct {
+	store  models.TaskStore
+	lists  *lists.Store
+	prefix string
+	base   string
+	now    func() time.Time
+
+	mutex   sync.Mutex
+	names   map[int]string
+	scratch map[scratchKey]scratchFile
+}
+
+// NewHandler は store のタスクを prefix（"/dav/" など）の下で、taskLists のリストごとのコレクションに分けて公開する Handler を作成します
+// taskLists が nil ならどのリストにも入っていないタスクのコレクション（ListName）だけを見せます
+// base はサーバを /w/{slug} などの下で動かすときのパスの接頭辞で、返す URL の先頭に付けます
+func NewHandler(store models.TaskStore, taskLists *lists.Store, prefix, base string) *Handler {
+	return &Handler{
+		store:   store,
+		lists:   taskLists,
+		prefix:  prefix,
+		base:    base,
+		now:     time.Now,
+		names:   make(map[int]string),
+		scratch: make(map[scratchKey]scratchFile),
+	}
+}
+
+// resource は URL が指すものです
+// list が空ならルートのコレクション、name が空ならリストのコレクション、そうでなければファイルです
+// listID はコレクションのリストの ID（ListName は 0）、exists はそのコレクションのリストがあるかです
+type resource struct {
+	list   string
+	listID int
+	exists bool
+	name   string
+}
+
+// isCollection はコレクション（フォルダ）を指しているかを返します
+func (res resource) isCollection() bool {
+	return res.name == ""
+}
+
+// collectionName はリストのコレクションの名前です。パスの区切りになる / は _ に置き換えます
+func collectionName(list lists.List) string {
+	return strings.ReplaceAll(list.Name, "/", "_")
+}
+
+// collections は ListName とすべてのリストのコレクションを返します
+func (h *Handler) collections() []resource {
+	collections := []resource{{list: ListName, exists: true}}
+	if h.lists == nil {
+		return collections
+	}
+	for _, list := range h.lists.List() {
+		if name := collectionName(list); name != ListName {
+			collections = append(collections, resource{list: name, listID: list.ID, exists: true})
+		}
+	}
+	return collections
+}
+
+// parsePath は prefix より後のパスを読み取ります。存在しうる場所でなければ ok が false です
+// まだないコレクション（MKCOL で作るものなど）は exists が false の resource を返します
+func (h *Handler) parsePath(p string) (resource, bool) {
+	if !strings.HasPrefix(p+"/", h.prefix) {
+		return resource{}, false
+	}
+	rest := strings.Trim(strings.TrimPrefix(p, h.prefix), "/")
+	if rest == "" {
+		return resource{}, true
+	}
+	segments := strings.Split(rest, "/")
+	if len(segments) > 2 {
+		return resource{}, false
+	}
+	res := resource{list: segments[0]}
+	for _, c := range h.collections() {
+		if c.list == res.list {
+			res.listID, res.exists = c.listID, true
+		}
+	}
+	if len(segments) == 2 {
+		res.name = segments[1]
+	}
+	return res, true
+}
+
+// href は res の URL のパスを返します（コレクションは / で終わります）
+func (h *Handler) href(res resource) string {
+	p := h.base + h.prefix
+	if res.list != "" {
+		p += res.list + "/"
+	}
+	p += res.name
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// isScratch は name をタスクではなく一時ファイルとして扱うかを返します
+func isScratch(name string) bool {
+	return !strings.HasSuffix(name, ".md") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~")
+}
+
+// fileName は task のファイル名を返します。h.mutex を持っている間に呼び出します
+func (h *Handler) fileName(task models.Task) string {
+	if name, ok := h.names[task.ID]; ok {
+		return name
+	}
+	return strconv.Itoa(task.ID) + ".md"
+}
+
+// findTask は listID のリストのコレクションにある name のファイルのタスクを探します
+func (h *Handler) findTask(ctx context.Context, listID int, name string) (models.Task, bool) {
+	tasks := h.store.GetTasks(ctx)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, task := range tasks {
+		if task.ListID == listID && h.fileName(task) == name {
+			return task, true
+		}
+	}
+	return models.Task{}, false
+}
+
+// Content は task のファイルの内容（Obsidian Tasks 形式の1行）を返します
+func Content(task models.Task) string {
+	check := " "
+	if task.Completed {
+		check = "x"
+	}
+	line := fmt.Sprintf("- [%s] %s", check, strings.Join(strings.Fields(task.Title), " "))
+	if task.DueDate != nil {
+		line += " 📅 " + task.DueDate.Format("2006-01-02")
+	}
+	return line + "\n"
+}
+
+// parsed はファイルの内容から読み取ったタスクです
+// checkbox が false の場合（"- [ ]" で始まらない場合）はタイトルだけを変え、完了状態と期限は変えません
+type parsed struct {
+	title     string
+	checkbox  bool
+	completed bool
+	due       *time.Time
+}
+
+// parseContent はファイルの最初の空でない行からタスクを読み取ります
+func parseContent(data []byte) (parsed, error) {
+	var line string
+	for _, l := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(strings.TrimPrefix(l, "\ufeff")); line != "" {
+			break
+		}
+	}
+	m := taskLine.FindStringSubmatch(line)
+	if m == nil {
+		return parsed{title: line}, nil
+	}
+	p := parsed{title: strings.TrimSpace(m[2]), checkbox: true, completed: m[1] != " "}
+	if m[3] != "" {
+		due, err := time.Parse("2006-01-02", m[3])
+		if err != nil {
+			return parsed{}, fmt.Errorf("%w: invalid due date %q", models.ErrValidation, m[3])
+		}
+		p.due = &due
+	}
+	return p, nil
+}
+
+// apply は読み取った内容をタスクに反映します
+func (h *Handler) apply(ctx context.Context, task models.Task, p parsed) error {
+	if p.title != "" && p.title != task.Title {
+		if err := h.store.SetTitle(ctx, task.ID, p.title); err != nil {
+			return err
+		}
+	}
+	if !p.checkbox {
+		return nil
+	}
+	if p.completed != task.Completed {
+		if err := h.store.ToggleTask(ctx, task.ID); err != nil {
+			return err
+		}
+	}
+	if !sameDate(p.due, task.DueDate) {
+		return h.store.SetDueDate(ctx, task.ID, p.due)
+	}
+	return nil
+}
+
+// sameDate は2つの期限が同じ日付か（どちらもなしか）を返します
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// create は listID のリストに name のファイルとして新しいタスクを作成します
+// 内容が空の場合（ファイルマネージャで新しいファイルを作ったときなど）は、拡張子を除いたファイル名をタイトルにします
+func (h *Handler) create(ctx context.Context, listID int, name string, p parsed) error {
+	title := p.title
+	if title == "" {
+		title = strings.TrimSuffix(name, ".md")
+	}
+	task, err := h.store.AddTaskWith(ctx, title, models.TaskUpdate{ListID: &listID})
+	if err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	if name != strconv.Itoa(task.ID)+".md" {
+		h.names[task.ID] = name
+	}
+	h.mutex.Unlock()
+	p.title = ""
+	return h.apply(ctx, task, p)
+}
+
+// ServeHTTP は WebDAV のメソッドを振り分けます
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res, ok := h.parsePath(r.URL.Path)
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	// まだないコレクションに対してはコレクションの作成だけを受け付けます
+	if res.list != "" && !res.exists && r.Method != "MKCOL" && r.Method != http.MethodOptions {
+		if r.Method == http.MethodPut {
+			http.Error(w, "Collection does not exist", http.StatusConflict)
+		} else {
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("DAV", "1, 2")
+		w.Header().Set("MS-Author-Via", "DAV")
+		w.Header().Set("Allow", "OPTIONS, PROPFIND, PROPPATCH, GET, HEAD, PUT, DELETE, MOVE, MKCOL, LOCK, UNLOCK")
+	case "PROPFIND":
+		err = h.propfind(w, r, res)
+	case "PROPPATCH":
+		err = h.proppatch(w, r, res)
+	case http.MethodGet, http.MethodHead:
+		err = h.get(w, r, res)
+	case http.MethodPut:
+		err = h.put(w, r, res)
+	case http.MethodDelete:
+		err = h.delete(w, r, res)
+	case "MOVE":
+		err = h.move(w, r, res)
+	case "MKCOL":
+		err = h.mkcol(w, r, res)
+	case "LOCK":
+		err = h.lock(w, r, res)
+	case "UNLOCK":
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// errNotFound は指したファイルがないときのエラーです
+var errNotFound = errors.New("not found")
+
+// errForbidden はリストにできないコレクション（入れ子のコレクションなど）を作ろうとしたときのエラーです
+var errForbidden = errors.New("collections can only be created at the top level")
+
+// errIsCollection はコレクションにファイルの操作をしたときのエラーです
+var errIsCollection = errors.New("collections cannot be changed")
+
+// writeError は err を WebDAV の状態コードで返します
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotFound), errors.Is(err, models.ErrTaskNotFound), errors.Is(err, lists.ErrListNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, errIsCollection):
+		http.Error(w, err.Error(), http.StatusMethodNotAllowed)
+	case errors.Is(err, models.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errPreconditionFailed):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, errBadGateway):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// get はファイルの内容を返します
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, res resource) error {
+	if res.isCollection() {
+		return errIsCollection
+	}
+	f, ok := h.file(r.Context(), res.listID, res.name)
+	if !ok {
+		return errNotFound
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Set("ETag", f.etag())
+	w.Header().Set("Last-Modified", f.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+	if r.Method == http.MethodGet {
+		w.Write(f.data)
+	}
+	return nil
+}
+
+// put はファイルを書き込みます。タスクのファイルならタスクを更新し、まだなければ作成します
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, res resource) error {
+	if res.isCollection() {
+		return errIsCollection
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxFileSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxFileSize {
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+		return nil
+	}
+
+	created, err := h.write(r.Context(), res.listID, res.name, data)
+	if err != nil {
+		return err
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return nil
+}
+
+// write は listID のリストのコレクションの name のファイルに data を書き込み、新しく作ったかを返します
+func (h *Handler) write(ctx context.Context, listID int, name string, data []byte) (bool, error) {
+	if isScratch(name) {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		key := scratchKey{listID: listID, name: name}
+		_, exists := h.scratch[key]
+		h.scratch[key] = scratchFile{data: data, modified: h.now()}
+		return !exists, nil
+	}
+
+	p, err := parseContent(data)
+	if err != nil {
+		return false, err
+	}
+	task, exists := h.findTask(ctx, listID, name)
+	if !exists {
+		return true, h.create(ctx, listID, name, p)
+	}
+	// 書き込みの途中で空にするクライアントがあるため、空のファイルではタスクを変えません
+	return false, h.apply(ctx, task, p)
+}
+
+// delete はファイルを削除します。タスクのファイルならタスクを削除します
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, res resource) error {
+	if res.isCollection() {
+		return errIsCollection
+	}
+	if err := h.remove(r.Context(), res.listID, res.name); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// remove は listID のリストのコレクションの name のファイルを削除します
+func (h *Handler) remove(ctx context.Context, listID int, name string) error {
+	if isScratch(name) {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		key := scratchKey{listID: listID, name: name}
+		if _, ok := h.scratch[key]; !ok {
+			return errNotFound
+		}
+		delete(h.scratch, key)
+		return nil
+	}
+	task, ok := h.findTask(ctx, listID, name)
+	if !ok {
+		return errNotFound
+	}
+	if err := h.store.DeleteTask(ctx, task.ID); err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	delete(h.names, task.ID)
+	h.mutex.Unlock()
+	return nil
+}
+
+// errPreconditionFailed は Overwrite: F で既にあるファイルへ移動しようとしたときのエラーです
+var errPreconditionFailed = errors.New("destination exists")
+
+// errBadGateway は Destination が別のサーバを指しているときのエラーです
+var errBadGateway = errors.New("destination is on another server")
+
+// move はファイルの名前を変えるか、ファイルをほかのコレクションへ移します
+// コレクションをまたいでタスクのファイルを移すと、タスクを移動先のリストに入れます
+// エディタは一時ファイルに書いてから元の名前へ移動して保存するため、一時ファイルをタスクのファイルへ移動すると内容をタスクに反映します
+// タスクのファイルを一時ファイルの名前へ移動したときは、タスクを消さずに一時ファイルへ内容を写します（保存前のバックアップの作成など）
+// コレクションの移動はリストの名前の変更です（moveCollection）
+func (h *Handler) move(w http.ResponseWriter, r *http.Request, res resource) error {
+	destination, err := url.Parse(r.Header.Get("Destination"))
+	if err != nil || destination.Path == "" {
+		return fmt.Errorf("%w: invalid Destination header", models.ErrValidation)
+	}
+	if destination.Host != "" && destination.Host != r.Host {
+		return errBadGateway
+	}
+	dst, ok := h.parsePath(strings.TrimPrefix(destination.Path, h.base))
+	if !ok || dst.list == "" || dst.isCollection() != res.isCollection() {
+		return fmt.Errorf("%w: invalid destination %s", models.ErrValidation, destination.Path)
+	}
+	if res.isCollection() {
+		return h.moveCollection(w, r, res, dst)
+	}
+	if !dst.exists {
+		return fmt.Errorf("%w: collection %q does not exist", models.ErrConflict, dst.list)
+	}
+	if dst.listID == res.listID && dst.name == res.name {
+		return fmt.Errorf("%w: source and destination are the same", models.ErrConflict)
+	}
+
+	ctx := r.Context()
+	f, ok := h.file(ctx, res.listID, res.name)
+	if !ok {
+		return errNotFound
+	}
+	_, dstExists := h.file(ctx, dst.listID, dst.name)
+	if dstExists && r.Header.Get("Overwrite") == "F" {
+		return errPreconditionFailed
+	}
+
+	switch {
+	case isScratch(res.name):
+		if _, err := h.write(ctx, dst.listID, dst.name, f.data); err != nil {
+			return err
+		}
+		h.mutex.Lock()
+		delete(h.scratch, scratchKey{listID: res.listID, name: res.name})
+		h.mutex.Unlock()
+	case isScratch(dst.name):
+		h.write(ctx, dst.listID, dst.name, f.data)
+	default:
+		// タスクのファイルどうしでは名前（とリスト）だけを変えます。移動先のタスクは上書きされるため削除します
+		if dstExists {
+			if err := h.remove(ctx, dst.listID, dst.name); err != nil {
+				return err
+			}
+		}
+		if dst.listID != res.listID {
+			if err := h.store.UpdateTask(ctx, f.taskID, models.TaskUpdate{ListID: &dst.listID}); err != nil {
+				return err
+			}
+		}
+		h.mutex.Lock()
+		h.names[f.taskID] = dst.name
+		if dst.name == strconv.Itoa(f.taskID)+".md" {
+			delete(h.names, f.taskID)
+		}
+		h.mutex.Unlock()
+	}
+
+	if dstExists {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	return nil
+}
+
+// moveCollection はリストのコレクションの名前を変えます（リストの名前の変更）
+// ルートと ListName のコレクションは変えられず、既にあるコレクションへは移動できません（中身をまとめることはしません）
+func (h *Handler) moveCollection(w http.ResponseWriter, r *http.Request, res, dst resource) error {
+	if res.list == "" || res.listID == 0 || h.lists == nil {
+		return errIsCollection
+	}
+	if dst.exists {
+		if r.Header.Get("Overwrite") == "F" {
+			return errPreconditionFailed
+		}
+		return fmt.Errorf("%w: collection %q already exists", models.ErrConflict, dst.list)
+	}
+	if _, err := h.lists.Rename(res.listID, dst.list); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// mkcol はトップレベルに res の名前のコレクション（リスト）を作成します
+func (h *Handler) mkcol(w http.ResponseWriter, r *http.Request, res resource) error {
+	switch {
+	case res.list == "" || res.exists && res.isCollection():
+		http.Error(w, "Collection already exists", http.StatusMethodNotAllowed)
+		return nil
+	case !res.isCollection() && !res.exists:
+		http.Error(w, "Parent collection does not exist", http.StatusConflict)
+		return nil
+	case !res.isCollection() || h.lists == nil:
+		return errForbidden
+	case r.ContentLength > 0:
+		http.Error(w, "MKCOL with a body is not supported", http.StatusUnsupportedMediaType)
+		return nil
+	}
+	if _, err := h.lists.Create(res.list); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// file は1つのファイルの内容と属性です
+type file struct {
+	name        string
+	taskID      int
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// etag は内容から求めた ETag を返します
+func (f file) etag() string {
+	sum := sha1.Sum(f.data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// file は listID のリストのコレクションの name のファイルを返します
+func (h *Handler) file(ctx context.Context, listID int, name string) (file, bool) {
+	if isScratch(name) {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		f, ok := h.scratch[scratchKey{listID: listID, name: name}]
+		return file{name: name, data: f.data, contentType: "application/octet-stream", modified: f.modified}, ok
+	}
+	task, ok := h.findTask(ctx, listID, name)
+	if !ok {
+		return file{}, false
+	}
+	return taskFile(name, task), true
+}
+
+// taskFile は task のファイルを作ります
+// 更新日時は最後に変更した日時で、記録していない古いタスクは作成日時（それもなければ 1970-01-01）にします
+func taskFile(name string, task models.Task) file {
+	modified := time.Unix(0, 0)
+	if task.UpdatedAt != nil {
+		modified = *task.UpdatedAt
+	} else if task.CreatedAt != nil {
+		modified = *task.CreatedAt
+	}
+	return file{name: name, taskID: task.ID, data: []byte(Content(task)), contentType: "text/markdown; charset=utf-8", modified: modified}
+}
+
+// files は listID のリストのコレクションにあるすべてのファイルを名前の順に返します
+func (h *Handler) files(ctx context.Context, listID int) []file {
+	tasks := h.store.GetTasks(ctx)
+	h.mutex.Lock()
+	var files []file
+	for _, task := range tasks {
+		if task.ListID == listID {
+			files = append(files, taskFile(h.fileName(task), task))
+		}
+	}
+	for key, f := range h.scratch {
+		if key.listID == listID {
+			files = append(files, file{name: key.name, data: f.data, contentType: "application/octet-stream", modified: f.modified})
+		}
+	}
+	h.mutex.Unlock()
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files
+}
+
+// lock は書き込みの前にロックを求めるクライアント（macOS の Finder や Windows のエクスプローラ）のために、形だけのロックを返します
+// ロックは記録せず、ほかのクライアントの書き込みも止めません
+func (h *Handler) lock(w http.ResponseWriter, r *http.Request, res resource) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	lockToken := "opaquelocktoken:" + hex.EncodeToString(token)
+	w.Header().Set("Lock-Token", "<"+lockToken+">")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<D:prop xmlns:D="DAV:"><D:lockdiscovery><D:activelock>
+<D:locktype><D:write/></D:locktype><D:lockscope><D:exclusive/></D:lockscope><D:depth>0</D:depth>
+<D:timeout>Second-3600</D:timeout><D:locktoken><D:href>%s</D:href></D:locktoken>
+<D:lockroot><D:href>%s</D:href></D:lockroot>
+</D:activelock></D:lockdiscovery></D:prop>
+`, lockToken, h.href(res))
+	return nil
+}
+
+// multistatus は PROPFIND と PROPPATCH の応答です
+type multistatus struct {
+	XMLName   xml.Name   `xml:"D:multistatus"`
+	Namespace string     `xml:"xmlns:D,attr"`
+	Responses []response `xml:"D:response"`
+}
+
+type response struct {
+	Href     string   `xml:"D:href"`
+	Propstat propstat `xml:"D:propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"D:prop"`
+	Status string `xml:"D:status"`
+}
+
+// prop は返す属性です。PROPFIND で求められた属性にかかわらず、すべての属性を返します
+type prop struct {
+	DisplayName   string        `xml:"D:displayname,omitempty"`
+	ResourceType  *resourceType `xml:"D:resourcetype"`
+	ContentLength string        `xml:"D:getcontentlength,omitempty"`
+	ContentType   string        `xml:"D:getcontenttype,omitempty"`
+	LastModified  string        `xml:"D:getlastmodified,omitempty"`
+	ETag          string        `xml:"D:getetag,omitempty"`
+	Updated       []emptyProp   `xml:",any"`
+}
+
+// emptyProp は PROPPATCH で変更したことにする、値のない属性です
+type emptyProp struct {
+	XMLName xml.Name
+}
+
+type resourceType struct {
+	Collection *struct{} `xml:"D:collection"`
+}
+
+// statusOK は propstat の状態です
+const statusOK = "HTTP/1.1 200 OK"
+
+// collectionResponse はコレクションの応答を作ります
+func (h *Handler) collectionResponse(res resource, name string) response {
+	return response{
+		Href: h.href(res),
+		Propstat: propstat{
+			Prop:   prop{DisplayName: name, ResourceType: &resourceType{Collection: &struct{}{}}},
+			Status: statusOK,
+		},
+	}
+}
+
+// fileResponse は list のコレクションにあるファイルの応答を作ります
+func (h *Handler) fileResponse(list string, f file) response {
+	return response{
+		Href: h.href(resource{list: list, name: f.name}),
+		Propstat: propstat{
+			Prop: prop{
+				DisplayName:   f.name,
+				ResourceType:  &resourceType{},
+				ContentLength: strconv.Itoa(len(f.data)),
+				ContentType:   f.contentType,
+				LastModified:  f.modified.UTC().Format(http.TimeFormat),
+				ETag:          f.etag(),
+			},
+			Status: statusOK,
+		},
+	}
+}
+
+// propfind はコレクションやファイルの属性を返します
+// Depth: 0 はそれ自身だけ、1 は直下まで、infinity（または指定なし）はすべてを返します
+func (h *Handler) propfind(w http.ResponseWriter, r *http.Request, res resource) error {
+	depth := -1
+	switch r.Header.Get("Depth") {
+	case "0":
+		depth = 0
+	case "1":
+		depth = 1
+	}
+
+	ctx := r.Context()
+	var responses []response
+	switch {
+	case res.list == "":
+		responses = append(responses, h.collectionResponse(res, ""))
+		if depth == 0 {
+			break
+		}
+		for _, c := range h.collections() {
+			responses = append(responses, h.collectionResponse(c, c.list))
+			if depth < 0 {
+				for _, f := range h.files(ctx, c.listID) {
+					responses = append(responses, h.fileResponse(c.list, f))
+				}
+			}
+		}
+	case res.isCollection():
+		responses = append(responses, h.collectionResponse(res, res.list))
+		if depth != 0 {
+			for _, f := range h.files(ctx, res.listID) {
+				responses = append(responses, h.fileResponse(res.list, f))
+			}
+		}
+	default:
+		f, ok := h.file(ctx, res.listID, res.name)
+		if !ok {
+			return errNotFound
+		}
+		responses = append(responses, h.fileResponse(res.list, f))
+	}
+	return writeMultistatus(w, multistatus{Namespace: "DAV:", Responses: responses})
+}
+
+// proppatch は属性の変更を受け付けたことにして返します
+// Windows のエクスプローラは書き込みのあとに更新日時などを設定しようとし、失敗するとコピーを取り消すため、変更せずに成功を返します
+func (h *Handler) proppatch(w http.ResponseWriter, r *http.Request, res resource) error {
+	if !res.isCollection() {
+		if _, ok := h.file(r.Context(), res.listID, res.name); !ok {
+			return errNotFound
+		}
+	}
+	names, err := patchedNames(io.LimitReader(r.Body, maxFileSize))
+	if err != nil {
+		return err
+	}
+	return writeMultistatus(w, multistatus{Namespace: "DAV:", Responses: []response{{
+		Href:     h.href(res),
+		Propstat: propstat{Prop: prop{Updated: names}, Status: statusOK},
+	}}})
+}
+
+// patchedNames は PROPPATCH の本文から、設定・削除しようとした属性の名前を読み取ります
+func patchedNames(body io.Reader) ([]emptyProp, error) {
+	decoder := xml.NewDecoder(body)
+	var names []emptyProp
+	inProp := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid XML: %v", models.ErrValidation, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if inProp == 1 {
+				names = append(names, emptyProp{XMLName: t.Name})
+			}
+			if inProp > 0 {
+				inProp++
+			} else if t.Name.Space == "DAV:" && t.Name.Local == "prop" {
+				inProp = 1
+			}
+		case xml.EndElement:
+			if inProp > 0 {
+				inProp--
+			}
+		}
+	}
+}
+
+// writeMultistatus は 207 Multi-Status で応答を書き込みます
+func writeMultistatus(w http.ResponseWriter, ms multistatus) error {
+	data, err := xml.Marshal(ms)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	w.Write(data)
+	return nil
+}
@@ -0,0 +1,342 @@
+package dav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+func newTestHandler(tasks ...models.Task) (*Handler, models.TaskStore) {
+	store := models.NewTodoAppFromTasks(tasks)
+	taskLists, _ := lists.NewStore("")
+	return NewHandler(store, taskLists, "/dav/", ""), store
+}
+
+func serve(h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestContentAndParse(t *testing.T) {
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	task := models.Task{ID: 3, Title: "牛乳を\n買う", Completed: true, DueDate: &due}
+	content := Content(task)
+	if content != "- [x] 牛乳を 買う 📅 2025-03-01\n" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	p, err := parseContent([]byte("\n\ufeff- [X] 牛乳を買う 📅 2025-03-02 ^task-3\nメモ\n"))
+	if err != nil || !p.checkbox || !p.completed || p.title != "牛乳を買う" || p.due.Format("2006-01-02") != "2025-03-02" {
+		t.Errorf("Unexpected parse result %+v, %v", p, err)
+	}
+	if p, _ := parseContent([]byte("- [ ] 期限なし")); !p.checkbox || p.completed || p.due != nil {
+		t.Errorf("Expected an incomplete task without a due date, got %+v", p)
+	}
+	if p, _ := parseContent([]byte("ただのメモ")); p.checkbox || p.title != "ただのメモ" {
+		t.Errorf("Expected a plain title, got %+v", p)
+	}
+	if _, err := parseContent([]byte("- [ ] x 📅 2025-02-30")); err == nil {
+		t.Error("Expected an invalid date to fail")
+	}
+}
+
+func TestGetAndPropfind(t *testing.T) {
+	h, _ := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"}, models.Task{ID: 2, Title: "掃除"})
+
+	rr := serve(h, http.MethodGet, "/dav/Tasks/1.md", "")
+	if rr.Code != http.StatusOK || rr.Body.String() != "- [ ] 牛乳を買う\n" || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/markdown") {
+		t.Errorf("Unexpected GET response %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+	if rr := serve(h, http.MethodHead, "/dav/Tasks/1.md", ""); rr.Body.Len() != 0 || rr.Header().Get("ETag") == "" {
+		t.Errorf("Expected HEAD to return headers only, got %q %v", rr.Body.String(), rr.Header())
+	}
+	for _, path := range []string{"/dav/Tasks/9.md", "/dav/Other/1.md", "/dav/Tasks/a/b"} {
+		if rr := serve(h, http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", path, rr.Code)
+		}
+	}
+
+	rr = serve(h, "PROPFIND", "/dav/", "", "Depth", "1")
+	body := rr.Body.String()
+	if rr.Code != http.StatusMultiStatus || !strings.Contains(body, "<D:href>/dav/Tasks/</D:href>") || strings.Contains(body, "1.md") {
+		t.Errorf("Unexpected root listing %d %s", rr.Code, body)
+	}
+	rr = serve(h, "PROPFIND", "/dav/Tasks/", "", "Depth", "1")
+	body = rr.Body.String()
+	if !strings.Contains(body, "<D:href>/dav/Tasks/1.md</D:href>") || !strings.Contains(body, "<D:href>/dav/Tasks/2.md</D:href>") || !strings.Contains(body, "<D:collection></D:collection>") {
+		t.Errorf("Expected both task files in the listing, got %s", body)
+	}
+	if body := serve(h, "PROPFIND", "/dav/", "").Body.String(); !strings.Contains(body, "2.md") {
+		t.Errorf("Expected Depth: infinity to include the files, got %s", body)
+	}
+	if body := serve(h, "PROPFIND", "/dav/Tasks/2.md", "", "Depth", "0").Body.String(); strings.Contains(body, "1.md") || !strings.Contains(body, "<D:getcontentlength>13</D:getcontentlength>") {
+		t.Errorf("Unexpected file properties %s", body)
+	}
+}
+
+func TestPutUpdatesAndCreatesTasks(t *testing.T) {
+	h, store := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"})
+	ctx := context.Background()
+
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/1.md", "- [x] 牛乳と卵を買う 📅 2025-03-01\n"); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d %s", rr.Code, rr.Body.String())
+	}
+	task := store.GetTasks(ctx)[0]
+	if task.Title != "牛乳と卵を買う" || !task.Completed || task.DueDate == nil || task.DueDate.Format("2006-01-02") != "2025-03-01" {
+		t.Errorf("Expected the task to be updated, got %+v", task)
+	}
+
+	// チェックボックスのない内容はタイトルだけを変えます
+	serve(h, http.MethodPut, "/dav/Tasks/1.md", "卵を買う")
+	if task := store.GetTasks(ctx)[0]; task.Title != "卵を買う" || !task.Completed || task.DueDate == nil {
+		t.Errorf("Expected only the title to change, got %+v", task)
+	}
+	// 空の書き込みではタスクを変えません
+	serve(h, http.MethodPut, "/dav/Tasks/1.md", "")
+	if task := store.GetTasks(ctx)[0]; task.Title != "卵を買う" {
+		t.Errorf("Expected an empty write to be ignored, got %+v", task)
+	}
+
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/買い物.md", ""); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/%E8%B2%B7%E3%81%84%E7%89%A9.md", "- [ ] 週末の買い物"); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected the created file to keep its name, got %d", rr.Code)
+	}
+	tasks := store.GetTasks(ctx)
+	if len(tasks) != 2 || tasks[1].Title != "週末の買い物" {
+		t.Errorf("Expected a new task named after its content, got %+v", tasks)
+	}
+	if body := serve(h, "PROPFIND", "/dav/Tasks/", "", "Depth", "1").Body.String(); !strings.Contains(body, "/dav/Tasks/%E8%B2%B7%E3%81%84%E7%89%A9.md") {
+		t.Errorf("Expected the escaped file name in the listing, got %s", body)
+	}
+
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/1.md", "- [ ] x 📅 2025-13-01"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid date, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/big.md", strings.Repeat("a", maxFileSize+1)); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodPut, "/dav/Tasks/", "x"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for a collection, got %d", rr.Code)
+	}
+}
+
+func TestDeleteAndScratchFiles(t *testing.T) {
+	h, store := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"}, models.Task{ID: 2, Title: "掃除"})
+	ctx := context.Background()
+
+	if rr := serve(h, http.MethodDelete, "/dav/Tasks/2.md", ""); rr.Code != http.StatusNoContent || len(store.GetTasks(ctx)) != 1 {
+		t.Errorf("Expected the task to be deleted, got %d %+v", rr.Code, store.GetTasks(ctx))
+	}
+	if rr := serve(h, http.MethodDelete, "/dav/Tasks/2.md", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rr.Code)
+	}
+
+	// 一時ファイルはタスクにならず、保存のときの移動で元のタスクへ反映します
+	for _, name := range []string{".DS_Store", "~1.md", "1.md.swp"} {
+		if rr := serve(h, http.MethodPut, "/dav/Tasks/"+name, "- [ ] 一時"); rr.Code != http.StatusCreated {
+			t.Errorf("PUT %s: expected 201, got %d", name, rr.Code)
+		}
+	}
+	if len(store.GetTasks(ctx)) != 1 {
+		t.Errorf("Expected scratch files not to create tasks, got %+v", store.GetTasks(ctx))
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/.DS_Store", ""); rr.Body.String() != "- [ ] 一時" {
+		t.Errorf("Expected the scratch file content, got %q", rr.Body.String())
+	}
+	rr := serve(h, "MOVE", "/dav/Tasks/~1.md", "", "Destination", "http://example.com/dav/Tasks/1.md")
+	if rr.Code != http.StatusNoContent || store.GetTasks(ctx)[0].Title != "一時" {
+		t.Errorf("Expected the scratch content to be applied, got %d %+v", rr.Code, store.GetTasks(ctx))
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/~1.md", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected the scratch file to be gone, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodDelete, "/dav/Tasks/.DS_Store", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected the scratch file to be deleted, got %d", rr.Code)
+	}
+}
+
+func TestMove(t *testing.T) {
+	h, store := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"}, models.Task{ID: 2, Title: "掃除"})
+	h.base = "/w/team"
+	ctx := context.Background()
+
+	// タスクを一時ファイルの名前へ移動しても、タスクは消さずに写します
+	rr := serve(h, "MOVE", "/dav/Tasks/1.md", "", "Destination", "/w/team/dav/Tasks/1.md~")
+	if rr.Code != http.StatusCreated || len(store.GetTasks(ctx)) != 2 {
+		t.Errorf("Expected a backup copy, got %d %+v", rr.Code, store.GetTasks(ctx))
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/1.md~", ""); rr.Body.String() != "- [ ] 牛乳を買う\n" {
+		t.Errorf("Expected the backup content, got %q", rr.Body.String())
+	}
+
+	if rr := serve(h, "MOVE", "/dav/Tasks/1.md", "", "Destination", "/w/team/dav/Tasks/%E8%B2%B7%E3%81%84%E7%89%A9.md"); rr.Code != http.StatusCreated {
+		t.Errorf("Expected the rename to succeed, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/買い物.md", ""); rr.Body.String() != "- [ ] 牛乳を買う\n" {
+		t.Errorf("Expected the task under its new name, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/1.md", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected the old name to be gone, got %d", rr.Code)
+	}
+
+	if rr := serve(h, "MOVE", "/dav/Tasks/2.md", "", "Destination", "/w/team/dav/Tasks/買い物.md", "Overwrite", "F"); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 with Overwrite: F, got %d", rr.Code)
+	}
+	if rr := serve(h, "MOVE", "/dav/Tasks/2.md", "", "Destination", "/w/team/dav/Tasks/買い物.md"); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected the overwrite to succeed, got %d", rr.Code)
+	}
+	if tasks := store.GetTasks(ctx); len(tasks) != 1 || tasks[0].ID != 2 {
+		t.Errorf("Expected the overwritten task to be deleted, got %+v", tasks)
+	}
+	if rr := serve(h, "MOVE", "/dav/Tasks/買い物.md", "", "Destination", "/w/team/dav/Tasks/2.md"); rr.Code != http.StatusCreated || len(h.names) != 0 {
+		t.Errorf("Expected the ID file name to drop the alias, got %d %v", rr.Code, h.names)
+	}
+
+	for _, c := range []struct {
+		destination string
+		status      int
+	}{
+		{"", http.StatusBadRequest},
+		{"/w/team/dav/Other/x.md", http.StatusConflict},
+		{"/w/team/dav/Other/", http.StatusBadRequest},
+		{"/w/team/dav/Tasks/2.md", http.StatusConflict},
+		{"http://other.example.com/w/team/dav/Tasks/3.md", http.StatusBadGateway},
+	} {
+		if rr := serve(h, "MOVE", "/dav/Tasks/2.md", "", "Destination", c.destination); rr.Code != c.status {
+			t.Errorf("MOVE to %q: expected %d, got %d", c.destination, c.status, rr.Code)
+		}
+	}
+	if rr := serve(h, "MOVE", "/dav/Tasks/9.md", "", "Destination", "/w/team/dav/Tasks/3.md"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing source, got %d", rr.Code)
+	}
+}
+
+func TestClientCompatibility(t *testing.T) {
+	h, _ := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"})
+
+	rr := serve(h, http.MethodOptions, "/dav/", "")
+	if rr.Header().Get("DAV") != "1, 2" || !strings.Contains(rr.Header().Get("Allow"), "PROPFIND") {
+		t.Errorf("Unexpected OPTIONS headers %v", rr.Header())
+	}
+
+	rr = serve(h, "LOCK", "/dav/Tasks/1.md", "")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Lock-Token"), "<opaquelocktoken:") || !strings.Contains(rr.Body.String(), "/dav/Tasks/1.md") {
+		t.Errorf("Unexpected LOCK response %d %v %s", rr.Code, rr.Header(), rr.Body.String())
+	}
+	if rr := serve(h, "UNLOCK", "/dav/Tasks/1.md", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 for UNLOCK, got %d", rr.Code)
+	}
+
+	patch := `<?xml version="1.0"?><D:propertyupdate xmlns:D="DAV:" xmlns:Z="urn:schemas-microsoft-com:"><D:set><D:prop><Z:Win32LastModifiedTime>Sat, 01 Mar 2025 00:00:00 GMT</Z:Win32LastModifiedTime></D:prop></D:set></D:propertyupdate>`
+	rr = serve(h, "PROPPATCH", "/dav/Tasks/1.md", patch)
+	if body, _ := io.ReadAll(rr.Body); rr.Code != http.StatusMultiStatus || !strings.Contains(string(body), `<Win32LastModifiedTime xmlns="urn:schemas-microsoft-com:"></Win32LastModifiedTime>`) {
+		t.Errorf("Expected the property to be accepted, got %d %s", rr.Code, body)
+	}
+	if rr := serve(h, "PROPPATCH", "/dav/Tasks/1.md", "<D:prop"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid XML, got %d", rr.Code)
+	}
+	if rr := serve(h, "PROPPATCH", "/dav/Tasks/9.md", patch); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rr.Code)
+	}
+
+	if rr := serve(h, "MKCOL", "/dav/Tasks/sub", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a nested collection, got %d", rr.Code)
+	}
+	if rr := serve(h, "COPY", "/dav/Tasks/1.md", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for COPY, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodGet, "/dav/Tasks/", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET on a collection, got %d", rr.Code)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	h, store := newTestHandler(models.Task{ID: 1, Title: "牛乳を買う"})
+	ctx := context.Background()
+
+	// コレクションを作るとリストができます
+	if rr := serve(h, "MKCOL", "/dav/%E4%BB%95%E4%BA%8B/", ""); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for MKCOL, got %d %s", rr.Code, rr.Body.String())
+	}
+	work, err := h.lists.Get(1)
+	if err != nil || work.Name != "仕事" {
+		t.Fatalf("Expected the list to be created, got %+v %v", work, err)
+	}
+	for path, status := range map[string]int{
+		"/dav/仕事/":    http.StatusMethodNotAllowed,
+		"/dav/Tasks/": http.StatusMethodNotAllowed,
+		"/dav/":       http.StatusMethodNotAllowed,
+		"/dav/なし/sub": http.StatusConflict,
+	} {
+		if rr := serve(h, "MKCOL", path, ""); rr.Code != status {
+			t.Errorf("MKCOL %s: expected %d, got %d", path, status, rr.Code)
+		}
+	}
+
+	body := serve(h, "PROPFIND", "/dav/", "", "Depth", "1").Body.String()
+	if !strings.Contains(body, "<D:href>/dav/Tasks/</D:href>") || !strings.Contains(body, "<D:href>/dav/%E4%BB%95%E4%BA%8B/</D:href>") {
+		t.Errorf("Expected both collections in the root listing, got %s", body)
+	}
+
+	// コレクションに作ったファイルはそのリストのタスクになり、ほかのコレクションには見えません
+	if rr := serve(h, http.MethodPut, "/dav/仕事/報告書.md", "- [ ] 報告書を書く"); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", rr.Code)
+	}
+	if tasks := store.GetTasks(ctx); len(tasks) != 2 || tasks[1].ListID != work.ID {
+		t.Errorf("Expected the task in the list, got %+v", tasks)
+	}
+	if body := serve(h, "PROPFIND", "/dav/Tasks/", "", "Depth", "1").Body.String(); strings.Contains(body, "報告書") || strings.Contains(body, "%E5%A0%B1") {
+		t.Errorf("Expected the list's task not to be in Tasks, got %s", body)
+	}
+	if rr := serve(h, http.MethodPut, "/dav/なし/x.md", "x"); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a missing collection, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodGet, "/dav/なし/x.md", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing collection, got %d", rr.Code)
+	}
+
+	// コレクションをまたぐ移動でタスクのリストが変わります
+	if rr := serve(h, "MOVE", "/dav/Tasks/1.md", "", "Destination", "/dav/%E4%BB%95%E4%BA%8B/1.md"); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for MOVE, got %d %s", rr.Code, rr.Body.String())
+	}
+	if task := store.GetTasks(ctx)[0]; task.ListID != work.ID {
+		t.Errorf("Expected the task to move to the list, got %+v", task)
+	}
+	if rr := serve(h, http.MethodGet, "/dav/仕事/1.md", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the task in the new collection, got %d", rr.Code)
+	}
+	if rr := serve(h, "MOVE", "/dav/仕事/1.md", "", "Destination", "/dav/Tasks/milk.md"); rr.Code != http.StatusCreated || store.GetTasks(ctx)[0].ListID != 0 {
+		t.Errorf("Expected the task to leave the list, got %d %+v", rr.Code, store.GetTasks(ctx)[0])
+	}
+
+	// コレクションの移動はリストの名前の変更です
+	if rr := serve(h, "MOVE", "/dav/仕事/", "", "Destination", "/dav/%E4%BB%95%E4%BA%8B2/"); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for renaming a collection, got %d %s", rr.Code, rr.Body.String())
+	}
+	if list, _ := h.lists.Get(work.ID); list.Name != "仕事2" {
+		t.Errorf("Expected the list to be renamed, got %+v", list)
+	}
+	if rr := serve(h, "MOVE", "/dav/Tasks/", "", "Destination", "/dav/Other/"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for renaming Tasks, got %d", rr.Code)
+	}
+	if rr := serve(h, "MOVE", "/dav/仕事2/", "", "Destination", "/dav/Tasks/"); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for moving onto an existing collection, got %d", rr.Code)
+	}
+	if rr := serve(h, http.MethodDelete, "/dav/仕事2/", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for deleting a collection, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,83 @@
+// Package demo は動作確認やデモ用に、それらしいタスクをランダムに作成します
+package demo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"todo-app/models"
+)
+
+// タスクのタイトルは動作と対象を組み合わせて作ります
+var (
+	jaObjects = []string{"牛乳", "企画書", "請求書", "歯医者の予約", "週報", "部屋の掃除", "プレゼン資料", "家賃", "誕生日プレゼント", "ゴミ出し", "洗濯物", "旅行の計画"}
+	jaVerbs   = []string{"を確認する", "を準備する", "を片付ける", "を見直す", "を送る", "を済ませる"}
+	enVerbs   = []string{"Review", "Write", "Send", "Fix", "Plan", "Update", "Call about", "Prepare"}
+	enObjects = []string{"the quarterly report", "release notes", "the login bug", "team lunch", "the dentist", "onboarding docs", "the budget", "slides for Friday", "the CI pipeline", "invoices"}
+)
+
+// 期限を設定する割合・完了済みにする割合、期限の範囲（今日からの日数）
+const (
+	dueDateRatio   = 0.6
+	completedRatio = 0.3
+	minDueDays     = -14
+	maxDueDays     = 45
+)
+
+// Generate は store に n 件のタスクを作成し、作成後の状態を返します
+// タイトル・期限・優先度・完了状態は rng で決まるため、同じシードからは同じタスクが作られます
+// 期限は now を基準に過去2週間から45日後までの日付（UTC の0時）です
+// ctx がキャンセルされたら、それまでに作成したタスクだけを返します
+// ストアの操作が失敗した場合は、それまでに作成したタスクとエラーを返します
+func Generate(ctx context.Context, store models.TaskStore, n int, rng *rand.Rand, now time.Time) ([]models.Task, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	tasks := make([]models.Task, 0, n)
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		task, err := generateTask(ctx, store, rng, today)
+		if err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// generateTask はタスクを1件作成し、期限・優先度・完了状態をランダムに設定します
+func generateTask(ctx context.Context, store models.TaskStore, rng *rand.Rand, today time.Time) (models.Task, error) {
+	task, err := store.AddTask(ctx, title(rng))
+	if err != nil {
+		return task, err
+	}
+
+	if rng.Float64() < dueDateRatio {
+		due := today.AddDate(0, 0, minDueDays+rng.Intn(maxDueDays-minDueDays+1))
+		if err := store.SetDueDate(ctx, task.ID, &due); err != nil {
+			return task, err
+		}
+		task.DueDate = &due
+	}
+
+	priorities := models.Priorities()
+	task.Priority = priorities[rng.Intn(len(priorities))]
+	if err := store.SetPriority(ctx, task.ID, task.Priority); err != nil {
+		return task, err
+	}
+
+	if rng.Float64() < completedRatio {
+		if err := store.ToggleTask(ctx, task.ID); err != nil {
+			return task, err
+		}
+		task.Completed = true
+	}
+	return task, nil
+}
+
+// title は日本語または英語のタスク名をランダムに作ります
+func title(rng *rand.Rand) string {
+	if rng.Intn(2) == 0 {
+		return jaObjects[rng.Intn(len(jaObjects))] + jaVerbs[rng.Intn(len(jaVerbs))]
+	}
+	return enVerbs[rng.Intn(len(enVerbs))] + " " + enObjects[rng.Intn(len(enObjects))]
+}
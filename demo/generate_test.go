@@ -0,0 +1,94 @@
+package demo
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+func TestGenerate(t *testing.T) {
+	store := models.NewTodoApp()
+	now := time.Date(2025, 6, 15, 13, 30, 0, 0, time.UTC)
+
+	tasks, _ := Generate(context.Background(), store, 200, rand.New(rand.NewSource(1)), now)
+	if len(tasks) != 200 {
+		t.Fatalf("Expected 200 tasks, got %d", len(tasks))
+	}
+	storetest.AssertTasks(t, store, tasks)
+
+	today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	var withDue, completed int
+	priorities := map[models.Priority]int{}
+	for _, task := range tasks {
+		if task.Title == "" {
+			t.Errorf("Task %d has an empty title", task.ID)
+		}
+		if task.DueDate != nil {
+			withDue++
+			days := int(task.DueDate.Sub(today).Hours() / 24)
+			if days < minDueDays || days > maxDueDays || !task.DueDate.Equal(task.DueDate.Truncate(24*time.Hour)) {
+				t.Errorf("Task %d has an unexpected due date %v", task.ID, task.DueDate)
+			}
+		}
+		if task.Completed {
+			completed++
+		}
+		priorities[task.Priority]++
+	}
+
+	if withDue == 0 || withDue == len(tasks) {
+		t.Errorf("Expected some but not all tasks to have a due date, got %d", withDue)
+	}
+	if completed == 0 || completed == len(tasks) {
+		t.Errorf("Expected some but not all tasks to be completed, got %d", completed)
+	}
+	for _, p := range models.Priorities() {
+		if priorities[p] == 0 {
+			t.Errorf("Expected at least one task with priority %q", p)
+		}
+	}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	now := time.Now()
+	a, _ := Generate(context.Background(), storetest.NewFake(), 20, rand.New(rand.NewSource(42)), now)
+	b, _ := Generate(context.Background(), storetest.NewFake(), 20, rand.New(rand.NewSource(42)), now)
+
+	for i := range a {
+		if !storetest.EqualTask(a[i], b[i]) {
+			t.Errorf("Task %d differs between runs: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestGenerateKeepsExistingTasks(t *testing.T) {
+	ctx := context.Background()
+	store := storetest.NewFake(models.Task{ID: 7, Title: "Existing"})
+
+	tasks, _ := Generate(context.Background(), store, 3, rand.New(rand.NewSource(1)), time.Now())
+	if tasks[0].ID != 8 {
+		t.Errorf("Expected generated IDs to continue after existing tasks, got %d", tasks[0].ID)
+	}
+	if got := len(store.GetTasks(ctx)); got != 4 {
+		t.Errorf("Expected 4 tasks, got %d", got)
+	}
+}
+
+func TestGenerateStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := storetest.NewFake()
+	store.Subscribe(func(event models.Event) {
+		if event.Task.ID == 3 {
+			cancel()
+		}
+	})
+
+	tasks, _ := Generate(ctx, store, 100, rand.New(rand.NewSource(1)), time.Now())
+	if len(tasks) != 3 {
+		t.Errorf("Expected generation to stop after the context was cancelled, got %d tasks", len(tasks))
+	}
+}
@@ -0,0 +1,55 @@
+//go:build !nogit
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"todo-app/store/gitstore"
+)
+
+// gitDriverName は Git リポジトリに保存するドライバの名前です。ドライバは gitstore の init で登録します
+// nogit のタグを付けてビルドすると gitstore を組み込まず、driver_nogit.go のスタブを登録します
+const gitDriverName = gitstore.DriverName
+
+// runGitAdmin は dir のリポジトリに対して `todo-app admin` の command（verify・compact・vacuum・purge-trash）を実行し、終了コードを返します
+func runGitAdmin(command, dir string, stdout, stderr io.Writer) int {
+	switch command {
+	case "verify":
+		problems, err := gitstore.Verify(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "admin verify: %v\n", err)
+			return 1
+		}
+		for _, problem := range problems {
+			fmt.Fprintln(stdout, problem)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(stderr, "admin verify: %d 件の不整合が見つかりました\n", len(problems))
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s: 不整合はありません\n", dir)
+	case "purge-trash":
+		store, err := gitstore.Open(dir, gitstore.Options{})
+		if err != nil {
+			fmt.Fprintf(stderr, "admin purge-trash: %v\n", err)
+			return 1
+		}
+		purged, err := store.PurgeTrash(context.Background(), time.Now())
+		if err != nil {
+			fmt.Fprintf(stderr, "admin purge-trash: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s: ごみ箱の %d 件のタスクを削除しました\n", dir, len(purged))
+	default:
+		if err := gitstore.Compact(dir); err != nil {
+			fmt.Fprintf(stderr, "admin %s: %v\n", command, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s: 履歴をまとめました\n", dir)
+	}
+	return 0
+}
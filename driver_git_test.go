@@ -0,0 +1,121 @@
+//go:build !nogit
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/models"
+	"todo-app/store/gitstore"
+)
+
+func TestRelayOutbox(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	relayOutbox(models.NewTodoApp())
+
+	dir := t.TempDir()
+	store, err := gitstore.Open(dir, gitstore.Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(context.Background(), "Unpublished")
+	// コミットした後、配信を終える前に止まった状態にします
+	os.WriteFile(filepath.Join(dir, ".git", "todo-outbox-published"), []byte("0\n"), 0644)
+
+	reopened, err := gitstore.Open(dir, gitstore.Options{})
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	var events []models.Event
+	reopened.Subscribe(func(event models.Event) { events = append(events, event) })
+	relayOutbox(reopened)
+	if len(events) != 1 || events[0].Task.Title != "Unpublished" {
+		t.Errorf("Expected the unpublished event to be relayed, got %+v", events)
+	}
+}
+
+func TestNewServerWorkspaces(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, key := range []string{"TODO_STORE", "TODO_USERS_FILE", "TODO_LISTS_FILE", "JIRA_JQL", "GOOGLE_REFRESH_TOKEN", "NOTION_TOKEN", "BACKUP_DESTINATION", "STALE_DIGEST_URL", "EXEC_HOOKS_FILE"} {
+		t.Setenv(key, "")
+	}
+	dir := t.TempDir()
+	t.Setenv("TODO_GIT_DIR", dir)
+	t.Setenv("TODO_WORKSPACES", "family:うちの家族, team")
+	t.Setenv("LEADER_LOCK_FILE", filepath.Join(t.TempDir(), "leader.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newServer(ctx, envConfig(t))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("POST", "/w/family/api/tasks", strings.NewReader(`{"title": "Buy milk"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to add a task to the workspace: %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "workspaces", "family", "tasks", "1.json")); err != nil {
+		t.Errorf("Expected the workspace task to be saved in its own repository: %v", err)
+	}
+	if len(server.Store().GetTasks(ctx)) != 0 {
+		t.Error("Expected the workspace task not to be added to the root store")
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("GET", "/w/team/api/tasks", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("Expected an empty team workspace, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRunAdminVerifyAndCompact(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("TODO_GIT_DIR", dir)
+	store, err := gitstore.Open(dir, gitstore.Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(context.Background(), "Task")
+
+	if code, stdout, _ := runAdminForTest("verify"); code != 0 || !strings.Contains(stdout, "不整合はありません") {
+		t.Errorf("Expected verify to pass, got %d %q", code, stdout)
+	}
+	if code, _, stderr := runAdminForTest("vacuum"); code != 0 {
+		t.Errorf("Expected vacuum to succeed, got %d %q", code, stderr)
+	}
+
+	trashed, _ := store.AddTask(context.Background(), "Trashed")
+	store.DeleteTask(context.Background(), trashed.ID)
+	if code, stdout, stderr := runAdminForTest("purge-trash"); code != 0 || !strings.Contains(stdout, "1 件") {
+		t.Errorf("Expected purge-trash to purge 1 task, got %d %q %q", code, stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tasks", "2.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the purged task file to be removed, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "tasks", "1.json"), []byte(`{"id": 2, "title": "Task"}`), 0644)
+	code, stdout, stderr := runAdminForTest("verify")
+	if code != 1 || !strings.Contains(stdout, "does not match the file name") || !strings.Contains(stderr, "2 件") {
+		t.Errorf("Expected verify to report problems, got %d %q %q", code, stdout, stderr)
+	}
+
+	t.Setenv("TODO_GIT_DIR", filepath.Join(dir, "missing"))
+	if code, _, _ := runAdminForTest("verify"); code != 1 {
+		t.Errorf("Expected verify to fail for a missing directory, got %d", code)
+	}
+	if code, _, _ := runAdminForTest("compact"); code != 1 {
+		t.Errorf("Expected compact to fail for a missing directory, got %d", code)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build nogit
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"todo-app/store"
+)
+
+// gitDriverName は Git リポジトリに保存するドライバの名前です（gitstore.DriverName と同じ）
+const gitDriverName = "git"
+
+// nogit のタグを付けてビルドしたバイナリには、git コマンドを使う gitstore を組み込みません
+// TODO_STORE=git や TODO_GIT_DIR で起動すると、スタブがタグを示すエラーを返します
+func init() {
+	store.RegisterStub(gitDriverName, "nogit")
+}
+
+// runGitAdmin は Git の保存先を組み込んでいないため、どのコマンドも実行できないことを示して 1 を返します
+func runGitAdmin(command, dir string, stdout, stderr io.Writer) int {
+	fmt.Fprintf(stderr, "admin %s: -tags nogit でビルドしたため Git の保存先（%s）を操作できません\n", command, dir)
+	return 1
+}
@@ -0,0 +1,23 @@
+//go:build nogit
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"todo-app/config"
+	"todo-app/store"
+)
+
+func TestGitDriverExcluded(t *testing.T) {
+	_, err := openStoreIn(config.Store{Driver: "git", DSN: t.TempDir()})
+	if !errors.Is(err, store.ErrDriverExcluded) || !strings.Contains(err.Error(), "-tags nogit") {
+		t.Errorf("Expected the git stub to name the build tag, got %v", err)
+	}
+
+	t.Setenv("TODO_GIT_DIR", t.TempDir())
+	if code, _, stderr := runAdminForTest("verify"); code != 1 || !strings.Contains(stderr, "nogit") {
+		t.Errorf("Expected admin verify to fail without the git driver, got %d %q", code, stderr)
+	}
+}
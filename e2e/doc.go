@@ -0,0 +1,3 @@
+// Package e2e はサーバ全体を起動し、実際の HTTP リクエストで一連の操作を確認する結合テストです
+// テストは e2e_test.go にあり、go test ./... で他のテストと一緒に実行されます
+package e2e
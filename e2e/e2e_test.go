@@ -0,0 +1,288 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/handlers"
+	"todo-app/models"
+	"todo-app/store/gitstore"
+	"todo-app/store/storetest"
+	"todo-app/webhooks"
+)
+
+// testServer は実際に起動したサーバと、その保存先です
+type testServer struct {
+	*httptest.Server
+	t   *testing.T
+	dir string
+}
+
+// startServer は一時ディレクトリの Git ストアを使ってサーバ全体を起動します
+// git がない環境ではメモリ上のストアで起動し、永続化の確認は省略します
+func startServer(t *testing.T) *testServer {
+	var store models.TaskStore = models.NewTodoApp()
+	dir := ""
+	if _, err := exec.LookPath("git"); err == nil {
+		dir = t.TempDir()
+		opened, err := gitstore.Open(dir, gitstore.Options{})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		store = opened
+	}
+
+	hooks := webhooks.NewStore()
+	dispatcher := webhooks.NewDispatcher(hooks, nil, nil)
+	store.Subscribe(dispatcher.HandleEvent)
+
+	server := httptest.NewServer(handlers.NewServer(handlers.Deps{
+		Store:    store,
+		Webhooks: hooks,
+		Config:   handlers.Config{StaticDir: "../static"},
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		dispatcher.Wait()
+	})
+	return &testServer{Server: server, t: t, dir: dir}
+}
+
+// do はリクエストを送り、状態コードと本文を返します
+func (s *testServer) do(method, path, body string) (int, []byte) {
+	s.t.Helper()
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return resp.StatusCode, data
+}
+
+// doJSON はリクエストを送り、200 であることを確認して本文を v に読み込みます
+func (s *testServer) doJSON(method, path, body string, v interface{}) {
+	s.t.Helper()
+	status, data := s.do(method, path, body)
+	if status != http.StatusOK {
+		s.t.Fatalf("%s %s: expected status code %d, got %d: %s", method, path, http.StatusOK, status, data)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		s.t.Fatalf("%s %s: invalid JSON %q: %v", method, path, data, err)
+	}
+}
+
+// assertPersisted は保存先を開き直し、want のタスクが保存されていることを確認します
+func (s *testServer) assertPersisted(want []models.Task) {
+	s.t.Helper()
+	if s.dir == "" {
+		return
+	}
+	reopened, err := gitstore.Open(s.dir, gitstore.Options{})
+	if err != nil {
+		s.t.Fatalf("Reopen failed: %v", err)
+	}
+	storetest.AssertTasks(s.t, reopened, want)
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	s := startServer(t)
+
+	var created struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	s.doJSON("POST", "/api/tasks", `{"title": "牛乳を買う"}`, &created)
+	if !created.Success || created.Task.ID != 1 || created.Task.Title != "牛乳を買う" {
+		t.Fatalf("Unexpected created task: %+v", created)
+	}
+	s.doJSON("POST", "/api/tasks", `{"title": "Call \"mom\" <tonight>"}`, &created)
+	s.doJSON("POST", "/api/tasks", `{"title": "Throw away"}`, &created)
+
+	var result map[string]bool
+	s.doJSON("PUT", "/api/tasks/2/toggle", "", &result)
+	if !result["success"] {
+		t.Error("Expected toggle to succeed")
+	}
+	s.doJSON("DELETE", "/api/tasks/3", "", &result)
+	if !result["success"] {
+		t.Error("Expected delete to succeed")
+	}
+	if status, body := s.do("DELETE", "/api/tasks/3", ""); status != http.StatusNotFound {
+		t.Errorf("Expected second delete to return %d, got %d: %s", http.StatusNotFound, status, body)
+	}
+
+	want := []models.Task{
+		{ID: 1, Title: "牛乳を買う"},
+		{ID: 2, Title: `Call "mom" <tonight>`, Completed: true},
+	}
+
+	var tasks []models.Task
+	s.doJSON("GET", "/api/tasks", "", &tasks)
+	if len(tasks) != len(want) || !storetest.EqualTask(tasks[0], want[0]) || !storetest.EqualTask(tasks[1], want[1]) {
+		t.Errorf("Unexpected task list: %+v", tasks)
+	}
+	s.assertPersisted(want)
+
+	// 削除したIDは再利用されません
+	s.doJSON("POST", "/api/tasks", `{"title": "After delete"}`, &created)
+	if created.Task.ID != 4 {
+		t.Errorf("Expected ID 4 after deleting task 3, got %d", created.Task.ID)
+	}
+
+	status, body := s.do("GET", "/api/export/markdown", "")
+	if status != http.StatusOK {
+		t.Fatalf("Expected export to succeed, got %d", status)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+	file, err := reader.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, _ := io.ReadAll(file)
+	file.Close()
+	for _, line := range []string{"- [ ] 牛乳を買う ^task-1", `- [x] Call "mom" <tonight> ^task-2`, "- [ ] After delete ^task-4"} {
+		if !strings.Contains(string(markdown), line) {
+			t.Errorf("Expected %q in exported markdown:\n%s", line, markdown)
+		}
+	}
+}
+
+func TestWebhookFlow(t *testing.T) {
+	var mutex sync.Mutex
+	var received []models.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event models.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		mutex.Lock()
+		received = append(received, event)
+		mutex.Unlock()
+	}))
+	defer receiver.Close()
+
+	s := startServer(t)
+
+	var registered struct {
+		Success bool             `json:"success"`
+		Webhook webhooks.Webhook `json:"webhook"`
+	}
+	s.doJSON("POST", "/api/webhooks", `{"url": "`+receiver.URL+`", "events": ["task.updated"]}`, &registered)
+	if !registered.Success {
+		t.Fatal("Expected webhook to be registered")
+	}
+
+	var ignored interface{}
+	s.doJSON("POST", "/api/tasks", `{"title": "Notify me"}`, &ignored)
+	s.doJSON("PUT", "/api/tasks/1/toggle", "", &ignored)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mutex.Lock()
+		count := len(received)
+		mutex.Unlock()
+		if count > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 1 || received[0].Type != models.EventTaskUpdated || !received[0].Task.Completed {
+		t.Errorf("Expected a single task.updated webhook, got %+v", received)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	s := startServer(t)
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"POST", "/api/tasks", "not json", http.StatusBadRequest},
+		{"POST", "/api/tasks", `{"title": ""}`, http.StatusBadRequest},
+		{"PATCH", "/api/tasks", "", http.StatusMethodNotAllowed},
+		{"PUT", "/api/tasks/abc/toggle", "", http.StatusBadRequest},
+		{"DELETE", "/api/tasks/abc", "", http.StatusBadRequest},
+		{"POST", "/api/tasks/1", "", http.StatusMethodNotAllowed},
+		{"GET", "/api/tasks/999", "", http.StatusNotFound},
+		{"POST", "/api/webhooks", `{"url": "ftp://example.com"}`, http.StatusBadRequest},
+		{"POST", "/api/export/markdown", "", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		if status, body := s.do(tc.method, tc.path, tc.body); status != tc.status {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tc.method, tc.path, tc.status, status, body)
+		}
+	}
+
+	// エラーのリクエストでタスクが作られたり保存されたりしていないこと
+	var tasks []models.Task
+	s.doJSON("GET", "/api/tasks", "", &tasks)
+	if len(tasks) != 0 {
+		t.Errorf("Expected no tasks after failed requests, got %+v", tasks)
+	}
+	s.assertPersisted(nil)
+}
+
+func TestServesFrontend(t *testing.T) {
+	s := startServer(t)
+
+	for _, path := range []string{"/", "/static/script.js", "/static/style.css"} {
+		if status, body := s.do("GET", path, ""); status != http.StatusOK || len(body) == 0 {
+			t.Errorf("GET %s: expected content, got %d", path, status)
+		}
+	}
+	if _, body := s.do("GET", "/", ""); !strings.Contains(string(body), "ToDo リスト") {
+		t.Error("Expected the index page")
+	}
+}
+
+func TestFrontendEscapesQuotes(t *testing.T) {
+	// LLM が作ったサブタスクの案などを属性の値に埋め込んでも、引用符で属性を抜け出せないこと
+	// node がない環境では確認を省略します
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+	s := startServer(t)
+	_, script := s.do("GET", "/static/script.js", "")
+	start := bytes.Index(script, []byte("function escapeHtml("))
+	end := bytes.Index(script[start:], []byte("\n}\n"))
+	if start < 0 || end < 0 {
+		t.Fatal("Expected escapeHtml in script.js")
+	}
+
+	suggestion := `x" autofocus onfocus="alert(1)' <b>`
+	program := string(script[start:start+end+2]) + "\nprocess.stdout.write(escapeHtml(process.argv[1]));"
+	out, err := exec.Command("node", "-e", program, suggestion).Output()
+	if err != nil {
+		t.Fatalf("node failed: %v", err)
+	}
+	if got, want := string(out), "x&quot; autofocus onfocus=&quot;alert(1)&#39; &lt;b&gt;"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,231 @@
+// Package e2ee はタスクのタイトルと説明をクライアント側で暗号化するモード（エンドツーエンド暗号化）のサーバ側の部品です
+// サーバは鍵を持たず、暗号文と検索用のトークン（ブラインドインデックス）、鍵の導出に使う公開の値だけを保存します
+//
+// 暗号化の方式（static/e2e.js が実装しています）
+//   - 鍵: パスフレーズから PBKDF2-SHA256（Keys.Salt・Keys.Iterations）で 64 バイトを導出し、前半を AES-256-GCM、後半を HMAC-SHA256 の鍵にします
+//   - タイトル: "e2e:v1:" に続けて、12 バイトの nonce と AES-GCM の暗号文（タグを含む）をつなげて base64url（パディングなし）にしたもの
+//   - 説明: タイトルと同じ形式（空の説明は説明なしのため暗号化しません）
+//   - トークン: 空白で区切った単語ごとに、NFKC で正規化して小文字にした単語の HMAC-SHA256 の先頭 16 バイトを16進数にしたもの
+package e2ee
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"todo-app/models"
+)
+
+// Prefix は暗号化したタイトルの先頭に付ける版の印です
+const Prefix = "e2e:v1:"
+
+// KDF は鍵の導出に使う関数の名前です
+const KDF = "PBKDF2-SHA256"
+
+// MinIterations は受け付ける PBKDF2 の最小の繰り返し回数です
+const MinIterations = 100000
+
+// MaxIndexTokens は1件のタスクに付けられるトークンの最大数です
+const MaxIndexTokens = 64
+
+// minCiphertext は nonce（12 バイト）と GCM のタグ（16 バイト）を合わせた、暗号文の最小のバイト数です
+const minCiphertext = 12 + 16
+
+// minSalt は salt の最小のバイト数です
+const minSalt = 16
+
+// tokenLength はトークン（16 バイトの16進数）の文字数です
+const tokenLength = 32
+
+// ErrKeysExist は鍵の情報をすでに登録しているときのエラーです
+// 登録し直すと既存のタスクを読めなくなるため、API からは上書きできません
+var ErrKeysExist = fmt.Errorf("%w: encryption keys are already set", models.ErrConflict)
+
+// ValidateTitle は title が暗号化したタイトルの形式であることを確認します
+// 平文のタイトルを誤って保存しないように、暗号化するモードでは形式の合わないタイトルを ErrValidation にします
+func ValidateTitle(title string) error {
+	if err := validateCiphertext(title); err != nil {
+		return fmt.Errorf("%w: title must be encrypted (%v)", models.ErrValidation, err)
+	}
+	return nil
+}
+
+// ValidateDescription は description が空か、暗号化した説明の形式であることを確認します
+// 暗号化するモードでは、サーバが読める説明を保存しないように、形式の合わない説明を ErrValidation にします
+func ValidateDescription(description string) error {
+	if description == "" {
+		return nil
+	}
+	if err := validateCiphertext(description); err != nil {
+		return fmt.Errorf("%w: description must be encrypted (%v)", models.ErrValidation, err)
+	}
+	return nil
+}
+
+func validateCiphertext(value string) error {
+	if !strings.HasPrefix(value, Prefix) {
+		return fmt.Errorf("missing %q prefix", Prefix)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value[len(Prefix):])
+	if err != nil {
+		return errors.New("ciphertext is not base64url")
+	}
+	if len(data) < minCiphertext {
+		return errors.New("ciphertext is too short")
+	}
+	return nil
+}
+
+// ValidateIndex は tokens がトークン（32 文字の小文字の16進数）の一覧であることを確認します
+func ValidateIndex(tokens []string) error {
+	if len(tokens) > MaxIndexTokens {
+		return fmt.Errorf("%w: at most %d index tokens are allowed", models.ErrValidation, MaxIndexTokens)
+	}
+	for _, token := range tokens {
+		if !isToken(token) {
+			return fmt.Errorf("%w: index token %q must be %d lowercase hex characters", models.ErrValidation, token, tokenLength)
+		}
+	}
+	return nil
+}
+
+func isToken(token string) bool {
+	if len(token) != tokenLength {
+		return false
+	}
+	for _, c := range token {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchIndex はタスクのトークン index が、検索するトークン query をすべて含むかを返します
+func MatchIndex(index, query []string) bool {
+	for _, q := range query {
+		found := false
+		for _, token := range index {
+			if token == q {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys はクライアントが鍵を導出するための公開の値です。パスフレーズや鍵そのものは含みません
+// KDF: 鍵の導出に使う関数（PBKDF2-SHA256）
+// Iterations: PBKDF2 の繰り返し回数
+// Salt: PBKDF2 の salt（base64url）
+// Check: 決まった文字列を暗号化したもの。復号できればパスフレーズが正しいと分かります
+// CreatedAt: 登録した日時
+type Keys struct {
+	KDF        string    `json:"kdf"`
+	Iterations int       `json:"iterations"`
+	Salt       string    `json:"salt"`
+	Check      string    `json:"check"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate は鍵の情報が正しい形式かを確認します
+func (k Keys) Validate() error {
+	if k.KDF != KDF {
+		return fmt.Errorf("%w: kdf must be %q", models.ErrValidation, KDF)
+	}
+	if k.Iterations < MinIterations {
+		return fmt.Errorf("%w: iterations must be at least %d", models.ErrValidation, MinIterations)
+	}
+	if salt, err := base64.RawURLEncoding.DecodeString(k.Salt); err != nil || len(salt) < minSalt {
+		return fmt.Errorf("%w: salt must be at least %d bytes of base64url", models.ErrValidation, minSalt)
+	}
+	if err := validateCiphertext(k.Check); err != nil {
+		return fmt.Errorf("%w: check must be encrypted (%v)", models.ErrValidation, err)
+	}
+	return nil
+}
+
+// KeyStore は鍵の情報を JSON のファイルに保存します
+// 鍵の情報を失うと暗号化したタスクを読めなくなるため、メモリ上だけに持つことはしません
+type KeyStore struct {
+	path  string
+	now   func() time.Time
+	mutex sync.Mutex
+	keys  *Keys
+}
+
+// NewKeyStore は path のファイルを使う KeyStore を作成します。ファイルがあれば読み込みます
+func NewKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{path: path, now: time.Now}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys Keys
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := keys.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	s.keys = &keys
+	return s, nil
+}
+
+// Get は登録済みの鍵の情報を返します。まだ登録していなければ false を返します
+func (s *KeyStore) Get() (Keys, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.keys == nil {
+		return Keys{}, false
+	}
+	return *s.keys, true
+}
+
+// Set は鍵の情報を登録し、ファイルに書き出します
+// 形式が正しくなければ ErrValidation を、すでに登録していれば ErrKeysExist を返します
+func (s *KeyStore) Set(keys Keys) (Keys, error) {
+	if err := keys.Validate(); err != nil {
+		return Keys{}, err
+	}
+	keys.CreatedAt = s.now().UTC()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.keys != nil {
+		return Keys{}, ErrKeysExist
+	}
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return Keys{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".e2e-keys-*")
+	if err != nil {
+		return Keys{}, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return Keys{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Keys{}, err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return Keys{}, err
+	}
+	s.keys = &keys
+	return keys, nil
+}
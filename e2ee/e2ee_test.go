@@ -0,0 +1,172 @@
+package e2ee
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+// ciphertext は n バイトの暗号文に見える値を返します
+func ciphertext(n int) string {
+	return Prefix + base64.RawURLEncoding.EncodeToString(make([]byte, n))
+}
+
+func validKeys() Keys {
+	return Keys{
+		KDF:        KDF,
+		Iterations: MinIterations,
+		Salt:       base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+		Check:      ciphertext(40),
+	}
+}
+
+func TestValidateTitle(t *testing.T) {
+	if err := ValidateTitle(ciphertext(28)); err != nil {
+		t.Errorf("Expected a ciphertext to be accepted, got %v", err)
+	}
+	for _, title := range []string{"Buy milk", Prefix + "not base64!", ciphertext(27), "e2e:v2:" + ciphertext(40)[len(Prefix):]} {
+		if err := ValidateTitle(title); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%q: expected ErrValidation, got %v", title, err)
+		}
+	}
+}
+
+func TestValidateDescription(t *testing.T) {
+	for _, description := range []string{"", ciphertext(28)} {
+		if err := ValidateDescription(description); err != nil {
+			t.Errorf("%q: expected to be accepted, got %v", description, err)
+		}
+	}
+	for _, description := range []string{"- [ ] plain text", Prefix + "not base64!", ciphertext(27)} {
+		if err := ValidateDescription(description); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%q: expected ErrValidation, got %v", description, err)
+		}
+	}
+}
+
+func TestValidateIndex(t *testing.T) {
+	token := strings.Repeat("0a", 16)
+	if err := ValidateIndex([]string{token, strings.Repeat("f", 32)}); err != nil {
+		t.Errorf("Expected tokens to be accepted, got %v", err)
+	}
+	if err := ValidateIndex(nil); err != nil {
+		t.Errorf("Expected an empty index to be accepted, got %v", err)
+	}
+
+	tooMany := make([]string, MaxIndexTokens+1)
+	for i := range tooMany {
+		tooMany[i] = token
+	}
+	for _, tokens := range [][]string{{"milk"}, {strings.Repeat("A", 32)}, {strings.Repeat("0", 31)}, {strings.Repeat("g", 32)}, tooMany} {
+		if err := ValidateIndex(tokens); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%v: expected ErrValidation, got %v", tokens[0], err)
+		}
+	}
+}
+
+func TestMatchIndex(t *testing.T) {
+	index := []string{"a", "b", "c"}
+	tests := []struct {
+		query []string
+		want  bool
+	}{
+		{nil, true},
+		{[]string{"a"}, true},
+		{[]string{"c", "a"}, true},
+		{[]string{"a", "d"}, false},
+	}
+	for _, tt := range tests {
+		if got := MatchIndex(index, tt.query); got != tt.want {
+			t.Errorf("MatchIndex(%v) = %v, expected %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestKeysValidate(t *testing.T) {
+	if err := validKeys().Validate(); err != nil {
+		t.Fatalf("Expected valid keys, got %v", err)
+	}
+	tests := []func(k *Keys){
+		func(k *Keys) { k.KDF = "scrypt" },
+		func(k *Keys) { k.Iterations = 1000 },
+		func(k *Keys) { k.Salt = "short" },
+		func(k *Keys) { k.Salt = "***" },
+		func(k *Keys) { k.Check = "plain" },
+	}
+	for i, modify := range tests {
+		keys := validKeys()
+		modify(&keys)
+		if err := keys.Validate(); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("case %d: expected ErrValidation, got %v", i, err)
+		}
+	}
+}
+
+func TestKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "e2e.json")
+	store, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2025, 3, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*3600)) }
+	if _, ok := store.Get(); ok {
+		t.Fatal("Expected no keys before registration")
+	}
+
+	if _, err := store.Set(Keys{KDF: KDF}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected invalid keys to be rejected, got %v", err)
+	}
+	keys, err := store.Set(validKeys())
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !keys.CreatedAt.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || keys.CreatedAt.Location() != time.UTC {
+		t.Errorf("Unexpected created_at %v", keys.CreatedAt)
+	}
+	if _, err := store.Set(validKeys()); !errors.Is(err, ErrKeysExist) || !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected keys to be set only once, got %v", err)
+	}
+
+	// 再起動しても同じ鍵の情報を読み込みます
+	reopened, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	if got, ok := reopened.Get(); !ok || got != keys {
+		t.Errorf("Expected %+v after reopening, got %+v", keys, got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected only the key file, got %d entries", len(entries))
+	}
+}
+
+func TestKeyStoreErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte("{"), 0600)
+	if _, err := NewKeyStore(broken); err == nil {
+		t.Error("Expected an error for a broken file")
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"kdf": "none"}`), 0600)
+	if _, err := NewKeyStore(invalid); err == nil {
+		t.Error("Expected an error for invalid keys")
+	}
+	if _, err := NewKeyStore(dir); err == nil {
+		t.Error("Expected an error when the path is a directory")
+	}
+
+	store, _ := NewKeyStore(filepath.Join(dir, "missing", "e2e.json"))
+	if _, err := store.Set(validKeys()); err == nil {
+		t.Error("Expected an error when the directory does not exist")
+	}
+	if _, ok := store.Get(); ok {
+		t.Error("Expected failed writes not to register keys")
+	}
+}
@@ -0,0 +1,309 @@
+// Package exechooks はタスクのイベントをきっかけに、設定したコマンドを実行します
+//
+// コマンドはシェルを通さずに実行し、引数のテンプレートを展開した値もそのまま1つの引数として渡すため、
+// タスクのタイトルに記号が含まれていてもコマンドとして解釈されることはありません
+// 環境変数は PATH とタスクの情報だけを渡し、タイムアウトを過ぎたコマンドは強制終了します
+package exechooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"todo-app/models"
+)
+
+// EventCompleted は未完了のタスクを完了にしたときのイベントです（models のイベントに加えて使えます）
+const EventCompleted = "task.completed"
+
+// DefaultTimeout はタイムアウトを指定しないフックの実行時間の上限です
+const DefaultTimeout = 10 * time.Second
+
+// MaxTimeout は指定できる実行時間の上限です
+const MaxTimeout = 5 * time.Minute
+
+// maxOutput はログに残すコマンドの出力の最大バイト数です
+const maxOutput = 4096
+
+// maxConcurrent は同時に実行するコマンドの最大数です
+const maxConcurrent = 4
+
+// Hook は1件のコマンドフックの設定です
+// Event: きっかけ（task.created / task.updated / task.completed / task.deleted / task.reminder）
+// Filter: 対象のタスクを選ぶ検索式（models.ParseQuery の形式、空ならすべて）
+// Command: 実行するコマンド（絶対パスか PATH から探す名前）
+// Args: 引数の Go テンプレート（.Task・.Event を参照できます）
+// Dir: 作業ディレクトリ（空なら一時ディレクトリ）
+// TimeoutSeconds: 実行時間の上限（秒、省略時は DefaultTimeout）
+type Hook struct {
+	Name           string   `json:"name"`
+	Event          string   `json:"event"`
+	Filter         string   `json:"filter,omitempty"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	Dir            string   `json:"dir,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+
+	query models.Query
+	args  []*template.Template
+}
+
+// compile はフックの設定を検証し、検索式と引数のテンプレートを読み取ります
+func (h *Hook) compile() error {
+	if h.Name == "" {
+		return errors.New("name is required")
+	}
+	switch h.Event {
+	case string(models.EventTaskCreated), string(models.EventTaskUpdated), string(models.EventTaskDeleted), string(models.EventTaskReminder), EventCompleted:
+	default:
+		return fmt.Errorf("hook %s: unknown event %q", h.Name, h.Event)
+	}
+	if h.Command == "" {
+		return fmt.Errorf("hook %s: command is required", h.Name)
+	}
+	if h.TimeoutSeconds < 0 || time.Duration(h.TimeoutSeconds)*time.Second > MaxTimeout {
+		return fmt.Errorf("hook %s: timeout_seconds must be between 0 and %d", h.Name, int(MaxTimeout/time.Second))
+	}
+	query, err := models.ParseQuery(h.Filter)
+	if err != nil {
+		return fmt.Errorf("hook %s: filter: %v", h.Name, err)
+	}
+	h.query = query
+	h.args = make([]*template.Template, len(h.Args))
+	for i, arg := range h.Args {
+		if h.args[i], err = template.New(h.Name).Option("missingkey=error").Parse(arg); err != nil {
+			return fmt.Errorf("hook %s: args[%d]: %v", h.Name, i, err)
+		}
+	}
+	return nil
+}
+
+// timeout はフックの実行時間の上限を返します
+func (h Hook) timeout() time.Duration {
+	if h.TimeoutSeconds == 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// TemplateData は引数のテンプレートに渡すデータです
+type TemplateData struct {
+	Event models.Event
+	Task  models.Task
+}
+
+// Load は JSON の設定ファイル（フックの配列）を読み込みます
+func Load(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Hook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return hooks, nil
+}
+
+// Result は1回の実行の結果です
+type Result struct {
+	Hook     string
+	TaskID   int
+	ExitCode int
+	Duration time.Duration
+	Output   string
+	Err      error
+}
+
+// Runner はイベントバスの購読者として、当てはまるフックのコマンドを非同期に実行し、結果をログに記録します
+type Runner struct {
+	hooks  []Hook
+	logger *slog.Logger
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	// completed: タスクごとの直前の完了状態（task.completed を未完了から完了への変化だけで起こすために使います）
+	mutex     sync.Mutex
+	completed map[int]bool
+}
+
+// NewRunner は hooks を検証して Runner を作成します。logger が nil なら slog.Default() を使います
+func NewRunner(hooks []Hook, logger *slog.Logger) (*Runner, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	compiled := make([]Hook, len(hooks))
+	for i, hook := range hooks {
+		if err := hook.compile(); err != nil {
+			return nil, err
+		}
+		compiled[i] = hook
+	}
+	return &Runner{
+		hooks:     compiled,
+		logger:    logger,
+		sem:       make(chan struct{}, maxConcurrent),
+		completed: make(map[int]bool),
+	}, nil
+}
+
+// HandleEvent はイベントに当てはまるフックを非同期に実行します
+func (r *Runner) HandleEvent(event models.Event) {
+	events := r.events(event)
+	for _, hook := range r.hooks {
+		if !hasEvent(events, hook.Event) || !hook.query.Match(event.Task) {
+			continue
+		}
+		r.wg.Add(1)
+		go func(hook Hook) {
+			defer r.wg.Done()
+			r.sem <- struct{}{}
+			defer func() { <-r.sem }()
+			r.log(r.Run(context.Background(), hook, event))
+		}(hook)
+	}
+}
+
+// Wait は実行中のコマンドがすべて終わるまで待ちます
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// events はイベントから起こるきっかけを返し、タスクの完了状態を記録します
+func (r *Runner) events(event models.Event) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := event.Task.ID
+	events := []string{string(event.Type)}
+	switch event.Type {
+	case models.EventTaskCreated:
+		r.completed[id] = event.Task.Completed
+	case models.EventTaskUpdated:
+		if event.Task.Completed && !r.completed[id] {
+			events = append(events, EventCompleted)
+		}
+		r.completed[id] = event.Task.Completed
+	case models.EventTaskDeleted:
+		delete(r.completed, id)
+	}
+	return events
+}
+
+func hasEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run はフックのコマンドを1回、同期的に実行します
+// 標準入力にはイベントの JSON を、環境変数には PATH と TODO_EVENT・TODO_TASK_ID・TODO_TASK_TITLE を渡します
+func (r *Runner) Run(ctx context.Context, hook Hook, event models.Event) Result {
+	result := Result{Hook: hook.Name, TaskID: event.Task.ID, ExitCode: -1}
+	data := TemplateData{Event: event, Task: event.Task}
+	args := make([]string, len(hook.args))
+	for i, tmpl := range hook.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			result.Err = fmt.Errorf("args[%d]: %v", i, err)
+			return result
+		}
+		args[i] = buf.String()
+	}
+	input, err := json.Marshal(event)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	dir := hook.Dir
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "todo-hook-"); err != nil {
+			result.Err = err
+			return result
+		}
+		defer os.RemoveAll(dir)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hook.Command, args...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"TODO_EVENT=" + string(event.Type),
+		"TODO_TASK_ID=" + strconv.Itoa(event.Task.ID),
+		"TODO_TASK_TITLE=" + event.Task.Title,
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	output := &limitedBuffer{limit: maxOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	err = cmd.Run()
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Err = fmt.Errorf("timed out after %s", hook.timeout())
+	case err != nil:
+		result.Err = err
+	}
+	return result
+}
+
+// log は実行の結果をログに記録します。失敗した場合はコマンドの出力も残します
+func (r *Runner) log(result Result) {
+	if result.Err != nil {
+		r.logger.Warn("exec hook failed", "hook", result.Hook, "task_id", result.TaskID,
+			"duration", result.Duration.Round(time.Millisecond), "err", result.Err, "output", result.Output)
+		return
+	}
+	r.logger.Info("exec hook ran", "hook", result.Hook, "task_id", result.TaskID,
+		"duration", result.Duration.Round(time.Millisecond), "exit_code", result.ExitCode)
+}
+
+// limitedBuffer は最初の limit バイトだけを残す出力先です。それ以降は捨てますが、書き込み自体は成功させます
+type limitedBuffer struct {
+	mutex     sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.truncated {
+		return b.buf.String() + "…(truncated)"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,165 @@
+package exechooks
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"todo-app/models"
+)
+
+func newRunner(t *testing.T, hooks ...Hook) (*Runner, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	runner, err := NewRunner(hooks, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("NewRunner failed: %v", err)
+	}
+	return runner, &logs
+}
+
+func TestNewRunnerValidatesHooks(t *testing.T) {
+	testCases := []Hook{
+		{Event: "task.created", Command: "true"},
+		{Name: "a", Event: "task.renamed", Command: "true"},
+		{Name: "a", Event: "task.created"},
+		{Name: "a", Event: "task.created", Command: "true", TimeoutSeconds: 301},
+		{Name: "a", Event: "task.created", Command: "true", Filter: "list:deploy"},
+		{Name: "a", Event: "task.created", Command: "true", Args: []string{"{{.Task.ID"}},
+	}
+	for _, hook := range testCases {
+		if _, err := NewRunner([]Hook{hook}, nil); err == nil {
+			t.Errorf("Expected an error for %+v", hook)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	runner, _ := newRunner(t)
+	hook := Hook{
+		Name:    "echo",
+		Event:   "task.created",
+		Command: "sh",
+		Args:    []string{"-c", `echo "$1 $TODO_TASK_ID $TODO_EVENT"; cat; echo; env | grep -c ^SECRET_ || true`, "sh", "{{.Task.Title}}"},
+	}
+	if err := hook.compile(); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	os.Setenv("SECRET_TOKEN", "do-not-leak")
+	defer os.Unsetenv("SECRET_TOKEN")
+
+	task := models.Task{ID: 7, Title: "deploy; rm -rf /"}
+	result := runner.Run(context.Background(), hook, models.NewEvent(context.Background(), 1, models.EventTaskCreated, task))
+	if result.Err != nil || result.ExitCode != 0 {
+		t.Fatalf("Expected the command to succeed, got %+v", result)
+	}
+	lines := strings.Split(result.Output, "\n")
+	if lines[0] != "deploy; rm -rf / 7 task.created" {
+		t.Errorf("Expected the title to be passed as one argument, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"title":"deploy; rm -rf /"`) {
+		t.Errorf("Expected the event JSON on stdin, got %q", lines[1])
+	}
+	if lines[2] != "0" {
+		t.Errorf("Expected other environment variables not to be passed, got %q", lines[2])
+	}
+}
+
+func TestRunTimeoutAndFailure(t *testing.T) {
+	runner, _ := newRunner(t)
+	event := models.NewEvent(context.Background(), 1, models.EventTaskCreated, models.Task{ID: 1, Title: "a"})
+
+	slow := Hook{Name: "slow", Event: "task.created", Command: "sleep", Args: []string{"5"}, TimeoutSeconds: 1}
+	slow.compile()
+	if result := runner.Run(context.Background(), slow, event); result.Err == nil || !strings.Contains(result.Err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %+v", result)
+	}
+
+	failing := Hook{Name: "fail", Event: "task.created", Command: "sh", Args: []string{"-c", "echo oops; exit 3"}}
+	failing.compile()
+	if result := runner.Run(context.Background(), failing, event); result.Err == nil || result.ExitCode != 3 || result.Output != "oops\n" {
+		t.Errorf("Expected exit code 3 with the output, got %+v", result)
+	}
+
+	missing := Hook{Name: "missing", Event: "task.created", Command: "todo-hook-does-not-exist"}
+	missing.compile()
+	if result := runner.Run(context.Background(), missing, event); result.Err == nil {
+		t.Errorf("Expected an error for a missing command, got %+v", result)
+	}
+
+	badArg := Hook{Name: "bad", Event: "task.created", Command: "true", Args: []string{"{{.Unknown}}"}}
+	badArg.compile()
+	if result := runner.Run(context.Background(), badArg, event); result.Err == nil {
+		t.Errorf("Expected a template error, got %+v", result)
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "ran.txt")
+	runner, logs := newRunner(t,
+		Hook{
+			Name:    "deploy",
+			Event:   "task.completed",
+			Filter:  "title:deploy",
+			Command: "sh",
+			Args:    []string{"-c", `echo "$TODO_TASK_TITLE" >> "$1"`, "sh", out},
+			Dir:     dir,
+		},
+		Hook{Name: "fail", Event: "task.deleted", Command: "false"},
+	)
+
+	app := models.NewTodoApp()
+	app.Subscribe(runner.HandleEvent)
+	ctx := context.Background()
+	deploy, _ := app.AddTask(ctx, "deploy api")
+	other, _ := app.AddTask(ctx, "write docs")
+	app.ToggleTask(ctx, deploy.ID)
+	app.ToggleTask(ctx, other.ID)
+	app.SetPriority(ctx, deploy.ID, models.PriorityHigh)
+	app.DeleteTask(ctx, other.ID)
+	runner.Wait()
+
+	data, _ := os.ReadFile(out)
+	if string(data) != "deploy api\n" {
+		t.Errorf("Expected the hook to run once for the completed deploy task, got %q", data)
+	}
+	if !strings.Contains(logs.String(), `msg="exec hook ran" hook=deploy task_id=1`) || !strings.Contains(logs.String(), `msg="exec hook failed" hook=fail task_id=2`) {
+		t.Errorf("Expected the runs to be logged, got %q", logs.String())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hooks.json")
+	os.WriteFile(path, []byte(`[{"name": "n", "event": "task.created", "command": "true", "args": ["{{.Task.ID}}"]}]`), 0o600)
+	hooks, err := Load(path)
+	if err != nil || len(hooks) != 1 || hooks[0].Args[0] != "{{.Task.ID}}" {
+		t.Errorf("Unexpected hooks %+v (%v)", hooks, err)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0o600)
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 4}
+	if n, err := buf.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Unexpected write result %d, %v", n, err)
+	}
+	if n, _ := buf.Write([]byte("defg")); n != 4 {
+		t.Errorf("Expected the whole write to be reported, got %d", n)
+	}
+	if got := buf.String(); got != "abcd…(truncated)" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+// Package export はタスク一覧をほかのツールで扱える形式に書き出します
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"todo-app/models"
+)
+
+// DefaultListName はリストに属さないタスクを書き出すファイルの名前です
+const DefaultListName = "Tasks"
+
+// MarkdownList は Markdown ファイル1つ分（リスト1つ分）のタスクです
+type MarkdownList struct {
+	Name  string
+	Tasks []models.Task
+}
+
+// WriteMarkdownVault は Obsidian / Logseq で開ける Markdown ファイル群を zip として書き出します
+// リストごとに1ファイルを作り、先頭にリストの情報を YAML フロントマターとして付けます
+func WriteMarkdownVault(w io.Writer, lists []MarkdownList, exportedAt time.Time) error {
+	archive := zip.NewWriter(w)
+	used := make(map[string]int)
+
+	for _, list := range lists {
+		name := fileName(list.Name)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name + ".md",
+			Method:   zip.Deflate,
+			Modified: exportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, Markdown(list, exportedAt)); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Markdown はリスト1つ分の Markdown を作成します
+// タスクは Obsidian Tasks 形式のチェックボックス（期限は 📅、ブロックIDは ^task-ID）で表します
+func Markdown(list MarkdownList, exportedAt time.Time) string {
+	completed := 0
+	for _, task := range list.Tasks {
+		if task.Completed {
+			completed++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", list.Name)
+	fmt.Fprintf(&b, "exported: %s\n", exportedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "tasks: %d\n", len(list.Tasks))
+	fmt.Fprintf(&b, "completed: %d\n", completed)
+	b.WriteString("tags: [todo]\n")
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", list.Name)
+
+	for _, task := range list.Tasks {
+		check := " "
+		if task.Completed {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s", check, singleLine(task.Title))
+		if task.DueDate != nil {
+			fmt.Fprintf(&b, " 📅 %s", task.DueDate.Format("2006-01-02"))
+		}
+		fmt.Fprintf(&b, " ^task-%d\n", task.ID)
+	}
+	return b.String()
+}
+
+// singleLine は改行を空白に置き換え、チェックボックスの行が崩れないようにします
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// fileName はリスト名からファイル名に使えない文字を取り除きます
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		return DefaultListName
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestMarkdown(t *testing.T) {
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	exportedAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	list := MarkdownList{Name: "Groceries", Tasks: []models.Task{
+		{ID: 1, Title: "Buy milk", DueDate: &due},
+		{ID: 2, Title: "Eggs\nand bacon", Completed: true},
+	}}
+
+	expected := `---
+title: "Groceries"
+exported: 2025-02-01T09:00:00Z
+tasks: 2
+completed: 1
+tags: [todo]
+---
+
+# Groceries
+
+- [ ] Buy milk 📅 2025-03-01 ^task-1
+- [x] Eggs and bacon ^task-2
+`
+	if got := Markdown(list, exportedAt); got != expected {
+		t.Errorf("Unexpected markdown:\n%s", got)
+	}
+}
+
+func TestWriteMarkdownVault(t *testing.T) {
+	lists := []MarkdownList{
+		{Name: "Work/Home", Tasks: []models.Task{{ID: 1, Title: "A"}}},
+		{Name: "Work/Home", Tasks: []models.Task{{ID: 2, Title: "B"}}},
+		{Name: "  ", Tasks: nil},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdownVault(&buf, lists, time.Now()); err != nil {
+		t.Fatalf("WriteMarkdownVault failed: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+
+	names := []string{}
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	expected := []string{"Work-Home.md", "Work-Home (2).md", "Tasks.md"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected files %v, got %v", expected, names)
+	}
+
+	file, _ := reader.File[1].Open()
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if !strings.Contains(string(content), "- [ ] B ^task-2") {
+		t.Errorf("Unexpected content: %s", content)
+	}
+}
@@ -0,0 +1,202 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PDF の用紙（A4、単位はポイント）と余白です
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+)
+
+// 文字の大きさと行の高さです
+const (
+	pdfTitleSize    = 18
+	pdfSubtitleSize = 9
+	pdfTaskSize     = 11
+	pdfTaskLeading  = 15
+	pdfDueSize      = 9
+	pdfDueLeading   = 12
+	pdfTaskGap      = 7
+)
+
+// pdfTextX はタスクのタイトルを書き始める位置です（左にチェックボックスを描きます）
+const pdfTextX = pdfMargin + 18
+
+// pdfFont はタイトルを書くフォントです
+// フォントは埋め込まず、Adobe-Japan1 の標準のゴシック体を指定します。閲覧ソフトが手元の日本語フォントで表示するため、ファイルが小さく済みます
+const pdfFont = "HeiseiKakuGo-W5"
+
+// WritePDF はリスト1つ分のタスクを、印刷できる A4 の PDF として書き出します
+// タスクごとにチェックボックス（完了したものはチェック付き）・タイトル・期限を並べ、入りきらなければ次のページに続けます
+func WritePDF(w io.Writer, list MarkdownList, exportedAt time.Time) error {
+	pages := layoutPDF(list, exportedAt)
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /UniJIS-UCS2-H /DescendantFonts [4 0 R] >>", pdfFont))
+	// 半角の英数字（横書きの CID 1〜95 と 231〜632）は幅を全角の半分にします
+	object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500 231 632 500] >>", pdfFont))
+	object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [-92 -250 1010 922] /ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>", pdfFont))
+	object(fmt.Sprintf("<< /Title <FEFF%s> /Producer (todo-app) /CreationDate (D:%sZ) >>", pdfHex(list.Name), exportedAt.UTC().Format("20060102150405")))
+	for i, page := range pages {
+		page.WriteString(pdfText(pdfPageWidth/2-12, pdfMargin/2, pdfSubtitleSize, fmt.Sprintf("%d / %d", i+1, len(pages))))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 8+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// layoutPDF はタスクをページに割り付け、ページごとの描画の命令（コンテンツストリーム）を返します
+func layoutPDF(list MarkdownList, exportedAt time.Time) []*bytes.Buffer {
+	open := 0
+	for _, task := range list.Tasks {
+		if !task.Completed {
+			open++
+		}
+	}
+
+	page := &bytes.Buffer{}
+	pages := []*bytes.Buffer{page}
+	y := float64(pdfPageHeight - pdfMargin - pdfTitleSize)
+	page.WriteString(pdfText(pdfMargin, y, pdfTitleSize, list.Name))
+	y -= pdfSubtitleSize + 9
+	page.WriteString("0.4 g\n")
+	page.WriteString(pdfText(pdfMargin, y, pdfSubtitleSize, fmt.Sprintf("%s 時点 ・ 未完了 %d 件 / 全 %d 件", exportedAt.Format("2006-01-02 15:04"), open, len(list.Tasks))))
+	page.WriteString("0 g\n")
+	y -= 8
+	fmt.Fprintf(page, "0.5 w 0.7 G %d %.2f m %d %.2f l S 0 G\n", pdfMargin, y, pdfPageWidth-pdfMargin, y)
+	y -= pdfTaskSize + 12
+
+	if len(list.Tasks) == 0 {
+		page.WriteString(pdfText(pdfMargin, y, pdfTaskSize, "タスクはありません"))
+	}
+
+	maxWidth := float64(pdfPageWidth-pdfMargin-pdfTextX) / pdfTaskSize
+	for _, task := range list.Tasks {
+		lines := wrapPDF(strings.Join(strings.Fields(task.Title), " "), maxWidth)
+		height := float64(len(lines)-1) * pdfTaskLeading
+		if task.DueDate != nil {
+			height += pdfDueLeading
+		}
+		if y-height < pdfMargin {
+			page = &bytes.Buffer{}
+			pages = append(pages, page)
+			y = pdfPageHeight - pdfMargin - pdfTaskSize
+		}
+
+		fmt.Fprintf(page, "0.8 w %d %.2f 9 9 re S\n", pdfMargin, y-1)
+		if task.Completed {
+			fmt.Fprintf(page, "1.2 w %.2f %.2f m %.2f %.2f l %.2f %.2f l S\n0.5 g\n", pdfMargin+1.5, y+3.5, pdfMargin+4.0, y+0.5, pdfMargin+9.0, y+9)
+		}
+		for i, line := range lines {
+			if i > 0 {
+				y -= pdfTaskLeading
+			}
+			page.WriteString(pdfText(pdfTextX, y, pdfTaskSize, line))
+		}
+		if task.DueDate != nil {
+			y -= pdfDueLeading
+			page.WriteString("0.4 g\n")
+			page.WriteString(pdfText(pdfTextX, y, pdfDueSize, "期限 "+task.DueDate.Format("2006-01-02")))
+		}
+		page.WriteString("0 g\n")
+		y -= pdfTaskSize + pdfTaskGap
+	}
+	return pages
+}
+
+// pdfText は (x, y) から size の大きさで text を書く命令を返します
+func pdfText(x, y float64, size int, text string) string {
+	return fmt.Sprintf("BT /F1 %d Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, pdfHex(text))
+}
+
+// pdfHex は text を UTF-16BE の16進数の文字列にします
+// UniJIS-UCS2-H は基本多言語面の文字しか扱えないため、絵文字などは "?" に、制御文字は空白にします
+func pdfHex(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r < 0x20:
+			r = ' '
+		case r > 0xFFFF || utf16.IsSurrogate(r):
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// pdfWidth は文字の幅を全角を 1 とした単位で返します
+func pdfWidth(r rune) float64 {
+	if r < 0x80 || (r >= 0xFF61 && r <= 0xFF9F) {
+		return 0.5
+	}
+	return 1
+}
+
+// wrapPDF は text を maxWidth（全角の文字数）に収まるように行に分けます
+// 半角の空白があればそこで、なければ文字の途中で分けます
+func wrapPDF(text string, maxWidth float64) []string {
+	var lines []string
+	var line []rune
+	width, lastSpace := 0.0, -1
+	for _, r := range text {
+		if width+pdfWidth(r) > maxWidth && len(line) > 0 {
+			if r == ' ' {
+				// 行の終わりの空白は次の行へ持ち越しません
+				lines = append(lines, string(line))
+				line, width, lastSpace = nil, 0, -1
+				continue
+			}
+			if lastSpace > 0 {
+				lines = append(lines, string(line[:lastSpace]))
+				line = append([]rune(nil), line[lastSpace+1:]...)
+			} else {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			width, lastSpace = 0, -1
+			for i, c := range line {
+				width += pdfWidth(c)
+				if c == ' ' {
+					lastSpace = i
+				}
+			}
+		}
+		if r == ' ' {
+			lastSpace = len(line)
+		}
+		line = append(line, r)
+		width += pdfWidth(r)
+	}
+	return append(lines, string(line))
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// checkPDFStructure は相互参照表の位置がそれぞれのオブジェクトの先頭を指しているかを確かめ、ページ数を返します
+func checkPDFStructure(t *testing.T, pdf []byte) int {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF header and trailer, got %q ... %q", pdf[:20], pdf[len(pdf)-20:])
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("Expected startxref to point to the xref table, got %q", pdf[xref:xref+10])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("Expected object %d at offset %d, got %q", i+1, offset, pdf[offset:offset+10])
+		}
+	}
+	for _, m := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(pdf, -1) {
+		if length, _ := strconv.Atoi(string(m[1])); length != len(m[2]) {
+			t.Errorf("Expected a stream of %d bytes, got %d", length, len(m[2]))
+		}
+	}
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf)
+	n, _ := strconv.Atoi(string(count[1]))
+	return n
+}
+
+func TestWritePDF(t *testing.T) {
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	list := MarkdownList{Name: "持ち物", Tasks: []models.Task{
+		{ID: 1, Title: "パスポート", DueDate: &due},
+		{ID: 2, Title: "Charger", Completed: true},
+	}}
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, list, time.Date(2025, 2, 20, 9, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.Bytes()
+	if pages := checkPDFStructure(t, pdf); pages != 1 {
+		t.Errorf("Expected one page, got %d", pages)
+	}
+	for _, want := range []string{
+		"/BaseFont /HeiseiKakuGo-W5 /Encoding /UniJIS-UCS2-H",
+		"/Title <FEFF" + pdfHex("持ち物") + ">",
+		"/CreationDate (D:20250220093000Z)",
+		"<" + pdfHex("パスポート") + "> Tj",
+		"<" + pdfHex("期限 2025-03-01") + "> Tj",
+		"<" + pdfHex("2025-02-20 09:30 時点 ・ 未完了 1 件 / 全 2 件") + "> Tj",
+		"<" + pdfHex("1 / 1") + "> Tj",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("Expected the PDF to contain %q", want)
+		}
+	}
+	// チェックボックスは2つ、チェックは完了した1つだけです
+	if boxes, checks := bytes.Count(pdf, []byte(" 9 9 re S")), bytes.Count(pdf, []byte("1.2 w")); boxes != 2 || checks != 1 {
+		t.Errorf("Expected 2 boxes and 1 check, got %d and %d", boxes, checks)
+	}
+}
+
+func TestWritePDFPages(t *testing.T) {
+	var tasks []models.Task
+	for i := 1; i <= 80; i++ {
+		tasks = append(tasks, models.Task{ID: i, Title: fmt.Sprintf("Task %d", i)})
+	}
+	var buf bytes.Buffer
+	WritePDF(&buf, MarkdownList{Name: DefaultListName, Tasks: tasks}, time.Now())
+	pages := checkPDFStructure(t, buf.Bytes())
+	if pages < 2 {
+		t.Fatalf("Expected the tasks to continue on more pages, got %d", pages)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("<"+pdfHex(fmt.Sprintf("%d / %d", pages, pages))+"> Tj")) || !bytes.Contains(buf.Bytes(), []byte(pdfHex("Task 80"))) {
+		t.Error("Expected the last task and page number on the last page")
+	}
+
+	buf.Reset()
+	WritePDF(&buf, MarkdownList{Name: DefaultListName}, time.Now())
+	if checkPDFStructure(t, buf.Bytes()) != 1 || !bytes.Contains(buf.Bytes(), []byte(pdfHex("タスクはありません"))) {
+		t.Error("Expected one page saying there are no tasks")
+	}
+}
+
+func TestPDFHex(t *testing.T) {
+	if got := pdfHex("A あ\n📅"); got != "0041002030420020003F" {
+		t.Errorf("Unexpected hex %s", got)
+	}
+}
+
+func TestWrapPDF(t *testing.T) {
+	testCases := []struct {
+		text  string
+		width float64
+		want  []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"pack the bags now", 5, []string{"pack the", "bags now"}},
+		{"pack the bags now", 2, []string{"pack", "the", "bags", "now"}},
+		{"あいうえおかきくけこ", 4, []string{"あいうえ", "おかきく", "けこ"}},
+		{"abcdefghij", 2, []string{"abcd", "efgh", "ij"}},
+		{"", 5, []string{""}},
+	}
+	for _, tc := range testCases {
+		if got := wrapPDF(tc.text, tc.width); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("wrapPDF(%q, %v) = %q, want %q", tc.text, tc.width, got, tc.want)
+		}
+	}
+	if lines := wrapPDF(strings.Repeat("長い", 100), 30); len(lines) != 7 {
+		t.Errorf("Expected 7 lines, got %d", len(lines))
+	}
+}
@@ -1,3 +1,3 @@
 module todo-app
 
-go 1.18
+go 1.21
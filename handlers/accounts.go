@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/accounts"
+	"todo-app/models"
+	todov1 "todo-app/proto/todo/v1"
+)
+
+// SessionCookie はログインのセッションのトークンを入れる Cookie の名前です
+const SessionCookie = "todo_session"
+
+// publicPaths はユーザーアカウントを有効にしたときもログインせずに使えるパスです
+// 末尾が / のものは接頭辞として照合します。/api/admin/ は管理用トークンで、/scim/ は SCIM のトークンで、/share/ は共有リンクのトークンで保護します
+var publicPaths = []string{"/login", "/static/", "/api/auth/", "/api/admin/", "/api/schemas/", "/scim/", "/share/"}
+
+// accountsEnabled はユーザーアカウントが有効かどうかを返します
+func (s *Server) accountsEnabled() bool {
+	return s.accounts != nil && s.userHandlers != nil
+}
+
+// isPublicPath は path がログインせずに使えるパスかどうかを返します
+func isPublicPath(path string) bool {
+	for _, p := range publicPaths {
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAPIPath は path が API（ワークスペースの /w/{slug}/api/ と gRPC の TodoService を含む）かどうかを返します
+func isAPIPath(path string) bool {
+	if rest, ok := strings.CutPrefix(path, "/w/"); ok {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			path = rest[i:]
+		}
+	}
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, grpcServicePath)
+}
+
+// userKey はリクエストのコンテキストに認証したユーザーを入れるキーです
+type userKey struct{}
+
+// withAccounts はログインしたユーザーのリクエストを、そのユーザーのタスクだけを扱うサーバ（userHandlers）へ渡します
+// ユーザーはセッションの Cookie か、Authorization: Bearer の API キーで認証します
+// 認証できなければ、API には 401 を返し、画面はログイン画面へリダイレクトします
+// API キーがその日かその月のリクエスト数の上限に達していれば、429 と上限が戻るまでの Retry-After を返します
+// ログインせずに使えるパス（publicPaths）はそのまま next に渡します
+// API キーとセッションの管理（/api/keys・/api/sessions）とワークスペース（/w/）は next に、それ以外はユーザーのサーバに、ユーザーをコンテキストに入れて渡します
+func (s *Server) withAccounts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, key, ok := s.requestUser(r)
+		// セッションが切れていても、「ログインしたままにする」端末ならセッションを始め直します
+		if !ok && r.Header.Get("Authorization") == "" {
+			user, ok = s.resumeSession(w, r)
+		}
+		if !ok {
+			if isAPIPath(r.URL.Path) {
+				s.writeError(w, r, errUnauthorized)
+				return
+			}
+			http.Redirect(w, r, s.config.BasePath+"/login", http.StatusSeeOther)
+			return
+		}
+		if key != nil {
+			if retryAfter, ok := s.apiKeys.Use(key.ID); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				s.audit(r, slog.LevelWarn, "API key quota exceeded", "user_id", key.UserID, "key_id", key.ID)
+				s.writeError(w, r, errQuotaExceeded)
+				return
+			}
+		}
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+		// ワークスペース（/w/）はユーザーごとに分けず、ログインしたユーザーが共有します
+		if isAccountPath(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/w/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		handler, err := s.userHandlers.Get(user)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// accountPaths はユーザーのタスクのサーバではなく、このサーバで扱うアカウントの管理のパスです
+var accountPaths = []string{"/api/keys", "/api/sessions"}
+
+// isAccountPath は path がアカウントの管理のパス（accountPaths とその下）かどうかを返します
+func isAccountPath(path string) bool {
+	for _, p := range accountPaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// requestUser はリクエストの API キーかセッションで認証したユーザーと、API キーで認証したときはそのキーを返します
+// Authorization ヘッダがあれば、Cookie があっても API キーだけで認証します。無効にしたユーザーは認証しません
+func (s *Server) requestUser(r *http.Request) (accounts.User, *accounts.APIKey, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		user, ok := s.sessionUser(r)
+		return user, nil, ok
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return accounts.User{}, nil, false
+	}
+	key, ok := s.apiKeys.Authenticate(token)
+	if !ok {
+		s.audit(r, slog.LevelWarn, "invalid API key", "method", r.Method, "path", r.URL.Path)
+		return accounts.User{}, nil, false
+	}
+	user, ok := s.accounts.Lookup(key.UserID)
+	return user, &key, ok && !user.Disabled
+}
+
+// contextUser は withAccounts がコンテキストに入れたユーザーを返します
+func contextUser(r *http.Request) (accounts.User, bool) {
+	user, ok := r.Context().Value(userKey{}).(accounts.User)
+	return user, ok
+}
+
+// sessionUser はリクエストの Cookie のセッションでログインしているユーザーを返します
+func (s *Server) sessionUser(r *http.Request) (accounts.User, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return accounts.User{}, false
+	}
+	id, ok := s.sessions.Lookup(cookie.Value)
+	if !ok {
+		return accounts.User{}, false
+	}
+	user, ok := s.accounts.Lookup(id)
+	return user, ok && !user.Disabled
+}
+
+// setSessionCookie はセッションのトークンを Cookie に入れます（token が空なら消します）
+func (s *Server) setSessionCookie(w http.ResponseWriter, r *http.Request, token string, expires time.Time) {
+	s.setAuthCookie(w, r, SessionCookie, token, expires)
+}
+
+// setAuthCookie はセッションなどのトークンを JavaScript から読めない Cookie に入れます
+// TLS で受けたリクエストでは Secure を付けます。SameSite=Lax でほかのサイトからの POST には Cookie を送らせません
+func (s *Server) setAuthCookie(w http.ResponseWriter, r *http.Request, name, token string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    token,
+		Path:     s.config.BasePath + "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if expires.IsZero() {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+	}
+	http.SetCookie(w, cookie)
+}
+
+// startSession は user のセッションを作成して Cookie に入れ、ユーザーを返します
+// remember なら「ログインしたままにする」端末として refresh token も発行し、別の Cookie に入れます
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user accounts.User, remember bool) {
+	ip := s.requestIP(r)
+	device := 0
+	if remember {
+		id, refresh, refreshExpires, err := s.sessions.RefreshTokens.Issue(user.ID, ip, r.UserAgent())
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		device = id
+		s.setRefreshCookie(w, r, refresh, refreshExpires)
+	}
+	token, expires, err := s.sessions.CreateOnDevice(user.ID, device, ip, r.UserAgent())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.setSessionCookie(w, r, token, expires)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
+
+// credentials は登録とログインで送るユーザー名とパスワードです
+// Email は登録で送るメールアドレス（確認が有効なときは必須）、Captcha はログインで求められたときの CAPTCHA の応答です
+// Invite は招待制のときの招待コード、Remember は「ログインしたままにする」かどうかです
+type credentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Captcha  string `json:"captcha,omitempty"`
+	Invite   string `json:"invite,omitempty"`
+	Remember bool   `json:"remember,omitempty"`
+}
+
+// CaptchaVerifier は CAPTCHA の応答を確かめます（captcha.Verifier が満たします）
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// decodeCredentials はリクエスト本文のユーザー名とパスワードを読み取ります
+func decodeCredentials(r *http.Request) (credentials, error) {
+	var req credentials
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return credentials{}, errInvalidJSON
+	}
+	return req, nil
+}
+
+// RegisterHandler はユーザーを登録し（POST {"name": "...", "password": "...", "email": "..."}）、そのままログインした状態にします
+// メールアドレスの確認が有効なら、メールアドレスを必須にして確認メールを送り、ログインはさせずに verification_required を返します
+// Config.InviteOnly なら、管理者が発行した招待コード（"invite"）がなければ 403（invalid_invite）を返します
+func (s *Server) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	req, err := decodeCredentials(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if s.emailVerificationEnabled() && strings.TrimSpace(req.Email) == "" {
+		s.writeError(w, r, fmt.Errorf("%w: email is required", models.ErrValidation))
+		return
+	}
+	// 招待制なら先に招待コードを1回使い、登録できなかったときは戻します
+	var invite accounts.Invite
+	if s.config.InviteOnly {
+		if invite, err = s.invites.Redeem(req.Invite); err != nil {
+			s.audit(r, slog.LevelWarn, "rejected registration without a valid invite")
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	user, err := s.accounts.RegisterWithEmail(req.Name, req.Password, req.Email)
+	if err != nil {
+		if s.config.InviteOnly {
+			if err := s.invites.Refund(invite.ID); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to refund invite", "invite_id", invite.ID, "err", err)
+			}
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.audit(r, slog.LevelInfo, "registered user", "user_id", user.ID, "user", user.Name)
+	if !s.emailVerificationEnabled() {
+		s.startSession(w, r, user, req.Remember)
+		return
+	}
+
+	// 送れなくても登録は済んでいるため、ログイン画面から送り直してもらいます
+	if err := s.sendVerification(r, user.Name); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to send verification email", "user_id", user.ID, "err", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":               true,
+		"user":                  user,
+		"verification_required": true,
+	})
+}
+
+// LoginHandler はユーザー名とパスワードを確かめ（POST {"name": "...", "password": "..."}）、セッションの Cookie を発行します
+// メールアドレスの確認が有効なら、確かめていないユーザーには 403（email_not_verified）を返します
+// 続けて失敗した IP アドレスとユーザー名は、管理用トークンと同じく一定時間締め出します
+// Captcha を設定していれば、締め出す前でも Config.CaptchaAfter 回失敗した後は CAPTCHA の応答を求めます
+func (s *Server) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	ip := s.requestIP(r)
+	if retryAfter, ok := s.loginAttempts.Allow(ip); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		s.writeError(w, r, errTooManyAttempts)
+		return
+	}
+	req, err := decodeCredentials(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// IP アドレスを変えながら1人のユーザーのパスワードを試されないよう、ユーザー名ごとにも失敗を数えます
+	// ユーザー名として使えない名前は登録できないので数えません（でたらめな名前で記録を増やされないように）
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	validName := accounts.ValidateName(name) == nil
+	if validName {
+		if retryAfter, ok := s.loginAccounts.Allow(name); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			s.writeError(w, r, errTooManyAttempts)
+			return
+		}
+	}
+	if s.captchaRequired(ip, name, validName) {
+		if err := s.captcha.Verify(r.Context(), req.Captcha, ip); err != nil {
+			s.audit(r, slog.LevelWarn, "login captcha failed", "user", name, "err", err)
+			s.writeError(w, r, errCaptchaRequired)
+			return
+		}
+	}
+	user, err := s.accounts.Authenticate(req.Name, req.Password)
+	if err != nil {
+		lockedFor, failures := s.loginAttempts.Fail(ip)
+		s.audit(r, slog.LevelWarn, "login failed", "user", name, "failures", failures)
+		if lockedFor > 0 {
+			s.audit(r, slog.LevelWarn, "locked out from login", "locked_for", lockedFor)
+		}
+		if validName {
+			if lockedFor, _ := s.loginAccounts.Fail(name); lockedFor > 0 {
+				s.audit(r, slog.LevelWarn, "account locked out from login", "user", name, "locked_for", lockedFor)
+			}
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.loginAttempts.Succeed(ip)
+	s.loginAccounts.Succeed(name)
+	if s.rejectUnverified(w, r, user) {
+		return
+	}
+	s.startSession(w, r, user, req.Remember)
+}
+
+// captchaRequired はログインに CAPTCHA の応答を求めるかどうかを返します
+// IP アドレスか（使える名前なら）ユーザー名が Config.CaptchaAfter 回以上続けて失敗していれば求めます
+func (s *Server) captchaRequired(ip, name string, validName bool) bool {
+	if s.captcha == nil {
+		return false
+	}
+	after := s.config.CaptchaAfter
+	return s.loginAttempts.Failures(ip) >= after || validName && s.loginAccounts.Failures(name) >= after
+}
+
+// LogoutHandler はセッションと「ログインしたままにする」端末を取り除き（POST）、Cookie を消します。ログインしていなくても成功を返します
+func (s *Server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		s.sessions.Delete(cookie.Value)
+	}
+	if cookie, err := r.Cookie(RefreshCookie); err == nil {
+		s.sessions.RefreshTokens.RevokeToken(cookie.Value)
+		s.setRefreshCookie(w, r, "", time.Time{})
+	}
+	s.setSessionCookie(w, r, "", time.Time{})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// ChangePasswordHandler はログインしているユーザーのパスワードを変更します（POST {"password": "...", "new_password": "..."}）
+// 新しいパスワードには登録と同じポリシーと使い回しの禁止を適用します。API キーでは変更できません
+// 変更するとほかの端末のセッションも取り除き、リクエストした端末には新しいセッションを発行します
+// 今のパスワードの誤りはログインの失敗と同じくユーザー名ごとに数え、続けて失敗すると締め出します
+func (s *Server) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	user, ok := s.sessionUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	if retryAfter, ok := s.loginAccounts.Allow(user.Name); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		s.writeError(w, r, errTooManyAttempts)
+		return
+	}
+	var req struct {
+		Password    string `json:"password"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	if err := s.accounts.ChangePassword(user.ID, req.Password, req.NewPassword); err != nil {
+		if errors.Is(err, accounts.ErrInvalidCredentials) {
+			if lockedFor, failures := s.loginAccounts.Fail(user.Name); lockedFor > 0 {
+				s.audit(r, slog.LevelWarn, "account locked out from login", "user", user.Name, "locked_for", lockedFor, "failures", failures)
+			}
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.loginAccounts.Succeed(user.Name)
+	revoked := s.sessions.RevokeAll(user.ID)
+	s.audit(r, slog.LevelInfo, "changed password", "user_id", user.ID, "revoked_sessions", revoked)
+	// 「ログインしたままにする」端末だったなら、新しい refresh token を発行し直します
+	_, err := r.Cookie(RefreshCookie)
+	s.startSession(w, r, user, err == nil)
+}
+
+// MeHandler はログインしているユーザーを返します（GET）。ログインしていなければ 401 を返します
+// Accept: application/x-protobuf なら、todo.proto の User で返します
+func (s *Server) MeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	user, ok := s.sessionUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	if wantsProto(r) {
+		writeProto(w, todov1.MarshalUser(user))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
+
+// LoginPageHandler はログインと登録の画面（login.html）を返します
+// ログイン済みか、「ログインしたままにする」端末でセッションを始め直せればトップページへリダイレクトします
+func (s *Server) LoginPageHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.sessionUser(r); ok {
+		http.Redirect(w, r, s.config.BasePath+"/", http.StatusSeeOther)
+		return
+	}
+	if _, ok := s.resumeSession(w, r); ok {
+		http.Redirect(w, r, s.config.BasePath+"/", http.StatusSeeOther)
+		return
+	}
+	s.static.serve(w, r, "login.html")
+}
@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+	"todo-app/lockout"
+	"todo-app/logging"
+	"todo-app/models"
+	"todo-app/workspace"
+)
+
+// newTestAccountsServer はユーザーアカウントを有効にし、ユーザーごとにメモリ上の保存先を持つ Server を作成します
+func newTestAccountsServer(t *testing.T) *Server {
+	t.Helper()
+	users, err := accounts.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	users.Iterations = 1000
+	return NewServer(Deps{
+		Logger:   logging.Discard(),
+		Accounts: users,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+}
+
+// authRequest は name と password を送る /api/auth/{action} のリクエストを行います
+func authRequest(s *Server, action, name, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"name": name, "password": password})
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/"+action, strings.NewReader(string(body))))
+	return rr
+}
+
+// sessionCookie は応答で発行されたセッションの Cookie を返します
+func sessionCookie(t *testing.T, rr *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == SessionCookie {
+			return cookie
+		}
+	}
+	t.Fatalf("Expected a session cookie, got %v", rr.Header()["Set-Cookie"])
+	return nil
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestAccountsServer(t)
+
+	rr := authRequest(s, "register", "alice", "correct horse")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success bool          `json:"success"`
+		User    accounts.User `json:"user"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if !response.Success || response.User.ID != 1 || response.User.Name != "alice" {
+		t.Errorf("Unexpected response: %s", rr.Body.String())
+	}
+	cookie := sessionCookie(t, rr)
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.Path != "/" {
+		t.Errorf("Expected an HttpOnly SameSite=Lax cookie, got %+v", cookie)
+	}
+
+	assertErrorResponse(t, authRequest(s, "register", "alice", "another password"), http.StatusConflict, "conflict")
+	assertErrorResponse(t, authRequest(s, "register", "carol", "short"), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, authRequest(s, "login", "alice", "wrong password"), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, authRequest(s, "login", "nobody", "correct horse"), http.StatusUnauthorized, "unauthorized")
+
+	rr = authRequest(s, "login", "alice", "correct horse")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected to log in, got %d: %s", rr.Code, rr.Body.String())
+	}
+	cookie = sessionCookie(t, rr)
+
+	req := httptest.NewRequest("GET", "/api/auth/me", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"alice"`) {
+		t.Errorf("Expected the logged-in user, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/auth/me", nil))
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+}
+
+func TestTasksArePrivate(t *testing.T) {
+	s := newTestAccountsServer(t)
+	alice := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+	bob := sessionCookie(t, authRequest(s, "register", "bob", "battery staple"))
+
+	request := func(cookie *http.Cookie, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := request(alice, "POST", "/api/tasks", `{"title": "Alice's task"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Failed to add a task: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := request(bob, "GET", "/api/tasks", ""); strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("Expected bob not to see alice's task, got %s", rr.Body.String())
+	}
+	if rr := request(bob, "PUT", "/api/tasks/1/toggle", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected bob not to toggle alice's task, got %d", rr.Code)
+	}
+	if rr := request(alice, "GET", "/api/tasks", ""); !strings.Contains(rr.Body.String(), "Alice's task") {
+		t.Errorf("Expected alice to see her task, got %s", rr.Body.String())
+	}
+	if len(s.Store().GetTasks(context.Background())) != 0 {
+		t.Error("Expected the task not to be added to the shared store")
+	}
+
+	// API のバージョンはユーザーのサーバにも引き継ぎます
+	rr := request(alice, "POST", "/api/v2/tasks", `{"title": ""}`)
+	assertErrorResponse(t, rr, http.StatusUnprocessableEntity, "unprocessable")
+
+	// ログインしていなければ API は 401、画面はログイン画面へのリダイレクトです
+	for _, path := range []string{"/api/tasks", "/api/tasks/1/toggle", "/api/v2/tasks", "/api/board"} {
+		assertErrorResponse(t, request(nil, "GET", path, ""), http.StatusUnauthorized, "unauthorized")
+	}
+	if rr := request(nil, "GET", "/", ""); rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" {
+		t.Errorf("Expected a redirect to the login page, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if rr := request(&http.Cookie{Name: SessionCookie, Value: "forged"}, "GET", "/api/tasks", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a forged session to be rejected, got %d", rr.Code)
+	}
+
+	// ログアウトしたセッションは使えません
+	rr = request(alice, "POST", "/api/auth/logout", "")
+	if rr.Code != http.StatusOK || sessionCookie(t, rr).MaxAge >= 0 {
+		t.Errorf("Expected the cookie to be cleared, got %d %v", rr.Code, rr.Header()["Set-Cookie"])
+	}
+	if rr := request(alice, "GET", "/api/tasks", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the session to end after logging out, got %d", rr.Code)
+	}
+}
+
+func TestWorkspacesRequireLogin(t *testing.T) {
+	users, _ := accounts.NewStore("")
+	users.Iterations = 1000
+	workspaces := workspace.NewStore(func(ws workspace.Workspace) (http.Handler, error) {
+		return NewServer(Deps{Store: models.NewTodoApp(), Config: Config{BasePath: ws.Path()}}), nil
+	})
+	workspaces.Create("family", "")
+	s := NewServer(Deps{
+		Logger:     logging.Discard(),
+		Accounts:   users,
+		Workspaces: workspaces,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+	alice := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+
+	request := func(cookie *http.Cookie, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// ログインしていなければ、ワークスペースも API は 401、画面はログイン画面へのリダイレクトです
+	assertErrorResponse(t, request(nil, "POST", "/w/family/api/tasks", `{"title": "Buy milk"}`), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, request(nil, "GET", "/w/family/api/tasks", ""), http.StatusUnauthorized, "unauthorized")
+	if rr := request(nil, "GET", "/w/family/", ""); rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" {
+		t.Errorf("Expected a redirect to the login page, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	// ログインしたユーザーはワークスペースを共有します
+	if rr := request(alice, "POST", "/w/family/api/tasks", `{"title": "Buy milk"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected alice to use the workspace, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := request(alice, "GET", "/w/family/api/tasks", ""); !strings.Contains(rr.Body.String(), "Buy milk") {
+		t.Errorf("Expected the workspace task, got %s", rr.Body.String())
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestAccountsServer(t)
+	authRequest(s, "register", "alice", "correct horse")
+
+	for i := 0; i < lockout.DefaultThreshold; i++ {
+		if rr := authRequest(s, "login", "alice", "wrong password"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for attempt %d, got %d", i+1, rr.Code)
+		}
+	}
+	rr := authRequest(s, "login", "alice", "correct horse")
+	assertErrorResponse(t, rr, http.StatusTooManyRequests, "too_many_requests")
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
+
+func TestLoginAccountLockout(t *testing.T) {
+	s := newTestAccountsServer(t)
+	authRequest(s, "register", "alice", "correct horse")
+
+	login := func(ip, name, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"name": name, "password": password})
+		req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(string(body)))
+		req.RemoteAddr = ip + ":1234"
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// IP アドレスを変えながら試しても、ユーザー名ごとに締め出すこと
+	for i := 0; i < lockout.DefaultThreshold; i++ {
+		if rr := login(fmt.Sprintf("198.51.100.%d", i+1), "alice", "wrong password"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for attempt %d, got %d", i+1, rr.Code)
+		}
+	}
+	assertErrorResponse(t, login("198.51.100.99", " Alice ", "correct horse"), http.StatusTooManyRequests, "too_many_requests")
+
+	// ほかのユーザーは締め出さないこと
+	authRequest(s, "register", "bob", "battery staple")
+	if rr := login("198.51.100.99", "bob", "battery staple"); rr.Code != http.StatusOK {
+		t.Errorf("Expected bob to log in, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestLoginLockoutIgnoresInvalidNames(t *testing.T) {
+	s := newTestAccountsServer(t)
+
+	// ユーザー名として使えない名前の失敗は、ユーザー名ごとには数えないこと（IP アドレスごとには数えます）
+	names := []string{"x", "not a name!", "UPPER_CASE?"}
+	for _, name := range names {
+		if rr := authRequest(s, "login", name, "wrong password"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for %q, got %d", name, rr.Code)
+		}
+	}
+	for _, name := range names {
+		if failures := s.loginAccounts.Failures(name); failures != 0 {
+			t.Errorf("Expected no failures recorded for %q, got %d", name, failures)
+		}
+	}
+	if failures := s.loginAttempts.Failures("192.0.2.1"); failures != len(names) {
+		t.Errorf("Expected %d failures for the IP address, got %d", len(names), failures)
+	}
+}
+
+// fakeCaptcha は応答が "solved" のときだけ成功する CaptchaVerifier です
+type fakeCaptcha struct {
+	calls int
+}
+
+func (f *fakeCaptcha) Verify(ctx context.Context, response, remoteIP string) error {
+	f.calls++
+	if response != "solved" {
+		return errors.New("captcha rejected")
+	}
+	return nil
+}
+
+func TestLoginCaptcha(t *testing.T) {
+	users, _ := accounts.NewStore("")
+	users.Iterations = 1000
+	verifier := &fakeCaptcha{}
+	s := NewServer(Deps{
+		Logger:   logging.Discard(),
+		Accounts: users,
+		Config:   Config{CaptchaAfter: 2},
+		Captcha:  verifier,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+	authRequest(s, "register", "alice", "correct horse")
+
+	login := func(password, captcha string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"name": "alice", "password": password, "captcha": captcha})
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(string(body))))
+		return rr
+	}
+
+	// 失敗が CaptchaAfter 回に達するまでは CAPTCHA を求めないこと
+	for i := 0; i < 2; i++ {
+		if rr := login("wrong password", ""); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for attempt %d, got %d", i+1, rr.Code)
+		}
+	}
+	if verifier.calls != 0 {
+		t.Errorf("Expected no CAPTCHA before %d failures, got %d calls", 2, verifier.calls)
+	}
+
+	assertErrorResponse(t, login("correct horse", ""), http.StatusUnauthorized, "captcha_required")
+	assertErrorResponse(t, login("correct horse", "wrong"), http.StatusUnauthorized, "captcha_required")
+	if rr := login("correct horse", "solved"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the login to succeed with a solved CAPTCHA, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 成功すると失敗の回数が消え、CAPTCHA を求めなくなること
+	calls := verifier.calls
+	if rr := login("correct horse", ""); rr.Code != http.StatusOK || verifier.calls != calls {
+		t.Errorf("Expected no CAPTCHA after a successful login, got %d (%d calls)", rr.Code, verifier.calls-calls)
+	}
+}
+
+func TestLoginLockoutBehindProxy(t *testing.T) {
+	users, _ := accounts.NewStore("")
+	users.Iterations = 1000
+	proxies, _ := ParseTrustedProxies("10.0.0.1")
+	s := NewServer(Deps{
+		Logger:   logging.Discard(),
+		Accounts: users,
+		Config:   Config{TrustedProxies: proxies},
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+	authRequest(s, "register", "alice", "correct horse")
+	authRequest(s, "register", "bob", "battery staple")
+
+	login := func(client, name, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"name": name, "password": password})
+		req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(string(body)))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", client)
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// プロキシの後ろでも、締め出すのは失敗したクライアントだけであること
+	for i := 0; i < lockout.DefaultThreshold; i++ {
+		login("203.0.113.5", fmt.Sprintf("nobody%d", i), "wrong password")
+	}
+	assertErrorResponse(t, login("203.0.113.5", "bob", "battery staple"), http.StatusTooManyRequests, "too_many_requests")
+	if rr := login("198.51.100.7", "alice", "correct horse"); rr.Code != http.StatusOK {
+		t.Errorf("Expected another client behind the proxy to log in, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	s := newTestAccountsServer(t)
+	laptop := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+	phone := sessionCookie(t, authRequest(s, "login", "alice", "correct horse"))
+
+	change := func(cookie *http.Cookie, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/password", strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+	assertErrorResponse(t, change(nil, `{"password": "correct horse", "new_password": "battery staple"}`), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, change(laptop, `{"password": "wrong password", "new_password": "battery staple"}`), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, change(laptop, `{"password": "correct horse", "new_password": "correct horse"}`), http.StatusBadRequest, "password_reused")
+	assertErrorResponse(t, change(laptop, `{"password": "correct horse", "new_password": "short"}`), http.StatusBadRequest, "invalid")
+
+	rr := change(laptop, `{"password": "correct horse", "new_password": "battery staple"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the password to be changed, got %d %s", rr.Code, rr.Body.String())
+	}
+	current := sessionCookie(t, rr)
+
+	// ほかの端末のセッションは取り除き、変更した端末には新しいセッションを発行します
+	for _, cookie := range []*http.Cookie{laptop, phone} {
+		req := httptest.NewRequest("GET", "/api/auth/me", nil)
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+	}
+	req := httptest.NewRequest("GET", "/api/auth/me", nil)
+	req.AddCookie(current)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the new session to work, got %d", rr.Code)
+	}
+	assertErrorResponse(t, authRequest(s, "login", "alice", "correct horse"), http.StatusUnauthorized, "unauthorized")
+	if rr := authRequest(s, "login", "alice", "battery staple"); rr.Code != http.StatusOK {
+		t.Errorf("Expected to log in with the new password, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRegisterBreachedPassword(t *testing.T) {
+	s := newTestAccountsServer(t)
+	s.accounts.Policy.Breached = breachedPasswords{"password123"}
+	assertErrorResponse(t, authRequest(s, "register", "alice", "password123"), http.StatusBadRequest, "password_breached")
+	if rr := authRequest(s, "register", "alice", "correct horse"); rr.Code != http.StatusOK {
+		t.Errorf("Expected to register with another password, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+// breachedPasswords は一覧のパスワードだけを漏洩したものとして扱う accounts.BreachChecker です
+type breachedPasswords []string
+
+func (b breachedPasswords) Breached(ctx context.Context, password string) (bool, error) {
+	for _, breached := range b {
+		if password == breached {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestAccountsDisabled(t *testing.T) {
+	s := newTestServer()
+
+	assertErrorResponse(t, authRequest(s, "login", "alice", "correct horse"), http.StatusNotFound, "not_found")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected tasks to be available without accounts, got %d", rr.Code)
+	}
+}
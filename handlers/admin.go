@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/backup"
+	"todo-app/demo"
+	"todo-app/models"
+)
+
+// maxGenerateCount は GenerateHandler で一度に作成できるタスクの上限です
+const maxGenerateCount = 10000
+
+// requireAdmin は Authorization: Bearer <AdminToken> が一致するリクエストだけを next に渡します
+// AdminToken が空の場合は管理用エンドポイントを無効とし、常に 403 を返します
+// 続けてトークンを間違えた IP アドレスは一時的に締め出し（429）、失敗と締め出しは audit: としてログに記録します
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireToken("admin", s.config.AdminToken, next)
+}
+
+// requireToken は Authorization: Bearer <token> が一致するリクエストだけを next に渡します（requireAdmin を参照）
+// realm はログに記録するエンドポイントの種類です。締め出しはすべての種類で共有します
+func (s *Server) requireToken(realm, token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			s.writeError(w, r, errAdminDisabled)
+			return
+		}
+		ip := s.requestIP(r)
+		if retryAfter, ok := s.adminAttempts.Allow(ip); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			s.writeError(w, r, errTooManyAttempts)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			lockedFor, failures := s.adminAttempts.Fail(ip)
+			s.audit(r, slog.LevelWarn, realm+" authentication failed", "method", r.Method, "path", r.URL.Path, "failures", failures)
+			if lockedFor > 0 {
+				s.audit(r, slog.LevelWarn, "locked out from "+realm+" endpoints", "locked_for", lockedFor)
+			}
+			s.writeError(w, r, errUnauthorized)
+			return
+		}
+		s.adminAttempts.Succeed(ip)
+		next(w, r)
+	}
+}
+
+// clientIP はリクエストの接続元の IP アドレスを返します
+// X-Forwarded-For は偽装できるため見ません（信頼するプロキシを通したリクエストは requestIP が読み取ります）
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// BackupsHandler はバックアップの一覧取得（GET）と即時作成（POST）を行います
+func (s *Server) BackupsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		names, err := s.backups.List(r.Context())
+		if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: %v", errBackupFailed, err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"backups": names,
+		})
+	case http.MethodPost:
+		name, err := s.backups.Backup(r.Context())
+		if err != nil && name == "" {
+			s.writeError(w, r, fmt.Errorf("%w: %v", errBackupFailed, err))
+			return
+		}
+		response := map[string]interface{}{
+			"success": true,
+			"backup":  name,
+		}
+		// アップロードには成功し、古い世代の削除だけが失敗した場合
+		if err != nil {
+			response["warning"] = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// RestoreBackupHandler は /api/admin/backups/{name}/restore で指定したバックアップから復元します
+func (s *Server) RestoreBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	segments, ok := splitPath(r.URL.Path, "/api/admin/backups/")
+	if !ok || len(segments) != 2 || segments[1] != "restore" {
+		s.writeError(w, r, errPathNotFound)
+		return
+	}
+
+	snapshot, err := s.backups.Restore(r.Context(), segments[0])
+	if errors.Is(err, backup.ErrInvalidName) {
+		s.writeError(w, r, fmt.Errorf("%w: %v", models.ErrValidation, err))
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, fmt.Errorf("%w: %v", errBackupFailed, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"restored":   len(snapshot.Tasks),
+		"created_at": snapshot.CreatedAt,
+	})
+}
+
+// GenerateHandler は /api/admin/generate?count=N でデモ用のタスクを N 件作成します
+// seed を指定すると同じタスクを作り直せます（省略時は現在時刻）
+func (s *Server) GenerateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil || count < 1 || count > maxGenerateCount {
+		s.writeError(w, r, fmt.Errorf("%w: count must be between 1 and %d", models.ErrValidation, maxGenerateCount))
+		return
+	}
+	seed := time.Now().UnixNano()
+	if raw := query.Get("seed"); raw != "" {
+		seed, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: seed must be an integer", models.ErrValidation))
+			return
+		}
+	}
+
+	tasks, err := demo.Generate(r.Context(), s.store, count, rand.New(rand.NewSource(seed)), time.Now())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"created": len(tasks),
+		"seed":    seed,
+		"tasks":   tasks,
+	})
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/backup"
+	"todo-app/lockout"
+	"todo-app/models"
+)
+
+// newTestBackupServer はバックアップを有効にし、管理用トークンを "secret" にした Server を作成します
+func newTestBackupServer(t *testing.T) *Server {
+	store := models.NewTodoApp()
+	destination := &backup.LocalDir{Dir: t.TempDir()}
+	return NewServer(Deps{
+		Store:   store,
+		Config:  Config{AdminToken: "secret"},
+		Backups: backup.NewManager(store, destination, bytes.Repeat([]byte{1}, 32), 3),
+	})
+}
+
+func adminRequest(method, path string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	return req
+}
+
+func TestRequireAdmin(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	tests := []struct {
+		token     string
+		header    string
+		code      int
+		errorCode string
+	}{
+		{"", "Bearer ", http.StatusForbidden, "forbidden"},
+		{"secret", "", http.StatusUnauthorized, "unauthorized"},
+		{"secret", "Bearer wrong", http.StatusUnauthorized, "unauthorized"},
+		{"secret", "Bearer secret", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		called = false
+		s := NewServer(Deps{Config: Config{AdminToken: tt.token}})
+		req := httptest.NewRequest("GET", "/api/admin/backups", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rr := httptest.NewRecorder()
+		s.requireAdmin(next).ServeHTTP(rr, req)
+
+		if tt.errorCode != "" {
+			assertErrorResponse(t, rr, tt.code, tt.errorCode)
+		} else if rr.Code != tt.code {
+			t.Errorf("token=%q header=%q: expected status code %d, got %d", tt.token, tt.header, tt.code, rr.Code)
+		}
+		if called != (tt.code == http.StatusOK) {
+			t.Errorf("token=%q header=%q: unexpected call to next handler: %v", tt.token, tt.header, called)
+		}
+	}
+}
+
+func TestRequireAdminLockout(t *testing.T) {
+	var logs bytes.Buffer
+	attempts := lockout.New()
+	s := NewServer(Deps{Config: Config{AdminToken: "secret"}, AdminAttempts: attempts, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	next := func(w http.ResponseWriter, r *http.Request) {}
+
+	request := func(remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/admin/shares", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		s.requireAdmin(next).ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 成功すると失敗の回数は数え直しになります
+	for i := 0; i < lockout.DefaultThreshold-1; i++ {
+		request("203.0.113.5:1000", "wrong")
+	}
+	if rr := request("203.0.113.5:1000", "secret"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the correct token to be accepted, got %d", rr.Code)
+	}
+
+	for i := 0; i < lockout.DefaultThreshold; i++ {
+		if rr := request("203.0.113.5:1001", "wrong"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for attempt %d, got %d", i+1, rr.Code)
+		}
+	}
+	rr := request("203.0.113.5:1002", "secret")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected the IP address to be locked out even with the correct token, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if rr := request("198.51.100.7:1000", "secret"); rr.Code != http.StatusOK {
+		t.Errorf("Expected other IP addresses to be allowed, got %d", rr.Code)
+	}
+
+	// 共有した記録はほかのサーバ（ワークスペース）にも効きます
+	other := NewServer(Deps{Config: Config{AdminToken: "secret"}, AdminAttempts: attempts})
+	req := adminRequest("GET", "/api/admin/shares")
+	req.RemoteAddr = "203.0.113.5:1003"
+	rr = httptest.NewRecorder()
+	other.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the shared lockout to apply, got %d", rr.Code)
+	}
+
+	if !strings.Contains(logs.String(), `level=WARN msg="admin authentication failed" audit=true ip=203.0.113.5`) ||
+		!strings.Contains(logs.String(), `msg="locked out from admin endpoints" audit=true ip=203.0.113.5 locked_for=1m0s`) {
+		t.Errorf("Expected audit log entries, got %q", logs.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	if ip := clientIP(req); ip != "2001:db8::1" {
+		t.Errorf("Expected the connection address, got %q", ip)
+	}
+	req.RemoteAddr = "unix"
+	if ip := clientIP(req); ip != "unix" {
+		t.Errorf("Expected the raw address without a port, got %q", ip)
+	}
+}
+
+func TestBackupsHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestBackupServer(t)
+	s.store.AddTask(ctx, "Buy milk")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("POST", "/api/admin/backups"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var created struct {
+		Success bool   `json:"success"`
+		Backup  string `json:"backup"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if !created.Success || created.Backup == "" {
+		t.Fatalf("Unexpected response: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/backups"))
+	var listed struct {
+		Success bool     `json:"success"`
+		Backups []string `json:"backups"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if !listed.Success || len(listed.Backups) != 1 || listed.Backups[0] != created.Backup {
+		t.Errorf("Unexpected response: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/backups"))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/backups", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without a token, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestBackupsHandlerDisabled(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("POST", "/api/admin/backups"))
+	if rr.Code == http.StatusOK {
+		t.Error("Expected admin endpoints to be unavailable without a backup manager")
+	}
+}
+
+func TestRestoreBackupHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestBackupServer(t)
+	s.store.AddTask(ctx, "Buy milk")
+	name, err := s.backups.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	s.store.DeleteTask(ctx, 1)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("POST", "/api/admin/backups/"+name+"/restore"))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response["success"] != true || response["restored"] != float64(1) {
+		t.Errorf("Unexpected response: %v", response)
+	}
+	if tasks := s.store.GetTasks(ctx); len(tasks) != 1 || tasks[0].Title != "Buy milk" {
+		t.Errorf("Expected restored task, got %+v", tasks)
+	}
+}
+
+func TestRestoreBackupHandlerErrors(t *testing.T) {
+	s := newTestBackupServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{"GET", "/api/admin/backups/todo-backup-20250101T000000Z.json.enc/restore", http.StatusMethodNotAllowed},
+		{"POST", "/api/admin/backups/todo-backup-20250101T000000Z.json.enc", http.StatusNotFound},
+		{"POST", "/api/admin/backups/notes.txt/restore", http.StatusBadRequest},
+		{"POST", "/api/admin/backups/todo-backup-20250101T000000Z.json.enc/restore", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, adminRequest(tt.method, tt.path))
+		if rr.Code != tt.code {
+			t.Errorf("%s %s: expected status code %d, got %d", tt.method, tt.path, tt.code, rr.Code)
+		}
+	}
+}
+
+func TestGenerateHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestBackupServer(t)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("POST", "/api/admin/generate?count=25&seed=7"))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success bool          `json:"success"`
+		Created int           `json:"created"`
+		Seed    int64         `json:"seed"`
+		Tasks   []models.Task `json:"tasks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if !response.Success || response.Created != 25 || response.Seed != 7 || len(response.Tasks) != 25 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	if got := len(s.store.GetTasks(ctx)); got != 25 {
+		t.Errorf("Expected 25 tasks in the store, got %d", got)
+	}
+}
+
+func TestGenerateHandlerErrors(t *testing.T) {
+	ctx := context.Background()
+	s := newTestBackupServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{"GET", "/api/admin/generate?count=1", http.StatusMethodNotAllowed},
+		{"POST", "/api/admin/generate", http.StatusBadRequest},
+		{"POST", "/api/admin/generate?count=0", http.StatusBadRequest},
+		{"POST", "/api/admin/generate?count=abc", http.StatusBadRequest},
+		{"POST", "/api/admin/generate?count=10001", http.StatusBadRequest},
+		{"POST", "/api/admin/generate?count=1&seed=x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, adminRequest(tt.method, tt.path))
+		if rr.Code != tt.code {
+			t.Errorf("%s %s: expected status code %d, got %d", tt.method, tt.path, tt.code, rr.Code)
+		}
+	}
+
+	// トークンなしでは作成できません
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/admin/generate?count=1", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without a token, got %d", http.StatusUnauthorized, rr.Code)
+	}
+	if tasks := s.store.GetTasks(ctx); len(tasks) != 0 {
+		t.Errorf("Expected failed requests to create no tasks, got %d", len(tasks))
+	}
+}
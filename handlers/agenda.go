@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-app/agenda"
+	"todo-app/models"
+)
+
+// AgendaHandler は今日の期限切れ・今日が期限・今日の予定のタスクを返します
+// ?tz=Asia/Tokyo のように IANA のタイムゾーン名を指定すると、その地域の「今日」で計算します（省略時はサーバのタイムゾーン）
+func (s *Server) AgendaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	loc, err := parseTimeZone(r.URL.Query().Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"agenda":  agenda.Build(s.store.GetTasks(r.Context()), time.Now(), loc),
+	})
+}
+
+// SuggestedTasksHandler は今取りかかるとよい未完了のタスクを点数の高い順に返します（GET /api/tasks/suggested）
+// 点数は期限の近さ・優先度・作成からの日数・見積もり時間で決めます。?limit=5&tz=Asia/Tokyo で件数（最大50）と「今日」のタイムゾーンを指定できます
+func (s *Server) SuggestedTasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := agenda.ParseLimit(query.Get("limit"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"suggestions": agenda.Suggest(s.store.GetTasks(r.Context()), time.Now(), loc, limit),
+	})
+}
+
+// parseTimeZone は IANA のタイムゾーン名を読み込みます。空ならサーバのタイムゾーンを返します
+func parseTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", models.ErrValidation, name)
+	}
+	return loc, nil
+}
+
+// TodayHandler は今日のタスクの画面（today.html）を返します
+func (s *Server) TodayHandler(w http.ResponseWriter, r *http.Request) {
+	s.static.serve(w, r, "today.html")
+}
+
+// ReviewHandler は1週間の振り返り（完了・持ち越し・新規のタスク）を返します
+// ?week=2025-W07&tz=Asia/Tokyo のように ISO 週とタイムゾーンを指定できます（省略時は今週）
+func (s *Server) ReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	monday, err := agenda.ParseWeek(query.Get("week"), time.Now(), loc)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"review":  agenda.BuildReview(s.store.GetTasks(r.Context()), monday),
+	})
+}
+
+// ReviewPageHandler は印刷できる週の振り返りの画面（review.html）を返します
+func (s *Server) ReviewPageHandler(w http.ResponseWriter, r *http.Request) {
+	s.static.serve(w, r, "review.html")
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/agenda"
+	"todo-app/models"
+)
+
+func TestAgendaHandler(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	today := time.Now().In(loc)
+	yesterday := today.AddDate(0, 0, -1)
+	tasks := []models.Task{
+		{ID: 1, Title: "Overdue", DueDate: &yesterday},
+		{ID: 2, Title: "Due today", DueDate: &today},
+		{ID: 3, Title: "Scheduled", ScheduledDate: &today},
+		{ID: 4, Title: "Someday"},
+	}
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(tasks)})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/agenda?tz=Asia/Tokyo", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success bool          `json:"success"`
+		Agenda  agenda.Agenda `json:"agenda"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	got := response.Agenda
+	if !response.Success || got.Date != today.Format("2006-01-02") || got.TimeZone != "Asia/Tokyo" {
+		t.Errorf("Unexpected agenda header: %s", rr.Body.String())
+	}
+	if len(got.Overdue) != 1 || got.Overdue[0].ID != 1 ||
+		len(got.DueToday) != 1 || got.DueToday[0].ID != 2 ||
+		len(got.Scheduled) != 1 || got.Scheduled[0].ID != 3 {
+		t.Errorf("Unexpected agenda sections: %s", rr.Body.String())
+	}
+}
+
+func TestAgendaHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/agenda?tz=Mars/Olympus", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/agenda", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestSuggestedTasksHandler(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -1)
+	tasks := []models.Task{
+		{ID: 1, Title: "Someday", Priority: models.PriorityLow},
+		{ID: 2, Title: "Overdue", DueDate: &yesterday, Priority: models.PriorityHigh},
+		{ID: 3, Title: "Done", Completed: true, DueDate: &yesterday},
+		{ID: 4, Title: "Normal"},
+	}
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(tasks)})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/suggested?limit=2&tz=UTC", nil))
+	var response struct {
+		Success     bool                `json:"success"`
+		Suggestions []agenda.Suggestion `json:"suggestions"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || rr.Code != http.StatusOK || !response.Success {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if len(response.Suggestions) != 2 || response.Suggestions[0].Task.ID != 2 || response.Suggestions[1].Task.ID != 4 {
+		t.Errorf("Expected the overdue task first, got %s", rr.Body.String())
+	}
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/tasks/suggested?limit=0", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/suggested?tz=Mars/Olympus", http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/tasks/suggested", http.StatusMethodNotAllowed, "method_not_allowed"},
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+}
+
+func TestTodayPage(t *testing.T) {
+	staticDir := t.TempDir()
+	os.WriteFile(filepath.Join(staticDir, "today.html"), []byte("<h1>今日のタスク</h1>"), 0644)
+	s := NewServer(Deps{Config: Config{StaticDir: staticDir}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/today", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "今日のタスク") {
+		t.Errorf("Unexpected today page: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReviewHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	done, _ := s.Store().AddTask(ctx, "Done this week")
+	s.Store().AddTask(ctx, "Created this week")
+	s.Store().ToggleTask(ctx, done.ID)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/review?tz=UTC", nil))
+
+	var response struct {
+		Success bool          `json:"success"`
+		Review  agenda.Review `json:"review"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	year, week := time.Now().UTC().ISOWeek()
+	review := response.Review
+	if !response.Success || review.Week != fmt.Sprintf("%04d-W%02d", year, week) {
+		t.Errorf("Expected the current week, got %s", rr.Body.String())
+	}
+	if len(review.Completed) != 1 || len(review.Created) != 2 || len(review.CarriedOver) != 0 {
+		t.Errorf("Unexpected review sections: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/review?week=2025-W07", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.Review.From != "2025-02-10" || len(response.Review.Created) != 0 {
+		t.Errorf("Unexpected review for 2025-W07: %s", rr.Body.String())
+	}
+}
+
+func TestReviewHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	for _, query := range []string{"week=2025-07", "tz=Mars/Olympus"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/review?"+query, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/review", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestReviewPage(t *testing.T) {
+	staticDir := t.TempDir()
+	os.WriteFile(filepath.Join(staticDir, "review.html"), []byte("<h1>週の振り返り</h1>"), 0644)
+	s := NewServer(Deps{Config: Config{StaticDir: staticDir}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/review", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "週の振り返り") {
+		t.Errorf("Unexpected review page: %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"todo-app/analytics"
+	"todo-app/models"
+)
+
+// CompletionsHandler は期間内の作成数と完了数を集計単位ごとに返します
+// ?range=30d&bucket=day&tz=Asia/Tokyo のように期間（日・週・月）、集計単位（day・week・month）、タイムゾーンを指定できます
+func (s *Server) CompletionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	period, err := analytics.ParseRange(query.Get("range"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	bucket, err := analytics.ParseBucket(query.Get("bucket"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"completions": analytics.CountCompletions(s.store.GetTasks(r.Context()), time.Now(), loc, period, bucket),
+	})
+}
+
+// BurndownHandler は期間内の各日の終わりに残っている未完了のタスク数を返します
+// ?from=2025-03-01&to=2025-03-14&tz=Asia/Tokyo のように期間とタイムゾーンを指定できます（省略時は今日までの2週間）
+// すべてのリストのタスクを対象にします。リストごとのバーンダウンは ListBurndownHandler が返します
+func (s *Server) BurndownHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	s.writeBurndown(w, r, s.store.GetTasks(r.Context()))
+}
+
+// writeBurndown は tasks のバーンダウンを、リクエストの期間とタイムゾーンで書き出します
+func (s *Server) writeBurndown(w http.ResponseWriter, r *http.Request, tasks []models.Task) {
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	now := time.Now()
+	from, to, err := analytics.ParsePeriod(query.Get("from"), query.Get("to"), now, loc)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"burndown": analytics.CountBurndown(tasks, now, from, to),
+	})
+}
+
+// HeatmapHandler は1年の日ごとの完了数を、コントリビューションのグラフのようなヒートマップ向けに返します
+// ?year=2025&tz=Asia/Tokyo のように年とタイムゾーンを指定できます（省略時は今年とサーバのタイムゾーン）
+func (s *Server) HeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	year, err := analytics.ParseYear(query.Get("year"), time.Now(), loc)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"heatmap": analytics.CountHeatmap(s.store.GetTasks(r.Context()), year, loc),
+	})
+}
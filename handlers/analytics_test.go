@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-app/analytics"
+	"todo-app/models"
+)
+
+func TestCompletionsHandler(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	done, _ := store.AddTask(ctx, "Done")
+	store.AddTask(ctx, "Open")
+	store.ToggleTask(ctx, done.ID)
+	s := NewServer(Deps{Store: store})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/completions?range=7d&bucket=day&tz=UTC", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success     bool                  `json:"success"`
+		Completions analytics.Completions `json:"completions"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	got := response.Completions
+	if !response.Success || got.Range != "7d" || got.Bucket != analytics.BucketDay || len(got.Buckets) != 7 {
+		t.Fatalf("Unexpected completions: %s", rr.Body.String())
+	}
+	today := got.Buckets[6]
+	if today.Start != time.Now().UTC().Format("2006-01-02") || today.Created != 2 || today.Completed != 1 {
+		t.Errorf("Expected 2 created and 1 completed today, got %+v", today)
+	}
+}
+
+func TestCompletionsHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	for _, query := range []string{"range=0d", "range=forever", "bucket=year", "tz=Mars/Olympus"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/completions?"+query, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/analytics/completions", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestBurndownHandler(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	done, _ := store.AddTask(ctx, "Done")
+	store.AddTask(ctx, "Open")
+	store.ToggleTask(ctx, done.ID)
+	s := NewServer(Deps{Store: store})
+
+	today := time.Now().UTC()
+	query := "from=" + today.AddDate(0, 0, -1).Format("2006-01-02") + "&to=" + today.AddDate(0, 0, 1).Format("2006-01-02") + "&tz=UTC"
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/burndown?"+query, nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success  bool               `json:"success"`
+		Burndown analytics.Burndown `json:"burndown"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	days := response.Burndown.Days
+	if !response.Success || len(days) != 3 {
+		t.Fatalf("Unexpected burndown: %s", rr.Body.String())
+	}
+	if *days[0].Remaining != 0 || *days[1].Remaining != 1 || days[2].Remaining != nil {
+		t.Errorf("Expected 0, 1 and no count for yesterday, today and tomorrow: %s", rr.Body.String())
+	}
+}
+
+func TestBurndownHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	for _, query := range []string{"from=yesterday", "from=2025-03-10&to=2025-03-01", "tz=Mars/Olympus"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/burndown?"+query, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/analytics/burndown", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestHeatmapHandler(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	done, _ := store.AddTask(ctx, "Done")
+	store.AddTask(ctx, "Open")
+	store.ToggleTask(ctx, done.ID)
+	s := NewServer(Deps{Store: store})
+
+	today := time.Now().UTC()
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/heatmap?tz=UTC", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success bool              `json:"success"`
+		Heatmap analytics.Heatmap `json:"heatmap"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	heatmap := response.Heatmap
+	if !response.Success || heatmap.Year != today.Year() || heatmap.Total != 1 {
+		t.Fatalf("Unexpected heatmap: %s", rr.Body.String())
+	}
+	if day := heatmap.Days[today.YearDay()-1]; day.Date != today.Format("2006-01-02") || day.Count != 1 || day.Level != 4 {
+		t.Errorf("Expected today's completion to be counted, got %+v", day)
+	}
+}
+
+func TestHeatmapHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	for _, query := range []string{"year=25", "tz=Mars/Olympus"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics/heatmap?"+query, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/analytics/heatmap", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
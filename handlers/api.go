@@ -2,46 +2,388 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
+	"todo-app/markdown"
 	"todo-app/models"
+	todov1 "todo-app/proto/todo/v1"
 )
 
-var todoApp = models.NewTodoApp()
-
-func GetTasksHandler(w http.ResponseWriter, r *http.Request) {
+// GetTasksHandler はタスクの一覧を返します
+// ?q= の検索式か ?index= のトークン、?priority=high の優先度、?list= のリスト、?tag=shopping のタグで絞り込み、?sort=due_date で期限の近い順（期限のないものは最後）に並べます
+// ?limit=50&offset=100 で一部だけを返します。絞り込んだ後の件数は X-Total-Count ヘッダに、続きがあれば次のページの URL を Link ヘッダに入れます
+// Accept: application/x-protobuf なら、todo.proto の ListTasksResponse で返します
+func (s *Server) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	// サブタスクの進み具合は、絞り込む前のすべてのタスクから求めます
+	tasks := s.store.GetTasks(r.Context())
+	models.RollUp(tasks)
+	if q := r.URL.Query().Get("q"); q != "" {
+		query, err := models.ParseQuery(q)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		tasks = query.Filter(tasks)
+	}
+	if index := r.URL.Query().Get("index"); index != "" {
+		tasks = filterByIndex(tasks, strings.Split(index, ","))
+	}
+	if priority := r.URL.Query().Get("priority"); priority != "" {
+		priorities, err := parsePriorities(priority)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		tasks = filterByPriority(tasks, priorities)
+	}
+	if list := r.URL.Query().Get("list"); list != "" {
+		listID, err := parseListFilter(list)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		tasks = filterByList(tasks, listID)
+	}
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		tags, err := parseTagFilter(tag)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		tasks = filterByTag(tasks, tags)
+	}
+	sortKey := r.URL.Query().Get("sort")
+	if s.e2e != nil && strings.TrimPrefix(sortKey, "-") == models.SortByTitle {
+		s.writeError(w, r, fmt.Errorf("%w: tasks cannot be sorted by title when titles are encrypted", models.ErrValidation))
+		return
+	}
+	if err := models.SortTasks(tasks, sortKey); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	offset, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	page, err := models.Paginate(tasks, offset, limit)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	tasks := todoApp.GetTasks()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.Limit > 0 && page.Offset+page.Limit < page.Total {
+		next := *r.URL
+		query := next.Query()
+		query.Set("offset", strconv.Itoa(page.Offset+page.Limit))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	if wantsProto(r) {
+		writeProto(w, todov1.MarshalTasks(page.Tasks))
+		return
+	}
+	json.NewEncoder(w).Encode(page.Tasks)
+}
+
+// parsePage は ?offset= と ?limit= を読み取ります。省略したときは先頭から（offset 0）すべて（limit 0）です
+func parsePage(query url.Values) (offset, limit int, err error) {
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("%w: offset must be a non-negative integer", models.ErrValidation)
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > models.MaxPageLimit {
+			return 0, 0, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrValidation, models.MaxPageLimit)
+		}
+	}
+	return offset, limit, nil
+}
+
+// parseDueDate は期限の日付（YYYY-MM-DD）を読み取ります。空なら nil を返します
+func parseDueDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	due, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: due_date %q is not a date (YYYY-MM-DD)", models.ErrValidation, value)
+	}
+	return &due, nil
 }
 
-// リクエストのJSONからタイトルを受け取り、サーバでタスクを作って返します
-func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
+// リクエストのJSONからタイトル（と説明・期限・優先度・リスト・タグ・繰り返し方）を受け取り、サーバでタスクを作って返します
+func (s *Server) AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.writeError(w, r, errMethodNotAllowed)
 		return
 	}
 
 	var req struct {
-		Title string `json:"title"`
+		Title       string             `json:"title"`
+		Description string             `json:"description"`
+		Index       []string           `json:"index"`
+		DueDate     string             `json:"due_date"`
+		Priority    models.Priority    `json:"priority"`
+		ListID      int                `json:"list_id"`
+		Tags        []string           `json:"tags"`
+		Recurrence  *models.Recurrence `json:"recurrence"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := s.validateEncrypted(req.Title, req.Index); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.validateDescription(req.Description); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	due, err := parseDueDate(req.DueDate)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.checkList(req.ListID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// すべての項目を付けて1回で作成し、作成に反応するルールや Webhook に項目を渡します
+	// タイトルが空などの入力の誤りはモデルが ErrValidation として返し、タスクは作りません
+	update := models.TaskUpdate{DueDate: due, Recurrence: req.Recurrence}
+	if req.Description != "" {
+		update.Description = &req.Description
+	}
+	if req.Priority != "" {
+		update.Priority = &req.Priority
+	}
+	if req.ListID != 0 {
+		update.ListID = &req.ListID
+	}
+	if len(req.Tags) > 0 {
+		update.Tags = &req.Tags
+	}
+	if len(req.Index) > 0 {
+		update.BlindIndex = &req.Index
+	}
+	task, err := s.store.AddTaskWith(r.Context(), req.Title, update)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
+
+// UpdateTaskHandler はタスクの編集できる項目を本文の内容に置き換え、変更後のタスクを返します（PUT /api/tasks/{id}）
+// 本文は POST /api/tasks と同じで、タイトルは必須です。説明・期限・優先度・リスト・タグ・繰り返し方は省略すると外します
+// 完了状態と親タスクは変えません（/toggle か PATCH で変更します）
+func (s *Server) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Title       string             `json:"title"`
+		Description string             `json:"description"`
+		Index       []string           `json:"index"`
+		DueDate     string             `json:"due_date"`
+		Priority    models.Priority    `json:"priority"`
+		ListID      int                `json:"list_id"`
+		Tags        []string           `json:"tags"`
+		Recurrence  *models.Recurrence `json:"recurrence"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := s.validateEncrypted(req.Title, req.Index); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.validateDescription(req.Description); err != nil {
+		s.writeError(w, r, err)
+		return
 	}
+	due, err := parseDueDate(req.DueDate)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.checkList(req.ListID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// タイトルが空・定義されていない優先度・不正なタグ・存在しないIDはモデルがエラーとして返します
+	update := models.TaskUpdate{Title: &req.Title, Description: &req.Description, DueDate: due, ClearDueDate: due == nil, Priority: &req.Priority, ListID: &req.ListID, Tags: &req.Tags,
+		Recurrence: req.Recurrence, ClearRecurrence: req.Recurrence == nil}
+	if err := s.store.UpdateTask(r.Context(), id, update); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(req.Index) > 0 {
+		if err := s.store.SetBlindIndex(r.Context(), id, req.Index); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
 
+// PatchTaskHandler は本文で指定した項目（title・description・completed・due_date・priority・list_id・parent_id・tags・recurrence）だけを変更し、変更後のタスクを返します（PATCH /api/tasks/{id}）
+// description は空文字で説明を、due_date は null で期限を、priority は空文字で優先度を、list_id は 0 でリストを、parent_id は 0 で親タスクを、tags は空の配列でタグを、recurrence は null で繰り返しを外します。本文に項目が1つもなければ 400 を返します
+// 完了状態を変えるときはプラグインのフックを通すため、ほかの項目より先に ToggleTask で変更します
+func (s *Server) PatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Title       *string          `json:"title"`
+		Description *string          `json:"description"`
+		Index       []string         `json:"index"`
+		Completed   *bool            `json:"completed"`
+		DueDate     json.RawMessage  `json:"due_date"`
+		Priority    *models.Priority `json:"priority"`
+		ListID      *int             `json:"list_id"`
+		ParentID    *int             `json:"parent_id"`
+		Tags        *[]string        `json:"tags"`
+		Recurrence  json.RawMessage  `json:"recurrence"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if req.Title == nil && req.Description == nil && req.Completed == nil && req.DueDate == nil && req.Priority == nil && req.ListID == nil && req.ParentID == nil && req.Tags == nil && req.Recurrence == nil {
+		s.writeError(w, r, fmt.Errorf("%w: specify at least one of title, description, completed, due_date, priority, list_id, parent_id, tags or recurrence", models.ErrValidation))
 		return
 	}
 
-	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+	update := models.TaskUpdate{Title: req.Title, Description: req.Description, Priority: req.Priority, ListID: req.ListID, ParentID: req.ParentID, Tags: req.Tags}
+	if req.Title != nil {
+		if err := s.validateEncrypted(*req.Title, req.Index); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	} else if len(req.Index) > 0 {
+		s.writeError(w, r, fmt.Errorf("%w: index is only accepted with a new title", models.ErrValidation))
 		return
 	}
+	if req.Description != nil {
+		if err := s.validateDescription(*req.Description); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if req.DueDate != nil {
+		var value *string
+		if err := json.Unmarshal(req.DueDate, &value); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		if value == nil {
+			update.ClearDueDate = true
+		} else if update.DueDate, err = parseDueDate(*value); err != nil {
+			s.writeError(w, r, err)
+			return
+		} else if update.DueDate == nil {
+			update.ClearDueDate = true
+		}
+	}
+	if req.Recurrence != nil {
+		if err := json.Unmarshal(req.Recurrence, &update.Recurrence); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		update.ClearRecurrence = update.Recurrence == nil
+	}
+	if err := update.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if req.ListID != nil {
+		if err := s.checkList(*req.ListID); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if req.ParentID != nil {
+		if err := models.CheckParent(s.store.GetTasks(r.Context()), id, *req.ParentID); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
 
-	task := todoApp.AddTask(req.Title)
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if req.Completed != nil && *req.Completed != task.Completed {
+		if err := s.store.ToggleTask(r.Context(), id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if req.Title != nil || req.Description != nil || req.DueDate != nil || req.Priority != nil || req.ListID != nil || req.ParentID != nil || req.Tags != nil || req.Recurrence != nil {
+		if err := s.store.UpdateTask(r.Context(), id, update); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if len(req.Index) > 0 {
+		if err := s.store.SetBlindIndex(r.Context(), id, req.Index); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if task, err = s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -50,49 +392,96 @@ func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetTaskHandler は1件のタスクを返します（GET /api/tasks/{id}）
+// ?render=html を付けると、説明（Markdown）を HTML にした description_html も返します。HTML は書かれた HTML をエスケープし、安全なリンクだけを残したものです
+// 暗号化するモードでは説明が暗号文のため、description_html は付けません（復号と表示はクライアントが行います）
+// Accept: application/x-protobuf なら、todo.proto の Task で返します（description_html は付けません）
+func (s *Server) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	render := r.URL.Query().Get("render")
+	if render != "" && render != "html" {
+		s.writeError(w, r, fmt.Errorf("%w: render must be html", models.ErrValidation))
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tasks := s.store.GetTasks(r.Context())
+	models.RollUp(tasks)
+	if progress := progressOf(tasks, id); progress.Total > 0 {
+		task.Progress = &progress
+	}
+	if wantsProto(r) {
+		writeProto(w, todov1.MarshalTask(task))
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"task":    task,
+	}
+	if render == "html" && s.e2e == nil {
+		response["description_html"] = markdown.Render(task.Description)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // URL からIDを取り出し、そのタスクの完了状態を反転します
-func ToggleTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) ToggleTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.writeError(w, r, errMethodNotAllowed)
 		return
 	}
 
-	idStr := r.URL.Path[len("/api/tasks/"):]
-	idStr = idStr[:len(idStr)-len("/toggle")]
-
-	id, err := strconv.Atoi(idStr)
+	id, err := parseID(r.URL.Path, "/api/tasks/", "toggle")
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	success := todoApp.ToggleTask(id)
+	if err := s.store.ToggleTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{
-		"success": success,
+		"success": true,
 	})
 }
 
 // URL からIDを取り出し、そのタスクを削除します
-func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.writeError(w, r, errMethodNotAllowed)
 		return
 	}
 
-	idStr := r.URL.Path[len("/api/tasks/"):]
-
-	id, err := strconv.Atoi(idStr)
+	id, err := parseID(r.URL.Path, "/api/tasks/", "")
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	success := todoApp.DeleteTask(id)
+	if err := s.store.DeleteTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{
-		"success": success,
+		"success": true,
 	})
 }
@@ -2,20 +2,24 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"todo-app/models"
+	"todo-app/store/storetest"
 )
 
-func setupTestApp() {
-	todoApp = models.NewTodoApp()
+// newTestServer はテストごとに独立したタスク一覧を持つ Server を作成します
+func newTestServer() *Server {
+	return NewServer(Deps{Store: models.NewTodoApp()})
 }
 
 func TestGetTasksHandler(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("GET", "/api/tasks", nil)
 	if err != nil {
@@ -23,7 +27,7 @@ func TestGetTasksHandler(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(GetTasksHandler)
+	handler := http.HandlerFunc(s.GetTasksHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
@@ -47,10 +51,11 @@ func TestGetTasksHandler(t *testing.T) {
 }
 
 func TestGetTasksHandlerWithTasks(t *testing.T) {
-	setupTestApp()
+	ctx := context.Background()
+	s := newTestServer()
 	
-	todoApp.AddTask("Task 1")
-	todoApp.AddTask("Task 2")
+	s.store.AddTask(ctx, "Task 1")
+	s.store.AddTask(ctx, "Task 2")
 	
 	req, err := http.NewRequest("GET", "/api/tasks", nil)
 	if err != nil {
@@ -58,7 +63,7 @@ func TestGetTasksHandlerWithTasks(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(GetTasksHandler)
+	handler := http.HandlerFunc(s.GetTasksHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
@@ -81,7 +86,7 @@ func TestGetTasksHandlerWithTasks(t *testing.T) {
 }
 
 func TestGetTasksHandlerInvalidMethod(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("POST", "/api/tasks", nil)
 	if err != nil {
@@ -89,7 +94,7 @@ func TestGetTasksHandlerInvalidMethod(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(GetTasksHandler)
+	handler := http.HandlerFunc(s.GetTasksHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusMethodNotAllowed {
@@ -98,7 +103,7 @@ func TestGetTasksHandlerInvalidMethod(t *testing.T) {
 }
 
 func TestAddTaskHandler(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	requestBody := map[string]string{
 		"title": "New Task",
@@ -112,7 +117,7 @@ func TestAddTaskHandler(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(AddTaskHandler)
+	handler := http.HandlerFunc(s.AddTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
@@ -150,7 +155,7 @@ func TestAddTaskHandler(t *testing.T) {
 }
 
 func TestAddTaskHandlerInvalidMethod(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("GET", "/api/tasks", nil)
 	if err != nil {
@@ -158,7 +163,7 @@ func TestAddTaskHandlerInvalidMethod(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(AddTaskHandler)
+	handler := http.HandlerFunc(s.AddTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusMethodNotAllowed {
@@ -167,7 +172,7 @@ func TestAddTaskHandlerInvalidMethod(t *testing.T) {
 }
 
 func TestAddTaskHandlerInvalidJSON(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("POST", "/api/tasks", strings.NewReader("invalid json"))
 	if err != nil {
@@ -176,7 +181,7 @@ func TestAddTaskHandlerInvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(AddTaskHandler)
+	handler := http.HandlerFunc(s.AddTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusBadRequest {
@@ -185,7 +190,7 @@ func TestAddTaskHandlerInvalidJSON(t *testing.T) {
 }
 
 func TestAddTaskHandlerEmptyTitle(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	requestBody := map[string]string{
 		"title": "",
@@ -199,7 +204,7 @@ func TestAddTaskHandlerEmptyTitle(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(AddTaskHandler)
+	handler := http.HandlerFunc(s.AddTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusBadRequest {
@@ -208,9 +213,10 @@ func TestAddTaskHandlerEmptyTitle(t *testing.T) {
 }
 
 func TestToggleTaskHandler(t *testing.T) {
-	setupTestApp()
+	ctx := context.Background()
+	s := newTestServer()
 	
-	task := todoApp.AddTask("Test Task")
+	task, _ := s.store.AddTask(ctx, "Test Task")
 	
 	req, err := http.NewRequest("PUT", "/api/tasks/1/toggle", nil)
 	if err != nil {
@@ -218,7 +224,7 @@ func TestToggleTaskHandler(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(ToggleTaskHandler)
+	handler := http.HandlerFunc(s.ToggleTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
@@ -240,7 +246,7 @@ func TestToggleTaskHandler(t *testing.T) {
 		t.Error("Expected success to be true")
 	}
 	
-	tasks := todoApp.GetTasks()
+	tasks := s.store.GetTasks(ctx)
 	if len(tasks) != 1 {
 		t.Errorf("Expected 1 task, got %d", len(tasks))
 	}
@@ -250,7 +256,7 @@ func TestToggleTaskHandler(t *testing.T) {
 }
 
 func TestToggleTaskHandlerInvalidMethod(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("GET", "/api/tasks/1/toggle", nil)
 	if err != nil {
@@ -258,7 +264,7 @@ func TestToggleTaskHandlerInvalidMethod(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(ToggleTaskHandler)
+	handler := http.HandlerFunc(s.ToggleTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusMethodNotAllowed {
@@ -267,7 +273,7 @@ func TestToggleTaskHandlerInvalidMethod(t *testing.T) {
 }
 
 func TestToggleTaskHandlerInvalidID(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("PUT", "/api/tasks/invalid/toggle", nil)
 	if err != nil {
@@ -275,7 +281,7 @@ func TestToggleTaskHandlerInvalidID(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(ToggleTaskHandler)
+	handler := http.HandlerFunc(s.ToggleTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusBadRequest {
@@ -284,7 +290,7 @@ func TestToggleTaskHandlerInvalidID(t *testing.T) {
 }
 
 func TestToggleTaskHandlerNonExistentTask(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("PUT", "/api/tasks/999/toggle", nil)
 	if err != nil {
@@ -292,28 +298,17 @@ func TestToggleTaskHandlerNonExistentTask(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(ToggleTaskHandler)
+	handler := http.HandlerFunc(s.ToggleTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
-	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, status)
-	}
-	
-	var response map[string]bool
-	err = json.Unmarshal(rr.Body.Bytes(), &response)
-	if err != nil {
-		t.Errorf("Failed to unmarshal response: %v", err)
-	}
-	
-	if success, ok := response["success"]; !ok || success {
-		t.Error("Expected success to be false for non-existent task")
-	}
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
 }
 
 func TestDeleteTaskHandler(t *testing.T) {
-	setupTestApp()
+	ctx := context.Background()
+	s := newTestServer()
 	
-	todoApp.AddTask("Test Task")
+	s.store.AddTask(ctx, "Test Task")
 	
 	req, err := http.NewRequest("DELETE", "/api/tasks/1", nil)
 	if err != nil {
@@ -321,7 +316,7 @@ func TestDeleteTaskHandler(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(DeleteTaskHandler)
+	handler := http.HandlerFunc(s.DeleteTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
@@ -343,14 +338,14 @@ func TestDeleteTaskHandler(t *testing.T) {
 		t.Error("Expected success to be true")
 	}
 	
-	tasks := todoApp.GetTasks()
+	tasks := s.store.GetTasks(ctx)
 	if len(tasks) != 0 {
 		t.Errorf("Expected 0 tasks after deletion, got %d", len(tasks))
 	}
 }
 
 func TestDeleteTaskHandlerInvalidMethod(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("GET", "/api/tasks/1", nil)
 	if err != nil {
@@ -358,7 +353,7 @@ func TestDeleteTaskHandlerInvalidMethod(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(DeleteTaskHandler)
+	handler := http.HandlerFunc(s.DeleteTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusMethodNotAllowed {
@@ -367,7 +362,7 @@ func TestDeleteTaskHandlerInvalidMethod(t *testing.T) {
 }
 
 func TestDeleteTaskHandlerInvalidID(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("DELETE", "/api/tasks/invalid", nil)
 	if err != nil {
@@ -375,7 +370,7 @@ func TestDeleteTaskHandlerInvalidID(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(DeleteTaskHandler)
+	handler := http.HandlerFunc(s.DeleteTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusBadRequest {
@@ -384,7 +379,7 @@ func TestDeleteTaskHandlerInvalidID(t *testing.T) {
 }
 
 func TestDeleteTaskHandlerNonExistentTask(t *testing.T) {
-	setupTestApp()
+	s := newTestServer()
 	
 	req, err := http.NewRequest("DELETE", "/api/tasks/999", nil)
 	if err != nil {
@@ -392,20 +387,383 @@ func TestDeleteTaskHandlerNonExistentTask(t *testing.T) {
 	}
 	
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(DeleteTaskHandler)
+	handler := http.HandlerFunc(s.DeleteTaskHandler)
 	handler.ServeHTTP(rr, req)
 	
-	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, status)
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestGetTasksHandlerGolden(t *testing.T) {
+	fake := storetest.NewFake(storetest.Fixtures()...)
+	s := NewServer(Deps{Store: fake})
+
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.GetTasksHandler).ServeHTTP(rr, req)
+
+	storetest.Golden(t, "get_tasks.json", rr.Body.Bytes())
+	if calls := fake.Calls(); len(calls) != 1 || calls[0] != "GetTasks()" {
+		t.Errorf("Expected a single GetTasks call, got %v", calls)
 	}
-	
-	var response map[string]bool
-	err = json.Unmarshal(rr.Body.Bytes(), &response)
-	if err != nil {
-		t.Errorf("Failed to unmarshal response: %v", err)
+}
+
+func TestGetTasksHandlerQuery(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	s.Store().AddTask(ctx, "Write report")
+	bug, _ := s.Store().AddTask(ctx, "Fix bug")
+	s.Store().AddTask(ctx, "Fix typo")
+	s.Store().SetPriority(ctx, bug.ID, models.PriorityHigh)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?q="+url.QueryEscape("fix priority>=medium -completed"), nil))
+
+	var tasks []models.Task
+	if err := json.Unmarshal(rr.Body.Bytes(), &tasks); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
-	
-	if success, ok := response["success"]; !ok || success {
-		t.Error("Expected success to be false for non-existent task")
+	if rr.Code != http.StatusOK || len(tasks) != 1 || tasks[0].ID != bug.ID {
+		t.Errorf("Expected only the bug, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?q=list:work", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestAddTaskHandlerDueDate(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Pay rent", "due_date": "2024-05-01"}`)))
+	var response struct {
+		Task models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || response.Task.DueDate == nil || response.Task.DueDate.Format("2006-01-02") != "2024-05-01" {
+		t.Fatalf("Expected the task with its due date, got %d %s", rr.Code, rr.Body.String())
+	}
+	if tasks := s.store.GetTasks(context.Background()); tasks[0].DueDate == nil {
+		t.Error("Expected the due date to be saved")
+	}
+
+	for _, body := range []string{`{"title": "Bad", "due_date": "tomorrow"}`, `{"title": "Bad", "due_date": "2024-13-01"}`} {
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+	if tasks := s.store.GetTasks(context.Background()); len(tasks) != 1 {
+		t.Errorf("Expected no task to be added with an invalid due date, got %d tasks", len(tasks))
+	}
+}
+
+func TestAddTaskHandlerCreatesOnce(t *testing.T) {
+	s := newTestServer()
+	recorder := storetest.Record(t, s.store)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Pay rent", "description": "Bank", "due_date": "2024-05-01", "priority": "high", "tags": ["home"], "recurrence": {"frequency": "monthly"}}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the task to be added, got %d %s", rr.Code, rr.Body.String())
+	}
+	// 作成に反応するルールや Webhook が指定した項目を見られるよう、項目を付けたタスクで1回だけ作成すること
+	recorder.AssertEvents(t, storetest.Created(1))
+	if task := recorder.Events()[0].Task; task.DueDate == nil || task.Priority != "high" || task.Description != "Bank" || len(task.Tags) != 1 || task.Recurrence == nil {
+		t.Errorf("Expected the created event to carry every field, got %+v", task)
+	}
+
+	// 途中の項目が誤っていれば、タスクを作らないこと
+	recorder.Reset()
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Bad", "due_date": "2024-05-01", "recurrence": {"frequency": "hourly"}}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	recorder.AssertEvents(t)
+	if tasks := s.store.GetTasks(context.Background()); len(tasks) != 1 {
+		t.Errorf("Expected no half-built task to be left, got %d tasks", len(tasks))
+	}
+}
+
+func TestGetTasksHandlerSortByDueDate(t *testing.T) {
+	s := newTestServer()
+	for _, body := range []string{
+		`{"title": "No due date"}`,
+		`{"title": "Later", "due_date": "2024-06-01"}`,
+		`{"title": "Sooner", "due_date": "2024-05-01"}`,
+		`{"title": "Also later", "due_date": "2024-06-01"}`,
+	} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)))
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?sort=due_date", nil))
+	var tasks []models.Task
+	json.Unmarshal(rr.Body.Bytes(), &tasks)
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Sooner,Later,Also later,No due date" {
+		t.Errorf("Expected tasks by due date with undated tasks last, got %s", got)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?sort=priority", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestGetTasksHandlerPagination(t *testing.T) {
+	s := newTestServer()
+	for _, title := range []string{"delta", "Alpha", "charlie", "Bravo", "echo"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "`+title+`"}`)))
+	}
+
+	titles := func(rr *httptest.ResponseRecorder) string {
+		var tasks []models.Task
+		if err := json.Unmarshal(rr.Body.Bytes(), &tasks); err != nil {
+			t.Fatalf("Failed to unmarshal tasks %q: %v", rr.Body.String(), err)
+		}
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?sort=title&limit=2&offset=1", nil))
+	if got := titles(rr); got != "Bravo,charlie" {
+		t.Errorf("Expected the second page of tasks by title, got %s", got)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("Expected X-Total-Count 5, got %q", got)
+	}
+	if got := rr.Header().Get("Link"); got != `</api/tasks?limit=2&offset=3&sort=title>; rel="next"` {
+		t.Errorf("Expected a link to the next page, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?sort=-title&limit=2&offset=4", nil))
+	if got := titles(rr); got != "Alpha" {
+		t.Errorf("Expected the last page of tasks by title in reverse, got %s", got)
+	}
+	if got := rr.Header().Get("Link"); got != "" {
+		t.Errorf("Expected no link on the last page, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?q=o&offset=10", nil))
+	if got := titles(rr); got != "" || rr.Header().Get("X-Total-Count") != "2" {
+		t.Errorf("Expected an empty page past the end with the filtered total, got %q (total %s)", got, rr.Header().Get("X-Total-Count"))
+	}
+
+	for _, query := range []string{"limit=0", "limit=501", "limit=ten", "offset=-1"} {
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?"+query, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+}
+
+func TestUpdateTaskHandler(t *testing.T) {
+	s := newTestServer()
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Draft", "due_date": "2024-05-01", "priority": "low"}`)))
+	s.Store().ToggleTask(context.Background(), 1)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/tasks/1", strings.NewReader(`{"title": "Final", "priority": "high"}`)))
+	var response struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success {
+		t.Fatalf("Expected the task to be updated, got %d %s", rr.Code, rr.Body.String())
+	}
+	if task := response.Task; task.Title != "Final" || task.Priority != models.PriorityHigh || task.DueDate != nil || !task.Completed {
+		t.Errorf("Expected the title and priority to be replaced, the due date cleared and the completion kept, got %+v", task)
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"PUT", "/api/tasks/1", `{"title": ""}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1", `{"priority": "high"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1", `{"title": "Bad", "due_date": "2024-02-30"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1", `{"title": "Bad", "completed": true}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/99", `{"title": "Missing"}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/tasks/abc", `{"title": "Bad ID"}`, http.StatusBadRequest, "invalid"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+	if tasks := s.Store().GetTasks(context.Background()); tasks[0].Title != "Final" {
+		t.Errorf("Expected failed updates not to change the task, got %+v", tasks[0])
+	}
+}
+
+func TestPatchTaskHandler(t *testing.T) {
+	s := newTestServer()
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Draft", "due_date": "2024-05-01", "priority": "low"}`)))
+
+	patch := func(body string) (*httptest.ResponseRecorder, models.Task) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("PATCH", "/api/tasks/1", strings.NewReader(body)))
+		var response struct {
+			Success bool        `json:"success"`
+			Task    models.Task `json:"task"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response.Task
+	}
+
+	// 指定した項目だけが変わります
+	rr, task := patch(`{"title": "Final"}`)
+	if rr.Code != http.StatusOK || task.Title != "Final" || task.Priority != models.PriorityLow || task.DueDate == nil || task.Completed {
+		t.Fatalf("Expected only the title to change, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr, task = patch(`{"completed": true, "priority": "high", "due_date": null}`)
+	if rr.Code != http.StatusOK || !task.Completed || task.CompletedAt == nil || task.Priority != models.PriorityHigh || task.DueDate != nil || task.Title != "Final" {
+		t.Fatalf("Expected the completion, priority and due date to change, got %d %s", rr.Code, rr.Body.String())
+	}
+	// 同じ完了状態を送っても反転しません
+	if rr, task = patch(`{"completed": true, "due_date": "2024-06-01"}`); !task.Completed || task.DueDate == nil || task.DueDate.Format("2006-01-02") != "2024-06-01" {
+		t.Errorf("Expected the task to stay completed with the new due date, got %d %s", rr.Code, rr.Body.String())
+	}
+	if _, task = patch(`{"completed": false, "priority": ""}`); task.Completed || task.Priority != "" {
+		t.Errorf("Expected the task to be reopened without a priority, got %+v", task)
+	}
+
+	testCases := []struct {
+		path, body string
+		status     int
+		code       string
+	}{
+		{"/api/tasks/1", `{}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", ``, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"title": ""}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"priority": "urgent"}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"due_date": "tomorrow"}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"due_date": "2024-02-30"}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"completed": "yes"}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/1", `{"id": 2}`, http.StatusBadRequest, "invalid"},
+		{"/api/tasks/99", `{"title": "Missing"}`, http.StatusNotFound, "not_found"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("PATCH", tc.path, strings.NewReader(tc.body)))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+	if tasks := s.Store().GetTasks(context.Background()); tasks[0].Title != "Final" || tasks[0].Completed {
+		t.Errorf("Expected invalid patches not to change the task, got %+v", tasks[0])
+	}
+}
+
+func TestTaskRecurrence(t *testing.T) {
+	s := newTestServer()
+
+	rr := listRequest(s, "POST", "/api/tasks", `{"title": "Water plants", "due_date": "2024-05-01", "recurrence": {"frequency": "weekly", "interval": 2}}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"recurrence":{"frequency":"weekly","interval":2}`) {
+		t.Fatalf("Expected the task to be added with a recurrence, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = listRequest(s, "PATCH", "/api/tasks/1", `{"recurrence": {"frequency": "monthly"}}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"recurrence":{"frequency":"monthly"}`) {
+		t.Errorf("Expected the recurrence to change, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = listRequest(s, "PATCH", "/api/tasks/1", `{"recurrence": null}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"recurrence"`) {
+		t.Errorf("Expected null to remove the recurrence, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "PATCH", "/api/tasks/1", `{"recurrence": {"frequency": "daily"}}`)
+	// PUT は省略した繰り返しを外します
+	rr = listRequest(s, "PUT", "/api/tasks/1", `{"title": "Water plants"}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"recurrence"`) {
+		t.Errorf("Expected PUT without a recurrence to remove it, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"title": "Bad", "recurrence": {"frequency": "yearly"}}`,
+		`{"title": "Bad", "recurrence": {"frequency": "daily", "interval": 100}}`,
+		`{"title": "Bad", "recurrence": {"interval": 2}}`,
+	} {
+		assertErrorResponse(t, listRequest(s, "POST", "/api/tasks", body), http.StatusBadRequest, "invalid")
+	}
+	assertErrorResponse(t, listRequest(s, "PATCH", "/api/tasks/1", `{"recurrence": {"frequency": "hourly"}}`), http.StatusBadRequest, "invalid")
+	if tasks := s.Store().GetTasks(context.Background()); len(tasks) != 1 || tasks[0].Recurrence != nil {
+		t.Errorf("Expected invalid recurrences to be rejected, got %+v", tasks)
+	}
+}
+
+func TestTaskDescription(t *testing.T) {
+	s := newTestServer()
+	rr := listRequest(s, "POST", "/api/tasks", `{"title": "Move house", "description": "- [ ] pack **books**\n\n<script>alert(1)</script>"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"description":"- [ ] pack **books**`) {
+		t.Fatalf("Expected the description to be stored, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 何も付けなければ説明は Markdown のまま返し、?render=html で HTML も返します
+	var got struct {
+		Success         bool        `json:"success"`
+		Task            models.Task `json:"task"`
+		DescriptionHTML *string     `json:"description_html"`
+	}
+	rr = listRequest(s, "GET", "/api/tasks/1", ``)
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	if rr.Code != http.StatusOK || got.Task.Title != "Move house" || got.DescriptionHTML != nil {
+		t.Errorf("Expected the raw task only, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = listRequest(s, "GET", "/api/tasks/1?render=html", ``)
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	want := "<ul>\n<li><input type=\"checkbox\" disabled> pack <strong>books</strong></li>\n</ul>\n<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"
+	if got.DescriptionHTML == nil || *got.DescriptionHTML != want || !strings.HasPrefix(got.Task.Description, "- [ ]") {
+		t.Errorf("Expected both the raw and the sanitized description, got %s", rr.Body.String())
+	}
+
+	// PATCH は説明だけを変え、空文字で外します。PUT は省略すると外します
+	rr = listRequest(s, "PATCH", "/api/tasks/1", `{"description": "Call the movers"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"description":"Call the movers"`) {
+		t.Errorf("Expected PATCH to change the description, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = listRequest(s, "PUT", "/api/tasks/1", `{"title": "Move house"}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"description"`) {
+		t.Errorf("Expected PUT to remove the omitted description, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", "/api/tasks", `{"title": "Long", "description": "` + strings.Repeat("a", models.MaxDescriptionLength+1) + `"}`, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks/1", `{"description": 3}`, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/1?render=pdf", ``, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/9", ``, http.StatusNotFound, "not_found"},
+	}
+	for _, tc := range testCases {
+		assertErrorResponse(t, listRequest(s, tc.method, tc.path, tc.body), tc.status, tc.code)
+	}
+
+	// 暗号化するモードでは暗号文の説明だけを受け付け、サーバは HTML にしません
+	e2eServer := newE2EServer(t)
+	rr = httptest.NewRecorder()
+	e2eServer.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "`+ciphertextTitle(28)+`", "description": "plain text"}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	description := ciphertextTitle(32)
+	rr = httptest.NewRecorder()
+	e2eServer.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "`+ciphertextTitle(28)+`", "description": "`+description+`"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected an encrypted description to be accepted, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	e2eServer.ServeHTTP(rr, httptest.NewRequest("PATCH", "/api/tasks/1", strings.NewReader(`{"description": "plain text"}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	rr = httptest.NewRecorder()
+	e2eServer.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/1?render=html", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), description) || strings.Contains(rr.Body.String(), "description_html") {
+		t.Errorf("Expected the encrypted description without description_html, got %d %s", rr.Code, rr.Body.String())
 	}
 }
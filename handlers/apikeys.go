@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// API キーのエラーです
+// errAPIKeyNotFound: API キーが見つからないこと（ほかのユーザーのキーを含みます）
+// errQuotaExceeded: API キーがその日かその月のリクエスト数の上限に達したこと
+var (
+	errAPIKeyNotFound = errors.New("API key not found")
+	errQuotaExceeded  = errors.New("API key quota exceeded")
+)
+
+// APIKeysHandler はログインしているユーザーの API キーの一覧（GET）と作成（POST {"name": "..."}）を行います
+// 作成したときだけ応答の token にキーそのものを入れます。あとから確認する方法はありません
+func (s *Server) APIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"keys":    s.apiKeys.List(user.ID),
+		})
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		key, token, err := s.apiKeys.Create(user.ID, req.Name)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.audit(r, slog.LevelInfo, "created API key", "user_id", user.ID, "key_id", key.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"key":     key,
+			"token":   token,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// APIKeyUsageHandler は URL の ID の API キーのその日とその月のリクエスト数と上限を返します（GET /api/keys/{id}/usage）
+func (s *Server) APIKeyUsageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/keys/", "usage")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	usage, ok := s.apiKeys.Usage(user.ID, id)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errAPIKeyNotFound, id))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"usage":   usage,
+	})
+}
+
+// RevokeAPIKeyHandler は URL の ID の API キーを取り消します（DELETE）。取り消したキーはすぐに使えなくなります
+func (s *Server) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/keys/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	revoked, err := s.apiKeys.Revoke(user.ID, id)
+	if !revoked {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errAPIKeyNotFound, id))
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.audit(r, slog.LevelInfo, "revoked API key", "user_id", user.ID, "key_id", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+)
+
+func TestAPIKeysHandler(t *testing.T) {
+	s := newTestAccountsServer(t)
+	alice := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+	bob := sessionCookie(t, authRequest(s, "register", "bob", "battery staple"))
+
+	request := func(method, path, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth != nil {
+			auth(req)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+	withCookie := func(cookie *http.Cookie) func(*http.Request) {
+		return func(req *http.Request) { req.AddCookie(cookie) }
+	}
+	withToken := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	rr := request("POST", "/api/keys", `{"name": "cron"}`, withCookie(alice))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Success bool            `json:"success"`
+		Key     accounts.APIKey `json:"key"`
+		Token   string          `json:"token"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if !created.Success || created.Key.Name != "cron" || !strings.HasPrefix(created.Token, accounts.APIKeyPrefix) {
+		t.Fatalf("Unexpected response: %s", rr.Body.String())
+	}
+
+	// API キーはセッションの代わりに使え、そのユーザーのタスクだけを扱います
+	if rr := request("POST", "/api/tasks", `{"title": "From cron"}`, withToken(created.Token)); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the API key to add a task, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := request("GET", "/api/tasks", "", withCookie(alice)); !strings.Contains(rr.Body.String(), "From cron") {
+		t.Errorf("Expected alice to see the task added with her key, got %s", rr.Body.String())
+	}
+	if rr := request("GET", "/api/tasks", "", withCookie(bob)); strings.Contains(rr.Body.String(), "From cron") {
+		t.Errorf("Expected bob not to see alice's task, got %s", rr.Body.String())
+	}
+
+	// 一覧にはキーそのものを含めません
+	rr = request("GET", "/api/keys", "", withToken(created.Token))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), created.Token) || !strings.Contains(rr.Body.String(), `"name":"cron"`) {
+		t.Errorf("Unexpected key list: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := request("GET", "/api/keys", "", withCookie(bob)); !strings.Contains(rr.Body.String(), `"keys":[]`) {
+		t.Errorf("Expected bob to have no keys, got %s", rr.Body.String())
+	}
+
+	assertErrorResponse(t, request("POST", "/api/keys", `{"name": ""}`, withCookie(alice)), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, request("GET", "/api/keys", "", nil), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, request("GET", "/api/tasks", "", withToken("todo_wrong")), http.StatusUnauthorized, "unauthorized")
+	// Authorization ヘッダがあれば、Cookie があっても API キーだけで認証します
+	assertErrorResponse(t, request("GET", "/api/tasks", "", func(req *http.Request) {
+		withCookie(alice)(req)
+		withToken("todo_wrong")(req)
+	}), http.StatusUnauthorized, "unauthorized")
+
+	// ほかのユーザーのキーは取り消せません
+	path := "/api/keys/" + strconv.Itoa(created.Key.ID)
+	assertErrorResponse(t, request("DELETE", path, "", withCookie(bob)), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, request("GET", path, "", withCookie(alice)), http.StatusMethodNotAllowed, "method_not_allowed")
+	if rr := request("DELETE", path, "", withCookie(alice)); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the key to be revoked, got %d %s", rr.Code, rr.Body.String())
+	}
+	assertErrorResponse(t, request("GET", "/api/tasks", "", withToken(created.Token)), http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, request("DELETE", path, "", withCookie(alice)), http.StatusNotFound, "not_found")
+}
+
+func TestAPIKeysDisabled(t *testing.T) {
+	s := newTestServer()
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/keys", nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestAPIKeyQuota(t *testing.T) {
+	s := newTestAccountsServer(t)
+	s.apiKeys.DailyLimit = 2
+	alice := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+	bob := sessionCookie(t, authRequest(s, "register", "bob", "battery staple"))
+	key, token, _ := s.apiKeys.Create(1, "cron")
+
+	request := func(method, path string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		auth(req)
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+	withToken := func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+
+	for i := 0; i < 2; i++ {
+		if rr := request("GET", "/api/tasks", withToken); rr.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to be allowed, got %d", i+1, rr.Code)
+		}
+	}
+	rr := request("GET", "/api/tasks", withToken)
+	assertErrorResponse(t, rr, http.StatusTooManyRequests, "too_many_requests")
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	// セッションのリクエストは数えません
+	if rr := request("GET", "/api/tasks", func(req *http.Request) { req.AddCookie(alice) }); rr.Code != http.StatusOK {
+		t.Errorf("Expected the session to be allowed, got %d", rr.Code)
+	}
+
+	path := "/api/keys/" + strconv.Itoa(key.ID) + "/usage"
+	rr = request("GET", path, func(req *http.Request) { req.AddCookie(alice) })
+	var got struct {
+		Success bool              `json:"success"`
+		Usage   accounts.KeyUsage `json:"usage"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	if rr.Code != http.StatusOK || !got.Success || got.Usage.DailyRequests != 2 || got.Usage.DailyLimit != 2 || got.Usage.MonthlyLimit != 0 {
+		t.Errorf("Unexpected usage: %d %s", rr.Code, rr.Body.String())
+	}
+	assertErrorResponse(t, request("GET", path, func(req *http.Request) { req.AddCookie(bob) }), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, request("POST", path, func(req *http.Request) { req.AddCookie(alice) }), http.StatusMethodNotAllowed, "method_not_allowed")
+}
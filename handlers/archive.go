@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"todo-app/agenda"
+)
+
+// archivePage は archive.html に渡す値です
+// Encrypted: タイトルを暗号化しているか。暗号化しているときは件数だけを表示します
+type archivePage struct {
+	Archive   agenda.Archive
+	Encrypted bool
+	query     url.Values
+	loc       *time.Location
+}
+
+// PrevURL は新しい完了のページ（前のページ）へのリンクです。1ページ目なら空です
+func (p archivePage) PrevURL() string {
+	if p.Archive.Page <= 1 {
+		return ""
+	}
+	return p.pageURL(p.Archive.Page - 1)
+}
+
+// NextURL は古い完了のページ（次のページ）へのリンクです。最後のページなら空です
+func (p archivePage) NextURL() string {
+	if p.Archive.Page >= p.Archive.Pages {
+		return ""
+	}
+	return p.pageURL(p.Archive.Page + 1)
+}
+
+// pageURL は page ページ目へのリンクです。ほかのクエリ（per_page や tz）は引き継ぎます
+func (p archivePage) pageURL(page int) string {
+	query := url.Values{}
+	for key, values := range p.query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(page))
+	return "?" + query.Encode()
+}
+
+// Clock は完了した時刻を履歴のタイムゾーンで返します
+func (p archivePage) Clock(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(p.loc).Format("15:04")
+}
+
+// ArchiveHandler は完了したタスクの履歴を、完了した日ごとにまとめて新しい順に返します
+// ?page=2&per_page=50&tz=Asia/Tokyo のようにページとタイムゾーンを指定できます（省略時は1ページ目・50件・サーバのタイムゾーン）
+func (s *Server) ArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	archive, _, ok := s.buildArchive(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"archive": archive,
+	})
+}
+
+// ArchivePageHandler は完了したタスクの履歴を、サーバ側で描画した HTML で返します
+// クエリは ArchiveHandler と同じです
+func (s *Server) ArchivePageHandler(w http.ResponseWriter, r *http.Request) {
+	archive, loc, ok := s.buildArchive(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	query.Del("page")
+	page := archivePage{Archive: archive, Encrypted: s.e2e != nil, query: query, loc: loc}
+	s.renderPage(w, r, "archive.html", page)
+}
+
+// buildArchive はクエリのページとタイムゾーンで Archive を作成します。失敗したらエラーを書き込み、ok が false です
+func (s *Server) buildArchive(w http.ResponseWriter, r *http.Request) (archive agenda.Archive, loc *time.Location, ok bool) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return agenda.Archive{}, nil, false
+	}
+
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return agenda.Archive{}, nil, false
+	}
+	page, perPage, err := agenda.ParsePage(query.Get("page"), query.Get("per_page"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return agenda.Archive{}, nil, false
+	}
+	return agenda.BuildArchive(s.store.GetTasks(r.Context()), loc, page, perPage), loc, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/agenda"
+	"todo-app/e2ee"
+	"todo-app/models"
+)
+
+func archiveTasks() []models.Task {
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2025, 3, day, hour, 30, 0, 0, time.UTC)
+		return &t
+	}
+	return []models.Task{
+		{ID: 1, Title: "Pay <rent>", Completed: true, CompletedAt: at(10, 9)},
+		{ID: 2, Title: "Report", Completed: true, CompletedAt: at(9, 18)},
+		{ID: 3, Title: "Still open"},
+		{ID: 4, Title: "Legacy", Completed: true},
+	}
+}
+
+func TestArchiveHandler(t *testing.T) {
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(archiveTasks())})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/archive?tz=UTC&per_page=2", nil))
+	var response struct {
+		Success bool           `json:"success"`
+		Archive agenda.Archive `json:"archive"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || response.Archive.Total != 3 || response.Archive.Pages != 2 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if days := response.Archive.Days; len(days) != 2 || days[0].Date != "2025-03-10" || days[1].Tasks[0].Title != "Report" {
+		t.Errorf("Expected the two newest completions grouped by day, got %+v", days)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/archive?page=0", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/archive?per_page=1000", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/archive?tz=Mars/Olympus", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/archive", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/archive?page=x", http.StatusBadRequest, "invalid"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+}
+
+func TestArchivePage(t *testing.T) {
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(archiveTasks())})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/archive?tz=Asia/Tokyo&per_page=1&page=2", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Unexpected response %d %s", rr.Code, body)
+	}
+	for _, want := range []string{
+		"完了したタスク 3 件（Asia/Tokyo）",
+		"2025-03-10",
+		`<span class="archive-time">03:30</span>Report`,
+		"2 / 3 ページ",
+		`href="?page=1&amp;per_page=1&amp;tz=Asia%2FTokyo"`,
+		`href="?page=3&amp;per_page=1&amp;tz=Asia%2FTokyo"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q, got %s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/archive?per_page=1&page=3", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "完了日時の記録なし") || !strings.Contains(body, "Legacy") || strings.Contains(body, "古い完了") {
+		t.Errorf("Expected the last page with the legacy task, got %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/archive", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "Pay &lt;rent&gt;") || strings.Contains(body, "Still open") || strings.Contains(body, "新しい完了") {
+		t.Errorf("Expected only completed tasks on the first page, got %s", body)
+	}
+}
+
+func TestArchivePageHidesEncryptedTitles(t *testing.T) {
+	keys, err := e2ee.NewKeyStore(filepath.Join(t.TempDir(), "e2e.json"))
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(archiveTasks()), E2E: keys})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/archive", nil))
+	if body := rr.Body.String(); strings.Contains(body, "Report") || !strings.Contains(body, "1 件") {
+		t.Errorf("Expected only counts to be shown, got %s", body)
+	}
+}
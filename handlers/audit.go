@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// audit はログインや管理用トークンの失敗、API キーの作成などの操作を、監査用のログ（audit=true）として記録します
+// 失敗や締め出しは warn、それ以外は info の level で記録します。どれもクライアントの IP アドレス（ip）を付けます
+func (s *Server) audit(r *http.Request, level slog.Level, msg string, args ...any) {
+	args = append([]any{slog.Bool("audit", true), slog.String("ip", s.requestIP(r))}, args...)
+	s.logger.Log(r.Context(), level, msg, args...)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo-app/board"
+	"todo-app/models"
+)
+
+// BoardHandler はタスクをカンバンのカラムとスイムレーンの格子に並べて返します（GET）
+// ?swimlanes=assignee で担当する人ごと、?swimlanes=priority で優先度ごと、?swimlanes=tag でタグごとの行に分けます（省略時は1行）
+// ?list= を付けると、そのリストのタスクだけをリストのボードに並べます（boardFor）
+func (s *Server) BoardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	lane, err := board.ParseSwimlane(r.URL.Query().Get("swimlanes"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	b, listID, err := s.boardFor(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tasks := s.store.GetTasks(r.Context())
+	if listID != nil {
+		tasks = filterByList(tasks, *listID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"board":   b.Grid(tasks, lane),
+	})
+}
+
+// boardFor はリクエストの ?list= のボードを返します
+// ?list= がなければすべてのタスクのボードと nil を、リストの ID（none はどのリストにも入っていないタスク）ならそのリストのボードと ID を返します
+// リストのボードはカラムとタスクの位置をリストごとに別に持ちます。存在しないリストなら ErrListNotFound を返します
+func (s *Server) boardFor(r *http.Request) (*board.Store, *int, error) {
+	list := r.URL.Query().Get("list")
+	if list == "" {
+		return s.board, nil, nil
+	}
+	listID, err := parseListFilter(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	if listID != 0 {
+		if _, err := s.lists.Get(listID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return s.listBoards.Get(listID), &listID, nil
+}
+
+// BoardColumnsHandler はカラムの一覧（GET）と追加（POST）を扱います
+// POST は {"name": "作業中", "done": false, "position": 1} で、position を省略すると最後に追加します
+// ?list= を付けると、そのリストのボードのカラムを扱います
+func (s *Server) BoardColumnsHandler(w http.ResponseWriter, r *http.Request) {
+	b, _, err := s.boardFor(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"columns": b.Columns(),
+		})
+	case http.MethodPost:
+		var req struct {
+			Name     string `json:"name"`
+			Done     *bool  `json:"done"`
+			Position *int   `json:"position"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		column, err := b.AddColumn(req.Name, board.ColumnUpdate{Done: req.Done, Position: req.Position})
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"column":  column,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// BoardColumnHandler は URL の ID のカラムの変更（PUT）と削除（DELETE）を扱います
+// PUT は送った項目（name・done・position）だけを変えます。position でカラムを並べ替えます
+// ?list= を付けると、そのリストのボードのカラムを扱います
+func (s *Server) BoardColumnHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	b, _, err := s.boardFor(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/board/columns/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if err := b.DeleteColumn(id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{
+			"success": true,
+		})
+		return
+	}
+
+	var req board.ColumnUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	column, err := b.UpdateColumn(id, req)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"column":  column,
+	})
+}
+
+// BoardTaskHandler はドラッグ＆ドロップで動かしたタスクの位置を {"column_id": 3, "position": 0} で保存します（PUT）
+// 完了のカラムに入れたタスクは完了にし、完了のカラムから出したタスクは未完了に戻します
+// position はカラムの中の位置（0 が先頭）で、省略すると最後に置きます
+// ?list= を付けると、そのリストのボードに置きます（リストに入っていないタスクは置けません）
+func (s *Server) BoardTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	b, listID, err := s.boardFor(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/board/tasks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		ColumnID int  `json:"column_id"`
+		Position *int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if listID != nil && task.ListID != *listID {
+		s.writeError(w, r, fmt.Errorf("%w: task %d is not in list %d", models.ErrValidation, id, *listID))
+		return
+	}
+	column, err := b.Column(req.ColumnID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// 完了状態を先に変え、プラグインが拒否したときは位置も変えません
+	if column.Done != task.Completed {
+		if err := s.store.ToggleTask(r.Context(), id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+	if err := b.Place(id, column.ID, position); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err = s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/board"
+	"todo-app/models"
+)
+
+func TestBoardHandler(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	s.store.AddTask(ctx, "Write report")
+	review, _ := s.store.AddTask(ctx, "Review PR")
+	s.store.ClaimTask(ctx, review.ID, "alice")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/board?swimlanes=assignee", nil))
+	var response struct {
+		Success bool        `json:"success"`
+		Board   board.Board `json:"board"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || len(response.Board.Columns) != 2 || len(response.Board.Swimlanes) != 2 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if lane := response.Board.Swimlanes[0]; lane.Key != "alice" || len(lane.Cells[0].Tasks) != 1 || lane.Cells[0].Tasks[0].ID != review.ID {
+		t.Errorf("Expected alice's lane to hold the claimed task, got %+v", lane)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/board?swimlanes=owner", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/board", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestBoardColumnsHandler(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/board/columns", strings.NewReader(`{"name": "作業中", "position": 1}`)))
+	var added struct {
+		Column board.Column `json:"column"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &added)
+	if rr.Code != http.StatusOK || added.Column.ID != 3 || added.Column.Name != "作業中" {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/board/columns/3", strings.NewReader(`{"name": "進行中", "position": 0}`)))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"進行中"`) {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/board/columns", nil))
+	var listed struct {
+		Columns []board.Column `json:"columns"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if len(listed.Columns) != 3 || listed.Columns[0].ID != 3 {
+		t.Errorf("Expected the renamed column first, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/board/columns/3", nil))
+	if rr.Code != http.StatusOK || len(s.board.Columns()) != 2 {
+		t.Errorf("Expected the column to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/board/columns", `{"name": ""}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/board/columns", `{"done": true}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/board/columns", `{"name": "x", "color": "red"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/board/columns/9", `{"name": "x"}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/board/columns/1", `{"position": -1}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/board/columns/9", ``, http.StatusNotFound, "not_found"},
+		{"DELETE", "/api/board/columns/x", ``, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/board/columns/1", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"DELETE", "/api/board/columns", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	s.board.DeleteColumn(2)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/board/columns/1", nil))
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+
+	// 本文を読めなければ 400 を返します
+	rr = httptest.NewRecorder()
+	s.BoardColumnHandler(rr, httptest.NewRequest(http.MethodPut, "/api/board/columns/1", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	rr = httptest.NewRecorder()
+	s.BoardColumnsHandler(rr, httptest.NewRequest(http.MethodPost, "/api/board/columns", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestBoardTaskHandler(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	first, _ := s.store.AddTask(ctx, "First")
+	second, _ := s.store.AddTask(ctx, "Second")
+
+	// 完了のカラムに入れるとタスクを完了にします
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/board/tasks/1", strings.NewReader(`{"column_id": 2}`)))
+	var response struct {
+		Task models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Task.Completed {
+		t.Fatalf("Expected the task to be completed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 未着手のカラムの先頭に戻すと未完了に戻します
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/board/tasks/1", strings.NewReader(`{"column_id": 1, "position": 0}`)))
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || response.Task.Completed {
+		t.Fatalf("Expected the task to be reopened, got %d %s", rr.Code, rr.Body.String())
+	}
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/api/board/tasks/2", strings.NewReader(`{"column_id": 1, "position": 0}`)))
+	grid := s.board.Grid(s.store.GetTasks(ctx), board.SwimlaneNone)
+	if cell := grid.Swimlanes[0].Cells[0].Tasks; len(cell) != 2 || cell[0].ID != second.ID || cell[1].ID != first.ID {
+		t.Errorf("Expected the saved order, got %+v", cell)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"PUT", "/api/board/tasks/9", `{"column_id": 1}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/board/tasks/1", `{"column_id": 9}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/board/tasks/1", `{}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/board/tasks/x", `{"column_id": 1}`, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/board/tasks/1", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.BoardTaskHandler(rr, httptest.NewRequest("PUT", "/api/board/tasks/1", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestBoardHandlerLists(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	work, _ := s.lists.Create("Work")
+	tags := []string{"bug", "ui"}
+	inList, _ := s.store.AddTaskWith(ctx, "Fix button", models.TaskUpdate{ListID: &work.ID, Tags: &tags})
+	s.store.AddTask(ctx, "Buy milk")
+
+	// リストのボードには別のカラムを足せ、すべてのタスクのボードは変わりません
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/board/columns?list=1", strings.NewReader(`{"name": "作業中", "position": 1}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if got := len(s.board.Columns()); got != 2 {
+		t.Errorf("Expected the shared board to keep 2 columns, got %d", got)
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/board/tasks/1?list=1", strings.NewReader(`{"column_id": 3}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/board?list=1&swimlanes=tag", nil))
+	var response struct {
+		Board board.Board `json:"board"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || len(response.Board.Columns) != 3 || len(response.Board.Swimlanes) != 3 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	// 2つのタグが付いたタスクは両方の行に並びます
+	for _, lane := range response.Board.Swimlanes[:2] {
+		if cell := lane.Cells[1].Tasks; len(cell) != 1 || cell[0].ID != inList.ID {
+			t.Errorf("Expected the task in the %q lane, got %+v", lane.Key, lane)
+		}
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/board?list=9", ``, http.StatusNotFound, "not_found"},
+		{"GET", "/api/board?list=x", ``, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/board/tasks/2?list=1", `{"column_id": 1}`, http.StatusBadRequest, "invalid"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	// リストを削除するとボードも忘れます
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/api/lists/1", nil))
+	if got := len(s.listBoards.Get(work.ID).Columns()); got != 2 {
+		t.Errorf("Expected the list board to be discarded, got %d columns", got)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"todo-app/agenda"
+)
+
+// calendarPage は calendar.html に渡す値です
+// Weeks: 月曜日から始まる週ごとの日。前後の月の日は nil です
+// Prev / Next: 前の月と次の月（2025-02 など）
+// TZ: リクエストで指定したタイムゾーン（リンクに引き継ぎます）
+// Encrypted: タイトルを暗号化しているか。暗号化しているときは件数だけを表示します
+type calendarPage struct {
+	Calendar  agenda.Calendar
+	Weeks     [][]*agenda.CalendarDay
+	Prev      string
+	Next      string
+	TZ        string
+	Encrypted bool
+}
+
+// CalendarHandler は1か月の日ごとに、その日が期限のタスクを返します
+// ?month=2025-03&tz=Asia/Tokyo のように年月とタイムゾーンを指定できます（省略時は今月とサーバのタイムゾーン）
+func (s *Server) CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	calendar, ok := s.buildCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"calendar": calendar,
+	})
+}
+
+// CalendarPageHandler は期限の集まり具合がひと目でわかる月のカレンダーを、サーバ側で描画した HTML で返します
+// クエリは CalendarHandler と同じです
+func (s *Server) CalendarPageHandler(w http.ResponseWriter, r *http.Request) {
+	calendar, ok := s.buildCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	first, _ := time.Parse("2006-01", calendar.Month)
+	page := calendarPage{
+		Calendar:  calendar,
+		Weeks:     calendarWeeks(calendar, first.Weekday()),
+		Prev:      first.AddDate(0, -1, 0).Format("2006-01"),
+		Next:      first.AddDate(0, 1, 0).Format("2006-01"),
+		TZ:        r.URL.Query().Get("tz"),
+		Encrypted: s.e2e != nil,
+	}
+	s.renderPage(w, r, "calendar.html", page)
+}
+
+// buildCalendar はクエリの年月とタイムゾーンで Calendar を作成します。失敗したらエラーを書き込み、ok が false です
+func (s *Server) buildCalendar(w http.ResponseWriter, r *http.Request) (calendar agenda.Calendar, ok bool) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return agenda.Calendar{}, false
+	}
+
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return agenda.Calendar{}, false
+	}
+	now := time.Now()
+	first, err := agenda.ParseMonth(query.Get("month"), now, loc)
+	if err != nil {
+		s.writeError(w, r, err)
+		return agenda.Calendar{}, false
+	}
+	return agenda.BuildCalendar(s.store.GetTasks(r.Context()), first, now), true
+}
+
+// calendarWeeks は calendar の日を月曜日から始まる週に分けます。first は月の1日の曜日です
+func calendarWeeks(calendar agenda.Calendar, first time.Weekday) [][]*agenda.CalendarDay {
+	week := make([]*agenda.CalendarDay, (int(first)+6)%7)
+	var weeks [][]*agenda.CalendarDay
+	for i := range calendar.Days {
+		week = append(week, &calendar.Days[i])
+		if len(week) == 7 {
+			weeks = append(weeks, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		weeks = append(weeks, append(week, make([]*agenda.CalendarDay, 7-len(week))...))
+	}
+	return weeks
+}
+
+// calendarDensity は未完了のタスクの数を、カレンダーの色の濃さ（0〜3）にします
+func calendarDensity(open int) int {
+	switch {
+	case open == 0:
+		return 0
+	case open == 1:
+		return 1
+	case open <= 3:
+		return 2
+	}
+	return 3
+}
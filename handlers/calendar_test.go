@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/agenda"
+	"todo-app/e2ee"
+	"todo-app/models"
+)
+
+func calendarTasks() []models.Task {
+	due := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	return []models.Task{
+		{ID: 1, Title: "Pay <rent>", DueDate: &due},
+		{ID: 2, Title: "Report", DueDate: &due, Completed: true},
+		{ID: 3, Title: "Someday"},
+	}
+}
+
+func TestCalendarHandler(t *testing.T) {
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(calendarTasks())})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/calendar?month=2025-03&tz=UTC", nil))
+	var response struct {
+		Success  bool            `json:"success"`
+		Calendar agenda.Calendar `json:"calendar"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || response.Calendar.Month != "2025-03" || len(response.Calendar.Days) != 31 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if day := response.Calendar.Days[9]; len(day.Tasks) != 2 || day.Open != 1 {
+		t.Errorf("Expected two tasks due on March 10, got %+v", day)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/calendar?month=2025-13", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/calendar?tz=Mars/Olympus", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/calendar", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/calendar?month=March", http.StatusBadRequest, "invalid"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+}
+
+func TestCalendarPage(t *testing.T) {
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(calendarTasks())})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar?month=2025-03&tz=Asia/Tokyo", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Unexpected response %d %s", rr.Code, body)
+	}
+	for _, want := range []string{
+		"2025-03（Asia/Tokyo）",
+		`href="?month=2025-02&amp;tz=Asia%2fTokyo"`,
+		`href="?month=2025-04&amp;tz=Asia%2fTokyo"`,
+		"calendar-day density-1",
+		"Pay &lt;rent&gt;",
+		`<li class="completed">Report</li>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q, got %s", want, body)
+		}
+	}
+	// 2025年3月1日は土曜日なので、最初の週は月曜日から金曜日までが空です
+	if got := strings.Count(body, `class="calendar-day outside"`); got != 5+6 {
+		t.Errorf("Expected 11 cells outside the month, got %d", got)
+	}
+}
+
+func TestCalendarPageHidesEncryptedTitles(t *testing.T) {
+	keys, err := e2ee.NewKeyStore(filepath.Join(t.TempDir(), "e2e.json"))
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks(calendarTasks()), E2E: keys})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/calendar?month=2025-03", nil))
+	if body := rr.Body.String(); strings.Contains(body, "Report") || !strings.Contains(body, "1/2") {
+		t.Errorf("Expected only counts to be shown, got %s", body)
+	}
+}
+
+func TestCalendarWeeks(t *testing.T) {
+	first := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	calendar := agenda.BuildCalendar(nil, first, first)
+	weeks := calendarWeeks(calendar, first.Weekday())
+	// 2024年9月1日は日曜日で、30日の月曜日で終わります
+	if len(weeks) != 6 || weeks[0][5] != nil || weeks[0][6].Date != "2024-09-01" || weeks[5][0].Date != "2024-09-30" || weeks[5][1] != nil {
+		t.Errorf("Unexpected weeks %v", weeks)
+	}
+	for open, want := range []int{0, 1, 2, 2, 3} {
+		if got := calendarDensity(open); got != want {
+			t.Errorf("calendarDensity(%d) = %d, want %d", open, got, want)
+		}
+	}
+}
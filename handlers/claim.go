@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ClaimHandler はリクエストのJSON {"claimant": "alice"} でタスクの担当を扱います
+// POST /api/tasks/{id}/claim: claimant の担当にして作業中にします。ほかの人が担当していれば 409 を返します
+// DELETE /api/tasks/{id}/claim: claimant の担当を外します
+// 担当の確認と設定はストアがまとめて行うため、共有のキューから同時に取っても同じタスクを2人が担当することはありません
+func (s *Server) ClaimHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "claim")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Claimant string `json:"claimant"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		err = s.store.ClaimTask(r.Context(), id, req.Claimant)
+	} else {
+		err = s.store.ReleaseTask(r.Context(), id, req.Claimant)
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
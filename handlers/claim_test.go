@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestClaimHandler(t *testing.T) {
+	s := newTestServer()
+	task, _ := s.store.AddTask(context.Background(), "Shared queue item")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/claim", strings.NewReader(`{"claimant": "alice"}`)))
+	var response struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || response.Task.ID != task.ID || response.Task.ClaimedBy != "alice" || response.Task.ClaimedAt == nil {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	// ほかの人は同じタスクを担当できません
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/claim", strings.NewReader(`{"claimant": "bob"}`)))
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+	if !strings.Contains(rr.Body.String(), "Someone else is already working on this task.") {
+		t.Errorf("Expected the already claimed message, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/tasks/1/claim", strings.NewReader(`{"claimant": "alice"}`)))
+	response.Task = models.Task{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || response.Task.ClaimedBy != "" {
+		t.Fatalf("Expected the claim to be released, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/claim", strings.NewReader(`{"claimant": "bob"}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected bob to claim the released task, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestClaimHandlerErrors(t *testing.T) {
+	s := newTestServer()
+	s.store.AddTask(context.Background(), "Shared queue item")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/tasks/1/claim", `{}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/claim", `{"claimant": ""}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/tasks/1/claim", `{"claimant": 5}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/tasks/1/claim", `{"claimant": "alice"}`, http.StatusConflict, "conflict"},
+		{"POST", "/api/tasks/9/claim", `{"claimant": "alice"}`, http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/x/claim", `{"claimant": "alice"}`, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/1/claim", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	// 本文を読めなければ 400 を返します
+	rr := httptest.NewRecorder()
+	s.ClaimHandler(rr, httptest.NewRequest("POST", "/api/tasks/1/claim", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo-app/models"
+)
+
+// CommentsHandler はタスクのコメントを扱います
+// GET /api/tasks/{id}/comments: コメントを書いた順に返します
+// POST /api/tasks/{id}/comments: リクエストのJSON {"author": "alice", "body": "**了解**です"} のコメントを追加し、追加したコメントを返します
+// 本文は Markdown で、詳細画面（/tasks/{id}）では説明と同じく HTML にして表示します
+func (s *Server) CommentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "comments")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		task, err := s.findTask(r.Context(), id)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		comments := task.Comments
+		if comments == nil {
+			comments = []models.Comment{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"comments": comments,
+		})
+		return
+	}
+
+	var req struct {
+		Author string `json:"author"`
+		Body   string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	// 暗号化するモードでは、サーバが読めるコメントを保存しないように、コメントを受け付けません
+	if s.e2e != nil {
+		s.writeError(w, r, fmt.Errorf("%w: comments are not available when end-to-end encryption is enabled", models.ErrValidation))
+		return
+	}
+	comment, err := s.store.AddComment(r.Context(), id, req.Author, req.Body)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"comment": comment,
+	})
+}
+
+// CommentHandler は1件のコメントを削除します（DELETE /api/tasks/{id}/comments/{commentID}）
+func (s *Server) CommentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, commentID, err := parseSubID(r.URL.Path, "/api/tasks/", "comments")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.store.DeleteComment(r.Context(), id, commentID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
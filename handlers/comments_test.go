@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestCommentsHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/tasks", `{"title": "Plan the trip"}`)
+
+	var added struct {
+		Success bool           `json:"success"`
+		Comment models.Comment `json:"comment"`
+	}
+	rr := listRequest(s, "POST", "/api/tasks/1/comments", `{"author": "alice", "body": "Booked **flights**"}`)
+	json.Unmarshal(rr.Body.Bytes(), &added)
+	if rr.Code != http.StatusOK || !added.Success || added.Comment.ID != 1 || added.Comment.Author != "alice" {
+		t.Fatalf("Expected the comment to be added, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/tasks/1/comments", `{"body": "Hotel next"}`)
+
+	var listed struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	rr = listRequest(s, "GET", "/api/tasks/1/comments", ``)
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if len(listed.Comments) != 2 || listed.Comments[1].Body != "Hotel next" {
+		t.Errorf("Expected 2 comments in order, got %s", rr.Body.String())
+	}
+
+	rr = listRequest(s, "DELETE", "/api/tasks/1/comments/1", ``)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the comment to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", "/api/tasks/1/comments", `{"body": "   "}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/comments", `{"body": "` + strings.Repeat("a", models.MaxCommentLength+1) + `"}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/comments", `{"author": "bob"}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/9/comments", `{"body": "Hello"}`, http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/9/comments", ``, http.StatusNotFound, "not_found"},
+		{"DELETE", "/api/tasks/1/comments/1", ``, http.StatusNotFound, "not_found"},
+		{"DELETE", "/api/tasks/1/comments/x", ``, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/comments", `{}`, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/api/tasks/1/comments/2", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := listRequest(s, tc.method, tc.path, tc.body)
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestCommentsRejectedWithE2E(t *testing.T) {
+	s := newE2EServer(t)
+	listRequest(s, "POST", "/api/tasks", `{"title": "`+ciphertextTitle(40)+`"}`)
+
+	rr := listRequest(s, "POST", "/api/tasks/1/comments", `{"body": "plaintext"}`)
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
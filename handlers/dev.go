@@ -0,0 +1,16 @@
+package handlers
+
+import "net/http"
+
+// noCache は開発モードでブラウザにキャッシュさせないよう、レスポンスにヘッダを付けます
+func noCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store, must-revalidate")
+		w.Header().Set("Pragma", "no-cache")
+		w.Header().Set("Expires", "0")
+		// If-Modified-Since などで 304 を返さず、常にディスク上の最新の内容を返します
+		r.Header.Del("If-Modified-Since")
+		r.Header.Del("If-None-Match")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDevModeReloadsFiles(t *testing.T) {
+	staticDir, templateDir := t.TempDir(), t.TempDir()
+	index := filepath.Join(templateDir, "index.html")
+	style := filepath.Join(staticDir, "style.css")
+	os.WriteFile(filepath.Join(templateDir, "layout.html"), []byte(`{{define "layout"}}<h1>{{template "content" .}}</h1>{{end}}`), 0644)
+	os.WriteFile(index, []byte(`{{define "content"}}{{"v1"}}{{end}}`), 0644)
+	os.WriteFile(style, []byte("body {}"), 0644)
+
+	s := NewServer(Deps{Config: Config{StaticDir: staticDir, TemplateDir: templateDir, Dev: true}})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if cacheControl := rr.Header().Get("Cache-Control"); !strings.Contains(cacheControl, "no-store") {
+			t.Errorf("GET %s: expected caching to be disabled, got %q", path, cacheControl)
+		}
+		return rr
+	}
+
+	if body := get("/").Body.String(); body != "<h1>v1</h1>" {
+		t.Errorf("Expected rendered template, got %q", body)
+	}
+	get("/static/style.css")
+
+	// サーバを作り直さなくても、ファイルの変更が次のリクエストに反映されること
+	os.WriteFile(index, []byte(`{{define "content"}}{{"v2"}}{{end}}`), 0644)
+	os.WriteFile(style, []byte("body { color: red; }"), 0644)
+
+	if body := get("/").Body.String(); body != "<h1>v2</h1>" {
+		t.Errorf("Expected reloaded template, got %q", body)
+	}
+
+	req := httptest.NewRequest("GET", "/static/style.css", nil)
+	req.Header.Set("If-Modified-Since", "Mon, 01 Jan 2100 00:00:00 GMT")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "body { color: red; }" {
+		t.Errorf("Expected reloaded static file, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDevModeTemplateError(t *testing.T) {
+	templateDir := t.TempDir()
+	os.WriteFile(filepath.Join(templateDir, "layout.html"), []byte(`{{define "layout"}}{{template "content" .}}{{end}}`), 0644)
+	os.WriteFile(filepath.Join(templateDir, "index.html"), []byte(`{{ broken`), 0644)
+
+	s := NewServer(Deps{Config: Config{TemplateDir: templateDir, Dev: true}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "index.html") {
+		t.Errorf("Expected the template error to be shown, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCachingEnabledOutsideDevMode(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected no Cache-Control header outside dev mode, got %q", rr.Header().Get("Cache-Control"))
+	}
+}
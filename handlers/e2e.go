@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo-app/e2ee"
+	"todo-app/models"
+)
+
+// validateEncrypted は暗号化するモードでは title が暗号文で index がトークンの一覧であることを確認します
+// モードが無効なときはトークンを受け付けません（平文のタイトルと検索用のトークンを混在させないためです）
+func (s *Server) validateEncrypted(title string, index []string) error {
+	if s.e2e == nil {
+		if len(index) > 0 {
+			return fmt.Errorf("%w: index is only accepted when end-to-end encryption is enabled", models.ErrValidation)
+		}
+		return nil
+	}
+	if err := e2ee.ValidateTitle(title); err != nil {
+		return err
+	}
+	return e2ee.ValidateIndex(index)
+}
+
+// validateDescription はタスクの説明 description の長さを確認します
+// 暗号化するモードでは、サーバが読める説明を保存しないように、暗号文の説明だけを受け付けます（長さは暗号文の文字数です）
+func (s *Server) validateDescription(description string) error {
+	if s.e2e != nil {
+		if err := e2ee.ValidateDescription(description); err != nil {
+			return err
+		}
+	}
+	return models.ValidateDescription(description)
+}
+
+// filterByIndex は query のトークンをすべて持つタスクだけを返します
+func filterByIndex(tasks []models.Task, query []string) []models.Task {
+	filtered := []models.Task{}
+	for _, task := range tasks {
+		if e2ee.MatchIndex(task.BlindIndex, query) {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
+
+// E2EHandler は暗号化するモードの状態を返します（GET）
+// 有効なら鍵を導出するための公開の値（未登録なら null）を返し、クライアントはそれを使ってタイトルを暗号化・復号します
+func (s *Server) E2EHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var keys *e2ee.Keys
+	if s.e2e != nil {
+		if k, ok := s.e2e.Get(); ok {
+			keys = &k
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"enabled": s.e2e != nil,
+		"keys":    keys,
+	})
+}
+
+// E2EKeysHandler はクライアントが作った鍵の情報を登録します（PUT）
+// 登録できるのは一度だけで、すでに登録していれば 409 を返します
+func (s *Server) E2EKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req e2ee.Keys
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	keys, err := s.e2e.Set(req)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"keys":    keys,
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-app/e2ee"
+)
+
+// ciphertextTitle は暗号化したタイトルに見える値を返します
+func ciphertextTitle(n int) string {
+	return e2ee.Prefix + base64.RawURLEncoding.EncodeToString(make([]byte, n))
+}
+
+func newE2EServer(t *testing.T) *Server {
+	t.Helper()
+	keys, err := e2ee.NewKeyStore(filepath.Join(t.TempDir(), "e2e.json"))
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	return NewServer(Deps{E2E: keys})
+}
+
+func TestE2EHandlerDisabled(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/e2e", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"enabled":false,"keys":null,"success":true}` {
+		t.Errorf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 無効なときは鍵を登録できず、トークンも受け付けません
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/e2e/keys", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected keys to be unavailable, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Buy milk", "index": ["`+strings.Repeat("a", 32)+`"]}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/e2e", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestE2EDisablesWebDAV(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newE2EServer(t).ServeHTTP(rr, httptest.NewRequest("PROPFIND", "/dav/Tasks/", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected WebDAV to be unavailable in E2E mode, got %d", rr.Code)
+	}
+}
+
+func TestE2EKeys(t *testing.T) {
+	s := newE2EServer(t)
+	body := `{"kdf": "PBKDF2-SHA256", "iterations": 200000, "salt": "` + base64.RawURLEncoding.EncodeToString(make([]byte, 16)) + `", "check": "` + ciphertextTitle(40) + `"}`
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/e2e/keys", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected keys to be registered, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/e2e", nil))
+	var response struct {
+		Enabled bool       `json:"enabled"`
+		Keys    *e2ee.Keys `json:"keys"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if !response.Enabled || response.Keys == nil || response.Keys.Iterations != 200000 || response.Keys.CreatedAt.IsZero() {
+		t.Errorf("Unexpected state %s", rr.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"PUT", body, http.StatusConflict, "conflict"},
+		{"PUT", `{"kdf": "PBKDF2-SHA256", "iterations": 1000, "salt": "x", "check": "plain"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", `{"kdf": "PBKDF2-SHA256", "iterations": 200000, "salt": "AAAAAAAAAAAAAAAAAAAAAA", "check": "e2e:v1:short"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", `[`, http.StatusBadRequest, "invalid"},
+		{"GET", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, "/api/e2e/keys", strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+}
+
+func TestE2ETasks(t *testing.T) {
+	s := newE2EServer(t)
+	milk, bread := strings.Repeat("0a", 16), strings.Repeat("0b", 16)
+
+	// 平文のタイトルや形式の合わないトークンは受け付けません
+	for _, body := range []string{
+		`{"title": "Buy milk"}`,
+		`{"title": "` + ciphertextTitle(40) + `", "index": ["milk"]}`,
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+	if tasks := s.store.GetTasks(context.Background()); len(tasks) != 0 {
+		t.Fatalf("Expected rejected requests to create no tasks, got %d", len(tasks))
+	}
+
+	for _, body := range []string{
+		`{"title": "` + ciphertextTitle(40) + `", "index": ["` + milk + `", "` + bread + `"]}`,
+		`{"title": "` + ciphertextTitle(41) + `", "index": ["` + milk + `"]}`,
+		`{"title": "` + ciphertextTitle(42) + `"}`,
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected an encrypted task to be created, got %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{milk, []int{1, 2}},
+		{milk + "," + bread, []int{1}},
+		{strings.Repeat("0c", 16), []int{}},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?index="+tt.query, nil))
+		var tasks []struct {
+			ID         int      `json:"id"`
+			BlindIndex []string `json:"blind_index"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &tasks)
+		ids := []int{}
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("index=%s: expected tasks %v, got %v", tt.query, tt.want, ids)
+		}
+	}
+}
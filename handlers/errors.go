@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todo-app/accounts"
+	"todo-app/board"
+	"todo-app/i18n"
+	"todo-app/integrations/llm"
+	"todo-app/lists"
+	"todo-app/logging"
+	"todo-app/models"
+	"todo-app/plugins"
+	"todo-app/pomodoro"
+	"todo-app/reports"
+	"todo-app/schema"
+	"todo-app/webhooks"
+	"todo-app/websocket"
+)
+
+// ハンドラで発生するエラーです。モデルのエラーと同じく writeError で状態コードに変換します
+var (
+	errMethodNotAllowed  = errors.New("method not allowed")
+	errInvalidJSON       = errors.New("invalid JSON")
+	errWebhookNotFound   = errors.New("webhook not found")
+	errRuleNotFound      = errors.New("rule not found")
+	errShareNotFound     = errors.New("share link not found")
+	errWorkspaceNotFound = errors.New("workspace not found")
+	errAdminDisabled     = errors.New("admin endpoints are disabled")
+	errUnauthorized      = errors.New("unauthorized")
+	errTooManyAttempts   = errors.New("too many failed attempts")
+	errCaptchaRequired   = errors.New("captcha required")
+	errBackupFailed      = errors.New("backup storage failed")
+	errStoreFailed       = errors.New("task store failed to save changes")
+)
+
+// errorBody は標準のエラーエンベロープ {"success": false, "error": {...}} の error 部分です
+// Code: クライアントが判定に使う短い識別子（not_found / invalid / conflict など）。言語によらず変わりません
+// Message: 人が読むための説明（Accept-Language に合わせて翻訳します）
+// Detail: 原因の詳しい説明（英語のまま返します。想定外のエラーでは返しません）
+// Fields: リクエスト本文がスキーマに合わないときの項目ごとの誤り
+// RequestID: リクエストの ID（X-Request-ID）。問い合わせのときに伝えてもらうと、サーバのログの request_id と突き合わせられます
+type errorBody struct {
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	Detail    string              `json:"detail,omitempty"`
+	Fields    []schema.FieldError `json:"fields,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// errorStatus は err に対応する HTTP の状態コードとエラーコードを返します
+// API のバージョン 2 以降では、JSON や ID の形は正しいが値に誤りのある入力を 422 にします
+func errorStatus(err error, version int) (int, string) {
+	switch {
+	case version >= apiVersion2 && errors.Is(err, models.ErrValidation) && !errors.Is(err, errInvalidID) && !errors.Is(err, errInvalidJSON):
+		return http.StatusUnprocessableEntity, "unprocessable"
+	case errors.Is(err, models.ErrTaskNotFound), errors.Is(err, errWebhookNotFound), errors.Is(err, errRuleNotFound), errors.Is(err, errPathNotFound),
+		errors.Is(err, errShareNotFound), errors.Is(err, errWorkspaceNotFound), errors.Is(err, errAPIKeyNotFound), errors.Is(err, errSessionNotFound), errors.Is(err, errInviteNotFound), errors.Is(err, accounts.ErrUserNotFound), errors.Is(err, lists.ErrListNotFound),
+		errors.Is(err, models.ErrTimeEntryNotFound), errors.Is(err, models.ErrCommentNotFound), errors.Is(err, pomodoro.ErrSessionNotFound), errors.Is(err, webhooks.ErrDeliveryNotFound),
+		errors.Is(err, board.ErrColumnNotFound), errors.Is(err, reports.ErrScheduleNotFound), errors.Is(err, models.ErrNothingToUndo):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, models.ErrValidation), errors.Is(err, errInvalidID), errors.Is(err, errInvalidJSON), errors.Is(err, errUnsupportedVersion):
+		return http.StatusBadRequest, "invalid"
+	case errors.Is(err, errCaptchaRequired):
+		return http.StatusUnauthorized, "captcha_required"
+	case errors.Is(err, errUnauthorized), errors.Is(err, accounts.ErrInvalidCredentials):
+		return http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, accounts.ErrEmailNotVerified):
+		return http.StatusForbidden, "email_not_verified"
+	case errors.Is(err, accounts.ErrUserDisabled):
+		return http.StatusForbidden, "user_disabled"
+	case errors.Is(err, accounts.ErrInvalidInvite):
+		return http.StatusForbidden, "invalid_invite"
+	case errors.Is(err, accounts.ErrPasswordBreached):
+		return http.StatusBadRequest, "password_breached"
+	case errors.Is(err, accounts.ErrPasswordReused):
+		return http.StatusBadRequest, "password_reused"
+	case errors.Is(err, errAdminDisabled), errors.Is(err, websocket.ErrCrossOrigin):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, errTooManyAttempts), errors.Is(err, errQuotaExceeded):
+		return http.StatusTooManyRequests, "too_many_requests"
+	case errors.Is(err, models.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, errMethodNotAllowed):
+		return http.StatusMethodNotAllowed, "method_not_allowed"
+	case errors.Is(err, llm.ErrProvider), errors.Is(err, errBackupFailed):
+		return http.StatusBadGateway, "bad_gateway"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, "canceled"
+	case errors.Is(err, errStoreFailed):
+		return http.StatusServiceUnavailable, "unavailable"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+// errorMessages は err の種類ごとのメッセージのキー（i18n のカタログのキー）です。上から順に照合します
+var errorMessages = []struct {
+	target error
+	key    string
+}{
+	{models.ErrTaskNotFound, "error.task_not_found"},
+	{models.ErrTimeEntryNotFound, "error.time_entry_not_found"},
+	{models.ErrCommentNotFound, "error.comment_not_found"},
+	{pomodoro.ErrSessionNotFound, "error.session_not_found"},
+	{errWebhookNotFound, "error.webhook_not_found"},
+	{webhooks.ErrDeliveryNotFound, "error.delivery_not_found"},
+	{errRuleNotFound, "error.rule_not_found"},
+	{board.ErrColumnNotFound, "error.column_not_found"},
+	{reports.ErrScheduleNotFound, "error.report_schedule_not_found"},
+	{errShareNotFound, "error.share_not_found"},
+	{errWorkspaceNotFound, "error.workspace_not_found"},
+	{errAPIKeyNotFound, "error.api_key_not_found"},
+	{errSessionNotFound, "error.login_session_not_found"},
+	{errInviteNotFound, "error.invite_not_found"},
+	{accounts.ErrUserNotFound, "error.user_not_found"},
+	{lists.ErrListNotFound, "error.list_not_found"},
+	{errPathNotFound, "error.path_not_found"},
+	{models.ErrNothingToUndo, "error.nothing_to_undo"},
+	{errInvalidID, "error.invalid_id"},
+	{errInvalidJSON, "error.invalid_json"},
+	{errUnsupportedVersion, "error.unsupported_api_version"},
+	{errInvalidRequest, "error.invalid_request"},
+	{models.ErrValidation, "error.validation"},
+	{plugins.ErrVetoed, "error.vetoed"},
+	{models.ErrAlreadyClaimed, "error.already_claimed"},
+	{models.ErrConflict, "error.conflict"},
+	{errMethodNotAllowed, "error.method_not_allowed"},
+	{errAdminDisabled, "error.admin_disabled"},
+	{websocket.ErrCrossOrigin, "error.cross_origin"},
+	{errUnauthorized, "error.unauthorized"},
+	{accounts.ErrInvalidCredentials, "error.invalid_credentials"},
+	{accounts.ErrEmailNotVerified, "error.email_not_verified"},
+	{accounts.ErrUserDisabled, "error.user_disabled"},
+	{accounts.ErrInvalidInvite, "error.invalid_invite"},
+	{accounts.ErrPasswordBreached, "error.password_breached"},
+	{accounts.ErrPasswordReused, "error.password_reused"},
+	{errTooManyAttempts, "error.too_many_attempts"},
+	{errCaptchaRequired, "error.captcha_required"},
+	{errQuotaExceeded, "error.quota_exceeded"},
+	{errBackupFailed, "error.backup_failed"},
+	{errStoreFailed, "error.store_failed"},
+	{llm.ErrProvider, "error.suggestion_failed"},
+	{context.DeadlineExceeded, "error.timeout"},
+	{context.Canceled, "error.canceled"},
+}
+
+// errorMessageKey は err を説明するメッセージのキーを返します。どれにも当たらなければ error.internal です
+func errorMessageKey(err error) string {
+	for _, m := range errorMessages {
+		if errors.Is(err, m.target) {
+			return m.key
+		}
+	}
+	return "error.internal"
+}
+
+// writeError は err を対応する状態コードと標準のエラーエンベロープで返します
+// 状態コードはリクエストで選んだ API のバージョンに合わせます（errorStatus）
+// メッセージは r の Accept-Language で選んだ言語（日本語か英語）にし、Content-Language で知らせます
+// 想定外のエラー（500）は内容をログに記録し、クライアントには詳細を返しません
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := errorStatus(err, requestAPIVersion(r))
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	body := errorBody{Code: code, Message: i18n.Message(lang, errorMessageKey(err)), Detail: err.Error(), RequestID: logging.RequestID(r.Context())}
+	if status == http.StatusInternalServerError {
+		s.logger.ErrorContext(r.Context(), "internal error", "err", err)
+		body.Detail = ""
+	}
+	var rerr *requestError
+	if errors.As(err, &rerr) {
+		body.Fields = rerr.fields
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   body,
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/integrations/llm"
+	"todo-app/logging"
+	"todo-app/models"
+	"todo-app/plugins"
+	"todo-app/reports"
+)
+
+// assertErrorResponse はレスポンスが status と標準のエラーエンベロープ（code）であることを確認します
+func assertErrorResponse(t *testing.T, rr *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rr.Code != status {
+		t.Errorf("Expected status code %d, got %d", status, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+	var response struct {
+		Success *bool     `json:"success"`
+		Error   errorBody `json:"error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal error response %q: %v", rr.Body.String(), err)
+	}
+	if response.Success == nil || *response.Success {
+		t.Errorf("Expected success to be false, got %s", rr.Body.String())
+	}
+	if response.Error.Code != code || response.Error.Message == "" {
+		t.Errorf("Expected error code %q with a message, got %+v", code, response.Error)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: id 1", models.ErrTaskNotFound), http.StatusNotFound, "not_found"},
+		{errWebhookNotFound, http.StatusNotFound, "not_found"},
+		{errPathNotFound, http.StatusNotFound, "not_found"},
+		{fmt.Errorf("%w: title is required", models.ErrValidation), http.StatusBadRequest, "invalid"},
+		{errInvalidID, http.StatusBadRequest, "invalid"},
+		{errInvalidJSON, http.StatusBadRequest, "invalid"},
+		{fmt.Errorf("restore: %w", models.ErrConflict), http.StatusConflict, "conflict"},
+		{errMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{fmt.Errorf("%w: 503 Service Unavailable", llm.ErrProvider), http.StatusBadGateway, "bad_gateway"},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+		{context.Canceled, http.StatusServiceUnavailable, "canceled"},
+		{errors.New("disk full"), http.StatusInternalServerError, "internal"},
+	}
+
+	for _, tc := range testCases {
+		status, code := errorStatus(tc.err, apiVersion1)
+		if status != tc.status || code != tc.code {
+			t.Errorf("errorStatus(%v) = %d, %q; expected %d, %q", tc.err, status, code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestWriteErrorHidesInternalErrors(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewServer(Deps{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+
+	rr := httptest.NewRecorder()
+	s.writeError(rr, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("secret database password"))
+
+	assertErrorResponse(t, rr, http.StatusInternalServerError, "internal")
+	if strings.Contains(rr.Body.String(), "secret") {
+		t.Errorf("Expected internal error details to be hidden, got %s", rr.Body.String())
+	}
+	if !strings.Contains(logs.String(), "secret database password") {
+		t.Errorf("Expected internal error to be logged, got %q", logs.String())
+	}
+}
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/tasks", `{"title": ""}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks", "not json", http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"PUT", "/api/tasks/1/toggle", "", http.StatusNotFound, "not_found"},
+		{"PUT", "/api/tasks/abc/toggle", "", http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/unknown", "", http.StatusNotFound, "not_found"},
+		{"DELETE", "/api/tasks/1", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/webhooks", `{"url": "ftp://example.com"}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/webhooks/1", "", http.StatusNotFound, "not_found"},
+	}
+
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			assertErrorResponse(t, rr, tc.status, tc.code)
+		})
+	}
+}
+
+func TestErrorMessageKey(t *testing.T) {
+	testCases := map[error]string{
+		fmt.Errorf("%w: id 1", models.ErrTaskNotFound):             "error.task_not_found",
+		fmt.Errorf("%w: id 2", errRuleNotFound):                    "error.rule_not_found",
+		fmt.Errorf("%w: title is required", models.ErrValidation):  "error.validation",
+		fmt.Errorf("%w: plugin keep: locked", plugins.ErrVetoed):   "error.vetoed",
+		fmt.Errorf("%w: claimed by bob", models.ErrAlreadyClaimed): "error.already_claimed",
+		fmt.Errorf("%w: id 3", reports.ErrScheduleNotFound):        "error.report_schedule_not_found",
+		fmt.Errorf("%w: 429 Too Many Requests", llm.ErrProvider):   "error.suggestion_failed",
+		errInvalidID:            "error.invalid_id",
+		context.Canceled:        "error.canceled",
+		errors.New("disk full"): "error.internal",
+	}
+	for err, want := range testCases {
+		if got := errorMessageKey(err); got != want {
+			t.Errorf("errorMessageKey(%v) = %q, expected %q", err, got, want)
+		}
+	}
+}
+
+func TestWriteErrorLocalizesMessage(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		acceptLanguage string
+		language       string
+		message        string
+	}{
+		{"", "en", "The request contains invalid values."},
+		{"ja-JP,ja;q=0.9,en;q=0.8", "ja", "入力内容に誤りがあります。"},
+		{"fr-FR,en;q=0.5", "en", "The request contains invalid values."},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": ""}`))
+		if tc.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+		if got := rr.Header().Get("Content-Language"); got != tc.language {
+			t.Errorf("Accept-Language %q: expected Content-Language %q, got %q", tc.acceptLanguage, tc.language, got)
+		}
+		var response struct {
+			Error errorBody `json:"error"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if response.Error.Message != tc.message {
+			t.Errorf("Accept-Language %q: expected message %q, got %q", tc.acceptLanguage, tc.message, response.Error.Message)
+		}
+		if !strings.Contains(response.Error.Detail, "title") {
+			t.Errorf("Expected the detail to explain the validation error, got %q", response.Error.Detail)
+		}
+	}
+}
+
+func TestWriteErrorRequestID(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/99", nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "abc123"))
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	var response struct {
+		Error errorBody `json:"error"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Error.RequestID != "abc123" {
+		t.Errorf("Expected the request ID in the error response, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/tasks/99", nil))
+	if strings.Contains(rr.Body.String(), "request_id") {
+		t.Errorf("Expected no request ID without the middleware, got %s", rr.Body.String())
+	}
+}
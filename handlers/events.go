@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"todo-app/models"
+)
+
+// liveSyncQueueSize はクライアントへまだ送っていないイベントを溜めておける数です（/ws と /api/events）
+const liveSyncQueueSize = 64
+
+// eventLogSize は接続し直したクライアントへ送り直せるように覚えておくイベントの数です
+const eventLogSize = 1000
+
+// eventStreamRetry はクライアントが接続し直すまで待つ時間（ミリ秒）として知らせる値です
+const eventStreamRetry = 1000
+
+// EventStreamHandler はタスクの変更イベントを Server-Sent Events で送り続けます（GET /api/events）
+// WebSocket を使えないクライアントのためのもので、送る内容は /ws と同じイベントの JSON です
+// Last-Event-ID ヘッダ（か ?last_event_id=）で最後に受け取ったイベントの ID を送ると、その後のイベントを先に送り直します
+// 送り直せないほど古い ID なら reset イベントを送るため、クライアントはタスクの一覧を読み込み直してください
+func (s *Server) EventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var since int64
+	if lastID != "" {
+		var err error
+		if since, err = strconv.ParseInt(lastID, 10, 64); err != nil || since < 0 {
+			s.writeError(w, r, fmt.Errorf("%w: Last-Event-ID must be a non-negative integer", models.ErrValidation))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("streaming is not supported by the response writer"))
+		return
+	}
+	// ストリームはサーバの WriteTimeout より長く続くため、この接続だけ書き込みの期限を外します
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// 送り直すイベントを選ぶ前に購読し、その間の変更も取りこぼさないようにします
+	events, lagging, unsubscribe := s.subscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry)
+
+	replayed := map[int64]bool{}
+	if lastID != "" {
+		missed, ok := s.eventLog.Since(since)
+		if !ok {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, event := range missed {
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+			replayed[event.ID] = true
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(liveSyncPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			if replayed[event.ID] {
+				continue
+			}
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			// コメントの行を送り、途中のプロキシに接続を閉じられないようにします
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-s.stopping.Done():
+			// サーバを止めるときは接続を閉じ、再起動した後に接続し直してもらいます
+			return
+		case <-lagging:
+			// 閉じるとクライアントは Last-Event-ID を付けて接続し直し、溜まっていたイベントを受け取れます
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// subscribeEvents はクライアントへ送るためにタスクの変更イベントを購読します
+// events に届いたイベントを溜めておき、溜まりきるほど受け取りが遅いクライアントでは lagging を閉じます
+// lagging が閉じたら接続を切ります。接続し直したクライアントは一覧を読み込み直すか、/api/events なら送り直しを受け取ります
+func (s *Server) subscribeEvents() (events <-chan models.Event, lagging <-chan struct{}, unsubscribe func()) {
+	queue := make(chan models.Event, liveSyncQueueSize)
+	lag := make(chan struct{})
+	var lagOnce sync.Once
+	unsubscribe = s.store.Subscribe(func(event models.Event) {
+		select {
+		case queue <- event:
+		default:
+			lagOnce.Do(func() { close(lag) })
+		}
+	})
+	return queue, lag, unsubscribe
+}
+
+// writeStreamEvent は event を Server-Sent Events の1件（id と data の行）として書き込みます
+func writeStreamEvent(w http.ResponseWriter, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.ID, data)
+	return err
+}
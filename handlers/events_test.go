@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestEventStreamHandler(t *testing.T) {
+	s := newTestServer()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	for _, title := range []string{"First", "Second"} {
+		http.Post(server.URL+"/api/tasks", "application/json", strings.NewReader(`{"title": "`+title+`"}`))
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", got)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	if id, event := readStreamEvent(t, reader); id != "2" || event.Type != models.EventTaskCreated || event.Task.Title != "Second" {
+		t.Errorf("Expected the missed event 2 to be replayed, got %s %+v", id, event)
+	}
+	http.Post(server.URL+"/api/tasks", "application/json", strings.NewReader(`{"title": "Third"}`))
+	if id, event := readStreamEvent(t, reader); id != "3" || event.Task.Title != "Third" {
+		t.Errorf("Expected the live event 3, got %s %+v", id, event)
+	}
+}
+
+func TestEventStreamHandlerOutlivesWriteTimeout(t *testing.T) {
+	s := newTestServer()
+	server := httptest.NewUnstartedServer(s)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	time.Sleep(300 * time.Millisecond)
+	http.Post(server.URL+"/api/tasks", "application/json", strings.NewReader(`{"title": "Late"}`))
+	if _, event := readStreamEvent(t, reader); event.Task.Title != "Late" {
+		t.Errorf("Expected the stream to stay open past the write timeout, got %+v", event)
+	}
+}
+
+func TestEventStreamHandlerReset(t *testing.T) {
+	s := newTestServer()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events?last_event_id=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected a reset event, got %v", err)
+		}
+		if line == "event: reset\n" {
+			break
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/events?last_event_id=latest", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+// readStreamEvent は Server-Sent Events の次のイベントの id と data を読み取ります（retry やコメントの行は読み飛ばします）
+func readStreamEvent(t *testing.T, reader *bufio.Reader) (string, models.Event) {
+	t.Helper()
+	var id string
+	var event models.Event
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatal(err)
+			}
+		case line == "" && id != "":
+			return id, event
+		}
+	}
+}
+
+func TestEventStreamHandlerClosesWhenStopping(t *testing.T) {
+	stopping, stop := context.WithCancel(context.Background())
+	server := httptest.NewServer(NewServer(Deps{Stopping: stopping}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(resp.Body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stream to end when the server is stopping")
+	}
+}
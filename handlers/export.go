@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-app/export"
+)
+
+// タスク一覧を Obsidian / Logseq 互換の Markdown ファイル群（zip）としてダウンロードさせます
+func (s *Server) ExportMarkdownHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	lists := []export.MarkdownList{
+		{Name: export.DefaultListName, Tasks: s.store.GetTasks(r.Context())},
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todo-markdown-%s.zip"`, now.Format("20060102")))
+	export.WriteMarkdownVault(w, lists, now)
+}
+
+// ExportPDFHandler はすべてのリストのタスク一覧を印刷できる PDF として返します（GET /api/export/pdf）
+// ブラウザでそのまま開けるよう inline で返します。?download=true なら保存させます
+func (s *Server) ExportPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	s.writePDF(w, r, export.MarkdownList{Name: export.DefaultListName, Tasks: s.store.GetTasks(r.Context())})
+}
+
+// writePDF は list のタスクを PDF として書き出します
+func (s *Server) writePDF(w http.ResponseWriter, r *http.Request, list export.MarkdownList) {
+	now := time.Now()
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "true" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="todo-%s.pdf"`, disposition, now.Format("20060102")))
+	if err := export.WritePDF(w, list, now); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to write PDF", "err", err)
+	}
+}
+
+// NotionExportHandler は現在のタスクをすべて Notion のデータベースへ書き出します
+func (s *Server) NotionExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result := s.notion.Export(r.Context(), s.store.GetTasks(r.Context()))
+	if result.Failed > 0 {
+		s.logger.WarnContext(r.Context(), "notion: some tasks failed to export", "failed", result.Failed, "total", result.Failed+result.Exported)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": result.Failed == 0,
+		"result":  result,
+	})
+}
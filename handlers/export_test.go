@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/integrations/notion"
+	"todo-app/models"
+)
+
+func TestExportMarkdownHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	s.store.AddTask(ctx, "Buy milk")
+	task, _ := s.store.AddTask(ctx, "Call mom")
+	s.store.ToggleTask(ctx, task.ID)
+
+	req := httptest.NewRequest("GET", "/api/export/markdown", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.ExportMarkdownHandler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/zip" {
+		t.Errorf("Expected Content-Type application/zip, got %s", contentType)
+	}
+	if !strings.Contains(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Error("Expected the zip to be served as an attachment")
+	}
+
+	body := rr.Body.Bytes()
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "Tasks.md" {
+		t.Fatalf("Unexpected files in zip: %v", reader.File)
+	}
+	file, _ := reader.File[0].Open()
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if !strings.Contains(string(content), "- [ ] Buy milk ^task-1") || !strings.Contains(string(content), "- [x] Call mom ^task-2") {
+		t.Errorf("Unexpected markdown: %s", content)
+	}
+}
+
+func TestExportMarkdownHandlerInvalidMethod(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest("POST", "/api/export/markdown", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.ExportMarkdownHandler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestExportPDFHandler(t *testing.T) {
+	s := newTestServer()
+	s.store.AddTask(context.Background(), "Passport")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/export/pdf", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "inline;") {
+		t.Fatalf("Unexpected response %d %v", rr.Code, rr.Header())
+	}
+	if body := rr.Body.String(); !strings.HasPrefix(body, "%PDF-") || !strings.Contains(body, "00500061007300730070006F00720074") {
+		t.Errorf("Expected a PDF with the task title, got %.200q", body)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/export/pdf?download=true", nil))
+	if !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("Expected an attachment, got %q", rr.Header().Get("Content-Disposition"))
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/export/pdf", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+
+	// 暗号化するモードではタイトルを読めないため、PDF は作れません
+	rr = httptest.NewRecorder()
+	newE2EServer(t).ServeHTTP(rr, httptest.NewRequest("GET", "/api/export/pdf", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected no PDF export in E2E mode, got %d", rr.Code)
+	}
+}
+
+func TestNotionExportHandler(t *testing.T) {
+	ctx := context.Background()
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		w.Write([]byte(`{"object": "page"}`))
+	}))
+	defer server.Close()
+
+	exporter := notion.NewExporter(notion.Config{Token: "secret", DatabaseID: "db", BaseURL: server.URL}, nil)
+	s := NewServer(Deps{Store: models.NewTodoApp(), Notion: exporter})
+	s.store.AddTask(ctx, "Task 1")
+	s.store.AddTask(ctx, "Task 2")
+	handler := s
+
+	req := httptest.NewRequest("POST", "/api/export/notion", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var response struct {
+		Success bool          `json:"success"`
+		Result  notion.Result `json:"result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !response.Success || response.Result.Exported != 2 || pages != 2 {
+		t.Errorf("Unexpected response: %+v (pages=%d)", response, pages)
+	}
+
+	req = httptest.NewRequest("GET", "/api/export/notion", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FeaturesHandler は設定で止められる機能が有効かどうかを返します（GET /api/features）
+// 画面は止められている機能の入力欄を隠し、変更の通知に接続しないようにします
+func (s *Server) FeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success":   true,
+		"search":    !s.config.DisableSearch,
+		"live_sync": !s.config.DisableLiveSync,
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeaturesHandler(t *testing.T) {
+	s := NewServer(Deps{})
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/features", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"live_sync":true,"search":true,"success":true}` {
+		t.Errorf("Expected all features to be enabled by default, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDisabledFeatures(t *testing.T) {
+	s := NewServer(Deps{Config: Config{DisableSearch: true, DisableLiveSync: true}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/features", nil))
+	if strings.TrimSpace(rr.Body.String()) != `{"live_sync":false,"search":false,"success":true}` {
+		t.Errorf("Expected the disabled features to be reported, got %s", rr.Body.String())
+	}
+
+	for _, path := range []string{"/api/tasks/search?q=milk", "/api/events"} {
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/ws", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected /ws to be disabled, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-app/models"
+	todov1 "todo-app/proto/todo/v1"
+)
+
+// grpcServicePath は todo.proto の TodoService のメソッドのパスの先頭です（/todo.v1.TodoService/{Method}）
+const grpcServicePath = "/todo.v1.TodoService/"
+
+// gRPC のステータスコードです
+const (
+	grpcOK                 = 0
+	grpcUnknown            = 2
+	grpcInvalidArgument    = 3
+	grpcNotFound           = 5
+	grpcPermissionDenied   = 7
+	grpcResourceExhausted  = 8
+	grpcFailedPrecondition = 9
+	grpcUnimplemented      = 12
+	grpcInternal           = 13
+	grpcUnauthenticated    = 16
+)
+
+// grpcError は gRPC のステータスで返すエラーです
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e *grpcError) Error() string { return e.message }
+
+// GRPCHandler は TodoService のメソッドを gRPC（HTTP/2、application/grpc）と gRPC-Web（application/grpc-web）で扱います
+// メッセージは圧縮していない1件だけを受け付け、結果は1件のメッセージとステータス（grpc-status・grpc-message）で返します
+// gRPC のステータスは HTTP の trailer で、gRPC-Web のステータスは本文の最後の trailer のフレームで返します
+func (s *Server) GRPCHandler(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	web := strings.HasPrefix(contentType, "application/grpc-web")
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	if !isGRPCContentType(contentType) {
+		s.writeError(w, r, fmt.Errorf("%w: content type must be application/grpc or application/grpc-web, got %q", models.ErrValidation, contentType))
+		return
+	}
+
+	reply, err := s.callGRPC(r)
+	if web {
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+	} else {
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	}
+	code, message := grpcOK, ""
+	if err != nil {
+		code, message = s.grpcStatus(r, err)
+	} else {
+		w.Write(grpcFrame(0, reply))
+	}
+	if web {
+		w.Write(grpcFrame(0x80, []byte("grpc-status: "+strconv.Itoa(code)+"\r\ngrpc-message: "+url.PathEscape(message)+"\r\n")))
+		return
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	w.Header().Set("Grpc-Message", url.PathEscape(message))
+}
+
+// wantsProto はリクエストの Accept が JSON の代わりに wire 形式（todo.proto のメッセージ）を求めているかを返します
+func wantsProto(r *http.Request) bool {
+	return todov1.Accepts(r.Header.Get("Accept"))
+}
+
+// writeProto は wantsProto のリクエストに、message を wire 形式で返します
+func writeProto(w http.ResponseWriter, message []byte) {
+	w.Header().Set("Content-Type", todov1.ContentType)
+	w.Header().Add("Vary", "Accept")
+	w.Write(message)
+}
+
+// callGRPC はリクエストのメッセージを読み取り、パスのメソッドを呼んで返すメッセージを作ります
+func (s *Server) callGRPC(r *http.Request) ([]byte, error) {
+	request, err := readGRPCFrame(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.TrimPrefix(r.URL.Path, grpcServicePath) {
+	case "ListTasks":
+		tasks := s.store.GetTasks(r.Context())
+		models.RollUp(tasks)
+		return todov1.MarshalTasks(tasks), nil
+	case "GetTask":
+		id, err := todov1.UnmarshalGetTaskRequest(request)
+		if err != nil {
+			return nil, &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		task, err := s.findTask(r.Context(), id)
+		if err != nil {
+			return nil, err
+		}
+		tasks := s.store.GetTasks(r.Context())
+		models.RollUp(tasks)
+		if progress := progressOf(tasks, id); progress.Total > 0 {
+			task.Progress = &progress
+		}
+		return todov1.MarshalTask(task), nil
+	case "ListLists":
+		return todov1.MarshalLists(s.lists.List()), nil
+	case "GetCurrentUser":
+		user, ok := contextUser(r)
+		if !ok {
+			return nil, &grpcError{grpcUnauthenticated, "no signed-in user (user accounts are not enabled)"}
+		}
+		return todov1.MarshalUser(user), nil
+	}
+	return nil, &grpcError{grpcUnimplemented, "unknown method " + r.URL.Path}
+}
+
+// readGRPCFrame は本文から長さの付いた1件のメッセージを読み取ります
+func readGRPCFrame(body io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "missing message frame"}
+	}
+	if header[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxRequestBody {
+		return nil, &grpcError{grpcResourceExhausted, fmt.Sprintf("message must be at most %d bytes", maxRequestBody)}
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "truncated message frame"}
+	}
+	return message, nil
+}
+
+// grpcFrame は flags（0 はメッセージ、0x80 は gRPC-Web の trailer）と長さを前に付けたフレームを返します
+func grpcFrame(flags byte, message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// grpcStatus はエラーを gRPC のステータスコードとメッセージにします
+// API のエラーは JSON の API と同じ HTTP のステータスを選び、それに対応するコードにします
+func (s *Server) grpcStatus(r *http.Request, err error) (int, string) {
+	var gerr *grpcError
+	if errors.As(err, &gerr) {
+		return gerr.code, gerr.message
+	}
+	status, _ := errorStatus(err, requestAPIVersion(r))
+	switch {
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return grpcInvalidArgument, err.Error()
+	case status == http.StatusUnauthorized:
+		return grpcUnauthenticated, err.Error()
+	case status == http.StatusForbidden:
+		return grpcPermissionDenied, err.Error()
+	case status == http.StatusNotFound:
+		return grpcNotFound, err.Error()
+	case status == http.StatusConflict:
+		return grpcFailedPrecondition, err.Error()
+	case status == http.StatusTooManyRequests:
+		return grpcResourceExhausted, err.Error()
+	case status >= http.StatusInternalServerError:
+		s.logger.ErrorContext(r.Context(), "internal error", "err", err)
+		return grpcInternal, "internal error"
+	}
+	return grpcUnknown, err.Error()
+}
+
+// isGRPCContentType は gRPC か gRPC-Web の protobuf のメッセージの Content-Type かを返します（gRPC-Web の base64 の text 形式は扱いません）
+func isGRPCContentType(contentType string) bool {
+	for _, t := range []string{"application/grpc", "application/grpc+proto", "application/grpc-web", "application/grpc-web+proto"} {
+		if contentType == t || strings.HasPrefix(contentType, t+";") {
+			return true
+		}
+	}
+	return false
+}
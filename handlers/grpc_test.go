@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	todov1 "todo-app/proto/todo/v1"
+)
+
+// grpcRequest は TodoService の method に message を1件のフレームにして送ります
+func grpcRequest(s http.Handler, contentType, method string, message []byte, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", grpcServicePath+method, bytes.NewReader(grpcFrame(0, message)))
+	req.Header.Set("Content-Type", contentType)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGRPCWeb(t *testing.T) {
+	s := newTestServer()
+	task, _ := s.store.AddTask(context.Background(), "Write report")
+
+	rr := grpcRequest(s, "application/grpc-web+proto", "GetTask", []byte{0x08, byte(task.ID)})
+	body := rr.Body.Bytes()
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/grpc-web+proto" {
+		t.Fatalf("Unexpected response %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	message := todov1.MarshalTask(task)
+	if !bytes.HasPrefix(body, grpcFrame(0, message)) {
+		t.Fatalf("Expected the task message first, got %x", body)
+	}
+	if trailer := string(body[5+len(message)+5:]); body[5+len(message)] != 0x80 || !strings.Contains(trailer, "grpc-status: 0\r\n") {
+		t.Errorf("Expected an OK trailer frame, got %q", trailer)
+	}
+
+	testCases := []struct {
+		method  string
+		message []byte
+		status  string
+	}{
+		{"GetTask", []byte{0x08, 0x09}, "grpc-status: 5\r\n"},
+		{"GetTask", []byte{0x0a, 0x05}, "grpc-status: 3\r\n"},
+		{"GetCurrentUser", nil, "grpc-status: 16\r\n"},
+		{"DeleteTask", nil, "grpc-status: 12\r\n"},
+	}
+	for _, tc := range testCases {
+		rr := grpcRequest(s, "application/grpc-web", tc.method, tc.message)
+		body := rr.Body.Bytes()
+		if len(body) < 5 || body[0] != 0x80 || !strings.Contains(string(body[5:]), tc.status) {
+			t.Errorf("%s %x: expected %q, got %q", tc.method, tc.message, tc.status, body)
+		}
+	}
+
+	req := httptest.NewRequest("POST", grpcServicePath+"ListTasks", nil)
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestGRPCTrailers(t *testing.T) {
+	s := newTestServer()
+	s.lists.Create("Work")
+
+	rr := grpcRequest(s, "application/grpc", "ListLists", nil)
+	result := rr.Result()
+	if result.Header.Get("Content-Type") != "application/grpc+proto" || !bytes.Equal(rr.Body.Bytes(), grpcFrame(0, todov1.MarshalLists(s.lists.List()))) {
+		t.Fatalf("Unexpected response %q %x", result.Header.Get("Content-Type"), rr.Body.Bytes())
+	}
+	if result.Trailer.Get("Grpc-Status") != "0" {
+		t.Errorf("Expected an OK status trailer, got %v", result.Trailer)
+	}
+
+	rr = grpcRequest(s, "application/grpc", "GetTask", []byte{0x08, 0x09})
+	if result := rr.Result(); result.Trailer.Get("Grpc-Status") != "5" || rr.Body.Len() != 0 {
+		t.Errorf("Expected NOT_FOUND without a message, got %v %x", result.Trailer, rr.Body.Bytes())
+	}
+}
+
+func TestGRPCCurrentUser(t *testing.T) {
+	s := newTestAccountsServer(t)
+	cookie := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+
+	rr := grpcRequest(s, "application/grpc-web", "GetCurrentUser", nil, cookie)
+	message, err := readGRPCFrame(rr.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the message frame: %v", err)
+	}
+	fields, err := todov1.Parse(message)
+	if err != nil || len(fields) < 2 || string(fields[1].Bytes) != "alice" {
+		t.Errorf("Expected alice's User message, got %x (%v)", rr.Body.Bytes(), err)
+	}
+
+	// ログインしていなければ画面へのリダイレクトではなく 401 を返します
+	rr = grpcRequest(s, "application/grpc-web", "GetCurrentUser", nil)
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+}
+
+func TestProtoContentNegotiation(t *testing.T) {
+	s := newTestServer()
+	task, _ := s.store.AddTask(context.Background(), "Write report")
+	s.lists.Create("Work")
+
+	testCases := []struct {
+		path string
+		want []byte
+	}{
+		{"/api/tasks", todov1.MarshalTasks(s.store.GetTasks(context.Background()))},
+		{"/api/tasks/1", todov1.MarshalTask(task)},
+		{"/api/lists", todov1.MarshalLists(s.lists.List())},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != todov1.ContentType || !bytes.Equal(rr.Body.Bytes(), tc.want) {
+			t.Errorf("%s: unexpected response %d %q %x", tc.path, rr.Code, rr.Header().Get("Content-Type"), rr.Body.Bytes())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/lists", nil))
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected JSON without an Accept header, got %q", rr.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo-app/importer"
+	"todo-app/models"
+)
+
+// maxImportSize は取り込める CSV の最大サイズ（バイト）です
+const maxImportSize = 5 << 20
+
+// ImportCSVHandler はスプレッドシートから書き出した CSV をタスクとして取り込み、行ごとの結果を返します
+// CSV はリクエストボディか、multipart/form-data の file で送ります
+// ?columns=title:Task Name,due_date:Deadline で列の対応を、?dry_run=true で検証だけを行うことを指定できます
+func (s *Server) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	mapping, err := importer.ParseMapping(query.Get("columns"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	dryRun := false
+	if value := query.Get("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: dry_run must be true or false", models.ErrValidation))
+			return
+		}
+	}
+
+	body, err := readImportBody(w, r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	rows, err := importer.ReadCSV(strings.NewReader(body), mapping)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  importer.Import(r.Context(), s.store, rows, dryRun),
+	})
+}
+
+// readImportBody はリクエストボディ、または multipart/form-data の file から CSV を読み込みます
+func readImportBody(w http.ResponseWriter, r *http.Request) (string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var source io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			if isTooLarge(err) {
+				return "", errImportTooLarge
+			}
+			return "", fmt.Errorf("%w: multipart request must include a file field", models.ErrValidation)
+		}
+		defer file.Close()
+		source = file
+	}
+
+	data, err := io.ReadAll(source)
+	if isTooLarge(err) {
+		return "", errImportTooLarge
+	}
+	return string(data), err
+}
+
+var errImportTooLarge = fmt.Errorf("%w: CSV must be at most %d MB", models.ErrValidation, maxImportSize>>20)
+
+// isTooLarge は http.MaxBytesReader の上限を超えたことによるエラーかを返します
+func isTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/importer"
+)
+
+const importCSV = "Task Name,Deadline,priority\nWrite report,2025-03-01,high\n,2025-03-02,\nFix bug,,low\n"
+
+func decodeImportReport(t *testing.T, rr *httptest.ResponseRecorder) importer.Report {
+	t.Helper()
+	var response struct {
+		Success bool            `json:"success"`
+		Report  importer.Report `json:"report"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || !response.Success {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+	return response.Report
+}
+
+func TestImportCSVHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	path := "/api/import/csv?columns=title:Task+Name,due_date:Deadline"
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", path+"&dry_run=true", strings.NewReader(importCSV)))
+	report := decodeImportReport(t, rr)
+	if !report.DryRun || report.Created != 2 || report.Failed != 1 || len(s.Store().GetTasks(ctx)) != 0 {
+		t.Errorf("Unexpected dry run: %s", rr.Body.String())
+	}
+	if row := report.Rows[1]; row.Line != 3 || len(row.Errors) != 1 {
+		t.Errorf("Expected an error on line 3, got %+v", row)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", path, strings.NewReader(importCSV)))
+	report = decodeImportReport(t, rr)
+	tasks := s.Store().GetTasks(ctx)
+	if report.DryRun || report.Created != 2 || len(tasks) != 2 || tasks[0].DueDate == nil || tasks[1].Priority != "low" {
+		t.Errorf("Unexpected import: %s", rr.Body.String())
+	}
+}
+
+func TestImportCSVHandlerMultipart(t *testing.T) {
+	s := newTestServer()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile("file", "tasks.csv")
+	file.Write([]byte("title\nFrom a spreadsheet\n"))
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/api/import/csv", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	if report := decodeImportReport(t, rr); report.Created != 1 || report.Rows[0].Title != "From a spreadsheet" {
+		t.Errorf("Unexpected import: %s", rr.Body.String())
+	}
+}
+
+func TestImportCSVHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"method", "GET", "/api/import/csv", "", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"mapping", "POST", "/api/import/csv?columns=owner:Assignee", "", "title\nA\n", http.StatusBadRequest, "invalid"},
+		{"dry run", "POST", "/api/import/csv?dry_run=maybe", "", "title\nA\n", http.StatusBadRequest, "invalid"},
+		{"no title", "POST", "/api/import/csv", "", "name\nA\n", http.StatusBadRequest, "invalid"},
+		{"no file", "POST", "/api/import/csv", "multipart/form-data; boundary=x", "--x--\r\n", http.StatusBadRequest, "invalid"},
+		{"too large", "POST", "/api/import/csv", "", "title\n" + strings.Repeat("a", maxImportSize), http.StatusBadRequest, "invalid"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		t.Run(tc.name, func(t *testing.T) {
+			assertErrorResponse(t, rr, tc.status, tc.code)
+		})
+	}
+}
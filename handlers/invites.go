@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// errInviteNotFound は招待コードが見つからないことを表します
+var errInviteNotFound = errors.New("invite not found")
+
+// InvitesHandler は招待コードの一覧（GET）と発行（POST {"max_uses": 1, "expires_at": "..."}）を行います
+// 発行したときだけ応答の code にコードそのものを、url に登録画面へのリンクを入れます。あとから確認する方法はありません
+func (s *Server) InvitesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"invites": s.invites.List(),
+		})
+	case http.MethodPost:
+		var req struct {
+			MaxUses   int        `json:"max_uses"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				s.writeError(w, r, errInvalidJSON)
+				return
+			}
+		}
+		if req.MaxUses == 0 {
+			req.MaxUses = 1
+		}
+		invite, code, err := s.invites.Create(req.MaxUses, req.ExpiresAt)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.audit(r, slog.LevelInfo, "created invite", "invite_id", invite.ID, "max_uses", invite.MaxUses)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"invite":  invite,
+			"code":    code,
+			"url":     s.absoluteURL(r, "/login?invite="+url.QueryEscape(code)),
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// RevokeInviteHandler は URL の ID の招待コードを取り消します（DELETE）。取り消したコードでは登録できなくなります
+func (s *Server) RevokeInviteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/admin/invites/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	revoked, err := s.invites.Revoke(id)
+	if !revoked {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errInviteNotFound, id))
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.audit(r, slog.LevelInfo, "revoked invite", "invite_id", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+	"todo-app/logging"
+	"todo-app/models"
+)
+
+func TestInviteOnlyRegistration(t *testing.T) {
+	users, _ := accounts.NewStore("")
+	users.Iterations = 1000
+	s := NewServer(Deps{
+		Logger:   logging.Discard(),
+		Config:   Config{AdminToken: "secret", InviteOnly: true, PublicURL: "https://todo.example.com"},
+		Accounts: users,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+	register := func(name, invite string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"name": name, "password": "correct horse", "invite": invite})
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/register", strings.NewReader(string(body))))
+		return rr
+	}
+
+	// 招待コードの発行は管理用トークンが必要です
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/admin/invites", strings.NewReader(`{"max_uses": 1}`)))
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+
+	req := adminRequest("POST", "/api/admin/invites")
+	req.Body = http.NoBody
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	var created struct {
+		Invite accounts.Invite `json:"invite"`
+		Code   string          `json:"code"`
+		URL    string          `json:"url"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if rr.Code != http.StatusOK || created.Invite.MaxUses != 1 || created.Code == "" {
+		t.Fatalf("Expected an invite for 1 use, got %d %s", rr.Code, rr.Body.String())
+	}
+	if created.URL != "https://todo.example.com/login?invite="+created.Code {
+		t.Errorf("Unexpected invite URL %q", created.URL)
+	}
+
+	assertErrorResponse(t, register("alice", ""), http.StatusForbidden, "invalid_invite")
+	assertErrorResponse(t, register("alice", "inv_wrong"), http.StatusForbidden, "invalid_invite")
+	// 登録できなかったときは招待コードを使ったことにしません
+	assertErrorResponse(t, register("x", created.Code), http.StatusBadRequest, "invalid")
+	if rr := register("alice", created.Code); rr.Code != http.StatusOK {
+		t.Fatalf("Expected to register with the invite, got %d %s", rr.Code, rr.Body.String())
+	}
+	assertErrorResponse(t, register("bob", created.Code), http.StatusForbidden, "invalid_invite")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/invites"))
+	if !strings.Contains(rr.Body.String(), `"uses":1`) || strings.Contains(rr.Body.String(), created.Code) {
+		t.Errorf("Expected the list to show the use without the code, got %s", rr.Body.String())
+	}
+
+	path := "/api/admin/invites/" + strconv.Itoa(created.Invite.ID)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", path))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the invite to be revoked, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", path))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestRegistrationWithoutInviteOnly(t *testing.T) {
+	s := newTestAccountsServer(t)
+	if rr := authRequest(s, "register", "alice", "correct horse"); rr.Code != http.StatusOK {
+		t.Errorf("Expected to register without an invite, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"todo-app/export"
+	"todo-app/lists"
+	"todo-app/models"
+	todov1 "todo-app/proto/todo/v1"
+)
+
+// ListsHandler はリストの一覧（GET）と作成（POST {"name": "..."}）を行います
+// GET は Accept: application/x-protobuf なら、todo.proto の ListListsResponse で返します
+func (s *Server) ListsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if wantsProto(r) {
+			writeProto(w, todov1.MarshalLists(s.lists.List()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"lists":   s.lists.List(),
+		})
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		list, err := s.lists.Create(req.Name)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"list":    list,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// ListHandler は URL の ID のリストの取得（GET）・名前の変更（PUT {"name": "..."}）・削除（DELETE）を行います
+// 削除したリストに入っていたタスクは削除せず、どのリストにも入っていない状態に戻します
+func (s *Server) ListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r.URL.Path, "/api/lists/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var list lists.List
+	switch r.Method {
+	case http.MethodGet:
+		list, err = s.lists.Get(id)
+	case http.MethodPut:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		list, err = s.lists.Rename(id, req.Name)
+	case http.MethodDelete:
+		if err := s.lists.Delete(id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if err := s.clearList(r, id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.listBoards.Delete(id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
+		return
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"list":    list,
+	})
+}
+
+// DuplicateListHandler は URL の ID のリストを複製します（POST {"name": "...", "include_tasks": true}）
+// 名前を省略すると「元の名前 のコピー」にします。include_tasks が true なら未完了のタスクを新しい ID で複製し、
+// 元のタスクのサブタスクは複製した親タスクのサブタスクにします。完了したタスク・記録した時間・コメントは複製しません
+// タスクの複製に途中で失敗したら、作ったタスクをごみ箱へ移して新しいリストを削除し、エラーを返します
+func (s *Server) DuplicateListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	source, err := s.findList(r, "duplicate")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Name         string `json:"name"`
+		IncludeTasks bool   `json:"include_tasks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if req.Name == "" {
+		req.Name = source.Name + " のコピー"
+	}
+
+	list, err := s.lists.Create(req.Name)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tasks := []models.Task{}
+	if req.IncludeTasks {
+		tasks, err = s.copyTasks(r, source.ID, list.ID)
+		if err != nil {
+			// 途中で失敗したら、作ったタスクとリストを取り除いて複製する前に戻します
+			s.discardCopies(r, tasks)
+			if deleteErr := s.lists.Delete(list.ID); deleteErr != nil {
+				s.logger.ErrorContext(r.Context(), "failed to remove the duplicated list", "list_id", list.ID, "err", deleteErr)
+			}
+			s.writeError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"list":    list,
+		"tasks":   tasks,
+	})
+}
+
+// copyTasks はリスト from の未完了のタスクを、新しい ID でリスト to に作成します
+// サブタスクは親タスクより後に作られるため、ID の順に複製すれば親タスクの新しい ID がわかります
+func (s *Server) copyTasks(r *http.Request, from, to int) ([]models.Task, error) {
+	open := []models.Task{}
+	for _, task := range filterByList(s.store.GetTasks(r.Context()), from) {
+		if !task.Completed {
+			open = append(open, task)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].ID < open[j].ID })
+
+	copied := make([]models.Task, 0, len(open))
+	newIDs := map[int]int{}
+	for _, task := range open {
+		task := task
+		update := models.TaskUpdate{DueDate: task.DueDate, ListID: &to, Recurrence: task.Recurrence}
+		if task.Description != "" {
+			update.Description = &task.Description
+		}
+		if task.Priority != "" {
+			update.Priority = &task.Priority
+		}
+		if len(task.Tags) > 0 {
+			update.Tags = &task.Tags
+		}
+		if len(task.BlindIndex) > 0 {
+			update.BlindIndex = &task.BlindIndex
+		}
+		if parentID, ok := newIDs[task.ParentID]; ok {
+			update.ParentID = &parentID
+		}
+		created, err := s.store.AddTaskWith(r.Context(), task.Title, update)
+		if err != nil {
+			return copied, err
+		}
+		newIDs[task.ID] = created.ID
+		copied = append(copied, created)
+	}
+	return copied, nil
+}
+
+// discardCopies は複製の途中で作ったタスクを、サブタスクから順にごみ箱へ移します
+func (s *Server) discardCopies(r *http.Request, copied []models.Task) {
+	for i := len(copied) - 1; i >= 0; i-- {
+		if err := s.store.DeleteTask(r.Context(), copied[i].ID); err != nil && !errors.Is(err, models.ErrTaskNotFound) {
+			s.logger.ErrorContext(r.Context(), "failed to remove a duplicated task", "task_id", copied[i].ID, "err", err)
+		}
+	}
+}
+
+// ListBurndownHandler はリストのタスクだけのバーンダウンを返します（GET /api/lists/{id}/burndown）
+// 期間とタイムゾーンは BurndownHandler と同じく指定できます
+func (s *Server) ListBurndownHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	list, err := s.findList(r, "burndown")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeBurndown(w, r, filterByList(s.store.GetTasks(r.Context()), list.ID))
+}
+
+// ListTimelineHandler はリストのタスクだけのタイムラインを返します（GET /api/lists/{id}/timeline）
+func (s *Server) ListTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	list, err := s.findList(r, "timeline")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"timeline": s.timeline.ListTimeline(s.store.GetTasks(r.Context()), list.ID),
+	})
+}
+
+// ListExportPDFHandler はリストのタスクだけを、リストの名前を見出しにした PDF として返します（GET /api/lists/{id}/export.pdf）
+func (s *Server) ListExportPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	list, err := s.findList(r, "export.pdf")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writePDF(w, r, export.MarkdownList{Name: list.Name, Tasks: filterByList(s.store.GetTasks(r.Context()), list.ID)})
+}
+
+// ListQRCodeHandler はリストを開いた画面（/?list={id}）を指す QR コードを PNG で返します（GET /api/lists/{id}/qr.png）
+func (s *Server) ListQRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	list, err := s.findList(r, "qr.png")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeQRCode(w, r, s.absoluteURL(r, "/?list="+strconv.Itoa(list.ID)))
+}
+
+// findList は URL の "/api/lists/{id}/{action}" のリストを返します
+func (s *Server) findList(r *http.Request, action string) (lists.List, error) {
+	id, err := parseID(r.URL.Path, "/api/lists/", action)
+	if err != nil {
+		return lists.List{}, err
+	}
+	return s.lists.Get(id)
+}
+
+// clearList はリスト id に入っているタスクをどのリストにも入っていない状態に戻します
+func (s *Server) clearList(r *http.Request, id int) error {
+	none := 0
+	for _, task := range s.store.GetTasks(r.Context()) {
+		if task.ListID != id {
+			continue
+		}
+		if err := s.store.UpdateTask(r.Context(), task.ID, models.TaskUpdate{ListID: &none}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkList はタスクを入れるリスト id があることを確かめます。0（どのリストにも入れない）は常に受け付けます
+func (s *Server) checkList(id int) error {
+	if id == 0 {
+		return nil
+	}
+	if _, err := s.lists.Get(id); err != nil {
+		return fmt.Errorf("%w: list_id %d does not exist", models.ErrValidation, id)
+	}
+	return nil
+}
+
+// parseListFilter は一覧の list パラメータ（リストの ID か、どのリストにも入っていないタスクを表す "none"）を読み取ります
+func parseListFilter(value string) (int, error) {
+	if strings.EqualFold(value, "none") {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%w: list must be a list ID or none, got %q", models.ErrValidation, value)
+	}
+	return id, nil
+}
+
+// filterByList はリスト listID（0 ならどのリストにも入っていないもの）のタスクだけを返します
+func filterByList(tasks []models.Task, listID int) []models.Task {
+	filtered := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.ListID == listID {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+// listRequest はリストや一覧の API へのリクエストを行います
+func listRequest(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rr
+}
+
+func TestListsHandler(t *testing.T) {
+	s := newTestServer()
+
+	rr := listRequest(s, "POST", "/api/lists", `{"name": "Work"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Success bool       `json:"success"`
+		List    lists.List `json:"list"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if !created.Success || created.List.ID != 1 || created.List.Name != "Work" {
+		t.Errorf("Unexpected response: %s", rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/lists", `{"name": "Groceries"}`)
+
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists", `{"name": "work"}`), http.StatusConflict, "conflict")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists", `{"name": ""}`), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists", `{"title": "Work"}`), http.StatusBadRequest, "invalid")
+
+	rr = listRequest(s, "GET", "/api/lists", "")
+	var listed struct {
+		Lists []lists.List `json:"lists"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if len(listed.Lists) != 2 || listed.Lists[1].Name != "Groceries" {
+		t.Errorf("Unexpected lists: %s", rr.Body.String())
+	}
+
+	rr = listRequest(s, "PUT", "/api/lists/1", `{"name": "Office"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"Office"`) {
+		t.Errorf("Expected the list to be renamed, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := listRequest(s, "GET", "/api/lists/1", ""); !strings.Contains(rr.Body.String(), `"name":"Office"`) {
+		t.Errorf("Expected the renamed list, got %s", rr.Body.String())
+	}
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/9", ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "PUT", "/api/lists/1", `{"name": "groceries"}`), http.StatusConflict, "conflict")
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/abc", ""), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "PATCH", "/api/lists/1", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestTasksInLists(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Work"}`)
+	listRequest(s, "POST", "/api/lists", `{"name": "Groceries"}`)
+
+	rr := listRequest(s, "POST", "/api/tasks", `{"title": "Write report", "list_id": 1}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"list_id":1`) {
+		t.Fatalf("Expected the task to be added to the list, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/tasks", `{"title": "Buy milk", "list_id": 2}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Call mom"}`)
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks", `{"title": "Lost", "list_id": 9}`), http.StatusBadRequest, "invalid")
+
+	titles := func(query string) []string {
+		var tasks []models.Task
+		rr := listRequest(s, "GET", "/api/tasks"+query, "")
+		json.Unmarshal(rr.Body.Bytes(), &tasks)
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+	if got := titles("?list=1"); len(got) != 1 || got[0] != "Write report" {
+		t.Errorf("Expected only the work task, got %v", got)
+	}
+	if got := titles("?list=none"); len(got) != 1 || got[0] != "Call mom" {
+		t.Errorf("Expected only the task without a list, got %v", got)
+	}
+	assertErrorResponse(t, listRequest(s, "GET", "/api/tasks?list=work", ""), http.StatusBadRequest, "invalid")
+
+	// PATCH で別のリストへ移し、0 でリストから外します
+	if rr := listRequest(s, "PATCH", "/api/tasks/3", `{"list_id": 2}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"list_id":2`) {
+		t.Errorf("Expected the task to move to list 2, got %d %s", rr.Code, rr.Body.String())
+	}
+	if got := titles("?list=2"); len(got) != 2 {
+		t.Errorf("Expected two groceries tasks, got %v", got)
+	}
+	listRequest(s, "PATCH", "/api/tasks/3", `{"list_id": 0}`)
+	if got := titles("?list=none"); len(got) != 1 || got[0] != "Call mom" {
+		t.Errorf("Expected the task to leave its list, got %v", got)
+	}
+	assertErrorResponse(t, listRequest(s, "PATCH", "/api/tasks/3", `{"list_id": 9}`), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "PATCH", "/api/tasks/3", `{"list_id": -1}`), http.StatusBadRequest, "invalid")
+
+	// PUT は本文にないリストを外します
+	listRequest(s, "PUT", "/api/tasks/1", `{"title": "Write the report"}`)
+	if got := titles("?list=1"); len(got) != 0 {
+		t.Errorf("Expected PUT without list_id to remove the task from its list, got %v", got)
+	}
+
+	// リストを削除しても、入っていたタスクはリストのない状態で残ります
+	if rr := listRequest(s, "DELETE", "/api/lists/2", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the list to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+	for _, task := range s.Store().GetTasks(ctx) {
+		if task.ListID != 0 {
+			t.Errorf("Expected every task to leave the deleted list, got %+v", task)
+		}
+	}
+	if got := titles(""); len(got) != 3 {
+		t.Errorf("Expected the tasks to remain, got %v", got)
+	}
+	assertErrorResponse(t, listRequest(s, "DELETE", "/api/lists/2", ""), http.StatusNotFound, "not_found")
+}
+
+func TestDuplicateListHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Sprint 1"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Review PRs", "list_id": 1, "priority": "high", "tags": ["dev"]}`)
+	listRequest(s, "POST", "/api/tasks/1/subtasks", `{"title": "Read the diff"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Shipped", "list_id": 1}`)
+	listRequest(s, "PATCH", "/api/tasks/3", `{"completed": true}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Elsewhere"}`)
+
+	rr := listRequest(s, "POST", "/api/lists/1/duplicate", `{"include_tasks": true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Success bool          `json:"success"`
+		List    lists.List    `json:"list"`
+		Tasks   []models.Task `json:"tasks"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if !response.Success || response.List.ID != 2 || response.List.Name != "Sprint 1 のコピー" {
+		t.Fatalf("Unexpected list: %s", rr.Body.String())
+	}
+	if len(response.Tasks) != 2 {
+		t.Fatalf("Expected the two open tasks to be copied, got %s", rr.Body.String())
+	}
+	parent, child := response.Tasks[0], response.Tasks[1]
+	if parent.ID <= 4 || parent.Title != "Review PRs" || parent.ListID != 2 || parent.Priority != "high" || !parent.HasTag("dev") || parent.Completed {
+		t.Errorf("Unexpected copied task %+v", parent)
+	}
+	if child.Title != "Read the diff" || child.ParentID != parent.ID || child.ListID != 2 {
+		t.Errorf("Expected the subtask to belong to the copied parent, got %+v", child)
+	}
+	if got := len(filterByList(s.Store().GetTasks(ctx), 1)); got != 3 {
+		t.Errorf("Expected the source list to keep its 3 tasks, got %d", got)
+	}
+
+	// 名前を省略すると「Sprint 1 のコピー」になるため、2回目は名前が重なります。include_tasks がなければタスクは複製しません
+	rr = listRequest(s, "POST", "/api/lists/1/duplicate", ``)
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+	rr = listRequest(s, "POST", "/api/lists/1/duplicate", `{"name": "Sprint 2"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"Sprint 2"`) || !strings.Contains(rr.Body.String(), `"tasks":[]`) {
+		t.Errorf("Expected an empty copy, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/9/duplicate", `{}`), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/1/duplicate", `{"include_tasks": "yes"}`), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/1/duplicate", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/1/unknown", ""), http.StatusNotFound, "not_found")
+}
+
+func TestDuplicateListHandlerRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(Deps{Store: models.NewTodoApp(models.WithMaxTasks(3))})
+	listRequest(s, "POST", "/api/lists", `{"name": "Sprint 1"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Review PRs", "list_id": 1}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Deploy", "list_id": 1}`)
+
+	// 1件目の複製で上限に達し、2件目の複製に失敗します
+	rr := listRequest(s, "POST", "/api/lists/1/duplicate", `{"include_tasks": true}`)
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+	if tasks := s.Store().GetTasks(ctx); len(tasks) != 2 {
+		t.Errorf("Expected the copied task to be removed, got %+v", tasks)
+	}
+	if all := s.lists.List(); len(all) != 1 {
+		t.Errorf("Expected the new list to be removed, got %+v", all)
+	}
+}
+
+func TestListBurndownHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Sprint"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Review PRs", "list_id": 1}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Elsewhere"}`)
+
+	rr := listRequest(s, "GET", "/api/lists/1/burndown?tz=UTC", "")
+	var response struct {
+		Burndown struct {
+			Days []struct {
+				Remaining *int `json:"remaining"`
+			} `json:"days"`
+		} `json:"burndown"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	days := response.Burndown.Days
+	if rr.Code != http.StatusOK || len(days) == 0 || days[len(days)-1].Remaining == nil || *days[len(days)-1].Remaining != 1 {
+		t.Errorf("Expected a burndown of the list's task only, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/9/burndown", ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/1/burndown", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestListTimelineHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Packing"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Passport", "list_id": 1, "due_date": "2025-03-05"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Taxes", "due_date": "2025-03-06"}`)
+
+	rr := listRequest(s, "GET", "/api/lists/1/timeline", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"title":"Passport"`) || strings.Contains(rr.Body.String(), "Taxes") {
+		t.Errorf("Expected a timeline of the list only, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/9/timeline", ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/1/timeline", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestListExportPDFHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Packing"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Passport", "list_id": 1}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Taxes"}`)
+
+	rr := listRequest(s, "GET", "/api/lists/1/export.pdf", "")
+	// "Packing" と "Passport" は載せ、ほかのリストの "Taxes" は載せません
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, "005000610063006B0069006E0067") ||
+		!strings.Contains(body, "00500061007300730070006F00720074") || strings.Contains(body, "00540061007800650073") {
+		t.Errorf("Expected a PDF of the list, got %d %.200q", rr.Code, body)
+	}
+
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/9/export.pdf", ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/1/export.pdf", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestListQRCodeHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Groceries"}`)
+
+	rr := listRequest(s, "GET", "/api/lists/1/qr.png", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected a QR code, got %d %v", rr.Code, rr.Header())
+	}
+
+	assertErrorResponse(t, listRequest(s, "GET", "/api/lists/9/qr.png", ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/lists/1/qr.png", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
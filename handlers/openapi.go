@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+)
+
+// openAPIFile は API の OpenAPI 3.0 の文書です。cmd/tsgen が frontend/api.ts と同じ型とエンドポイントから生成します
+//
+//go:embed openapi.json
+var openAPIFile []byte
+
+// openAPIDocument は読み込み済みの openAPIFile です。埋め込んだファイルの誤りは起動時に panic します
+var openAPIDocument = mustDecodeOpenAPI(openAPIFile)
+
+func mustDecodeOpenAPI(data []byte) map[string]interface{} {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		panic(err)
+	}
+	return document
+}
+
+// OpenAPIHandler は API の OpenAPI 3.0 の文書を返します（GET /api/openapi.json）
+// ワークスペースのサーバでは servers に Config.BasePath を入れ、そのワークスペースの API を呼び出せるようにします
+func (s *Server) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	document := make(map[string]interface{}, len(openAPIDocument)+1)
+	for key, value := range openAPIDocument {
+		document[key] = value
+	}
+	if s.config.BasePath != "" {
+		document["servers"] = []map[string]string{{"url": s.config.BasePath}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// APIDocsHandler は OpenAPI の文書を読み込んで API を試せる Swagger UI の画面を返します（GET /api/docs）
+// Swagger UI のスクリプトとスタイルは CDN から読み込み、static/api_docs.js が文書の URL を渡して表示します
+func (s *Server) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	s.renderPage(w, r, "api_docs.html", s.config.BasePath+"/api/openapi.json")
+}
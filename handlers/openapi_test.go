@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	s := newTestServer()
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected the OpenAPI document, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var document struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+		Servers []map[string]string               `json:"servers"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if document.OpenAPI != "3.0.3" || document.Paths["/api/tasks"]["post"] == nil || document.Paths["/api/tasks/{id}"]["delete"] == nil {
+		t.Errorf("Expected the task endpoints to be described, got %s %v", document.OpenAPI, document.Paths["/api/tasks"])
+	}
+	if document.Servers != nil {
+		t.Errorf("Expected no servers at the top level, got %v", document.Servers)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/openapi.json", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestOpenAPIHandlerBasePath(t *testing.T) {
+	s := NewServer(Deps{Config: Config{BasePath: "/w/family"}})
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if !strings.Contains(rr.Body.String(), `"servers":[{"url":"/w/family"}]`) {
+		t.Errorf("Expected the workspace path as the server, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/docs", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "swagger-ui-bundle.js") || !strings.Contains(rr.Body.String(), `data-url="/w/family/api/openapi.json"`) {
+		t.Errorf("Expected the Swagger UI page for the workspace, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// パスの解析に失敗した理由
+// errPathNotFound: 形式が違う（セグメントが多い・少ない・空、アクションが違うなど）
+// errInvalidID: 形式は合っているが ID が正の整数ではない
+var (
+	errPathNotFound = errors.New("path not found")
+	errInvalidID    = errors.New("invalid id")
+)
+
+// maxIDDigits は ID として受け付ける最大の桁数です（int のオーバーフローを防ぎます）
+const maxIDDigits = 18
+
+// splitPath は prefix に続くパスを "/" で区切って返します
+// prefix で始まらない場合や空のセグメント（"//" や末尾の "/"）を含む場合は false を返します
+func splitPath(path, prefix string) ([]string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return nil, false
+	}
+	rest := path[len(prefix):]
+	if rest == "" {
+		return nil, false
+	}
+	segments := strings.Split(rest, "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+// parseID は prefix に続く "{id}"（action が空の場合）または "{id}/{action}" を解析して ID を返します
+// action は "timer/start" のように複数のセグメントでも構いません
+func parseID(path, prefix, action string) (int, error) {
+	segments, ok := splitPath(path, prefix)
+	if !ok {
+		return 0, errPathNotFound
+	}
+	if action == "" && len(segments) != 1 {
+		return 0, errPathNotFound
+	}
+	if action != "" && (len(segments) < 2 || strings.Join(segments[1:], "/") != action) {
+		return 0, errPathNotFound
+	}
+	return parsePositiveInt(segments[0])
+}
+
+// parseSubID は prefix に続く "{id}/{action}/{subID}" を解析して2つの ID を返します
+// （"/api/tasks/1/time-entries/2" の 1 と 2 など）
+func parseSubID(path, prefix, action string) (int, int, error) {
+	segments, ok := splitPath(path, prefix)
+	if !ok || len(segments) != 3 || segments[1] != action {
+		return 0, 0, errPathNotFound
+	}
+	id, err := parsePositiveInt(segments[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	subID, err := parsePositiveInt(segments[2])
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, subID, nil
+}
+
+// parsePositiveInt は数字だけからなる正の整数を解析します
+// strconv.Atoi と違い、符号（"+1", "-1"）や先頭の 0（"01"）、大きすぎる値は受け付けません
+func parsePositiveInt(s string) (int, error) {
+	if s == "" || len(s) > maxIDDigits || s[0] == '0' {
+		return 0, errInvalidID
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, errInvalidID
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, errInvalidID
+	}
+	return n, nil
+}
+
+// pathAction は prefix に続くパスの2番目のセグメント（"{id}/toggle" の "toggle"）を返します
+// "{id}" だけなら空文字を、それ以外の形式なら false を返します
+func pathAction(path, prefix string) (string, bool) {
+	segments, ok := splitPath(path, prefix)
+	if !ok || len(segments) > 2 {
+		return "", false
+	}
+	if len(segments) == 1 {
+		return "", true
+	}
+	return segments[1], true
+}
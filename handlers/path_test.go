@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	testCases := []struct {
+		path   string
+		action string
+		id     int
+		err    error
+	}{
+		{"/api/tasks/1", "", 1, nil},
+		{"/api/tasks/123/toggle", "toggle", 123, nil},
+		{"/api/tasks/abc", "", 0, errInvalidID},
+		{"/api/tasks/abc/toggle", "toggle", 0, errInvalidID},
+		{"/api/tasks/-1", "", 0, errInvalidID},
+		{"/api/tasks/+1", "", 0, errInvalidID},
+		{"/api/tasks/0", "", 0, errInvalidID},
+		{"/api/tasks/01", "", 0, errInvalidID},
+		{"/api/tasks/99999999999999999999", "", 0, errInvalidID},
+		{"/api/tasks/", "", 0, errPathNotFound},
+		{"/api/tasks/1/", "", 0, errPathNotFound},
+		{"/api/tasks//toggle", "toggle", 0, errPathNotFound},
+		{"/api/tasks/1/toggle", "", 0, errPathNotFound},
+		{"/api/tasks/1", "toggle", 0, errPathNotFound},
+		{"/api/tasks/1/done", "toggle", 0, errPathNotFound},
+		{"/api/tasks/abc/toggle/x", "toggle", 0, errPathNotFound},
+		{"/api/tasks/7/timer/start", "timer/start", 7, nil},
+		{"/api/tasks/7/timer", "timer/start", 0, errPathNotFound},
+		{"/api/tasks/7/timer/stop", "timer/start", 0, errPathNotFound},
+		{"/api/t", "", 0, errPathNotFound},
+		{"", "toggle", 0, errPathNotFound},
+	}
+
+	for _, tc := range testCases {
+		id, err := parseID(tc.path, "/api/tasks/", tc.action)
+		if id != tc.id || !errors.Is(err, tc.err) {
+			t.Errorf("parseID(%q, %q) = %d, %v; expected %d, %v", tc.path, tc.action, id, err, tc.id, tc.err)
+		}
+	}
+}
+
+func TestParseSubID(t *testing.T) {
+	testCases := []struct {
+		path  string
+		id    int
+		subID int
+		err   error
+	}{
+		{"/api/tasks/1/time-entries/2", 1, 2, nil},
+		{"/api/tasks/abc/time-entries/2", 0, 0, errInvalidID},
+		{"/api/tasks/1/time-entries/0", 0, 0, errInvalidID},
+		{"/api/tasks/1/time-entries", 0, 0, errPathNotFound},
+		{"/api/tasks/1/time-entries/2/x", 0, 0, errPathNotFound},
+		{"/api/tasks/1/other/2", 0, 0, errPathNotFound},
+		{"/api/tasks/1/time-entries/", 0, 0, errPathNotFound},
+	}
+
+	for _, tc := range testCases {
+		id, subID, err := parseSubID(tc.path, "/api/tasks/", "time-entries")
+		if id != tc.id || subID != tc.subID || !errors.Is(err, tc.err) {
+			t.Errorf("parseSubID(%q) = %d, %d, %v; expected %d, %d, %v", tc.path, id, subID, err, tc.id, tc.subID, tc.err)
+		}
+	}
+}
+
+func TestPathAction(t *testing.T) {
+	testCases := []struct {
+		path   string
+		action string
+		ok     bool
+	}{
+		{"/api/tasks/1", "", true},
+		{"/api/tasks/1/toggle", "toggle", true},
+		{"/api/tasks/abc/toggle", "toggle", true},
+		{"/api/tasks/1/toggle/x", "", false},
+		{"/api/tasks/", "", false},
+		{"/api/tasks/1/", "", false},
+	}
+
+	for _, tc := range testCases {
+		action, ok := pathAction(tc.path, "/api/tasks/")
+		if action != tc.action || ok != tc.ok {
+			t.Errorf("pathAction(%q) = %q, %v; expected %q, %v", tc.path, action, ok, tc.action, tc.ok)
+		}
+	}
+}
+
+// ハンドラを直接呼び出しても、短いパスや余分なセグメントで panic しないこと
+func TestHandlersRejectMalformedPaths(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	s.store.AddTask(ctx, "Task")
+
+	testCases := []struct {
+		handler http.HandlerFunc
+		method  string
+		path    string
+		status  int
+	}{
+		{s.ToggleTaskHandler, "PUT", "/", http.StatusNotFound},
+		{s.ToggleTaskHandler, "PUT", "/api/tasks/1/toggle/x", http.StatusNotFound},
+		{s.ToggleTaskHandler, "PUT", "/api/tasks/1", http.StatusNotFound},
+		{s.DeleteTaskHandler, "DELETE", "/api", http.StatusNotFound},
+		{s.DeleteTaskHandler, "DELETE", "/api/tasks/1/toggle", http.StatusNotFound},
+		{s.DeleteWebhookHandler, "DELETE", "/api/webhooks/", http.StatusNotFound},
+		{s.DeleteWebhookHandler, "DELETE", "/api/webhooks/-1", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		req := &http.Request{Method: tc.method, URL: &url.URL{Path: tc.path}, Header: http.Header{}}
+		rr := httptest.NewRecorder()
+		tc.handler(rr, req)
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected status code %d, got %d", tc.method, tc.path, tc.status, rr.Code)
+		}
+	}
+
+	if tasks := s.store.GetTasks(ctx); len(tasks) != 1 || tasks[0].Completed {
+		t.Errorf("Expected malformed requests to leave the task untouched, got %+v", tasks)
+	}
+}
+
+func TestServerRoutesMalformedTaskPaths(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	s.store.AddTask(ctx, "Task")
+
+	for _, path := range []string{"/api/tasks/1/toggle/x", "/api/tasks/abc/toggle/x", "/api/tasks/1/unknown", "/api/tasks/1/"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("PUT", path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("PUT %s: expected status code %d, got %d", path, http.StatusNotFound, rr.Code)
+		}
+	}
+}
+
+func FuzzParseID(f *testing.F) {
+	for _, seed := range []string{"/api/tasks/1", "/api/tasks/1/toggle", "/api/tasks/abc/toggle/x", "/api/t", "/api/tasks//", "/api/tasks/-0", "/api/tasks/٣"} {
+		f.Add(seed, "toggle")
+		f.Add(seed, "")
+	}
+
+	f.Fuzz(func(t *testing.T, path, action string) {
+		id, err := parseID(path, "/api/tasks/", action)
+		if err != nil {
+			if id != 0 {
+				t.Errorf("parseID(%q, %q) returned id %d with error %v", path, action, id, err)
+			}
+			return
+		}
+		if id <= 0 {
+			t.Fatalf("parseID(%q, %q) returned non-positive id %d", path, action, id)
+		}
+
+		// 受け付けたパスは ID と action から組み立て直したものと一致します
+		rebuilt := "/api/tasks/" + strconv.Itoa(id)
+		if action != "" {
+			rebuilt += "/" + action
+		}
+		if rebuilt != path {
+			t.Errorf("parseID(%q, %q) accepted a non-canonical path (id %d)", path, action, id)
+		}
+	})
+}
+
+func FuzzServerRouting(f *testing.F) {
+	ctx := context.Background()
+	for _, seed := range []string{"/", "/api/tasks/1/toggle", "/api/tasks/abc/toggle/x", "/api/tasks/", "/api/webhooks/1", "/api/admin/backups/x/restore", "/api/tasks/%2F"} {
+		f.Add("PUT", seed)
+		f.Add("DELETE", seed)
+	}
+
+	s := newTestServer()
+	s.store.AddTask(ctx, "Task")
+
+	f.Fuzz(func(t *testing.T, method, path string) {
+		if method == "" || strings.ContainsAny(method, " \r\n") {
+			return
+		}
+		req := &http.Request{Method: method, URL: &url.URL{Path: path}, Header: http.Header{}, Body: http.NoBody}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code >= http.StatusInternalServerError {
+			t.Errorf("%s %q: unexpected status code %d", method, path, rr.Code)
+		}
+	})
+}
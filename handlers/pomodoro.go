@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"todo-app/models"
+	"todo-app/pomodoro"
+)
+
+// findTask は指定IDのタスクを返します。見つからなければ ErrTaskNotFound を返します
+func (s *Server) findTask(ctx context.Context, id int) (models.Task, error) {
+	return findIn(s.store.GetTasks(ctx), id)
+}
+
+// findIn は tasks から id のタスクを探します
+func findIn(tasks []models.Task, id int) (models.Task, error) {
+	for _, task := range tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return models.Task{}, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+}
+
+// TaskPomodorosHandler はタスクのポモドーロを扱います
+// GET /api/tasks/{id}/pomodoros: セッションの履歴
+// POST /api/tasks/{id}/pomodoros: セッションの開始。{"minutes": 25} で長さを指定できます（本文は省略可）
+func (s *Server) TaskPomodorosHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "pomodoros")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		sessions := s.pomodoros.ForTask(id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"sessions":  sessions,
+			"completed": pomodoro.Completed(sessions),
+		})
+		return
+	}
+
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	session, err := s.pomodoros.Start(id, req.Minutes)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeSession(w, session)
+}
+
+// PomodoroActionHandler は作業中のセッションを中断（/stop）または完了（/complete）します
+func (s *Server) PomodoroActionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	action, ok := pathAction(r.URL.Path, "/api/pomodoros/")
+	if !ok || (action != "stop" && action != "complete") {
+		s.writeError(w, r, errPathNotFound)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/pomodoros/", action)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	finish := s.pomodoros.Stop
+	if action == "complete" {
+		finish = s.pomodoros.Complete
+	}
+	session, err := finish(id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeSession(w, session)
+}
+
+// DailyPomodorosHandler は1日に開始したセッションと完了した数を返します
+// ?date=2025-03-10&tz=Asia/Tokyo のように日付とタイムゾーンを指定できます（省略時は今日）
+func (s *Server) DailyPomodorosHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	loc, err := parseTimeZone(query.Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	date := time.Now().In(loc)
+	if value := query.Get("date"); value != "" {
+		date, err = time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: invalid date %q (YYYY-MM-DD)", models.ErrValidation, value))
+			return
+		}
+	}
+
+	sessions := s.pomodoros.OnDate(date)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"date":      date.Format("2006-01-02"),
+		"sessions":  sessions,
+		"completed": pomodoro.Completed(sessions),
+	})
+}
+
+// writeSession はセッションを1件返します
+func (s *Server) writeSession(w http.ResponseWriter, session pomodoro.Session) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"session": session,
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/pomodoro"
+)
+
+func decodeSession(t *testing.T, rr *httptest.ResponseRecorder) pomodoro.Session {
+	t.Helper()
+	var response struct {
+		Success bool             `json:"success"`
+		Session pomodoro.Session `json:"session"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || !response.Success {
+		t.Fatalf("Unexpected session response %d: %s", rr.Code, rr.Body.String())
+	}
+	return response.Session
+}
+
+func TestPomodoroHandlers(t *testing.T) {
+	s := newTestServer()
+	task, _ := s.Store().AddTask(context.Background(), "Focus")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/pomodoros", strings.NewReader(`{"minutes": 50}`)))
+	session := decodeSession(t, rr)
+	if session.TaskID != task.ID || session.Minutes != 50 || session.Status != pomodoro.StatusRunning {
+		t.Errorf("Unexpected started session: %+v", session)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/pomodoros", nil))
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/pomodoros/1/complete", nil))
+	if completed := decodeSession(t, rr); completed.Status != pomodoro.StatusCompleted || completed.EndedAt == nil {
+		t.Errorf("Unexpected completed session: %+v", completed)
+	}
+
+	// 本文を省略すると既定の長さで開始します
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/pomodoros", nil))
+	if second := decodeSession(t, rr); second.Minutes != pomodoro.DefaultMinutes {
+		t.Errorf("Expected the default length, got %+v", second)
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/pomodoros/2/stop", nil))
+	if stopped := decodeSession(t, rr); stopped.Status != pomodoro.StatusStopped {
+		t.Errorf("Unexpected stopped session: %+v", stopped)
+	}
+
+	for _, path := range []string{"/api/tasks/1/pomodoros", "/api/pomodoros?tz=UTC"} {
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var response struct {
+			Success   bool               `json:"success"`
+			Sessions  []pomodoro.Session `json:"sessions"`
+			Completed int                `json:"completed"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", path, err)
+		}
+		if !response.Success || len(response.Sessions) != 2 || response.Completed != 1 {
+			t.Errorf("%s: expected 2 sessions and 1 completed, got %s", path, rr.Body.String())
+		}
+	}
+}
+
+func TestPomodoroHandlerErrors(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Focus")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/tasks/99/pomodoros", "", http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/99/pomodoros", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/1/pomodoros", "{", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/pomodoros", `{"minutes": 500}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/tasks/1/pomodoros", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/api/pomodoros/99/stop", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/pomodoros/1/pause", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/pomodoros/abc/stop", "", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/pomodoros/1/stop", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/api/pomodoros?date=today", "", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/pomodoros?tz=Mars/Olympus", "", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/pomodoros", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assertErrorResponse(t, rr, tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"todo-app/models"
+)
+
+// PriorityHandler はタスクの優先度を変更し、変更後のタスクを返します（PATCH /api/tasks/{id}/priority）
+// 本文は {"priority": "high"} で、空文字を送ると優先度を外します
+func (s *Server) PriorityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "priority")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Priority models.Priority `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	// 定義されていない優先度はモデルが ErrValidation として返します
+	if err := s.store.SetPriority(r.Context(), id, req.Priority); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
+
+// parsePriorities は ?priority=high,medium のようにカンマで区切った優先度を読み取ります
+// "none" は優先度を設定していないタスクを表します
+func parsePriorities(value string) (map[models.Priority]bool, error) {
+	priorities := map[models.Priority]bool{}
+	for _, raw := range strings.Split(value, ",") {
+		priority := models.Priority(strings.ToLower(strings.TrimSpace(raw)))
+		if priority == "none" {
+			priorities[""] = true
+			continue
+		}
+		if priority == "" || priority.Validate() != nil {
+			return nil, fmt.Errorf("%w: priority must be low, medium, high or none, got %q", models.ErrValidation, raw)
+		}
+		priorities[priority] = true
+	}
+	return priorities, nil
+}
+
+// filterByPriority は priorities のいずれかの優先度を持つタスクだけを返します
+func filterByPriority(tasks []models.Task, priorities map[models.Priority]bool) []models.Task {
+	filtered := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if priorities[task.Priority] {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
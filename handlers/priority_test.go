@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestPriorityHandler(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Important")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PATCH", "/api/tasks/1/priority", strings.NewReader(`{"priority": "high"}`)))
+	var response struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || response.Task.Priority != models.PriorityHigh {
+		t.Fatalf("Expected the task with a high priority, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PATCH", "/api/tasks/1/priority", strings.NewReader(`{"priority": ""}`)))
+	if tasks := s.Store().GetTasks(context.Background()); rr.Code != http.StatusOK || tasks[0].Priority != "" {
+		t.Errorf("Expected an empty priority to clear it, got %d %+v", rr.Code, tasks[0])
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"PATCH", "/api/tasks/1/priority", `{"priority": "urgent"}`, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks/1/priority", `{}`, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks/99/priority", `{"priority": "low"}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/tasks/1/priority", `{"priority": "low"}`, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestGetTasksHandlerPriorityFilter(t *testing.T) {
+	s := newTestServer()
+	for _, body := range []string{
+		`{"title": "Low", "priority": "low"}`,
+		`{"title": "High", "priority": "high"}`,
+		`{"title": "None"}`,
+		`{"title": "Also high", "priority": "high"}`,
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Failed to add %s: %d %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	testCases := map[string]string{
+		"high":      "High,Also high",
+		"HIGH,none": "High,None,Also high",
+		"low":       "Low",
+	}
+	for priority, expected := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks?priority="+priority, nil))
+		var tasks []models.Task
+		json.Unmarshal(rr.Body.Bytes(), &tasks)
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		if got := strings.Join(titles, ","); got != expected {
+			t.Errorf("priority=%s: expected %s, got %s", priority, expected, got)
+		}
+	}
+
+	for _, path := range []string{"/api/tasks?priority=urgent", "/api/tasks?priority=high,"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Bad", "priority": "urgent"}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies は X-Forwarded-For と X-Real-IP を信頼するリバースプロキシです
+// Prefixes: プロキシの IP アドレスの範囲
+// Unix: Unix ドメインソケットで受けた接続（接続元の IP アドレスがありません）もプロキシからのものとして信頼します
+type TrustedProxies struct {
+	Prefixes []netip.Prefix
+	Unix     bool
+}
+
+// ParseTrustedProxies は "10.0.0.0/8, 192.0.2.1, unix" のようなカンマ区切りの CIDR か IP アドレス（unix は Unix ドメインソケット）を読み取ります
+func ParseTrustedProxies(raw string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case item == "unix":
+			proxies.Unix = true
+		case strings.Contains(item, "/"):
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return TrustedProxies{}, fmt.Errorf("trusted proxy %q is not a CIDR: %v", item, err)
+			}
+			proxies.Prefixes = append(proxies.Prefixes, prefix.Masked())
+		default:
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return TrustedProxies{}, fmt.Errorf("trusted proxy %q is not an IP address", item)
+			}
+			addr = addr.Unmap()
+			proxies.Prefixes = append(proxies.Prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return proxies, nil
+}
+
+// contains は addr が信頼するプロキシのアドレスかどうかを返します
+func (p TrustedProxies) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.Prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// trusts は接続元 remoteAddr（http.Request.RemoteAddr）が信頼するプロキシかどうかを返します
+func (p TrustedProxies) trusts(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		// IP アドレスのない接続元は Unix ドメインソケットです
+		return p.Unix
+	}
+	return p.contains(addr)
+}
+
+// requestIP はリクエストを送ったクライアントの IP アドレスを返します
+// 接続元が信頼するプロキシ（Config.TrustedProxies）なら、X-Forwarded-For を後ろからたどって最初の信頼しないアドレスを、
+// X-Forwarded-For がなければ X-Real-IP を使います。それ以外は偽装できるため接続元のアドレスを使います（clientIP）
+func (s *Server) requestIP(r *http.Request) string {
+	remote := clientIP(r)
+	proxies := s.config.TrustedProxies
+	if !proxies.trusts(r.RemoteAddr) {
+		return remote
+	}
+
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap().String()
+			if !proxies.contains(addr) {
+				break
+			}
+		}
+		return client
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+	return remote
+}
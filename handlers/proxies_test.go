@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.0.2.1 ,unix,::ffff:198.51.100.7, ")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	if len(proxies.Prefixes) != 3 || !proxies.Unix {
+		t.Errorf("Unexpected proxies: %+v", proxies)
+	}
+	for _, addr := range []string{"10.1.2.3:80", "192.0.2.1:80", "198.51.100.7:80", "@"} {
+		if !proxies.trusts(addr) {
+			t.Errorf("Expected %s to be trusted", addr)
+		}
+	}
+	if proxies.trusts("192.0.2.2:80") {
+		t.Error("Expected an address outside the proxies not to be trusted")
+	}
+
+	for _, raw := range []string{"10.0.0.0/33", "proxy.example.com"} {
+		if _, err := ParseTrustedProxies(raw); err == nil {
+			t.Errorf("Expected an error for %q", raw)
+		}
+	}
+	if proxies, err := ParseTrustedProxies(""); err != nil || proxies.trusts("10.0.0.1:80") || proxies.trusts("@") {
+		t.Errorf("Expected no trusted proxies by default, got %+v %v", proxies, err)
+	}
+}
+
+func TestRequestIP(t *testing.T) {
+	proxies, _ := ParseTrustedProxies("10.0.0.0/8, unix")
+	s := NewServer(Deps{Config: Config{TrustedProxies: proxies}})
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"direct", "203.0.113.5:1234", []string{"198.51.100.1"}, "", "203.0.113.5"},
+		{"proxy", "10.0.0.1:1234", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"spoofed hop", "10.0.0.1:1234", []string{"192.0.2.66, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chained proxies", "10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2", "10.0.0.3"}, "", "198.51.100.1"},
+		{"garbage hop", "10.0.0.1:1234", []string{"not-an-ip, 198.51.100.1"}, "", "198.51.100.1"},
+		{"real ip", "10.0.0.1:1234", nil, "198.51.100.2", "198.51.100.2"},
+		{"unix socket", "@", []string{"198.51.100.3"}, "", "198.51.100.3"},
+		{"proxy without headers", "10.0.0.1:1234", nil, "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if got := s.requestIP(req); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"todo-app/qrcode"
+)
+
+// qrScale は QR コードの1モジュールのピクセル数です
+const qrScale = 8
+
+// TaskQRCodeHandler はタスクの短いリンクを指す QR コードを PNG で返します（短いリンクがなければ発行します）
+func (s *Server) TaskQRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "qr.png")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	code, err := s.shortlinks.CodeFor(id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeQRCode(w, r, s.absoluteURL(r, "/t/"+code))
+}
+
+// writeQRCode は url を指す QR コードを PNG で書き出します
+func (s *Server) writeQRCode(w http.ResponseWriter, r *http.Request, url string) {
+	var buf bytes.Buffer
+	if err := qrcode.WritePNG(&buf, url, qrScale); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// absoluteURL は path（Config.BasePath を除いたパス）の絶対 URL を返します
+// Config.PublicURL があればそれを、なければリクエストのホストを使います
+func (s *Server) absoluteURL(r *http.Request, path string) string {
+	path = s.config.BasePath + path
+	if s.config.PublicURL != "" {
+		return strings.TrimRight(s.config.PublicURL, "/") + path
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
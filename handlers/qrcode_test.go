@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTaskQRCodeHandler(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Pin me on the fridge")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/1/qr.png", nil))
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected response: %d %v", rr.Code, rr.Header())
+	}
+	img, err := png.Decode(rr.Body)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	// "http://example.com/t/xxxxxxx"（28 バイト）は型番 3（29 モジュール）に収まります
+	if width := img.Bounds().Dx(); width != (29+8)*qrScale {
+		t.Errorf("Expected width %d, got %d", (29+8)*qrScale, width)
+	}
+}
+
+func TestTaskQRCodeHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/tasks/99/qr.png", http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/abc/qr.png", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/qr.png", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/share/unknown/qr.png", http.StatusNotFound, "not_found"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestShareQRCode(t *testing.T) {
+	s := newTestServer()
+	list, _ := s.lists.Create("買い物")
+	link, _ := s.shares.Create(list.ID, "Fridge")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/share/"+link.Token+"/qr.png", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected response: %d %v", rr.Code, rr.Header())
+	}
+	if _, err := png.Decode(rr.Body); err != nil {
+		t.Errorf("Failed to decode PNG: %v", err)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "todo.local:8080"
+
+	s := newTestServer()
+	if got := s.absoluteURL(req, "/t/abc"); got != "http://todo.local:8080/t/abc" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+	req.TLS = &tls.ConnectionState{}
+	if got := s.absoluteURL(req, "/t/abc"); got != "https://todo.local:8080/t/abc" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+
+	s = NewServer(Deps{Config: Config{PublicURL: "https://todo.example.com/"}})
+	if got := s.absoluteURL(req, "/t/abc"); got != "https://todo.example.com/t/abc" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+}
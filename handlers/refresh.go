@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"todo-app/accounts"
+)
+
+// RefreshCookie は「ログインしたままにする」端末の refresh token を入れる Cookie の名前です
+const RefreshCookie = "todo_refresh"
+
+// setRefreshCookie は refresh token をセッションの Cookie と同じ属性の Cookie に入れます（token が空なら消します）
+func (s *Server) setRefreshCookie(w http.ResponseWriter, r *http.Request, token string, expires time.Time) {
+	s.setAuthCookie(w, r, RefreshCookie, token, expires)
+}
+
+// resumeSession は refresh token の Cookie を入れ替え、その端末の新しいセッションを始めてユーザーを返します
+// セッションが期限切れになったか、サーバを再起動してセッションが消えたときに、もう一度ログインしなくて済むようにします
+// 使えないトークンなら false を返します。入れ替えた後の古いトークンが使われたときは、その端末を取り消して監査のログに記録します
+func (s *Server) resumeSession(w http.ResponseWriter, r *http.Request) (accounts.User, bool) {
+	cookie, err := r.Cookie(RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		return accounts.User{}, false
+	}
+	userID, device, refresh, refreshExpires, err := s.sessions.RefreshTokens.Rotate(cookie.Value, r.UserAgent())
+	switch {
+	case errors.Is(err, accounts.ErrRefreshReused):
+		s.audit(r, slog.LevelWarn, "refresh token reused, revoked the device")
+		s.setRefreshCookie(w, r, "", time.Time{})
+		return accounts.User{}, false
+	case errors.Is(err, accounts.ErrInvalidRefresh):
+		// 同じブラウザのほかのタブが先に入れ替えた Cookie を消さないよう、ここでは Cookie をそのままにします
+		return accounts.User{}, false
+	case err != nil:
+		s.logger.ErrorContext(r.Context(), "failed to rotate refresh token", "err", err)
+		return accounts.User{}, false
+	}
+	s.setRefreshCookie(w, r, refresh, refreshExpires)
+
+	user, ok := s.accounts.Lookup(userID)
+	if !ok || user.Disabled {
+		return accounts.User{}, false
+	}
+	token, expires, err := s.sessions.CreateOnDevice(user.ID, device, s.requestIP(r), r.UserAgent())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to create session", "err", err)
+		return accounts.User{}, false
+	}
+	s.setSessionCookie(w, r, token, expires)
+	s.audit(r, slog.LevelInfo, "resumed session", "user_id", user.ID, "device_id", device)
+	return user, true
+}
+
+// RefreshHandler は refresh token の Cookie で新しいセッションを始めます（POST）。使えなければ 401 を返します
+// 画面と API はセッションが切れていても自動で始め直すため、ふつうは呼ぶ必要はありません
+func (s *Server) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	user, ok := s.resumeSession(w, r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"user":    user,
+	})
+}
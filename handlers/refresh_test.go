@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+)
+
+// cookieNamed は応答で発行された name の Cookie を返します
+func cookieNamed(t *testing.T, rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	t.Fatalf("Expected a %s cookie, got %v", name, rr.Header()["Set-Cookie"])
+	return nil
+}
+
+func TestRememberMe(t *testing.T) {
+	s := newTestAccountsServer(t)
+	authRequest(s, "register", "alice", "correct horse")
+
+	login := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"name": "alice", "password": "correct horse", "remember": true}`))
+	login.Header.Set("User-Agent", "Firefox")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, login)
+	cookieNamed(t, rr, SessionCookie)
+	refresh := cookieNamed(t, rr, RefreshCookie)
+	if !refresh.HttpOnly || refresh.Path != "/" {
+		t.Errorf("Expected an HttpOnly refresh cookie, got %+v", refresh)
+	}
+
+	// サーバを再起動してセッションが消えても、refresh token で始め直して API を使えます
+	restarted := accounts.NewSessions()
+	restarted.RefreshTokens = s.sessions.RefreshTokens
+	s.sessions = restarted
+	request := func(refresh *http.Cookie, userAgent string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/tasks", nil)
+		req.AddCookie(refresh)
+		req.Header.Set("User-Agent", userAgent)
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+	assertErrorResponse(t, request(refresh, "Chrome"), http.StatusUnauthorized, "unauthorized")
+	rr = request(refresh, "Firefox")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the session to be resumed, got %d %s", rr.Code, rr.Body.String())
+	}
+	rotated := cookieNamed(t, rr, RefreshCookie)
+	cookieNamed(t, rr, SessionCookie)
+	if rotated.Value == refresh.Value {
+		t.Error("Expected the refresh token to be rotated")
+	}
+
+	// ログアウトすると、その端末の refresh token も使えなくなります
+	logout := httptest.NewRequest("POST", "/api/auth/logout", nil)
+	logout.AddCookie(rotated)
+	s.ServeHTTP(httptest.NewRecorder(), logout)
+	assertErrorResponse(t, request(rotated, "Firefox"), http.StatusUnauthorized, "unauthorized")
+}
+
+func TestRefreshHandler(t *testing.T) {
+	s := newTestAccountsServer(t)
+	body := `{"name": "alice", "password": "correct horse", "remember": true}`
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/register", strings.NewReader(body)))
+	refresh := cookieNamed(t, rr, RefreshCookie)
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/refresh", nil))
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+
+	req := httptest.NewRequest("POST", "/api/auth/refresh", nil)
+	req.AddCookie(refresh)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"alice"`) {
+		t.Fatalf("Expected the session to be refreshed, got %d %s", rr.Code, rr.Body.String())
+	}
+	session := cookieNamed(t, rr, SessionCookie)
+
+	// すべての端末からログアウトすると refresh token も取り消します
+	req = httptest.NewRequest("DELETE", "/api/sessions", nil)
+	req.AddCookie(session)
+	s.ServeHTTP(httptest.NewRecorder(), req)
+	req = httptest.NewRequest("POST", "/api/auth/refresh", nil)
+	req.AddCookie(cookieNamed(t, rr, RefreshCookie))
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+}
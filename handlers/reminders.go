@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"todo-app/models"
+	"todo-app/reminders"
+)
+
+// ReminderHandler はタスクのリマインダーを扱い、変更後のタスクを返します
+// PUT /api/tasks/{id}/reminder: リクエストのJSON {"remind_at": "2025-03-10T09:00:00+09:00"} の日時に通知するよう設定します（設定し直すと、通知済みでももう一度通知します）
+// DELETE /api/tasks/{id}/reminder: リマインダーを止めます（通知する前なら取り消し、通知した後なら通知済みの表示を消します）
+func (s *Server) ReminderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "reminder")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var remindAt *time.Time
+	if r.Method == http.MethodPut {
+		var req struct {
+			RemindAt *time.Time `json:"remind_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		if req.RemindAt == nil {
+			s.writeError(w, r, fmt.Errorf("%w: remind_at is required", models.ErrValidation))
+			return
+		}
+		remindAt = req.RemindAt
+	}
+
+	if err := s.store.SetReminder(r.Context(), id, remindAt); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
+
+// SnoozeReminderHandler はリクエストのJSON {"minutes": 30} の時間だけ後にリマインダーを通知し直し、変更後のタスクを返します（POST /api/tasks/{id}/reminder/snooze）
+// 本文を省略するか 0 なら reminders.DefaultSnooze 後にします。リマインダーのないタスクは 409 を返します
+func (s *Server) SnoozeReminderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "reminder/snooze")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	snooze := time.Duration(req.Minutes) * time.Minute
+	if snooze < 0 || snooze > reminders.MaxSnooze {
+		s.writeError(w, r, fmt.Errorf("%w: minutes must be 0 to %d", models.ErrValidation, int(reminders.MaxSnooze/time.Minute)))
+		return
+	}
+	if snooze == 0 {
+		snooze = reminders.DefaultSnooze
+	}
+
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if task.RemindAt == nil {
+		s.writeError(w, r, fmt.Errorf("%w: task %d has no reminder to snooze", models.ErrConflict, id))
+		return
+	}
+	remindAt := time.Now().Add(snooze)
+	if err := s.store.SetReminder(r.Context(), id, &remindAt); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err = s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
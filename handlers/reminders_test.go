@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestReminderHandlers(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/tasks", `{"title": "Call the dentist"}`)
+
+	decode := func(rr *httptest.ResponseRecorder) models.Task {
+		t.Helper()
+		var response struct {
+			Success bool        `json:"success"`
+			Task    models.Task `json:"task"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || rr.Code != http.StatusOK || !response.Success {
+			t.Fatalf("Expected a successful response, got %d %s", rr.Code, rr.Body.String())
+		}
+		return response.Task
+	}
+
+	task := decode(listRequest(s, "PUT", "/api/tasks/1/reminder", `{"remind_at": "2025-03-10T09:00:00+09:00"}`))
+	if task.RemindAt == nil || !task.RemindAt.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) || task.RemindedAt != nil {
+		t.Errorf("Expected the reminder to be set, got %+v", task)
+	}
+	if err := s.Store().FireReminder(context.Background(), 1, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Expected the reminder to fire: %v", err)
+	}
+
+	before := time.Now()
+	task = decode(listRequest(s, "POST", "/api/tasks/1/reminder/snooze", `{"minutes": 30}`))
+	if task.RemindedAt != nil || task.RemindAt == nil || task.RemindAt.Before(before.Add(30*time.Minute)) || task.RemindAt.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("Expected the reminder to be snoozed for 30 minutes, got %+v", task)
+	}
+	before = time.Now()
+	task = decode(listRequest(s, "POST", "/api/tasks/1/reminder/snooze", ``))
+	if task.RemindAt == nil || task.RemindAt.Before(before.Add(10*time.Minute)) {
+		t.Errorf("Expected the default snooze of 10 minutes, got %+v", task)
+	}
+
+	task = decode(listRequest(s, "DELETE", "/api/tasks/1/reminder", ``))
+	if task.RemindAt != nil || task.RemindedAt != nil {
+		t.Errorf("Expected the reminder to be dismissed, got %+v", task)
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", "/api/tasks/1/reminder/snooze", `{}`, http.StatusConflict, "conflict"},
+		{"PUT", "/api/tasks/1/reminder", `{}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/reminder", `{"remind_at": "tomorrow"}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/reminder", ``, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/reminder/snooze", `{"minutes": -5}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/reminder/snooze", `{"minutes": 20000}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/9/reminder", `{"remind_at": "2025-03-10T09:00:00Z"}`, http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/1/reminder", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		assertErrorResponse(t, listRequest(s, tc.method, tc.path, tc.body), tc.status, tc.code)
+	}
+}
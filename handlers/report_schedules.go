@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo-app/reports"
+)
+
+// ReportSchedulesHandler は定期的なレポートのスケジュールの一覧（GET）と登録（POST）を扱います
+// POST は {"report": "completed_csv", "frequency": "weekly", "timezone": "Asia/Tokyo", "webhook": "https://..."} で、
+// 届け先は webhook か email のどちらかを指定します
+func (s *Server) ReportSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"schedules": s.reports.List(),
+		})
+	case http.MethodPost:
+		var req reports.Schedule
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		schedule, err := s.reports.Add(req)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		writeSchedule(w, schedule)
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// ReportScheduleHandler は1件のスケジュールを扱います
+// DELETE /api/reports/schedules/{id}: スケジュールを削除します
+// POST /api/reports/schedules/{id}/run: 直前の期間のレポートをすぐに作って届けます（届け先の確認用）。届けられなかったときは last_error に記録します
+func (s *Server) ReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	if action, ok := pathAction(r.URL.Path, "/api/reports/schedules/"); ok && action == "run" {
+		if r.Method != http.MethodPost {
+			s.writeError(w, r, errMethodNotAllowed)
+			return
+		}
+		id, err := parseID(r.URL.Path, "/api/reports/schedules/", "run")
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		schedule, err := s.reports.RunNow(r.Context(), id, s.store)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		writeSchedule(w, schedule)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/reports/schedules/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.reports.Delete(id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
+
+// writeSchedule は {"success": true, "schedule": {...}} を書き込みます
+func writeSchedule(w http.ResponseWriter, schedule reports.Schedule) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"schedule": schedule,
+	})
+}
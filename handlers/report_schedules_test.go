@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/models"
+	"todo-app/reports"
+)
+
+func TestReportSchedulesHandler(t *testing.T) {
+	received := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer hook.Close()
+	s := NewServer(Deps{Store: models.NewTodoApp(), Reports: reports.NewScheduler(nil, nil)})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/reports/schedules", strings.NewReader(`{"report": "completed_csv", "timezone": "UTC", "webhook": "`+hook.URL+`"}`)))
+	var added struct {
+		Success  bool             `json:"success"`
+		Schedule reports.Schedule `json:"schedule"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &added)
+	if rr.Code != http.StatusOK || !added.Success || added.Schedule.ID != 1 || added.Schedule.Frequency != reports.FrequencyWeekly {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/schedules", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"report":"completed_csv"`) {
+		t.Errorf("Unexpected list %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/reports/schedules/1/run", nil))
+	if rr.Code != http.StatusOK || received != 1 || !strings.Contains(rr.Body.String(), `"last_run"`) {
+		t.Errorf("Expected the report to be delivered, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/reports/schedules/1", nil))
+	if rr.Code != http.StatusOK || len(s.reports.List()) != 0 {
+		t.Errorf("Expected the schedule to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/reports/schedules", `{"report": "burndown", "webhook": "https://example.com"}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/reports/schedules", `{"report": "stale_summary", "email": "alice@example.com"}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/reports/schedules", `{"report": "stale_summary"}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/reports/schedules/1", ``, http.StatusNotFound, "not_found"},
+		{"POST", "/api/reports/schedules/1/run", ``, http.StatusNotFound, "not_found"},
+		{"GET", "/api/reports/schedules/1/run", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"DELETE", "/api/reports/schedules/x", ``, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/reports/schedules/1", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"PUT", "/api/reports/schedules", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ReportSchedulesHandler(rr, httptest.NewRequest("POST", "/api/reports/schedules", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/schedules", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected schedules to be unavailable without a Scheduler, got %d", rr.Code)
+	}
+}
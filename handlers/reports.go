@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-app/analytics"
+	"todo-app/models"
+)
+
+// EstimatesReportHandler は見積もりと記録した作業時間の比較をグループごとに返します
+// ?group=priority でグループ分けの項目を、?format=csv で CSV のダウンロードを指定できます
+func (s *Server) EstimatesReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	if _, err := analytics.ParseGroupBy(query.Get("group")); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		s.writeError(w, r, fmt.Errorf("%w: unknown format %q (json or csv)", models.ErrValidation, format))
+		return
+	}
+
+	report := analytics.CompareEstimates(s.store.GetTasks(r.Context()))
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todo-estimates-%s.csv"`, time.Now().Format("20060102")))
+		if err := report.WriteCSV(w); err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to write estimates report", "err", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// StaleReportHandler は指定した日数以上変更されていない未完了のタスクを、放置された期間とともに返します
+// ?olderThan=30d のように日（d）または週（w）で期間を指定できます（省略時は30日）
+func (s *Server) StaleReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	olderThan := r.URL.Query().Get("olderThan")
+	age, err := analytics.ParseAge(olderThan)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if olderThan == "" {
+		olderThan = "30d"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"older_than": olderThan,
+		"tasks":      analytics.FindStale(s.store.GetTasks(r.Context()), time.Now(), age),
+	})
+}
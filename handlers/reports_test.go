@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/analytics"
+	"todo-app/models"
+)
+
+func TestEstimatesReportHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	task, _ := s.Store().AddTask(ctx, "Estimated")
+	s.Store().AddTask(ctx, "Not estimated")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/tasks/1/estimate", strings.NewReader(`{"minutes": 30}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	s.Store().ToggleTask(ctx, task.ID)
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/estimates", nil))
+	var response struct {
+		Success bool                     `json:"success"`
+		Report  analytics.EstimateReport `json:"report"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	total := response.Report.Total
+	if !response.Success || total.Tasks != 1 || total.Completed != 1 || total.EstimatedSeconds != 1800 {
+		t.Errorf("Unexpected report: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/estimates?format=csv", nil))
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") ||
+		!strings.Contains(rr.Header().Get("Content-Disposition"), "attachment") ||
+		!strings.HasPrefix(rr.Body.String(), "priority,tasks,completed") {
+		t.Errorf("Unexpected CSV response: %v %s", rr.Header(), rr.Body.String())
+	}
+}
+
+func TestEstimatesReportHandlerErrors(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Task")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/reports/estimates?group=tag", "", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/reports/estimates?format=xml", "", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/reports/estimates", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"PUT", "/api/tasks/1/estimate", `{"minutes": -1}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/estimate", `{`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/99/estimate", `{"minutes": 5}`, http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/1/estimate", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assertErrorResponse(t, rr, tt.status, tt.code)
+		})
+	}
+}
+
+func TestStaleReportHandler(t *testing.T) {
+	old := time.Now().Add(-45 * 24 * time.Hour)
+	recent := time.Now().Add(-3 * 24 * time.Hour)
+	s := NewServer(Deps{Store: models.NewTodoAppFromTasks([]models.Task{
+		{ID: 1, Title: "Forgotten", CreatedAt: &old, UpdatedAt: &old},
+		{ID: 2, Title: "Recent", CreatedAt: &old, UpdatedAt: &recent},
+		{ID: 3, Title: "Done", Completed: true, CreatedAt: &old, UpdatedAt: &old},
+	})})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/stale", nil))
+
+	var response struct {
+		Success   bool                  `json:"success"`
+		OlderThan string                `json:"older_than"`
+		Tasks     []analytics.StaleTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !response.Success || response.OlderThan != "30d" || len(response.Tasks) != 1 ||
+		response.Tasks[0].Task.ID != 1 || response.Tasks[0].AgeDays != 45 {
+		t.Errorf("Unexpected stale report: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/stale?olderThan=1d", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.OlderThan != "1d" || len(response.Tasks) != 2 {
+		t.Errorf("Expected 2 stale tasks for 1d, got %s", rr.Body.String())
+	}
+}
+
+func TestStaleReportHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/reports/stale?olderThan=soon", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/reports/stale", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-app/models"
+	"todo-app/rules"
+)
+
+// RulesHandler は自動化ルールの一覧（GET）と登録（POST）を扱います
+func (s *Server) RulesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"rules":   s.rules.List(),
+		})
+	case http.MethodPost:
+		var req rules.Rule
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		if err := s.resolveRuleActions(r, req.Actions); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		rule, err := s.rules.Add(req)
+		if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: %v", models.ErrValidation, err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"rule":    rule,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// resolveRuleActions は登録するルールのアクションのうち、リクエストによって決まるものを確かめます
+// assign の "me" はログインしているユーザーの名前に置き換え、move_to_list の移動先のリストがあるかを確かめます
+func (s *Server) resolveRuleActions(r *http.Request, actions []rules.Action) error {
+	for i, action := range actions {
+		switch action.Type {
+		case "assign":
+			if action.Value != "me" {
+				continue
+			}
+			user, ok := contextUser(r)
+			if !ok {
+				return fmt.Errorf("%w: assign to me requires a signed-in user", models.ErrValidation)
+			}
+			actions[i].Value = user.Name
+		case "move_to_list":
+			id, err := strconv.Atoi(action.Value)
+			if err != nil || id == 0 {
+				continue
+			}
+			if _, err := s.lists.Get(id); err != nil {
+				return fmt.Errorf("%w: list %d does not exist", models.ErrValidation, id)
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteRuleHandler は URL からIDを取り出し、その自動化ルールを削除します
+func (s *Server) DeleteRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/rules/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if !s.rules.Delete(id) {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errRuleNotFound, id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/rules"
+)
+
+func TestRulesHandler(t *testing.T) {
+	s := newTestServer()
+
+	body := `{"name": "Bugs first", "trigger": "task.created",
+		"conditions": [{"field": "title", "op": "contains", "value": "bug"}],
+		"actions": [{"type": "set_priority", "value": "high"}]}`
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/rules", strings.NewReader(body)))
+
+	var added struct {
+		Success bool       `json:"success"`
+		Rule    rules.Rule `json:"rule"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &added); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !added.Success || added.Rule.ID != 1 || len(added.Rule.Conditions) != 1 {
+		t.Errorf("Unexpected response: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/rules", nil))
+	var listed struct {
+		Success bool         `json:"success"`
+		Rules   []rules.Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil || !listed.Success || len(listed.Rules) != 1 || listed.Rules[0].Name != "Bugs first" {
+		t.Errorf("Unexpected rules: %s", rr.Body.String())
+	}
+}
+
+func TestRulesHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", `{invalid`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"trigger": "task.created", "actions": [{"type": "move_to_list", "value": "Archive"}]}`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"trigger": "task.created", "actions": [{"type": "move_to_list", "value": "7"}]}`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"trigger": "task.created", "actions": [{"type": "assign", "value": "me"}]}`, http.StatusBadRequest, "invalid"},
+		{"PUT", `{}`, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, "/api/rules", strings.NewReader(tc.body)))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestDeleteRuleHandler(t *testing.T) {
+	s := newTestServer()
+	s.rules.Add(rules.Rule{Trigger: rules.TriggerCompleted, Actions: []rules.Action{{Type: "set_estimate", Value: "0"}}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/rules/1", nil))
+	if rr.Code != http.StatusOK || len(s.rules.List()) != 0 {
+		t.Errorf("Expected the rule to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"DELETE", "/api/rules/1", http.StatusNotFound, "not_found"},
+		{"DELETE", "/api/rules/abc", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/rules/1", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestRulesHandlerAssignToMe(t *testing.T) {
+	s := newTestAccountsServer(t)
+	cookie := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+
+	body := `{"trigger": "task.created", "actions": [{"type": "assign", "value": "me"}]}`
+	req := httptest.NewRequest("POST", "/api/rules", strings.NewReader(body))
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	var added struct {
+		Rule rules.Rule `json:"rule"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &added); err != nil || len(added.Rule.Actions) != 1 || added.Rule.Actions[0].Value != "alice" {
+		t.Errorf("Expected me to be resolved to the signed-in user, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,594 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/accounts"
+	"todo-app/models"
+	"todo-app/workspace"
+)
+
+// SCIM 2.0（RFC 7643・7644）のスキーマの URN です
+const (
+	scimUserSchema   = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema  = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema  = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// scimContentType は SCIM の応答の Content-Type です
+const scimContentType = "application/scim+json"
+
+// scimFilterPattern は対応するフィルタ（userName eq "..." と displayName eq "..."）です
+var scimFilterPattern = regexp.MustCompile(`^(?i)(userName|displayName) eq "((?:[^"\\]|\\.)*)"$`)
+
+// scimMemberFilterPattern は PATCH でメンバーを取り除くときのパス（members[value eq "..."]）です
+var scimMemberFilterPattern = regexp.MustCompile(`^(?i)members\[value eq "([^"]*)"\]$`)
+
+// errSCIMFilter は対応していないフィルタを表します
+var errSCIMFilter = errors.New("unsupported filter")
+
+// scimMeta は SCIM のリソースの meta 属性です
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	Location     string    `json:"location"`
+}
+
+// scimEmail は SCIM のユーザーのメールアドレスです
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// scimUser は SCIM のユーザーです。id はユーザーの ID、active は無効にしていないかどうかです
+type scimUser struct {
+	Schemas  []string    `json:"schemas"`
+	ID       string      `json:"id"`
+	UserName string      `json:"userName"`
+	Active   bool        `json:"active"`
+	Emails   []scimEmail `json:"emails,omitempty"`
+	Meta     scimMeta    `json:"meta"`
+}
+
+// scimMember は SCIM のグループのメンバー（value はユーザーの ID）です
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// scimGroup は SCIM のグループです。id はグループに対応するワークスペースの slug です
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members"`
+	Meta        scimMeta     `json:"meta"`
+}
+
+// scimPatch は PATCH で送る操作の一覧です
+type scimPatch struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// writeSCIM は SCIM のリソースを status で返します
+func writeSCIM(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeSCIMError は err を SCIM のエラーの形式（RFC 7644 3.12）で返します
+// 状態コードはほかの API と同じく errorStatus で決めます
+func (s *Server) writeSCIMError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := errorStatus(err, 1)
+	if errors.Is(err, errSCIMFilter) {
+		status = http.StatusBadRequest
+	}
+	body := map[string]interface{}{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  err.Error(),
+	}
+	switch {
+	case errors.Is(err, errSCIMFilter):
+		body["scimType"] = "invalidFilter"
+	case code == "conflict":
+		body["scimType"] = "uniqueness"
+	case code == "invalid":
+		body["scimType"] = "invalidValue"
+	}
+	if status >= http.StatusInternalServerError {
+		s.logger.ErrorContext(r.Context(), "SCIM request failed", "path", r.URL.Path, "err", err)
+		body["detail"] = http.StatusText(status)
+	}
+	writeSCIM(w, status, body)
+}
+
+// parseSCIMFilter は filter（空なら絞り込みません）から属性と値を取り出します。attr は小文字にそろえます
+func parseSCIMFilter(filter string) (string, string, error) {
+	if filter == "" {
+		return "", "", nil
+	}
+	m := scimFilterPattern.FindStringSubmatch(strings.TrimSpace(filter))
+	if m == nil {
+		return "", "", fmt.Errorf("%w: %q (only userName eq and displayName eq are supported)", errSCIMFilter, filter)
+	}
+	return strings.ToLower(m[1]), strings.ReplaceAll(m[2], `\"`, `"`), nil
+}
+
+// scimPage は startIndex（1から）と count で resources の一部を ListResponse にして返します
+func scimPage[T any](r *http.Request, resources []T) map[string]interface{} {
+	start, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if start < 1 {
+		start = 1
+	}
+	total := len(resources)
+	if start > total {
+		resources = resources[:0]
+	} else {
+		resources = resources[start-1:]
+	}
+	if count, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && count >= 0 && count < len(resources) {
+		resources = resources[:count]
+	}
+	return map[string]interface{}{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   start,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}
+}
+
+// scimBool は PATCH の値の真偽値を読み取ります。Azure AD などは "True" のような文字列で送ります
+func scimBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("%w: active must be a boolean", models.ErrValidation)
+}
+
+// toSCIMUser は user を SCIM のユーザーにします
+func (s *Server) toSCIMUser(r *http.Request, user accounts.User) scimUser {
+	id := strconv.Itoa(user.ID)
+	u := scimUser{
+		Schemas:  []string{scimUserSchema},
+		ID:       id,
+		UserName: user.Name,
+		Active:   !user.Disabled,
+		Meta:     scimMeta{ResourceType: "User", Created: user.CreatedAt, Location: s.absoluteURL(r, "/scim/v2/Users/"+id)},
+	}
+	if user.Email != "" {
+		u.Emails = []scimEmail{{Value: user.Email, Primary: true}}
+	}
+	return u
+}
+
+// SCIMUsersHandler はユーザーの一覧（GET。filter=userName eq "..." で絞り込めます）と作成（POST）を行います
+// 作成では userName・password（省略できます）・emails・active を使います。メールアドレスは確認済みにします
+func (s *Server) SCIMUsersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		attr, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+		if err != nil || attr == "displayname" {
+			s.writeSCIMError(w, r, fmt.Errorf("%w: %q", errSCIMFilter, r.URL.Query().Get("filter")))
+			return
+		}
+		users := []scimUser{}
+		for _, user := range s.accounts.List() {
+			if attr == "" || strings.EqualFold(user.Name, value) {
+				users = append(users, s.toSCIMUser(r, user))
+			}
+		}
+		writeSCIM(w, http.StatusOK, scimPage(r, users))
+	case http.MethodPost:
+		var req struct {
+			UserName string      `json:"userName"`
+			Password string      `json:"password"`
+			Active   *bool       `json:"active"`
+			Emails   []scimEmail `json:"emails"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		email := ""
+		for i, e := range req.Emails {
+			if i == 0 || e.Primary {
+				email = e.Value
+			}
+		}
+		user, err := s.accounts.Provision(req.UserName, req.Password, email)
+		if err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		if req.Active != nil && !*req.Active {
+			if user, err = s.accounts.SetDisabled(user.ID, true); err != nil {
+				s.writeSCIMError(w, r, err)
+				return
+			}
+		}
+		s.audit(r, slog.LevelInfo, "provisioned user", "user_id", user.ID, "user", user.Name)
+		writeSCIM(w, http.StatusCreated, s.toSCIMUser(r, user))
+	default:
+		s.writeSCIMError(w, r, errMethodNotAllowed)
+	}
+}
+
+// SCIMUserHandler は URL の ID のユーザーの取得（GET）、active の変更（PUT・PATCH）、無効化（DELETE）を行います
+// DELETE してもユーザーとタスクは消さずに無効にするだけです。無効にするとセッションと「ログインしたままにする」端末も取り消します
+func (s *Server) SCIMUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r.URL.Path, "/scim/v2/Users/", "")
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	user, ok := s.accounts.Lookup(id)
+	if !ok {
+		s.writeSCIMError(w, r, fmt.Errorf("%w: id %d", accounts.ErrUserNotFound, id))
+		return
+	}
+
+	active := !user.Disabled
+	switch r.Method {
+	case http.MethodGet:
+		writeSCIM(w, http.StatusOK, s.toSCIMUser(r, user))
+		return
+	case http.MethodPut:
+		var req struct {
+			Active *bool `json:"active"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		if req.Active != nil {
+			active = *req.Active
+		}
+	case http.MethodPatch:
+		var req scimPatch
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		// active 以外の属性（名前やメールアドレス）の変更には対応していないため無視します
+		for _, op := range req.Operations {
+			if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+				continue
+			}
+			value := op.Value
+			if op.Path == "" {
+				var attrs map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &attrs); err != nil {
+					s.writeSCIMError(w, r, errInvalidJSON)
+					return
+				}
+				value = attrs["active"]
+			} else if !strings.EqualFold(op.Path, "active") {
+				continue
+			}
+			if value == nil {
+				continue
+			}
+			if active, err = scimBool(value); err != nil {
+				s.writeSCIMError(w, r, err)
+				return
+			}
+		}
+	case http.MethodDelete:
+		active = false
+	default:
+		s.writeSCIMError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	if active == user.Disabled {
+		if user, err = s.accounts.SetDisabled(id, !active); err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		if active {
+			s.audit(r, slog.LevelInfo, "enabled user", "user_id", id, "user", user.Name)
+		} else {
+			revoked := s.sessions.RevokeAll(id)
+			s.audit(r, slog.LevelInfo, "disabled user", "user_id", id, "user", user.Name, "revoked_sessions", revoked)
+		}
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeSCIM(w, http.StatusOK, s.toSCIMUser(r, user))
+}
+
+// groupSlug は SCIM のグループの displayName からワークスペースの slug を作ります
+// 英数字以外は - にし、英数字がなければ（日本語の名前など）名前のハッシュを使います
+func groupSlug(displayName string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(displayName) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimSuffix(slug[:40], "-")
+	}
+	if slug == "" {
+		sum := sha256.Sum256([]byte(displayName))
+		slug = "group-" + hex.EncodeToString(sum[:4])
+	}
+	return slug
+}
+
+// toSCIMGroup は ws を SCIM のグループにします
+func (s *Server) toSCIMGroup(r *http.Request, ws workspace.Workspace) scimGroup {
+	members := make([]scimMember, 0, len(ws.Members))
+	for _, id := range ws.Members {
+		member := scimMember{Value: strconv.Itoa(id)}
+		if user, ok := s.accounts.Lookup(id); ok {
+			member.Display = user.Name
+		}
+		members = append(members, member)
+	}
+	return scimGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          ws.Slug,
+		DisplayName: ws.Name,
+		Members:     members,
+		Meta:        scimMeta{ResourceType: "Group", Created: ws.CreatedAt, Location: s.absoluteURL(r, "/scim/v2/Groups/"+ws.Slug)},
+	}
+}
+
+// scimMemberIDs は SCIM のメンバーをユーザーの ID にします。登録されていないユーザーなら ErrValidation を返します
+func (s *Server) scimMemberIDs(members []scimMember) ([]int, error) {
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.Atoi(member.Value)
+		if _, ok := s.accounts.Lookup(id); err != nil || !ok {
+			return nil, fmt.Errorf("%w: member %q is not a user", models.ErrValidation, member.Value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SCIMGroupsHandler はグループの一覧（GET。filter=displayName eq "..." で絞り込めます）と作成（POST）を行います
+// グループはワークスペースに対応し、作成すると displayName から作った slug のワークスペースを members だけが使えるようにして作ります
+// 一覧には SCIM で作った（メンバーを限った）ワークスペースだけを返します
+func (s *Server) SCIMGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		attr, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+		if err != nil || attr == "username" {
+			s.writeSCIMError(w, r, fmt.Errorf("%w: %q", errSCIMFilter, r.URL.Query().Get("filter")))
+			return
+		}
+		groups := []scimGroup{}
+		for _, ws := range s.workspaces.List() {
+			if ws.Restricted && (attr == "" || ws.Name == value) {
+				groups = append(groups, s.toSCIMGroup(r, ws))
+			}
+		}
+		writeSCIM(w, http.StatusOK, scimPage(r, groups))
+	case http.MethodPost:
+		var req struct {
+			DisplayName string       `json:"displayName"`
+			Members     []scimMember `json:"members"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		if strings.TrimSpace(req.DisplayName) == "" {
+			s.writeSCIMError(w, r, fmt.Errorf("%w: displayName is required", models.ErrValidation))
+			return
+		}
+		members, err := s.scimMemberIDs(req.Members)
+		if err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		ws, err := s.workspaces.Create(groupSlug(req.DisplayName), req.DisplayName)
+		if err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		ws, _ = s.workspaces.SetMembers(ws.Slug, "", members)
+		s.audit(r, slog.LevelInfo, "provisioned group", "workspace", ws.Slug, "members", len(members))
+		writeSCIM(w, http.StatusCreated, s.toSCIMGroup(r, ws))
+	default:
+		s.writeSCIMError(w, r, errMethodNotAllowed)
+	}
+}
+
+// SCIMGroupHandler は URL の ID（slug）のグループの取得（GET）、置き換え（PUT）、メンバーと名前の変更（PATCH）、削除（DELETE）を行います
+// 削除するとワークスペースとそのタスクも削除します
+func (s *Server) SCIMGroupHandler(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/scim/v2/Groups/")
+	ws, _, ok := s.workspaces.Lookup(slug)
+	if !ok || !ws.Restricted {
+		s.writeSCIMError(w, r, fmt.Errorf("%w: %q", errWorkspaceNotFound, slug))
+		return
+	}
+
+	name, members := ws.Name, ws.Members
+	switch r.Method {
+	case http.MethodGet:
+		writeSCIM(w, http.StatusOK, s.toSCIMGroup(r, ws))
+		return
+	case http.MethodDelete:
+		s.workspaces.Delete(slug)
+		s.audit(r, slog.LevelInfo, "deprovisioned group", "workspace", slug)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPut:
+		var req struct {
+			DisplayName string       `json:"displayName"`
+			Members     []scimMember `json:"members"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		ids, err := s.scimMemberIDs(req.Members)
+		if err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		name, members = req.DisplayName, ids
+	case http.MethodPatch:
+		var req scimPatch
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeSCIMError(w, r, errInvalidJSON)
+			return
+		}
+		var err error
+		for _, op := range req.Operations {
+			if name, members, err = s.applyGroupPatch(name, members, op.Op, op.Path, op.Value); err != nil {
+				s.writeSCIMError(w, r, err)
+				return
+			}
+		}
+	default:
+		s.writeSCIMError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	ws, _ = s.workspaces.SetMembers(slug, name, members)
+	s.audit(r, slog.LevelInfo, "updated group", "workspace", slug, "members", len(ws.Members))
+	writeSCIM(w, http.StatusOK, s.toSCIMGroup(r, ws))
+}
+
+// applyGroupPatch は PATCH の1つの操作をグループの名前とメンバーに適用します
+// 対応するのは members の追加（add）・置き換え（replace）・削除（remove。members[value eq "..."] か value で指定）と displayName の置き換えです
+func (s *Server) applyGroupPatch(name string, members []int, op, path string, value json.RawMessage) (string, []int, error) {
+	op = strings.ToLower(op)
+	if path == "" && op != "remove" {
+		// パスがなければ値が属性のオブジェクトです
+		var attrs struct {
+			DisplayName string       `json:"displayName"`
+			Members     []scimMember `json:"members"`
+		}
+		if err := json.Unmarshal(value, &attrs); err != nil {
+			return "", nil, errInvalidJSON
+		}
+		if attrs.DisplayName != "" {
+			name = attrs.DisplayName
+		}
+		if attrs.Members != nil {
+			raw, _ := json.Marshal(attrs.Members)
+			return s.applyGroupPatch(name, members, op, "members", raw)
+		}
+		return name, members, nil
+	}
+
+	if strings.EqualFold(path, "displayName") {
+		var displayName string
+		if err := json.Unmarshal(value, &displayName); err != nil {
+			return "", nil, fmt.Errorf("%w: displayName must be a string", models.ErrValidation)
+		}
+		return displayName, members, nil
+	}
+	if m := scimMemberFilterPattern.FindStringSubmatch(path); m != nil && op == "remove" {
+		id, _ := strconv.Atoi(m[1])
+		return name, removeMembers(members, []int{id}), nil
+	}
+	if !strings.EqualFold(path, "members") {
+		return "", nil, fmt.Errorf("%w: unsupported path %q", models.ErrValidation, path)
+	}
+
+	var given []scimMember
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &given); err != nil {
+			return "", nil, fmt.Errorf("%w: members must be a list", models.ErrValidation)
+		}
+	}
+	switch op {
+	case "remove":
+		if given == nil {
+			return name, nil, nil
+		}
+		ids := make([]int, 0, len(given))
+		for _, member := range given {
+			id, _ := strconv.Atoi(member.Value)
+			ids = append(ids, id)
+		}
+		return name, removeMembers(members, ids), nil
+	case "add", "replace":
+		ids, err := s.scimMemberIDs(given)
+		if err != nil {
+			return "", nil, err
+		}
+		if op == "add" {
+			ids = append(append([]int{}, members...), ids...)
+		}
+		return name, ids, nil
+	default:
+		return "", nil, fmt.Errorf("%w: unsupported op %q", models.ErrValidation, op)
+	}
+}
+
+// removeMembers は members から remove の ID を除いたものを返します
+func removeMembers(members, remove []int) []int {
+	kept := make([]int, 0, len(members))
+	for _, id := range members {
+		removed := false
+		for _, r := range remove {
+			removed = removed || id == r
+		}
+		if !removed {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// SCIMConfigHandler は SCIM のサービスプロバイダの設定（対応する機能）を返します（GET /scim/v2/ServiceProviderConfig）
+func (s *Server) SCIMConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeSCIMError(w, r, errMethodNotAllowed)
+		return
+	}
+	supported := func(b bool) map[string]bool { return map[string]bool{"supported": b} }
+	writeSCIM(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{scimConfigSchema},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 0},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]string{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Authorization: Bearer に設定 scim_token の値を送ります",
+		}},
+	})
+}
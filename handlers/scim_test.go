@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+	"todo-app/logging"
+	"todo-app/models"
+	"todo-app/workspace"
+)
+
+// newSCIMTestServer は SCIM とワークスペースを有効にしたユーザーアカウントのサーバを作成します
+func newSCIMTestServer(t *testing.T) *Server {
+	t.Helper()
+	users, err := accounts.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	users.Iterations = 1000
+	return NewServer(Deps{
+		Config:   Config{SCIMToken: "scim-secret"},
+		Logger:   logging.Discard(),
+		Accounts: users,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+		Workspaces: workspace.NewStore(func(ws workspace.Workspace) (http.Handler, error) {
+			return NewServer(Deps{Config: Config{BasePath: ws.Path()}}), nil
+		}),
+	})
+}
+
+// scimRequest は SCIM のトークンを付けたリクエストを行います
+func scimRequest(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer scim-secret")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	return rr
+}
+
+// assertSCIMError は応答が SCIM の形式の status のエラーかを確かめます
+func assertSCIMError(t *testing.T, rr *httptest.ResponseRecorder, status int, scimType string) {
+	t.Helper()
+	var body struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType"`
+	}
+	if rr.Code != status || rr.Header().Get("Content-Type") != scimContentType {
+		t.Errorf("Expected status code %d with %s, got %d %s", status, scimContentType, rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || len(body.Schemas) != 1 || body.Schemas[0] != scimErrorSchema || body.ScimType != scimType {
+		t.Errorf("Unexpected SCIM error %s", rr.Body.String())
+	}
+}
+
+func TestSCIMUsers(t *testing.T) {
+	s := newSCIMTestServer(t)
+
+	rr := scimRequest(s, "POST", "/scim/v2/Users", `{"schemas": ["`+scimUserSchema+`"], "userName": "alice", "password": "correct horse", "emails": [{"value": "a@example.com", "primary": true}]}`)
+	var alice scimUser
+	if err := json.Unmarshal(rr.Body.Bytes(), &alice); err != nil || rr.Code != http.StatusCreated || alice.ID != "1" || !alice.Active || len(alice.Emails) != 1 {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+	if !strings.HasSuffix(alice.Meta.Location, "/scim/v2/Users/1") {
+		t.Errorf("Unexpected location %q", alice.Meta.Location)
+	}
+	assertSCIMError(t, scimRequest(s, "POST", "/scim/v2/Users", `{"userName": "ALICE"}`), http.StatusConflict, "uniqueness")
+	assertSCIMError(t, scimRequest(s, "POST", "/scim/v2/Users", `{"userName": "x"}`), http.StatusBadRequest, "invalidValue")
+	scimRequest(s, "POST", "/scim/v2/Users", `{"userName": "bob", "active": false}`)
+
+	rr = scimRequest(s, "GET", "/scim/v2/Users?filter="+strings.ReplaceAll(`userName eq "Bob"`, " ", "%20"), "")
+	var list struct {
+		TotalResults int        `json:"totalResults"`
+		Resources    []scimUser `json:"Resources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || list.TotalResults != 1 || list.Resources[0].UserName != "bob" || list.Resources[0].Active {
+		t.Errorf("Expected the disabled bob, got %s", rr.Body.String())
+	}
+	rr = scimRequest(s, "GET", "/scim/v2/Users?startIndex=2&count=1", "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || list.TotalResults != 2 || len(list.Resources) != 1 || list.Resources[0].UserName != "bob" {
+		t.Errorf("Expected the second page to be bob, got %s", rr.Body.String())
+	}
+	assertSCIMError(t, scimRequest(s, "GET", "/scim/v2/Users?filter=name%20co%20%22a%22", ""), http.StatusBadRequest, "invalidFilter")
+
+	login := authRequest(s, "login", "alice", "correct horse")
+	cookie := sessionCookie(t, login)
+
+	// Azure AD は active を文字列で送ります
+	rr = scimRequest(s, "PATCH", "/scim/v2/Users/1", `{"Operations": [{"op": "Replace", "path": "active", "value": "False"}]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &alice); err != nil || alice.Active {
+		t.Fatalf("Expected alice to be disabled, got %d %s", rr.Code, rr.Body.String())
+	}
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+	assertErrorResponse(t, authRequest(s, "login", "alice", "correct horse"), http.StatusForbidden, "user_disabled")
+
+	rr = scimRequest(s, "PATCH", "/scim/v2/Users/1", `{"Operations": [{"op": "replace", "value": {"active": true}}]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &alice); err != nil || !alice.Active {
+		t.Fatalf("Expected alice to be enabled, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := authRequest(s, "login", "alice", "correct horse"); rr.Code != http.StatusOK {
+		t.Errorf("Expected alice to log in again, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := scimRequest(s, "DELETE", "/scim/v2/Users/1", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if user, ok := s.accounts.Lookup(1); !ok || !user.Disabled {
+		t.Errorf("Expected DELETE to keep and disable the user, got %+v %v", user, ok)
+	}
+	assertSCIMError(t, scimRequest(s, "GET", "/scim/v2/Users/9", ""), http.StatusNotFound, "")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/scim/v2/Users", nil))
+	assertErrorResponse(t, rr, http.StatusUnauthorized, "unauthorized")
+}
+
+func TestSCIMGroups(t *testing.T) {
+	s := newSCIMTestServer(t)
+	s.accounts.Register("alice", "correct horse")
+	s.accounts.Register("bob", "battery staple")
+
+	rr := scimRequest(s, "POST", "/scim/v2/Groups", `{"displayName": "Dev Team", "members": [{"value": "1"}]}`)
+	var group scimGroup
+	if err := json.Unmarshal(rr.Body.Bytes(), &group); err != nil || rr.Code != http.StatusCreated || group.ID != "dev-team" || len(group.Members) != 1 || group.Members[0].Display != "alice" {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+	assertSCIMError(t, scimRequest(s, "POST", "/scim/v2/Groups", `{"displayName": "Dev Team"}`), http.StatusConflict, "uniqueness")
+	assertSCIMError(t, scimRequest(s, "POST", "/scim/v2/Groups", `{"displayName": "QA", "members": [{"value": "9"}]}`), http.StatusBadRequest, "invalidValue")
+
+	// メンバーでないユーザーにはワークスペースが見えません
+	workspaceStatus := func(name, password string) int {
+		req := httptest.NewRequest("GET", "/w/dev-team/api/tasks", nil)
+		req.AddCookie(sessionCookie(t, authRequest(s, "login", name, password)))
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if alice, bob := workspaceStatus("alice", "correct horse"), workspaceStatus("bob", "battery staple"); alice != http.StatusOK || bob != http.StatusNotFound {
+		t.Errorf("Expected only alice to use the workspace, got %d and %d", alice, bob)
+	}
+
+	rr = scimRequest(s, "PATCH", "/scim/v2/Groups/dev-team", `{"Operations": [
+		{"op": "add", "path": "members", "value": [{"value": "2"}]},
+		{"op": "remove", "path": "members[value eq \"1\"]"},
+		{"op": "replace", "path": "displayName", "value": "開発チーム"}
+	]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &group); err != nil || group.DisplayName != "開発チーム" || len(group.Members) != 1 || group.Members[0].Value != "2" {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+	if bob := workspaceStatus("bob", "battery staple"); bob != http.StatusOK {
+		t.Errorf("Expected bob to use the workspace after being added, got %d", bob)
+	}
+
+	rr = scimRequest(s, "PUT", "/scim/v2/Groups/dev-team", `{"displayName": "Dev", "members": [{"value": "1"}, {"value": "2"}]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &group); err != nil || group.DisplayName != "Dev" || len(group.Members) != 2 {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 管理用エンドポイントで作ったワークスペースはグループとして扱いません
+	s.workspaces.Create("family", "")
+	rr = scimRequest(s, "GET", "/scim/v2/Groups", "")
+	var list struct {
+		TotalResults int         `json:"totalResults"`
+		Resources    []scimGroup `json:"Resources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || list.TotalResults != 1 || list.Resources[0].ID != "dev-team" {
+		t.Errorf("Expected only dev-team, got %s", rr.Body.String())
+	}
+	assertSCIMError(t, scimRequest(s, "GET", "/scim/v2/Groups/family", ""), http.StatusNotFound, "")
+
+	if rr := scimRequest(s, "DELETE", "/scim/v2/Groups/dev-team", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if _, _, ok := s.workspaces.Lookup("dev-team"); ok {
+		t.Error("Expected the workspace to be deleted")
+	}
+}
+
+func TestGroupSlug(t *testing.T) {
+	for name, want := range map[string]string{
+		"Dev Team":                   "dev-team",
+		"  Sales & Marketing (JP) ":  "sales-marketing-jp",
+		strings.Repeat("abcde ", 10): "abcde-abcde-abcde-abcde-abcde-abcde-abcd",
+	} {
+		if got := groupSlug(name); got != want {
+			t.Errorf("groupSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := groupSlug("開発チーム"); !strings.HasPrefix(got, "group-") || workspace.ValidateSlug(got) != nil {
+		t.Errorf("Expected a hashed slug for a name without ASCII letters, got %q", got)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-app/models"
+)
+
+// 全文検索で返す件数の既定値と上限です（?limit=）
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// SearchTasksHandler はタイトルを大文字・小文字を区別せずにあいまい検索し、一致の度合いの高い順に返します（GET /api/tasks/search?q=）
+// 索引はストアの変更イベントで更新するため、タスクが多くてもすべてのタスクを調べ直しません
+// 暗号化するモードではサーバがタイトルを読めないため、GET /api/tasks?index= を使うよう 400 を返します
+func (s *Server) SearchTasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	if s.e2e != nil {
+		s.writeError(w, r, fmt.Errorf("%w: full-text search is not available when titles are encrypted; use GET /api/tasks?index=", models.ErrValidation))
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		s.writeError(w, r, fmt.Errorf("%w: q is required", models.ErrValidation))
+		return
+	}
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxSearchLimit {
+			s.writeError(w, r, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrValidation, maxSearchLimit))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"results": s.searchIndex().Search(query, limit),
+	})
+}
+
+// searchIndex は全文検索の索引を返します。最初の検索のときにストアのタスクを読み込み、以降は変更イベントで更新します
+func (s *Server) searchIndex() *models.SearchIndex {
+	s.searchOnce.Do(func() { s.search.Watch(s.store) })
+	return s.search
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"todo-app/models"
+)
+
+func TestSearchTasksHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/tasks", `{"title": "Write the weekly report"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Buy milk"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Report bug"}`)
+	listRequest(s, "PATCH", "/api/tasks/3", `{"completed": true}`)
+
+	search := func(query string) []models.SearchResult {
+		t.Helper()
+		rr := listRequest(s, "GET", "/api/tasks/search"+query, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Success bool                  `json:"success"`
+			Results []models.SearchResult `json:"results"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if !response.Success {
+			t.Errorf("Unexpected response: %s", rr.Body.String())
+		}
+		return response.Results
+	}
+
+	results := search("?q=REPOT")
+	if len(results) != 2 || results[0].Task.ID != 1 || results[1].Task.ID != 3 || !results[1].Task.Completed {
+		t.Errorf("Expected both report tasks with their current state, got %+v", results)
+	}
+	if results := search("?q=report&limit=1"); len(results) != 1 {
+		t.Errorf("Expected the limit to apply, got %+v", results)
+	}
+
+	// 追加・削除したタスクはすぐに検索に反映します
+	listRequest(s, "DELETE", "/api/tasks/2", "")
+	listRequest(s, "POST", "/api/tasks", `{"title": "Buy oat milk"}`)
+	if results := search("?q=milk"); len(results) != 1 || results[0].Task.Title != "Buy oat milk" {
+		t.Errorf("Expected only the new milk task, got %+v", results)
+	}
+	if results := search("?q=groceries"); results == nil || len(results) != 0 {
+		t.Errorf("Expected an empty list, got %+v", results)
+	}
+
+	assertErrorResponse(t, listRequest(s, "GET", "/api/tasks/search", ""), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "GET", "/api/tasks/search?q=milk&limit=0", ""), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks/search?q=milk", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+	assertErrorResponse(t, listRequest(newE2EServer(t), "GET", "/api/tasks/search?q=milk", ""), http.StatusBadRequest, "invalid")
+}
@@ -0,0 +1,594 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"todo-app/accounts"
+	"todo-app/backup"
+	"todo-app/board"
+	"todo-app/crdt"
+	"todo-app/dav"
+	"todo-app/e2ee"
+	"todo-app/integrations/llm"
+	"todo-app/integrations/notion"
+	"todo-app/lists"
+	"todo-app/lockout"
+	"todo-app/models"
+	"todo-app/pomodoro"
+	"todo-app/reports"
+	"todo-app/rules"
+	"todo-app/share"
+	"todo-app/shortlink"
+	"todo-app/timeline"
+	"todo-app/usage"
+	"todo-app/webhooks"
+	"todo-app/workspace"
+)
+
+// Config はサーバの設定です
+// StaticDir: CSS や JavaScript などの静的ファイルを置くディレクトリ（省略時は "static"）。Deps.Static を省略したときに使います
+// TemplateDir: 画面のテンプレートを読み込むディレクトリ。指定すると画面を描画するたびに読み込み直します（空なら埋め込んだテンプレート）
+// AdminToken: 管理用エンドポイントの Bearer トークン（空なら管理用エンドポイントは無効）
+// Dev: 開発モード。ブラウザのキャッシュを無効にします
+// PublicURL: QR コードなどに入れる絶対 URL の起点（例: https://todo.example.com、空ならリクエストのホスト）
+// BasePath: サーバを /w/{slug} などの下で動かすときのパスの接頭辞。返す URL やリダイレクト先に付けます
+// DisableSearch / DisableLiveSync: キーワード検索と、変更の通知（/ws と /api/events）を止めます
+// TrustedProxies: X-Forwarded-For と X-Real-IP からクライアントの IP アドレスを読み取るリバースプロキシ（空なら接続元のアドレスを使います）
+// InviteOnly: ユーザー登録に管理者が発行した招待コード（Deps.Invites）を求めます
+// SCIMToken: SCIM のエンドポイント（/scim/v2/）の Bearer トークン（空なら SCIM は無効）
+type Config struct {
+	StaticDir       string
+	TemplateDir     string
+	AdminToken      string
+	Dev             bool
+	PublicURL       string
+	BasePath        string
+	DisableSearch   bool
+	DisableLiveSync bool
+	TrustedProxies  TrustedProxies
+	CaptchaAfter    int
+	InviteOnly      bool
+	SCIMToken       string
+}
+
+// Deps は Server が使う依存関係です。省略したものは既定値で補います
+// Store: タスクの保存先（省略時はメモリ上の TodoApp）
+// Webhooks: Webhook の登録先（省略時は空の登録先）
+// Deliveries: Webhook を送る Dispatcher。設定したときだけ配信のキューを確認・再送する管理用エンドポイントを有効にします
+// Logger: ログの出力先（省略時は標準のロガー）
+// Pomodoros: ポモドーロのセッションの記録先（省略時は空の記録先）
+// Rules: 自動化ルールの登録先（省略時は空の登録先。ルールの実行は rules.Engine をストアに購読させて行います）
+// Shares: 共有リンクの発行先（省略時はメモリ上の空の発行先）
+// ShortLinks: タスクの短いリンクの発行先（省略時は空の発行先）
+// AdminAttempts: 管理用トークンの認証に失敗した IP アドレスの記録（省略時は既定の設定。複数のサーバで共有できます）
+// Template: トップページのテンプレート（省略時は埋め込んだ templates/index.html）
+// Static: /static/ の静的ファイルと静的な画面の HTML（省略時は Config.StaticDir のディレクトリから毎回読み込みます）
+// Notion / Backups / Workspaces: 設定したときだけ対応するエンドポイントを有効にします
+// E2E: 設定するとタイトルと説明をクライアント側で暗号化するモードになり、平文のタイトルと説明を受け付けなくなります
+// Board: カンバンのカラムとタスクを置いた位置（省略時は「未着手」と「完了」のカラムだけのボード）
+// Timeline: タイムラインに使うタスクの依存関係（省略時は依存関係のない記録先）
+// Lists: タスクを分けて入れるリスト（省略時はメモリ上の、リストのない登録先）
+// Sync: 設定したときだけ端末とタスクの Doc（CRDT）を同期するエンドポイントを有効にします
+// Reports: 設定したときだけ定期的なレポートのスケジュールを管理するエンドポイントを有効にします（実行は reports.Scheduler.Run で行います）
+// Usage: API のリクエストの記録先（省略時は既定の上限の記録先）
+// Suggester: 設定したときだけタスクを小さな作業に分ける案を LLM で作るエンドポイントを有効にします（暗号化するモードでは使えません）
+// Accounts / UserHandlers: 両方設定するとユーザーアカウントを有効にし、ログインしたユーザーのリクエストをそのユーザーのサーバへ渡します
+// Sessions: ログインのセッションの記録先（省略時は既定の有効期間の記録先）
+// APIKeys: ユーザーの API キーの登録先（省略時はメモリ上の登録先。ユーザーアカウントが有効なときだけ使います）
+// Invites: ユーザー登録の招待コードの登録先（省略時はメモリ上の登録先。ユーザーアカウントが有効なときだけ使います）
+// Stopping: サーバを止め始めるときにキャンセルするコンテキスト。キャンセルされると WebSocket と SSE の接続を閉じます（省略時は閉じません）
+// CheckStore: 保存先が変更を保存できているか確かめる関数。エラーを返す間は変更のリクエストを 503 で断ります（省略時は確かめません）
+// Mailer: 設定するとユーザー登録でメールアドレスを必須にし、メールで送るリンクで確かめるまでログインさせません
+// Captcha: 設定すると、IP アドレスかユーザー名が Config.CaptchaAfter 回続けてログインに失敗した後は CAPTCHA の応答を求めます
+type Deps struct {
+	Store         models.TaskStore
+	Webhooks      *webhooks.Store
+	Deliveries    *webhooks.Dispatcher
+	Pomodoros     *pomodoro.Store
+	Rules         *rules.Store
+	Shares        *share.Store
+	ShortLinks    *shortlink.Store
+	AdminAttempts *lockout.Limiter
+	Logger        *slog.Logger
+	Config        Config
+	Template      *template.Template
+	Static        fs.FS
+	Notion        *notion.Exporter
+	Backups       *backup.Manager
+	Workspaces    *workspace.Store
+	E2E           *e2ee.KeyStore
+	Sync          *crdt.Syncer
+	Board         *board.Store
+	Timeline      *timeline.Store
+	Lists         *lists.Store
+	Reports       *reports.Scheduler
+	Usage         *usage.Store
+	Suggester     *llm.Suggester
+	Accounts      *accounts.Store
+	Sessions      *accounts.Sessions
+	APIKeys       *accounts.APIKeys
+	Invites       *accounts.Invites
+	UserHandlers  *accounts.Handlers
+	Stopping      context.Context
+	CheckStore    func(context.Context) error
+	Captcha       CaptchaVerifier
+	Mailer        Mailer
+}
+
+// Server はタスクの保存先などの依存関係を持ち、すべての画面と API を提供する http.Handler です
+// パッケージ変数を持たないため、同じプロセスで複数のサーバを独立して動かせます
+type Server struct {
+	store         models.TaskStore
+	webhooks      *webhooks.Store
+	deliveries    *webhooks.Dispatcher
+	pomodoros     *pomodoro.Store
+	rules         *rules.Store
+	shares        *share.Store
+	shortlinks    *shortlink.Store
+	adminAttempts *lockout.Limiter
+	logger        *slog.Logger
+	config        Config
+	template      *template.Template
+	pages         *pageSet
+	static        *staticFiles
+	notion        *notion.Exporter
+	backups       *backup.Manager
+	workspaces    *workspace.Store
+	e2e           *e2ee.KeyStore
+	sync          *crdt.Syncer
+	board         *board.Store
+	listBoards    *board.Boards
+	timeline      *timeline.Store
+	lists         *lists.Store
+	reports       *reports.Scheduler
+	usage         *usage.Store
+	suggester     *llm.Suggester
+	accounts      *accounts.Store
+	sessions      *accounts.Sessions
+	apiKeys       *accounts.APIKeys
+	invites       *accounts.Invites
+	userHandlers  *accounts.Handlers
+	loginAttempts *lockout.Limiter
+	loginAccounts *lockout.Limiter
+	search        *models.SearchIndex
+	searchOnce    sync.Once
+	eventLog      *models.EventLog
+	stopping      context.Context
+	checkStore    func(context.Context) error
+	captcha       CaptchaVerifier
+	mailer        Mailer
+
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+// NewServer は deps を使う Server を作成し、ルーティングを登録します
+func NewServer(deps Deps) *Server {
+	s := &Server{
+		store:         deps.Store,
+		webhooks:      deps.Webhooks,
+		deliveries:    deps.Deliveries,
+		pomodoros:     deps.Pomodoros,
+		rules:         deps.Rules,
+		shares:        deps.Shares,
+		shortlinks:    deps.ShortLinks,
+		adminAttempts: deps.AdminAttempts,
+		logger:        deps.Logger,
+		config:        deps.Config,
+		template:      deps.Template,
+		notion:        deps.Notion,
+		backups:       deps.Backups,
+		workspaces:    deps.Workspaces,
+		e2e:           deps.E2E,
+		sync:          deps.Sync,
+		board:         deps.Board,
+		timeline:      deps.Timeline,
+		lists:         deps.Lists,
+		reports:       deps.Reports,
+		usage:         deps.Usage,
+		suggester:     deps.Suggester,
+		accounts:      deps.Accounts,
+		sessions:      deps.Sessions,
+		apiKeys:       deps.APIKeys,
+		invites:       deps.Invites,
+		userHandlers:  deps.UserHandlers,
+		stopping:      deps.Stopping,
+		checkStore:    deps.CheckStore,
+		captcha:       deps.Captcha,
+		mailer:        deps.Mailer,
+		loginAttempts: lockout.New(),
+		loginAccounts: lockout.New(),
+		mux:           http.NewServeMux(),
+	}
+	if s.store == nil {
+		s.store = models.NewTodoApp()
+	}
+	s.search = models.NewSearchIndex()
+	s.pages = newPageSet(s.config.TemplateDir)
+	s.eventLog = models.NewEventLog(eventLogSize)
+	if !s.config.DisableLiveSync {
+		s.eventLog.Watch(s.store)
+	}
+	if s.webhooks == nil {
+		s.webhooks = webhooks.NewStore()
+	}
+	if s.pomodoros == nil {
+		s.pomodoros = pomodoro.NewStore()
+	}
+	if s.rules == nil {
+		s.rules = rules.NewStore()
+	}
+	if s.shares == nil {
+		s.shares, _ = share.NewStore("")
+	}
+	if s.board == nil {
+		s.board = board.NewStore()
+	}
+	s.listBoards = board.NewBoards()
+	if s.timeline == nil {
+		s.timeline = timeline.NewStore()
+	}
+	if s.lists == nil {
+		s.lists, _ = lists.NewStore("")
+	}
+	if s.usage == nil {
+		s.usage = usage.NewStore()
+	}
+	if s.shortlinks == nil {
+		s.shortlinks = shortlink.NewStore()
+	}
+	if s.adminAttempts == nil {
+		s.adminAttempts = lockout.New()
+	}
+	if s.sessions == nil {
+		s.sessions = accounts.NewSessions()
+	}
+	if s.apiKeys == nil {
+		s.apiKeys, _ = accounts.NewAPIKeys("")
+	}
+	if s.invites == nil {
+		s.invites, _ = accounts.NewInvites("")
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	if s.stopping == nil {
+		s.stopping = context.Background()
+	}
+	if s.config.StaticDir == "" {
+		s.config.StaticDir = "static"
+	}
+	if deps.Static != nil {
+		s.static = newStaticFiles(deps.Static, true)
+	} else {
+		s.static = newStaticFiles(os.DirFS(s.config.StaticDir), false)
+	}
+	s.routes()
+	var handler http.Handler = s.mux
+	if s.checkStore != nil {
+		handler = s.requireStore(handler)
+	}
+	if s.accountsEnabled() {
+		handler = s.withAccounts(handler)
+	}
+	s.handler = s.recordUsage(s.withAPIVersion(s.withJournal(handler)))
+	return s
+}
+
+// Store はサーバが使っているタスクの保存先を返します
+func (s *Server) Store() models.TaskStore {
+	return s.store
+}
+
+// Webhooks はサーバが管理している Webhook の登録先を返します
+func (s *Server) Webhooks() *webhooks.Store {
+	return s.webhooks
+}
+
+// ServeHTTP はリクエストを登録済みのハンドラへ振り分け、API の使用状況を記録します
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// requireStore は保存先が前の変更を保存できていなければ、変更のリクエスト（GET・HEAD・OPTIONS 以外）を 503 で断ります
+// 保存できない間に受け付けた変更は再起動すると失われるため、保存先が直るまで新しい変更を受け付けません
+func (s *Server) requireStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if err := s.checkStore(r.Context()); err != nil {
+				s.writeError(w, r, fmt.Errorf("%w: %v", errStoreFailed, err))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routes はすべてのエンドポイントを登録します
+func (s *Server) routes() {
+	var static http.Handler = http.StripPrefix("/static/", s.static)
+	var home http.Handler = http.HandlerFunc(s.HomeHandler)
+	var today http.Handler = http.HandlerFunc(s.TodayHandler)
+	var review http.Handler = http.HandlerFunc(s.ReviewPageHandler)
+	if s.config.Dev {
+		static = noCache(static)
+		home = noCache(home)
+		today = noCache(today)
+		review = noCache(review)
+	}
+	s.mux.Handle("/static/", static)
+	s.mux.Handle("/", home)
+	s.mux.Handle("/today", today)
+	s.mux.Handle("/review", review)
+	s.mux.HandleFunc("/calendar", s.CalendarPageHandler)
+	s.mux.HandleFunc("/archive", s.ArchivePageHandler)
+	s.mux.HandleFunc("/tasks/", s.TaskPageHandler)
+	s.mux.HandleFunc("/share/", s.SharePageHandler)
+	s.mux.HandleFunc("/t/", s.ShortLinkRedirectHandler)
+
+	s.mux.HandleFunc("/api/tasks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			s.GetTasksHandler(w, r)
+		} else if r.Method == http.MethodPost {
+			s.validateBody(http.MethodPost, "task", s.AddTaskHandler)(w, r)
+		} else {
+			s.writeError(w, r, errMethodNotAllowed)
+		}
+	})
+
+	s.mux.HandleFunc("/api/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		// /api/tasks/{id}/{action} や /api/tasks/{id} (PUT・PATCH・DELETE) を振り分け
+		action, ok := pathAction(r.URL.Path, "/api/tasks/")
+		segments, _ := splitPath(r.URL.Path, "/api/tasks/")
+		switch {
+		case len(segments) == 1 && segments[0] == "suggested":
+			s.SuggestedTasksHandler(w, r)
+		case len(segments) == 1 && segments[0] == "search" && s.config.DisableSearch:
+			s.writeError(w, r, errPathNotFound)
+		case len(segments) == 1 && segments[0] == "search":
+			s.SearchTasksHandler(w, r)
+		case ok && action == "toggle":
+			s.ToggleTaskHandler(w, r)
+		case ok && action == "restore":
+			s.RestoreTaskHandler(w, r)
+		case ok && action == "pomodoros":
+			s.validateBody(http.MethodPost, "pomodoro", s.TaskPomodorosHandler)(w, r)
+		case ok && action == "time-entries":
+			s.TimeEntriesHandler(w, r)
+		case ok && action == "claim":
+			s.validateBody(http.MethodPost, "claim", s.validateBody(http.MethodDelete, "claim", s.ClaimHandler))(w, r)
+		case ok && action == "dependencies":
+			s.validateBody(http.MethodPut, "dependencies", s.DependenciesHandler)(w, r)
+		case ok && action == "estimate":
+			s.validateBody(http.MethodPut, "estimate", s.EstimateHandler)(w, r)
+		case ok && action == "subtasks":
+			s.validateBody(http.MethodPost, "subtask", s.SubtasksHandler)(w, r)
+		case ok && action == "comments":
+			s.validateBody(http.MethodPost, "comment", s.CommentsHandler)(w, r)
+		case len(segments) == 3 && segments[1] == "comments":
+			s.CommentHandler(w, r)
+		case ok && action == "history":
+			s.TaskHistoryHandler(w, r)
+		case ok && action == "reminder":
+			s.validateBody(http.MethodPut, "reminder", s.ReminderHandler)(w, r)
+		case len(segments) == 3 && segments[1] == "reminder" && segments[2] == "snooze":
+			s.validateBody(http.MethodPost, "reminder_snooze", s.SnoozeReminderHandler)(w, r)
+		case ok && action == "tags":
+			s.validateBody(http.MethodPost, "tag", s.AddTagHandler)(w, r)
+		case len(segments) == 3 && segments[1] == "tags":
+			s.RemoveTagHandler(w, r)
+		case ok && action == "priority":
+			s.validateBody(http.MethodPatch, "priority", s.PriorityHandler)(w, r)
+		case ok && action == "suggest-subtasks" && s.suggestionsEnabled():
+			s.SuggestSubtasksHandler(w, r)
+		case len(segments) == 3 && segments[1] == "suggest-subtasks" && segments[2] == "accept" && s.suggestionsEnabled():
+			s.validateBody(http.MethodPost, "accept_subtasks", s.AcceptSubtasksHandler)(w, r)
+		case ok && action == "shortlink":
+			s.ShortLinkHandler(w, r)
+		case ok && action == "qr.png":
+			s.TaskQRCodeHandler(w, r)
+		case ok && action == "" && r.Method == http.MethodGet:
+			s.GetTaskHandler(w, r)
+		case ok && action == "" && r.Method == http.MethodPut:
+			s.validateBody(http.MethodPut, "task", s.UpdateTaskHandler)(w, r)
+		case ok && action == "" && r.Method == http.MethodPatch:
+			s.validateBody(http.MethodPatch, "task_patch", s.PatchTaskHandler)(w, r)
+		case ok && action == "":
+			s.DeleteTaskHandler(w, r)
+		case len(segments) == 3 && segments[1] == "timer" && (segments[2] == "start" || segments[2] == "stop"):
+			s.TimerHandler(w, r)
+		case len(segments) == 3 && segments[1] == "time-entries":
+			s.validateBody(http.MethodPut, "time_entry", s.TimeEntryHandler)(w, r)
+		default:
+			s.writeError(w, r, errPathNotFound)
+		}
+	})
+
+	s.mux.HandleFunc("/api/trash", s.TrashHandler)
+	s.mux.HandleFunc("/api/undo", s.UndoHandler)
+
+	s.mux.HandleFunc(grpcServicePath, s.GRPCHandler)
+
+	s.mux.HandleFunc("/api/lists", s.validateBody(http.MethodPost, "list", s.ListsHandler))
+	s.mux.HandleFunc("/api/lists/", func(w http.ResponseWriter, r *http.Request) {
+		action, ok := pathAction(r.URL.Path, "/api/lists/")
+		switch {
+		case ok && action == "":
+			s.validateBody(http.MethodPut, "list", s.ListHandler)(w, r)
+		case ok && action == "duplicate":
+			s.validateBody(http.MethodPost, "list_duplicate", s.DuplicateListHandler)(w, r)
+		case ok && action == "burndown":
+			s.ListBurndownHandler(w, r)
+		case ok && action == "timeline":
+			s.ListTimelineHandler(w, r)
+		case ok && action == "export.pdf" && s.e2e == nil:
+			// /api/export/pdf と同じく、暗号化するモードでは印刷できません
+			s.ListExportPDFHandler(w, r)
+		case ok && action == "qr.png":
+			s.ListQRCodeHandler(w, r)
+		default:
+			s.writeError(w, r, errPathNotFound)
+		}
+	})
+
+	s.mux.HandleFunc("/api/board", s.BoardHandler)
+	s.mux.HandleFunc("/api/board/columns", s.validateBody(http.MethodPost, "board_column", s.BoardColumnsHandler))
+	s.mux.HandleFunc("/api/board/columns/", s.validateBody(http.MethodPut, "board_column", s.BoardColumnHandler))
+	s.mux.HandleFunc("/api/board/tasks/", s.validateBody(http.MethodPut, "board_move", s.BoardTaskHandler))
+
+	s.mux.HandleFunc("/api/timeline", s.TimelineHandler)
+	s.mux.HandleFunc("/api/suggestions", s.SuggestionsHandler)
+	s.mux.HandleFunc("/api/features", s.FeaturesHandler)
+
+	s.mux.HandleFunc("/api/agenda", s.AgendaHandler)
+	s.mux.HandleFunc("/api/calendar", s.CalendarHandler)
+	s.mux.HandleFunc("/api/archive", s.ArchiveHandler)
+	s.mux.HandleFunc("/api/review", s.ReviewHandler)
+	s.mux.HandleFunc("/api/analytics/completions", s.CompletionsHandler)
+	s.mux.HandleFunc("/api/analytics/burndown", s.BurndownHandler)
+	s.mux.HandleFunc("/api/analytics/heatmap", s.HeatmapHandler)
+	s.mux.HandleFunc("/api/reports/estimates", s.EstimatesReportHandler)
+	s.mux.HandleFunc("/api/reports/stale", s.StaleReportHandler)
+
+	s.mux.HandleFunc("/api/pomodoros", s.DailyPomodorosHandler)
+	s.mux.HandleFunc("/api/pomodoros/", s.PomodoroActionHandler)
+
+	s.mux.HandleFunc("/api/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			s.GetWebhooksHandler(w, r)
+		} else {
+			s.validateBody(http.MethodPost, "webhook", s.AddWebhookHandler)(w, r)
+		}
+	})
+
+	s.mux.HandleFunc("/api/webhooks/", s.DeleteWebhookHandler)
+
+	s.mux.HandleFunc("/api/rules", s.validateBody(http.MethodPost, "rule", s.RulesHandler))
+	s.mux.HandleFunc("/api/rules/", s.DeleteRuleHandler)
+
+	s.mux.HandleFunc("/api/schemas/", s.SchemaHandler)
+	s.mux.HandleFunc("/api/openapi.json", s.OpenAPIHandler)
+	s.mux.HandleFunc("/api/docs", s.APIDocsHandler)
+	// 登録していない API のパスも、ほかの API と同じエラーエンベロープで 404 を返します
+	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		s.writeError(w, r, errPathNotFound)
+	})
+	s.mux.HandleFunc("/api/e2e", s.E2EHandler)
+
+	s.mux.HandleFunc("/api/export/markdown", s.ExportMarkdownHandler)
+	s.mux.HandleFunc("/api/import/csv", s.ImportCSVHandler)
+	s.mux.HandleFunc("/api/admin/generate", s.requireAdmin(s.GenerateHandler))
+	s.mux.HandleFunc("/api/admin/shares", s.requireAdmin(s.validateBody(http.MethodPost, "share", s.SharesHandler)))
+	s.mux.HandleFunc("/api/admin/shares/", s.requireAdmin(s.RevokeShareHandler))
+	s.mux.HandleFunc("/api/admin/usage", s.requireAdmin(s.UsageHandler))
+
+	if !s.config.DisableLiveSync {
+		s.mux.HandleFunc("/ws", s.WebSocketHandler)
+		s.mux.HandleFunc("/api/events", s.EventStreamHandler)
+	}
+
+	if s.notion != nil {
+		s.mux.HandleFunc("/api/export/notion", s.NotionExportHandler)
+	}
+
+	if s.deliveries != nil {
+		s.mux.HandleFunc("/api/admin/webhooks/deliveries", s.requireAdmin(s.DeliveriesHandler))
+		s.mux.HandleFunc("/api/admin/webhooks/deliveries/", s.requireAdmin(s.DeliveryActionHandler))
+	}
+
+	if s.backups != nil {
+		s.mux.HandleFunc("/api/admin/backups", s.requireAdmin(s.BackupsHandler))
+		s.mux.HandleFunc("/api/admin/backups/", s.requireAdmin(s.RestoreBackupHandler))
+	}
+
+	if s.e2e != nil {
+		s.mux.HandleFunc("/api/e2e/keys", s.validateBody(http.MethodPut, "e2e_keys", s.E2EKeysHandler))
+	} else {
+		// 暗号化するモードではタイトルが暗号文のため、ファイルとして読み書きしたり印刷したりしても使えません
+		s.mux.Handle("/dav/", dav.NewHandler(s.store, s.lists, "/dav/", s.config.BasePath))
+		s.mux.HandleFunc("/api/export/pdf", s.ExportPDFHandler)
+	}
+
+	if s.sync != nil {
+		s.mux.HandleFunc("/api/sync", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				s.SyncHandler(w, r)
+			} else {
+				s.validateBody(http.MethodPost, "sync", s.SyncHandler)(w, r)
+			}
+		})
+	}
+
+	if s.reports != nil {
+		s.mux.HandleFunc("/api/reports/schedules", s.validateBody(http.MethodPost, "report_schedule", s.ReportSchedulesHandler))
+		s.mux.HandleFunc("/api/reports/schedules/", s.ReportScheduleHandler)
+	}
+
+	if s.accountsEnabled() {
+		s.mux.HandleFunc("/login", s.LoginPageHandler)
+		s.mux.HandleFunc("/api/auth/register", s.validateBody(http.MethodPost, "credentials", s.RegisterHandler))
+		s.mux.HandleFunc("/api/auth/login", s.validateBody(http.MethodPost, "credentials", s.LoginHandler))
+		s.mux.HandleFunc("/api/auth/logout", s.LogoutHandler)
+		s.mux.HandleFunc("/api/auth/refresh", s.RefreshHandler)
+		s.mux.HandleFunc("/api/auth/me", s.MeHandler)
+		s.mux.HandleFunc("/api/auth/password", s.validateBody(http.MethodPost, "password_change", s.ChangePasswordHandler))
+		if s.emailVerificationEnabled() {
+			s.mux.HandleFunc("/api/auth/verify", s.VerifyEmailHandler)
+			s.mux.HandleFunc("/api/auth/verify/resend", s.validateBody(http.MethodPost, "verify_resend", s.ResendVerificationHandler))
+		}
+		s.mux.HandleFunc("/api/keys", s.validateBody(http.MethodPost, "api_key", s.APIKeysHandler))
+		s.mux.HandleFunc("/api/keys/", func(w http.ResponseWriter, r *http.Request) {
+			switch action, _ := pathAction(r.URL.Path, "/api/keys/"); action {
+			case "usage":
+				s.APIKeyUsageHandler(w, r)
+			default:
+				s.RevokeAPIKeyHandler(w, r)
+			}
+		})
+		s.mux.HandleFunc("/api/sessions", s.SessionsHandler)
+		s.mux.HandleFunc("/api/sessions/", s.RevokeSessionHandler)
+		s.mux.HandleFunc("/api/admin/invites", s.requireAdmin(s.validateBody(http.MethodPost, "invite", s.InvitesHandler)))
+		s.mux.HandleFunc("/api/admin/invites/", s.requireAdmin(s.RevokeInviteHandler))
+		if s.config.SCIMToken != "" {
+			s.mux.HandleFunc("/scim/v2/ServiceProviderConfig", s.requireToken("scim", s.config.SCIMToken, s.SCIMConfigHandler))
+			s.mux.HandleFunc("/scim/v2/Users", s.requireToken("scim", s.config.SCIMToken, s.SCIMUsersHandler))
+			s.mux.HandleFunc("/scim/v2/Users/", s.requireToken("scim", s.config.SCIMToken, s.SCIMUserHandler))
+			if s.workspaces != nil {
+				s.mux.HandleFunc("/scim/v2/Groups", s.requireToken("scim", s.config.SCIMToken, s.SCIMGroupsHandler))
+				s.mux.HandleFunc("/scim/v2/Groups/", s.requireToken("scim", s.config.SCIMToken, s.SCIMGroupHandler))
+			}
+		}
+	}
+
+	if s.workspaces != nil {
+		s.mux.HandleFunc("/w/", s.WorkspaceHandler)
+		s.mux.HandleFunc("/api/admin/workspaces", s.requireAdmin(s.validateBody(http.MethodPost, "workspace", s.WorkspacesHandler)))
+		s.mux.HandleFunc("/api/admin/workspaces/", s.requireAdmin(s.DeleteWorkspaceHandler))
+	}
+}
+
+// HomeHandler はトップページ（index.html）を返します
+// ほかのハンドラに当たらなかったパスもここに届くため、/ 以外は 404 を返します
+func (s *Server) HomeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.template == nil {
+		s.renderPage(w, r, "index.html", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.template.Execute(w, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to render index page", "err", err)
+	}
+}
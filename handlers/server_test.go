@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestServerRouting(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	s.store.AddTask(ctx, "Routed")
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/api/tasks", "", http.StatusOK},
+		{"POST", "/api/tasks", `{"title": "New"}`, http.StatusOK},
+		{"DELETE", "/api/tasks", "", http.StatusMethodNotAllowed},
+		{"PUT", "/api/tasks/1/toggle", "", http.StatusOK},
+		{"DELETE", "/api/tasks/1", "", http.StatusOK},
+		{"GET", "/api/webhooks", "", http.StatusOK},
+		{"POST", "/api/webhooks", `{"url": "https://example.com/hook"}`, http.StatusOK},
+		{"DELETE", "/api/webhooks/1", "", http.StatusOK},
+		{"GET", "/api/export/markdown", "", http.StatusOK},
+		{"POST", "/api/export/notion", "", http.StatusNotFound},
+		{"PROPFIND", "/dav/Tasks/", "", http.StatusMultiStatus},
+		{"GET", "/dav/Tasks/2.md", "", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected status code %d, got %d", tc.method, tc.path, tc.status, rr.Code)
+		}
+	}
+
+	if tasks := s.Store().GetTasks(ctx); len(tasks) != 1 || tasks[0].Title != "New" {
+		t.Errorf("Unexpected tasks after routing: %+v", tasks)
+	}
+}
+
+func TestServersAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	first := NewServer(Deps{})
+	second := NewServer(Deps{})
+
+	req := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Only in first"}`))
+	first.ServeHTTP(httptest.NewRecorder(), req)
+	req = httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(`{"url": "https://example.com/hook"}`))
+	first.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(first.Store().GetTasks(ctx)) != 1 || len(first.Webhooks().List()) != 1 {
+		t.Error("Expected the first server to keep its task and webhook")
+	}
+	if len(second.Store().GetTasks(ctx)) != 0 || len(second.Webhooks().List()) != 0 {
+		t.Error("Expected the second server to be unaffected")
+	}
+}
+
+func TestHomeHandlerServesEmbeddedPage(t *testing.T) {
+	staticDir := t.TempDir()
+	os.WriteFile(filepath.Join(staticDir, "style.css"), []byte("body {}"), 0644)
+
+	s := NewServer(Deps{Config: Config{StaticDir: staticDir}})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "ToDo リスト") {
+		t.Errorf("Unexpected home page: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/static/style.css", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "body {}" {
+		t.Errorf("Unexpected static file: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 当てはまるハンドラのないパスでトップページを返さないこと
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/no-such-page", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown page, got %d", rr.Code)
+	}
+}
+
+func TestHomeHandlerTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("index").Parse(`<h1>{{"ToDo リスト"}}</h1>`))
+	s := NewServer(Deps{Template: tmpl})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	if rr.Body.String() != "<h1>ToDo リスト</h1>" {
+		t.Errorf("Expected rendered template, got %s", rr.Body.String())
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected HTML content type, got %s", contentType)
+	}
+}
+
+func TestHomeHandlerTemplateError(t *testing.T) {
+	var logs bytes.Buffer
+	tmpl := template.Must(template.New("index").Parse(`{{template "missing"}}`))
+	s := NewServer(Deps{Template: tmpl, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(logs.String(), "failed to render index page") {
+		t.Errorf("Expected template error to be logged, got %q", logs.String())
+	}
+}
+
+type requestKey struct{}
+
+// ハンドラはリクエストのコンテキストをストアへ渡し、イベントの購読者まで引き継がれること
+func TestHandlersPassRequestContext(t *testing.T) {
+	s := newTestServer()
+	var values []interface{}
+	s.store.Subscribe(func(event models.Event) {
+		values = append(values, event.Context().Value(requestKey{}))
+	})
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"POST", "/api/tasks", `{"title": "Traced"}`},
+		{"PUT", "/api/tasks/1/toggle", ""},
+		{"DELETE", "/api/tasks/1", ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req = req.WithContext(context.WithValue(req.Context(), requestKey{}, tc.method))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(values) != 3 || values[0] != "POST" || values[1] != "PUT" || values[2] != "DELETE" {
+		t.Errorf("Expected events to carry each request's context, got %v", values)
+	}
+}
+
+func TestRequireStore(t *testing.T) {
+	var failing error
+	s := NewServer(Deps{CheckStore: func(context.Context) error { return failing }})
+
+	failing = errors.New("git commit: index.lock exists")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Lost"}`)))
+	assertErrorResponse(t, rr, http.StatusServiceUnavailable, "unavailable")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected reads to keep working, got %d", rr.Code)
+	}
+
+	failing = nil
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Saved"}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the change to be accepted once the store recovers, got %d", rr.Code)
+	}
+}
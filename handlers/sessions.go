@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// errSessionNotFound はセッションが見つからないこと（ほかのユーザーのセッションを含みます）を表します
+var errSessionNotFound = errors.New("session not found")
+
+// SessionsHandler はログインしているユーザーのセッション（端末）の一覧（GET）と、すべての端末からのログアウト（DELETE）を行います
+// 一覧ではリクエストのセッションに current を付けます。すべての端末からログアウトすると「ログインしたままにする」端末も取り消し、
+// リクエストのセッションと refresh token の Cookie も消します
+func (s *Server) SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	current := ""
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		current = cookie.Value
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"sessions": s.sessions.List(user.ID, current),
+		})
+	case http.MethodDelete:
+		revoked := s.sessions.RevokeAll(user.ID)
+		s.audit(r, slog.LevelInfo, "logged out everywhere", "user_id", user.ID, "sessions", revoked)
+		if current != "" {
+			s.setSessionCookie(w, r, "", time.Time{})
+		}
+		if _, err := r.Cookie(RefreshCookie); err == nil {
+			s.setRefreshCookie(w, r, "", time.Time{})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"revoked": revoked,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// RevokeSessionHandler は URL の ID のセッションを取り除きます（DELETE）。その端末はすぐにログアウトした状態になります
+// 「ログインしたままにする」端末なら refresh token も取り消すため、その端末は自動でセッションを始め直せません
+func (s *Server) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextUser(r)
+	if !ok {
+		s.writeError(w, r, errUnauthorized)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, "/api/sessions/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if !s.sessions.Revoke(user.ID, id) {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errSessionNotFound, id))
+		return
+	}
+	s.audit(r, slog.LevelInfo, "revoked session", "user_id", user.ID, "session_id", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
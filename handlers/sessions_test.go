@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+)
+
+func TestSessionsHandler(t *testing.T) {
+	s := newTestAccountsServer(t)
+	laptop := sessionCookie(t, authRequest(s, "register", "alice", "correct horse"))
+	bob := sessionCookie(t, authRequest(s, "register", "bob", "battery staple"))
+
+	request := func(method, path string, cookie *http.Cookie, userAgent string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(cookie)
+		req.Header.Set("User-Agent", userAgent)
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+	login := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"name": "alice", "password": "correct horse"}`))
+	login.Header.Set("User-Agent", "Phone")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, login)
+	phone := sessionCookie(t, rr)
+
+	rr = request("GET", "/api/sessions", phone, "Phone")
+	var got struct {
+		Success  bool               `json:"success"`
+		Sessions []accounts.Session `json:"sessions"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	if rr.Code != http.StatusOK || len(got.Sessions) != 2 {
+		t.Fatalf("Expected alice's 2 sessions, got %d %s", rr.Code, rr.Body.String())
+	}
+	if got.Sessions[0].Current || !got.Sessions[1].Current || got.Sessions[1].UserAgent != "Phone" || got.Sessions[1].IP != "192.0.2.1" {
+		t.Errorf("Unexpected sessions %+v", got.Sessions)
+	}
+	if strings.Contains(rr.Body.String(), phone.Value) {
+		t.Error("Expected the list not to include the tokens")
+	}
+
+	// ほかのユーザーのセッションは取り除けません
+	path := "/api/sessions/" + strconv.Itoa(got.Sessions[0].ID)
+	assertErrorResponse(t, request("DELETE", path, bob, ""), http.StatusNotFound, "not_found")
+	assertErrorResponse(t, request("GET", path, phone, ""), http.StatusMethodNotAllowed, "method_not_allowed")
+	if rr := request("DELETE", path, phone, ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the session to be revoked, got %d %s", rr.Code, rr.Body.String())
+	}
+	assertErrorResponse(t, request("GET", "/api/tasks", laptop, ""), http.StatusUnauthorized, "unauthorized")
+
+	// すべての端末からのログアウトは、リクエストのセッションも取り除き Cookie を消します
+	rr = request("DELETE", "/api/sessions", phone, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"revoked":1`) {
+		t.Fatalf("Expected to log out everywhere, got %d %s", rr.Code, rr.Body.String())
+	}
+	if cookie := sessionCookie(t, rr); cookie.Value != "" {
+		t.Errorf("Expected the cookie to be cleared, got %q", cookie.Value)
+	}
+	assertErrorResponse(t, request("GET", "/api/sessions", phone, ""), http.StatusUnauthorized, "unauthorized")
+	if rr := request("GET", "/api/sessions", bob, ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected bob to stay logged in, got %d", rr.Code)
+	}
+}
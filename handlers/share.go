@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"todo-app/models"
+	"todo-app/share"
+)
+
+// SharesHandler は共有リンクの一覧（GET）と発行（POST {"list_id": 1, "name": "..."}）を行います
+// 共有リンクはリストごとに発行し、list_id のリストがなければ 400 を返します
+func (s *Server) SharesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"shares":  s.shares.List(),
+		})
+	case http.MethodPost:
+		var req struct {
+			ListID int    `json:"list_id"`
+			Name   string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		if _, err := s.lists.Get(req.ListID); err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: list_id %d does not exist", models.ErrValidation, req.ListID))
+			return
+		}
+		link, err := s.shares.Create(req.ListID, req.Name)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"share":   link,
+			"url":     s.config.BasePath + "/share/" + link.Token,
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// RevokeShareHandler は URL からトークンを取り出し、その共有リンクを取り消します
+func (s *Server) RevokeShareHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/api/admin/shares/")
+	revoked, err := s.shares.Revoke(token)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !revoked {
+		s.writeError(w, r, fmt.Errorf("%w: %q", errShareNotFound, token))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
+
+// SharePageHandler は共有リンクのリストのタスクを読み取り専用で返します（認証は不要です）
+// リストを削除したリンクは取り消したものと同じく 404 を返します。通常は share.html を返し、?format=json を付けると一覧を JSON で返します
+// /share/{token}/qr.png は共有リンクを指す QR コードを返します（冷蔵庫に貼ってスマートフォンで開く用途など）
+func (s *Server) SharePageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	// リンクを知っている人だけが見られるよう、検索エンジンやリファラに URL を残さないようにします
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	token := strings.TrimPrefix(r.URL.Path, "/share/")
+	qr := strings.HasSuffix(token, "/qr.png")
+	token = strings.TrimSuffix(token, "/qr.png")
+	link, ok := s.shares.Lookup(token)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: the link may have been revoked", errShareNotFound))
+		return
+	}
+	list, err := s.lists.Get(link.ListID)
+	if err != nil {
+		s.writeError(w, r, fmt.Errorf("%w: the shared list has been deleted", errShareNotFound))
+		return
+	}
+	if qr {
+		s.writeQRCode(w, r, s.absoluteURL(r, "/share/"+link.Token))
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"share":   share.NewView(link, list.Name, s.store.GetTasks(r.Context())),
+		})
+		return
+	}
+	s.static.serve(w, r, "share.html")
+}
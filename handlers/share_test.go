@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/models"
+	"todo-app/share"
+)
+
+func newShareTestServer(t *testing.T) *Server {
+	staticDir := t.TempDir()
+	os.WriteFile(filepath.Join(staticDir, "share.html"), []byte("<h1>共有されたタスク</h1>"), 0644)
+	return NewServer(Deps{Config: Config{StaticDir: staticDir, AdminToken: "secret"}})
+}
+
+// adminBodyRequest は adminRequest にボディを付けたものです
+func adminBodyRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	return req
+}
+
+func TestSharesHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newShareTestServer(t)
+	groceries, _ := s.lists.Create("買い物")
+	listID := groceries.ID
+	task, _ := s.Store().AddTask(ctx, "Buy milk")
+	s.Store().ToggleTask(ctx, task.ID)
+	s.Store().UpdateTask(ctx, task.ID, models.TaskUpdate{ListID: &listID})
+	s.Store().AddTask(ctx, "Private task")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/shares", `{"list_id": 1, "name": "Family"}`))
+	var created struct {
+		Success bool       `json:"success"`
+		Share   share.Link `json:"share"`
+		URL     string     `json:"url"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || !created.Success || created.URL != "/share/"+created.Share.Token || created.Share.ListID != 1 {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 認証なしで、共有したリストのタスクだけを読み取り専用で見られます
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", created.URL+"?format=json", nil))
+	var viewed struct {
+		Success bool       `json:"success"`
+		Share   share.View `json:"share"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &viewed); err != nil || !viewed.Success || viewed.Share.Name != "Family" || viewed.Share.List != "買い物" ||
+		len(viewed.Share.Tasks) != 1 || !viewed.Share.Tasks[0].Completed {
+		t.Errorf("Unexpected shared view: %s", rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), `"id"`) || rr.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("Expected a view without task IDs that is not indexed: %v %s", rr.Header(), rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", created.URL, nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "共有されたタスク") {
+		t.Errorf("Unexpected share page: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("GET", "/api/admin/shares", ""))
+	var listed struct {
+		Shares []share.Link `json:"shares"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil || len(listed.Shares) != 1 || listed.Shares[0].Name != "Family" {
+		t.Errorf("Unexpected shares: %s", rr.Body.String())
+	}
+
+	// 取り消したリンクは見られなくなります
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("DELETE", "/api/admin/shares/"+created.Share.Token, ""))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to revoke the link: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", created.URL, nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestSharesHandlerWithoutName(t *testing.T) {
+	s := newShareTestServer(t)
+	s.lists.Create("買い物")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/shares", `{"list_id": 1}`))
+	if rr.Code != http.StatusOK || len(s.shares.List()) != 1 {
+		t.Fatalf("Expected a link without a name, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// リストの名前を表示し、リストを削除するとリンクは見られなくなります
+	token := s.shares.List()[0].Token
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/share/"+token+"?format=json", nil))
+	if !strings.Contains(rr.Body.String(), `"name":"買い物"`) {
+		t.Errorf("Expected the list name, got %s", rr.Body.String())
+	}
+	s.lists.Delete(1)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/share/"+token+"?format=json", nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestSharesHandlerErrors(t *testing.T) {
+	s := newShareTestServer(t)
+
+	testCases := []struct {
+		req    *http.Request
+		status int
+		code   string
+	}{
+		{adminBodyRequest("POST", "/api/admin/shares", `{invalid`), http.StatusBadRequest, "invalid"},
+		{adminBodyRequest("POST", "/api/admin/shares", ``), http.StatusBadRequest, "invalid"},
+		{adminBodyRequest("POST", "/api/admin/shares", `{"list_id": 9}`), http.StatusBadRequest, "invalid"},
+		{adminBodyRequest("PUT", "/api/admin/shares", ""), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{adminBodyRequest("DELETE", "/api/admin/shares/unknown", ""), http.StatusNotFound, "not_found"},
+		{adminBodyRequest("GET", "/api/admin/shares/unknown", ""), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{httptest.NewRequest("GET", "/share/unknown", nil), http.StatusNotFound, "not_found"},
+		{httptest.NewRequest("POST", "/share/unknown", nil), http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, tc.req)
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+
+	// 管理用のトークンがなければ発行できません
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/admin/shares", nil))
+	if rr.Code != http.StatusUnauthorized || len(s.shares.List()) != 0 {
+		t.Errorf("Expected 401 without the admin token, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ShortLinkHandler はタスクの短いリンク（/t/{shortcode}）を返します。まだなければ発行します
+func (s *Server) ShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "shortlink")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	code, err := s.shortlinks.CodeFor(id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"shortcode": code,
+		"url":       s.config.BasePath + "/t/" + code,
+	})
+}
+
+// ShortLinkRedirectHandler は /t/{shortcode} をタスクの詳細画面（/tasks/{id}）へリダイレクトします
+// 発行していないコードや、削除したタスクのコードは 404 を返します
+func (s *Server) ShortLinkRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	code := strings.TrimPrefix(r.URL.Path, "/t/")
+	id, ok := s.shortlinks.Resolve(code)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: short link %q", errPathNotFound, code))
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("%s/tasks/%d", s.config.BasePath, id), http.StatusFound)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestShortLinkHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	task, _ := s.Store().AddTask(ctx, "Reference me")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/shortlink", nil))
+	var response struct {
+		Success   bool   `json:"success"`
+		Shortcode string `json:"shortcode"`
+		URL       string `json:"url"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || !response.Success || response.URL != "/t/"+response.Shortcode {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", response.URL, nil))
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/tasks/1" {
+		t.Errorf("Expected a redirect to the task, got %d %v", rr.Code, rr.Header())
+	}
+
+	// 削除したタスクの短いリンクは 404 になります
+	s.Store().DeleteTask(ctx, task.ID)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", response.URL, nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
+
+func TestShortLinkHandlerErrors(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/tasks/99/shortlink", http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/abc/shortlink", http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/1/shortlink", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/t/unknown", http.StatusNotFound, "not_found"},
+		{"POST", "/t/unknown", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staticFiles は /static/ の静的ファイル（CSS・JavaScript・アイコン）と、静的な画面の HTML を返します
+// 内容から作った ETag を付け、ブラウザには手元のコピーを使う前に確認させます（Cache-Control: no-cache）。
+// 変わっていなければ 304 を返すため、ファイルを入れ替えてもすぐに反映され、変わっていなければ本文を送り直しません
+// immutable が true なら（バイナリに埋め込んだファイル）、ETag を一度だけ計算して覚えておきます
+type staticFiles struct {
+	fsys      fs.FS
+	immutable bool
+	etags     sync.Map
+}
+
+// newStaticFiles は fsys のファイルを返す staticFiles を作成します
+func newStaticFiles(fsys fs.FS, immutable bool) *staticFiles {
+	return &staticFiles{fsys: fsys, immutable: immutable}
+}
+
+// ServeHTTP は URL のパスのファイルを返します。/static/ を取り除いてから呼び出してください
+// ディレクトリの一覧は返しません
+func (f *staticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	f.serve(w, r, strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/"))
+}
+
+// serve は name のファイルを返します。なければ 404 を返します
+func (f *staticFiles) serve(w http.ResponseWriter, r *http.Request, name string) {
+	data, err := fs.ReadFile(f.fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("ETag", f.etag(name, data))
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	// 更新日時の代わりに ETag（If-None-Match）で変わったかを確かめます
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+// etag は name の内容 data の ETag を返します
+func (f *staticFiles) etag(name string, data []byte) string {
+	if f.immutable {
+		if etag, ok := f.etags.Load(name); ok {
+			return etag.(string)
+		}
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	if f.immutable {
+		f.etags.Store(name, etag)
+	}
+	return etag
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFilesETag(t *testing.T) {
+	files := fstest.MapFS{
+		"style.css":   {Data: []byte("body {}")},
+		"today.html":  {Data: []byte("<h1>today</h1>")},
+		"icons/a.svg": {Data: []byte("<svg></svg>")},
+	}
+	s := NewServer(Deps{Static: files})
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/static/style.css", nil))
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || rr.Body.String() != "body {}" || etag == "" {
+		t.Fatalf("Expected the file with an ETag, got %d %q %q", rr.Code, rr.Body.String(), etag)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected Cache-Control no-cache, got %q", got)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/css; charset=utf-8" {
+		t.Errorf("Expected the CSS content type, got %q", got)
+	}
+
+	// 変わっていなければ本文を送り直さないこと
+	req := httptest.NewRequest("GET", "/static/style.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("Expected 304 for a matching ETag, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	// 静的な画面も同じファイルから返すこと
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/today", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "<h1>today</h1>" || rr.Header().Get("ETag") == "" {
+		t.Errorf("Expected the today page from the static files, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestStaticFilesNotFound(t *testing.T) {
+	s := NewServer(Deps{Static: fstest.MapFS{"icons/a.svg": {Data: []byte("<svg></svg>")}}})
+
+	for _, path := range []string{"/static/missing.js", "/static/", "/static/icons/"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d %q", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/static/icons/a.svg", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rr.Code)
+	}
+}
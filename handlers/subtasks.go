@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"todo-app/models"
+)
+
+// SubtasksHandler はタスクのサブタスク（チェックリスト）を扱います
+// GET /api/tasks/{id}/subtasks: サブタスクを一覧の順に返し、親タスクの進み具合（progress）も返します
+// POST /api/tasks/{id}/subtasks: リクエストのJSON {"title": "本を箱に詰める"} のサブタスクを追加し、追加したタスクと親タスクの進み具合を返します
+// サブタスクは親タスクと同じリストに入ります。完了状態は通常のタスクと同じく /toggle で切り替えます
+func (s *Server) SubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "subtasks")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	parent, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		tasks := s.store.GetTasks(r.Context())
+		models.RollUp(tasks)
+		subtasks := models.Subtasks(tasks, id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  true,
+			"subtasks": subtasks,
+			"progress": progressOf(tasks, id),
+		})
+		return
+	}
+
+	var req struct {
+		Title string   `json:"title"`
+		Index []string `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := s.validateEncrypted(req.Title, req.Index); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := models.CheckParent(s.store.GetTasks(r.Context()), 0, id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	task, err := s.addSubtask(r.Context(), parent, req.Title, req.Index)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tasks := s.store.GetTasks(r.Context())
+	models.RollUp(tasks)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"task":     task,
+		"progress": progressOf(tasks, id),
+	})
+}
+
+// addSubtask は title のタスクを parent のサブタスクとして、parent と同じリストに追加し、追加したタスクを返します
+// 親にできるかは呼び出し側で models.CheckParent を使って確かめてください
+func (s *Server) addSubtask(ctx context.Context, parent models.Task, title string, index []string) (models.Task, error) {
+	update := models.TaskUpdate{ParentID: &parent.ID}
+	if parent.ListID != 0 {
+		update.ListID = &parent.ListID
+	}
+	if len(index) > 0 {
+		update.BlindIndex = &index
+	}
+	return s.store.AddTaskWith(ctx, title, update)
+}
+
+// progressOf は RollUp した tasks から id のタスクの進み具合を返します（サブタスクがなければ 0 件の進み具合です）
+func progressOf(tasks []models.Task, id int) models.Progress {
+	for _, task := range tasks {
+		if task.ID == id && task.Progress != nil {
+			return *task.Progress
+		}
+	}
+	return models.Progress{}
+}
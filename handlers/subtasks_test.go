@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"todo-app/models"
+)
+
+func TestSubtasksHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/lists", `{"name": "Home"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Move house", "list_id": 1}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Water plants"}`)
+
+	var added struct {
+		Success  bool            `json:"success"`
+		Task     models.Task     `json:"task"`
+		Progress models.Progress `json:"progress"`
+	}
+	rr := listRequest(s, "POST", "/api/tasks/1/subtasks", `{"title": "Pack books"}`)
+	json.Unmarshal(rr.Body.Bytes(), &added)
+	if rr.Code != http.StatusOK || added.Task.ParentID != 1 || added.Task.ListID != 1 || added.Progress != (models.Progress{Total: 1}) {
+		t.Fatalf("Expected a subtask in the parent's list, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/tasks/1/subtasks", `{"title": "Book a truck"}`)
+	listRequest(s, "PUT", "/api/tasks/3/toggle", ``)
+
+	var listed struct {
+		Subtasks []models.Task   `json:"subtasks"`
+		Progress models.Progress `json:"progress"`
+	}
+	rr = listRequest(s, "GET", "/api/tasks/1/subtasks", ``)
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if len(listed.Subtasks) != 2 || listed.Progress != (models.Progress{Completed: 1, Total: 2, Percent: 50}) {
+		t.Errorf("Expected 2 subtasks with 50%% done, got %s", rr.Body.String())
+	}
+
+	// 一覧では、絞り込んでも親タスクに進み具合が付きます
+	var tasks []models.Task
+	rr = listRequest(s, "GET", "/api/tasks?q=-completed", ``)
+	json.Unmarshal(rr.Body.Bytes(), &tasks)
+	if len(tasks) != 3 || tasks[0].Progress == nil || tasks[0].Progress.Percent != 50 || tasks[1].Progress != nil {
+		t.Errorf("Expected the parent to carry the roll-up, got %s", rr.Body.String())
+	}
+
+	// PATCH で親タスクを付け替え、0 で外します
+	rr = listRequest(s, "PATCH", "/api/tasks/2", `{"parent_id": 1}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the task to become a subtask, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = listRequest(s, "PATCH", "/api/tasks/2", `{"parent_id": 0}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the task to leave its parent, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", "/api/tasks/3/subtasks", `{"title": "Too deep"}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/subtasks", `{"title": ""}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/subtasks", `{}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/9/subtasks", `{"title": "Orphan"}`, http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/9/subtasks", ``, http.StatusNotFound, "not_found"},
+		{"PATCH", "/api/tasks/1", `{"parent_id": 2}`, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks/2", `{"parent_id": 2}`, http.StatusBadRequest, "invalid"},
+		{"PATCH", "/api/tasks/2", `{"parent_id": 9}`, http.StatusBadRequest, "invalid"},
+		{"DELETE", "/api/tasks/1/subtasks", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tc := range testCases {
+		assertErrorResponse(t, listRequest(s, tc.method, tc.path, tc.body), tc.status, tc.code)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo-app/models"
+)
+
+// suggestionsEnabled は小さな作業に分ける案を作れるかを返します
+// 暗号化するモードではサーバがタイトルを読めないため、LLM を設定していても使えません
+func (s *Server) suggestionsEnabled() bool {
+	return s.suggester != nil && s.e2e == nil
+}
+
+// SuggestionsHandler は小さな作業に分ける案を作れるかを返します（画面で 💡 ボタンを出すかどうかに使います）
+func (s *Server) SuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+		"enabled": s.suggestionsEnabled(),
+	})
+}
+
+// SuggestSubtasksHandler はタスクのタイトルから LLM で小さな作業に分ける案を作って返します（POST /api/tasks/{id}/suggest-subtasks）
+// 案はまだ保存しません。使うものを選んで /accept へ送ると、タスクとして追加します
+func (s *Server) SuggestSubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "suggest-subtasks")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	suggestions, err := s.suggester.SuggestSubtasks(r.Context(), task.Title)
+	if err != nil {
+		s.logger.WarnContext(r.Context(), "llm: suggestions failed", "task_id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"task_id":     id,
+		"suggestions": suggestions,
+	})
+}
+
+// AcceptSubtasksHandler は選んだ案を元のタスクのサブタスクとして追加します（POST /api/tasks/{id}/suggest-subtasks/accept）
+// {"subtasks": ["会場を予約する", ...]} の順に追加し、追加したタスクを返します
+// 元のタスクがサブタスクなら（サブタスクは1段だけのため）400 を返します
+func (s *Server) AcceptSubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "suggest-subtasks/accept")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	parent, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Subtasks []string `json:"subtasks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	if err := models.CheckParent(s.store.GetTasks(r.Context()), 0, id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	tasks := []models.Task{}
+	for _, title := range req.Subtasks {
+		task, err := s.addSubtask(r.Context(), parent, title, nil)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"tasks":   tasks,
+	})
+}
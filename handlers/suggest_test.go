@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/integrations/llm"
+	"todo-app/logging"
+	"todo-app/models"
+)
+
+// newTestSuggestServer は content を案として返す LLM のフェイクを使うサーバを作成します
+func newTestSuggestServer(t *testing.T, status int, content string) *Server {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": content}}},
+		})
+	}))
+	t.Cleanup(provider.Close)
+	store := models.NewTodoApp()
+	store.AddTask(context.Background(), "歓迎会の準備")
+	return NewServer(Deps{
+		Store:     store,
+		Suggester: llm.NewSuggester(llm.Config{BaseURL: provider.URL}, provider.Client()),
+		Logger:    logging.Discard(),
+	})
+}
+
+func TestSuggestSubtasksHandler(t *testing.T) {
+	s := newTestSuggestServer(t, http.StatusOK, "会場を予約する\n招待状を送る")
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/suggestions", nil))
+	if !strings.Contains(rr.Body.String(), `"enabled":true`) {
+		t.Errorf("Expected suggestions to be enabled, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/suggest-subtasks", nil))
+	var suggested struct {
+		Success     bool     `json:"success"`
+		TaskID      int      `json:"task_id"`
+		Suggestions []string `json:"suggestions"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &suggested)
+	if rr.Code != http.StatusOK || !suggested.Success || suggested.TaskID != 1 || len(suggested.Suggestions) != 2 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if len(s.store.GetTasks(context.Background())) != 1 {
+		t.Error("Expected suggestions not to create tasks")
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/suggest-subtasks/accept", strings.NewReader(`{"subtasks": ["会場を予約する", "招待状を2日までに送る"]}`)))
+	var accepted struct {
+		Success bool          `json:"success"`
+		Tasks   []models.Task `json:"tasks"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &accepted)
+	if rr.Code != http.StatusOK || len(accepted.Tasks) != 2 || accepted.Tasks[1].Title != "招待状を2日までに送る" {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if len(s.store.GetTasks(context.Background())) != 3 || accepted.Tasks[0].ParentID != 1 {
+		t.Error("Expected the accepted suggestions to be added as subtasks")
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/tasks/1/suggest-subtasks", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/api/tasks/9/suggest-subtasks", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/9/suggest-subtasks/accept", `{"subtasks": ["a"]}`, http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/1/suggest-subtasks/accept", `{"subtasks": []}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/suggest-subtasks/accept", `{"subtasks": [""]}`, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/2/suggest-subtasks/accept", `{"subtasks": ["a"]}`, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/1/suggest-subtasks/accept", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/api/tasks/1/suggest-subtasks/reject", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/suggestions", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.AcceptSubtasksHandler(rr, httptest.NewRequest("POST", "/api/tasks/1/suggest-subtasks/accept", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
+
+func TestSuggestSubtasksHandlerProviderError(t *testing.T) {
+	s := newTestSuggestServer(t, http.StatusTooManyRequests, "")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/suggest-subtasks", nil))
+	assertErrorResponse(t, rr, http.StatusBadGateway, "bad_gateway")
+}
+
+func TestSuggestSubtasksDisabled(t *testing.T) {
+	encrypted := newE2EServer(t)
+	encrypted.suggester = llm.NewSuggester(llm.Config{}, nil)
+	for name, s := range map[string]*Server{
+		"without a provider": newTestServer(),
+		"in encrypted mode":  encrypted,
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/suggestions", nil))
+		if !strings.Contains(rr.Body.String(), `"enabled":false`) {
+			t.Errorf("%s: expected suggestions to be disabled, got %s", name, rr.Body.String())
+		}
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/suggest-subtasks", nil))
+		assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+	}
+}
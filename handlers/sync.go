@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo-app/crdt"
+	"todo-app/e2ee"
+)
+
+// syncRequest は POST /api/sync で端末が送る Doc です
+type syncRequest struct {
+	Docs []crdt.Doc `json:"docs"`
+}
+
+// SyncHandler は端末とタスクの Doc（CRDT）を同期します
+// GET はサーバの Doc をすべて返し、POST は端末の Doc をマージしてタスクに反映してから、マージした後の Doc をすべて返します
+// 端末は返された Doc を自分の Doc とマージするだけでよく、編集がぶつかっても確認を求めません
+func (s *Server) SyncHandler(w http.ResponseWriter, r *http.Request) {
+	var req syncRequest
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		if s.e2e != nil {
+			for _, doc := range req.Docs {
+				if title, ok := doc.Fields[crdt.FieldTitle]; ok {
+					var text string
+					json.Unmarshal(title.Value, &text)
+					if err := e2ee.ValidateTitle(text); err != nil {
+						s.writeError(w, r, err)
+						return
+					}
+				}
+			}
+		}
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	docs, err := s.sync.Sync(r.Context(), req.Docs)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"replica": crdt.ServerReplica,
+		"docs":    docs,
+	})
+}
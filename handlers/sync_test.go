@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/crdt"
+	"todo-app/e2ee"
+	"todo-app/models"
+)
+
+func newSyncServer(t *testing.T, store models.TaskStore, keys *e2ee.KeyStore) *Server {
+	t.Helper()
+	syncer, err := crdt.NewSyncer("", store)
+	if err != nil {
+		t.Fatalf("NewSyncer failed: %v", err)
+	}
+	return NewServer(Deps{Store: store, Sync: syncer, E2E: keys})
+}
+
+type syncResponse struct {
+	Success bool       `json:"success"`
+	Replica string     `json:"replica"`
+	Docs    []crdt.Doc `json:"docs"`
+}
+
+func TestSyncHandler(t *testing.T) {
+	store := models.NewTodoApp()
+	store.AddTask(context.Background(), "On the server")
+	s := newSyncServer(t, store, nil)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/sync", nil))
+	var response syncResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || response.Replica != "server" || len(response.Docs) != 1 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	body := `{"docs": [{"uid": "phone:1", "fields": {
+		"title": {"value": "From the phone", "stamp": {"wall": 1700000000000, "replica": "phone"}},
+		"priority": {"value": "high", "stamp": {"wall": 1700000000000, "replica": "phone"}}
+	}}]}`
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/sync", strings.NewReader(body)))
+	response = syncResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || len(response.Docs) != 2 || response.Docs[0].UID != "phone:1" || response.Docs[0].TaskID != 2 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	tasks := store.GetTasks(context.Background())
+	if len(tasks) != 2 || tasks[1].Title != "From the phone" || tasks[1].Priority != models.PriorityHigh {
+		t.Errorf("Expected the doc to be applied to the store, got %+v", tasks)
+	}
+}
+
+func TestSyncHandlerErrors(t *testing.T) {
+	s := newSyncServer(t, models.NewTodoApp(), nil)
+	stamp := `"stamp": {"wall": 1, "replica": "phone"}`
+	tests := []struct {
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", `{`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"docs": [{"uid": "a", "owner": "me"}]}`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"docs": [{"uid": "a", "fields": {"priority": {"value": "urgent", ` + stamp + `}}}]}`, http.StatusBadRequest, "invalid"},
+		{"POST", `{"docs": [{"uid": "a", "fields": {"priority": {"value": "high", ` + stamp + `}}}]}`, http.StatusBadRequest, "invalid"},
+		{"PUT", `{"docs": []}`, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, "/api/sync", strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	// SyncHandler を直接呼んだときも、本文を読めなければ 400 を返します
+	rr := httptest.NewRecorder()
+	s.SyncHandler(rr, httptest.NewRequest("POST", "/api/sync", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest("GET", "/api/sync", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected sync to be unavailable without a Syncer, got %d", rr.Code)
+	}
+}
+
+func TestSyncHandlerRequiresCiphertext(t *testing.T) {
+	keys, err := e2ee.NewKeyStore(filepath.Join(t.TempDir(), "e2e.json"))
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	s := newSyncServer(t, models.NewTodoApp(), keys)
+	stamp := `"stamp": {"wall": 1, "replica": "phone"}`
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/sync", strings.NewReader(`{"docs": [{"uid": "a", "fields": {"title": {"value": "Plain", `+stamp+`}}}]}`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/sync", strings.NewReader(`{"docs": [{"uid": "a", "fields": {"title": {"value": "`+ciphertextTitle(40)+`", `+stamp+`}}}]}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected an encrypted title to be accepted, got %d %s", rr.Code, rr.Body.String())
+	}
+}
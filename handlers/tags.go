@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"todo-app/models"
+)
+
+// AddTagHandler は URL の ID のタスクにタグ（{"tag": "shopping"}）を付け、変更後のタスクを返します（POST /api/tasks/{id}/tags）
+// 名前は小文字にそろえ、すでに付いているタグはそのままにします
+func (s *Server) AddTagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "tags")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	// 不正な名前やタグの付けすぎはモデルが ErrValidation として返します
+	s.updateTags(w, r, id, models.TaskUpdate{AddTags: []string{req.Tag}})
+}
+
+// RemoveTagHandler は URL の ID のタスクから URL のタグを外し、変更後のタスクを返します（DELETE /api/tasks/{id}/tags/{tag}）
+// 付いていないタグを指定してもエラーにはしません
+func (s *Server) RemoveTagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	segments, ok := splitPath(r.URL.Path, "/api/tasks/")
+	if !ok || len(segments) != 3 || segments[1] != "tags" {
+		s.writeError(w, r, errPathNotFound)
+		return
+	}
+	id, err := parsePositiveInt(segments[0])
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.updateTags(w, r, id, models.TaskUpdate{RemoveTags: []string{segments[2]}})
+}
+
+// updateTags はタスク id のタグを update のとおりに変更し、変更後のタスクを返します
+func (s *Server) updateTags(w http.ResponseWriter, r *http.Request, id int, update models.TaskUpdate) {
+	if err := s.store.UpdateTask(r.Context(), id, update); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
+
+// parseTagFilter は ?tag=shopping,work のようにカンマで区切ったタグを読み取ります
+// "none" はタグが1つもないタスクを表し、空の名前を表す "" で返します
+func parseTagFilter(value string) (map[string]bool, error) {
+	tags := map[string]bool{}
+	for _, raw := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(raw), "none") {
+			tags[""] = true
+			continue
+		}
+		tag, err := models.NormalizeTag(raw)
+		if err != nil {
+			return nil, err
+		}
+		tags[tag] = true
+	}
+	return tags, nil
+}
+
+// filterByTag は tags のいずれかが付いているタスク（"" があればタグのないタスクも）だけを返します
+func filterByTag(tasks []models.Task, tags map[string]bool) []models.Task {
+	filtered := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		match := len(task.Tags) == 0 && tags[""]
+		for _, tag := range task.Tags {
+			match = match || tags[tag]
+		}
+		if match {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestTagsHandler(t *testing.T) {
+	s := newTestServer()
+
+	rr := listRequest(s, "POST", "/api/tasks", `{"title": "Buy milk", "tags": ["Shopping", "#errand"]}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"tags":["shopping","errand"]`) {
+		t.Fatalf("Expected the task to be added with normalized tags, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/tasks", `{"title": "Write report"}`)
+	listRequest(s, "POST", "/api/tasks", `{"title": "Call mom"}`)
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks", `{"title": "Bad", "tags": ["two words"]}`), http.StatusBadRequest, "invalid")
+
+	rr = listRequest(s, "POST", "/api/tasks/2/tags", `{"tag": "Work"}`)
+	var added struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &added)
+	if rr.Code != http.StatusOK || !added.Success || !reflect.DeepEqual(added.Task.Tags, []string{"work"}) {
+		t.Errorf("Expected the tag to be added, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "POST", "/api/tasks/2/tags", `{"tag": "work"}`)
+	listRequest(s, "POST", "/api/tasks/2/tags", `{"tag": "shopping"}`)
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks/2/tags", `{"tag": ""}`), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks/2/tags", `{"name": "work"}`), http.StatusBadRequest, "invalid")
+	assertErrorResponse(t, listRequest(s, "POST", "/api/tasks/9/tags", `{"tag": "work"}`), http.StatusNotFound, "not_found")
+
+	titles := func(query string) []string {
+		var tasks []models.Task
+		rr := listRequest(s, "GET", "/api/tasks"+query, "")
+		json.Unmarshal(rr.Body.Bytes(), &tasks)
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+	if got := titles("?tag=shopping"); !reflect.DeepEqual(got, []string{"Buy milk", "Write report"}) {
+		t.Errorf("Expected the shopping tasks, got %v", got)
+	}
+	if got := titles("?tag=errand,work"); len(got) != 2 {
+		t.Errorf("Expected tasks with either tag, got %v", got)
+	}
+	if got := titles("?tag=none"); !reflect.DeepEqual(got, []string{"Call mom"}) {
+		t.Errorf("Expected only the task without tags, got %v", got)
+	}
+	if got := titles("?q=tag:work"); !reflect.DeepEqual(got, []string{"Write report"}) {
+		t.Errorf("Expected the query to filter by tag, got %v", got)
+	}
+	assertErrorResponse(t, listRequest(s, "GET", "/api/tasks?tag=a/b", ""), http.StatusBadRequest, "invalid")
+
+	rr = listRequest(s, "DELETE", "/api/tasks/2/tags/Shopping", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"tags":["work"]`) {
+		t.Errorf("Expected the tag to be removed, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := listRequest(s, "DELETE", "/api/tasks/2/tags/missing", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected removing a missing tag to succeed, got %d %s", rr.Code, rr.Body.String())
+	}
+	assertErrorResponse(t, listRequest(s, "PUT", "/api/tasks/2/tags/work", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+
+	// PATCH はタグを置き換え、PUT は本文にないタグを外します
+	if rr := listRequest(s, "PATCH", "/api/tasks/3", `{"tags": ["family", "phone"]}`); !strings.Contains(rr.Body.String(), `"tags":["family","phone"]`) {
+		t.Errorf("Expected PATCH to replace the tags, got %d %s", rr.Code, rr.Body.String())
+	}
+	listRequest(s, "PUT", "/api/tasks/3", `{"title": "Call mom tonight"}`)
+	if got := titles("?tag=none"); !reflect.DeepEqual(got, []string{"Call mom tonight"}) {
+		t.Errorf("Expected PUT without tags to remove them, got %v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/markdown"
+	"todo-app/models"
+)
+
+// historyLabels は変更履歴に表示する項目の名前です（JSON の名前から）。ないものは JSON の名前のまま表示します
+var historyLabels = map[string]string{
+	"title":            "タイトル",
+	"description":      "説明",
+	"completed":        "完了",
+	"completed_at":     "完了日時",
+	"due_date":         "期限",
+	"scheduled_date":   "予定日",
+	"priority":         "優先度",
+	"tags":             "タグ",
+	"list_id":          "リスト",
+	"parent_id":        "親タスク",
+	"estimate_minutes": "見積もり",
+	"time_entries":     "作業記録",
+	"claimed_by":       "担当者",
+	"claimed_at":       "担当した日時",
+	"recurrence":       "繰り返し",
+	"remind_at":        "リマインダー",
+	"reminded_at":      "リマインダーの通知",
+	"comments":         "コメント",
+}
+
+// historyEvents は変更履歴に表示するイベントの種類の名前です
+var historyEvents = map[models.EventType]string{
+	models.EventTaskCreated:  "作成",
+	models.EventTaskUpdated:  "変更",
+	models.EventTaskDeleted:  "削除",
+	models.EventTaskReminder: "リマインダーを通知",
+}
+
+// taskPage は task.html に渡す値です
+// Description / Comments: Markdown を HTML にした説明とコメント（markdown.Render がエスケープ済み）
+// Parent: サブタスクなら親タスク
+// History: 変更履歴（新しい順）。HistoryEnabled が false ならサーバが変更を記録していません
+// Encrypted: タイトルを暗号化しているか。暗号化しているときはタイトルを表示せず、編集とコメントもできません
+type taskPage struct {
+	Task           models.Task
+	Description    template.HTML
+	Parent         *models.Task
+	Subtasks       []models.Task
+	Progress       models.Progress
+	Comments       []pageComment
+	History        []models.HistoryEntry
+	HistoryEnabled bool
+	Encrypted      bool
+	loc            *time.Location
+}
+
+// pageComment は画面に表示するコメントです
+type pageComment struct {
+	models.Comment
+	HTML template.HTML
+}
+
+// Time は日時を画面のタイムゾーンで返します
+func (p taskPage) Time(t time.Time) string {
+	return t.In(p.loc).Format("2006-01-02 15:04")
+}
+
+// Event はイベントの種類の名前を返します
+func (p taskPage) Event(eventType models.EventType) string {
+	if label, ok := historyEvents[eventType]; ok {
+		return label
+	}
+	return string(eventType)
+}
+
+// Fields は変わった項目の名前を「、」でつないで返します
+func (p taskPage) Fields(fields []string) string {
+	labels := make([]string, len(fields))
+	for i, field := range fields {
+		labels[i] = field
+		if label, ok := historyLabels[field]; ok {
+			labels[i] = label
+		}
+	}
+	return strings.Join(labels, "、")
+}
+
+// TaskPageHandler はタスクの詳細（説明・日時・サブタスク・変更履歴・コメント）を、サーバ側で描画した HTML で返します（GET /tasks/{id}）
+// ?tz=Asia/Tokyo のように日時を表示するタイムゾーンを指定できます（省略時はサーバのタイムゾーン）
+func (s *Server) TaskPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/tasks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	loc, err := parseTimeZone(r.URL.Query().Get("tz"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tasks := s.store.GetTasks(r.Context())
+	models.RollUp(tasks)
+	task, err := findIn(tasks, id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	page := taskPage{
+		Task:           task,
+		Description:    template.HTML(markdown.Render(task.Description)),
+		Subtasks:       models.Subtasks(tasks, id),
+		Progress:       progressOf(tasks, id),
+		History:        models.TaskHistory(s.eventLog.ForTask(id), id),
+		HistoryEnabled: !s.config.DisableLiveSync,
+		Encrypted:      s.e2e != nil,
+		loc:            loc,
+	}
+	if task.ParentID != 0 {
+		if parent, err := findIn(tasks, task.ParentID); err == nil {
+			page.Parent = &parent
+		}
+	}
+	for _, comment := range task.Comments {
+		page.Comments = append(page.Comments, pageComment{Comment: comment, HTML: template.HTML(markdown.Render(comment.Body))})
+	}
+
+	s.renderPage(w, r, "task.html", page)
+}
+
+// TaskHistoryHandler はタスクの変更履歴を新しい順に返します（GET /api/tasks/{id}/history）
+// 履歴はサーバが起動してからの最近の変更（全タスクで直近 1000 件のイベント）だけで、リアルタイム同期を無効にしていると空です
+func (s *Server) TaskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "history")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	history := models.TaskHistory(s.eventLog.ForTask(id), id)
+	if history == nil {
+		history = []models.HistoryEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"history": history,
+	})
+}
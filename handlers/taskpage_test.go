@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestTaskPage(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/tasks", `{"title": "Plan <the> trip", "description": "Pack **light**\n\n<script>x</script>"}`)
+	listRequest(s, "POST", "/api/tasks/1/subtasks", `{"title": "Book flights"}`)
+	listRequest(s, "PUT", "/api/tasks/2/toggle", ``)
+	listRequest(s, "POST", "/api/tasks/1/comments", `{"author": "alice", "body": "See [map](https://example.com)"}`)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/1?tz=UTC", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Unexpected response %d %s", rr.Code, body)
+	}
+	for _, want := range []string{
+		"<h1 class=\"\">Plan &lt;the&gt; trip</h1>",
+		"<p>Pack <strong>light</strong></p>",
+		"&lt;script&gt;x&lt;/script&gt;",
+		`<a href="2" class="completed">Book flights</a>`,
+		"1 / 1 完了",
+		"alice",
+		`<a href="https://example.com" rel="nofollow noopener noreferrer">map</a>`,
+		"変更（コメント）",
+		"作成",
+		`id="commentForm"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q, got %s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/2", nil))
+	if body := rr.Body.String(); !strings.Contains(body, `親タスク: <a href="1">Plan &lt;the&gt; trip</a>`) {
+		t.Errorf("Expected the subtask page to link to its parent, got %s", body)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{"GET", "/tasks/9", http.StatusNotFound, "not_found"},
+		{"GET", "/tasks/x", http.StatusBadRequest, "invalid"},
+		{"GET", "/tasks/1?tz=Nowhere", http.StatusBadRequest, "invalid"},
+		{"POST", "/tasks/1", http.StatusMethodNotAllowed, "method_not_allowed"},
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		assertErrorResponse(t, rr, tc.status, tc.code)
+	}
+}
+
+func TestTaskPageHidesEncryptedContent(t *testing.T) {
+	s := newE2EServer(t)
+	title := ciphertextTitle(40)
+	listRequest(s, "POST", "/api/tasks", `{"title": "`+title+`"}`)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/1", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || strings.Contains(body, title) || strings.Contains(body, "commentForm") || !strings.Contains(body, "タスク #1") {
+		t.Errorf("Expected the page to hide the encrypted title and comments, got %d %s", rr.Code, body)
+	}
+}
+
+func TestTaskHistoryHandler(t *testing.T) {
+	s := newTestServer()
+	listRequest(s, "POST", "/api/tasks", `{"title": "Write report"}`)
+	listRequest(s, "PATCH", "/api/tasks/1", `{"title": "Write the report", "priority": "high"}`)
+	listRequest(s, "PUT", "/api/tasks/1/toggle", ``)
+
+	var response struct {
+		Success bool                  `json:"success"`
+		History []models.HistoryEntry `json:"history"`
+	}
+	rr := listRequest(s, "GET", "/api/tasks/1/history", ``)
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	var types []models.EventType
+	for _, entry := range response.History {
+		types = append(types, entry.Type)
+	}
+	want := []models.EventType{models.EventTaskUpdated, models.EventTaskUpdated, models.EventTaskCreated}
+	if rr.Code != http.StatusOK || !reflect.DeepEqual(types, want) {
+		t.Fatalf("Expected the history newest first, got %d %s", rr.Code, rr.Body.String())
+	}
+	if fields := response.History[0].Fields; !reflect.DeepEqual(fields, []string{"completed", "completed_at"}) {
+		t.Errorf("Expected the toggle to change completed and completed_at, got %v", fields)
+	}
+
+	rr = listRequest(s, "GET", "/api/tasks/9/history", ``)
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// templateFiles はサーバ側で描画する画面のテンプレートです
+// layout.html が画面の枠（head や読み込むスクリプト）を、partials/ が複数の画面で使う部品を定義し、
+// ほかのファイルが1つずつの画面で、"title" と "content"（必要なら "head" と "scripts"）を定義します
+//
+//go:embed templates
+var templateFiles embed.FS
+
+// templateFuncs はすべての画面のテンプレートで使える関数です
+var templateFuncs = template.FuncMap{
+	"date":    formatDate,
+	"plural":  plural,
+	"day":     func(date string) string { return strings.TrimLeft(date[len("2006-01-"):], "0") },
+	"density": calendarDensity,
+}
+
+// formatDate は日時 t（time.Time か *time.Time）を layout の形で返します。nil やゼロの日時なら空文字です
+func formatDate(layout string, t interface{}) string {
+	switch t := t.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return formatDate(layout, *t)
+	}
+	return ""
+}
+
+// plural は数 n を one（1 のとき）か other（それ以外）の形で返します。形の中の %d は n に置き換えます
+// 日本語のように数で形が変わらない言葉では、other を省略できます（{{plural 3 "%d 件"}} → "3 件"）
+func plural(n int, one string, other ...string) string {
+	form := one
+	if n != 1 && len(other) > 0 {
+		form = other[0]
+	}
+	return strings.ReplaceAll(form, "%d", fmt.Sprint(n))
+}
+
+// pageSet は画面のテンプレートの集まりです
+// dir が空なら埋め込んだテンプレートを起動時に一度だけ読み込み、dir を指定すると（開発モード）
+// 画面を描画するたびに dir から読み込み直すため、バイナリを作り直さずに画面を変更できます
+type pageSet struct {
+	dir   string
+	pages map[string]*template.Template
+}
+
+// embeddedPages は埋め込んだテンプレートから読み込んだ画面です。埋め込んだファイルの誤りは起動時に panic します
+var embeddedPages = mustParsePages()
+
+func mustParsePages() map[string]*template.Template {
+	files, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		panic(err)
+	}
+	pages, err := parsePages(files)
+	if err != nil {
+		panic(err)
+	}
+	return pages
+}
+
+// newPageSet は dir（空なら埋め込んだテンプレート）の画面の集まりを返します
+func newPageSet(dir string) *pageSet {
+	if dir == "" {
+		return &pageSet{pages: embeddedPages}
+	}
+	return &pageSet{dir: dir}
+}
+
+// parsePages は files の画面のテンプレートを、ファイル名（"task.html" など）ごとに layout.html と partials/ と組み合わせて読み込みます
+func parsePages(files fs.FS) (map[string]*template.Template, error) {
+	base, err := template.New("layout.html").Funcs(templateFuncs).ParseFS(files, "layout.html")
+	if err != nil {
+		return nil, err
+	}
+	if partials, _ := fs.Glob(files, "partials/*.html"); len(partials) > 0 {
+		if base, err = base.ParseFS(files, partials...); err != nil {
+			return nil, err
+		}
+	}
+
+	names, err := fs.Glob(files, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]*template.Template)
+	for _, name := range names {
+		if name == "layout.html" {
+			continue
+		}
+		page, err := template.Must(base.Clone()).ParseFS(files, name)
+		if err != nil {
+			return nil, err
+		}
+		pages[path.Base(name)] = page
+	}
+	return pages, nil
+}
+
+// lookup は name の画面のテンプレートを返します
+func (p *pageSet) lookup(name string) (*template.Template, error) {
+	pages := p.pages
+	if p.dir != "" {
+		var err error
+		if pages, err = parsePages(os.DirFS(p.dir)); err != nil {
+			return nil, err
+		}
+	}
+	page, ok := pages[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return page, nil
+}
+
+// renderPage は name の画面を data で描画して返します
+// テンプレートを読み込めなければ（開発モードでの書き間違いなど）500 とその原因を返します
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	page, err := s.pages.lookup(name)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to load "+strings.TrimSuffix(name, ".html")+" page", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, "layout", data); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to render "+strings.TrimSuffix(name, ".html")+" page", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	date := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		value interface{}
+		want  string
+	}{
+		{date, "2025-03-10 09:30"},
+		{&date, "2025-03-10 09:30"},
+		{(*time.Time)(nil), ""},
+		{time.Time{}, ""},
+		{"2025-03-10", ""},
+	} {
+		if got := formatDate("2006-01-02 15:04", tt.value); got != tt.want {
+			t.Errorf("formatDate(%v): expected %q, got %q", tt.value, tt.want, got)
+		}
+	}
+
+	for _, tt := range []struct {
+		n     int
+		forms []string
+		want  string
+	}{
+		{1, []string{"%d task", "%d tasks"}, "1 task"},
+		{0, []string{"%d task", "%d tasks"}, "0 tasks"},
+		{3, []string{"%d task", "%d tasks"}, "3 tasks"},
+		{3, []string{"%d 件"}, "3 件"},
+	} {
+		if got := plural(tt.n, tt.forms[0], tt.forms[1:]...); got != tt.want {
+			t.Errorf("plural(%d, %v): expected %q, got %q", tt.n, tt.forms, tt.want, got)
+		}
+	}
+}
+
+func TestParsePages(t *testing.T) {
+	files := fstest.MapFS{
+		"layout.html":        {Data: []byte(`{{define "layout"}}<title>{{template "title" .}}</title>{{template "content" .}}{{block "scripts" .}}<script></script>{{end}}{{end}}`)},
+		"partials/back.html": {Data: []byte(`{{define "back"}}<a href="{{.}}">back</a>{{end}}`)},
+		"home.html":          {Data: []byte(`{{define "title"}}Home{{end}}{{define "content"}}{{plural . "%d item" "%d items"}} {{template "back" "./"}}{{end}}`)},
+		"docs.html":          {Data: []byte(`{{define "title"}}Docs{{end}}{{define "content"}}docs{{end}}{{define "scripts"}}<script src="docs.js"></script>{{end}}`)},
+	}
+	pages, err := parsePages(files)
+	if err != nil {
+		t.Fatalf("parsePages failed: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Expected the layout and partials not to be pages, got %v", pages)
+	}
+
+	// 画面ごとに定義した部分が、ほかの画面に混ざらないこと
+	for name, want := range map[string]string{
+		"home.html": `<title>Home</title>2 items <a href="./">back</a><script></script>`,
+		"docs.html": `<title>Docs</title>docs<script src="docs.js"></script>`,
+	} {
+		var out strings.Builder
+		if err := pages[name].ExecuteTemplate(&out, "layout", 2); err != nil || out.String() != want {
+			t.Errorf("%s: expected %q, got %q (%v)", name, want, out.String(), err)
+		}
+	}
+}
+
+func TestEmbeddedPages(t *testing.T) {
+	for _, name := range []string{"index.html", "archive.html", "calendar.html", "task.html", "api_docs.html"} {
+		if _, err := newPageSet("").lookup(name); err != nil {
+			t.Errorf("Expected the embedded page %s: %v", name, err)
+		}
+	}
+	if _, err := newPageSet("").lookup("layout.html"); err == nil {
+		t.Error("Expected the layout not to be a page")
+	}
+}
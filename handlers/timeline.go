@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// TimelineHandler は予定日か期限のあるタスクを、ガントチャート向けの棒とクリティカルパスにして返します（GET）
+func (s *Server) TimelineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"timeline": s.timeline.Timeline(s.store.GetTasks(r.Context())),
+	})
+}
+
+// DependenciesHandler はタスクが依存するタスク（先に終える必要があるタスク）を扱います
+// GET /api/tasks/{id}/dependencies: 依存するタスクの ID を返します
+// PUT /api/tasks/{id}/dependencies: リクエストのJSON {"depends_on": [1, 2]} で置き換えます。依存が循環すると 409 を返します
+func (s *Server) DependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "dependencies")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if _, err := s.findTask(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	dependsOn := s.timeline.DependsOn(id)
+	if r.Method == http.MethodPut {
+		var req struct {
+			DependsOn []int `json:"depends_on"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		dependsOn, err = s.timeline.SetDependencies(id, req.DependsOn, s.store.GetTasks(r.Context()))
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"task_id":    id,
+		"depends_on": dependsOn,
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/timeline"
+)
+
+func TestTimelineHandler(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	design, _ := s.store.AddTask(ctx, "Design")
+	build, _ := s.store.AddTask(ctx, "Build")
+	s.store.AddTask(ctx, "Someday")
+	s.store.SetScheduledDate(ctx, design.ID, &monday)
+	s.store.SetDueDate(ctx, build.ID, &monday)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/tasks/2/dependencies", strings.NewReader(`{"depends_on": [1]}`)))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"depends_on":[1]`) {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/timeline", nil))
+	var response struct {
+		Success  bool              `json:"success"`
+		Timeline timeline.Timeline `json:"timeline"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || len(response.Timeline.Bars) != 2 {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if got := response.Timeline.CriticalPath; !reflect.DeepEqual(got, []int{design.ID, build.ID}) {
+		t.Errorf("Expected the critical path to follow the dependency, got %v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/timeline", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestDependenciesHandler(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	s.store.AddTask(ctx, "First")
+	s.store.AddTask(ctx, "Second")
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/api/tasks/2/dependencies", strings.NewReader(`{"depends_on": [1]}`)))
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/2/dependencies", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"depends_on":[1]`) {
+		t.Errorf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"PUT", "/api/tasks/1/dependencies", `{"depends_on": [2]}`, http.StatusConflict, "conflict"},
+		{"PUT", "/api/tasks/1/dependencies", `{"depends_on": [1]}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/dependencies", `{"depends_on": [9]}`, http.StatusNotFound, "not_found"},
+		{"PUT", "/api/tasks/1/dependencies", `{"depends_on": [0]}`, http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/dependencies", `{}`, http.StatusBadRequest, "invalid"},
+		{"GET", "/api/tasks/9/dependencies", ``, http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/x/dependencies", ``, http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/dependencies", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.DependenciesHandler(rr, httptest.NewRequest("PUT", "/api/tasks/1/dependencies", strings.NewReader(`{`)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+}
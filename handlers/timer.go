@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/models"
+)
+
+// EstimateHandler はリクエストのJSON {"minutes": 90} でタスクの見積もり時間を設定します（0 で外します）
+func (s *Server) EstimateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "estimate")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := s.store.SetEstimate(r.Context(), id, req.Minutes); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
+
+// TimerHandler はタスクのタイマーを開始（/timer/start）または停止（/timer/stop）します
+// 停止すると作業記録が1件確定し、タスクの tracked_seconds に加算されます
+func (s *Server) TimerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	action := "timer/start"
+	if strings.HasSuffix(r.URL.Path, "/stop") {
+		action = "timer/stop"
+	}
+	id, err := parseID(r.URL.Path, "/api/tasks/", action)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	change := models.StartTimer
+	if action == "timer/stop" {
+		change = models.StopTimer
+	}
+	entries, entry, err := change(task.TimeEntries, time.Now())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.saveTimeEntries(w, r, id, entries, &entry)
+}
+
+// TimeEntriesHandler はタスクの作業記録と合計時間を返します（GET /api/tasks/{id}/time-entries）
+func (s *Server) TimeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "time-entries")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	entries := task.TimeEntries
+	if entries == nil {
+		entries = []models.TimeEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":         true,
+		"entries":         entries,
+		"tracked_seconds": task.TrackedSeconds,
+	})
+}
+
+// TimeEntryHandler は1件の作業記録を扱います
+// PUT /api/tasks/{id}/time-entries/{entryID}: {"start": "...", "end": "..."} で開始・終了日時を修正（end を省略すると作業中に戻します）
+// DELETE /api/tasks/{id}/time-entries/{entryID}: 記録を削除
+func (s *Server) TimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, entryID, err := parseSubID(r.URL.Path, "/api/tasks/", "time-entries")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		entries, err := models.DeleteTimeEntry(task.TimeEntries, entryID)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.saveTimeEntries(w, r, id, entries, nil)
+		return
+	}
+
+	var req struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	entries, entry, err := models.EditTimeEntry(task.TimeEntries, entryID, req.Start, req.End)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.saveTimeEntries(w, r, id, entries, &entry)
+}
+
+// saveTimeEntries は作業記録を保存し、更新後のタスクと（あれば）変更した記録を返します
+func (s *Server) saveTimeEntries(w http.ResponseWriter, r *http.Request, id int, entries []models.TimeEntry, entry *models.TimeEntry) {
+	if err := s.store.SetTimeEntries(r.Context(), id, entries); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	task, err := s.findTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"task":    task,
+	}
+	if entry != nil {
+		response["entry"] = entry
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
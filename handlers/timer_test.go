@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+type timerResponse struct {
+	Success bool             `json:"success"`
+	Task    models.Task      `json:"task"`
+	Entry   models.TimeEntry `json:"entry"`
+}
+
+func doTimerRequest(t *testing.T, s *Server, method, path, body string) timerResponse {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var response timerResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || !response.Success {
+		t.Fatalf("%s %s: unexpected response %d: %s", method, path, rr.Code, rr.Body.String())
+	}
+	return response
+}
+
+func TestTimerHandlers(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Tracked")
+
+	started := doTimerRequest(t, s, "POST", "/api/tasks/1/timer/start", "")
+	if started.Entry.ID != 1 || !started.Entry.Running() || len(started.Task.TimeEntries) != 1 {
+		t.Errorf("Unexpected start response: %+v", started)
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/timer/start", nil))
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+
+	stopped := doTimerRequest(t, s, "POST", "/api/tasks/1/timer/stop", "")
+	if stopped.Entry.Running() {
+		t.Errorf("Expected the timer to stop, got %+v", stopped.Entry)
+	}
+
+	// 作業記録を1時間に修正すると合計時間も変わります
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	body := `{"start": "` + start.Format(time.RFC3339) + `", "end": "` + start.Add(time.Hour).Format(time.RFC3339) + `"}`
+	edited := doTimerRequest(t, s, "PUT", "/api/tasks/1/time-entries/1", body)
+	if edited.Task.TrackedSeconds != 3600 || !edited.Entry.Start.Equal(start) {
+		t.Errorf("Expected 3600 tracked seconds after the edit, got %+v", edited)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/1/time-entries", nil))
+	var list struct {
+		Success        bool               `json:"success"`
+		Entries        []models.TimeEntry `json:"entries"`
+		TrackedSeconds int64              `json:"tracked_seconds"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || !list.Success || len(list.Entries) != 1 || list.TrackedSeconds != 3600 {
+		t.Errorf("Unexpected time entries: %s", rr.Body.String())
+	}
+
+	deleted := doTimerRequest(t, s, "DELETE", "/api/tasks/1/time-entries/1", "")
+	if len(deleted.Task.TimeEntries) != 0 || deleted.Task.TrackedSeconds != 0 {
+		t.Errorf("Expected no time entries after the delete, got %+v", deleted.Task)
+	}
+}
+
+func TestTimerHandlerErrors(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Tracked")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"POST", "/api/tasks/1/timer/stop", "", http.StatusConflict, "conflict"},
+		{"POST", "/api/tasks/99/timer/start", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/1/timer/pause", "", http.StatusNotFound, "not_found"},
+		{"GET", "/api/tasks/1/timer/start", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/api/tasks/99/time-entries", "", http.StatusNotFound, "not_found"},
+		{"POST", "/api/tasks/1/time-entries", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"DELETE", "/api/tasks/1/time-entries/1", "", http.StatusNotFound, "not_found"},
+		{"PUT", "/api/tasks/1/time-entries/1", "{", http.StatusBadRequest, "invalid"},
+		{"PUT", "/api/tasks/1/time-entries/abc", "{}", http.StatusBadRequest, "invalid"},
+		{"POST", "/api/tasks/1/time-entries/1", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"DELETE", "/api/tasks/99/time-entries/1", "", http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assertErrorResponse(t, rr, tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// TrashHandler は削除してごみ箱に入っているタスクを、新しく削除した順に返します（GET /api/trash）
+// ごみ箱のタスクは POST /api/tasks/{id}/restore で元に戻せます。古いものは一定の期間（既定は30日）で完全に削除します
+func (s *Server) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	tasks := s.store.GetTrash(r.Context())
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].DeletedAt.After(*tasks[j].DeletedAt) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"tasks":   tasks,
+	})
+}
+
+// RestoreTaskHandler はごみ箱のタスクを同じ ID のまま一覧へ戻し、戻したタスクを返します（POST /api/tasks/{id}/restore）
+// ごみ箱になければ 404 を、同じ ID のタスクがすでにあるか件数の上限に達していれば 409 を返します
+func (s *Server) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/tasks/", "restore")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	task, err := s.store.RestoreTask(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"task":    task,
+	})
+}
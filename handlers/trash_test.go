@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/models"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	for _, title := range []string{"First", "Second"} {
+		task, _ := s.store.AddTask(ctx, title)
+		s.store.DeleteTask(ctx, task.ID)
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/trash", nil))
+	var trash struct {
+		Success bool          `json:"success"`
+		Tasks   []models.Task `json:"tasks"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &trash)
+	if rr.Code != http.StatusOK || !trash.Success || len(trash.Tasks) != 2 || trash.Tasks[0].Title != "Second" || trash.Tasks[0].DeletedAt == nil {
+		t.Fatalf("Expected the trash with the latest deletion first, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/restore", nil))
+	var restored struct {
+		Success bool        `json:"success"`
+		Task    models.Task `json:"task"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &restored)
+	if rr.Code != http.StatusOK || restored.Task.ID != 1 || restored.Task.DeletedAt != nil {
+		t.Fatalf("Expected the task to be restored, got %d %s", rr.Code, rr.Body.String())
+	}
+	if tasks := s.store.GetTasks(ctx); len(tasks) != 1 || tasks[0].Title != "First" {
+		t.Errorf("Expected the restored task in the list, got %+v", tasks)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/restore", nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks/2/restore", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/trash", nil))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"todo-app/models"
+)
+
+// JournalCookie は操作を取り消すためのセッション（ブラウザを閉じるまで）の ID を入れる Cookie の名前です
+const JournalCookie = "todo_journal"
+
+// withJournal は Cookie のセッションの ID をコンテキストに入れて（models.WithSession）next に渡します
+// Cookie がなければ、変更する API のリクエスト（GET・HEAD 以外）のときだけ作って応答で返します
+// そのセッションで行った追加・切り替え・編集・削除を POST /api/undo で取り消せます
+// 外側のサーバ（ユーザーごとのサーバを呼び出すサーバ）でセッションを入れたリクエストはそのまま渡します
+func (s *Server) withJournal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if models.Session(r.Context()) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var session string
+		if cookie, err := r.Cookie(JournalCookie); err == nil && cookie.Value != "" {
+			session = cookie.Value
+		} else if r.Method == http.MethodGet || r.Method == http.MethodHead || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		} else {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			session = base64.RawURLEncoding.EncodeToString(b)
+			http.SetCookie(w, &http.Cookie{
+				Name:     JournalCookie,
+				Value:    session,
+				Path:     s.config.BasePath + "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r.WithContext(models.WithSession(r.Context(), session)))
+	})
+}
+
+// UndoHandler はこのセッションで最後に行った操作を取り消し、取り消した操作とその後のタスクを返します（POST /api/undo）
+// 追加したタスクはごみ箱へ移し、削除したタスクはごみ箱から戻し、切り替えと編集は操作する前の状態に戻します
+// 取り消せる操作がなければ 404 を、操作の後にタスクが変更されていれば 409 を返します
+func (s *Server) UndoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	op, task, err := s.store.Undo(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"operation": op,
+		"task":      task,
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-app/models"
+)
+
+func TestUndoHandler(t *testing.T) {
+	s := newTestServer()
+	serve := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve("GET", "/api/tasks", "", nil); len(rr.Result().Cookies()) != 0 {
+		t.Errorf("Expected no cookie for a read-only request, got %v", rr.Result().Cookies())
+	}
+	rr := serve("POST", "/api/tasks", `{"title": "Pay rent", "due_date": "2025-03-01"}`, nil)
+	cookies := rr.Result().Cookies()
+	if rr.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Name != JournalCookie || !cookies[0].HttpOnly {
+		t.Fatalf("Expected the journal cookie to be set, got %d %v", rr.Code, cookies)
+	}
+	cookie := cookies[0]
+	if rr := serve("DELETE", "/api/tasks/1", "", cookie); rr.Code != http.StatusOK || len(rr.Result().Cookies()) != 0 {
+		t.Fatalf("Expected the delete to reuse the cookie, got %d %v", rr.Code, rr.Result().Cookies())
+	}
+
+	rr = serve("POST", "/api/undo", "", nil)
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+
+	var undone struct {
+		Success   bool             `json:"success"`
+		Operation models.Operation `json:"operation"`
+		Task      models.Task      `json:"task"`
+	}
+	rr = serve("POST", "/api/undo", "", cookie)
+	json.Unmarshal(rr.Body.Bytes(), &undone)
+	if rr.Code != http.StatusOK || !undone.Success || undone.Operation.Kind != models.OperationDelete || undone.Task.ID != 1 {
+		t.Fatalf("Expected the delete to be undone, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serve("POST", "/api/undo", "", cookie)
+	json.Unmarshal(rr.Body.Bytes(), &undone)
+	if rr.Code != http.StatusOK || undone.Operation.Kind != models.OperationAdd || undone.Task.DeletedAt == nil {
+		t.Fatalf("Expected the add (with its due date) to be undone, got %d %s", rr.Code, rr.Body.String())
+	}
+	if trash := s.store.GetTrash(context.Background()); len(trash) != 1 || trash[0].DueDate == nil {
+		t.Errorf("Expected the added task in the trash, got %+v", trash)
+	}
+
+	rr = serve("POST", "/api/undo", "", cookie)
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+
+	rr = serve("GET", "/api/undo", "", cookie)
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
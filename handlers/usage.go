@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/usage"
+)
+
+// statusRecorder は応答のステータスを記録する http.ResponseWriter です
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader はステータスを記録してから書き込みます
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write は WriteHeader を呼ばずに書き込んだ場合のステータス（200）を記録します
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush は書き込んだ内容をすぐにクライアントへ送ります（Server-Sent Events のため）
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap は元の ResponseWriter を返します（http.ResponseController で書き込みの期限などを設定するため）
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// recordUsage は /api/ へのリクエストを next に渡し、エンドポイントごとの使用状況として記録します
+func (s *Server) recordUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		s.usage.Record(usageEndpoint(r), recorder.status, time.Since(start))
+	})
+}
+
+// usageEndpoint は "GET /api/tasks/{id}/toggle" のように、数字だけのパスの要素を {id} に置き換えたエンドポイントの名前を返します
+func usageEndpoint(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return r.Method + " " + strings.Join(segments, "/")
+}
+
+// UsageHandler は API の使用状況をエンドポイントごとに集計して返します（管理用）
+// ?from=2025-03-01T00:00:00Z&to=2025-03-02T00:00:00Z で期間（省略時は直近24時間）、?endpoint=GET%20/api/tasks でエンドポイントを絞り込めます
+func (s *Server) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := usage.ParseWindow(query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"usage":   s.usage.Summarize(from, to, query.Get("endpoint")),
+	})
+}
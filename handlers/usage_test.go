@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"todo-app/models"
+	"todo-app/usage"
+)
+
+func TestUsageHandler(t *testing.T) {
+	s := NewServer(Deps{Store: models.NewTodoApp(), Config: Config{AdminToken: "secret"}})
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "牛乳を買う"}`)),
+		httptest.NewRequest("PUT", "/api/tasks/1/toggle", nil),
+		httptest.NewRequest("PUT", "/api/tasks/2/toggle", nil),
+		httptest.NewRequest("GET", "/static/style.css", nil),
+	} {
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/usage"))
+	var body struct {
+		Success bool          `json:"success"`
+		Usage   usage.Summary `json:"usage"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || rr.Code != http.StatusOK || !body.Success {
+		t.Fatalf("Unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	if body.Usage.Requests != 3 || body.Usage.ClientErrors != 1 {
+		t.Errorf("Expected 3 API requests with 1 error, got %+v", body.Usage)
+	}
+	toggle := body.Usage.Endpoints[0]
+	if toggle.Endpoint != "PUT /api/tasks/{id}/toggle" || toggle.Requests != 2 || toggle.ClientErrors != 1 || toggle.ErrorRate != 0.5 {
+		t.Errorf("Unexpected toggle stats: %+v", toggle)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/usage?endpoint="+url.QueryEscape("POST /api/tasks")))
+	if !strings.Contains(rr.Body.String(), `"requests":1,`) || strings.Contains(rr.Body.String(), "toggle") {
+		t.Errorf("Expected the endpoint filter to apply, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/usage?from=2025-03-01"))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/usage"))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/usage", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the usage endpoint to require the admin token, got %d", rr.Code)
+	}
+}
+
+func TestUsageEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/api/tasks":                   "GET /api/tasks",
+		"/api/tasks/12/time-entries/3": "GET /api/tasks/{id}/time-entries/{id}",
+		"/api/schemas/task":            "GET /api/schemas/task",
+		"/api/reports/schedules/7/run": "GET /api/reports/schedules/{id}/run",
+	}
+	for path, want := range tests {
+		if got := usageEndpoint(httptest.NewRequest("GET", path, nil)); got != want {
+			t.Errorf("usageEndpoint(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"todo-app/models"
+	"todo-app/schema"
+)
+
+// schemaFiles は API のリクエスト本文の JSON Schema です。ファイル名（拡張子を除く）で参照します
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// requestSchemas は読み込み済みのリクエスト本文のスキーマです
+var requestSchemas = loadSchemas(schemaFiles)
+
+// errInvalidRequest はリクエストの本文がスキーマに合わないときのエラーです
+var errInvalidRequest = fmt.Errorf("%w: request body does not match the schema", models.ErrValidation)
+
+// maxRequestBody は検証のために読み込むリクエスト本文の上限です
+const maxRequestBody = 1 << 20
+
+// requestError はスキーマに合わなかった項目ごとの誤りを持つ errInvalidRequest です
+type requestError struct {
+	fields []schema.FieldError
+	detail string
+}
+
+func (e *requestError) Error() string {
+	return errInvalidRequest.Error() + ": " + e.detail
+}
+
+func (e *requestError) Unwrap() error {
+	return errInvalidRequest
+}
+
+// loadSchemas は fsys の schemas/*.json を読み込みます。埋め込んだファイルの誤りは起動時に panic します
+func loadSchemas(fsys embed.FS) map[string]*schema.Schema {
+	entries, err := fsys.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	schemas := make(map[string]*schema.Schema, len(entries))
+	for _, entry := range entries {
+		data, err := fsys.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		schemas[strings.TrimSuffix(entry.Name(), ".json")] = schema.MustParse(data)
+	}
+	return schemas
+}
+
+// RequestSchema は name（task・rule など）のリクエスト本文の JSON Schema を返します
+func RequestSchema(name string) (*schema.Schema, bool) {
+	s, ok := requestSchemas[name]
+	return s, ok
+}
+
+// validateBody はハンドラの前にリクエスト本文をスキーマ name で検証するミドルウェアです
+// method のリクエストだけを検証し、合わなければ項目ごとの誤りを fields に入れて 400 を返します
+// ほかのメソッド（一覧の GET や許可していないメソッド）はそのままハンドラに渡します
+// 本文が空のリクエストは、省略できるかどうかをハンドラが判断するのでそのまま渡します
+func (s *Server) validateBody(method, name string, next http.HandlerFunc) http.HandlerFunc {
+	bodySchema, ok := requestSchemas[name]
+	if !ok {
+		panic(fmt.Sprintf("handlers: unknown request schema %q", name))
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+		if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: %v", errInvalidJSON, err))
+			return
+		}
+		if len(body) > maxRequestBody {
+			s.writeError(w, r, fmt.Errorf("%w: request body exceeds %d bytes", models.ErrValidation, maxRequestBody))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if len(bytes.TrimSpace(body)) == 0 {
+			next(w, r)
+			return
+		}
+
+		var verr *schema.ValidationError
+		if err := bodySchema.ValidateJSON(body); errors.As(err, &verr) {
+			s.writeError(w, r, &requestError{fields: verr.Errors, detail: verr.Error()})
+			return
+		} else if err != nil {
+			s.writeError(w, r, fmt.Errorf("%w: %v", errInvalidJSON, err))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// SchemaHandler は name のリクエスト本文の JSON Schema を返します（GET /api/schemas/{name}）
+func (s *Server) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	segments, ok := splitPath(r.URL.Path, "/api/schemas/")
+	if !ok || len(segments) != 1 {
+		s.writeError(w, r, errPathNotFound)
+		return
+	}
+	bodySchema, ok := requestSchemas[segments[0]]
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: schema %q", errPathNotFound, segments[0]))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(bodySchema)
+}
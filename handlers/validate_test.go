@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"todo-app/models"
+	"todo-app/schema"
+)
+
+func TestValidateBodyFieldErrors(t *testing.T) {
+	s := NewServer(Deps{Config: Config{AdminToken: "secret"}})
+	s.store.AddTask(context.Background(), "Buy milk")
+
+	tests := []struct {
+		request *http.Request
+		fields  []schema.FieldError
+	}{
+		{
+			httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": 1, "done": true}`)),
+			[]schema.FieldError{{Field: "done", Message: "is not allowed"}, {Field: "title", Message: "must be string"}},
+		},
+		{
+			httptest.NewRequest("PUT", "/api/tasks/1/estimate", strings.NewReader(`{"minutes": -5}`)),
+			[]schema.FieldError{{Field: "minutes", Message: "must be at least 0"}},
+		},
+		{
+			httptest.NewRequest("PUT", "/api/tasks/1/time-entries/1", strings.NewReader(`{"end": "later"}`)),
+			[]schema.FieldError{
+				{Field: "start", Message: "is required"},
+				{Field: "end", Message: "must be an RFC 3339 date-time (for example 2025-01-02T15:04:05Z)"},
+			},
+		},
+		{
+			httptest.NewRequest("POST", "/api/tasks/1/pomodoros", strings.NewReader(`{"minutes": 500}`)),
+			[]schema.FieldError{{Field: "minutes", Message: "must be at most 120"}},
+		},
+		{
+			httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(`{"url": "example.com", "events": ["task.done"]}`)),
+			[]schema.FieldError{
+				{Field: "events[0]", Message: `must be one of "task.created", "task.updated", "task.deleted", "task.reminder"`},
+				{Field: "url", Message: "must be an absolute URI"},
+			},
+		},
+		{
+			httptest.NewRequest("POST", "/api/rules", strings.NewReader(`{"trigger": "task.created", "actions": [{"type": "archive"}]}`)),
+			[]schema.FieldError{{Field: "actions[0].type", Message: `must be one of "set_priority", "set_due_in_days", "set_estimate", "add_tag", "remove_tag", "move_to_list", "assign"`}},
+		},
+		{
+			adminBodyRequest("POST", "/api/admin/shares", `{"list_id": 1, "name": ["family"]}`),
+			[]schema.FieldError{{Field: "name", Message: "must be string"}},
+		},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, tt.request)
+
+		assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+		var response struct {
+			Error errorBody `json:"error"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if !reflect.DeepEqual(response.Error.Fields, tt.fields) {
+			t.Errorf("%s %s: expected fields %+v, got %+v", tt.request.Method, tt.request.URL.Path, tt.fields, response.Error.Fields)
+		}
+		if !strings.HasPrefix(response.Error.Detail, "validation failed: request body does not match the schema: ") {
+			t.Errorf("%s %s: unexpected detail %q", tt.request.Method, tt.request.URL.Path, response.Error.Detail)
+		}
+	}
+}
+
+func TestValidateBodyPassesValidRequests(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Buy milk"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected a valid request to reach the handler, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 本文を省略できるエンドポイントには空の本文をそのまま渡します
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks/1/pomodoros", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected an empty body to reach the handler, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 検証しないメソッドはハンドラがそのまま扱います
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/rules", strings.NewReader(`{}`)))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestValidateBodyMalformed(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": `)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	if !strings.Contains(rr.Body.String(), "invalid JSON") {
+		t.Errorf("Expected an invalid JSON error, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	large := `{"title": "` + strings.Repeat("a", maxRequestBody) + `"}`
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/api/tasks", strings.NewReader(large)))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+	if tasks := s.store.GetTasks(context.Background()); len(tasks) != 0 {
+		t.Errorf("Expected rejected requests to create no tasks, got %d", len(tasks))
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	err := &requestError{fields: []schema.FieldError{{Field: "title", Message: "is required"}}, detail: "title: is required"}
+	if !errors.Is(err, errInvalidRequest) || !errors.Is(err, models.ErrValidation) {
+		t.Error("Expected the request error to be a validation error")
+	}
+	if key := errorMessageKey(err); key != "error.invalid_request" {
+		t.Errorf("Expected error.invalid_request, got %s", key)
+	}
+	if key := errorMessageKey(models.ErrValidation); key != "error.validation" {
+		t.Errorf("Expected other validation errors to keep their message, got %s", key)
+	}
+}
+
+func TestSchemaHandler(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/schemas/task", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/schema+json" {
+		t.Fatalf("Unexpected response %d %v", rr.Code, rr.Header())
+	}
+	var task struct {
+		Type     string   `json:"type"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &task); err != nil || task.Type != "object" || !reflect.DeepEqual(task.Required, []string{"title"}) {
+		t.Errorf("Unexpected schema: %s", rr.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/api/schemas/unknown", http.StatusNotFound, "not_found"},
+		{"GET", "/api/schemas/task/extra", http.StatusNotFound, "not_found"},
+		{"POST", "/api/schemas/task", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+}
+
+func TestRequestSchemas(t *testing.T) {
+	for _, name := range []string{"task", "estimate", "time_entry", "pomodoro", "webhook", "rule", "share", "workspace", "e2e_keys"} {
+		s, ok := RequestSchema(name)
+		if !ok || len(s.Type) != 1 || s.Type[0] != "object" || s.Title == "" {
+			t.Errorf("Expected an object schema with a title for %s, got %+v", name, s)
+		}
+	}
+	if _, ok := RequestSchema("unknown"); ok {
+		t.Error("Expected no schema for an unknown name")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected validateBody to panic for an unknown schema")
+		}
+	}()
+	newTestServer().validateBody(http.MethodPost, "unknown", nil)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"todo-app/accounts"
+)
+
+// Mailer はメールを送ります（reports.Mailer が満たします）
+type Mailer interface {
+	SendText(to, subject, body string) error
+}
+
+// emailVerificationEnabled はメールアドレスの確認が有効か（登録でメールアドレスを求め、確かめるまでログインさせないか）を返します
+func (s *Server) emailVerificationEnabled() bool {
+	return s.mailer != nil
+}
+
+// sendVerification は name のユーザーにメールアドレスを確かめるリンクをメールで送ります
+func (s *Server) sendVerification(r *http.Request, name string) error {
+	user, token, err := s.accounts.StartVerification(name)
+	if err != nil {
+		return err
+	}
+	link := s.absoluteURL(r, "/api/auth/verify?token="+url.QueryEscape(token))
+	body := user.Name + " さん\n\n" +
+		"ToDo リストに登録したメールアドレスを確認するため、次のリンクを開いてください。\n\n" +
+		link + "\n\n" +
+		"リンクには有効期限があります。期限が切れたら、ログイン画面から確認メールを送り直してください。\n" +
+		"心当たりがなければ、このメールは破棄してください。\n"
+	if err := s.mailer.SendText(user.Email, "メールアドレスの確認", body); err != nil {
+		return err
+	}
+	s.audit(r, slog.LevelInfo, "sent verification email", "user_id", user.ID)
+	return nil
+}
+
+// VerifyEmailHandler はメールで送ったリンクのトークンでメールアドレスを確認済みにします（GET /api/auth/verify?token=...）
+// ブラウザで開くリンクのため、結果はログイン画面へのリダイレクト（?verified=1 か 0）で返します
+func (s *Server) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	user, err := s.accounts.VerifyEmail(r.URL.Query().Get("token"))
+	if err != nil {
+		s.audit(r, slog.LevelWarn, "email verification failed", "err", err)
+		http.Redirect(w, r, s.config.BasePath+"/login?verified=0", http.StatusSeeOther)
+		return
+	}
+	s.audit(r, slog.LevelInfo, "verified email", "user_id", user.ID, "user", user.Name)
+	http.Redirect(w, r, s.config.BasePath+"/login?verified=1", http.StatusSeeOther)
+}
+
+// ResendVerificationHandler はユーザーに確認メールを送り直します（POST {"name": "..."}）。前に送ったリンクは使えなくなります
+// ユーザーの有無やメールアドレスを知られないよう、送らなかったとき（送ったばかりのときを含みます）も成功を返します
+func (s *Server) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := s.sendVerification(r, req.Name); err != nil {
+		s.audit(r, slog.LevelWarn, "verification email not sent", "user", req.Name, "err", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// rejectUnverified はメールアドレスの確認が有効で、user がまだ確かめていなければ 403 を返して true を返します
+// 確認を有効にする前に登録した（メールアドレスのない）ユーザーはそのままログインできます
+func (s *Server) rejectUnverified(w http.ResponseWriter, r *http.Request, user accounts.User) bool {
+	if !s.emailVerificationEnabled() || user.Email == "" || user.EmailVerified {
+		return false
+	}
+	s.writeError(w, r, accounts.ErrEmailNotVerified)
+	return true
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"todo-app/accounts"
+	"todo-app/logging"
+	"todo-app/models"
+)
+
+// fakeMailer は送ったメールを記録する Mailer です
+type fakeMailer struct {
+	to   []string
+	body []string
+}
+
+func (m *fakeMailer) SendText(to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.body = append(m.body, body)
+	return nil
+}
+
+// verificationLink はメールの本文から確認用のリンクのパスとクエリを取り出します
+func (m *fakeMailer) verificationLink(t *testing.T) string {
+	t.Helper()
+	if len(m.body) == 0 {
+		t.Fatal("Expected a verification email")
+	}
+	for _, line := range strings.Split(m.body[len(m.body)-1], "\n") {
+		if u, err := url.Parse(line); err == nil && u.Path == "/api/auth/verify" {
+			return u.RequestURI()
+		}
+	}
+	t.Fatalf("Expected a verification link, got %q", m.body[len(m.body)-1])
+	return ""
+}
+
+func TestEmailVerification(t *testing.T) {
+	users, _ := accounts.NewStore("")
+	users.Iterations = 1000
+	mailer := &fakeMailer{}
+	s := NewServer(Deps{
+		Logger:   logging.Discard(),
+		Accounts: users,
+		Mailer:   mailer,
+		UserHandlers: accounts.NewHandlers(func(user accounts.User) (http.Handler, error) {
+			return NewServer(Deps{Store: models.NewTodoApp()}), nil
+		}),
+	})
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	const login = `{"name": "alice", "password": "correct horse"}`
+
+	// 確認が有効なら登録にメールアドレスが要ります
+	assertErrorResponse(t, request("POST", "/api/auth/register", login), http.StatusBadRequest, "invalid")
+
+	rr := request("POST", "/api/auth/register", `{"name": "alice", "password": "correct horse", "email": "alice@example.com"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"verification_required":true`) {
+		t.Fatalf("Expected the registration to require verification, got %d %s", rr.Code, rr.Body.String())
+	}
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == SessionCookie {
+			t.Error("Expected no session before the address is verified")
+		}
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "alice@example.com" {
+		t.Fatalf("Expected a verification email to alice, got %v", mailer.to)
+	}
+	first := mailer.verificationLink(t)
+
+	assertErrorResponse(t, request("POST", "/api/auth/login", login), http.StatusForbidden, "email_not_verified")
+
+	// 送り直すと前のリンクは使えません（送ったばかりのときは送りません）
+	request("POST", "/api/auth/verify/resend", `{"name": "alice"}`)
+	if len(mailer.to) != 1 {
+		t.Errorf("Expected a resend right after sending to be skipped, got %d emails", len(mailer.to))
+	}
+	if rr := request("POST", "/api/auth/verify/resend", `{"name": "nobody"}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected an unknown name not to be revealed, got %d", rr.Code)
+	}
+
+	rr = request("GET", "/api/auth/verify?token=wrong", "")
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login?verified=0" {
+		t.Errorf("Expected a wrong token to redirect with verified=0, got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	rr = request("GET", first, "")
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login?verified=1" {
+		t.Fatalf("Expected the link to verify the address, got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	if rr := request("POST", "/api/auth/login", login); rr.Code != http.StatusOK {
+		t.Errorf("Expected alice to log in after verifying, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// 確認を有効にする前に登録したユーザー（メールアドレスなし）はそのままログインできます
+	users.Register("bob", "battery staple")
+	if rr := request("POST", "/api/auth/login", `{"name": "bob", "password": "battery staple"}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected bob to log in, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestEmailVerificationDisabled(t *testing.T) {
+	s := newTestAccountsServer(t)
+
+	rr := authRequest(s, "register", "alice", "correct horse")
+	if rr.Code != http.StatusOK || sessionCookie(t, rr).Value == "" {
+		t.Fatalf("Expected to log in right after registering, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/auth/verify?token=x", nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+}
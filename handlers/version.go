@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIVersionHeader は API のバージョンを選ぶリクエストヘッダです。応答にも選んだバージョンを入れます
+const APIVersionHeader = "API-Version"
+
+// 対応している API のバージョンです
+// 1: これまでの API。入力の誤りはすべて invalid（400）です
+// 2: 形は正しいが値に誤りのある入力（空のタイトル・スキーマに合わない本文など）を unprocessable（422）で返します
+const (
+	apiVersion1      = 1
+	apiVersion2      = 2
+	latestAPIVersion = apiVersion2
+)
+
+// errUnsupportedVersion は対応していない API のバージョンを指定したことを表します
+var errUnsupportedVersion = errors.New("unsupported API version")
+
+// apiVersionKey はリクエストのコンテキストに API のバージョンを入れるキーです
+type apiVersionKey struct{}
+
+// withAPIVersion は /api/v2/ で始まるパスか API-Version ヘッダから API のバージョンを選び、コンテキストに入れて next に渡します
+// /api/v2/ のパスは /api/ に書き換えるため、ハンドラはバージョンによらず同じパスで登録します
+// 省略時は 1 です。API 以外のパスと、外側のサーバ（ユーザーごとのサーバを呼び出すサーバ）でバージョンを選んだリクエストはそのまま渡します
+func (s *Server) withAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, chosen := r.Context().Value(apiVersionKey{}).(int); chosen || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := apiVersion1
+		if rest := strings.TrimPrefix(r.URL.Path, "/api/v2"); rest != r.URL.Path && (rest == "" || strings.HasPrefix(rest, "/")) {
+			version = apiVersion2
+			r = r.Clone(r.Context())
+			r.URL.Path = "/api" + rest
+			r.URL.RawPath = ""
+		} else if raw := r.Header.Get(APIVersionHeader); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < apiVersion1 || n > latestAPIVersion {
+				s.writeError(w, r, fmt.Errorf("%w: %q (supported: 1 to %d)", errUnsupportedVersion, raw, latestAPIVersion))
+				return
+			}
+			version = n
+		}
+
+		w.Header().Set(APIVersionHeader, strconv.Itoa(version))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+	})
+}
+
+// requestAPIVersion は r で選ばれた API のバージョンを返します（未設定なら 1）
+func requestAPIVersion(r *http.Request) int {
+	if version, ok := r.Context().Value(apiVersionKey{}).(int); ok {
+		return version
+	}
+	return apiVersion1
+}
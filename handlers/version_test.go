@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/models"
+)
+
+func TestErrorStatusVersion2(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: title is required", models.ErrValidation), http.StatusUnprocessableEntity, "unprocessable"},
+		{&requestError{detail: "extra: is not allowed"}, http.StatusUnprocessableEntity, "unprocessable"},
+		{errInvalidJSON, http.StatusBadRequest, "invalid"},
+		{fmt.Errorf("%w: abc", errInvalidID), http.StatusBadRequest, "invalid"},
+		{models.ErrTaskNotFound, http.StatusNotFound, "not_found"},
+		{models.ErrConflict, http.StatusConflict, "conflict"},
+	}
+	for _, tc := range testCases {
+		status, code := errorStatus(tc.err, apiVersion2)
+		if status != tc.status || code != tc.code {
+			t.Errorf("errorStatus(%v, 2) = %d, %q; expected %d, %q", tc.err, status, code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	s := newTestServer()
+	s.Store().AddTask(context.Background(), "Existing")
+
+	testCases := []struct {
+		name, method, path, version, body string
+		status                            int
+		code                              string
+	}{
+		{"v1 keeps 400 for validation errors", "POST", "/api/tasks", "", `{"title": ""}`, http.StatusBadRequest, "invalid"},
+		{"v2 prefix returns 422", "POST", "/api/v2/tasks", "", `{"title": ""}`, http.StatusUnprocessableEntity, "unprocessable"},
+		{"v2 header returns 422", "POST", "/api/tasks", "2", `{"title": ""}`, http.StatusUnprocessableEntity, "unprocessable"},
+		{"v2 schema errors return 422", "POST", "/api/v2/tasks", "", `{"title": "x", "extra": 1}`, http.StatusUnprocessableEntity, "unprocessable"},
+		{"v2 keeps 400 for broken JSON", "POST", "/api/v2/tasks", "", `{"title":`, http.StatusBadRequest, "invalid"},
+		{"v2 keeps 400 for invalid IDs", "DELETE", "/api/v2/tasks/abc", "", ``, http.StatusBadRequest, "invalid"},
+		{"v2 returns 404 for missing tasks", "DELETE", "/api/v2/tasks/99", "", ``, http.StatusNotFound, "not_found"},
+		{"unknown versions are rejected", "GET", "/api/tasks", "3", ``, http.StatusBadRequest, "invalid"},
+		{"v2x is not a version prefix", "GET", "/api/v2x/tasks", "", ``, http.StatusNotFound, "not_found"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.version != "" {
+				req.Header.Set(APIVersionHeader, tc.version)
+			}
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, req)
+			assertErrorResponse(t, rr, tc.status, tc.code)
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/tasks", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Existing") || rr.Header().Get(APIVersionHeader) != "2" {
+		t.Errorf("Expected /api/v2/tasks to list the tasks with API-Version: 2, got %d %q %s", rr.Code, rr.Header().Get(APIVersionHeader), rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Header().Get(APIVersionHeader) != "1" {
+		t.Errorf("Expected API-Version: 1 by default, got %q", rr.Header().Get(APIVersionHeader))
+	}
+}
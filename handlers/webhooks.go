@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo-app/models"
+	"todo-app/webhooks"
+)
+
+// 登録済みの Webhook を一覧で返します
+func (s *Server) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.webhooks.List())
+}
+
+// リクエストのJSONから通知先・プリセット・テンプレートを受け取り、Webhook を登録します
+func (s *Server) AddWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req webhooks.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	webhook, err := s.webhooks.Add(req)
+	if err != nil {
+		s.writeError(w, r, fmt.Errorf("%w: %v", models.ErrValidation, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"webhook": webhook,
+	})
+}
+
+// URL からIDを取り出し、その Webhook を削除します
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, err := parseID(r.URL.Path, "/api/webhooks/", "")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if !s.webhooks.Delete(id) {
+		s.writeError(w, r, fmt.Errorf("%w: id %d", errWebhookNotFound, id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"success": true,
+	})
+}
+
+// DeliveriesHandler は Webhook の送信待ちの配信・配信できなかったもの・Webhook ごとの成否を返します（管理用）
+func (s *Server) DeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	queue := s.deliveries.Queue()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"pending":   queue.Pending(),
+		"dead":      queue.Dead(),
+		"endpoints": queue.Endpoints(),
+	})
+}
+
+// DeliveryActionHandler は配信できなかったものを送り直すか（POST /{id}/replay）、捨てます（DELETE /{id}）（管理用）
+func (s *Server) DeliveryActionHandler(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/api/admin/webhooks/deliveries/"
+	action, ok := pathAction(r.URL.Path, prefix)
+	if !ok || (action != "" && action != "replay") {
+		s.writeError(w, r, errPathNotFound)
+		return
+	}
+	if (action == "replay" && r.Method != http.MethodPost) || (action == "" && r.Method != http.MethodDelete) {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+	id, err := parseID(r.URL.Path, prefix, action)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if action == "" {
+		if err := s.deliveries.Queue().Discard(id); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{
+			"success": true,
+		})
+		return
+	}
+
+	delivery, err := s.deliveries.Replay(id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todo-app/models"
+	"todo-app/webhooks"
+)
+
+func TestAddWebhookHandler(t *testing.T) {
+	s := newTestServer()
+
+	body := `{"url": "https://hooks.slack.com/services/x", "preset": "slack", "events": ["task.created"]}`
+	req := httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.AddWebhookHandler).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, status, rr.Body.String())
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Webhook webhooks.Webhook `json:"webhook"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !response.Success || response.Webhook.ID != 1 || response.Webhook.Preset != "slack" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	if len(s.webhooks.List()) != 1 {
+		t.Error("Expected webhook to be stored")
+	}
+}
+
+func TestAddWebhookHandlerInvalid(t *testing.T) {
+	s := newTestServer()
+
+	testCases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"method", "GET", "", http.StatusMethodNotAllowed},
+		{"json", "POST", "{", http.StatusBadRequest},
+		{"template", "POST", `{"url": "https://example.com", "template": "{{"}`, http.StatusBadRequest},
+		{"url", "POST", `{"url": "nope"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/webhooks", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.AddWebhookHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf("Expected status code %d, got %d", tc.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetWebhooksHandler(t *testing.T) {
+	s := newTestServer()
+	s.webhooks.Add(webhooks.Webhook{URL: "https://example.com/a"})
+
+	req := httptest.NewRequest("GET", "/api/webhooks", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.GetWebhooksHandler).ServeHTTP(rr, req)
+
+	var list []webhooks.Webhook
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(list) != 1 || list[0].URL != "https://example.com/a" {
+		t.Errorf("Unexpected webhooks: %+v", list)
+	}
+
+	req = httptest.NewRequest("POST", "/api/webhooks", nil)
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(s.GetWebhooksHandler).ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestDeleteWebhookHandler(t *testing.T) {
+	s := newTestServer()
+	s.webhooks.Add(webhooks.Webhook{URL: "https://example.com/a"})
+
+	testCases := []struct {
+		method  string
+		path    string
+		status  int
+		success bool
+	}{
+		{"GET", "/api/webhooks/1", http.StatusMethodNotAllowed, false},
+		{"DELETE", "/api/webhooks/abc", http.StatusBadRequest, false},
+		{"DELETE", "/api/webhooks/1", http.StatusOK, true},
+		{"DELETE", "/api/webhooks/1", http.StatusNotFound, false},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(s.DeleteWebhookHandler).ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected status code %d, got %d", tc.method, tc.path, tc.status, rr.Code)
+			continue
+		}
+		if tc.status == http.StatusOK {
+			var response map[string]bool
+			json.Unmarshal(rr.Body.Bytes(), &response)
+			if response["success"] != tc.success {
+				t.Errorf("%s %s: expected success=%v", tc.method, tc.path, tc.success)
+			}
+		}
+	}
+}
+
+func TestDeliveriesHandlers(t *testing.T) {
+	var fail int32 = 1
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer receiver.Close()
+
+	hooks := webhooks.NewStore()
+	hooks.Add(webhooks.Webhook{URL: receiver.URL})
+	queue, _ := webhooks.NewQueue("", webhooks.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	dispatcher := webhooks.NewDispatcher(hooks, queue, receiver.Client())
+	s := NewServer(Deps{Webhooks: hooks, Deliveries: dispatcher, Config: Config{AdminToken: "secret"}})
+
+	dispatcher.HandleEvent(models.Event{Type: models.EventTaskCreated})
+	dispatcher.HandleEvent(models.Event{Type: models.EventTaskDeleted})
+	dispatcher.Wait()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/webhooks/deliveries"))
+	var response struct {
+		Success   bool                      `json:"success"`
+		Pending   []webhooks.Delivery       `json:"pending"`
+		Dead      []webhooks.Delivery       `json:"dead"`
+		Endpoints []webhooks.EndpointStatus `json:"endpoints"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || !response.Success || len(response.Pending) != 0 || len(response.Dead) != 2 {
+		t.Fatalf("Unexpected deliveries %d %s", rr.Code, rr.Body.String())
+	}
+	if len(response.Endpoints) != 1 || response.Endpoints[0].ConsecutiveFailures != 2 {
+		t.Errorf("Unexpected endpoints %+v", response.Endpoints)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("POST", "/api/admin/webhooks/deliveries/1/replay"))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"delivery":{"id":1,`) {
+		t.Fatalf("Unexpected replay response %d %s", rr.Code, rr.Body.String())
+	}
+	dispatcher.Wait()
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/webhooks/deliveries/2"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected discard response %d %s", rr.Code, rr.Body.String())
+	}
+	if len(queue.Pending()) != 0 || len(queue.Dead()) != 0 {
+		t.Errorf("Expected an empty queue, got pending %+v dead %+v", queue.Pending(), queue.Dead())
+	}
+
+	tests := []struct {
+		request *http.Request
+		status  int
+		code    string
+	}{
+		{adminRequest("POST", "/api/admin/webhooks/deliveries/1/replay"), http.StatusNotFound, "not_found"},
+		{adminRequest("DELETE", "/api/admin/webhooks/deliveries/2"), http.StatusNotFound, "not_found"},
+		{adminRequest("GET", "/api/admin/webhooks/deliveries/1/replay"), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{adminRequest("POST", "/api/admin/webhooks/deliveries/1"), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{adminRequest("POST", "/api/admin/webhooks/deliveries"), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{adminRequest("POST", "/api/admin/webhooks/deliveries/1/retry"), http.StatusNotFound, "not_found"},
+		{adminRequest("POST", "/api/admin/webhooks/deliveries/x/replay"), http.StatusBadRequest, "invalid"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, tt.request)
+		assertErrorResponse(t, rr, tt.status, tt.code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/webhooks/deliveries", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the deliveries to require the admin token, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WorkspaceHandler は /w/{slug}/… のリクエストを、接頭辞を取り除いてワークスペースのサーバへ渡します
+// /w/{slug} は末尾に / を付けた URL へリダイレクトします。メンバーを限ったワークスペースは、メンバーでなければ 404 を返します
+func (s *Server) WorkspaceHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/w/")
+	slug := rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		slug = rest[:i]
+	}
+	workspace, handler, ok := s.workspaces.Lookup(slug)
+	if !ok {
+		s.writeError(w, r, fmt.Errorf("%w: %q", errWorkspaceNotFound, slug))
+		return
+	}
+	// メンバーを限ったワークスペースは、ほかのユーザーには存在しないものとして扱います
+	if user, ok := contextUser(r); ok && !workspace.Allows(user.ID) {
+		s.writeError(w, r, fmt.Errorf("%w: %q", errWorkspaceNotFound, slug))
+		return
+	}
+	if rest == slug {
+		http.Redirect(w, r, workspace.Path()+"/", http.StatusMovedPermanently)
+		return
+	}
+	http.StripPrefix(workspace.Path(), handler).ServeHTTP(w, r)
+}
+
+// WorkspacesHandler はワークスペースの一覧（GET）と作成（POST {"slug": "...", "name": "..."}）を行います
+func (s *Server) WorkspacesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"workspaces": s.workspaces.List(),
+		})
+	case http.MethodPost:
+		var req struct {
+			Slug string `json:"slug"`
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, r, errInvalidJSON)
+			return
+		}
+		workspace, err := s.workspaces.Create(req.Slug, req.Name)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"workspace": workspace,
+			"url":       workspace.Path() + "/",
+		})
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// DeleteWorkspaceHandler は URL から slug を取り出し、そのワークスペースを削除します
+func (s *Server) DeleteWorkspaceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		s.writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	slug := strings.TrimPrefix(r.URL.Path, "/api/admin/workspaces/")
+	if !s.workspaces.Delete(slug) {
+		s.writeError(w, r, fmt.Errorf("%w: %q", errWorkspaceNotFound, slug))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/workspace"
+)
+
+// newWorkspaceTestServer はワークスペースごとに独立した Server を作るサーバを作成します
+func newWorkspaceTestServer() *Server {
+	config := Config{AdminToken: "secret"}
+	workspaces := workspace.NewStore(func(ws workspace.Workspace) (http.Handler, error) {
+		wsConfig := config
+		wsConfig.BasePath = ws.Path()
+		return NewServer(Deps{Config: wsConfig}), nil
+	})
+	return NewServer(Deps{Config: config, Workspaces: workspaces})
+}
+
+func TestWorkspacesHandler(t *testing.T) {
+	s := newWorkspaceTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{"slug": "family", "name": "うちの家族"}`))
+	var created struct {
+		Success   bool                `json:"success"`
+		Workspace workspace.Workspace `json:"workspace"`
+		URL       string              `json:"url"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || !created.Success || created.URL != "/w/family/" || created.Workspace.Name != "うちの家族" {
+		t.Fatalf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{"slug": "family"}`))
+	assertErrorResponse(t, rr, http.StatusConflict, "conflict")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{"slug": "Bad Slug"}`))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{`))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/workspaces"))
+	if !strings.Contains(rr.Body.String(), `"slug":"family"`) {
+		t.Errorf("Expected family in the list, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("PUT", "/api/admin/workspaces"))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/workspaces", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected workspaces to require the admin token, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/workspaces/family"))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected family to be deleted, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/workspaces/family"))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, adminRequest("GET", "/api/admin/workspaces/family"))
+	assertErrorResponse(t, rr, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestWorkspaceIsolation(t *testing.T) {
+	s := newWorkspaceTestServer()
+	for _, slug := range []string{"family", "team"} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{"slug": "`+slug+`"}`))
+	}
+
+	post := func(path, title string) {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("POST", path, strings.NewReader(`{"title": "`+title+`"}`)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("POST %s failed: %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	post("/api/tasks", "root task")
+	post("/w/family/api/tasks", "buy milk")
+	post("/w/team/api/tasks", "ship release")
+
+	for path, want := range map[string]string{
+		"/api/tasks":          "root task",
+		"/w/family/api/tasks": "buy milk",
+		"/w/team/api/tasks":   "ship release",
+	} {
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var tasks []struct {
+			Title string `json:"title"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &tasks)
+		if len(tasks) != 1 || tasks[0].Title != want {
+			t.Errorf("GET %s: expected only %q, got %s", path, want, rr.Body.String())
+		}
+	}
+
+	// ワークスペースの中で発行した短いリンクは、そのワークスペースの URL になります
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("POST", "/w/team/api/tasks/1/shortlink", nil))
+	var link struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &link)
+	if !strings.HasPrefix(link.URL, "/w/team/t/") {
+		t.Fatalf("Expected a workspace short link, got %s", rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", link.URL, nil))
+	if location := rr.Header().Get("Location"); location != "/w/team/tasks/1" {
+		t.Errorf("Expected a redirect within the workspace, got %q", location)
+	}
+}
+
+func TestWorkspaceHandlerRouting(t *testing.T) {
+	s := newWorkspaceTestServer()
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, adminBodyRequest("POST", "/api/admin/workspaces", `{"slug": "family"}`))
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/w/family", nil))
+	if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "/w/family/" {
+		t.Errorf("Expected a redirect to /w/family/, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	// WebDAV の URL にはワークスペースのパスが付きます
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("PROPFIND", "/w/family/dav/", nil))
+	if rr.Code != http.StatusMultiStatus || !strings.Contains(rr.Body.String(), "<D:href>/w/family/dav/Tasks/</D:href>") {
+		t.Errorf("Expected WebDAV under the workspace path, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/w/unknown/api/tasks", nil))
+	assertErrorResponse(t, rr, http.StatusNotFound, "not_found")
+
+	// ワークスペースを設定しないサーバには /w/ がありません
+	rr = httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/workspaces", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected no workspace endpoints, got %d", rr.Code)
+	}
+}
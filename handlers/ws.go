@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-app/models"
+	"todo-app/websocket"
+)
+
+// liveSyncPingInterval は WebSocket の接続に ping を送る間隔です。切れた接続を見つけて購読を解除するために使います
+const liveSyncPingInterval = 30 * time.Second
+
+// WebSocketHandler はタスクの変更イベント（作成・更新・削除）を WebSocket で送り続けます（GET /ws）
+// 同じタスクを開いているほかのタブが、ページを読み込み直さずに一覧を更新するために使います
+// メッセージは models.Event の JSON（{"id":…,"type":"task.updated","task":{…},"time":…}）です。クライアントからのメッセージは読み捨てます
+func (s *Server) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	// 接続を知らせる前に購読し、101 を受け取った直後の変更も届くようにします
+	events, lagging, unsubscribe := s.subscribeEvents()
+	defer unsubscribe()
+
+	conn, err := websocket.Upgrade(w, r)
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		s.writeError(w, r, fmt.Errorf("%w: %v", models.ErrValidation, err))
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// クライアントからのメッセージは使いませんが、ping への応答と切断に気づくために読み続けます
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, err := conn.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(liveSyncPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			message, err := json.Marshal(event)
+			if err == nil {
+				err = conn.WriteText(message)
+			}
+			if err != nil {
+				conn.Close(websocket.CloseGoingAway)
+				return
+			}
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				conn.Close(websocket.CloseGoingAway)
+				return
+			}
+		case <-s.stopping.Done():
+			// サーバを止めるときは接続を閉じ（1001）、再起動した後に接続し直してもらいます
+			conn.Close(websocket.CloseGoingAway)
+			return
+		case <-lagging:
+			conn.Close(websocket.ClosePolicyViolation)
+			return
+		case <-closed:
+			conn.Close(websocket.CloseNormal)
+			return
+		}
+	}
+}
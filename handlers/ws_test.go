@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestWebSocketHandler(t *testing.T) {
+	s := newTestServer()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: " + host + "\r\nOrigin: " + server.URL + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code 101, got %d", response.StatusCode)
+	}
+
+	http.Post(server.URL+"/api/tasks", "application/json", strings.NewReader(`{"title": "Sync me"}`))
+	request, _ := http.NewRequest("PUT", server.URL+"/api/tasks/1/toggle", nil)
+	http.DefaultClient.Do(request)
+	request, _ = http.NewRequest("DELETE", server.URL+"/api/tasks/1", nil)
+	http.DefaultClient.Do(request)
+
+	for _, want := range []struct {
+		eventType models.EventType
+		completed bool
+	}{
+		{models.EventTaskCreated, false},
+		{models.EventTaskUpdated, true},
+		{models.EventTaskDeleted, true},
+	} {
+		var event models.Event
+		if err := json.Unmarshal(readServerFrame(t, reader), &event); err != nil {
+			t.Fatalf("Failed to unmarshal the event: %v", err)
+		}
+		if event.Type != want.eventType || event.Task.Title != "Sync me" || event.Task.Completed != want.completed {
+			t.Errorf("Expected %s with completed=%v, got %+v", want.eventType, want.completed, event)
+		}
+	}
+}
+
+func TestWebSocketHandlerRejectsBadHandshakes(t *testing.T) {
+	s := newTestServer()
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest("GET", "/ws", nil))
+	assertErrorResponse(t, rr, http.StatusBadRequest, "invalid")
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "https://evil.example")
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	assertErrorResponse(t, rr, http.StatusForbidden, "forbidden")
+}
+
+// readServerFrame はサーバから届いたテキストのフレームの内容を読み取ります
+func readServerFrame(t *testing.T, reader *bufio.Reader) []byte {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatal(err)
+	}
+	if header[0] != 0x81 {
+		t.Fatalf("Expected a text frame, got %#x", header[0])
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(reader, ext); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(ext))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// serverConfig は http.Server のタイムアウトと上限です
+// 小さな自前のサーバでも、ヘッダを少しずつ送り続ける（slowloris のような）クライアントに接続を使い切られないようにします
+// ReadHeaderTimeout: リクエストのヘッダを読み終えるまでの時間
+// ReadTimeout: 本文を含めてリクエストを読み終えるまでの時間
+// WriteTimeout: ヘッダを読み終えてから応答を書き終えるまでの時間（LLM への問い合わせなど時間のかかる処理より長くします）
+// IdleTimeout: keep-alive の接続で次のリクエストを待つ時間
+// MaxHeaderBytes: リクエストのヘッダの大きさの上限
+// MaxConnections: 同時に受け付ける接続の数（0 なら無制限）。上限に達すると、接続が閉じるまで新しい接続を待たせます
+// TLSCert / TLSKey: HTTPS で待ち受けるときの証明書と秘密鍵のファイル。HTTPS では HTTP/2 も使えます
+// ShutdownTimeout: 止めるときに処理中のリクエストが終わるのを待つ時間（0 なら待たずに接続を閉じます）
+type serverConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	MaxConnections    int
+	TLSCert           string
+	TLSKey            string
+	ShutdownTimeout   time.Duration
+}
+
+// serverFlags は serverConfig のフラグを fs に登録し、読み取った値を入れる serverConfig を返します
+func serverFlags(fs *flag.FlagSet) *serverConfig {
+	config := &serverConfig{}
+	fs.DurationVar(&config.ReadHeaderTimeout, "read-header-timeout", 5*time.Second, "リクエストのヘッダを読み終えるまでの時間")
+	fs.DurationVar(&config.ReadTimeout, "read-timeout", 30*time.Second, "本文を含めてリクエストを読み終えるまでの時間")
+	fs.DurationVar(&config.WriteTimeout, "write-timeout", 60*time.Second, "応答を書き終えるまでの時間")
+	fs.DurationVar(&config.IdleTimeout, "idle-timeout", 120*time.Second, "keep-alive の接続で次のリクエストを待つ時間")
+	fs.IntVar(&config.MaxHeaderBytes, "max-header-bytes", 64<<10, "リクエストのヘッダの大きさの上限（バイト）")
+	fs.IntVar(&config.MaxConnections, "max-connections", 512, "同時に受け付ける接続の数（0 なら無制限）")
+	fs.StringVar(&config.TLSCert, "tls-cert", "", "HTTPS（と HTTP/2）で待ち受けるときの証明書のファイル")
+	fs.StringVar(&config.TLSKey, "tls-key", "", "HTTPS で待ち受けるときの秘密鍵のファイル")
+	fs.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "止めるときに処理中のリクエストが終わるのを待つ時間")
+	return config
+}
+
+// validate は設定の誤りを返します
+func (c serverConfig) validate() error {
+	for name, d := range map[string]time.Duration{
+		"-read-header-timeout": c.ReadHeaderTimeout,
+		"-read-timeout":        c.ReadTimeout,
+		"-write-timeout":       c.WriteTimeout,
+		"-idle-timeout":        c.IdleTimeout,
+	} {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, d)
+		}
+	}
+	if c.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("-max-header-bytes must be positive, got %d", c.MaxHeaderBytes)
+	}
+	if c.MaxConnections < 0 {
+		return fmt.Errorf("-max-connections must not be negative, got %d", c.MaxConnections)
+	}
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("-shutdown-timeout must not be negative, got %s", c.ShutdownTimeout)
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("-tls-cert and -tls-key must be set together")
+	}
+	return nil
+}
+
+// newHTTPServer は config のタイムアウトと上限で handler を提供する http.Server を作成します
+// 接続のエラー（TLS のハンドシェイクの失敗など）は標準の Logger に warn として記録します
+func newHTTPServer(handler http.Handler, config serverConfig) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// serve は listener で server を動かします。証明書を設定していれば HTTPS で待ち受け、HTTP/2 も有効になります
+// 同時に受け付ける接続の数は config.MaxConnections までにします
+func serve(server *http.Server, listener net.Listener, config serverConfig) error {
+	if config.MaxConnections > 0 {
+		listener = limitListener(listener, config.MaxConnections)
+	}
+	if config.TLSCert != "" {
+		return server.ServeTLS(listener, config.TLSCert, config.TLSKey)
+	}
+	return server.Serve(listener)
+}
+
+// run は ctx がキャンセルされるまで serve で server を動かします
+// キャンセルされたら新しい接続の受け付けをやめ、処理中のリクエストが終わるのを config.ShutdownTimeout まで待ってから戻ります
+// 待ちきれなかったときは残りの接続を閉じてエラーを返します
+func run(ctx context.Context, server *http.Server, listener net.Listener, config serverConfig) error {
+	errs := make(chan error, 1)
+	go func() { errs <- serve(server, listener, config) }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("requests did not finish within %s: %w", config.ShutdownTimeout, err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// limitListener は同時に開いている接続を n 個までにする listener を返します
+// 上限に達している間は Accept が待ち、どれかの接続が閉じると次の接続を受け付けます
+func limitListener(listener net.Listener, n int) net.Listener {
+	return &limitedListener{Listener: listener, slots: make(chan struct{}, n), done: make(chan struct{})}
+}
+
+// limitedListener は limitListener の listener です
+type limitedListener struct {
+	net.Listener
+	slots     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept は空きができるまで待ってから、次の接続を受け付けます
+func (l *limitedListener) Accept() (net.Conn, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: func() { <-l.slots }}, nil
+}
+
+// Close は listener を閉じ、空きを待っている Accept を終わらせます
+func (l *limitedListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitedConn は閉じたときに limitedListener の空きを1つ戻す接続です
+type limitedConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+// Close は接続を閉じ、空きを戻します（2回閉じても1つだけ戻します）
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
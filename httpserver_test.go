@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	config := serverFlags(fs)
+	if err := fs.Parse([]string{"-read-header-timeout", "2s", "-max-connections", "10"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.ReadHeaderTimeout != 2*time.Second || config.MaxConnections != 10 || config.WriteTimeout != time.Minute || config.MaxHeaderBytes != 64<<10 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if err := config.validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+
+	server := newHTTPServer(http.NotFoundHandler(), *config)
+	if server.ReadHeaderTimeout != 2*time.Second || server.ReadTimeout != 30*time.Second || server.IdleTimeout != 2*time.Minute || server.MaxHeaderBytes != 64<<10 {
+		t.Errorf("Expected the config to be applied, got %+v", server)
+	}
+}
+
+func TestServerConfigValidate(t *testing.T) {
+	valid := serverConfig{ReadHeaderTimeout: time.Second, ReadTimeout: time.Second, WriteTimeout: time.Second, IdleTimeout: time.Second, MaxHeaderBytes: 1024}
+	testCases := map[string]func(c *serverConfig){
+		"-read-header-timeout": func(c *serverConfig) { c.ReadHeaderTimeout = 0 },
+		"-idle-timeout":        func(c *serverConfig) { c.IdleTimeout = -time.Second },
+		"-max-header-bytes":    func(c *serverConfig) { c.MaxHeaderBytes = 0 },
+		"-max-connections":     func(c *serverConfig) { c.MaxConnections = -1 },
+		"-tls-key":             func(c *serverConfig) { c.TLSCert = "cert.pem" },
+	}
+	for want, change := range testCases {
+		config := valid
+		change(&config)
+		if err := config.validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error about %s, got %v", want, err)
+		}
+	}
+}
+
+func TestRunShutsDownGracefully(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	server := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	}), serverConfig{ShutdownTimeout: 5 * time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- run(ctx, server, listener, serverConfig{ShutdownTimeout: 5 * time.Second}) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("Expected run to wait for the request in flight, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := net.DialTimeout("tcp", listener.Addr().String(), time.Second); err == nil {
+		t.Error("Expected new connections to be refused while shutting down")
+	}
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("Expected the request in flight to finish, got %q", got)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	config := serverConfig{ShutdownTimeout: 50 * time.Millisecond}
+	server := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}), config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- run(ctx, server, listener, config) }()
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+	cancel()
+
+	if err := <-stopped; err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("Expected a shutdown timeout error, got %v", err)
+	}
+}
+
+func TestLimitListener(t *testing.T) {
+	base, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := limitListener(base, 1)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", base.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("Expected the second connection to wait while the first is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.Close()
+	first.Close()
+	select {
+	case second := <-accepted:
+		second.Close()
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second connection to be accepted after the first closed")
+	}
+
+	listener.Close()
+	if _, ok := <-accepted; ok {
+		t.Error("Expected Accept to stop after Close")
+	}
+	if _, err := listener.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Expected net.ErrClosed, got %v", err)
+	}
+}
+
+// writeTestCertificate は localhost の自己署名証明書と秘密鍵を dir に書き出します
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestServeTLSUsesHTTP2(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	config := serverConfig{ReadHeaderTimeout: time.Second, ReadTimeout: time.Second, WriteTimeout: time.Second, IdleTimeout: time.Second, MaxHeaderBytes: 4096, MaxConnections: 4, TLSCert: certFile, TLSKey: keyFile}
+	server := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	}), config)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- serve(server, listener, config) }()
+	defer func() {
+		server.Close()
+		if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Expected the server to stop cleanly, got %v", err)
+		}
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "HTTP/2.0" {
+		t.Errorf("Expected HTTP/2 over TLS, got %q", body)
+	}
+}
+
+func TestServeRejectsLargeHeaders(t *testing.T) {
+	config := serverConfig{ReadHeaderTimeout: time.Second, ReadTimeout: time.Second, WriteTimeout: time.Second, IdleTimeout: time.Second, MaxHeaderBytes: 1024}
+	server := newHTTPServer(http.NotFoundHandler(), config)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serve(server, listener, config)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", "http://"+listener.Addr().String()+"/", nil)
+	req.Header.Set("X-Large", strings.Repeat("a", 8192))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("Expected 431, got %d", resp.StatusCode)
+	}
+}
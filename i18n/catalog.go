@@ -0,0 +1,99 @@
+package i18n
+
+// english は英語のメッセージです
+var english = map[string]string{
+	"error.task_not_found":            "The task was not found.",
+	"error.time_entry_not_found":      "The time entry was not found.",
+	"error.comment_not_found":         "The comment was not found.",
+	"error.session_not_found":         "The pomodoro session was not found.",
+	"error.webhook_not_found":         "The webhook was not found.",
+	"error.delivery_not_found":        "The webhook delivery was not found.",
+	"error.rule_not_found":            "The rule was not found.",
+	"error.column_not_found":          "The board column was not found.",
+	"error.report_schedule_not_found": "The report schedule was not found.",
+	"error.share_not_found":           "The share link was not found. It may have been revoked.",
+	"error.workspace_not_found":       "The workspace was not found.",
+	"error.api_key_not_found":         "The API key was not found.",
+	"error.login_session_not_found":   "The login session was not found.",
+	"error.invite_not_found":          "The invite code was not found.",
+	"error.list_not_found":            "The list was not found.",
+	"error.path_not_found":            "The requested URL was not found.",
+	"error.nothing_to_undo":           "There is nothing to undo.",
+	"error.validation":                "The request contains invalid values.",
+	"error.invalid_id":                "The ID must be a positive integer.",
+	"error.invalid_json":              "The request body is not valid JSON.",
+	"error.unsupported_api_version":   "The requested API version is not supported.",
+	"error.invalid_request":           "Some fields in the request body are invalid.",
+	"error.conflict":                  "The request conflicts with the current state.",
+	"error.vetoed":                    "The operation was rejected by a plugin.",
+	"error.already_claimed":           "Someone else is already working on this task.",
+	"error.method_not_allowed":        "This method is not allowed for the URL.",
+	"error.admin_disabled":            "Admin endpoints are disabled.",
+	"error.cross_origin":              "Connections from other sites are not allowed.",
+	"error.unauthorized":              "Authentication is required.",
+	"error.invalid_credentials":       "The name or password is incorrect.",
+	"error.too_many_attempts":         "Too many failed attempts. Please try again later.",
+	"error.captcha_required":          "Please complete the CAPTCHA and try again.",
+	"error.email_not_verified":        "Please verify your email address with the link we sent before logging in.",
+	"error.invalid_invite":            "The invite code is invalid, expired or already used.",
+	"error.user_disabled":             "This account has been disabled. Please contact your administrator.",
+	"error.user_not_found":            "The user was not found.",
+	"error.password_breached":         "This password has appeared in a data breach. Please choose another one.",
+	"error.password_reused":           "This password was used recently. Please choose another one.",
+	"error.quota_exceeded":            "This API key has reached its request limit. Please try again later.",
+	"error.backup_failed":             "The backup storage returned an error.",
+	"error.store_failed":              "Changes cannot be saved right now. Please try again later.",
+	"error.suggestion_failed":         "The AI service could not suggest subtasks. Please try again later.",
+	"error.timeout":                   "The request timed out.",
+	"error.canceled":                  "The request was canceled.",
+	"error.internal":                  "An internal server error occurred.",
+}
+
+// japanese は日本語のメッセージです
+var japanese = map[string]string{
+	"error.task_not_found":            "タスクが見つかりません。",
+	"error.time_entry_not_found":      "作業記録が見つかりません。",
+	"error.comment_not_found":         "コメントが見つかりません。",
+	"error.session_not_found":         "ポモドーロのセッションが見つかりません。",
+	"error.webhook_not_found":         "Webhook が見つかりません。",
+	"error.delivery_not_found":        "Webhook の配信が見つかりません。",
+	"error.rule_not_found":            "自動化ルールが見つかりません。",
+	"error.column_not_found":          "ボードのカラムが見つかりません。",
+	"error.report_schedule_not_found": "レポートのスケジュールが見つかりません。",
+	"error.share_not_found":           "共有リンクが見つかりません。取り消された可能性があります。",
+	"error.workspace_not_found":       "ワークスペースが見つかりません。",
+	"error.api_key_not_found":         "API キーが見つかりません。",
+	"error.login_session_not_found":   "ログインのセッションが見つかりません。",
+	"error.invite_not_found":          "招待コードが見つかりません。",
+	"error.list_not_found":            "リストが見つかりません。",
+	"error.path_not_found":            "指定された URL は見つかりません。",
+	"error.nothing_to_undo":           "取り消せる操作はありません。",
+	"error.validation":                "入力内容に誤りがあります。",
+	"error.invalid_id":                "ID は正の整数で指定してください。",
+	"error.invalid_json":              "リクエストの本文が正しい JSON ではありません。",
+	"error.unsupported_api_version":   "指定された API のバージョンには対応していません。",
+	"error.invalid_request":           "リクエストの本文に誤りのある項目があります。",
+	"error.conflict":                  "現在の状態と矛盾するため処理できません。",
+	"error.vetoed":                    "プラグインによって操作が拒否されました。",
+	"error.already_claimed":           "このタスクはほかの人が担当しています。",
+	"error.method_not_allowed":        "この URL ではそのメソッドを使えません。",
+	"error.admin_disabled":            "管理用のエンドポイントは無効です。",
+	"error.cross_origin":              "ほかのサイトからの接続は受け付けません。",
+	"error.unauthorized":              "認証が必要です。",
+	"error.invalid_credentials":       "ユーザー名かパスワードが正しくありません。",
+	"error.too_many_attempts":         "失敗が続いたため、しばらく受け付けません。時間をおいてからお試しください。",
+	"error.captcha_required":          "CAPTCHA を解いてからもう一度お試しください。",
+	"error.email_not_verified":        "ログインする前に、メールで送ったリンクからメールアドレスを確認してください。",
+	"error.invalid_invite":            "招待コードが誤っているか、期限切れか、使用済みです。",
+	"error.user_disabled":             "このアカウントは無効になっています。管理者に問い合わせてください。",
+	"error.user_not_found":            "ユーザーが見つかりません。",
+	"error.password_breached":         "このパスワードは漏洩したパスワードの一覧に含まれています。別のパスワードを選んでください。",
+	"error.password_reused":           "このパスワードは最近使ったものです。別のパスワードを選んでください。",
+	"error.quota_exceeded":            "この API キーのリクエスト数が上限に達しました。時間をおいてからお試しください。",
+	"error.backup_failed":             "バックアップの保存先でエラーが発生しました。",
+	"error.store_failed":              "今は変更を保存できません。しばらくしてからもう一度お試しください。",
+	"error.suggestion_failed":         "AI のサービスで案を作れませんでした。しばらくしてからもう一度お試しください。",
+	"error.timeout":                   "処理が時間内に終わりませんでした。",
+	"error.canceled":                  "処理が中断されました。",
+	"error.internal":                  "サーバ内部でエラーが発生しました。",
+}
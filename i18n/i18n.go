@@ -0,0 +1,72 @@
+// Package i18n は API が返す利用者向けのメッセージを、Accept-Language で選んだ言語に翻訳します
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang はメッセージの言語（BCP 47 の主言語タグ）です
+type Lang string
+
+const (
+	English  Lang = "en"
+	Japanese Lang = "ja"
+)
+
+// Default は Accept-Language がないか、対応していない言語だけを求められたときの言語です
+const Default = English
+
+// catalogs は言語ごとのメッセージのカタログです。キーはすべての言語で同じものを持ちます
+var catalogs = map[Lang]map[string]string{
+	English:  english,
+	Japanese: japanese,
+}
+
+// Negotiate は Accept-Language ヘッダから対応している言語のうち最も優先度の高いものを返します
+// "ja-JP,ja;q=0.9,en;q=0.8" のような地域付きのタグや q 値を扱い、q=0 の言語は選びません
+func Negotiate(acceptLanguage string) Lang {
+	type candidate struct {
+		lang    Lang
+		quality float64
+		order   int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		lang := Lang(strings.SplitN(tag, "-", 2)[0])
+		if _, ok := catalogs[lang]; ok && quality > 0 {
+			candidates = append(candidates, candidate{lang, quality, i})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
+
+// Message は key のメッセージを lang で返します
+// lang のカタログにない場合は英語を、英語にもない場合は key をそのまま返します
+func Message(lang Lang, key string) string {
+	if message, ok := catalogs[lang][key]; ok {
+		return message
+	}
+	if message, ok := catalogs[Default][key]; ok {
+		return message
+	}
+	return key
+}
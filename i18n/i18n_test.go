@@ -0,0 +1,49 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	testCases := map[string]Lang{
+		"":                         English,
+		"ja":                       Japanese,
+		"ja-JP,ja;q=0.9,en;q=0.8":  Japanese,
+		"en-US,en;q=0.9,ja;q=0.8":  English,
+		"fr-FR,ja;q=0.5,en;q=0.7":  English,
+		"fr, de":                   English,
+		"en;q=0, ja;q=0.1":         Japanese,
+		"EN-gb;q=0.4, JA-jp;q=0.6": Japanese,
+		"ja;q=invalid, en;q=0.5":   Japanese,
+		"*":                        English,
+	}
+	for header, want := range testCases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, expected %q", header, got, want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if got := Message(Japanese, "error.task_not_found"); got != "タスクが見つかりません。" {
+		t.Errorf("Unexpected Japanese message: %q", got)
+	}
+	if got := Message("fr", "error.task_not_found"); got != "The task was not found." {
+		t.Errorf("Expected the English fallback, got %q", got)
+	}
+	if got := Message(Japanese, "error.unknown"); got != "error.unknown" {
+		t.Errorf("Expected the key for an unknown message, got %q", got)
+	}
+}
+
+// TestCatalogsHaveSameKeys はすべての言語のカタログが同じキーを持つことを確かめます
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range english {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s catalog is missing %q", lang, key)
+			}
+		}
+		if len(catalog) != len(english) {
+			t.Errorf("%s catalog has %d messages, expected %d", lang, len(catalog), len(english))
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package importer はスプレッドシートなどから書き出したファイルを読み込み、タスクとして取り込みます
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/models"
+)
+
+// Fields は取り込めるタスクの項目です。title 以外は省略できます
+var Fields = []string{"title", "due_date", "scheduled_date", "priority", "estimate_minutes", "completed"}
+
+// Mapping はタスクの項目ごとに、値を読む CSV の列の見出しを表します
+type Mapping map[string]string
+
+// ParseMapping は "title:Task Name,due_date:Deadline" 形式の列の対応を読み取ります
+// 指定しなかった項目は、項目名と同じ見出し（大文字・小文字と空白・_ の違いは無視します）の列から読みます
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("%w: invalid column mapping %q (for example title:Task Name)", models.ErrValidation, pair)
+		}
+		field := strings.TrimSpace(parts[0])
+		if !isField(field) {
+			return nil, fmt.Errorf("%w: unknown field %q (%s)", models.ErrValidation, field, strings.Join(Fields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(parts[1])
+	}
+	return mapping, nil
+}
+
+func isField(field string) bool {
+	for _, known := range Fields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Row は CSV の1行から読み取ったタスクです
+// Line: CSV の行番号（見出しが1行目）
+// Errors: 取り込めない理由（空なら取り込めます）
+type Row struct {
+	Line            int        `json:"line"`
+	Title           string     `json:"title"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	ScheduledDate   *time.Time `json:"scheduled_date,omitempty"`
+	Priority        string     `json:"priority,omitempty"`
+	EstimateMinutes int        `json:"estimate_minutes,omitempty"`
+	Completed       bool       `json:"completed,omitempty"`
+	Errors          []string   `json:"errors,omitempty"`
+}
+
+// ReadCSV は見出し付きの CSV を読み、行ごとに値を検証します
+// 値の誤りは行の Errors に記録して読み続け、見出しがない・title の列がないなど CSV 全体の誤りだけを ErrValidation で返します
+func ReadCSV(r io.Reader, mapping Mapping) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: CSV has no header row", models.ErrValidation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrValidation, err)
+	}
+	columns, err := resolveColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []Row{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %v", models.ErrValidation, err)
+			}
+			return nil, err
+		}
+		if isBlank(record) {
+			continue
+		}
+		rows = append(rows, parseRow(line, record, columns))
+	}
+}
+
+// resolveColumns はタスクの項目ごとに列の位置を求めます（列がない項目は含めません）
+func resolveColumns(header []string, mapping Mapping) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := normalizeHeader(name)
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for _, field := range Fields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		if i, ok := positions[normalizeHeader(name)]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("%w: column %q for %s not found", models.ErrValidation, name, field)
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: CSV has no title column (map one with title:<column>)", models.ErrValidation)
+	}
+	return columns, nil
+}
+
+// normalizeHeader は見出しを比べるために小文字にし、空白と _ を取り除きます
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "\ufeff"))
+	return strings.NewReplacer(" ", "", "_", "").Replace(name)
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRow は1行の値を読み取り、誤りを Errors に記録します
+func parseRow(line int, record []string, columns map[string]int) Row {
+	row := Row{Line: line}
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	fail := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+
+	row.Title = value("title")
+	if row.Title == "" {
+		fail("title is empty")
+	}
+	for _, field := range []string{"due_date", "scheduled_date"} {
+		raw := value(field)
+		if raw == "" {
+			continue
+		}
+		date, err := parseDate(raw)
+		if err != nil {
+			fail("%s %q is not a date (YYYY-MM-DD)", field, raw)
+			continue
+		}
+		if field == "due_date" {
+			row.DueDate = &date
+		} else {
+			row.ScheduledDate = &date
+		}
+	}
+	if raw := strings.ToLower(value("priority")); raw != "" {
+		if err := models.Priority(raw).Validate(); err != nil {
+			fail("priority %q is not low, medium or high", raw)
+		} else {
+			row.Priority = raw
+		}
+	}
+	if raw := value("estimate_minutes"); raw != "" {
+		minutes, err := strconv.Atoi(raw)
+		if err != nil || minutes < 0 {
+			fail("estimate_minutes %q is not a number of minutes", raw)
+		} else {
+			row.EstimateMinutes = minutes
+		}
+	}
+	if raw := value("completed"); raw != "" {
+		completed, ok := parseBool(raw)
+		if !ok {
+			fail("completed %q is not true or false", raw)
+		}
+		row.Completed = completed
+	}
+	return row
+}
+
+// parseDate は YYYY-MM-DD・YYYY/MM/DD・RFC 3339 の日付を読み取ります
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "2006/1/2", time.RFC3339} {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date")
+}
+
+// parseBool はスプレッドシートでよく使われる真偽値の表記を読み取ります
+func parseBool(s string) (value bool, ok bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "1", "x", "done", "完了":
+		return true, true
+	case "false", "no", "n", "0", "未完了":
+		return false, true
+	}
+	return false, false
+}
+
+// Report は取り込みの結果です
+// DryRun: 検証だけを行い、タスクを作らなかったかどうか
+// Created: 作成した（DryRun なら作成できる）タスクの数
+// Failed: 値の誤りや保存の失敗で取り込めなかった行の数
+// Rows: 行ごとの結果（作成したタスクのIDを含みます）
+type Report struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Failed  int         `json:"failed"`
+	Rows    []RowResult `json:"rows"`
+}
+
+// RowResult は1行の取り込みの結果です
+type RowResult struct {
+	Row
+	TaskID int `json:"task_id,omitempty"`
+}
+
+// Import は誤りのない行をタスクとして store に作成します。dryRun なら検証だけを行います
+// 1行ずつ作成するため、途中で保存に失敗してもそれまでの行は作成されたまま残ります
+func Import(ctx context.Context, store models.TaskStore, rows []Row, dryRun bool) Report {
+	report := Report{DryRun: dryRun, Total: len(rows), Rows: make([]RowResult, 0, len(rows))}
+	for _, row := range rows {
+		result := RowResult{Row: row}
+		if len(row.Errors) == 0 && !dryRun {
+			id, err := create(ctx, store, row)
+			result.TaskID = id
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+		if len(result.Errors) > 0 {
+			report.Failed++
+		} else {
+			report.Created++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report
+}
+
+// create は1行分のタスクを作成し、値のある項目だけを設定します
+func create(ctx context.Context, store models.TaskStore, row Row) (int, error) {
+	task, err := store.AddTask(ctx, row.Title)
+	if err != nil {
+		return 0, err
+	}
+	var steps []func() error
+	if row.DueDate != nil {
+		steps = append(steps, func() error { return store.SetDueDate(ctx, task.ID, row.DueDate) })
+	}
+	if row.ScheduledDate != nil {
+		steps = append(steps, func() error { return store.SetScheduledDate(ctx, task.ID, row.ScheduledDate) })
+	}
+	if row.Priority != "" {
+		steps = append(steps, func() error { return store.SetPriority(ctx, task.ID, models.Priority(row.Priority)) })
+	}
+	if row.EstimateMinutes > 0 {
+		steps = append(steps, func() error { return store.SetEstimate(ctx, task.ID, row.EstimateMinutes) })
+	}
+	if row.Completed {
+		steps = append(steps, func() error { return store.ToggleTask(ctx, task.ID) })
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return task.ID, err
+		}
+	}
+	return task.ID, nil
+}
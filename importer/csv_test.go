@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+const sheet = "\ufeffTask Name,Deadline,Priority,Estimate Minutes,Completed\n" +
+	"Write report,2025-03-01,High,90,no\n" +
+	"Fix bug,2025/03/05,,,x\n" +
+	",,,,\n" +
+	"Broken,tomorrow,urgent,-5,maybe\n" +
+	",2025-03-01,,,\n"
+
+func TestReadCSV(t *testing.T) {
+	mapping, err := ParseMapping("title:Task Name, due_date:Deadline")
+	if err != nil {
+		t.Fatalf("ParseMapping failed: %v", err)
+	}
+	rows, err := ReadCSV(strings.NewReader(sheet), mapping)
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows without the blank one, got %+v", rows)
+	}
+
+	first := rows[0]
+	if first.Line != 2 || first.Title != "Write report" || first.Priority != "high" || first.EstimateMinutes != 90 ||
+		first.Completed || first.DueDate == nil || !first.DueDate.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || len(first.Errors) != 0 {
+		t.Errorf("Unexpected first row: %+v", first)
+	}
+	if second := rows[1]; !second.Completed || second.DueDate == nil || second.DueDate.Day() != 5 || len(second.Errors) != 0 {
+		t.Errorf("Unexpected second row: %+v", second)
+	}
+	if broken := rows[2]; broken.Line != 5 || len(broken.Errors) != 4 {
+		t.Errorf("Expected 4 errors on line 5, got %+v", broken)
+	}
+	if untitled := rows[3]; untitled.Line != 6 || len(untitled.Errors) != 1 || untitled.Errors[0] != "title is empty" {
+		t.Errorf("Expected an empty title error on line 6, got %+v", untitled)
+	}
+}
+
+func TestReadCSVDefaultColumns(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("title,scheduled_date\nPlan,2025-03-10T09:00:00+09:00\n"), nil)
+	if err != nil || len(rows) != 1 || rows[0].ScheduledDate == nil || len(rows[0].Errors) != 0 {
+		t.Errorf("Unexpected rows: %+v (%v)", rows, err)
+	}
+}
+
+func TestReadCSVInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		csv     string
+		mapping Mapping
+	}{
+		"empty":          {"", nil},
+		"no title":       {"name,due_date\nA,\n", nil},
+		"missing column": {"title\nA\n", Mapping{"due_date": "Deadline"}},
+		"bad quotes":     {"title\n\"unterminated\n", nil},
+		"bad header":     {"\"title\n", nil},
+	}
+	for name, tc := range testCases {
+		if _, err := ReadCSV(strings.NewReader(tc.csv), tc.mapping); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", name, err)
+		}
+	}
+}
+
+func TestParseMappingInvalid(t *testing.T) {
+	for _, s := range []string{"title", "title:", "owner:Assignee"} {
+		if _, err := ParseMapping(s); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", s, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	rows, err := ReadCSV(strings.NewReader(sheet), Mapping{"title": "Task Name", "due_date": "Deadline"})
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	store := storetest.NewFake()
+	report := Import(ctx, store, rows, true)
+	if !report.DryRun || report.Total != 4 || report.Created != 2 || report.Failed != 2 || len(store.GetTasks(ctx)) != 0 {
+		t.Errorf("Unexpected dry run: %+v", report)
+	}
+
+	report = Import(ctx, store, rows, false)
+	if report.DryRun || report.Created != 2 || report.Failed != 2 || report.Rows[0].TaskID == 0 || report.Rows[2].TaskID != 0 {
+		t.Errorf("Unexpected import: %+v", report)
+	}
+	tasks := store.GetTasks(ctx)
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %+v", tasks)
+	}
+	if tasks[0].Title != "Write report" || tasks[0].Priority != models.PriorityHigh || tasks[0].EstimateMinutes != 90 || tasks[0].DueDate == nil {
+		t.Errorf("Unexpected first task: %+v", tasks[0])
+	}
+	if !tasks[1].Completed || tasks[1].DueDate == nil {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+}
+
+func TestImportStoreError(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp(models.WithMaxTasks(1))
+	rows := []Row{{Line: 2, Title: "First"}, {Line: 3, Title: "Second"}}
+
+	report := Import(ctx, store, rows, false)
+	if report.Created != 1 || report.Failed != 1 || len(report.Rows[1].Errors) != 1 {
+		t.Errorf("Expected the second row to fail on the task limit, got %+v", report)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"todo-app/accounts"
+	"todo-app/analytics"
+	"todo-app/backup"
+	"todo-app/config"
+	"todo-app/exechooks"
+	"todo-app/handlers"
+	"todo-app/integrations/captcha"
+	"todo-app/integrations/gcal"
+	"todo-app/integrations/google"
+	"todo-app/integrations/googletasks"
+	"todo-app/integrations/jira"
+	"todo-app/integrations/llm"
+	"todo-app/integrations/notion"
+	"todo-app/integrations/pwned"
+	"todo-app/leader"
+	"todo-app/models"
+	"todo-app/reports"
+	"todo-app/store/filestore"
+)
+
+// startIntegrations は cfg で設定された外部サービス連携を起動し、cfg.IntegrationInterval ごとに同期します
+func startIntegrations(ctx context.Context, app models.TaskStore, cfg config.Config) {
+	startJiraSync(ctx, app, cfg)
+	startGoogleTasksSync(ctx, app, cfg)
+	startGoogleCalendarSync(ctx, app, cfg)
+}
+
+// googleTokenSource は cfg.Google のクライアント ID・シークレット・リフレッシュトークンから
+// Google API 用の TokenSource を作成します（未設定なら nil）
+func googleTokenSource(cfg config.Config) *google.TokenSource {
+	if cfg.Google.RefreshToken == "" {
+		return nil
+	}
+	return google.NewTokenSource(google.Config{
+		ClientID:     cfg.Google.ClientID,
+		ClientSecret: cfg.Google.ClientSecret,
+		TokenURL:     cfg.Google.TokenURL,
+	}, nil, cfg.Google.RefreshToken)
+}
+
+// jiraDefaultUser は全体のタスク（ユーザーアカウントを使わないときのタスク）と同期する Jira の認証情報のユーザーです
+const jiraDefaultUser = "default"
+
+// jiraCredentialStore は cfg.Jira の credentials_file の認証情報を読み込みます（未設定なら空です）
+func jiraCredentialStore(cfg config.Config) (*jira.CredentialStore, error) {
+	if path := cfg.Jira.CredentialsFile; path != "" {
+		return jira.LoadCredentialStore(path)
+	}
+	return jira.NewCredentialStore(), nil
+}
+
+// jiraCredentials は userID の認証情報を返します
+// "default" ユーザーは base_url などの設定を credentials_file より優先します（設定の値はファイルに書き込みません）
+func jiraCredentials(cfg config.Config, userID string) (jira.Credentials, bool, error) {
+	if userID == jiraDefaultUser && cfg.Jira.BaseURL != "" {
+		return jira.Credentials{
+			BaseURL:  cfg.Jira.BaseURL,
+			Email:    cfg.Jira.Email,
+			APIToken: cfg.Jira.APIToken,
+		}, true, nil
+	}
+	store, err := jiraCredentialStore(cfg)
+	if err != nil {
+		return jira.Credentials{}, false, err
+	}
+	creds, ok := store.Get(userID)
+	return creds, ok, nil
+}
+
+// startJiraSync は cfg.Jira の "default" ユーザーの認証情報で、全体のタスク app と Jira の課題を同期します（JQL が未設定なら連携しない）
+// ほかのユーザーの認証情報は、そのユーザーのタスクと同期します（newScopedServer と startUserJiraSyncs）
+func startJiraSync(ctx context.Context, app models.TaskStore, cfg config.Config) {
+	startJiraSyncFor(ctx, app, cfg, jiraDefaultUser, integrationStatePath(cfg.Store, "jira"))
+}
+
+// startJiraSyncFor は userID の認証情報があれば、その認証情報で app と Jira の課題を同期します
+// 課題とタスクの対応は statePath（空ならメモリ上だけ）に保存します
+func startJiraSyncFor(ctx context.Context, app models.TaskStore, cfg config.Config, userID, statePath string) {
+	if cfg.Jira.JQL == "" {
+		return
+	}
+	creds, ok, err := jiraCredentials(cfg, userID)
+	if err != nil {
+		slog.Error("jira: failed to load credentials", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	config := jira.Config{JQL: cfg.Jira.JQL, DoneTransition: cfg.Jira.DoneTransition, StateFile: statePath}
+	go jira.NewSyncer(jira.NewClient(creds, nil), app, config).Run(ctx, cfg.IntegrationInterval)
+}
+
+// startUserJiraSyncs は Jira の認証情報があるユーザーのサーバを起動時に作り、ユーザーのタスクとの同期を始めます
+// 認証情報のユーザーはアカウントの ID（"3" など）で、アカウントがないか無効なユーザーや、ユーザーアカウントを使わないとき（users が nil）の認証情報は使いません
+func startUserJiraSyncs(cfg config.Config, users *accounts.Store, userHandlers *accounts.Handlers) {
+	if cfg.Jira.JQL == "" {
+		return
+	}
+	store, err := jiraCredentialStore(cfg)
+	if err != nil {
+		slog.Error("jira: failed to load credentials", "err", err)
+		return
+	}
+	for _, userID := range store.Users() {
+		if userID == jiraDefaultUser {
+			continue
+		}
+		var user accounts.User
+		ok := false
+		if id, err := strconv.Atoi(userID); err == nil && users != nil {
+			user, ok = users.Lookup(id)
+		}
+		if !ok || user.Disabled {
+			slog.Warn("jira: credentials for an unknown or disabled user are ignored", "user", userID)
+			continue
+		}
+		if _, err := userHandlers.Get(user); err != nil {
+			slog.Error("jira: failed to start the user's sync", "user", userID, "err", err)
+		}
+	}
+}
+
+// integrationStatePath は外部サービスのタスクとアプリのタスクの対応を保存するファイルを、保存先の隣の {name}.json に決めます
+// 保存先のドライバが file か git でなければ空（対応はメモリ上だけ）を返します
+func integrationStatePath(settings config.Store, name string) string {
+	if settings.DSN == "" || (settings.Driver != filestore.DriverName && settings.Driver != gitDriverName) {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(settings.DSN), name+".json")
+}
+
+// startGoogleTasksSync は Google Tasks との双方向同期を起動します（cfg.Google.TasksList が未設定なら連携しない）
+func startGoogleTasksSync(ctx context.Context, app models.TaskStore, cfg config.Config) {
+	list := cfg.Google.TasksList
+	source := googleTokenSource(cfg)
+	if list == "" || source == nil {
+		return
+	}
+
+	config := googletasks.Config{
+		ListTitle: list,
+		Conflict:  googletasks.ConflictRule(cfg.Google.TasksConflict),
+		StateFile: integrationStatePath(cfg.Store, "googletasks"),
+	}
+	if list == "@default" {
+		config = googletasks.Config{ListID: list, Conflict: config.Conflict, StateFile: config.StateFile}
+	}
+
+	client := googletasks.NewClient(cfg.Google.TasksURL, source.Client())
+	go googletasks.NewSyncer(client, app, config).Run(ctx, cfg.IntegrationInterval)
+}
+
+// startGoogleCalendarSync は期限付きタスクを Google カレンダーへ反映する処理を起動します
+// cfg.Google.CalendarName のカレンダーに予定を作成します（なければ作成、未設定なら連携しない）
+func startGoogleCalendarSync(ctx context.Context, app models.TaskStore, cfg config.Config) {
+	name := cfg.Google.CalendarName
+	source := googleTokenSource(cfg)
+	if name == "" || source == nil {
+		return
+	}
+
+	client := gcal.NewClient(cfg.Google.CalendarURL, source.Client())
+	go func() {
+		calendarID, err := client.EnsureCalendar(ctx, name)
+		if err != nil {
+			slog.Error("google calendar: failed to prepare calendar", "calendar", name, "err", err)
+			return
+		}
+
+		syncer := gcal.NewSyncer(client, calendarID)
+		app.Subscribe(syncer.HandleEvent)
+		if err := syncer.SyncAll(ctx, app.GetTasks(ctx)); err != nil {
+			slog.Error("google calendar: initial sync failed", "err", err)
+		}
+		syncer.Run(ctx)
+	}()
+}
+
+// notionExporter は cfg.Notion から Notion へのエクスポートを作成します（未設定なら nil）
+func notionExporter(cfg config.Config) *notion.Exporter {
+	if cfg.Notion.Token == "" || cfg.Notion.DatabaseID == "" {
+		return nil
+	}
+
+	config := notion.Config{
+		Token:      cfg.Notion.Token,
+		DatabaseID: cfg.Notion.DatabaseID,
+		ListName:   cfg.Notion.ListName,
+		BaseURL:    cfg.Notion.URL,
+	}
+	if props := cfg.Notion.Properties; props != "" {
+		if err := json.Unmarshal([]byte(props), &config.Properties); err != nil {
+			slog.Warn("notion: invalid notion.properties, using defaults", "err", err)
+			config.Properties = notion.PropertyMap{}
+		}
+	}
+	return notion.NewExporter(config, nil)
+}
+
+// newBackupManager は cfg.Backup から定期バックアップの Manager を作成します（未設定なら nil）
+// scope が空でなければ（ワークスペースやユーザーのタスク）、同じ保存先に "{scope}." で始まる名前で分けて保存します
+func newBackupManager(cfg config.Config, app models.TaskStore, scope string) *backup.Manager {
+	if cfg.Backup.Destination == "" {
+		return nil
+	}
+	key, err := backup.ParseKey(cfg.Backup.Key)
+	if err != nil {
+		slog.Error("backup", "err", err)
+		return nil
+	}
+
+	destination, err := backup.NewDestination(cfg.Backup.Destination, backup.Credentials{
+		AccessKeyID:     cfg.Backup.AWSAccessKeyID,
+		SecretAccessKey: cfg.Backup.AWSSecretAccessKey,
+		Region:          cfg.Backup.AWSRegion,
+		Endpoint:        cfg.Backup.S3Endpoint,
+		Username:        cfg.Backup.WebDAVUsername,
+		Password:        cfg.Backup.WebDAVPassword,
+		Token:           cfg.Backup.DropboxToken,
+	}, nil)
+	if err != nil {
+		slog.Error("backup", "err", err)
+		return nil
+	}
+
+	if scope != "" {
+		destination = &backup.Prefixed{Destination: destination, Prefix: scope + "."}
+	}
+	return backup.NewManager(app, destination, key, cfg.Backup.Retention)
+}
+
+// newStaleDigest は放置されているタスクを cfg.StaleDigest.URL へ定期的に送る StaleDigest を作成します（未設定なら nil）
+func newStaleDigest(cfg config.Config) *analytics.StaleDigest {
+	if cfg.StaleDigest.URL == "" {
+		return nil
+	}
+	olderThan, err := analytics.ParseAge(cfg.StaleDigest.OlderThan)
+	if err != nil {
+		slog.Error("stale digest", "err", err)
+		return nil
+	}
+	return analytics.NewStaleDigest(cfg.StaleDigest.URL, olderThan, nil)
+}
+
+// newSuggester はタスクを小さな作業に分ける案を作る Suggester を作成します（cfg.LLM の URL も API キーも未設定なら nil）
+// URL を省略すると OpenAI を、モデルを省略すると gpt-4o-mini を使います
+func newSuggester(cfg config.Config) *llm.Suggester {
+	if cfg.LLM.URL == "" && cfg.LLM.APIKey == "" {
+		return nil
+	}
+	return llm.NewSuggester(llm.Config{BaseURL: cfg.LLM.URL, APIKey: cfg.LLM.APIKey, Model: cfg.LLM.Model}, nil)
+}
+
+// newCaptcha はログインで CAPTCHA の応答を確かめる Verifier を作成します（cfg.Captcha.VerifyURL が未設定なら nil）
+func newCaptcha(cfg config.Config) handlers.CaptchaVerifier {
+	if cfg.Captcha.VerifyURL == "" {
+		return nil
+	}
+	return captcha.NewVerifier(cfg.Captcha.VerifyURL, cfg.Captcha.Secret, nil)
+}
+
+// newBreachChecker は漏洩したパスワードを range API で確かめる Checker を作成します（cfg.Password.BreachCheck が無効なら nil）
+func newBreachChecker(cfg config.Config) accounts.BreachChecker {
+	if !cfg.Password.BreachCheck {
+		return nil
+	}
+	return pwned.NewChecker(cfg.Password.BreachURL, nil)
+}
+
+// newMailer は cfg.SMTP の SMTP サーバからメールを送る Mailer を作成します
+func newMailer(cfg config.Config) *reports.Mailer {
+	return reports.NewMailer(cfg.SMTP.Addr, cfg.SMTP.From, cfg.SMTP.Username, cfg.SMTP.Password)
+}
+
+// newVerificationMailer はユーザー登録の確認メールを送る Mailer を返します（cfg.VerifyEmail が無効なら nil）
+func newVerificationMailer(cfg config.Config) handlers.Mailer {
+	if !cfg.VerifyEmail {
+		return nil
+	}
+	return newMailer(cfg)
+}
+
+// newReportScheduler は定期的なレポートのスケジュールを保持する Scheduler を作成します
+// cfg.SMTP.Addr が未設定ならメールでは届けません
+func newReportScheduler(cfg config.Config) *reports.Scheduler {
+	var mailer *reports.Mailer
+	if cfg.SMTP.Addr != "" {
+		mailer = newMailer(cfg)
+	}
+	return reports.NewScheduler(mailer, nil)
+}
+
+// newExecHooks は cfg.ExecHooksFile（フックの設定の JSON ファイル）からコマンドフックを準備します（未設定なら nil）
+func newExecHooks(cfg config.Config) *exechooks.Runner {
+	path := cfg.ExecHooksFile
+	if path == "" {
+		return nil
+	}
+	hooks, err := exechooks.Load(path)
+	if err != nil {
+		slog.Error("exec hooks", "err", err)
+		return nil
+	}
+	runner, err := exechooks.NewRunner(hooks, nil)
+	if err != nil {
+		slog.Error("exec hooks", "err", err)
+		return nil
+	}
+	return runner
+}
+
+// newElector は複数のインスタンスから定期的な処理を動かす leader を選ぶ FileElector を cfg.Leader から作成します（未設定なら nil）
+// リースの有効期間は、leader が止まってからほかのインスタンスが引き継ぐまでの時間です
+// scope が空でなければ（ワークスペースやユーザーのタスク）、"{リースのファイル}.{scope}" の別のリースで選びます
+func newElector(cfg config.Config, scope string) *leader.FileElector {
+	path := cfg.Leader.LockFile
+	if path == "" {
+		return nil
+	}
+	if scope != "" {
+		path += "." + scope
+	}
+	id := cfg.Leader.ID
+	if id == "" {
+		hostname, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return leader.NewFileElector(path, id, cfg.Leader.TTL)
+}
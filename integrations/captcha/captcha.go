@@ -0,0 +1,68 @@
+// Package captcha は CAPTCHA の応答を reCAPTCHA・hCaptcha・Cloudflare Turnstile の siteverify API で確かめます
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrRejected は応答がない、またはサービスが応答を正しくないと判断したことを表します
+var ErrRejected = errors.New("captcha: response rejected")
+
+// Verifier は siteverify API に応答を送って確かめます
+// 3つのサービスは同じ形式（secret・response・remoteip のフォームと {"success": ...} の応答）なので、URL を変えるだけで使えます
+type Verifier struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewVerifier は verifyURL に secret を使って問い合わせる Verifier を作成します
+func NewVerifier(verifyURL, secret string, httpClient *http.Client) *Verifier {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Verifier{url: verifyURL, secret: secret, httpClient: httpClient}
+}
+
+// Verify は response（利用者が解いた CAPTCHA の応答）が正しいかを確かめます。remoteIP は空でも構いません
+func (v *Verifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" {
+		return ErrRejected
+	}
+	form := url.Values{"secret": {v.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha: %s", resp.Status)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrRejected, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = map[string]string{"secret": r.PostForm.Get("secret"), "response": r.PostForm.Get("response"), "remoteip": r.PostForm.Get("remoteip")}
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+	v := NewVerifier(server.URL, "s3cret", nil)
+
+	if err := v.Verify(context.Background(), "good", "192.0.2.1"); err != nil {
+		t.Fatalf("Expected the response to be accepted, got %v", err)
+	}
+	if form["secret"] != "s3cret" || form["response"] != "good" || form["remoteip"] != "192.0.2.1" {
+		t.Errorf("Unexpected form %v", form)
+	}
+	if err := v.Verify(context.Background(), "bad", ""); !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected ErrRejected, got %v", err)
+	}
+
+	form = nil
+	if err := v.Verify(context.Background(), "", ""); !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected an empty response to be rejected, got %v", err)
+	}
+	if form != nil {
+		t.Error("Expected an empty response not to be sent")
+	}
+}
+
+func TestVerifyServiceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewVerifier(server.URL, "s3cret", nil).Verify(context.Background(), "good", "")
+	if err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Expected a service error, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package gcal は期限付きのタスクを Google カレンダーの予定として反映します
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL は Google Calendar API のエンドポイントです
+const DefaultBaseURL = "https://www.googleapis.com/calendar/v3"
+
+// ErrNotFound は予定やカレンダーが存在しない（削除済みを含む）ことを表します
+var ErrNotFound = errors.New("gcal: not found")
+
+// EventDate は終日予定の日付です（YYYY-MM-DD）
+type EventDate struct {
+	Date string `json:"date"`
+}
+
+// CalendarEvent は Google カレンダーの予定です
+type CalendarEvent struct {
+	ID          string    `json:"id,omitempty"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description,omitempty"`
+	Start       EventDate `json:"start"`
+	End         EventDate `json:"end"`
+}
+
+// Client は Google Calendar API の薄いクライアントです
+// httpClient には OAuth のアクセストークンを付与するクライアントを渡します
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient は Client を作成します。baseURL が空なら DefaultBaseURL を使います
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// EnsureCalendar は指定した名前のカレンダーを探し、なければ作成してIDを返します
+func (c *Client) EnsureCalendar(ctx context.Context, name string) (string, error) {
+	var list struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Summary string `json:"summary"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/users/me/calendarList", nil, &list); err != nil {
+		return "", err
+	}
+	for _, item := range list.Items {
+		if item.Summary == name {
+			return item.ID, nil
+		}
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/calendars", map[string]string{"summary": name}, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// InsertEvent は予定を作成します
+func (c *Client) InsertEvent(ctx context.Context, calendarID string, event CalendarEvent) (CalendarEvent, error) {
+	var created CalendarEvent
+	err := c.do(ctx, http.MethodPost, "/calendars/"+url.PathEscape(calendarID)+"/events", event, &created)
+	return created, err
+}
+
+// UpdateEvent は予定を置き換えます
+func (c *Client) UpdateEvent(ctx context.Context, calendarID string, event CalendarEvent) (CalendarEvent, error) {
+	var updated CalendarEvent
+	err := c.do(ctx, http.MethodPut, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(event.ID), event, &updated)
+	return updated, err
+}
+
+// DeleteEvent は予定を削除します
+func (c *Client) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	return c.do(ctx, http.MethodDelete, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), nil, nil)
+}
+
+// do はリクエストを送り、JSON レスポンスを out にデコードします
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gcal: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,123 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"todo-app/models"
+)
+
+// queueSize はイベントバスから受け取った変更を溜めておけるキューの長さです
+const queueSize = 256
+
+// Syncer は期限付きのタスクを専用カレンダーの終日予定として作成・更新・削除します
+// eventIDs: タスクIDから作成した予定IDへの対応
+type Syncer struct {
+	client     *Client
+	calendarID string
+	queue      chan models.Event
+
+	mutex    sync.Mutex
+	eventIDs map[int]string
+}
+
+// NewSyncer は Syncer を作成します
+func NewSyncer(client *Client, calendarID string) *Syncer {
+	return &Syncer{
+		client:     client,
+		calendarID: calendarID,
+		queue:      make(chan models.Event, queueSize),
+		eventIDs:   make(map[int]string),
+	}
+}
+
+// HandleEvent はイベントバスの購読者として変更をキューに積みます
+// API 呼び出しは Run のゴルーチンで行うため、タスクの操作を待たせません
+func (s *Syncer) HandleEvent(event models.Event) {
+	select {
+	case s.queue <- event:
+	default:
+		slog.Warn("google calendar sync: queue is full, dropped event", "event_id", event.ID, "task_id", event.Task.ID)
+	}
+}
+
+// Run は ctx がキャンセルされるまでキューのイベントをカレンダーに反映します
+func (s *Syncer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.queue:
+			if err := s.Apply(ctx, event.Task, event.Type == models.EventTaskDeleted); err != nil {
+				slog.Error("google calendar sync failed", "task_id", event.Task.ID, "err", err)
+			}
+		}
+	}
+}
+
+// SyncAll は既存のタスクをまとめてカレンダーに反映します（起動時の初回同期用）
+func (s *Syncer) SyncAll(ctx context.Context, tasks []models.Task) error {
+	for _, task := range tasks {
+		if err := s.Apply(ctx, task, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply は1件のタスクの状態をカレンダーに反映します
+// 期限のあるタスクは予定を作成・更新し、削除されたタスクや期限を外したタスクは予定を削除します
+func (s *Syncer) Apply(ctx context.Context, task models.Task, deleted bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	eventID, exists := s.eventIDs[task.ID]
+
+	if deleted || task.DueDate == nil {
+		if !exists {
+			return nil
+		}
+		if err := s.client.DeleteEvent(ctx, s.calendarID, eventID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		delete(s.eventIDs, task.ID)
+		return nil
+	}
+
+	event := taskEvent(task)
+	if exists {
+		event.ID = eventID
+		_, err := s.client.UpdateEvent(ctx, s.calendarID, event)
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		// カレンダー側で予定が消されていた場合は作り直します
+		event.ID = ""
+	}
+
+	created, err := s.client.InsertEvent(ctx, s.calendarID, event)
+	if err != nil {
+		return err
+	}
+	s.eventIDs[task.ID] = created.ID
+	return nil
+}
+
+// taskEvent はタスクから期限日の終日予定を組み立てます
+// 完了済みのタスクは予定を残したまま、件名に完了マークを付けます
+func taskEvent(task models.Task) CalendarEvent {
+	summary := task.Title
+	if task.Completed {
+		summary = "✅ " + summary
+	}
+	due := *task.DueDate
+	return CalendarEvent{
+		Summary:     summary,
+		Description: fmt.Sprintf("ToDo task #%d", task.ID),
+		Start:       EventDate{Date: due.Format("2006-01-02")},
+		End:         EventDate{Date: due.AddDate(0, 0, 1).Format("2006-01-02")},
+	}
+}
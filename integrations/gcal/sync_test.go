@@ -0,0 +1,229 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+type fakeCalendar struct {
+	mutex     sync.Mutex
+	calendars map[string]string
+	events    map[string]CalendarEvent
+	nextID    int
+}
+
+func newFakeCalendar() *fakeCalendar {
+	return &fakeCalendar{
+		calendars: map[string]string{"primary": "me@example.com"},
+		events:    map[string]CalendarEvent{},
+	}
+}
+
+func (f *fakeCalendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/users/me/calendarList":
+		items := []map[string]string{}
+		for id, summary := range f.calendars {
+			items = append(items, map[string]string{"id": id, "summary": summary})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case r.URL.Path == "/calendars" && r.Method == http.MethodPost:
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		f.nextID++
+		id := fmt.Sprintf("cal%d", f.nextID)
+		f.calendars[id] = body["summary"]
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		var event CalendarEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		f.nextID++
+		event.ID = fmt.Sprintf("ev%d", f.nextID)
+		f.events[event.ID] = event
+		json.NewEncoder(w).Encode(event)
+	case len(parts) == 4 && r.Method == http.MethodPut:
+		if _, ok := f.events[parts[3]]; !ok {
+			http.Error(w, "gone", http.StatusGone)
+			return
+		}
+		var event CalendarEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		f.events[parts[3]] = event
+		json.NewEncoder(w).Encode(event)
+	case len(parts) == 4 && r.Method == http.MethodDelete:
+		if _, ok := f.events[parts[3]]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.events, parts[3])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeCalendar) snapshot() []CalendarEvent {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	events := make([]CalendarEvent, 0, len(f.events))
+	for _, event := range f.events {
+		events = append(events, event)
+	}
+	return events
+}
+
+func newTestClient(t *testing.T, fake *fakeCalendar) *Client {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, server.Client())
+}
+
+func TestEnsureCalendar(t *testing.T) {
+	fake := newFakeCalendar()
+	client := newTestClient(t, fake)
+
+	id, err := client.EnsureCalendar(context.Background(), "ToDo")
+	if err != nil {
+		t.Fatalf("EnsureCalendar failed: %v", err)
+	}
+	if fake.calendars[id] != "ToDo" {
+		t.Errorf("Expected calendar to be created, got %v", fake.calendars)
+	}
+
+	again, err := client.EnsureCalendar(context.Background(), "ToDo")
+	if err != nil || again != id {
+		t.Errorf("Expected existing calendar %s to be reused, got %s (%v)", id, again, err)
+	}
+}
+
+func TestApplyLifecycle(t *testing.T) {
+	fake := newFakeCalendar()
+	syncer := NewSyncer(newTestClient(t, fake), "cal")
+	ctx := context.Background()
+
+	due := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	task := models.Task{ID: 1, Title: "File taxes", DueDate: &due}
+
+	if err := syncer.Apply(ctx, task, false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	events := fake.snapshot()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].Summary != "File taxes" || events[0].Start.Date != "2025-03-31" || events[0].End.Date != "2025-04-01" {
+		t.Errorf("Unexpected event: %+v", events[0])
+	}
+
+	task.Completed = true
+	syncer.Apply(ctx, task, false)
+	events = fake.snapshot()
+	if len(events) != 1 || events[0].Summary != "✅ File taxes" {
+		t.Errorf("Expected event to be updated in place, got %+v", events)
+	}
+
+	task.DueDate = nil
+	syncer.Apply(ctx, task, false)
+	if len(fake.snapshot()) != 0 {
+		t.Error("Expected event to be deleted when the due date is cleared")
+	}
+
+	task.DueDate = &due
+	syncer.Apply(ctx, task, false)
+	syncer.Apply(ctx, task, true)
+	if len(fake.snapshot()) != 0 {
+		t.Error("Expected event to be deleted with the task")
+	}
+}
+
+func TestApplyRecreatesRemovedEvent(t *testing.T) {
+	fake := newFakeCalendar()
+	syncer := NewSyncer(newTestClient(t, fake), "cal")
+	ctx := context.Background()
+
+	due := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	task := models.Task{ID: 1, Title: "Renew lease", DueDate: &due}
+	syncer.Apply(ctx, task, false)
+
+	fake.mutex.Lock()
+	fake.events = map[string]CalendarEvent{}
+	fake.mutex.Unlock()
+
+	if err := syncer.Apply(ctx, task, false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(fake.snapshot()) != 1 {
+		t.Error("Expected removed event to be re-created")
+	}
+
+	fake.mutex.Lock()
+	fake.events = map[string]CalendarEvent{}
+	fake.mutex.Unlock()
+	if err := syncer.Apply(ctx, task, true); err != nil {
+		t.Errorf("Expected deleting a missing event to succeed, got %v", err)
+	}
+}
+
+func TestEventBusIntegration(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCalendar()
+	syncer := NewSyncer(newTestClient(t, fake), "cal")
+
+	app := models.NewTodoApp()
+	app.AddTask(ctx, "Existing")
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	app.SetDueDate(ctx, 1, &due)
+	if err := syncer.SyncAll(context.Background(), app.GetTasks(ctx)); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	app.Subscribe(syncer.HandleEvent)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go syncer.Run(ctx)
+
+	task, _ := app.AddTask(ctx, "Dentist")
+	app.SetDueDate(ctx, task.ID, &due)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(fake.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(fake.snapshot()) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(fake.snapshot()))
+	}
+
+	app.DeleteTask(ctx, task.ID)
+	deadline = time.Now().Add(2 * time.Second)
+	for len(fake.snapshot()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(fake.snapshot()) != 1 {
+		t.Errorf("Expected deleted task's event to be removed, got %d events", len(fake.snapshot()))
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	if _, err := client.EnsureCalendar(context.Background(), "ToDo"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected 500 error, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package google は Google API 連携で共通に使う OAuth 2.0 の処理を提供します
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 既定のエンドポイントとスコープ
+const (
+	DefaultAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+
+	ScopeTasks    = "https://www.googleapis.com/auth/tasks"
+	ScopeCalendar = "https://www.googleapis.com/auth/calendar"
+)
+
+// Config は OAuth クライアントの設定です
+// AuthURL / TokenURL が空の場合は Google の既定エンドポイントを使います
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+}
+
+// Token はトークンエンドポイントから受け取ったトークンです
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Valid はアクセストークンが期限切れ間近でないかを返します
+func (t Token) Valid() bool {
+	return t.AccessToken != "" && time.Until(t.Expiry) > time.Minute
+}
+
+// AuthCodeURL はユーザーに同意してもらうための認可URLを組み立てます
+// リフレッシュトークンを確実に受け取れるよう access_type=offline を指定します
+func (c Config) AuthCodeURL(state string) string {
+	authURL := c.AuthURL
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+	query := url.Values{}
+	query.Set("client_id", c.ClientID)
+	query.Set("redirect_uri", c.RedirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(c.Scopes, " "))
+	query.Set("state", state)
+	query.Set("access_type", "offline")
+	query.Set("prompt", "consent")
+	return authURL + "?" + query.Encode()
+}
+
+// Exchange は認可コードをトークンに交換します
+func (c Config) Exchange(ctx context.Context, httpClient *http.Client, code string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.RedirectURL)
+	return c.requestToken(ctx, httpClient, form)
+}
+
+// Refresh はリフレッシュトークンから新しいアクセストークンを取得します
+func (c Config) Refresh(ctx context.Context, httpClient *http.Client, refreshToken string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	token, err := c.requestToken(ctx, httpClient, form)
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, err
+}
+
+// requestToken はトークンエンドポイントへフォームを送信します
+func (c Config) requestToken(ctx context.Context, httpClient *http.Client, form url.Values) (Token, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	tokenURL := c.TokenURL
+	if tokenURL == "" {
+		tokenURL = DefaultTokenURL
+	}
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Token{}, fmt.Errorf("google: token request failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Token{}, err
+	}
+	return Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// TokenSource はリフレッシュトークンを使ってアクセストークンを自動更新します
+type TokenSource struct {
+	config     Config
+	httpClient *http.Client
+
+	mutex sync.Mutex
+	token Token
+}
+
+// NewTokenSource はリフレッシュトークンから TokenSource を作成します
+func NewTokenSource(config Config, httpClient *http.Client, refreshToken string) *TokenSource {
+	return &TokenSource{
+		config:     config,
+		httpClient: httpClient,
+		token:      Token{RefreshToken: refreshToken},
+	}
+}
+
+// Token は有効なアクセストークンを返します（必要なら更新します）
+func (s *TokenSource) Token(ctx context.Context) (Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	token, err := s.config.Refresh(ctx, s.httpClient, s.token.RefreshToken)
+	if err != nil {
+		return Token{}, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// Client は Authorization ヘッダを自動で付与する http.Client を返します
+func (s *TokenSource) Client() *http.Client {
+	base := http.DefaultTransport
+	if s.httpClient != nil && s.httpClient.Transport != nil {
+		base = s.httpClient.Transport
+	}
+	return &http.Client{Transport: &transport{source: s, base: base}}
+}
+
+// transport はリクエストごとにアクセストークンを付与する RoundTripper です
+type transport struct {
+	source *TokenSource
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return t.base.RoundTrip(clone)
+}
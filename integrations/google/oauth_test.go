@@ -0,0 +1,123 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTokenServer(t *testing.T, refreshes *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  "access-" + r.Form.Get("code"),
+				"refresh_token": "refresh-1",
+				"expires_in":    3600,
+			})
+		case "refresh_token":
+			*refreshes++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "fresh",
+				"expires_in":   3600,
+			})
+		default:
+			http.Error(w, "unsupported_grant_type", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	config := Config{ClientID: "id", RedirectURL: "http://localhost/callback", Scopes: []string{ScopeTasks}}
+
+	parsed, err := url.Parse(config.AuthCodeURL("xyz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(parsed.String(), DefaultAuthURL) {
+		t.Errorf("Expected default auth URL, got %s", parsed)
+	}
+	query := parsed.Query()
+	if query.Get("state") != "xyz" || query.Get("scope") != ScopeTasks || query.Get("access_type") != "offline" {
+		t.Errorf("Unexpected query: %v", query)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	refreshes := 0
+	server := newTokenServer(t, &refreshes)
+	config := Config{ClientID: "id", ClientSecret: "secret", TokenURL: server.URL}
+
+	token, err := config.Exchange(context.Background(), server.Client(), "abc")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if token.AccessToken != "access-abc" || token.RefreshToken != "refresh-1" || !token.Valid() {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+}
+
+func TestTokenSourceRefreshesOnce(t *testing.T) {
+	refreshes := 0
+	server := newTokenServer(t, &refreshes)
+	config := Config{ClientID: "id", ClientSecret: "secret", TokenURL: server.URL}
+	source := NewTokenSource(config, server.Client(), "refresh-1")
+
+	for i := 0; i < 3; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token failed: %v", err)
+		}
+		if token.AccessToken != "fresh" || token.RefreshToken != "refresh-1" {
+			t.Errorf("Unexpected token: %+v", token)
+		}
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", refreshes)
+	}
+}
+
+func TestTokenSourceClientSetsAuthorization(t *testing.T) {
+	refreshes := 0
+	tokenServer := newTokenServer(t, &refreshes)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer api.Close()
+
+	source := NewTokenSource(Config{ClientID: "id", ClientSecret: "secret", TokenURL: tokenServer.URL}, nil, "refresh-1")
+	resp, err := source.Client().Get(api.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body strings.Builder
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	body.Write(buf[:n])
+	if body.String() != "Bearer fresh" {
+		t.Errorf("Expected bearer token header, got %q", body.String())
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	refreshes := 0
+	server := newTokenServer(t, &refreshes)
+	config := Config{ClientID: "id", ClientSecret: "wrong", TokenURL: server.URL}
+
+	if _, err := NewTokenSource(config, nil, "refresh-1").Token(context.Background()); err == nil {
+		t.Error("Expected error for invalid client")
+	}
+}
@@ -0,0 +1,177 @@
+// Package googletasks は Google Tasks API とタスク一覧を双方向に同期します
+package googletasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL は Google Tasks API のエンドポイントです
+const DefaultBaseURL = "https://tasks.googleapis.com/tasks/v1"
+
+// Google Tasks のタスク状態
+const (
+	StatusNeedsAction = "needsAction"
+	StatusCompleted   = "completed"
+)
+
+// TaskList は Google Tasks のタスクリストです
+type TaskList struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Task は Google Tasks のタスクです
+type Task struct {
+	ID      string `json:"id,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Updated string `json:"updated,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Completed はタスクが完了状態かどうかを返します
+func (t Task) Completed() bool {
+	return t.Status == StatusCompleted
+}
+
+// Client は Google Tasks API の薄いクライアントです
+// httpClient には OAuth のアクセストークンを付与するクライアントを渡します
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient は Client を作成します。baseURL が空なら DefaultBaseURL を使います
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// TaskLists はユーザーのタスクリストをすべて返します
+func (c *Client) TaskLists(ctx context.Context) ([]TaskList, error) {
+	var lists []TaskList
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("maxResults", "100")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items         []TaskList `json:"items"`
+			NextPageToken string     `json:"nextPageToken"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/users/@me/lists?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		lists = append(lists, page.Items...)
+		if page.NextPageToken == "" {
+			return lists, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// Tasks はタスクリスト内のタスクを完了済み・削除済みも含めてすべて返します
+func (c *Client) Tasks(ctx context.Context, listID string) ([]Task, error) {
+	var tasks []Task
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("maxResults", "100")
+		query.Set("showCompleted", "true")
+		query.Set("showHidden", "true")
+		query.Set("showDeleted", "true")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items         []Task `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/lists/"+url.PathEscape(listID)+"/tasks?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Items...)
+		if page.NextPageToken == "" {
+			return tasks, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// InsertTask はタスクリストにタスクを作成します
+func (c *Client) InsertTask(ctx context.Context, listID string, task Task) (Task, error) {
+	var created Task
+	err := c.do(ctx, http.MethodPost, "/lists/"+url.PathEscape(listID)+"/tasks", task, &created)
+	return created, err
+}
+
+// SetCompleted はタスクの完了状態を更新します
+func (c *Client) SetCompleted(ctx context.Context, listID, taskID string, completed bool) (Task, error) {
+	status := StatusNeedsAction
+	if completed {
+		status = StatusCompleted
+	}
+	body := map[string]interface{}{"status": status}
+	if !completed {
+		// 未完了に戻すときは完了日時も消す必要があります
+		body["completed"] = nil
+	}
+	var updated Task
+	err := c.do(ctx, http.MethodPatch, "/lists/"+url.PathEscape(listID)+"/tasks/"+url.PathEscape(taskID), body, &updated)
+	return updated, err
+}
+
+// DeleteTask はタスクを削除します
+func (c *Client) DeleteTask(ctx context.Context, listID, taskID string) error {
+	return c.do(ctx, http.MethodDelete, "/lists/"+url.PathEscape(listID)+"/tasks/"+url.PathEscape(taskID), nil, nil)
+}
+
+// do はリクエストを送り、JSON レスポンスを out にデコードします
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("googletasks: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,74 @@
+package googletasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// state は Config.StateFile に保存する、タスクリストと対応の一覧です
+type state struct {
+	ListID string      `json:"list_id"`
+	Links  []stateLink `json:"links"`
+}
+
+// stateLink は Google のタスク（RemoteID）とアプリのタスク（TaskID）の対応と、前回同期時の完了状態です
+type stateLink struct {
+	RemoteID  string `json:"remote_id"`
+	TaskID    int    `json:"task_id"`
+	Completed bool   `json:"completed"`
+}
+
+// loadState は StateFile から対応を読み込みます。ファイルがないか、別のタスクリストの対応なら何も読み込みません
+// 呼び出し元がロックを取ります
+func (s *Syncer) loadState(listID string) error {
+	if s.config.StateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.config.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("googletasks: %s: %w", s.config.StateFile, err)
+	}
+	if saved.ListID != listID {
+		return nil
+	}
+	for _, l := range saved.Links {
+		s.links[l.RemoteID] = &link{taskID: l.TaskID, completed: l.Completed}
+	}
+	return nil
+}
+
+// saveState は対応を一時ファイルに書き出してから StateFile を置き換えます。呼び出し元がロックを取ります
+func (s *Syncer) saveState(listID string) error {
+	if s.config.StateFile == "" {
+		return nil
+	}
+	saved := state{ListID: listID, Links: make([]stateLink, 0, len(s.links))}
+	for remoteID, l := range s.links {
+		saved.Links = append(saved.Links, stateLink{RemoteID: remoteID, TaskID: l.taskID, Completed: l.completed})
+	}
+	sort.Slice(saved.Links, func(i, j int) bool { return saved.Links[i].RemoteID < saved.Links[j].RemoteID })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.config.StateFile), 0755); err != nil {
+		return err
+	}
+	tmp := s.config.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.config.StateFile)
+}
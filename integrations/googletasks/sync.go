@@ -0,0 +1,304 @@
+package googletasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// TaskManager は同期に必要な TodoApp の操作だけを切り出したインターフェースです
+type TaskManager interface {
+	AddTask(ctx context.Context, title string) (models.Task, error)
+	GetTasks(ctx context.Context) []models.Task
+	ToggleTask(ctx context.Context, id int) error
+	DeleteTask(ctx context.Context, id int) error
+}
+
+// ConflictRule は前回の同期以降に片側でタスクが削除され、もう片側で完了状態が
+// 変わっていた場合の解決方法です
+type ConflictRule string
+
+const (
+	// PreferLocal はアプリ側の操作を優先します
+	PreferLocal ConflictRule = "local"
+	// PreferRemote は Google Tasks 側の操作を優先します
+	PreferRemote ConflictRule = "remote"
+	// PreferCompleted は完了になった側の変更を残し、それ以外は削除を優先します
+	PreferCompleted ConflictRule = "completed"
+)
+
+// Config は Syncer の動作設定です
+// ListID: 同期する Google のタスクリストID（空なら ListTitle で探します）
+// ListTitle: 同期するタスクリスト名（どちらも空ならユーザーの既定リスト）
+// Conflict: 競合時の解決方法（既定は PreferLocal）
+// StateFile: Google のタスクとアプリのタスクの対応を保存する JSON ファイル（空ならメモリ上だけで、再起動すると対応を忘れます）
+type Config struct {
+	ListID    string
+	ListTitle string
+	Conflict  ConflictRule
+	StateFile string
+}
+
+// Result は1回の同期で行った変更の件数です
+type Result struct {
+	Pulled    int
+	Pushed    int
+	Deleted   int
+	Conflicts int
+}
+
+// link は Google のタスクとアプリのタスクの対応と、前回同期時の完了状態です
+type link struct {
+	taskID    int
+	completed bool
+}
+
+// Syncer は Google Tasks のタスクリストとアプリのタスク一覧を双方向に同期します
+type Syncer struct {
+	client *Client
+	tasks  TaskManager
+	config Config
+
+	mutex  sync.Mutex
+	listID string
+	links  map[string]*link
+	// loaded: StateFile から対応を読み込んだか（最初の Sync で読み込みます）
+	loaded bool
+}
+
+// NewSyncer は Syncer を作成します
+func NewSyncer(client *Client, tasks TaskManager, config Config) *Syncer {
+	if config.Conflict == "" {
+		config.Conflict = PreferLocal
+	}
+	return &Syncer{
+		client: client,
+		tasks:  tasks,
+		config: config,
+		links:  make(map[string]*link),
+	}
+}
+
+// Sync は双方向の同期を1回実行します
+// 片側で変わった完了状態は反対側へ反映し、片側で削除されたタスクは反対側でも削除します
+// 削除と変更が重なった場合は Conflict に従い、未連携のタスクは互いに作成します
+// Config.StateFile があれば、最初の同期で前回までの対応を読み込み、同期のたびに（途中で失敗しても）保存します
+func (s *Syncer) Sync(ctx context.Context) (result Result, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listID, err := s.resolveList(ctx)
+	if err != nil {
+		return result, err
+	}
+	if !s.loaded {
+		if err := s.loadState(listID); err != nil {
+			return result, err
+		}
+		s.loaded = true
+	}
+	defer func() {
+		if saveErr := s.saveState(listID); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}()
+
+	remoteTasks, err := s.client.Tasks(ctx, listID)
+	if err != nil {
+		return result, err
+	}
+
+	localTasks := make(map[int]models.Task)
+	for _, task := range s.tasks.GetTasks(ctx) {
+		localTasks[task.ID] = task
+	}
+	linkedTaskIDs := make(map[int]bool)
+	seenRemote := make(map[string]bool)
+
+	for _, remote := range remoteTasks {
+		if remote.Deleted || remote.Title == "" {
+			continue
+		}
+		seenRemote[remote.ID] = true
+
+		l, linked := s.links[remote.ID]
+		if !linked {
+			task, err := s.pull(ctx, remote)
+			if err != nil {
+				return result, err
+			}
+			s.links[remote.ID] = &link{taskID: task.ID, completed: remote.Completed()}
+			linkedTaskIDs[task.ID] = true
+			result.Pulled++
+			continue
+		}
+		linkedTaskIDs[l.taskID] = true
+
+		local, exists := localTasks[l.taskID]
+		remoteChanged := remote.Completed() != l.completed
+		if !exists {
+			// ローカルで削除済み。リモートでも変更があれば削除と変更の競合です
+			keep := false
+			if remoteChanged {
+				result.Conflicts++
+				keep = s.keepDeleted(remote.Completed(), true)
+			}
+			if keep {
+				task, err := s.pull(ctx, remote)
+				if err != nil {
+					return result, err
+				}
+				*l = link{taskID: task.ID, completed: remote.Completed()}
+				linkedTaskIDs[task.ID] = true
+				result.Pulled++
+				continue
+			}
+			if err := s.client.DeleteTask(ctx, listID, remote.ID); err != nil {
+				return result, err
+			}
+			delete(s.links, remote.ID)
+			result.Deleted++
+			continue
+		}
+
+		// 前回の同期から完了状態は片側でしか変わり得ないため、変わった側に合わせます
+		want := local.Completed
+		if remoteChanged {
+			want = remote.Completed()
+		}
+		if local.Completed != want {
+			if err := s.tasks.ToggleTask(ctx, local.ID); err != nil {
+				return result, err
+			}
+			result.Pulled++
+		}
+		if remote.Completed() != want {
+			if _, err := s.client.SetCompleted(ctx, listID, remote.ID, want); err != nil {
+				return result, err
+			}
+			result.Pushed++
+		}
+		l.completed = want
+	}
+
+	for remoteID, l := range s.links {
+		if seenRemote[remoteID] {
+			continue
+		}
+		delete(s.links, remoteID)
+		local, exists := localTasks[l.taskID]
+		if !exists {
+			continue
+		}
+		// リモートで削除済み。ローカルでも変更があれば削除と変更の競合です
+		if local.Completed != l.completed {
+			result.Conflicts++
+			if s.keepDeleted(local.Completed, false) {
+				// 連携を外したので、後続の処理でリモートに作り直されます
+				continue
+			}
+		}
+		if err := s.tasks.DeleteTask(ctx, l.taskID); err != nil && !errors.Is(err, models.ErrTaskNotFound) {
+			return result, err
+		}
+		linkedTaskIDs[l.taskID] = true
+		result.Deleted++
+	}
+
+	for _, local := range s.tasks.GetTasks(ctx) {
+		if linkedTaskIDs[local.ID] {
+			continue
+		}
+		status := StatusNeedsAction
+		if local.Completed {
+			status = StatusCompleted
+		}
+		created, err := s.client.InsertTask(ctx, listID, Task{Title: local.Title, Status: status})
+		if err != nil {
+			return result, err
+		}
+		s.links[created.ID] = &link{taskID: local.ID, completed: local.Completed}
+		result.Pushed++
+	}
+
+	return result, nil
+}
+
+// pull はリモートのタスクをアプリ側に作成し、完了状態を合わせます
+func (s *Syncer) pull(ctx context.Context, remote Task) (models.Task, error) {
+	task, err := s.tasks.AddTask(ctx, remote.Title)
+	if err != nil {
+		return task, err
+	}
+	if remote.Completed() {
+		if err := s.tasks.ToggleTask(ctx, task.ID); err != nil {
+			return task, err
+		}
+		task.Completed = true
+	}
+	return task, nil
+}
+
+// keepDeleted は「片側で削除、もう片側で変更」という競合で、変更側を残すかどうかを決めます
+// completed は変更された側の完了状態、changedRemote は変更された側がリモートかどうかです
+func (s *Syncer) keepDeleted(completed, changedRemote bool) bool {
+	switch s.config.Conflict {
+	case PreferRemote:
+		return changedRemote
+	case PreferCompleted:
+		return completed
+	default:
+		return !changedRemote
+	}
+}
+
+// resolveList は同期対象のタスクリストIDを決め、以降の同期のために覚えておきます
+func (s *Syncer) resolveList(ctx context.Context) (string, error) {
+	if s.listID != "" {
+		return s.listID, nil
+	}
+	switch {
+	case s.config.ListID != "":
+		s.listID = s.config.ListID
+	case s.config.ListTitle == "":
+		s.listID = "@default"
+	default:
+		lists, err := s.client.TaskLists(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, list := range lists {
+			if list.Title == s.config.ListTitle {
+				s.listID = list.ID
+				return s.listID, nil
+			}
+		}
+		return "", fmt.Errorf("googletasks: task list %q not found", s.config.ListTitle)
+	}
+	return s.listID, nil
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに Sync を実行します
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := s.Sync(ctx); err != nil {
+			slog.Error("google tasks sync failed", "err", err)
+		} else if result != (Result{}) {
+			slog.Info("google tasks sync", "pulled", result.Pulled, "pushed", result.Pushed, "deleted", result.Deleted, "conflicts", result.Conflicts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package googletasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"todo-app/models"
+)
+
+type fakeGoogleTasks struct {
+	mutex  sync.Mutex
+	lists  []TaskList
+	tasks  map[string][]*Task
+	nextID int
+}
+
+func newFakeGoogleTasks() *fakeGoogleTasks {
+	return &fakeGoogleTasks{
+		lists: []TaskList{{ID: "@default", Title: "My Tasks"}, {ID: "groceries", Title: "Groceries"}},
+		tasks: map[string][]*Task{},
+	}
+}
+
+func (f *fakeGoogleTasks) add(listID, title string, completed bool) *Task {
+	f.nextID++
+	task := &Task{ID: fmt.Sprintf("g%d", f.nextID), Title: title, Status: StatusNeedsAction}
+	if completed {
+		task.Status = StatusCompleted
+	}
+	f.tasks[listID] = append(f.tasks[listID], task)
+	return task
+}
+
+func (f *fakeGoogleTasks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/users/@me/lists":
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": f.lists})
+	case len(parts) == 3 && parts[0] == "lists" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": f.tasks[parts[1]]})
+	case len(parts) == 3 && parts[0] == "lists" && r.Method == http.MethodPost:
+		var task Task
+		json.NewDecoder(r.Body).Decode(&task)
+		created := f.add(parts[1], task.Title, task.Completed())
+		json.NewEncoder(w).Encode(created)
+	case len(parts) == 4 && r.Method == http.MethodPatch:
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, task := range f.tasks[parts[1]] {
+			if task.ID == parts[3] {
+				task.Status = body["status"].(string)
+				json.NewEncoder(w).Encode(task)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	case len(parts) == 4 && r.Method == http.MethodDelete:
+		for _, task := range f.tasks[parts[1]] {
+			if task.ID == parts[3] {
+				task.Deleted = true
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestSyncer(t *testing.T, fake *fakeGoogleTasks, config Config) (*Syncer, *models.TodoApp) {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	app := models.NewTodoApp()
+	return NewSyncer(NewClient(server.URL, server.Client()), app, config), app
+}
+
+func mustSync(t *testing.T, syncer *Syncer) Result {
+	t.Helper()
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	return result
+}
+
+func TestSyncPullsAndPushes(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleTasks()
+	fake.add("groceries", "Milk", false)
+	fake.add("groceries", "Eggs", true)
+	syncer, app := newTestSyncer(t, fake, Config{ListTitle: "Groceries"})
+	app.AddTask(ctx, "Bread")
+
+	result := mustSync(t, syncer)
+	if result.Pulled != 2 || result.Pushed != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 3 {
+		t.Fatalf("Expected 3 local tasks, got %d", len(tasks))
+	}
+	if len(fake.tasks["groceries"]) != 3 || fake.tasks["groceries"][2].Title != "Bread" {
+		t.Errorf("Expected local task to be pushed to Google, got %+v", fake.tasks["groceries"])
+	}
+	for _, task := range tasks {
+		if task.Title == "Eggs" && !task.Completed {
+			t.Error("Expected completed remote task to be completed locally")
+		}
+	}
+
+	if result := mustSync(t, syncer); result != (Result{}) {
+		t.Errorf("Expected second sync to be a no-op, got %+v", result)
+	}
+}
+
+func TestSyncCompletionBothWays(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleTasks()
+	remote := fake.add("@default", "Call mom", false)
+	syncer, app := newTestSyncer(t, fake, Config{})
+	mustSync(t, syncer)
+
+	remote.Status = StatusCompleted
+	mustSync(t, syncer)
+	if !app.GetTasks(ctx)[0].Completed {
+		t.Error("Expected remote completion to be pulled")
+	}
+
+	app.ToggleTask(ctx, app.GetTasks(ctx)[0].ID)
+	mustSync(t, syncer)
+	if remote.Completed() {
+		t.Error("Expected local reopen to be pushed")
+	}
+}
+
+func TestSyncConflictLocalDeleteRemoteChange(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		rule     ConflictRule
+		wantKept bool
+	}{
+		{PreferLocal, false},
+		{PreferRemote, true},
+		{PreferCompleted, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.rule), func(t *testing.T) {
+			fake := newFakeGoogleTasks()
+			remote := fake.add("@default", "Pay rent", false)
+			syncer, app := newTestSyncer(t, fake, Config{Conflict: tc.rule})
+			mustSync(t, syncer)
+
+			app.DeleteTask(ctx, app.GetTasks(ctx)[0].ID)
+			remote.Status = StatusCompleted
+
+			result := mustSync(t, syncer)
+			if result.Conflicts != 1 {
+				t.Errorf("Expected 1 conflict, got %+v", result)
+			}
+			kept := len(app.GetTasks(ctx)) == 1
+			if kept != tc.wantKept {
+				t.Errorf("Expected kept=%v, got %v", tc.wantKept, kept)
+			}
+			if kept == remote.Deleted {
+				t.Error("Expected both sides to agree after sync")
+			}
+			if kept && !app.GetTasks(ctx)[0].Completed {
+				t.Error("Expected re-created task to keep the remote completion")
+			}
+		})
+	}
+}
+
+func TestSyncConflictRemoteDeleteLocalChange(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		rule     ConflictRule
+		wantKept bool
+	}{
+		{PreferLocal, true},
+		{PreferRemote, false},
+		{PreferCompleted, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.rule), func(t *testing.T) {
+			fake := newFakeGoogleTasks()
+			remote := fake.add("@default", "Renew passport", false)
+			syncer, app := newTestSyncer(t, fake, Config{Conflict: tc.rule})
+			mustSync(t, syncer)
+
+			remote.Deleted = true
+			app.ToggleTask(ctx, app.GetTasks(ctx)[0].ID)
+
+			result := mustSync(t, syncer)
+			if result.Conflicts != 1 {
+				t.Errorf("Expected 1 conflict, got %+v", result)
+			}
+			kept := len(app.GetTasks(ctx)) == 1
+			if kept != tc.wantKept {
+				t.Errorf("Expected kept=%v, got %v", tc.wantKept, kept)
+			}
+			if kept && (len(fake.tasks["@default"]) != 2 || !fake.tasks["@default"][1].Completed()) {
+				t.Errorf("Expected kept task to be re-created remotely, got %+v", fake.tasks["@default"])
+			}
+		})
+	}
+}
+
+func TestSyncDeletesPropagate(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleTasks()
+	fake.add("@default", "Old", false)
+	remote := fake.add("@default", "Gone", false)
+	syncer, app := newTestSyncer(t, fake, Config{ListID: "@default"})
+	mustSync(t, syncer)
+
+	remote.Deleted = true
+	result := mustSync(t, syncer)
+	if result.Deleted != 1 || len(app.GetTasks(ctx)) != 1 {
+		t.Errorf("Expected remote deletion to remove local task, result %+v", result)
+	}
+
+	app.DeleteTask(ctx, app.GetTasks(ctx)[0].ID)
+	result = mustSync(t, syncer)
+	if result.Deleted != 1 || !fake.tasks["@default"][0].Deleted {
+		t.Errorf("Expected local deletion to remove remote task, result %+v", result)
+	}
+}
+
+func TestSyncUnknownList(t *testing.T) {
+	syncer, _ := newTestSyncer(t, newFakeGoogleTasks(), Config{ListTitle: "Missing"})
+
+	if _, err := syncer.Sync(context.Background()); err == nil {
+		t.Error("Expected error for unknown task list")
+	}
+}
+
+func TestSyncStateFile(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleTasks()
+	fake.add("@default", "Call mom", false)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	app := models.NewTodoApp()
+	app.AddTask(ctx, "Bread")
+	path := filepath.Join(t.TempDir(), "googletasks.json")
+
+	config := Config{ListID: "@default", StateFile: path}
+	if result := mustSync(t, NewSyncer(NewClient(server.URL, server.Client()), app, config)); result.Pulled != 1 || result.Pushed != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	// 再起動した後も対応を覚えているため、タスクを作り直しません
+	restarted := NewSyncer(NewClient(server.URL, server.Client()), app, config)
+	if result := mustSync(t, restarted); result != (Result{}) {
+		t.Errorf("Expected the restarted sync to be a no-op, got %+v", result)
+	}
+	if tasks := app.GetTasks(ctx); len(tasks) != 2 || len(fake.tasks["@default"]) != 2 {
+		t.Errorf("Expected no duplicates, got %d local and %d remote tasks", len(tasks), len(fake.tasks["@default"]))
+	}
+
+	// 別のタスクリストの対応は使いません
+	other := NewSyncer(NewClient(server.URL, server.Client()), models.NewTodoApp(), Config{ListTitle: "Groceries", StateFile: path})
+	mustSync(t, other)
+	if len(other.links) != 0 {
+		t.Errorf("Expected the links of another list to be ignored, got %v", other.links)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSyncer(NewClient(server.URL, server.Client()), app, config).Sync(ctx); err == nil {
+		t.Error("Expected a broken state file to fail the sync")
+	}
+}
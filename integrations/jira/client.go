@@ -0,0 +1,169 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Credentials は Jira REST API に接続するための認証情報です
+// BaseURL: https://example.atlassian.net のようなサイトURL
+// Email / APIToken: Basic 認証に使うアカウントとAPIトークン
+type Credentials struct {
+	BaseURL  string `json:"base_url"`
+	Email    string `json:"email"`
+	APIToken string `json:"api_token"`
+}
+
+// Issue は同期に必要な範囲だけを取り出した Jira の課題です
+type Issue struct {
+	Key            string
+	Summary        string
+	Status         string
+	StatusCategory string
+}
+
+// Transition は課題に対して実行できるワークフロー遷移です
+type Transition struct {
+	ID   string
+	Name string
+	To   string
+}
+
+// Client は Jira REST API (v2) の薄いクライアントです
+type Client struct {
+	creds      Credentials
+	httpClient *http.Client
+}
+
+// NewClient は認証情報から Client を作成します
+// httpClient が nil の場合は http.DefaultClient を使います
+func NewClient(creds Credentials, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	creds.BaseURL = strings.TrimRight(creds.BaseURL, "/")
+	return &Client{creds: creds, httpClient: httpClient}
+}
+
+// SearchIssues は JQL に一致する課題をページングしながらすべて取得します
+func (c *Client) SearchIssues(ctx context.Context, jql string) ([]Issue, error) {
+	var issues []Issue
+	startAt := 0
+	for {
+		query := url.Values{}
+		query.Set("jql", jql)
+		query.Set("fields", "summary,status")
+		query.Set("startAt", strconv.Itoa(startAt))
+		query.Set("maxResults", "100")
+
+		var page struct {
+			StartAt    int `json:"startAt"`
+			MaxResults int `json:"maxResults"`
+			Total      int `json:"total"`
+			Issues     []struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Summary string `json:"summary"`
+					Status  struct {
+						Name           string `json:"name"`
+						StatusCategory struct {
+							Key string `json:"key"`
+						} `json:"statusCategory"`
+					} `json:"status"`
+				} `json:"fields"`
+			} `json:"issues"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, raw := range page.Issues {
+			issues = append(issues, Issue{
+				Key:            raw.Key,
+				Summary:        raw.Fields.Summary,
+				Status:         raw.Fields.Status.Name,
+				StatusCategory: raw.Fields.Status.StatusCategory.Key,
+			})
+		}
+
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// Transitions は課題の現在のステータスから実行可能な遷移を返します
+func (c *Client) Transitions(ctx context.Context, issueKey string) ([]Transition, error) {
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/transitions", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	transitions := make([]Transition, 0, len(resp.Transitions))
+	for _, t := range resp.Transitions {
+		transitions = append(transitions, Transition{ID: t.ID, Name: t.Name, To: t.To.Name})
+	}
+	return transitions, nil
+}
+
+// DoTransition は課題に遷移を実行します
+func (c *Client) DoTransition(ctx context.Context, issueKey, transitionID string) error {
+	body := map[string]interface{}{
+		"transition": map[string]string{"id": transitionID},
+	}
+	return c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/transitions", body, nil)
+}
+
+// do は認証付きでリクエストを送り、JSON レスポンスを out にデコードします
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.creds.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.creds.Email, c.creds.APIToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jira: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,93 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+)
+
+// CredentialStore はユーザーごとの Jira 認証情報を保持します
+// path が設定されている場合は変更のたびに JSON ファイルへ保存します
+type CredentialStore struct {
+	path  string
+	creds map[string]Credentials
+	mutex sync.RWMutex
+}
+
+// NewCredentialStore は空の CredentialStore を作成します（メモリ上のみ）
+func NewCredentialStore() *CredentialStore {
+	return &CredentialStore{creds: make(map[string]Credentials)}
+}
+
+// LoadCredentialStore は JSON ファイルから CredentialStore を読み込みます
+// ファイルが存在しない場合は空のストアを返し、最初の保存時に作成します
+func LoadCredentialStore(path string) (*CredentialStore, error) {
+	store := NewCredentialStore()
+	store.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.creds); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get は指定ユーザーの認証情報を返します
+func (s *CredentialStore) Get(userID string) (Credentials, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	creds, ok := s.creds[userID]
+	return creds, ok
+}
+
+// Set は指定ユーザーの認証情報を登録（上書き）します
+func (s *CredentialStore) Set(userID string, creds Credentials) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.creds[userID] = creds
+	return s.save()
+}
+
+// Delete は指定ユーザーの認証情報を削除します
+func (s *CredentialStore) Delete(userID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.creds, userID)
+	return s.save()
+}
+
+// Users は認証情報が登録されているユーザーIDを昇順で返します
+func (s *CredentialStore) Users() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	users := make([]string, 0, len(s.creds))
+	for userID := range s.creds {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// save はファイルパスが設定されていれば、本人のみ読み書きできる権限で保存します
+func (s *CredentialStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
@@ -0,0 +1,70 @@
+package jira
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialStorePerUser(t *testing.T) {
+	store := NewCredentialStore()
+
+	if _, ok := store.Get("alice"); ok {
+		t.Error("Expected no credentials for unknown user")
+	}
+
+	store.Set("alice", Credentials{BaseURL: "https://a.atlassian.net", Email: "alice@example.com", APIToken: "a"})
+	store.Set("bob", Credentials{BaseURL: "https://b.atlassian.net", Email: "bob@example.com", APIToken: "b"})
+
+	creds, ok := store.Get("alice")
+	if !ok || creds.Email != "alice@example.com" {
+		t.Errorf("Unexpected credentials for alice: %+v", creds)
+	}
+
+	users := store.Users()
+	if len(users) != 2 || users[0] != "alice" || users[1] != "bob" {
+		t.Errorf("Unexpected users: %v", users)
+	}
+
+	store.Delete("alice")
+	if _, ok := store.Get("alice"); ok {
+		t.Error("Expected alice's credentials to be deleted")
+	}
+}
+
+func TestCredentialStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jira.json")
+
+	store, err := LoadCredentialStore(path)
+	if err != nil {
+		t.Fatalf("LoadCredentialStore failed: %v", err)
+	}
+	if err := store.Set("alice", Credentials{BaseURL: "https://a.atlassian.net", Email: "alice@example.com", APIToken: "a"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected credentials file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	reloaded, err := LoadCredentialStore(path)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if creds, ok := reloaded.Get("alice"); !ok || creds.APIToken != "a" {
+		t.Errorf("Expected persisted credentials, got %+v", creds)
+	}
+}
+
+func TestLoadCredentialStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jira.json")
+	os.WriteFile(path, []byte("not json"), 0600)
+
+	if _, err := LoadCredentialStore(path); err == nil {
+		t.Error("Expected error for invalid credentials file")
+	}
+}
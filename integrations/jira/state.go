@@ -0,0 +1,56 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// state は Config.StateFile に保存する、課題キーから取り込んだタスクの ID への対応です
+type state struct {
+	Links map[string]int `json:"links"`
+}
+
+// loadState は StateFile から対応を読み込みます。ファイルがなければ何も読み込みません
+// 呼び出し元がロックを取ります
+func (s *Syncer) loadState() error {
+	if s.config.StateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.config.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("jira: %s: %w", s.config.StateFile, err)
+	}
+	for key, taskID := range saved.Links {
+		s.links[key] = taskID
+	}
+	return nil
+}
+
+// saveState は対応を一時ファイルに書き出してから StateFile を置き換えます。呼び出し元がロックを取ります
+func (s *Syncer) saveState() error {
+	if s.config.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state{Links: s.links}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.config.StateFile), 0755); err != nil {
+		return err
+	}
+	tmp := s.config.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.config.StateFile)
+}
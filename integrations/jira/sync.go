@@ -0,0 +1,180 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// TaskManager は同期に必要な TodoApp の操作だけを切り出したインターフェースです
+type TaskManager interface {
+	AddTask(ctx context.Context, title string) (models.Task, error)
+	GetTasks(ctx context.Context) []models.Task
+	ToggleTask(ctx context.Context, id int) error
+}
+
+// StatusMapping は Jira のステータス名から「完了扱いかどうか」への対応表です
+// 対応表にないステータスはステータスカテゴリが "done" かどうかで判定します
+type StatusMapping map[string]bool
+
+// Completed は課題を完了扱いにするかどうかを返します
+func (m StatusMapping) Completed(issue Issue) bool {
+	for status, done := range m {
+		if strings.EqualFold(status, issue.Status) {
+			return done
+		}
+	}
+	return issue.StatusCategory == "done"
+}
+
+// Config は Syncer の動作設定です
+// JQL: 取り込む課題を絞り込むフィルタ
+// Statuses: ステータスの対応表（省略時はステータスカテゴリで判定）
+// DoneTransition: タスク完了時に実行する遷移名（空なら Jira 側は更新しない）
+// StateFile: 課題キーと取り込んだタスクの対応を保存する JSON ファイル（空ならメモリ上だけで、再起動すると課題を取り込み直します）
+type Config struct {
+	JQL            string
+	Statuses       StatusMapping
+	DoneTransition string
+	StateFile      string
+}
+
+// Result は1回の同期で行った変更の件数です
+type Result struct {
+	Imported     int
+	Updated      int
+	Transitioned int
+}
+
+// Syncer は JQL フィルタの課題をタスクとして取り込み、状態を同期します
+// links: 課題キーから取り込んだタスクIDへの対応
+type Syncer struct {
+	client *Client
+	tasks  TaskManager
+	config Config
+
+	mutex sync.Mutex
+	links map[string]int
+	// loaded: StateFile から対応を読み込んだか（最初の Sync で読み込みます）
+	loaded bool
+}
+
+// NewSyncer は Syncer を作成します
+func NewSyncer(client *Client, tasks TaskManager, config Config) *Syncer {
+	return &Syncer{
+		client: client,
+		tasks:  tasks,
+		config: config,
+		links:  make(map[string]int),
+	}
+}
+
+// Sync は課題を1回同期します
+// 未取り込みの課題はタスクとして追加し、取り込み済みの課題は完了状態を合わせます
+// DoneTransition が設定されている場合、アプリ側で完了したタスクは Jira 側を遷移させます
+// Config.StateFile があれば、最初の同期で前回までの対応を読み込み、同期のたびに（途中で失敗しても）保存します
+func (s *Syncer) Sync(ctx context.Context) (result Result, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.loaded {
+		if err := s.loadState(); err != nil {
+			return result, err
+		}
+		s.loaded = true
+	}
+	defer func() {
+		if saveErr := s.saveState(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}()
+
+	issues, err := s.client.SearchIssues(ctx, s.config.JQL)
+	if err != nil {
+		return result, err
+	}
+
+	existing := make(map[int]models.Task)
+	for _, task := range s.tasks.GetTasks(ctx) {
+		existing[task.ID] = task
+	}
+
+	for _, issue := range issues {
+		issueDone := s.config.Statuses.Completed(issue)
+
+		id, linked := s.links[issue.Key]
+		task, found := existing[id]
+		if !linked || !found {
+			task, err = s.tasks.AddTask(ctx, fmt.Sprintf("[%s] %s", issue.Key, issue.Summary))
+			if err != nil {
+				return result, err
+			}
+			s.links[issue.Key] = task.ID
+			result.Imported++
+			if issueDone {
+				if err := s.tasks.ToggleTask(ctx, task.ID); err != nil {
+					return result, err
+				}
+			}
+			continue
+		}
+
+		if task.Completed == issueDone {
+			continue
+		}
+
+		if task.Completed && s.config.DoneTransition != "" {
+			if err := s.transition(ctx, issue.Key); err != nil {
+				return result, err
+			}
+			result.Transitioned++
+			continue
+		}
+
+		if err := s.tasks.ToggleTask(ctx, task.ID); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+
+	return result, nil
+}
+
+// transition は設定された遷移名に一致する遷移を課題に実行します
+func (s *Syncer) transition(ctx context.Context, issueKey string) error {
+	transitions, err := s.client.Transitions(ctx, issueKey)
+	if err != nil {
+		return err
+	}
+	for _, t := range transitions {
+		if strings.EqualFold(t.Name, s.config.DoneTransition) || strings.EqualFold(t.To, s.config.DoneTransition) {
+			return s.client.DoTransition(ctx, issueKey, t.ID)
+		}
+	}
+	return fmt.Errorf("jira: transition %q is not available for %s", s.config.DoneTransition, issueKey)
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに Sync を実行します
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := s.Sync(ctx); err != nil {
+			slog.Error("jira sync failed", "err", err)
+		} else if result != (Result{}) {
+			slog.Info("jira sync", "imported", result.Imported, "updated", result.Updated, "transitioned", result.Transitioned)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"todo-app/models"
+)
+
+type fakeJira struct {
+	mutex       sync.Mutex
+	issues      []Issue
+	transitions []string
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/rest/api/2/search":
+		issues := make([]map[string]interface{}, 0)
+		for _, issue := range f.issues {
+			issues = append(issues, map[string]interface{}{
+				"key": issue.Key,
+				"fields": map[string]interface{}{
+					"summary": issue.Summary,
+					"status": map[string]interface{}{
+						"name":           issue.Status,
+						"statusCategory": map[string]string{"key": issue.StatusCategory},
+					},
+				},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"startAt": 0,
+			"total":   len(issues),
+			"issues":  issues,
+		})
+	case strings.HasSuffix(r.URL.Path, "/transitions") && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transitions": []map[string]interface{}{
+				{"id": "11", "name": "Start", "to": map[string]string{"name": "In Progress"}},
+				{"id": "31", "name": "Done", "to": map[string]string{"name": "Done"}},
+			},
+		})
+	case strings.HasSuffix(r.URL.Path, "/transitions") && r.Method == http.MethodPost:
+		var body struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/transitions")
+		f.transitions = append(f.transitions, key+":"+body.Transition.ID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestSyncer(t *testing.T, fake *fakeJira, config Config) (*Syncer, *models.TodoApp) {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := NewClient(Credentials{BaseURL: server.URL + "/", Email: "me@example.com", APIToken: "secret"}, server.Client())
+	app := models.NewTodoApp()
+	return NewSyncer(client, app, config), app
+}
+
+func TestSyncImportsIssues(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{
+		{Key: "PRJ-1", Summary: "Fix login", Status: "To Do", StatusCategory: "new"},
+		{Key: "PRJ-2", Summary: "Write docs", Status: "Closed", StatusCategory: "done"},
+	}}
+	syncer, app := newTestSyncer(t, fake, Config{JQL: "project = PRJ"})
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("Expected 2 imported issues, got %d", result.Imported)
+	}
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Title != "[PRJ-1] Fix login" || tasks[0].Completed {
+		t.Errorf("Unexpected first task: %+v", tasks[0])
+	}
+	if !tasks[1].Completed {
+		t.Error("Expected done issue to be imported as completed")
+	}
+
+	result, err = syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	if result != (Result{}) {
+		t.Errorf("Expected second sync to be a no-op, got %+v", result)
+	}
+	if len(app.GetTasks(ctx)) != 2 {
+		t.Error("Second sync should not duplicate tasks")
+	}
+}
+
+func TestSyncStatusMapping(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{
+		{Key: "PRJ-1", Summary: "Deploy", Status: "Ready for Release", StatusCategory: "indeterminate"},
+	}}
+	syncer, app := newTestSyncer(t, fake, Config{
+		JQL:      "project = PRJ",
+		Statuses: StatusMapping{"ready for release": true},
+	})
+
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !app.GetTasks(ctx)[0].Completed {
+		t.Error("Expected mapped status to mark task as completed")
+	}
+
+	fake.issues[0].Status = "Reopened"
+	fake.issues[0].StatusCategory = "new"
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Updated != 1 || app.GetTasks(ctx)[0].Completed {
+		t.Errorf("Expected reopened issue to reopen the task, result %+v", result)
+	}
+}
+
+func TestSyncTransitionsCompletedTasks(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{
+		{Key: "PRJ-7", Summary: "Ship it", Status: "In Progress", StatusCategory: "indeterminate"},
+	}}
+	syncer, app := newTestSyncer(t, fake, Config{JQL: "project = PRJ", DoneTransition: "Done"})
+
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	app.ToggleTask(ctx, app.GetTasks(ctx)[0].ID)
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Transitioned != 1 {
+		t.Errorf("Expected 1 transition, got %d", result.Transitioned)
+	}
+	if len(fake.transitions) != 1 || fake.transitions[0] != "PRJ-7:31" {
+		t.Errorf("Unexpected transitions: %v", fake.transitions)
+	}
+	if !app.GetTasks(ctx)[0].Completed {
+		t.Error("Completed task should stay completed after transition")
+	}
+}
+
+func TestSyncWithoutTransitionFollowsJira(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{
+		{Key: "PRJ-8", Summary: "Review", Status: "To Do", StatusCategory: "new"},
+	}}
+	syncer, app := newTestSyncer(t, fake, Config{JQL: "project = PRJ"})
+
+	syncer.Sync(context.Background())
+	app.ToggleTask(ctx, app.GetTasks(ctx)[0].ID)
+
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(fake.transitions) != 0 {
+		t.Errorf("Expected no transitions, got %v", fake.transitions)
+	}
+	if app.GetTasks(ctx)[0].Completed {
+		t.Error("Expected task to follow the Jira status when transitions are disabled")
+	}
+}
+
+func TestSyncUnknownTransition(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{
+		{Key: "PRJ-9", Summary: "Close", Status: "To Do", StatusCategory: "new"},
+	}}
+	syncer, app := newTestSyncer(t, fake, Config{JQL: "project = PRJ", DoneTransition: "Archive"})
+
+	syncer.Sync(context.Background())
+	app.ToggleTask(ctx, app.GetTasks(ctx)[0].ID)
+
+	if _, err := syncer.Sync(context.Background()); err == nil {
+		t.Error("Expected an error for an unavailable transition")
+	}
+}
+
+func TestSyncAuthenticationError(t *testing.T) {
+	server := httptest.NewServer(&fakeJira{})
+	defer server.Close()
+
+	client := NewClient(Credentials{BaseURL: server.URL, Email: "me@example.com", APIToken: "wrong"}, nil)
+	syncer := NewSyncer(client, models.NewTodoApp(), Config{JQL: "project = PRJ"})
+
+	_, err := syncer.Sync(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected 401 error, got %v", err)
+	}
+}
+
+func TestSyncStateFile(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeJira{issues: []Issue{{Key: "PRJ-1", Summary: "Fix login", Status: "To Do", StatusCategory: "new"}}}
+	path := filepath.Join(t.TempDir(), "jira.json")
+	syncer, app := newTestSyncer(t, fake, Config{JQL: "project = PRJ", StateFile: path})
+	if result, err := syncer.Sync(ctx); err != nil || result.Imported != 1 {
+		t.Fatalf("Unexpected first sync: %+v, %v", result, err)
+	}
+
+	// 再起動した後も対応を覚えているため、課題を取り込み直しません
+	restarted := NewSyncer(syncer.client, app, syncer.config)
+	if result, err := restarted.Sync(ctx); err != nil || result != (Result{}) {
+		t.Errorf("Expected the restarted sync to be a no-op, got %+v, %v", result, err)
+	}
+	if tasks := app.GetTasks(ctx); len(tasks) != 1 {
+		t.Errorf("Expected no duplicated tasks, got %+v", tasks)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSyncer(syncer.client, app, syncer.config).Sync(ctx); err == nil {
+		t.Error("Expected a broken state file to fail the sync")
+	}
+}
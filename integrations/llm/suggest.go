@@ -0,0 +1,166 @@
+// Package llm は OpenAI 互換の Chat Completions API を使って、タスクを小さな作業に分ける案を作ります
+// OpenAI のほか、同じ形式の API を持つ Ollama や vLLM などのサーバも使えます
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 既定のエンドポイントとモデル
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "gpt-4o-mini"
+)
+
+// MaxSuggestions は1回に返す案の上限です
+const MaxSuggestions = 10
+
+// maxSuggestionLength は1件の案の長さ（文字数）の上限です。超えた部分は切り捨てます
+const maxSuggestionLength = 200
+
+// ErrProvider は LLM のサービスがエラーを返したか、使える案を返さなかったときのエラーです
+var ErrProvider = errors.New("llm provider error")
+
+// systemPrompt は案の作り方の指示です。番号や記号を付けずに1行に1件ずつ返すよう頼みます
+const systemPrompt = "You help people break a to-do item into concrete, actionable subtasks. " +
+	"Reply with 3 to 7 subtasks, one per line, without numbering, bullets or any other text. " +
+	"Write them in the same language as the to-do item and keep each one short."
+
+// Config は Suggester の設定です
+// BaseURL: Chat Completions API のエンドポイント（/chat/completions の手前まで。空なら DefaultBaseURL）
+// APIKey: Bearer トークン（空なら Authorization ヘッダを付けません。ローカルの Ollama など）
+// Model: 使うモデル（空なら DefaultModel）
+type Config struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+// Suggester はタスクのタイトルから小さな作業の案を作ります
+type Suggester struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// NewSuggester は Suggester を作成します。httpClient が nil の場合はタイムアウト30秒のクライアントを使います
+func NewSuggester(config Config, httpClient *http.Client) *Suggester {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.Model == "" {
+		config.Model = DefaultModel
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &Suggester{config: config, httpClient: httpClient}
+}
+
+// SuggestSubtasks は title のタスクを分けた作業の案を最大 MaxSuggestions 件返します
+func (s *Suggester) SuggestSubtasks(ctx context.Context, title string) ([]string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": s.config.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt},
+			{"role": "user", "content": title},
+		},
+		"temperature": 0.2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(data, &result) == nil && result.Error.Message != "" {
+			return nil, fmt.Errorf("%w: %s: %s", ErrProvider, resp.Status, result.Error.Message)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrProvider, resp.Status)
+	}
+	if err := json.Unmarshal(data, &result); err != nil || len(result.Choices) == 0 {
+		return nil, fmt.Errorf("%w: unexpected response", ErrProvider)
+	}
+
+	suggestions := parseSuggestions(result.Choices[0].Message.Content)
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("%w: no suggestions in the response", ErrProvider)
+	}
+	return suggestions, nil
+}
+
+// parseSuggestions は応答の本文を1行1件の案に分けます
+// 指示に従わずに付けられた箇条書きの記号や番号は取り除き、同じ案は1件にまとめます
+func parseSuggestions(content string) []string {
+	var suggestions []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•・ ")
+		line = strings.TrimSpace(trimNumber(line))
+		if line == "" || strings.HasPrefix(line, "```") || seen[line] {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxSuggestionLength {
+			line = string([]rune(line)[:maxSuggestionLength])
+		}
+		seen[line] = true
+		suggestions = append(suggestions, line)
+		if len(suggestions) == MaxSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
+
+// trimNumber は "1. " や "2) " のような行頭の番号を取り除きます
+func trimNumber(line string) string {
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits == len(line) || (line[digits] != '.' && line[digits] != ')') {
+		return line
+	}
+	return line[digits+1:]
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeProvider は Chat Completions API のフェイクです。受け取ったリクエストを記録し、content を返します
+func fakeProvider(t *testing.T, status int, content string) (*httptest.Server, *map[string]interface{}) {
+	received := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"message": "Incorrect API key provided."}})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte("upstream unavailable"))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestSuggestSubtasks(t *testing.T) {
+	server, received := fakeProvider(t, http.StatusOK, "会場を予約する\n招待状を送る\n\n料理を注文する\n")
+	suggester := NewSuggester(Config{BaseURL: server.URL + "/v1/", APIKey: "secret"}, server.Client())
+
+	suggestions, err := suggester.SuggestSubtasks(context.Background(), "歓迎会の準備")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"会場を予約する", "招待状を送る", "料理を注文する"}
+	if !reflect.DeepEqual(suggestions, want) {
+		t.Errorf("Expected %v, got %v", want, suggestions)
+	}
+	if (*received)["model"] != DefaultModel {
+		t.Errorf("Expected the default model, got %v", (*received)["model"])
+	}
+	messages := (*received)["messages"].([]interface{})
+	if user := messages[1].(map[string]interface{}); user["content"] != "歓迎会の準備" {
+		t.Errorf("Expected the title as the user message, got %v", user)
+	}
+}
+
+func TestSuggestSubtasksErrors(t *testing.T) {
+	server, _ := fakeProvider(t, http.StatusOK, "ok")
+	unauthorized := NewSuggester(Config{BaseURL: server.URL + "/v1", APIKey: "wrong"}, server.Client())
+	if _, err := unauthorized.SuggestSubtasks(context.Background(), "x"); !errors.Is(err, ErrProvider) || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Errorf("Expected the provider's error message, got %v", err)
+	}
+
+	failing, _ := fakeProvider(t, http.StatusBadGateway, "")
+	suggester := NewSuggester(Config{BaseURL: failing.URL + "/v1", APIKey: "secret"}, failing.Client())
+	if _, err := suggester.SuggestSubtasks(context.Background(), "x"); !errors.Is(err, ErrProvider) || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Expected a provider error with the status, got %v", err)
+	}
+
+	empty, _ := fakeProvider(t, http.StatusOK, "\n- \n")
+	suggester = NewSuggester(Config{BaseURL: empty.URL + "/v1", APIKey: "secret"}, empty.Client())
+	if _, err := suggester.SuggestSubtasks(context.Background(), "x"); !errors.Is(err, ErrProvider) {
+		t.Errorf("Expected a provider error without suggestions, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := suggester.SuggestSubtasks(ctx, "x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+
+	closed := NewSuggester(Config{BaseURL: "http://127.0.0.1:1"}, nil)
+	if _, err := closed.SuggestSubtasks(context.Background(), "x"); !errors.Is(err, ErrProvider) {
+		t.Errorf("Expected a provider error when the server is unreachable, got %v", err)
+	}
+}
+
+func TestParseSuggestions(t *testing.T) {
+	content := "```\n1. 会場を予約する\n2) 招待状を送る\n- 料理を注文する\n・料理を注文する\n* 2025年の予算を確認する\n3\n" + strings.Repeat("あ", 250)
+	got := parseSuggestions(content)
+	want := []string{"会場を予約する", "招待状を送る", "料理を注文する", "2025年の予算を確認する", "3", strings.Repeat("あ", maxSuggestionLength)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := parseSuggestions("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"); len(got) != MaxSuggestions {
+		t.Errorf("Expected at most %d suggestions, got %d", MaxSuggestions, len(got))
+	}
+}
@@ -0,0 +1,167 @@
+// Package notion はタスク一覧を Notion のデータベースへエクスポートします
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"todo-app/models"
+)
+
+// 既定のエンドポイントと API バージョン
+const (
+	DefaultBaseURL = "https://api.notion.com/v1"
+	APIVersion     = "2022-06-28"
+)
+
+// PropertyMap はタスクの各項目を書き込む Notion データベースのプロパティ名です
+// 空のプロパティは書き込みません（Title は必須です）
+// Title: タイトル型 / Completed: チェックボックス型 / DueDate: 日付型
+// List: セレクト型 / ID: 数値型
+type PropertyMap struct {
+	Title     string `json:"title"`
+	Completed string `json:"completed,omitempty"`
+	DueDate   string `json:"due_date,omitempty"`
+	List      string `json:"list,omitempty"`
+	ID        string `json:"id,omitempty"`
+}
+
+// DefaultPropertyMap は Notion の新規データベースにそのまま合わせやすい既定の対応です
+func DefaultPropertyMap() PropertyMap {
+	return PropertyMap{
+		Title:     "Name",
+		Completed: "Done",
+		DueDate:   "Due",
+		List:      "List",
+	}
+}
+
+// Config は Exporter の設定です
+// Token: Notion インテグレーションのシークレット
+// DatabaseID: 書き込み先のデータベースID
+// ListName: List プロパティに書き込むリスト名
+// BaseURL: API のエンドポイント（空なら DefaultBaseURL）
+type Config struct {
+	Token      string
+	DatabaseID string
+	Properties PropertyMap
+	ListName   string
+	BaseURL    string
+}
+
+// Result はエクスポートの結果です
+type Result struct {
+	Exported int      `json:"exported"`
+	Failed   int      `json:"failed"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// Exporter はタスクを Notion のデータベースにページとして作成します
+type Exporter struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// NewExporter は Exporter を作成します
+func NewExporter(config Config, httpClient *http.Client) *Exporter {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.Properties.Title == "" {
+		config.Properties = DefaultPropertyMap()
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &Exporter{config: config, httpClient: httpClient}
+}
+
+// Export はタスクを1件ずつページとして作成します
+// 失敗したタスクがあっても残りのエクスポートは続け、結果にまとめて返します
+func (e *Exporter) Export(ctx context.Context, tasks []models.Task) Result {
+	var result Result
+	for _, task := range tasks {
+		if err := e.createPage(ctx, task); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("task %d: %v", task.ID, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		result.Exported++
+	}
+	return result
+}
+
+// properties はタスクを Notion のプロパティ値に変換します
+func (e *Exporter) properties(task models.Task) map[string]interface{} {
+	mapping := e.config.Properties
+	props := map[string]interface{}{
+		mapping.Title: map[string]interface{}{
+			"title": []map[string]interface{}{
+				{"text": map[string]string{"content": task.Title}},
+			},
+		},
+	}
+	if mapping.Completed != "" {
+		props[mapping.Completed] = map[string]bool{"checkbox": task.Completed}
+	}
+	if mapping.DueDate != "" && task.DueDate != nil {
+		props[mapping.DueDate] = map[string]interface{}{
+			"date": map[string]string{"start": task.DueDate.Format("2006-01-02")},
+		}
+	}
+	if mapping.List != "" && e.config.ListName != "" {
+		props[mapping.List] = map[string]interface{}{
+			"select": map[string]string{"name": e.config.ListName},
+		}
+	}
+	if mapping.ID != "" {
+		props[mapping.ID] = map[string]int{"number": task.ID}
+	}
+	return props
+}
+
+// createPage はタスク1件をデータベースのページとして作成します
+func (e *Exporter) createPage(ctx context.Context, task models.Task) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"parent":     map[string]string{"database_id": e.config.DatabaseID},
+		"properties": e.properties(task),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.BaseURL+"/pages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+e.config.Token)
+	req.Header.Set("Notion-Version", APIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("notion: %s: %s", resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("notion: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+type fakeNotion struct {
+	mutex sync.Mutex
+	pages []map[string]interface{}
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") != APIVersion {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"message": "API token is invalid."})
+		return
+	}
+
+	var page map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&page)
+
+	props := page["properties"].(map[string]interface{})
+	if _, ok := props["Name"]; !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Name is not a property that exists."})
+		return
+	}
+
+	f.mutex.Lock()
+	f.pages = append(f.pages, page)
+	f.mutex.Unlock()
+	json.NewEncoder(w).Encode(map[string]string{"object": "page", "id": "p1"})
+}
+
+func TestExport(t *testing.T) {
+	fake := &fakeNotion{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	props := DefaultPropertyMap()
+	props.ID = "Task ID"
+	exporter := NewExporter(Config{
+		Token:      "secret",
+		DatabaseID: "db1",
+		Properties: props,
+		ListName:   "Inbox",
+		BaseURL:    server.URL + "/",
+	}, server.Client())
+
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	result := exporter.Export(context.Background(), []models.Task{
+		{ID: 1, Title: "Buy milk", Completed: true, DueDate: &due},
+		{ID: 2, Title: "Call mom"},
+	})
+	if result.Exported != 2 || result.Failed != 0 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	page := fake.pages[0]
+	if page["parent"].(map[string]interface{})["database_id"] != "db1" {
+		t.Errorf("Unexpected parent: %v", page["parent"])
+	}
+	encoded, _ := json.Marshal(page["properties"])
+	for _, want := range []string{
+		`"Name":{"title":[{"text":{"content":"Buy milk"}}]}`,
+		`"Done":{"checkbox":true}`,
+		`"Due":{"date":{"start":"2025-03-01"}}`,
+		`"List":{"select":{"name":"Inbox"}}`,
+		`"Task ID":{"number":1}`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("Expected properties to contain %s, got %s", want, encoded)
+		}
+	}
+
+	encoded, _ = json.Marshal(fake.pages[1]["properties"])
+	if strings.Contains(string(encoded), `"Due"`) {
+		t.Errorf("Expected no due date property for task without due date, got %s", encoded)
+	}
+}
+
+func TestExportCustomMapping(t *testing.T) {
+	fake := &fakeNotion{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	exporter := NewExporter(Config{
+		Token:      "secret",
+		BaseURL:    server.URL,
+		Properties: PropertyMap{Title: "Task"},
+	}, nil)
+
+	result := exporter.Export(context.Background(), []models.Task{{ID: 1, Title: "A"}})
+	if result.Failed != 1 || !strings.Contains(result.Errors[0], "Name is not a property") {
+		t.Errorf("Expected the Notion error message to be reported, got %+v", result)
+	}
+}
+
+func TestExportAuthError(t *testing.T) {
+	server := httptest.NewServer(&fakeNotion{})
+	defer server.Close()
+
+	exporter := NewExporter(Config{Token: "wrong", BaseURL: server.URL}, nil)
+	result := exporter.Export(context.Background(), []models.Task{{ID: 1, Title: "A"}, {ID: 2, Title: "B"}})
+	if result.Exported != 0 || result.Failed != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
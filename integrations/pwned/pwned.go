@@ -0,0 +1,66 @@
+// Package pwned はパスワードが漏洩したパスワードの一覧に含まれていないかを、Pwned Passwords の range API で確かめます
+// パスワードの SHA-1 の先頭5文字だけを送り（k-匿名性）、返ってきた残りの部分の一覧と手元で突き合わせるため、パスワードもハッシュも送りません
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultURL は Have I Been Pwned の range API の URL です（末尾にハッシュの先頭5文字を付けます）
+const DefaultURL = "https://api.pwnedpasswords.com/range/"
+
+// Checker は range API に問い合わせてパスワードを確かめます
+type Checker struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewChecker は rangeURL（空なら DefaultURL）に問い合わせる Checker を作成します
+func NewChecker(rangeURL string, httpClient *http.Client) *Checker {
+	if rangeURL == "" {
+		rangeURL = DefaultURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Checker{url: rangeURL, httpClient: httpClient}
+}
+
+// Breached は password が漏洩したパスワードの一覧に含まれているかを返します
+func (c *Checker) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// 応答の大きさから問い合わせたハッシュを推測されないよう、件数 0 のダミーの行を混ぜてもらいます
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned: %s", resp.Status)
+	}
+
+	// 1行に1つ "残りの35文字:件数" が並びます
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		rest, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(rest, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
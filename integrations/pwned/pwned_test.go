@@ -0,0 +1,52 @@
+package pwned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBreached(t *testing.T) {
+	// "password" の SHA-1 は 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8 です
+	var path, padding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, padding = r.URL.Path, r.Header.Get("Add-Padding")
+		w.Write([]byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n1F2B668E8AABEF1C59E9EC6F82E3F3CD786:0\r\n"))
+	}))
+	defer server.Close()
+	c := NewChecker(server.URL+"/range/", nil)
+
+	breached, err := c.Breached(context.Background(), "password")
+	if err != nil || !breached {
+		t.Errorf("Expected the password to be breached, got %v %v", breached, err)
+	}
+	if path != "/range/5BAA6" || padding != "true" {
+		t.Errorf("Expected only the prefix to be sent with padding, got %q %q", path, padding)
+	}
+	if breached, err := c.Breached(context.Background(), "correct horse battery staple 42"); err != nil || breached {
+		t.Errorf("Expected the password not to be breached, got %v %v", breached, err)
+	}
+}
+
+func TestBreachedIgnoresPadding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("1E4C9B93F3F0682250B6CF8331B7EE68FD8:0\n"))
+	}))
+	defer server.Close()
+
+	if breached, err := NewChecker(server.URL+"/", nil).Breached(context.Background(), "password"); err != nil || breached {
+		t.Errorf("Expected a padding line not to count, got %v %v", breached, err)
+	}
+}
+
+func TestBreachedServiceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewChecker(server.URL+"/", nil).Breached(context.Background(), "password"); err == nil {
+		t.Error("Expected an error from the service")
+	}
+}
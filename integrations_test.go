@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/config"
+	"todo-app/leader"
+	"todo-app/models"
+	"todo-app/reports"
+)
+
+func TestStartJiraSyncDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	app := models.NewTodoApp()
+	startJiraSync(context.Background(), app, config.Default())
+
+	if len(app.GetTasks(ctx)) != 0 {
+		t.Error("Expected no tasks when Jira sync is disabled")
+	}
+}
+
+func TestStartJiraSync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"total": 1,
+			"issues": []map[string]interface{}{
+				{"key": "OPS-1", "fields": map[string]interface{}{"summary": "Rotate keys"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Jira.JQL = "project = OPS"
+	cfg.Jira.BaseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := models.NewTodoApp()
+	startJiraSync(ctx, app, cfg)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(app.GetTasks(ctx)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 1 || tasks[0].Title != "[OPS-1] Rotate keys" {
+		t.Errorf("Expected imported Jira issue, got %+v", tasks)
+	}
+}
+
+func TestStartJiraSyncForUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"total": 1,
+			"issues": []map[string]interface{}{
+				{"key": "OPS-2", "fields": map[string]interface{}{"summary": "Review access"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "jira_credentials.json")
+	data, _ := json.Marshal(map[string]interface{}{"3": map[string]string{"base_url": server.URL}})
+	if err := os.WriteFile(credentials, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Jira.JQL = "project = OPS"
+	cfg.Jira.CredentialsFile = credentials
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	global := models.NewTodoApp()
+	startJiraSync(ctx, global, cfg)
+	user := models.NewTodoApp()
+	startJiraSyncFor(ctx, user, cfg, "3", filepath.Join(dir, "3.jira.json"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(user.GetTasks(ctx)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if tasks := user.GetTasks(ctx); len(tasks) != 1 || tasks[0].Title != "[OPS-2] Review access" {
+		t.Errorf("Expected the user's Jira issue in the user's tasks, got %+v", tasks)
+	}
+	if tasks := global.GetTasks(ctx); len(tasks) != 0 {
+		t.Errorf("Expected no user's Jira issues in the global tasks, got %+v", tasks)
+	}
+}
+
+func TestIntegrationStatePath(t *testing.T) {
+	testCases := []struct {
+		settings config.Store
+		want     string
+	}{
+		{config.Store{Driver: "file", DSN: "data/tasks.json"}, filepath.Join("data", "googletasks.json")},
+		{config.Store{Driver: gitDriverName, DSN: "data/repo"}, filepath.Join("data", "googletasks.json")},
+		{config.Store{Driver: "file"}, ""},
+		{config.Store{Driver: "postgres", DSN: "postgres://localhost/todo"}, ""},
+	}
+	for _, tc := range testCases {
+		if got := integrationStatePath(tc.settings, "googletasks"); got != tc.want {
+			t.Errorf("integrationStatePath(%+v) = %q, expected %q", tc.settings, got, tc.want)
+		}
+	}
+}
+
+func TestStartGoogleTasksSync(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 3600})
+	}))
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items": []map[string]string{{"id": "g1", "title": "Buy milk", "status": "needsAction"}},
+		})
+	}))
+	defer api.Close()
+
+	cfg := config.Default()
+	cfg.Google.RefreshToken = "refresh"
+	cfg.Google.TokenURL = tokenServer.URL
+	cfg.Google.TasksList = "@default"
+	cfg.Google.TasksURL = api.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := models.NewTodoApp()
+	startGoogleTasksSync(ctx, app, cfg)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(app.GetTasks(ctx)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 1 || tasks[0].Title != "Buy milk" {
+		t.Errorf("Expected pulled Google task, got %+v", tasks)
+	}
+}
+
+func TestStartGoogleCalendarSync(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 3600})
+	}))
+	defer tokenServer.Close()
+
+	var mutex sync.Mutex
+	var created []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/me/calendarList":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]string{{"id": "todo-cal", "summary": "ToDo"}},
+			})
+		case "/calendars/todo-cal/events":
+			var event map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&event)
+			mutex.Lock()
+			created = append(created, event["summary"].(string))
+			mutex.Unlock()
+			json.NewEncoder(w).Encode(map[string]string{"id": "ev1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	cfg := config.Default()
+	cfg.Google.RefreshToken = "refresh"
+	cfg.Google.TokenURL = tokenServer.URL
+	cfg.Google.CalendarName = "ToDo"
+	cfg.Google.CalendarURL = api.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := models.NewTodoApp()
+	task, _ := app.AddTask(ctx, "Dentist")
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	app.SetDueDate(ctx, task.ID, &due)
+	startGoogleCalendarSync(ctx, app, cfg)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mutex.Lock()
+		n := len(created)
+		mutex.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(created) != 1 || created[0] != "Dentist" {
+		t.Errorf("Expected an event for the task with a due date, got %v", created)
+	}
+}
+
+func TestNotionExporter(t *testing.T) {
+	cfg := config.Default()
+	if notionExporter(cfg) != nil {
+		t.Error("Expected no exporter when Notion is not configured")
+	}
+
+	cfg.Notion = config.Notion{Token: "secret", DatabaseID: "db", Properties: "{invalid"}
+	if notionExporter(cfg) == nil {
+		t.Error("Expected an exporter when Notion is configured")
+	}
+}
+
+func TestNewBackupManager(t *testing.T) {
+	cfg := config.Default()
+	if newBackupManager(cfg, models.NewTodoApp(), "") != nil {
+		t.Error("Expected no backup manager without a destination")
+	}
+
+	cfg.Backup.Destination = "file://" + t.TempDir()
+	cfg.Backup.Key = "short"
+	if newBackupManager(cfg, models.NewTodoApp(), "") != nil {
+		t.Error("Expected no backup manager with an invalid key")
+	}
+
+	cfg.Backup.Key = "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+	cfg.Backup.Retention = 2
+	manager := newBackupManager(cfg, models.NewTodoApp(), "")
+	if manager == nil || manager.Retention != 2 {
+		t.Fatalf("Unexpected backup manager: %+v", manager)
+	}
+	if _, err := manager.Backup(context.Background()); err != nil {
+		t.Errorf("Backup failed: %v", err)
+	}
+
+	cfg.Backup.Destination = "ftp://example.com/backups"
+	if newBackupManager(cfg, models.NewTodoApp(), "") != nil {
+		t.Error("Expected no backup manager for an unsupported destination")
+	}
+}
+
+func TestNewStaleDigest(t *testing.T) {
+	cfg := config.Default()
+	if newStaleDigest(cfg) != nil {
+		t.Error("Expected no digest without a URL")
+	}
+
+	cfg.StaleDigest.URL = "https://example.com/digest"
+	cfg.StaleDigest.OlderThan = "2w"
+	digest := newStaleDigest(cfg)
+	if digest == nil || digest.OlderThan != 14*24*time.Hour {
+		t.Fatalf("Unexpected digest: %+v", digest)
+	}
+
+	cfg.StaleDigest.OlderThan = "someday"
+	if newStaleDigest(cfg) != nil {
+		t.Error("Expected no digest with an invalid age")
+	}
+}
+
+func TestNewSuggester(t *testing.T) {
+	cfg := config.Default()
+	if newSuggester(cfg) != nil {
+		t.Error("Expected no suggester without a URL and an API key")
+	}
+
+	cfg.LLM.URL = "http://localhost:11434/v1"
+	if newSuggester(cfg) == nil {
+		t.Error("Expected a suggester with a URL")
+	}
+}
+
+func TestNewReportScheduler(t *testing.T) {
+	email := reports.Schedule{Report: reports.KindStaleSummary, Email: "alice@example.com"}
+	cfg := config.Default()
+	if _, err := newReportScheduler(cfg).Add(email); !errors.Is(err, reports.ErrEmailDisabled) {
+		t.Errorf("Expected email delivery to be disabled without an SMTP server, got %v", err)
+	}
+
+	cfg.SMTP = config.SMTP{Addr: "smtp.example.com:587", From: "todo@example.com"}
+	if _, err := newReportScheduler(cfg).Add(email); err != nil {
+		t.Errorf("Expected email delivery with an SMTP server, got %v", err)
+	}
+}
+
+func TestNewExecHooks(t *testing.T) {
+	cfg := config.Default()
+	if newExecHooks(cfg) != nil {
+		t.Error("Expected no hooks without a hooks file")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hooks.json")
+	cfg.ExecHooksFile = path
+	if newExecHooks(cfg) != nil {
+		t.Error("Expected no hooks for a missing file")
+	}
+
+	os.WriteFile(path, []byte(`[{"name": "deploy", "event": "task.exploded", "command": "true"}]`), 0o600)
+	if newExecHooks(cfg) != nil {
+		t.Error("Expected no hooks for an invalid hook")
+	}
+
+	os.WriteFile(path, []byte(`[{"name": "deploy", "event": "task.completed", "filter": "title:deploy", "command": "true"}]`), 0o600)
+	if newExecHooks(cfg) == nil {
+		t.Error("Expected hooks to be loaded")
+	}
+}
+
+func TestNewElector(t *testing.T) {
+	cfg := config.Default()
+	if newElector(cfg, "") != nil {
+		t.Error("Expected no elector without a lock file")
+	}
+
+	cfg.Leader.LockFile = "/shared/todo-leader.json"
+	elector := newElector(cfg, "")
+	if elector == nil || elector.ID == "" || elector.TTL != leader.DefaultTTL {
+		t.Fatalf("Unexpected elector: %+v", elector)
+	}
+
+	cfg.Leader.ID = "web-1"
+	cfg.Leader.TTL = time.Minute
+	if elector := newElector(cfg, "w-team"); elector.ID != "web-1" || elector.TTL != time.Minute || elector.Path != "/shared/todo-leader.json.w-team" {
+		t.Errorf("Unexpected elector: %+v", elector)
+	}
+}
@@ -0,0 +1,181 @@
+// Package leader は複数のインスタンスのうち1つだけを leader に選び、定期的な処理をその1つだけで動かします
+// 選出には共有のファイルシステム（NFS など）に置いたリースのファイルを使います
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTTL はリースの有効期間の既定値です。leader は TTL の 1/3 ごとに更新します
+const DefaultTTL = 30 * time.Second
+
+// staleMutex は排他用のファイルが残っていても取り除いてよい経過時間です（更新の途中で止まった場合）
+const staleMutex = 10 * time.Second
+
+// Lease はリースのファイルの内容です
+// Holder: leader のインスタンスの ID
+// Expires: 更新されなければ leader でなくなる時刻
+type Lease struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileElector はリースのファイルで leader を選びます
+// リースを読み書きする間は、同じディレクトリの <Path>.lock を O_EXCL で作って排他します
+type FileElector struct {
+	Path string
+	ID   string
+	TTL  time.Duration
+
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// NewFileElector は path のリースを ID id で取り合う FileElector を作成します。ttl が 0 なら DefaultTTL です
+func NewFileElector(path, id string, ttl time.Duration) *FileElector {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &FileElector{Path: path, ID: id, TTL: ttl, now: time.Now, logger: slog.Default()}
+}
+
+// TryAcquire はリースが空いているか期限切れか自分のものなら、自分のリースとして更新し true を返します
+// ほかのインスタンスが有効なリースを持っていれば false を返します
+func (e *FileElector) TryAcquire() (bool, error) {
+	acquired := false
+	err := e.withMutex(func() error {
+		lease, err := e.read()
+		if err != nil {
+			return err
+		}
+		now := e.now()
+		if lease.Holder != "" && lease.Holder != e.ID && now.Before(lease.Expires) {
+			return nil
+		}
+		acquired = true
+		return e.write(Lease{Holder: e.ID, Expires: now.Add(e.TTL)})
+	})
+	return acquired && err == nil, err
+}
+
+// Release は自分のリースを手放し、ほかのインスタンスがすぐに leader になれるようにします
+func (e *FileElector) Release() error {
+	return e.withMutex(func() error {
+		lease, err := e.read()
+		if err != nil || lease.Holder != e.ID {
+			return err
+		}
+		return e.write(Lease{})
+	})
+}
+
+// RunWhileLeader は ctx が終わるまでリースを取り合い、leader の間だけ start を動かします
+// start には leader でなくなるとキャンセルされる ctx を渡します
+// リースの読み書きに失敗しても、最後に更新したリースの期限までは leader のままとします（その間はほかのインスタンスも取れません）
+func (e *FileElector) RunWhileLeader(ctx context.Context, start func(ctx context.Context)) {
+	var cancel context.CancelFunc
+	var leaseUntil time.Time
+	stop := func(reason string) {
+		if cancel != nil {
+			cancel()
+			cancel = nil
+			e.logger.Info("leader: no longer the leader", "id", e.ID, "reason", reason)
+		}
+	}
+
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+	for {
+		now := e.now()
+		acquired, err := e.TryAcquire()
+		switch {
+		case err != nil && now.Before(leaseUntil):
+			e.logger.Warn("leader: failed to renew the lease", "id", e.ID, "err", err)
+		case err != nil:
+			stop(err.Error())
+		case !acquired:
+			stop("lease held by another instance")
+		default:
+			leaseUntil = now.Add(e.TTL)
+			if cancel == nil {
+				leaderCtx, leaderCancel := context.WithCancel(ctx)
+				cancel = leaderCancel
+				e.logger.Info("leader: became the leader", "id", e.ID)
+				start(leaderCtx)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			stop("shutting down")
+			e.Release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// withMutex は <Path>.lock を作れたときだけ fn を実行します。古い排他用のファイルは取り除きます
+func (e *FileElector) withMutex(fn func() error) error {
+	mutex := e.Path + ".lock"
+	f, err := os.OpenFile(mutex, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		if info, statErr := os.Stat(mutex); statErr == nil && e.now().Sub(info.ModTime()) > staleMutex {
+			os.Remove(mutex)
+		}
+		return fmt.Errorf("leader: %s is locked by another instance", mutex)
+	}
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer os.Remove(mutex)
+	return fn()
+}
+
+// read はリースを読み込みます。ファイルがなければ空のリースを返します
+func (e *FileElector) read() (Lease, error) {
+	var lease Lease
+	data, err := os.ReadFile(e.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lease, nil
+	}
+	if err != nil {
+		return lease, err
+	}
+	if len(data) == 0 {
+		return lease, nil
+	}
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return lease, fmt.Errorf("leader: %s: %v", e.Path, err)
+	}
+	return lease, nil
+}
+
+// write はリースを一時ファイルに書いてから置き換えます
+func (e *FileElector) write(lease Lease) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(e.Path), filepath.Base(e.Path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), e.Path)
+}
@@ -0,0 +1,182 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestElector は時計を進められる FileElector を作成します
+func newTestElector(path, id string, now *time.Time) *FileElector {
+	e := NewFileElector(path, id, 0)
+	e.now = func() time.Time { return *now }
+	e.logger = slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	return e
+}
+
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.json")
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := newTestElector(path, "a", &now)
+	b := newTestElector(path, "b", &now)
+
+	if ok, err := a.TryAcquire(); !ok || err != nil {
+		t.Fatalf("Expected a to acquire the lease, got %v %v", ok, err)
+	}
+	if ok, err := b.TryAcquire(); ok || err != nil {
+		t.Errorf("Expected b not to acquire a held lease, got %v %v", ok, err)
+	}
+
+	// a は更新し続ける限り leader のままです
+	now = now.Add(DefaultTTL - time.Second)
+	if ok, _ := a.TryAcquire(); !ok {
+		t.Error("Expected a to renew its lease")
+	}
+	now = now.Add(DefaultTTL - time.Second)
+	if ok, _ := b.TryAcquire(); ok {
+		t.Error("Expected the renewed lease to stay with a")
+	}
+
+	// 更新が止まると期限切れのリースを b が引き継ぎます
+	now = now.Add(2 * time.Second)
+	if ok, _ := b.TryAcquire(); !ok {
+		t.Error("Expected b to take over the expired lease")
+	}
+
+	// 手放したリースはすぐに取れます
+	if err := b.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if ok, _ := a.TryAcquire(); !ok {
+		t.Error("Expected a to acquire the released lease")
+	}
+	// 他人のリースは手放せません
+	b.Release()
+	if ok, _ := b.TryAcquire(); ok {
+		t.Error("Expected b's release not to affect a's lease")
+	}
+}
+
+func TestTryAcquireErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "leader.json")
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := newTestElector(path, "a", &now)
+
+	os.WriteFile(path, []byte("{"), 0644)
+	if _, err := e.TryAcquire(); err == nil {
+		t.Error("Expected an error for a corrupted lease")
+	}
+	os.WriteFile(path, nil, 0644)
+	if ok, err := e.TryAcquire(); !ok || err != nil {
+		t.Errorf("Expected an empty lease file to be free, got %v %v", ok, err)
+	}
+
+	// 排他用のファイルがあると取れず、古くなれば取り除かれます
+	os.WriteFile(path+".lock", nil, 0644)
+	if _, err := e.TryAcquire(); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("Expected the mutex to block, got %v", err)
+	}
+	now = time.Now().Add(time.Minute)
+	e.TryAcquire()
+	if ok, err := e.TryAcquire(); !ok || err != nil {
+		t.Errorf("Expected the stale mutex to be removed, got %v %v", ok, err)
+	}
+
+	missing := newTestElector(filepath.Join(dir, "missing", "leader.json"), "a", &now)
+	if _, err := missing.TryAcquire(); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+	os.Mkdir(filepath.Join(dir, "dir.json"), 0755)
+	directory := newTestElector(filepath.Join(dir, "dir.json"), "a", &now)
+	if _, err := directory.TryAcquire(); err == nil {
+		t.Error("Expected an error when the lease path is a directory")
+	}
+}
+
+func TestRunWhileLeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.json")
+	var logs bytes.Buffer
+	a := NewFileElector(path, "a", 30*time.Millisecond)
+	a.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	b := NewFileElector(path, "b", 30*time.Millisecond)
+	b.logger = slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	var mutex sync.Mutex
+	started := map[string]int{}
+	job := func(id string) func(context.Context) {
+		return func(ctx context.Context) {
+			mutex.Lock()
+			started[id]++
+			mutex.Unlock()
+		}
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() { a.RunWhileLeader(ctxA, job("a")); close(doneA) }()
+	time.Sleep(20 * time.Millisecond)
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	doneB := make(chan struct{})
+	go func() { b.RunWhileLeader(ctxB, job("b")); close(doneB) }()
+	defer func() { cancelB(); <-doneB }()
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	if started["a"] != 1 || started["b"] != 0 {
+		t.Errorf("Expected only a to run the jobs, got %v", started)
+	}
+	mutex.Unlock()
+
+	// a が止まるとリースを手放し、b が引き継ぎます
+	cancelA()
+	<-doneA
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	if started["b"] != 1 {
+		t.Errorf("Expected b to take over, got %v", started)
+	}
+	mutex.Unlock()
+	if !strings.Contains(logs.String(), `msg="leader: became the leader" id=a`) || !strings.Contains(logs.String(), `msg="leader: no longer the leader" id=a reason="shutting down"`) {
+		t.Errorf("Unexpected logs %q", logs.String())
+	}
+}
+
+func TestRunWhileLeaderLosesLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.json")
+	var logs bytes.Buffer
+	e := NewFileElector(path, "a", 30*time.Millisecond)
+	e.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	lost := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.RunWhileLeader(ctx, func(ctx context.Context) {
+			go func() { <-ctx.Done(); close(lost) }()
+			// ほかのインスタンスがリースを奪ったことにします
+			other := NewFileElector(path, "b", time.Hour)
+			other.write(Lease{Holder: "b", Expires: time.Now().Add(time.Hour)})
+		})
+	}()
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the job context to be canceled when the lease is lost")
+	}
+	cancel()
+	<-done
+	if !strings.Contains(logs.String(), "lease held by another instance") {
+		t.Errorf("Unexpected logs %q", logs.String())
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.sock")
+
+	listener, err := listen("unix:"+path, 0660)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0660 {
+		t.Fatalf("Expected a socket with mode 0660, got %v (%v)", info, err)
+	}
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect to the socket: %v", err)
+	}
+	conn.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+	buf := make([]byte, 64)
+	n, _ := conn.Read(buf)
+	conn.Close()
+	if n == 0 {
+		t.Error("Expected a response over the socket")
+	}
+	listener.Close()
+
+	// 前回の起動で残ったソケットは取り除いて作り直します
+	stale, _ := net.Listen("unix", path)
+	if l, ok := stale.(*net.UnixListener); ok {
+		l.SetUnlinkOnClose(false)
+	}
+	stale.Close()
+	listener, err = listen("unix:"+path, 0600)
+	if err != nil {
+		t.Fatalf("Expected a stale socket to be replaced, got %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "not-a-socket")
+	os.WriteFile(file, []byte("data"), 0644)
+
+	if _, err := listen("unix:"+file, 0660); err == nil {
+		t.Error("Expected an error for a regular file")
+	}
+	if data, _ := os.ReadFile(file); string(data) != "data" {
+		t.Error("Expected the regular file to be kept")
+	}
+	if _, err := listen("unix:", 0660); err == nil {
+		t.Error("Expected an error for an empty path")
+	}
+	if _, err := listen("unix:"+filepath.Join(dir, "missing", "todo.sock"), 0660); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	listener, err := listen("127.0.0.1:0", 0660)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	listener.Close()
+}
+
+func TestParseSocketMode(t *testing.T) {
+	if mode, err := parseSocketMode("0660"); err != nil || mode != 0660 {
+		t.Errorf("Unexpected mode %v (%v)", mode, err)
+	}
+	for _, s := range []string{"", "rw", "0999", "1777"} {
+		if _, err := parseSocketMode(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestListenURL(t *testing.T) {
+	testCases := map[string]string{
+		":8080":               "http://localhost:8080",
+		"127.0.0.1:9000":      "http://127.0.0.1:9000",
+		"[::]:8080":           "http://localhost:8080",
+		"unix:/run/todo.sock": "unix:/run/todo.sock",
+		"invalid":             "invalid",
+	}
+	for addr, want := range testCases {
+		if got := listenURL(addr, false); got != want {
+			t.Errorf("listenURL(%q) = %q, expected %q", addr, got, want)
+		}
+	}
+	if got := listenURL(":8443", true); got != "https://localhost:8443" {
+		t.Errorf("Expected an https URL with TLS, got %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+// Package lists はタスクを分けて入れるリスト（「仕事」「買い物」など）を管理します
+//
+// リストの名前だけを保持し、タスクがどのリストに入っているかはタスクの ListID で表します
+package lists
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"todo-app/models"
+)
+
+// ErrListNotFound は指定した ID のリストがないときのエラーです
+var ErrListNotFound = errors.New("list not found")
+
+// maxLists は作れるリストの最大数です
+const maxLists = 100
+
+// maxListName はリストの名前の最大の長さ（文字数）です
+const maxListName = 50
+
+// List はタスクを分けて入れるリストです
+type List struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store はリストを保持し、path が空でなければ JSON ファイルに保存します
+type Store struct {
+	path   string
+	now    func() time.Time
+	mutex  sync.Mutex
+	lists  []List
+	nextID int
+}
+
+// NewStore は path のファイルからリストを読み込んで Store を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければリストのない Store から始めます
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, now: time.Now, nextID: 1}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		NextID int    `json:"next_id"`
+		Lists  []List `json:"lists"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	s.lists = file.Lists
+	if file.NextID > s.nextID {
+		s.nextID = file.NextID
+	}
+	return s, nil
+}
+
+// validateName はリストの名前の前後の空白を取り除き、長さを確かめます
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxListName {
+		return "", fmt.Errorf("%w: list name must be 1 to %d characters", models.ErrValidation, maxListName)
+	}
+	return name, nil
+}
+
+// List は作成した順にすべてのリストを返します
+func (s *Store) List() []List {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]List{}, s.lists...)
+}
+
+// Get は id のリストを返します。なければ ErrListNotFound を返します
+func (s *Store) Get(id int) (List, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i, err := s.index(id)
+	if err != nil {
+		return List{}, err
+	}
+	return s.lists[i], nil
+}
+
+// Create は name のリストを作成します
+// 名前が空か長すぎれば ErrValidation を、同じ名前のリストがあるか上限に達していれば ErrConflict を返します
+func (s *Store) Create(name string) (List, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return List{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.lists) >= maxLists {
+		return List{}, fmt.Errorf("%w: at most %d lists", models.ErrConflict, maxLists)
+	}
+	if err := s.checkUnique(name, 0); err != nil {
+		return List{}, err
+	}
+	list := List{ID: s.nextID, Name: name, CreatedAt: s.now().UTC()}
+	s.lists = append(s.lists, list)
+	s.nextID++
+	if err := s.save(); err != nil {
+		s.lists = s.lists[:len(s.lists)-1]
+		s.nextID--
+		return List{}, err
+	}
+	return list, nil
+}
+
+// Rename は id のリストの名前を name に変えます
+func (s *Store) Rename(id int, name string) (List, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return List{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i, err := s.index(id)
+	if err != nil {
+		return List{}, err
+	}
+	if err := s.checkUnique(name, id); err != nil {
+		return List{}, err
+	}
+	old := s.lists[i].Name
+	s.lists[i].Name = name
+	if err := s.save(); err != nil {
+		s.lists[i].Name = old
+		return List{}, err
+	}
+	return s.lists[i], nil
+}
+
+// Delete は id のリストを削除します。リストに入っていたタスクはそのまま残るため、呼び出し側でリストから外します
+func (s *Store) Delete(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i, err := s.index(id)
+	if err != nil {
+		return err
+	}
+	removed := s.lists[i]
+	s.lists = append(s.lists[:i:i], s.lists[i+1:]...)
+	if err := s.save(); err != nil {
+		s.lists = append(s.lists[:i], append([]List{removed}, s.lists[i:]...)...)
+		return err
+	}
+	return nil
+}
+
+// index は id のリストの位置を返します。ロック中に呼び出します
+func (s *Store) index(id int) (int, error) {
+	for i, list := range s.lists {
+		if list.ID == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: id %d", ErrListNotFound, id)
+}
+
+// checkUnique は except 以外に name と同じ名前（大文字と小文字は区別しません）のリストがないことを確かめます。ロック中に呼び出します
+func (s *Store) checkUnique(name string, except int) error {
+	for _, list := range s.lists {
+		if list.ID != except && strings.EqualFold(list.Name, name) {
+			return fmt.Errorf("%w: list %q already exists", models.ErrConflict, list.Name)
+		}
+	}
+	return nil
+}
+
+// save はリストを一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"next_id": s.nextID,
+		"lists":   s.lists,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,95 @@
+package lists
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"todo-app/models"
+)
+
+func TestStore(t *testing.T) {
+	s, _ := NewStore("")
+
+	work, err := s.Create(" Work ")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if work.ID != 1 || work.Name != "Work" || work.CreatedAt.IsZero() {
+		t.Errorf("Unexpected list: %+v", work)
+	}
+	groceries, _ := s.Create("Groceries")
+
+	if _, err := s.Create("work"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected a conflict for a duplicate name, got %v", err)
+	}
+	for _, name := range []string{"", "  ", strings.Repeat("あ", maxListName+1)} {
+		if _, err := s.Create(name); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Create(%q): expected a validation error, got %v", name, err)
+		}
+	}
+
+	if got, err := s.Get(groceries.ID); err != nil || got != groceries {
+		t.Errorf("Expected to get the list, got %+v %v", got, err)
+	}
+	if _, err := s.Get(99); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+
+	renamed, err := s.Rename(work.ID, "Office")
+	if err != nil || renamed.Name != "Office" {
+		t.Errorf("Expected the list to be renamed, got %+v %v", renamed, err)
+	}
+	if _, err := s.Rename(work.ID, "office"); err != nil {
+		t.Errorf("Expected a list to keep its own name with a different case, got %v", err)
+	}
+	if _, err := s.Rename(work.ID, "Groceries"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected a conflict when renaming to another list's name, got %v", err)
+	}
+	if _, err := s.Rename(99, "Other"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+
+	if err := s.Delete(work.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := s.Delete(work.ID); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound for a deleted list, got %v", err)
+	}
+	if lists := s.List(); len(lists) != 1 || lists[0].ID != groceries.ID {
+		t.Errorf("Expected only the groceries list, got %+v", lists)
+	}
+	// 削除したリストの ID は再利用しません
+	if next, _ := s.Create("Work"); next.ID != 3 {
+		t.Errorf("Expected IDs not to be reused, got %d", next.ID)
+	}
+}
+
+func TestStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lists.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	work, _ := s.Create("Work")
+	s.Create("Groceries")
+	s.Delete(work.ID)
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if lists := reloaded.List(); len(lists) != 1 || lists[0].Name != "Groceries" {
+		t.Errorf("Expected the lists to survive a reload, got %+v", lists)
+	}
+	if next, _ := reloaded.Create("Home"); next.ID != 3 {
+		t.Errorf("Expected IDs to continue after a reload, got %d", next.ID)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0644)
+	if _, err := NewStore(path); err == nil {
+		t.Error("Expected an error for a corrupt file")
+	}
+}
@@ -0,0 +1,149 @@
+// Package lockout は認証の失敗を数え、続けて失敗した相手を一時的に締め出します
+package lockout
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// 既定の設定です
+// DefaultThreshold: 締め出すまでに許す連続した失敗の回数
+// DefaultBaseDelay: 初めて締め出すときの時間（以降は失敗のたびに2倍）
+// DefaultMaxDelay: 締め出す時間の上限
+// DefaultForgetAfter: 最後の失敗からこれだけ経つと失敗の回数を忘れます
+// DefaultMaxRecords: 覚えておくキーの数の上限
+const (
+	DefaultThreshold   = 5
+	DefaultBaseDelay   = time.Minute
+	DefaultMaxDelay    = time.Hour
+	DefaultForgetAfter = 24 * time.Hour
+	DefaultMaxRecords  = 100000
+)
+
+// Limiter はキー（IP アドレスやアカウント）ごとの連続した失敗を記録します
+// Threshold 回失敗すると BaseDelay だけ締め出し、その後も失敗するたびに締め出す時間を2倍にします（MaxDelay まで）
+// 記録は最後に失敗した順に並べておき、古いものから忘れます。MaxRecords を超えると一番古い記録を捨てます
+type Limiter struct {
+	Threshold   int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	ForgetAfter time.Duration
+	MaxRecords  int
+
+	now     func() time.Time
+	mutex   sync.Mutex
+	records map[string]*list.Element
+	order   *list.List
+}
+
+// record は1つのキーの失敗の記録です
+type record struct {
+	key         string
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// New は既定の設定の Limiter を作成します
+func New() *Limiter {
+	return &Limiter{
+		Threshold:   DefaultThreshold,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		ForgetAfter: DefaultForgetAfter,
+		MaxRecords:  DefaultMaxRecords,
+		now:         time.Now,
+		records:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Allow は key が締め出されていないかを返します。締め出されていれば解除までの時間を返します
+func (l *Limiter) Allow(key string) (retryAfter time.Duration, ok bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	l.forget(now)
+	elem, exists := l.records[key]
+	if !exists {
+		return 0, true
+	}
+	rec := elem.Value.(*record)
+	if !now.Before(rec.lockedUntil) {
+		return 0, true
+	}
+	return rec.lockedUntil.Sub(now), false
+}
+
+// Failures は key の連続した失敗の回数を返します
+func (l *Limiter) Failures(key string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.forget(l.now())
+	if elem, exists := l.records[key]; exists {
+		return elem.Value.(*record).failures
+	}
+	return 0
+}
+
+// Fail は key の失敗を記録し、締め出す時間（まだ締め出さなければ 0）と連続した失敗の回数を返します
+func (l *Limiter) Fail(key string) (lockedFor time.Duration, failures int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	l.forget(now)
+	elem, exists := l.records[key]
+	if exists {
+		l.order.MoveToBack(elem)
+	} else {
+		if l.MaxRecords > 0 && l.order.Len() >= l.MaxRecords {
+			l.remove(l.order.Front())
+		}
+		elem = l.order.PushBack(&record{key: key})
+		l.records[key] = elem
+	}
+	rec := elem.Value.(*record)
+	rec.failures++
+	rec.lastFailure = now
+	if rec.failures < l.Threshold {
+		return 0, rec.failures
+	}
+
+	delay := l.BaseDelay
+	for i := l.Threshold; i < rec.failures && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	rec.lockedUntil = now.Add(delay)
+	return delay, rec.failures
+}
+
+// Succeed は key の失敗の記録を消します。認証に成功したときに呼びます
+func (l *Limiter) Succeed(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if elem, exists := l.records[key]; exists {
+		l.remove(elem)
+	}
+}
+
+// forget は最後の失敗から ForgetAfter 以上経った記録を古いものから消します（呼び出し元がロックを持ちます）
+// 記録は最後に失敗した順に並んでいるので、忘れられない記録が見つかった所で止めます
+func (l *Limiter) forget(now time.Time) {
+	for elem := l.order.Front(); elem != nil; elem = l.order.Front() {
+		rec := elem.Value.(*record)
+		if now.Sub(rec.lastFailure) < l.ForgetAfter || now.Before(rec.lockedUntil) {
+			return
+		}
+		l.remove(elem)
+	}
+}
+
+// remove は elem の記録を消します（呼び出し元がロックを持ちます）
+func (l *Limiter) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.records, elem.Value.(*record).key)
+}
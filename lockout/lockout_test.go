@@ -0,0 +1,103 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter は時計を進められる Limiter を作成します
+func newTestLimiter() (*Limiter, *time.Time) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := New()
+	l.Threshold = 3
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterLocksOutWithBackoff(t *testing.T) {
+	l, now := newTestLimiter()
+
+	for i := 1; i < 3; i++ {
+		if lockedFor, failures := l.Fail("1.2.3.4"); lockedFor != 0 || failures != i {
+			t.Fatalf("Expected no lockout after %d failures, got %s (%d)", i, lockedFor, failures)
+		}
+	}
+	if lockedFor, _ := l.Fail("1.2.3.4"); lockedFor != time.Minute {
+		t.Errorf("Expected a 1 minute lockout, got %s", lockedFor)
+	}
+	if retryAfter, ok := l.Allow("1.2.3.4"); ok || retryAfter != time.Minute {
+		t.Errorf("Expected to be locked out for 1 minute, got %s %v", retryAfter, ok)
+	}
+	if _, ok := l.Allow("5.6.7.8"); !ok {
+		t.Error("Expected other keys to be allowed")
+	}
+
+	*now = now.Add(time.Minute)
+	if _, ok := l.Allow("1.2.3.4"); !ok {
+		t.Error("Expected the lockout to expire")
+	}
+	if lockedFor, _ := l.Fail("1.2.3.4"); lockedFor != 2*time.Minute {
+		t.Errorf("Expected the lockout to double, got %s", lockedFor)
+	}
+	for i := 0; i < 10; i++ {
+		l.Fail("1.2.3.4")
+	}
+	if lockedFor, failures := l.Fail("1.2.3.4"); lockedFor != time.Hour || failures != 15 {
+		t.Errorf("Expected the lockout to be capped at 1 hour, got %s (%d)", lockedFor, failures)
+	}
+}
+
+func TestLimiterSucceedAndForget(t *testing.T) {
+	l, now := newTestLimiter()
+
+	l.Fail("a")
+	l.Fail("a")
+	l.Succeed("a")
+	if lockedFor, failures := l.Fail("a"); lockedFor != 0 || failures != 1 {
+		t.Errorf("Expected success to reset the failures, got %s (%d)", lockedFor, failures)
+	}
+
+	l.Fail("a")
+	*now = now.Add(DefaultForgetAfter)
+	if _, failures := l.Fail("a"); failures != 1 {
+		t.Errorf("Expected old failures to be forgotten, got %d", failures)
+	}
+}
+
+func TestLimiterForgetsOldestFirst(t *testing.T) {
+	l, now := newTestLimiter()
+
+	l.Fail("a")
+	*now = now.Add(time.Hour)
+	l.Fail("b")
+	l.Fail("b")
+	*now = now.Add(DefaultForgetAfter - time.Hour)
+	if failures := l.Failures("a"); failures != 0 {
+		t.Errorf("Expected a to be forgotten, got %d failures", failures)
+	}
+	if failures := l.Failures("b"); failures != 2 {
+		t.Errorf("Expected b to be remembered, got %d failures", failures)
+	}
+	if l.order.Len() != 1 || len(l.records) != 1 {
+		t.Errorf("Expected 1 record, got %d (%d)", l.order.Len(), len(l.records))
+	}
+}
+
+func TestLimiterMaxRecords(t *testing.T) {
+	l, _ := newTestLimiter()
+	l.MaxRecords = 2
+
+	l.Fail("a")
+	l.Fail("b")
+	l.Fail("a")
+	l.Fail("c")
+	if failures := l.Failures("b"); failures != 0 {
+		t.Errorf("Expected the oldest record to be evicted, got %d failures", failures)
+	}
+	if failures := l.Failures("a"); failures != 2 {
+		t.Errorf("Expected a recent failure to keep a, got %d failures", failures)
+	}
+	if len(l.records) != 2 {
+		t.Errorf("Expected 2 records, got %d", len(l.records))
+	}
+}
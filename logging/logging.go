@@ -0,0 +1,217 @@
+// Package logging は log/slog による構造化ログの出力先と、HTTP のリクエストを1件ずつ記録するミドルウェアです
+// リクエストには ID を付けてコンテキストで引き継ぎ、そのコンテキストで書いたログにも同じ ID（request_id）を付けます
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ログの形式です（log_format）
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RequestIDHeader はリクエストの ID を受け取り、応答で返すヘッダです
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength は受け付けるリクエストの ID の長さの上限です。これより長いものは使わずに新しく作ります
+const maxRequestIDLength = 128
+
+// requestIDKey はリクエストの ID をコンテキストに入れるためのキーです
+type requestIDKey struct{}
+
+// WithRequestID はリクエストの ID id を持つコンテキストを返します
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID はコンテキストのリクエストの ID を返します（なければ空）
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ParseLevel は "debug"・"info"・"warn"・"error" をログの詳しさに変換します
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
+
+// New は level 以上のログを format（text なら key=value、json なら1行1つの JSON）で w に書き出す Logger を作成します
+// InfoContext などに渡したコンテキストにリクエストの ID があれば、request_id として付けます
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatText, "":
+		return slog.New(contextHandler{slog.NewTextHandler(w, options)}), nil
+	case FormatJSON:
+		return slog.New(contextHandler{slog.NewJSONHandler(w, options)}), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// contextHandler はコンテキストのリクエストの ID をログに付ける slog.Handler です
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle はコンテキストにリクエストの ID があれば request_id を付けて書き出します
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs は attrs を付けた contextHandler を返します
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup は name のグループに入れる contextHandler を返します
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Discard はログを捨てる Logger を返します（テスト用）
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Requests はリクエストを next に渡し、終わったら1件ずつ記録するミドルウェアです
+// メソッド・パス・ステータス・応答の大きさ・かかった時間・リクエストの ID を記録します
+// 5xx はエラー、それ以外は info として記録します。WebSocket や Server-Sent Events は接続が閉じたときに記録します
+// リクエストの ID はクライアントが X-Request-ID で送ったもの（英数字と - _ . : の128文字まで）を使い、なければ作ります
+// ID は応答の X-Request-ID で返し、コンテキストに入れて next へ渡します（RequestID で取り出せます）
+func Requests(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(WithRequestID(r.Context(), id))
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", recorder.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// validRequestID は id がクライアントから受け取ったリクエストの ID として使えるかどうかを返します
+// ログに混ぜても読み違えないよう、英数字と - _ . : だけを受け付けます
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID はリクエストを見分けるためのランダムな ID（16文字の16進数）を作成します
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder は応答のステータスと大きさを記録する http.ResponseWriter です
+// Server-Sent Events と WebSocket のため、Flush と Hijack は元の ResponseWriter へそのまま渡します
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader はステータスを記録してから書き込みます
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write は書き込んだ大きさを記録します。WriteHeader を呼ばずに書き込んだ場合のステータスは 200 です
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush は書き込んだ内容をすぐにクライアントへ送ります
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap は元の ResponseWriter を返します（http.ResponseController で書き込みの期限などを設定するため）
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Hijack は接続を WebSocket などに引き渡します。引き渡した接続はステータスを 101 として記録します
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking is not supported by the response writer")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
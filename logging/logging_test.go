@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", FormatJSON)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "task_id", 3)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "shown" || entry["level"] != "WARN" || entry["task_id"] != float64(3) {
+		t.Errorf("Unexpected log entry %v", entry)
+	}
+
+	if _, err := New(&buf, "verbose", FormatText); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if _, err := New(&buf, "info", "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestRequests(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "info", FormatText)
+	handler := Requests(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/tasks?limit=1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/fail", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per request, got %q", buf.String())
+	}
+	for _, want := range []string{"level=INFO", "msg=request", "method=GET", "path=/api/tasks", "status=200", "bytes=5", "duration=", "request_id="} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("Expected %q in %q", want, lines[0])
+		}
+	}
+	if !strings.Contains(lines[1], "level=ERROR") || !strings.Contains(lines[1], "status=500") {
+		t.Errorf("Expected server errors to be logged as errors, got %q", lines[1])
+	}
+}
+
+func TestResponseRecorderPassesThrough(t *testing.T) {
+	var w http.ResponseWriter = &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	if _, ok := w.(http.Flusher); !ok {
+		t.Error("Expected the recorder to support flushing")
+	}
+	if _, _, err := w.(http.Hijacker).Hijack(); err == nil {
+		t.Error("Expected an error when the underlying writer cannot be hijacked")
+	}
+
+	inner := &deadlineWriter{ResponseRecorder: httptest.NewRecorder()}
+	w = &responseRecorder{ResponseWriter: inner}
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil || !inner.called {
+		t.Errorf("Expected the response controller to reach the underlying writer, got %v", err)
+	}
+}
+
+// deadlineWriter は書き込みの期限の設定を記録する ResponseWriter です
+type deadlineWriter struct {
+	*httptest.ResponseRecorder
+	called bool
+}
+
+func (w *deadlineWriter) SetWriteDeadline(time.Time) error {
+	w.called = true
+	return nil
+}
+
+func TestRequestsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "info", FormatText)
+	var seen string
+	handler := Requests(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		logger.WarnContext(r.Context(), "inside")
+	}))
+
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set(RequestIDHeader, "client-42")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if seen != "client-42" || rr.Header().Get(RequestIDHeader) != "client-42" {
+		t.Errorf("Expected the incoming request ID to be used, got %q and header %q", seen, rr.Header().Get(RequestIDHeader))
+	}
+	if strings.Count(buf.String(), "request_id=client-42") != 2 {
+		t.Errorf("Expected both log lines to carry the request ID, got %q", buf.String())
+	}
+
+	for _, incoming := range []string{"", "has space", "line\nbreak", strings.Repeat("a", maxRequestIDLength+1)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if seen == incoming || len(seen) != 16 || rr.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("Expected a new request ID instead of %q, got %q", incoming, seen)
+		}
+	}
+}
+
+func TestContextHandlerWithoutRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "info", FormatJSON)
+	logger.With("component", "test").Info("started")
+	if strings.Contains(buf.String(), "request_id") || !strings.Contains(buf.String(), `"component":"test"`) {
+		t.Errorf("Unexpected log entry %q", buf.String())
+	}
+}
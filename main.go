@@ -1,45 +1,544 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"todo-app/accounts"
+	"todo-app/config"
+	"todo-app/crdt"
+	"todo-app/e2ee"
 	"todo-app/handlers"
+	"todo-app/integrations/llm"
+	"todo-app/lists"
+	"todo-app/lockout"
+	"todo-app/logging"
+	"todo-app/models"
+	"todo-app/plugins"
+	"todo-app/recurrence"
+	"todo-app/reminders"
+	"todo-app/rules"
+	"todo-app/share"
+	"todo-app/store"
+	"todo-app/store/filestore"
+	"todo-app/trash"
+	"todo-app/webhooks"
+	"todo-app/workspace"
 )
 
-func homeHandler(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, filepath.Join("static", "index.html"))
+// trashPurgeInterval はごみ箱から保持する期間を過ぎたタスクを探して削除する間隔です
+const trashPurgeInterval = time.Hour
+
+// recurrenceInterval は完了した繰り返すタスクを探し、次の回のタスクを作る間隔です。完了したときはイベントを受けてすぐに作ります
+const recurrenceInterval = 10 * time.Minute
+
+// reminderInterval はリマインダーを確かめる最長の間隔です。ふだんは次のリマインダーの日時に合わせて通知します
+const reminderInterval = time.Minute
+
+// openStore は設定（cfg.Store）に応じてタスクの保存先を準備します
+func openStore(cfg config.Config) models.TaskStore {
+	store, err := openStoreIn(cfg.Store)
+	if err != nil {
+		fatal("保存先を開けませんでした", "driver", cfg.Store.Driver, "dsn", cfg.Store.DSN, "err", err)
+	}
+	return store
 }
 
-func main() {
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-	
-	http.HandleFunc("/", homeHandler)
-	
-	http.HandleFunc("/api/tasks", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handlers.GetTasksHandler(w, r)
-		} else if r.Method == http.MethodPost {
-			handlers.AddTaskHandler(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// openStoreIn は settings のドライバでその DSN にタスクを保存するストアを準備します
+func openStoreIn(settings config.Store) (models.TaskStore, error) {
+	storeConfig := store.Config{DSN: settings.DSN, Options: todoOptions(settings)}
+	if settings.Driver == gitDriverName {
+		storeConfig.Params = map[string]string{
+			"remote": settings.GitRemote,
+			"branch": settings.GitBranch,
 		}
-	})
-	
-	http.HandleFunc("/api/tasks/", func(w http.ResponseWriter, r *http.Request) {
-		// /api/tasks/{id}/toggle か /api/tasks/{id} (DELETE) を振り分け
-		if r.URL.Path[len(r.URL.Path)-7:] == "/toggle" {
-			handlers.ToggleTaskHandler(w, r)
-		} else {
-			handlers.DeleteTaskHandler(w, r)
+	}
+	return store.Open(settings.Driver, storeConfig)
+}
+
+// scopedDSN はワークスペースやユーザー（scope が "workspaces" や "users"）ごとのタスクを保存する場所を、全体の保存先 dsn の隣に決めます
+// git は {dsn}/{scope}/{name} のリポジトリ、file は {dsn のディレクトリ}/{scope}/{name}.json です
+// それ以外のドライバ（データベースなど）は同じ DSN を使うため、ワークスペースやユーザーごとに分けられません
+func scopedDSN(driver, dsn, scope, name string) string {
+	switch {
+	case dsn == "":
+		return ""
+	case driver == gitDriverName:
+		return filepath.Join(dsn, scope, name)
+	case driver == filestore.DriverName:
+		return filepath.Join(filepath.Dir(dsn), scope, name+".json")
+	}
+	return dsn
+}
+
+// todoOptions は保存先の設定から TodoApp のオプションを組み立てます
+func todoOptions(settings config.Store) []models.Option {
+	var options []models.Option
+	if settings.MaxTasks > 0 {
+		options = append(options, models.WithMaxTasks(settings.MaxTasks))
+	}
+	return options
+}
+
+// openE2EKeys は e2e_key_file が設定されていれば、タイトルを暗号化するモードの鍵の情報を保存する KeyStore を返します
+// 設定されていなければ nil を返し、平文のタイトルを扱います
+func openE2EKeys(cfg config.Config) *e2ee.KeyStore {
+	path := cfg.E2EKeyFile
+	if path == "" {
+		return nil
+	}
+	keys, err := e2ee.NewKeyStore(path)
+	if err != nil {
+		fatal("暗号化の鍵の情報を読み込めませんでした", "path", path, "err", err)
+	}
+	return keys
+}
+
+// listsPath はタスクのリストを保存するファイルを返します。lists_file が設定されていなければ空（メモリ上だけ）です
+// ワークスペースやユーザー（scope の name）のリストは、lists_file の隣の {scope}/{name}.lists.json に保存します
+func listsPath(cfg config.Config, scope, name string) string {
+	path := cfg.ListsFile
+	if path == "" || scope == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), scope, name+".lists.json")
+}
+
+// sharesPath はリストの共有リンクを保存するファイルを返します。lists_file が設定されていなければ空（メモリ上だけ）です
+// 共有リンクはリストごとに発行するため、lists_file の隣の shares.json（ワークスペースやユーザーは {scope}/{name}.shares.json）に保存します
+func sharesPath(cfg config.Config, scope, name string) string {
+	if cfg.ListsFile == "" {
+		return ""
+	}
+	if scope == "" {
+		return filepath.Join(filepath.Dir(cfg.ListsFile), "shares.json")
+	}
+	return filepath.Join(filepath.Dir(cfg.ListsFile), scope, name+".shares.json")
+}
+
+// openSyncer は sync_state_file が設定されていれば、端末とタスクを CRDT で同期する Syncer を返します
+// 設定されていなければ nil を返し、同期のエンドポイントを無効にします
+func openSyncer(cfg config.Config, store models.TaskStore) *crdt.Syncer {
+	path := cfg.SyncStateFile
+	if path == "" {
+		return nil
+	}
+	syncer, err := crdt.NewSyncer(path, store)
+	if err != nil {
+		fatal("同期の状態を読み込めませんでした", "path", path, "err", err)
+	}
+	return syncer
+}
+
+// devTemplateDir は開発モードで画面のテンプレートを読み込むディレクトリです（リポジトリのルートで go run . -dev を実行する前提です）
+const devTemplateDir = "handlers/templates"
+
+// subscribeServices はタスクの変更に反応する Webhook・自動化ルール・コマンドフックを store に購読させ、
+// Webhook の登録先と Dispatcher、ルールの登録先を返します
+// ルールの move_to_list の移動先は taskLists から確かめます
+// Webhook の配信は queue（nil ならメモリ上）に積み、送信に失敗したものを ctx がキャンセルされるまで cfg.WebhookRetryInterval ごとに再送します
+func subscribeServices(ctx context.Context, cfg config.Config, store models.TaskStore, taskLists *lists.Store, queue *webhooks.Queue) (*webhooks.Store, *webhooks.Dispatcher, *rules.Store) {
+	// タスクの変更を登録済みの Webhook へ通知
+	hooks := webhooks.NewStore()
+	dispatcher := webhooks.NewDispatcher(hooks, queue, nil)
+	store.Subscribe(dispatcher.HandleEvent)
+	go dispatcher.Run(ctx, cfg.WebhookRetryInterval)
+
+	// 登録された自動化ルールをタスクの変更に適用
+	ruleStore := rules.NewStore()
+	store.Subscribe(rules.NewEngine(ruleStore, store, taskLists).HandleEvent)
+
+	// 設定ファイルのコマンドフックをタスクの変更で実行
+	if runner := newExecHooks(cfg); runner != nil {
+		store.Subscribe(runner.HandleEvent)
+	}
+	return hooks, dispatcher, ruleStore
+}
+
+// outboxRelay は変更と同じコミットに書き出したイベントを、後から配信し直せるストアです（gitstore.Store）
+type outboxRelay interface {
+	Relay() (int, error)
+}
+
+// relayOutbox は前回の終了までに配信を終えていないイベントを、購読者をそろえた後で配信します
+func relayOutbox(store models.TaskStore) {
+	relay, ok := store.(outboxRelay)
+	if !ok {
+		return
+	}
+	n, err := relay.Relay()
+	if err != nil {
+		slog.Error("outbox: failed to relay events", "err", err)
+	}
+	if n > 0 {
+		slog.Info("outbox: relayed unpublished events", "count", n)
+	}
+}
+
+// storeChecker は変更を保存できているか確かめられるストアです（gitstore.Store）
+type storeChecker interface {
+	Check(ctx context.Context) error
+}
+
+// checkStore は store が保存できているか確かめられれば、その関数を返します（確かめられなければ nil）
+func checkStore(store models.TaskStore) func(context.Context) error {
+	if checker, ok := store.(storeChecker); ok {
+		return checker.Check
+	}
+	return nil
+}
+
+// openWebhookQueue は webhook_queue_file が設定されていれば、そのファイルに保存する Webhook の配信のキューを返します
+// 設定されていなければ nil を返し、配信のキューはメモリ上だけに持ちます
+func openWebhookQueue(cfg config.Config) *webhooks.Queue {
+	path := cfg.WebhookQueueFile
+	if path == "" {
+		return nil
+	}
+	queue, err := webhooks.NewQueue(path, webhooks.DefaultRetryPolicy)
+	if err != nil {
+		fatal("Webhook の配信のキューを読み込めませんでした", "path", path, "err", err)
+	}
+	return queue
+}
+
+// newScopedServer は scope の name（ワークスペースやユーザー）ごとに、独立した保存先・Webhook・ルールを持つサーバを作成します
+// 保存先が git か file の場合は、タスクを全体の保存先の隣の {scope}/{name} に保存します（scopedDSN）
+// 管理用トークンの失敗の記録 attempts と LLM の suggester は全体で共有します。Webhook の再送・ごみ箱の古いタスクの削除・繰り返すタスクの次の回の作成・リマインダーの通知・定期バックアップは ctx がキャンセルされるまで続け、キャンセルされたら WebSocket と SSE の接続を閉じます
+func newScopedServer(ctx context.Context, cfg config.Config, handlerConfig handlers.Config, attempts *lockout.Limiter, suggester *llm.Suggester, scope, name string) (http.Handler, error) {
+	settings := cfg.Store
+	settings.DSN = scopedDSN(settings.Driver, settings.DSN, scope, name)
+	base, err := openStoreIn(settings)
+	if err != nil {
+		return nil, err
+	}
+	taskLists, err := lists.NewStore(listsPath(cfg, scope, name))
+	if err != nil {
+		return nil, err
+	}
+	shares, err := share.NewStore(sharesPath(cfg, scope, name))
+	if err != nil {
+		return nil, err
+	}
+	store := plugins.Wrap(base, plugins.Registered()...)
+	hooks, dispatcher, ruleStore := subscribeServices(ctx, cfg, store, taskLists, nil)
+	relayOutbox(base)
+
+	// 定期バックアップは全体と同じ保存先に、{scope}-{name}. で始まる名前で分けて保存します
+	backups := newBackupManager(cfg, store, scope+"-"+name)
+
+	// ごみ箱の古いタスクの削除・繰り返すタスクの次の回の作成・リマインダーの通知・定期バックアップ
+	jobs := func(ctx context.Context) {
+		// ユーザーのタスクは、そのユーザーの Jira の認証情報で同期します
+		if scope == "users" {
+			startJiraSyncFor(ctx, store, cfg, name, integrationStatePath(settings, name+".jira"))
+		}
+		go trash.Run(ctx, store, cfg.TrashRetention, trashPurgeInterval)
+		go recurrence.Run(ctx, store, recurrenceInterval)
+		go reminders.Run(ctx, store, reminderInterval)
+		if backups != nil {
+			go backups.Run(ctx, cfg.Backup.Interval)
+		}
+	}
+	// 複数のインスタンスで動かす場合は、全体の処理と同じく leader に選ばれたインスタンスだけで動かします（リースはワークスペースやユーザーごとです）
+	if elector := newElector(cfg, scope+"-"+name); elector != nil {
+		go elector.RunWhileLeader(ctx, jobs)
+	} else {
+		jobs(ctx)
+	}
+
+	return handlers.NewServer(handlers.Deps{
+		Store:         store,
+		Lists:         taskLists,
+		Shares:        shares,
+		Webhooks:      hooks,
+		Deliveries:    dispatcher,
+		Rules:         ruleStore,
+		AdminAttempts: attempts,
+		Logger:        slog.Default(),
+		Config:        handlerConfig,
+		Static:        staticFS(cfg),
+		Backups:       backups,
+		Suggester:     suggester,
+		Stopping:      ctx,
+		CheckStore:    checkStore(base),
+	}), nil
+}
+
+// newWorkspaceHandler はワークスペースごとのサーバ（保存先は workspaces/{slug}）を作る関数を返します
+func newWorkspaceHandler(ctx context.Context, cfg config.Config, handlerConfig handlers.Config, attempts *lockout.Limiter, suggester *llm.Suggester) workspace.HandlerFunc {
+	return func(ws workspace.Workspace) (http.Handler, error) {
+		handlerConfig.BasePath = ws.Path()
+		return newScopedServer(ctx, cfg, handlerConfig, attempts, suggester, "workspaces", ws.Slug)
+	}
+}
+
+// newUserHandler はユーザーごとのサーバ（保存先は users/{ID}）を作る関数を返します
+// ユーザーのサーバはトップレベルの URL のまま使うため、BasePath は変えません
+func newUserHandler(ctx context.Context, cfg config.Config, handlerConfig handlers.Config, attempts *lockout.Limiter, suggester *llm.Suggester) accounts.HandlerFunc {
+	return func(user accounts.User) (http.Handler, error) {
+		return newScopedServer(ctx, cfg, handlerConfig, attempts, suggester, "users", strconv.Itoa(user.ID))
+	}
+}
+
+// openAccounts は users_file が設定されていれば、そのファイルにユーザーを保存する accounts.Store を返します
+// 設定されていなければ nil を返し、ユーザーアカウントを無効にします（すべての人が同じタスクを扱います）
+// パスワードの条件は cfg.Password です
+func openAccounts(cfg config.Config) *accounts.Store {
+	path := cfg.UsersFile
+	if path == "" {
+		return nil
+	}
+	users, err := accounts.NewStore(path)
+	if err != nil {
+		fatal("ユーザーの情報を読み込めませんでした", "path", path, "err", err)
+	}
+	users.Policy = accounts.PasswordPolicy{
+		MinLength: cfg.Password.MinLength,
+		History:   cfg.Password.History,
+		Breached:  newBreachChecker(cfg),
+	}
+	return users
+}
+
+// openAPIKeys はユーザーの API キーを、users_file と同じディレクトリの api_keys.json に保存する accounts.APIKeys を返します
+// キーごとのリクエスト数の上限は cfg.APIKeys です
+func openAPIKeys(cfg config.Config) *accounts.APIKeys {
+	path := filepath.Join(filepath.Dir(cfg.UsersFile), "api_keys.json")
+	keys, err := accounts.NewAPIKeys(path)
+	if err != nil {
+		fatal("API キーの情報を読み込めませんでした", "path", path, "err", err)
+	}
+	keys.DailyLimit = cfg.APIKeys.DailyLimit
+	keys.MonthlyLimit = cfg.APIKeys.MonthlyLimit
+	return keys
+}
+
+// openInvites はユーザー登録の招待コードを、users_file と同じディレクトリの invites.json に保存する accounts.Invites を返します
+func openInvites(cfg config.Config) *accounts.Invites {
+	path := filepath.Join(filepath.Dir(cfg.UsersFile), "invites.json")
+	invites, err := accounts.NewInvites(path)
+	if err != nil {
+		fatal("招待コードの情報を読み込めませんでした", "path", path, "err", err)
+	}
+	return invites
+}
+
+// openSessions はログインのセッションを作成します。セッションはメモリ上だけに置き、
+// 「ログインしたままにする」端末の refresh token は users_file と同じディレクトリの refresh_tokens.json に保存します
+func openSessions(cfg config.Config) *accounts.Sessions {
+	path := filepath.Join(filepath.Dir(cfg.UsersFile), "refresh_tokens.json")
+	refresh, err := accounts.NewRefreshTokens(path)
+	if err != nil {
+		fatal("ログインしたままにする端末の情報を読み込めませんでした", "path", path, "err", err)
+	}
+	sessions := accounts.NewSessions()
+	sessions.RefreshTokens = refresh
+	return sessions
+}
+
+// createWorkspaces は raw（workspaces の設定。例: "family:うちの家族,team"）のワークスペースを起動時に作成します
+func createWorkspaces(workspaces *workspace.Store, raw string) {
+	if raw == "" {
+		return
+	}
+	for _, item := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		name := ""
+		if len(parts) == 2 {
+			name = strings.TrimSpace(parts[1])
+		}
+		if _, err := workspaces.Create(strings.TrimSpace(parts[0]), name); err != nil {
+			fatal("設定 workspaces のワークスペースを作成できませんでした", "err", err)
+		}
+	}
+}
+
+// newServer は設定に応じて依存関係を組み立て、サーバを作成します
+// 外部サービス連携や定期バックアップも ctx がキャンセルされるまで動かし、キャンセルされたら WebSocket と SSE の接続を閉じます
+// cfg.Dev が true なら、テンプレートと静的ファイルをリクエストのたびに読み込み直します
+func newServer(ctx context.Context, cfg config.Config) *handlers.Server {
+	// コンパイル時に組み込んだプラグインのフックを作成・完了・削除に適用
+	base := openStore(cfg)
+	store := plugins.Wrap(base, plugins.Registered()...)
+	taskLists, err := lists.NewStore(listsPath(cfg, "", ""))
+	if err != nil {
+		fatal("リストの情報を読み込めませんでした", "err", err)
+	}
+	hooks, dispatcher, ruleStore := subscribeServices(ctx, cfg, store, taskLists, openWebhookQueue(cfg))
+	relayOutbox(base)
+
+	// 定期バックアップ（管理用エンドポイントは ADMIN_TOKEN で保護）
+	backups := newBackupManager(cfg, store, "")
+	digest := newStaleDigest(cfg)
+
+	// 定期レポートのスケジュールはインスタンスごとのメモリ上にあるため、leader かどうかにかかわらず各インスタンスで動かします
+	reportScheduler := newReportScheduler(cfg)
+	go reportScheduler.Run(ctx, store, time.Minute)
+
+	// 外部サービスとの同期・定期バックアップ・放置されているタスクの定期ダイジェスト・ごみ箱の古いタスクの削除・繰り返すタスクの次の回の作成
+	jobs := func(ctx context.Context) {
+		startIntegrations(ctx, store, cfg)
+		go trash.Run(ctx, store, cfg.TrashRetention, trashPurgeInterval)
+		go recurrence.Run(ctx, store, recurrenceInterval)
+		go reminders.Run(ctx, store, reminderInterval)
+		if backups != nil {
+			go backups.Run(ctx, cfg.Backup.Interval)
 		}
+		if digest != nil {
+			go digest.Run(ctx, store, cfg.StaleDigest.Interval)
+		}
+	}
+	// 複数のインスタンスで動かす場合は、leader に選ばれたインスタンスだけで定期的な処理を動かします
+	if elector := newElector(cfg, ""); elector != nil {
+		go elector.RunWhileLeader(ctx, jobs)
+	} else {
+		jobs(ctx)
+	}
+
+	shares, err := share.NewStore(sharesPath(cfg, "", ""))
+	if err != nil {
+		fatal("共有リンクを読み込めませんでした", "err", err)
+	}
+
+	proxies, err := handlers.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		fatal("設定 trusted_proxies を読み取れませんでした", "err", err)
+	}
+	handlerConfig := handlers.Config{
+		StaticDir:       cfg.StaticDir,
+		AdminToken:      cfg.AdminToken,
+		PublicURL:       cfg.PublicURL,
+		Dev:             cfg.Dev,
+		DisableSearch:   !cfg.Features.Search,
+		DisableLiveSync: !cfg.Features.LiveSync,
+		TrustedProxies:  proxies,
+		CaptchaAfter:    cfg.Captcha.After,
+		InviteOnly:      cfg.InviteOnly,
+		SCIMToken:       cfg.SCIMToken,
+	}
+	// 開発モードでは画面のテンプレートをソースのディレクトリから読み込み、描画するたびに読み込み直します
+	if cfg.Dev {
+		handlerConfig.TemplateDir = devTemplateDir
+	}
+
+	// /w/{slug}/ で使うワークスペース（管理用エンドポイントで作成するほか、設定 workspaces で起動時に作成）
+	// 管理用トークンを続けて間違えた IP アドレスは、ワークスペースを含むすべての管理用エンドポイントから締め出します
+	attempts := lockout.New()
+	suggester := newSuggester(cfg)
+	workspaces := workspace.NewStore(newWorkspaceHandler(ctx, cfg, handlerConfig, attempts, suggester))
+	createWorkspaces(workspaces, cfg.Workspaces)
+
+	// users_file を設定すると、ログインしたユーザーごとに別のタスクを扱います（保存先は users/{ID}）
+	// スクリプトからはセッションの代わりに、ユーザーが作成した API キー（Authorization: Bearer）でも使えます
+	var userHandlers *accounts.Handlers
+	var apiKeys *accounts.APIKeys
+	var invites *accounts.Invites
+	var sessions *accounts.Sessions
+	users := openAccounts(cfg)
+	if users != nil {
+		userHandlers = accounts.NewHandlers(newUserHandler(ctx, cfg, handlerConfig, attempts, suggester))
+		apiKeys = openAPIKeys(cfg)
+		invites = openInvites(cfg)
+		sessions = openSessions(cfg)
+	}
+	startUserJiraSyncs(cfg, users, userHandlers)
+
+	return handlers.NewServer(handlers.Deps{
+		Store:         store,
+		Webhooks:      hooks,
+		Deliveries:    dispatcher,
+		Rules:         ruleStore,
+		Lists:         taskLists,
+		Shares:        shares,
+		AdminAttempts: attempts,
+		Logger:        slog.Default(),
+		Config:        handlerConfig,
+		Static:        staticFS(cfg),
+		Notion:        notionExporter(cfg),
+		Backups:       backups,
+		Workspaces:    workspaces,
+		E2E:           openE2EKeys(cfg),
+		Sync:          openSyncer(cfg, store),
+		Reports:       reportScheduler,
+		Suggester:     suggester,
+		Accounts:      users,
+		APIKeys:       apiKeys,
+		Invites:       invites,
+		Sessions:      sessions,
+		UserHandlers:  userHandlers,
+		Stopping:      ctx,
+		CheckStore:    checkStore(base),
+		Captcha:       newCaptcha(cfg),
+		Mailer:        newVerificationMailer(cfg),
 	})
+}
+
+// fatal はエラーをログに記録して終了します（起動に必要なものを準備できなかったとき）
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func main() {
+	// todo-app admin <command> は保存先の保守用のコマンドです
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// 設定は既定値・設定ファイル（-config か TODO_CONFIG）・環境変数・フラグの順に重ね、後のものを優先します
+	httpConfig := serverFlags(flag.CommandLine)
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		fatal("設定を読み込めませんでした", "err", err)
+	}
+	logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fatal("ログの設定が不正です", "err", err)
+	}
+	slog.SetDefault(logger)
+	slog.Debug("設定を読み込みました", "config", cfg.Redacted())
+
+	mode, err := parseSocketMode(cfg.SocketMode)
+	if err != nil {
+		fatal("-socket-mode が不正です", "err", err)
+	}
+	if err := httpConfig.validate(); err != nil {
+		fatal("HTTP サーバの設定が不正です", "err", err)
+	}
+
+	// SIGINT（Ctrl+C）か SIGTERM を受け取ったら、定期的な処理を止めて処理中のリクエストが終わるのを待ってから終了します
+	// 2回目のシグナルでは待たずにすぐ終了します
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	server := newServer(ctx, cfg)
+
+	listener, err := listen(cfg.Listen, mode)
+	if err != nil {
+		fatal("待ち受けられませんでした", "listen", cfg.Listen, "err", err)
+	}
 
-	port := "8080"
-	fmt.Printf("ToDo アプリケーションを開始しています...\n")
-	fmt.Printf("ブラウザで http://localhost:%s にアクセスしてください\n", port)
+	slog.Info("ToDo アプリケーションを開始しました。ブラウザでアクセスしてください", "url", listenURL(cfg.Listen, httpConfig.TLSCert != ""))
+	if cfg.Dev {
+		slog.Info("開発モード: 静的ファイルの変更はブラウザを再読み込みするだけで反映されます", "static_dir", cfg.StaticDir)
+	}
 
-	// 指定ポートでHTTPサーバを起動（Ctrl+Cで停止）
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// 指定したアドレスでHTTPサーバを起動（Ctrl+Cで停止）。リクエストは1件ずつログに記録します
+	if err := run(ctx, newHTTPServer(logging.Requests(logger, server), *httpConfig), listener, *httpConfig); err != nil {
+		fatal("ToDo アプリケーションを正常に停止できませんでした", "err", err)
+	}
+	slog.Info("ToDo アプリケーションを停止しました")
 }
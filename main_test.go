@@ -1,74 +1,74 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"todo-app/config"
+	"todo-app/handlers"
+	"todo-app/lockout"
+	"todo-app/models"
+	"todo-app/store"
+	"todo-app/store/filestore"
+	"todo-app/workspace"
 )
 
-func TestHomeHandler(t *testing.T) {
-	tempDir := t.TempDir()
-	staticDir := filepath.Join(tempDir, "static")
-	err := os.MkdirAll(staticDir, 0755)
+// envConfig は t.Setenv で設定した環境変数から、サーバを起動するときと同じように設定を読み込みます
+func envConfig(t *testing.T) config.Config {
+	t.Helper()
+	cfg, err := config.FromEnv(os.Getenv)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("Failed to load the config: %v", err)
 	}
-	
-	indexContent := `<!DOCTYPE html>
-<html>
-<head><title>ToDo リスト</title></head>
-<body><h1>📝 ToDo リスト</h1></body>
-</html>`
-	
-	indexPath := filepath.Join(staticDir, "index.html")
-	err = os.WriteFile(indexPath, []byte(indexContent), 0644)
-	if err != nil {
-		t.Fatal(err)
-	}
-	
-	originalDir, _ := os.Getwd()
-	defer os.Chdir(originalDir)
-	os.Chdir(tempDir)
-	
+	return cfg
+}
+
+func TestHomeHandler(t *testing.T) {
+	// トップページは埋め込んだテンプレートから描画するため、静的ファイルのディレクトリに index.html がなくても表示できます
+	server := handlers.NewServer(handlers.Deps{
+		Config: handlers.Config{StaticDir: t.TempDir()},
+	})
+
 	req, err := http.NewRequest("GET", "/", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	
+
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(homeHandler)
-	handler.ServeHTTP(rr, req)
-	
+	server.ServeHTTP(rr, req)
+
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, status)
 	}
-	
+
 	responseBody := rr.Body.String()
-	if !strings.Contains(responseBody, "ToDo リスト") {
-		t.Errorf("Expected response to contain 'ToDo リスト', but got: %s", responseBody)
+	if !strings.Contains(responseBody, "ToDo リスト") || !strings.Contains(responseBody, `<script src="/static/script.js"></script>`) {
+		t.Errorf("Expected response to contain the index page, but got: %s", responseBody)
 	}
 }
 
-func TestHomeHandlerFileNotFound(t *testing.T) {
-	tempDir := t.TempDir()
-	originalDir, _ := os.Getwd()
-	defer os.Chdir(originalDir)
-	os.Chdir(tempDir)
-	
-	req, err := http.NewRequest("GET", "/", nil)
-	if err != nil {
-		t.Fatal(err)
+func TestStaticFS(t *testing.T) {
+	// 埋め込んだ静的ファイルは static/ を取り除いた名前で読めること
+	if _, err := fs.Stat(staticFS(config.Config{}), "style.css"); err != nil {
+		t.Errorf("Expected style.css in the embedded static files: %v", err)
 	}
-	
-	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(homeHandler)
-	handler.ServeHTTP(rr, req)
-	
-	if status := rr.Code; status != http.StatusNotFound {
-		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, status)
+	// 開発モードではディスクから読み込むため、埋め込んだファイルを渡さないこと
+	if files := staticFS(config.Config{Dev: true}); files != nil {
+		t.Errorf("Expected no embedded static files in dev mode, got %v", files)
+	}
+}
+
+func TestDevTemplateDirExists(t *testing.T) {
+	// 開発モードはリポジトリのルートから devTemplateDir のテンプレートを読み込みます
+	if _, err := os.Stat(filepath.Join(devTemplateDir, "layout.html")); err != nil {
+		t.Errorf("Expected the templates in %s: %v", devTemplateDir, err)
 	}
 }
 
@@ -82,30 +82,17 @@ func TestAPITasksRouting(t *testing.T) {
 		{"DELETE", http.StatusMethodNotAllowed},
 		{"PUT", http.StatusMethodNotAllowed},
 	}
-	
+
 	for _, tc := range testCases {
 		t.Run(tc.method, func(t *testing.T) {
-			req, err := http.NewRequest(tc.method, "/api/tasks", nil)
+			req, err := http.NewRequest(tc.method, "/api/tasks", strings.NewReader(`{"title": "Routed"}`))
 			if err != nil {
 				t.Fatal(err)
 			}
-			
+
 			rr := httptest.NewRecorder()
-			
-			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method == http.MethodGet {
-					w.WriteHeader(http.StatusOK)
-					w.Write([]byte(`[]`))
-				} else if r.Method == http.MethodPost {
-					w.WriteHeader(http.StatusOK)
-					w.Write([]byte(`{"success": true}`))
-				} else {
-					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-				}
-			})
-			
-			handler.ServeHTTP(rr, req)
-			
+			handlers.NewServer(handlers.Deps{}).ServeHTTP(rr, req)
+
 			if status := rr.Code; status != tc.expectedStatus {
 				t.Errorf("Expected status code %d, got %d", tc.expectedStatus, status)
 			}
@@ -123,14 +110,259 @@ func TestAPITasksIDRouting(t *testing.T) {
 		{"/api/tasks/1", false},
 		{"/api/tasks/456", false},
 	}
-	
+
 	for _, tc := range testCases {
 		t.Run(tc.path, func(t *testing.T) {
-			isToggle := tc.path[len(tc.path)-7:] == "/toggle"
-			
+			// 本文のない PUT は、トグルのハンドラなら存在しないタスクとして 404、
+			// タスクを置き換えるハンドラなら本文の誤りとして 400 になるので、状態コードで振り分け先がわかります
+			req := httptest.NewRequest("PUT", tc.path, nil)
+			rr := httptest.NewRecorder()
+			handlers.NewServer(handlers.Deps{}).ServeHTTP(rr, req)
+
+			isToggle := rr.Code == http.StatusNotFound
 			if isToggle != tc.expectedToggle {
 				t.Errorf("For path %s, expected toggle=%v, got %v", tc.path, tc.expectedToggle, isToggle)
 			}
 		})
 	}
 }
+
+func TestOpenStore(t *testing.T) {
+	t.Setenv("TODO_STORE", "")
+	t.Setenv("TODO_GIT_DIR", "")
+	if _, ok := openStore(envConfig(t)).(*models.TodoApp); !ok {
+		t.Error("Expected an in-memory store without TODO_GIT_DIR")
+	}
+}
+
+func TestOpenStoreDriver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	t.Setenv("TODO_STORE", "file")
+	t.Setenv("TODO_STORE_DSN", path)
+	t.Setenv("TODO_GIT_DIR", t.TempDir())
+	if _, ok := openStore(envConfig(t)).(*filestore.Store); !ok {
+		t.Error("Expected TODO_STORE to take precedence over TODO_GIT_DIR")
+	}
+
+	if _, err := openStoreIn(config.Store{Driver: "postgres", DSN: "postgres://localhost/todo"}); !errors.Is(err, store.ErrUnknownDriver) || !strings.Contains(err.Error(), "compiled in: file, git, memory") {
+		t.Errorf("Expected an unknown driver error listing the compiled-in drivers, got %v", err)
+	}
+}
+
+func TestScopedDSN(t *testing.T) {
+	testCases := []struct {
+		driver, dsn, scope, name, expected string
+	}{
+		{"memory", "", "workspaces", "family", ""},
+		{"git", "/var/lib/todo", "workspaces", "family", filepath.Join("/var/lib/todo", "workspaces", "family")},
+		{"file", "/var/lib/todo/tasks.json", "workspaces", "family", filepath.Join("/var/lib/todo", "workspaces", "family.json")},
+		{"file", "/var/lib/todo/tasks.json", "users", "3", filepath.Join("/var/lib/todo", "users", "3.json")},
+		{"postgres", "postgres://localhost/todo", "users", "3", "postgres://localhost/todo"},
+	}
+	for _, tc := range testCases {
+		if got := scopedDSN(tc.driver, tc.dsn, tc.scope, tc.name); got != tc.expected {
+			t.Errorf("scopedDSN(%q, %q, %q, %q): expected %q, got %q", tc.driver, tc.dsn, tc.scope, tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestListsPath(t *testing.T) {
+	t.Setenv("TODO_LISTS_FILE", "")
+	if path := listsPath(envConfig(t), "users", "3"); path != "" {
+		t.Errorf("Expected lists in memory without TODO_LISTS_FILE, got %q", path)
+	}
+
+	t.Setenv("TODO_LISTS_FILE", "/var/lib/todo/lists.json")
+	if path := listsPath(envConfig(t), "", ""); path != "/var/lib/todo/lists.json" {
+		t.Errorf("Expected the root lists in TODO_LISTS_FILE, got %q", path)
+	}
+	if path := listsPath(envConfig(t), "workspaces", "family"); path != filepath.Join("/var/lib/todo", "workspaces", "family.lists.json") {
+		t.Errorf("Expected the workspace lists next to TODO_LISTS_FILE, got %q", path)
+	}
+}
+
+func TestSharesPath(t *testing.T) {
+	t.Setenv("TODO_LISTS_FILE", "")
+	if path := sharesPath(envConfig(t), "", ""); path != "" {
+		t.Errorf("Expected shares in memory without TODO_LISTS_FILE, got %q", path)
+	}
+
+	t.Setenv("TODO_LISTS_FILE", "/var/lib/todo/lists.json")
+	if path := sharesPath(envConfig(t), "", ""); path != filepath.Join("/var/lib/todo", "shares.json") {
+		t.Errorf("Expected the root shares next to TODO_LISTS_FILE, got %q", path)
+	}
+	if path := sharesPath(envConfig(t), "users", "3"); path != filepath.Join("/var/lib/todo", "users", "3.shares.json") {
+		t.Errorf("Expected the user's shares next to TODO_LISTS_FILE, got %q", path)
+	}
+}
+
+func TestOpenStoreMaxTasks(t *testing.T) {
+	t.Setenv("TODO_STORE", "")
+	t.Setenv("TODO_GIT_DIR", "")
+	t.Setenv("TODO_MAX_TASKS", "1")
+	store := openStore(envConfig(t))
+
+	store.AddTask(context.Background(), "Only")
+	if _, err := store.AddTask(context.Background(), "Too many"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected TODO_MAX_TASKS to limit the tasks, got %v", err)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	for _, key := range []string{"TODO_STORE", "TODO_GIT_DIR", "JIRA_JQL", "GOOGLE_REFRESH_TOKEN", "NOTION_TOKEN", "BACKUP_DESTINATION", "STALE_DIGEST_URL", "EXEC_HOOKS_FILE", "TODO_WORKSPACES", "TODO_USERS_FILE", "TODO_LISTS_FILE", "LEADER_LOCK_FILE", "E2E_KEY_FILE", "WEBHOOK_QUEUE_FILE", "SYNC_STATE_FILE", "SMTP_ADDR", "LLM_URL", "LLM_API_KEY"} {
+		t.Setenv(key, "")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newServer(ctx, envConfig(t))
+
+	rule := `{"trigger": "task.created", "actions": [{"type": "set_priority", "value": "high"}]}`
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("POST", "/api/rules", strings.NewReader(rule)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to add a rule: %d %s", rr.Code, rr.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Wired"}`))
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	tasks := server.Store().GetTasks(ctx)
+	if rr.Code != http.StatusOK || len(tasks) != 1 {
+		t.Fatalf("Expected the task to be added through the server, got %d", rr.Code)
+	}
+	if tasks[0].Priority != models.PriorityHigh {
+		t.Errorf("Expected the rule to set the priority, got %+v", tasks[0])
+	}
+}
+
+func TestOpenE2EKeys(t *testing.T) {
+	t.Setenv("E2E_KEY_FILE", "")
+	if openE2EKeys(envConfig(t)) != nil {
+		t.Error("Expected encryption to be disabled without E2E_KEY_FILE")
+	}
+
+	t.Setenv("E2E_KEY_FILE", filepath.Join(t.TempDir(), "e2e.json"))
+	keys := openE2EKeys(envConfig(t))
+	if keys == nil {
+		t.Fatal("Expected encryption to be enabled with E2E_KEY_FILE")
+	}
+	if _, ok := keys.Get(); ok {
+		t.Error("Expected no keys before the first client registers them")
+	}
+}
+
+func TestOpenSyncer(t *testing.T) {
+	t.Setenv("SYNC_STATE_FILE", "")
+	if openSyncer(envConfig(t), models.NewTodoApp()) != nil {
+		t.Error("Expected sync to be disabled without SYNC_STATE_FILE")
+	}
+
+	t.Setenv("SYNC_STATE_FILE", filepath.Join(t.TempDir(), "sync.json"))
+	if openSyncer(envConfig(t), models.NewTodoApp()) == nil {
+		t.Error("Expected sync to be enabled with SYNC_STATE_FILE")
+	}
+}
+
+func TestOpenWebhookQueue(t *testing.T) {
+	t.Setenv("WEBHOOK_QUEUE_FILE", "")
+	if openWebhookQueue(envConfig(t)) != nil {
+		t.Error("Expected an in-memory queue without WEBHOOK_QUEUE_FILE")
+	}
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	t.Setenv("WEBHOOK_QUEUE_FILE", path)
+	queue := openWebhookQueue(envConfig(t))
+	if queue == nil {
+		t.Fatal("Expected a queue with WEBHOOK_QUEUE_FILE")
+	}
+	if _, err := queue.Enqueue(1, models.Event{}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the queue to be saved to WEBHOOK_QUEUE_FILE: %v", err)
+	}
+}
+
+func TestNewServerUsers(t *testing.T) {
+	for _, key := range []string{"TODO_GIT_DIR", "TODO_WORKSPACES", "TODO_LISTS_FILE", "JIRA_JQL", "GOOGLE_REFRESH_TOKEN", "NOTION_TOKEN", "BACKUP_DESTINATION", "STALE_DIGEST_URL", "EXEC_HOOKS_FILE"} {
+		t.Setenv(key, "")
+	}
+	dir := t.TempDir()
+	t.Setenv("TODO_STORE", "file")
+	t.Setenv("TODO_STORE_DSN", filepath.Join(dir, "tasks.json"))
+	t.Setenv("TODO_USERS_FILE", filepath.Join(dir, "users.json"))
+	t.Setenv("LEADER_LOCK_FILE", filepath.Join(t.TempDir(), "leader.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newServer(ctx, envConfig(t))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected tasks to require a session, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("POST", "/api/auth/register", strings.NewReader(`{"name": "alice", "password": "correct horse"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to register: %d %s", rr.Code, rr.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == handlers.SessionCookie {
+			cookie = c
+		}
+	}
+	req := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title": "Buy milk"}`))
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to add a task as the user: %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "users", "1.json")); err != nil {
+		t.Errorf("Expected the user's tasks to be saved in their own file: %v", err)
+	}
+	if len(server.Store().GetTasks(ctx)) != 0 {
+		t.Error("Expected the user's task not to be added to the root store")
+	}
+
+	// API キーは TODO_USERS_FILE の隣に保存し、セッションの代わりに使えます
+	req = httptest.NewRequest("POST", "/api/keys", strings.NewReader(`{"name": "cron"}`))
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	var created struct {
+		Token string `json:"token"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if rr.Code != http.StatusOK || created.Token == "" {
+		t.Fatalf("Failed to create an API key: %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api_keys.json")); err != nil {
+		t.Errorf("Expected the API keys to be saved next to the users: %v", err)
+	}
+	req = httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Buy milk") {
+		t.Errorf("Expected the API key to list the user's tasks, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestNewWorkspaceHandlerError(t *testing.T) {
+	// リポジトリを作れない場所（ファイル）を TODO_GIT_DIR にするとワークスペースを作成できません
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0644)
+	t.Setenv("TODO_STORE", "")
+	t.Setenv("TODO_GIT_DIR", file)
+
+	workspaces := workspace.NewStore(newWorkspaceHandler(context.Background(), envConfig(t), handlers.Config{}, lockout.New(), nil))
+	if _, err := workspaces.Create("family", ""); err == nil {
+		t.Error("Expected an error when the repository cannot be created")
+	}
+}
@@ -0,0 +1,205 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// renderInline は段落の中の書き方（コード・強調・取り消し線・リンク・改行）を HTML にします
+// それ以外の文字はすべてエスケープします
+func renderInline(text string) string {
+	return inline(text, true)
+}
+
+// inline は renderInline の本体です。links が false ならリンクを作りません（リンクのラベルの中でリンクを入れ子にしないためです）
+func inline(text string, links bool) string {
+	var out strings.Builder
+	plain := 0
+	flush := func(end int) {
+		out.WriteString(html.EscapeString(text[plain:end]))
+	}
+
+	for i := 0; i < len(text); {
+		h, next := inlineAt(text, i, links)
+		if next == i {
+			i++
+			continue
+		}
+		flush(i)
+		out.WriteString(h)
+		i, plain = next, next
+	}
+	flush(len(text))
+	return out.String()
+}
+
+// inlineAt は text[i] から始まる書き方を HTML にし、続きの位置を返します
+// 書き方が始まらなければ i をそのまま返します
+func inlineAt(text string, i int, links bool) (string, int) {
+	rest := text[i:]
+	switch rest[0] {
+	case '\\':
+		if len(rest) > 1 && strings.IndexByte(escapable, rest[1]) >= 0 {
+			return html.EscapeString(rest[1:2]), i + 2
+		}
+		if strings.HasPrefix(rest, "\\\n") {
+			return "<br>\n", i + 2
+		}
+	case '\n':
+		return "<br>\n", i + 1
+	case '`':
+		run := len(rest) - len(strings.TrimLeft(rest, "`"))
+		if end := strings.Index(rest[run:], rest[:run]); end >= 0 {
+			code := strings.TrimSpace(strings.ReplaceAll(rest[run:run+end], "\n", " "))
+			return "<code>" + html.EscapeString(code) + "</code>", i + run + end + run
+		}
+		return html.EscapeString(rest[:run]), i + run
+	case '*', '_', '~':
+		for _, d := range delimiters {
+			if !strings.HasPrefix(rest, d.mark) || (d.mark[0] == '_' && i > 0 && isWordByte(text[i-1])) {
+				continue
+			}
+			if end := closing(rest, d.mark); end > 0 {
+				return "<" + d.tag + ">" + inline(rest[len(d.mark):end], links) + "</" + d.tag + ">", i + end + len(d.mark)
+			}
+		}
+	case '[':
+		if label, href, n, ok := link(rest); ok && links {
+			if safeURL(href) {
+				return `<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer">` + inline(label, false) + "</a>", i + n
+			}
+			return inline(label, false), i + n
+		}
+	case '<':
+		if end := strings.IndexByte(rest, '>'); end > 0 && links {
+			if href := rest[1:end]; isAutolink(href) {
+				return anchor(href), i + end + 1
+			}
+		}
+	case 'h', 'H':
+		if links && (i == 0 || !isWordByte(text[i-1])) && hasWebScheme(rest) {
+			end := strings.IndexAny(rest, " \t\n<")
+			if end < 0 {
+				end = len(rest)
+			}
+			href := strings.TrimRight(rest[:end], ".,;:!?'\")")
+			if isAutolink(href) {
+				return anchor(href), i + len(href)
+			}
+		}
+	}
+	return "", i
+}
+
+// escapable は \ を前に付けて、書き方ではなく文字そのものとして書ける記号です
+const escapable = "\\`*_~[]()#+-.!<>|{}"
+
+// delimiters は強調の書き方です。長い印から順に試します
+var delimiters = []struct {
+	mark string
+	tag  string
+}{
+	{"**", "strong"},
+	{"__", "strong"},
+	{"~~", "del"},
+	{"*", "em"},
+	{"_", "em"},
+}
+
+// closing は mark で始まる text の、強調を閉じる mark の位置を返します（なければ -1）
+// 中身は空白で始まったり終わったりせず、コードの中の mark と、1文字の mark を探すときの2文字の mark は飛ばします
+func closing(text, mark string) int {
+	if len(text) <= len(mark) || text[len(mark)] == ' ' || text[len(mark)] == '\n' {
+		return -1
+	}
+	for i := len(mark) + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case text[i] == '`':
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			if end := strings.Index(text[i+run:], text[i:i+run]); end >= 0 {
+				i += run + end + run - 1
+			} else {
+				i += run - 1
+			}
+		case strings.HasPrefix(text[i:], mark):
+			if len(mark) == 1 && i+1 < len(text) && text[i+1] == mark[0] {
+				i++
+				continue
+			}
+			if text[i-1] == ' ' || text[i-1] == '\n' {
+				continue
+			}
+			if mark[0] == '_' && i+len(mark) < len(text) && isWordByte(text[i+len(mark)]) {
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// link は [label](href) で始まる text のラベルと URL、書き方の長さを返します
+func link(text string) (label, href string, n int, ok bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if !strings.HasPrefix(text[i+1:], "(") {
+				return "", "", 0, false
+			}
+			end := strings.IndexAny(text[i+2:], ") \n")
+			if end < 0 || text[i+2+end] != ')' {
+				return "", "", 0, false
+			}
+			return text[1:i], text[i+2 : i+2+end], i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// safeURL は href がリンクにしてよい URL（http・https・mailto か、スキームのない相対 URL）かを返します
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// isAutolink は text がそのままリンクにする URL（空白を含まない http:// か https:// で始まる URL）かを返します
+func isAutolink(text string) bool {
+	return hasWebScheme(text) && !strings.ContainsAny(text, " \t\n<>")
+}
+
+// hasWebScheme は text が http:// か https:// とその後の文字で始まるかを返します
+func hasWebScheme(text string) bool {
+	for _, scheme := range []string{"http://", "https://"} {
+		if len(text) > len(scheme) && strings.EqualFold(text[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func anchor(href string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer">` + html.EscapeString(href) + "</a>"
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= 0x80
+}
@@ -0,0 +1,181 @@
+// Package markdown はタスクの説明の Markdown を HTML にします
+// 外部のライブラリを使わずに、よく使う書き方（見出し・段落・箇条書き・チェックリスト・引用・コードブロック・強調・リンク）だけに対応しています
+// 書かれた HTML はすべてエスケープし、リンクは http・https・mailto と相対 URL だけを残すため、返す HTML はそのままページに埋め込めます
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern     = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	listItemPattern = regexp.MustCompile(`^( {0,3})([-*+]|(\d{1,9})[.)])(?:[ \t]+(.*))?$`)
+	fencePattern    = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^`\\s]*)")
+	languagePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+)
+
+// Render は Markdown の src を HTML にして返します
+// 段落の中の改行は <br> にします（タスクのメモとして書いたとおりに折り返すためです）
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	var out strings.Builder
+	renderBlocks(&out, strings.Split(src, "\n"))
+	return out.String()
+}
+
+// renderBlocks は lines をブロック（段落・見出し・箇条書きなど）に分けて out に書き出します
+func renderBlocks(out *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case fencePattern.MatchString(line):
+			i = renderFence(out, lines, i)
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+			i++
+		case rulePattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+		case isQuote(line):
+			var quoted []string
+			for ; i < len(lines) && isQuote(lines[i]); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimLeft(lines[i], " "), ">"), " "))
+			}
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+		case listItemPattern.MatchString(line):
+			i = renderList(out, lines, i)
+		default:
+			start := i
+			for i++; i < len(lines) && !startsBlock(lines[i]); i++ {
+			}
+			paragraph := make([]string, 0, i-start)
+			for _, line := range lines[start:i] {
+				paragraph = append(paragraph, strings.TrimSpace(line))
+			}
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+		}
+	}
+}
+
+// startsBlock は line が段落を終わらせる行（空行か、ほかのブロックの始まり）かを返します
+func startsBlock(line string) bool {
+	return strings.TrimSpace(line) == "" || fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || isQuote(line) || listItemPattern.MatchString(line)
+}
+
+func isQuote(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+// renderFence は lines[start] から始まるコードブロックを書き出し、続きの行の位置を返します
+// 閉じる行がなければ最後までをコードブロックにします
+func renderFence(out *strings.Builder, lines []string, start int) int {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	fence := m[1]
+	if languagePattern.MatchString(m[2]) {
+		out.WriteString(`<pre><code class="language-` + m[2] + `">`)
+	} else {
+		out.WriteString("<pre><code>")
+	}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		out.WriteString(html.EscapeString(lines[i]) + "\n")
+	}
+	out.WriteString("</code></pre>\n")
+	return i
+}
+
+// renderList は lines[start] から始まる箇条書き（番号付きなら <ol>）を書き出し、続きの行の位置を返します
+// 項目の続きは字下げした行で、字下げした箇条書きは入れ子にします。空行か種類の違う項目で箇条書きを終えます
+func renderList(out *strings.Builder, lines []string, start int) int {
+	first := listItemPattern.FindStringSubmatch(lines[start])
+	ordered := first[3] != ""
+	switch {
+	case !ordered:
+		out.WriteString("<ul>\n")
+	case strings.TrimLeft(first[3], "0") == "1":
+		out.WriteString("<ol>\n")
+	default:
+		n, _ := strconv.Atoi(first[3])
+		out.WriteString(`<ol start="` + strconv.Itoa(n) + `">` + "\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if m == nil || (m[3] != "") != ordered {
+			break
+		}
+		indent := len(m[1]) + len(m[2]) + 1
+		body := []string{m[4]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				break
+			}
+			spaces := len(line) - len(strings.TrimLeft(line, " "))
+			if spaces >= 2 {
+				body = append(body, line[min(spaces, indent):])
+				continue
+			}
+			if startsBlock(line) {
+				break
+			}
+			body = append(body, strings.TrimSpace(line))
+		}
+		out.WriteString("<li>")
+		renderItem(out, body, !ordered)
+		out.WriteString("</li>\n")
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+			break
+		}
+	}
+
+	if ordered {
+		out.WriteString("</ol>\n")
+	} else {
+		out.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// renderItem は箇条書きの1項目の本文を書き出します
+// 本文が文章だけならそのまま、入れ子の箇条書きなどを含めばブロックとして書き出します
+// checkbox が true なら、先頭の [ ] / [x] をチェックボックスにします
+func renderItem(out *strings.Builder, body []string, checkbox bool) {
+	if checkbox {
+		switch {
+		case strings.HasPrefix(body[0], "[ ] "):
+			out.WriteString(`<input type="checkbox" disabled> `)
+			body[0] = body[0][4:]
+		case strings.HasPrefix(body[0], "[x] "), strings.HasPrefix(body[0], "[X] "):
+			out.WriteString(`<input type="checkbox" checked disabled> `)
+			body[0] = body[0][4:]
+		}
+	}
+	text := 1
+	for text < len(body) && !startsBlock(body[text]) {
+		text++
+	}
+	out.WriteString(renderInline(strings.Join(body[:text], "\n")))
+	if text < len(body) {
+		out.WriteString("\n")
+		renderBlocks(out, body[text:])
+	}
+}
@@ -0,0 +1,59 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraphs", "first line\nsecond line\n\nnext", "<p>first line<br>\nsecond line</p>\n<p>next</p>\n"},
+		{"heading", "## Plan ##", "<h2>Plan</h2>\n"},
+		{"hashtag is not a heading", "#shopping", "<p>#shopping</p>\n"},
+		{"emphasis", "**bold** *em* _em_ ~~gone~~ snake_case_name", "<p><strong>bold</strong> <em>em</em> <em>em</em> <del>gone</del> snake_case_name</p>\n"},
+		{"nested emphasis", "*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>\n"},
+		{"code", "run `go test ./...` now", "<p>run <code>go test ./...</code> now</p>\n"},
+		{"code keeps markup", "`**not bold** <b>`", "<p><code>**not bold** &lt;b&gt;</code></p>\n"},
+		{"escape", `\*literal\*`, "<p>*literal*</p>\n"},
+		{"link", "[docs](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">docs</a></p>` + "\n"},
+		{"relative link", "[task](/tasks/3)", `<p><a href="/tasks/3" rel="nofollow noopener noreferrer">task</a></p>` + "\n"},
+		{"autolink", "see https://example.com/x.", `<p>see <a href="https://example.com/x" rel="nofollow noopener noreferrer">https://example.com/x</a>.</p>` + "\n"},
+		{"no link in link label", "[https://a.example](https://b.example)", `<p><a href="https://b.example" rel="nofollow noopener noreferrer">https://a.example</a></p>` + "\n"},
+		{"list", "- one\n- two\n  continued\n\n1. first", "<ul>\n<li>one</li>\n<li>two<br>\ncontinued</li>\n</ul>\n<ol>\n<li>first</li>\n</ol>\n"},
+		{"ordered start", "3. third\n4. fourth", "<ol start=\"3\">\n<li>third</li>\n<li>fourth</li>\n</ol>\n"},
+		{"nested list", "- parent\n  - child", "<ul>\n<li>parent\n<ul>\n<li>child</li>\n</ul>\n</li>\n</ul>\n"},
+		{"checklist", "- [ ] open\n- [x] done", "<ul>\n<li><input type=\"checkbox\" disabled> open</li>\n<li><input type=\"checkbox\" checked disabled> done</li>\n</ul>\n"},
+		{"quote", "> quoted\n> **text**", "<blockquote>\n<p>quoted<br>\n<strong>text</strong></p>\n</blockquote>\n"},
+		{"fence", "```go\nif a < b {\n```", "<pre><code class=\"language-go\">if a &lt; b {\n</code></pre>\n"},
+		{"unclosed fence", "~~~\ncode", "<pre><code>code\n</code></pre>\n"},
+		{"rule", "text\n\n---", "<p>text</p>\n<hr>\n"},
+		{"crlf", "a\r\nb", "<p>a<br>\nb</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.src); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`<script>alert(1)</script>`, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{`<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n"},
+		{`[click](javascript:alert(1))`, "<p>click)</p>\n"},
+		{`[click](JavaScript:alert` + "`1`" + `)`, "<p>click</p>\n"},
+		{`[click](data:text/html;base64,PHNjcmlwdD4=)`, "<p>click</p>\n"},
+		{`[x](https://example.com/"onmouseover=alert)`, `<p><a href="https://example.com/&#34;onmouseover=alert" rel="nofollow noopener noreferrer">x</a></p>` + "\n"},
+		{"```\" onload=\"x\ncode\n```", "<pre><code>code\n</code></pre>\n"},
+		{"# <b>title</b>", "<h1>&lt;b&gt;title&lt;/b&gt;</h1>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.src); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.src, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrCommentNotFound は指定IDのコメントがタスクにないことを表します
+var ErrCommentNotFound = errors.New("comment not found")
+
+// MaxComments は1件のタスクに付けられるコメントの最大数です
+const MaxComments = 200
+
+// MaxCommentLength はコメントの本文の最大の長さ（文字数）です
+const MaxCommentLength = 5000
+
+// maxCommentAuthor はコメントを書いた人の名前の最大の長さ（文字数）です
+const maxCommentAuthor = 50
+
+// Comment はタスクに付けた1件のコメントです
+// Author: 書いた人（名乗らなければ空）
+// Body: 本文（Markdown）
+type Comment struct {
+	ID        int       `json:"id"`
+	Author    string    `json:"author,omitempty"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AppendComment は comments に now に書いた author の body のコメントを加えたものと、加えたコメントを返します
+// 名前の前後の空白は取り除きます。本文が空か長すぎるか、名前が長すぎれば ErrValidation を、
+// すでに MaxComments 件あれば ErrConflict を返します
+func AppendComment(comments []Comment, author, body string, now time.Time) ([]Comment, Comment, error) {
+	author = strings.TrimSpace(author)
+	if strings.TrimSpace(body) == "" {
+		return nil, Comment{}, fmt.Errorf("%w: comment body is required", ErrValidation)
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return nil, Comment{}, fmt.Errorf("%w: comment must be at most %d characters", ErrValidation, MaxCommentLength)
+	}
+	if utf8.RuneCountInString(author) > maxCommentAuthor {
+		return nil, Comment{}, fmt.Errorf("%w: author must be at most %d characters", ErrValidation, maxCommentAuthor)
+	}
+	if len(comments) >= MaxComments {
+		return nil, Comment{}, fmt.Errorf("%w: a task can have at most %d comments", ErrConflict, MaxComments)
+	}
+
+	nextID := 1
+	for _, comment := range comments {
+		if comment.ID >= nextID {
+			nextID = comment.ID + 1
+		}
+	}
+	added := Comment{ID: nextID, Author: author, Body: body, CreatedAt: now}
+	return append(copyComments(comments), added), added, nil
+}
+
+// RemoveComment は comments から id のコメントを除いたものを返します
+// 見つからなければ ErrCommentNotFound を返します
+func RemoveComment(comments []Comment, id int) ([]Comment, error) {
+	for i, comment := range comments {
+		if comment.ID == id {
+			rest := append(copyComments(comments[:i]), comments[i+1:]...)
+			if len(rest) == 0 {
+				rest = nil
+			}
+			return rest, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id %d", ErrCommentNotFound, id)
+}
+
+// copyComments はコメントの並びのコピーを返します（nil はそのまま）
+func copyComments(comments []Comment) []Comment {
+	if comments == nil {
+		return nil
+	}
+	return append([]Comment(nil), comments...)
+}
+
+// AddComment は指定IDのタスクに author の body のコメントを追加し、追加したコメントを返します
+// 見つからなければ ErrTaskNotFound を、コメントが不正なら ErrValidation を、多すぎれば ErrConflict を返します（AppendComment）
+func (app *TodoApp) AddComment(ctx context.Context, id int, author, body string) (Comment, error) {
+	var added Comment
+	err := app.updateTaskIf(ctx, id, "", func(task *Task) error {
+		comments, comment, err := AppendComment(task.Comments, author, body, app.now())
+		if err != nil {
+			return err
+		}
+		task.Comments, added = comments, comment
+		return nil
+	})
+	return added, err
+}
+
+// DeleteComment は指定IDのタスクから commentID のコメントを削除します
+// タスクが見つからなければ ErrTaskNotFound を、コメントが見つからなければ ErrCommentNotFound を返します
+func (app *TodoApp) DeleteComment(ctx context.Context, id, commentID int) error {
+	return app.updateTaskIf(ctx, id, "", func(task *Task) error {
+		comments, err := RemoveComment(task.Comments, commentID)
+		if err != nil {
+			return err
+		}
+		task.Comments = comments
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComments(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	comments, first, err := AppendComment(nil, " alice ", "First", now)
+	if err != nil || first.ID != 1 || first.Author != "alice" || !first.CreatedAt.Equal(now) || len(comments) != 1 {
+		t.Fatalf("Unexpected comment: %+v %+v (%v)", comments, first, err)
+	}
+	more, second, _ := AppendComment(comments, "", "Second", now)
+	if second.ID != 2 || len(more) != 2 || len(comments) != 1 {
+		t.Errorf("Expected a second comment and the original comments unchanged, got %+v %+v", more, comments)
+	}
+
+	for _, tt := range []struct {
+		author, body string
+	}{
+		{"", ""},
+		{"", " \n "},
+		{"", strings.Repeat("あ", MaxCommentLength+1)},
+		{strings.Repeat("a", 51), "Hello"},
+	} {
+		if _, _, err := AppendComment(nil, tt.author, tt.body, now); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for author %q and a %d character body, got %v", tt.author, len(tt.body), err)
+		}
+	}
+	full := make([]Comment, MaxComments)
+	if _, _, err := AppendComment(full, "", "One more", now); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict beyond %d comments, got %v", MaxComments, err)
+	}
+
+	rest, err := RemoveComment(more, 1)
+	if err != nil || len(rest) != 1 || rest[0].ID != 2 || more[0].ID != 1 {
+		t.Errorf("Expected only comment 2 to remain and the original comments unchanged, got %+v %+v (%v)", rest, more, err)
+	}
+	if _, err := RemoveComment(more, 9); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Expected ErrCommentNotFound, got %v", err)
+	}
+	if _, third, _ := AppendComment(rest, "", "Third", now); third.ID != 3 {
+		t.Errorf("Expected IDs not to be reused, got %d", third.ID)
+	}
+}
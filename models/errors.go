@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// TaskStore の操作が失敗した理由です。errors.Is で判定してください
+// ErrTaskNotFound: 指定したIDのタスクがない
+// ErrValidation: 入力が不正（タイトルが空など）
+// ErrConflict: 現在の状態と矛盾する（IDの重複など）
+var (
+	ErrTaskNotFound = errors.New("task not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+)
+
+// ErrAlreadyClaimed はタスクをほかの人が担当していることを表します（ErrConflict として扱われます）
+var ErrAlreadyClaimed = fmt.Errorf("%w: task is already claimed", ErrConflict)
+
+// maxClaimantLength はタスクを担当する人の名前の最大の長さです
+const maxClaimantLength = 64
+
+// validateClaimant は担当する人の名前が空でなく、長すぎないことを確認します
+func validateClaimant(claimant string) error {
+	if claimant == "" || len([]rune(claimant)) > maxClaimantLength {
+		return fmt.Errorf("%w: claimant must be 1 to %d characters", ErrValidation, maxClaimantLength)
+	}
+	return nil
+}
+
+// notFound は id のタスクが見つからないことを表すエラーを返します
+func notFound(id int) error {
+	return fmt.Errorf("%w: id %d", ErrTaskNotFound, id)
+}
+
+// validateTitle はタイトルが空でないことを確認します
+func validateTitle(title string) error {
+	if title == "" {
+		return fmt.Errorf("%w: title is required", ErrValidation)
+	}
+	return nil
+}
+
+// MaxDescriptionLength はタスクの説明の最大の長さ（文字数）です
+const MaxDescriptionLength = 10000
+
+// ValidateDescription はタスクの説明が長すぎないかを確認し、長すぎれば ErrValidation を返します（空は説明なしです）
+func ValidateDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrValidation, MaxDescriptionLength)
+	}
+	return nil
+}
+
+// validateTasks は ReplaceTasks で置き換えるタスクの ID とタイトルを確認します
+func validateTasks(tasks []Task) error {
+	seen := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		if task.ID <= 0 {
+			return fmt.Errorf("%w: id %d must be positive", ErrValidation, task.ID)
+		}
+		if err := validateTitle(task.Title); err != nil {
+			return fmt.Errorf("task %d: %w", task.ID, err)
+		}
+		if seen[task.ID] {
+			return fmt.Errorf("%w: duplicate id %d", ErrConflict, task.ID)
+		}
+		seen[task.ID] = true
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAddTaskValidation(t *testing.T) {
+	app := NewTodoApp()
+	events := 0
+	app.Subscribe(func(Event) { events++ })
+
+	task, err := app.AddTask(context.Background(), "")
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an empty title, got %v", err)
+	}
+	if task.ID != 0 || len(app.GetTasks(context.Background())) != 0 || events != 0 {
+		t.Error("Expected a rejected task not to be added or published")
+	}
+
+	// 失敗しても ID は消費されません
+	if task, _ := app.AddTask(context.Background(), "First"); task.ID != 1 {
+		t.Errorf("Expected ID 1, got %d", task.ID)
+	}
+}
+
+func TestNotFoundErrorMessage(t *testing.T) {
+	err := NewTodoApp().DeleteTask(context.Background(), 42)
+	if !errors.Is(err, ErrTaskNotFound) || err.Error() != "task not found: id 42" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestPriorityValidate(t *testing.T) {
+	for _, p := range append(Priorities(), "") {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", p, err)
+		}
+	}
+	for _, p := range []Priority{"urgent", "HIGH", " low"} {
+		if err := p.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", p, err)
+		}
+	}
+}
+
+func TestReplaceTasksValidation(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	app.AddTask(ctx, "Kept")
+
+	testCases := []struct {
+		tasks []Task
+		err   error
+	}{
+		{[]Task{{ID: 1, Title: "A"}, {ID: 1, Title: "B"}}, ErrConflict},
+		{[]Task{{ID: -1, Title: "Negative"}}, ErrValidation},
+		{[]Task{{ID: 2, Title: ""}}, ErrValidation},
+	}
+	for _, tc := range testCases {
+		if err := app.ReplaceTasks(ctx, tc.tasks); !errors.Is(err, tc.err) {
+			t.Errorf("ReplaceTasks(%+v): expected %v, got %v", tc.tasks, tc.err, err)
+		}
+	}
+	if tasks := app.GetTasks(ctx); len(tasks) != 1 || tasks[0].Title != "Kept" {
+		t.Errorf("Expected rejected replacements to leave the tasks untouched, got %+v", tasks)
+	}
+}
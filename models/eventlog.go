@@ -0,0 +1,68 @@
+package models
+
+import "sync"
+
+// EventLog は最近のタスクの変更イベントを決まった件数だけ覚えておきます
+// 接続し直したクライアントへ、切れていた間のイベントを送り直すために使います
+type EventLog struct {
+	mutex  sync.RWMutex
+	size   int
+	events []Event
+}
+
+// NewEventLog は最近の size 件のイベントを覚える EventLog を作成します
+func NewEventLog(size int) *EventLog {
+	return &EventLog{size: size}
+}
+
+// Watch は store のイベントを記録し始めます。購読を解除する関数を返します
+func (l *EventLog) Watch(store TaskStore) (unsubscribe func()) {
+	return store.Subscribe(l.HandleEvent)
+}
+
+// HandleEvent はイベントを記録します。size 件を超えたら古いものから捨てます
+func (l *EventLog) HandleEvent(event Event) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.events = append(l.events, event)
+	if len(l.events) > l.size {
+		l.events = append([]Event(nil), l.events[len(l.events)-l.size:]...)
+	}
+}
+
+// Since は ID が id より大きいイベントを記録した順に返します
+// id の次のイベントをすでに捨てていたり、id が記録したどのイベントより新しかったり（サーバの再起動で番号が戻った場合など）して
+// 続きを送り直せないときは ok を false にします。その場合、クライアントはタスクの一覧を読み込み直す必要があります
+func (l *EventLog) Since(id int64) (events []Event, ok bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if len(l.events) == 0 {
+		return nil, id == 0
+	}
+	first, last := l.events[0].ID, l.events[len(l.events)-1].ID
+	if id < first-1 || id > last {
+		return nil, false
+	}
+	for _, event := range l.events {
+		if event.ID > id {
+			events = append(events, event)
+		}
+	}
+	return events, true
+}
+
+// ForTask は記録しているイベントのうち、指定IDのタスクのものを記録した順に返します
+func (l *EventLog) ForTask(id int) []Event {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	var events []Event
+	for _, event := range l.events {
+		if event.Task.ID == id {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestEventLog(t *testing.T) {
+	log := NewEventLog(3)
+	if _, ok := log.Since(0); !ok {
+		t.Error("Expected an empty log to replay from the start")
+	}
+	if _, ok := log.Since(5); ok {
+		t.Error("Expected an empty log not to replay after an unknown ID")
+	}
+
+	for id := int64(1); id <= 5; id++ {
+		log.HandleEvent(Event{ID: id, Type: EventTaskCreated})
+	}
+
+	for _, tt := range []struct {
+		since int64
+		want  []int64
+		ok    bool
+	}{
+		{2, []int64{3, 4, 5}, true},
+		{4, []int64{5}, true},
+		{5, nil, true},
+		{1, nil, false},
+		{6, nil, false},
+	} {
+		events, ok := log.Since(tt.since)
+		var ids []int64
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		if ok != tt.ok || len(ids) != len(tt.want) {
+			t.Errorf("Since(%d): expected %v (ok=%v), got %v (ok=%v)", tt.since, tt.want, tt.ok, ids, ok)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("Since(%d): expected %v, got %v", tt.since, tt.want, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestEventLogForTask(t *testing.T) {
+	log := NewEventLog(10)
+	log.HandleEvent(Event{ID: 1, Type: EventTaskCreated, Task: Task{ID: 1}})
+	log.HandleEvent(Event{ID: 2, Type: EventTaskCreated, Task: Task{ID: 2}})
+	log.HandleEvent(Event{ID: 3, Type: EventTaskUpdated, Task: Task{ID: 1}})
+
+	events := log.ForTask(1)
+	if len(events) != 2 || events[0].ID != 1 || events[1].ID != 3 {
+		t.Errorf("Expected events 1 and 3, got %+v", events)
+	}
+	if events := log.ForTask(9); len(events) != 0 {
+		t.Errorf("Expected no events for an unknown task, got %+v", events)
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType はタスクに起きた変更の種類です
+type EventType string
+
+const (
+	EventTaskCreated EventType = "task.created"
+	EventTaskUpdated EventType = "task.updated"
+	EventTaskDeleted EventType = "task.deleted"
+	// EventTaskReminder はリマインダーの日時になったタスクを知らせます（FireReminder）
+	EventTaskReminder EventType = "task.reminder"
+)
+
+// Event はタスクの変更を表すイベントです
+// ID: 発生順に振られる通し番号（購読者が順序を判断するために使えます）
+// Task: 変更後のタスク（削除の場合は削除直前のタスク）
+// ctx: 変更を行った操作のコンテキスト（JSON には含めません）
+type Event struct {
+	ID   int64     `json:"id"`
+	Type EventType `json:"type"`
+	Task Task      `json:"task"`
+	Time time.Time `json:"time"`
+
+	ctx context.Context
+}
+
+// NewEvent は変更を行った操作のコンテキスト ctx を持つイベントを作成します
+// TaskStore を独自に実装するストアが、TodoApp と同じイベントを配信するために使います
+func NewEvent(ctx context.Context, id int64, eventType EventType, task Task) Event {
+	return Event{ID: id, Type: eventType, Task: task, Time: time.Now(), ctx: ctx}
+}
+
+// Context は変更を行った操作のコンテキストを返します
+// 購読者はリクエストIDやトレースなどの値を引き継ぐために使えます。未設定なら context.Background() を返します
+// 配信後もリクエストが終わるとキャンセルされるため、非同期の処理に使う場合は値だけを引き継いでください
+func (e Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// EventHandler はイベントを受け取る購読者の関数です
+// 変更を行ったゴルーチン上で同期的に呼ばれるため、時間のかかる処理は
+// 購読者側でキューに積むなどして非同期に行ってください
+type EventHandler func(Event)
+
+// eventBus は TodoApp の変更を購読者へ配信します
+// lastID: 最後に振ったイベントの番号（TodoApp のロック内で更新します）
+// now: イベントの時刻に使う時計（TodoApp の WithClock で変更できます）
+type eventBus struct {
+	lastID int64
+	now    func() time.Time
+
+	mutex       sync.RWMutex
+	subscribers map[int]EventHandler
+	nextSubID   int
+}
+
+// newEvent は番号を振ったイベントを作成します。TodoApp のロック中に呼び出します
+func (b *eventBus) newEvent(ctx context.Context, eventType EventType, task Task) Event {
+	b.lastID++
+	event := NewEvent(ctx, b.lastID, eventType, task)
+	if b.now != nil {
+		event.Time = b.now()
+	}
+	return event
+}
+
+// publish はすべての購読者にイベントを配信します
+// 購読者が TodoApp を操作できるよう、TodoApp のロックを外してから呼び出します
+func (b *eventBus) publish(event Event) {
+	b.mutex.RLock()
+	handlers := make([]EventHandler, 0, len(b.subscribers))
+	for _, handler := range b.subscribers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Subscribe はタスクの変更イベントを購読し、購読を解除する関数を返します
+func (app *TodoApp) Subscribe(handler EventHandler) (unsubscribe func()) {
+	b := &app.events
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]EventHandler)
+	}
+	id := b.nextSubID
+	b.nextSubID++
+	b.subscribers[id] = handler
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.subscribers, id)
+	}
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribeReceivesEvents(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+
+	var events []Event
+	unsubscribe := app.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	task, _ := app.AddTask(ctx, "Task")
+	app.ToggleTask(ctx, task.ID)
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	app.SetDueDate(ctx, task.ID, &due)
+	app.DeleteTask(ctx, task.ID)
+	app.ToggleTask(ctx, 999)
+
+	expected := []EventType{EventTaskCreated, EventTaskUpdated, EventTaskUpdated, EventTaskDeleted}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, eventType := range expected {
+		if events[i].Type != eventType {
+			t.Errorf("Event %d: expected %s, got %s", i, eventType, events[i].Type)
+		}
+		if events[i].ID != int64(i+1) {
+			t.Errorf("Event %d: expected ID %d, got %d", i, i+1, events[i].ID)
+		}
+	}
+	if !events[1].Task.Completed {
+		t.Error("Expected update event to carry the toggled task")
+	}
+	if events[2].Task.DueDate == nil || !events[2].Task.DueDate.Equal(due) {
+		t.Error("Expected update event to carry the due date")
+	}
+	if events[3].Task.ID != task.ID {
+		t.Error("Expected delete event to carry the deleted task")
+	}
+
+	unsubscribe()
+	app.AddTask(ctx, "After unsubscribe")
+	if len(events) != len(expected) {
+		t.Error("Expected no events after unsubscribe")
+	}
+}
+
+func TestSubscriberCanModifyApp(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+
+	app.Subscribe(func(e Event) {
+		if e.Type == EventTaskCreated && !e.Task.Completed {
+			app.ToggleTask(ctx, e.Task.ID)
+		}
+	})
+
+	task, _ := app.AddTask(ctx, "Auto complete")
+	if !app.GetTasks(ctx)[0].Completed || task.Completed {
+		t.Error("Expected subscriber to be able to modify the app")
+	}
+}
+
+func TestSubscribeConcurrency(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+
+	var mutex sync.Mutex
+	count := 0
+	app.Subscribe(func(e Event) {
+		mutex.Lock()
+		count++
+		mutex.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(50)
+	for i := 0; i < 50; i++ {
+		go func() {
+			defer wg.Done()
+			app.AddTask(ctx, "Task")
+		}()
+	}
+	wg.Wait()
+
+	if count != 50 {
+		t.Errorf("Expected 50 events, got %d", count)
+	}
+}
+
+func TestSetDueDate(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	task, _ := app.AddTask(ctx, "Task")
+
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := app.SetDueDate(ctx, task.ID, &due); err != nil {
+		t.Error("Expected SetDueDate to return true for existing task")
+	}
+	if got := app.GetTasks(ctx)[0].DueDate; got == nil || !got.Equal(due) {
+		t.Errorf("Expected due date %v, got %v", due, got)
+	}
+
+	app.SetDueDate(ctx, task.ID, nil)
+	if app.GetTasks(ctx)[0].DueDate != nil {
+		t.Error("Expected due date to be cleared")
+	}
+
+	if err := app.SetDueDate(ctx, 999, &due); !errors.Is(err, ErrTaskNotFound) {
+		t.Error("Expected SetDueDate to return false for non-existent task")
+	}
+}
+
+func TestTaskTimestamps(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	app := NewTodoApp(WithClock(func() time.Time { return now }))
+
+	task, _ := app.AddTask(ctx, "Task")
+	if task.CreatedAt == nil || !task.CreatedAt.Equal(now) || task.CompletedAt != nil {
+		t.Errorf("Expected created at %v and no completion, got %v and %v", now, task.CreatedAt, task.CompletedAt)
+	}
+
+	if task.UpdatedAt == nil || !task.UpdatedAt.Equal(now) {
+		t.Errorf("Expected updated at %v, got %v", now, task.UpdatedAt)
+	}
+
+	now = now.Add(time.Hour)
+	app.ToggleTask(ctx, task.ID)
+	got := app.GetTasks(ctx)[0]
+	if got.CompletedAt == nil || !got.CompletedAt.Equal(now) {
+		t.Errorf("Expected completed at %v, got %v", now, got.CompletedAt)
+	}
+	if got.UpdatedAt == nil || !got.UpdatedAt.Equal(now) {
+		t.Errorf("Expected updated at %v after toggling, got %v", now, got.UpdatedAt)
+	}
+	if !got.CreatedAt.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Expected created at to stay unchanged, got %v", got.CreatedAt)
+	}
+
+	app.ToggleTask(ctx, task.ID)
+	if got := app.GetTasks(ctx)[0]; got.CompletedAt != nil {
+		t.Errorf("Expected completed at to be cleared, got %v", got.CompletedAt)
+	}
+}
+
+func TestSetScheduledDate(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	task, _ := app.AddTask(ctx, "Task")
+
+	scheduled := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := app.SetScheduledDate(ctx, task.ID, &scheduled); err != nil {
+		t.Errorf("Expected SetScheduledDate to succeed for existing task, got %v", err)
+	}
+	scheduled = scheduled.AddDate(0, 0, 1)
+	if got := app.GetTasks(ctx)[0].ScheduledDate; got == nil || !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the app to keep its own copy of the scheduled date, got %v", got)
+	}
+
+	app.SetScheduledDate(ctx, task.ID, nil)
+	if app.GetTasks(ctx)[0].ScheduledDate != nil {
+		t.Error("Expected scheduled date to be cleared")
+	}
+
+	if err := app.SetScheduledDate(ctx, 999, &scheduled); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for non-existent task, got %v", err)
+	}
+}
+
+func TestSetEstimate(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	task, _ := app.AddTask(ctx, "Task")
+
+	if err := app.SetEstimate(ctx, task.ID, 45); err != nil {
+		t.Errorf("Expected SetEstimate to succeed for existing task, got %v", err)
+	}
+	if got := app.GetTasks(ctx)[0].EstimateMinutes; got != 45 {
+		t.Errorf("Expected estimate 45, got %d", got)
+	}
+	if err := app.SetEstimate(ctx, task.ID, -5); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for a negative estimate, got %v", err)
+	}
+	if err := app.SetEstimate(ctx, 999, 10); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for non-existent task, got %v", err)
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	task, _ := app.AddTask(ctx, "Task")
+
+	if err := app.SetPriority(ctx, task.ID, PriorityHigh); err != nil {
+		t.Error("Expected SetPriority to return true for existing task")
+	}
+	if got := app.GetTasks(ctx)[0].Priority; got != PriorityHigh {
+		t.Errorf("Expected priority high, got %q", got)
+	}
+
+	app.SetPriority(ctx, task.ID, "")
+	if app.GetTasks(ctx)[0].Priority != "" {
+		t.Error("Expected priority to be cleared")
+	}
+
+	if err := app.SetPriority(ctx, 999, PriorityLow); !errors.Is(err, ErrTaskNotFound) {
+		t.Error("Expected SetPriority to return false for non-existent task")
+	}
+}
+
+type testContextKey struct{}
+
+func TestEventContext(t *testing.T) {
+	app := NewTodoApp()
+	var events []Event
+	app.Subscribe(func(event Event) { events = append(events, event) })
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "trace-1")
+	task, _ := app.AddTask(ctx, "Traced")
+	app.ToggleTask(context.Background(), task.ID)
+
+	if got := events[0].Context().Value(testContextKey{}); got != "trace-1" {
+		t.Errorf("Expected the event to carry the caller's context, got %v", got)
+	}
+	if events[1].Context().Value(testContextKey{}) != nil {
+		t.Error("Expected the second event to carry its own context")
+	}
+	if (Event{}).Context() == nil {
+		t.Error("Expected a zero Event to return a non-nil context")
+	}
+}
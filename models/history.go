@@ -0,0 +1,67 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// HistoryEntry はタスクの変更履歴の1件です
+// Fields: 変わった項目の JSON の名前（名前順）。作成と削除、直前の状態がわからない変更では空です
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Type   EventType `json:"type"`
+	Fields []string  `json:"fields,omitempty"`
+}
+
+// historyIgnored は変更履歴で変わった項目に数えない項目です（ほかの項目の変更に伴って変わるものです）
+var historyIgnored = map[string]bool{"updated_at": true, "tracked_seconds": true, "progress": true, "blind_index": true}
+
+// TaskHistory は events（記録した順）から指定IDのタスクの変更履歴を新しい順に作ります
+// 変わった項目は、続くイベントのタスクの状態を比べて求めます
+func TaskHistory(events []Event, id int) []HistoryEntry {
+	var history []HistoryEntry
+	var previous map[string]json.RawMessage
+	for _, event := range events {
+		if event.Task.ID != id {
+			continue
+		}
+		current := taskFields(event.Task)
+		entry := HistoryEntry{Time: event.Time, Type: event.Type}
+		if event.Type == EventTaskUpdated && previous != nil {
+			entry.Fields = changedFields(previous, current)
+		}
+		history = append(history, entry)
+		previous = current
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history
+}
+
+// taskFields はタスクを JSON の項目ごとに分けます
+func taskFields(task Task) map[string]json.RawMessage {
+	data, _ := json.Marshal(task)
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// changedFields は before と after で値の違う項目の名前を名前順に返します
+func changedFields(before, after map[string]json.RawMessage) []string {
+	var changed []string
+	for name, value := range after {
+		if !historyIgnored[name] && !bytes.Equal(before[name], value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok && !historyIgnored[name] {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
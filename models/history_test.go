@@ -0,0 +1,39 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTaskHistory(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	updated := start.Add(time.Minute)
+	task := Task{ID: 1, Title: "Write report"}
+	renamed := Task{ID: 1, Title: "Write the report", Priority: PriorityHigh, UpdatedAt: &updated}
+	completed := renamed
+	completed.Completed = true
+	completed.TrackedSeconds = 60
+
+	events := []Event{
+		{ID: 1, Type: EventTaskCreated, Task: task, Time: start},
+		{ID: 2, Type: EventTaskCreated, Task: Task{ID: 2, Title: "Other"}, Time: start},
+		{ID: 3, Type: EventTaskUpdated, Task: renamed, Time: start.Add(time.Minute)},
+		{ID: 4, Type: EventTaskUpdated, Task: completed, Time: start.Add(2 * time.Minute)},
+		{ID: 5, Type: EventTaskDeleted, Task: completed, Time: start.Add(3 * time.Minute)},
+	}
+	want := []HistoryEntry{
+		{Time: start.Add(3 * time.Minute), Type: EventTaskDeleted},
+		{Time: start.Add(2 * time.Minute), Type: EventTaskUpdated, Fields: []string{"completed"}},
+		{Time: start.Add(time.Minute), Type: EventTaskUpdated, Fields: []string{"priority", "title"}},
+		{Time: start, Type: EventTaskCreated},
+	}
+	if got := TaskHistory(events, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// 作成のイベントを捨てた後の最初の変更は、何が変わったかわかりません
+	if got := TaskHistory(events[3:], 1); len(got) != 2 || got[1].Fields != nil {
+		t.Errorf("Expected the first known update to have no fields, got %+v", got)
+	}
+}
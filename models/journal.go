@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNothingToUndo はセッションに取り消せる操作が残っていないことを表します
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// OperationKind は取り消せる操作の種類です
+type OperationKind string
+
+const (
+	OperationAdd    OperationKind = "add"
+	OperationToggle OperationKind = "toggle"
+	OperationEdit   OperationKind = "edit"
+	OperationDelete OperationKind = "delete"
+)
+
+// journalDepth はセッションごとに覚えておく操作の件数です。超えたら古いものから捨てます
+const journalDepth = 20
+
+// journalTTL は操作を取り消せる期間です。これより前の操作は取り消せず、この間操作のなかったセッションの記録は捨てます
+const journalTTL = time.Hour
+
+// journalScope は WithSession で作った1つのコンテキスト（リクエスト）です
+// 同じ scope で同じタスクに続けて行った操作は、1つの操作としてまとめます
+type journalScope struct {
+	session string
+}
+
+// sessionKey は操作したセッションをコンテキストに入れるキーです
+type sessionKey struct{}
+
+// WithSession は操作したセッション session を持つコンテキストを返します
+// TodoApp はセッションごとに操作を記録し、Undo はそのコンテキストのセッションの最後の操作を取り消します
+// 返したコンテキストで同じタスクに続けて行った操作（追加してから期限を付けるなど）は1つの操作として記録します
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &journalScope{session: session})
+}
+
+// Session はコンテキストのセッションを返します（なければ空）
+func Session(ctx context.Context) string {
+	if scope, ok := ctx.Value(sessionKey{}).(*journalScope); ok {
+		return scope.session
+	}
+	return ""
+}
+
+// Operation は取り消せる操作の記録です
+// Kind: 操作の種類
+// TaskID: 操作したタスクの ID
+// Time: 操作した日時
+// Before: 操作する前のタスク（追加では nil）。編集と完了状態の切り替えは、取り消すとこの状態に戻します
+// After: 操作した後のタスクの UpdatedAt（削除では DeletedAt）。取り消す前に、その後ほかの変更がないことを確かめます
+type Operation struct {
+	Kind   OperationKind `json:"kind"`
+	TaskID int           `json:"task_id"`
+	Time   time.Time     `json:"time"`
+
+	Before *Task     `json:"-"`
+	After  time.Time `json:"-"`
+
+	scope *journalScope
+}
+
+// Journal はセッションごとに最近の操作を記録します（Undo で取り消すため）
+// 記録はメモリ上だけに持ち、サーバを再起動すると消えます。ゼロ値のまま使えます
+type Journal struct {
+	mutex    sync.Mutex
+	sessions map[string][]Operation
+}
+
+// Record はコンテキストのセッションの操作として op を記録します。セッションのないコンテキストでは何もしません
+// 直前の操作と同じコンテキストで同じタスクへの操作なら、直前の操作にまとめます（Before は最初のものを残します）
+// Kind が空の op は、操作としては記録せず、まとめられる直前の操作があれば After だけを更新します
+func (j *Journal) Record(ctx context.Context, op Operation) {
+	scope, ok := ctx.Value(sessionKey{}).(*journalScope)
+	if !ok || scope.session == "" {
+		return
+	}
+	op.scope = scope
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.sessions == nil {
+		j.sessions = make(map[string][]Operation)
+	}
+	for session, ops := range j.sessions {
+		if op.Time.Sub(ops[len(ops)-1].Time) > journalTTL {
+			delete(j.sessions, session)
+		}
+	}
+
+	ops := j.sessions[scope.session]
+	if n := len(ops); n > 0 && ops[n-1].scope == scope && ops[n-1].TaskID == op.TaskID {
+		last := &ops[n-1]
+		last.After = op.After
+		if last.Kind == OperationToggle && op.Kind != "" && op.Kind != OperationToggle {
+			last.Kind = op.Kind
+		}
+		return
+	}
+	if op.Kind == "" {
+		return
+	}
+	ops = append(ops, op)
+	if len(ops) > journalDepth {
+		ops = append([]Operation(nil), ops[len(ops)-journalDepth:]...)
+	}
+	j.sessions[scope.session] = ops
+}
+
+// Pop はコンテキストのセッションの最後の操作を記録から取り出します
+// 取り消せる操作がない（now から journalTTL より前の操作しかない場合も含みます）と ErrNothingToUndo を返します
+func (j *Journal) Pop(ctx context.Context, now time.Time) (Operation, error) {
+	session := Session(ctx)
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	ops := j.sessions[session]
+	if len(ops) == 0 || now.Sub(ops[len(ops)-1].Time) > journalTTL {
+		delete(j.sessions, session)
+		return Operation{}, ErrNothingToUndo
+	}
+	op := ops[len(ops)-1]
+	if len(ops) == 1 {
+		delete(j.sessions, session)
+	} else {
+		j.sessions[session] = ops[:len(ops)-1]
+	}
+	op.scope = nil
+	return op, nil
+}
+
+// touch はコンテキストのセッションで taskID のタスクに行った最後の操作の After を after にします
+// 取り消してタスクを1つ前の操作の後の状態に戻したとき、その操作を続けて取り消せるようにします
+func (j *Journal) touch(ctx context.Context, taskID int, after time.Time) {
+	session := Session(ctx)
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	ops := j.sessions[session]
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].TaskID == taskID {
+			ops[i].After = after
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// tickingClock は呼ぶたびに1秒ずつ進む時計です
+func tickingClock(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestUndoConflictsWithLaterChanges(t *testing.T) {
+	app := NewTodoApp(WithClock(tickingClock(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC))))
+	alice := func() context.Context { return WithSession(context.Background(), "alice") }
+	task, _ := app.AddTask(alice(), "Report")
+	app.SetTitle(alice(), task.ID, "Final report")
+	app.ToggleTask(WithSession(context.Background(), "bob"), task.ID)
+
+	if _, _, err := app.Undo(alice()); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict after another session changed the task, got %v", err)
+	}
+	if tasks := app.GetTasks(context.Background()); tasks[0].Title != "Final report" || !tasks[0].Completed {
+		t.Errorf("Expected the task to be left alone, got %+v", tasks[0])
+	}
+	// 取り消せなかった操作は記録から外し、その前の操作も同じ理由で取り消せません
+	if _, _, err := app.Undo(alice()); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected the add to conflict as well, got %v", err)
+	}
+	if _, _, err := app.Undo(alice()); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected nothing left to undo, got %v", err)
+	}
+
+	// 取り消しは続けて行えます（取り消した後の状態を次の操作の後の状態として扱います）
+	ctx := alice()
+	app.SetTitle(ctx, task.ID, "Draft")
+	app.SetPriority(alice(), task.ID, PriorityHigh)
+	app.Undo(alice())
+	if _, undone, err := app.Undo(alice()); err != nil || undone.Title != "Final report" || undone.Priority != "" {
+		t.Errorf("Expected consecutive undos to succeed, got %+v %v", undone, err)
+	}
+}
+
+func TestUndoSkipsNonJournaledChanges(t *testing.T) {
+	app := NewTodoApp(WithClock(tickingClock(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC))))
+	ctx := WithSession(context.Background(), "alice")
+	task, _ := app.AddTask(ctx, "Secret")
+	app.SetBlindIndex(ctx, task.ID, []string{"token"})
+
+	// 同じリクエストの検索用トークンは追加にまとめるため、取り消せます
+	if op, _, err := app.Undo(WithSession(context.Background(), "alice")); err != nil || op.Kind != OperationAdd {
+		t.Errorf("Expected the add to be undone, got %+v %v", op, err)
+	}
+	if _, _, err := app.Undo(context.Background()); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected a context without a session to have nothing to undo, got %v", err)
+	}
+}
+
+func TestJournalLimits(t *testing.T) {
+	var journal Journal
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= journalDepth+5; i++ {
+		journal.Record(WithSession(context.Background(), "alice"), Operation{Kind: OperationEdit, TaskID: i, Time: start})
+	}
+	ctx := WithSession(context.Background(), "alice")
+	for i := journalDepth + 5; i > 5; i-- {
+		if op, err := journal.Pop(ctx, start); err != nil || op.TaskID != i {
+			t.Fatalf("Expected task %d, got %+v %v", i, op, err)
+		}
+	}
+	if _, err := journal.Pop(ctx, start); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected only the latest %d operations to be kept, got %v", journalDepth, err)
+	}
+
+	journal.Record(ctx, Operation{Kind: OperationEdit, TaskID: 1, Time: start})
+	if _, err := journal.Pop(ctx, start.Add(journalTTL+time.Second)); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected an expired operation not to be undone, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Option は NewTodoApp / NewTodoAppFromTasks に渡す設定です
+type Option func(app *TodoApp)
+
+// WithStartID は最初に採番するIDを id にします（既存タスクの最大ID+1 の方が大きければそちらを使います）
+func WithStartID(id int) Option {
+	return func(app *TodoApp) {
+		if id > 0 {
+			app.startID = id
+		}
+	}
+}
+
+// WithMaxTasks は保持できるタスクの件数を n 件までに制限します（0 以下なら無制限）
+// 上限に達した状態で AddTask を呼ぶと ErrConflict を返します
+func WithMaxTasks(n int) Option {
+	return func(app *TodoApp) {
+		app.maxTasks = n
+	}
+}
+
+// WithClock はイベントの時刻や作成・完了日時に使う現在時刻の取得方法を now に置き換えます（テスト用）
+func WithClock(now func() time.Time) Option {
+	return func(app *TodoApp) {
+		if now != nil {
+			app.now = now
+		}
+	}
+}
+
+// WithIDGenerator は新しいタスクのIDを next で採番します
+// 既存のタスクと重複するIDや 0 以下のIDを返した場合、AddTask は ErrConflict を返します
+func WithIDGenerator(next func() int) Option {
+	return func(app *TodoApp) {
+		app.generateID = next
+	}
+}
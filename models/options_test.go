@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithStartID(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp(WithStartID(100))
+	if task, _ := app.AddTask(ctx, "First"); task.ID != 100 {
+		t.Errorf("Expected ID 100, got %d", task.ID)
+	}
+
+	// 既存タスクの最大ID+1 の方が大きければそちらを使います
+	restored := NewTodoAppFromTasks([]Task{{ID: 200, Title: "Old"}}, WithStartID(100))
+	if task, _ := restored.AddTask(ctx, "Next"); task.ID != 201 {
+		t.Errorf("Expected ID 201, got %d", task.ID)
+	}
+
+	// 置き換えて空になった場合は最初のIDから採番します
+	restored.ReplaceTasks(ctx, nil)
+	if task, _ := restored.AddTask(ctx, "Again"); task.ID != 100 {
+		t.Errorf("Expected ID 100 after ReplaceTasks, got %d", task.ID)
+	}
+
+	if task, _ := NewTodoApp(WithStartID(0)).AddTask(ctx, "Default"); task.ID != 1 {
+		t.Errorf("Expected an invalid start ID to be ignored, got %d", task.ID)
+	}
+}
+
+func TestWithMaxTasks(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp(WithMaxTasks(2))
+	app.AddTask(ctx, "One")
+	app.AddTask(ctx, "Two")
+
+	if _, err := app.AddTask(ctx, "Three"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict when the limit is reached, got %v", err)
+	}
+	if len(app.GetTasks(ctx)) != 2 {
+		t.Error("Expected the rejected task not to be added")
+	}
+
+	app.DeleteTask(ctx, 1)
+	if _, err := app.AddTask(ctx, "Three"); err != nil {
+		t.Errorf("Expected AddTask to succeed after deleting a task, got %v", err)
+	}
+
+	tooMany := []Task{{ID: 1, Title: "A"}, {ID: 2, Title: "B"}, {ID: 3, Title: "C"}}
+	if err := app.ReplaceTasks(ctx, tooMany); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict when replacing with too many tasks, got %v", err)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	app := NewTodoApp(WithClock(func() time.Time { return now }))
+	var events []Event
+	app.Subscribe(func(event Event) { events = append(events, event) })
+
+	task, _ := app.AddTask(context.Background(), "Clocked")
+	now = now.Add(time.Hour)
+	app.ToggleTask(context.Background(), task.ID)
+
+	if !events[0].Time.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) || !events[1].Time.Equal(now) {
+		t.Errorf("Expected event times to come from the clock, got %v and %v", events[0].Time, events[1].Time)
+	}
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	ctx := context.Background()
+	ids := []int{42, 7, 42, 0}
+	app := NewTodoApp(WithIDGenerator(func() int {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}))
+
+	first, _ := app.AddTask(ctx, "First")
+	second, _ := app.AddTask(ctx, "Second")
+	if first.ID != 42 || second.ID != 7 {
+		t.Errorf("Expected generated IDs 42 and 7, got %d and %d", first.ID, second.ID)
+	}
+
+	if _, err := app.AddTask(ctx, "Duplicate"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate generated ID, got %v", err)
+	}
+	if _, err := app.AddTask(ctx, "Zero"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a non-positive generated ID, got %v", err)
+	}
+	if len(app.GetTasks(ctx)) != 2 {
+		t.Error("Expected rejected tasks not to be added")
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// タスクの一覧を並べ替えるキーです。先頭に - を付けると逆順になります（"-created" で新しい順）
+const (
+	SortByID        = "id"
+	SortByCreated   = "created"
+	SortByTitle     = "title"
+	SortByDueDate   = "due_date"
+	SortByCompleted = "completed"
+)
+
+// MaxPageLimit は1ページに返せるタスクの件数の上限です
+const MaxPageLimit = 500
+
+// sortKeys は並べ替えのキーと比べ方です。比べ方は a が先なら負、後なら正、同じなら 0 を返します
+var sortKeys = map[string]func(a, b Task) int{
+	SortByID:        func(a, b Task) int { return compareInt(a.ID, b.ID) },
+	SortByCreated:   compareCreated,
+	SortByTitle:     func(a, b Task) int { return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)) },
+	SortByDueDate:   compareDueDate,
+	SortByCompleted: func(a, b Task) int { return compareBool(a.Completed, b.Completed) },
+}
+
+// TaskPage はタスクの一覧の1ページです
+// Total: 絞り込んだ後、ページに分ける前のタスクの件数
+// Offset / Limit: ページの先頭の位置と件数の上限（Limit が 0 なら上限なし）
+type TaskPage struct {
+	Tasks  []Task
+	Total  int
+	Offset int
+	Limit  int
+}
+
+// SortTasks は key の順に tasks を並べ替えます。key が空なら何もしません
+//   - id: 追加した順 / created: 作成した日時の古い順（作成日時のないタスクが先）
+//   - title: タイトルの順（大文字・小文字を区別しません）
+//   - due_date: 期限の近い順（期限のないタスクは逆順でも最後）
+//   - completed: 未完了のタスクが先
+//
+// 同じ順位のタスクどうしは並べ替える前の順のままにします。知らないキーは ErrValidation を返します
+func SortTasks(tasks []Task, key string) error {
+	if key == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(key, "-")
+	descending := name != key
+	compare, ok := sortKeys[name]
+	if !ok {
+		return fmt.Errorf("%w: sort must be one of %s, %s, %s, %s or %s (prefix - to reverse)",
+			ErrValidation, SortByID, SortByCreated, SortByTitle, SortByDueDate, SortByCompleted)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if name == SortByDueDate && (a.DueDate == nil) != (b.DueDate == nil) {
+			return a.DueDate != nil
+		}
+		if descending {
+			return compare(a, b) > 0
+		}
+		return compare(a, b) < 0
+	})
+	return nil
+}
+
+// Paginate は tasks の offset 件目から最大 limit 件（limit が 0 ならすべて）を1ページとして返します
+// offset がタスクの件数を超えたときは空のページです。offset が負か limit が 0〜MaxPageLimit の外なら ErrValidation を返します
+func Paginate(tasks []Task, offset, limit int) (TaskPage, error) {
+	if offset < 0 {
+		return TaskPage{}, fmt.Errorf("%w: offset must not be negative", ErrValidation)
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return TaskPage{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxPageLimit)
+	}
+
+	page := TaskPage{Tasks: []Task{}, Total: len(tasks), Offset: offset, Limit: limit}
+	if offset >= len(tasks) {
+		return page, nil
+	}
+	end := len(tasks)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	page.Tasks = tasks[offset:end]
+	return page, nil
+}
+
+// compareCreated は作成した日時で比べます。作成日時のないタスク（記録する前に作られたもの）を古いものとして先にします
+func compareCreated(a, b Task) int {
+	switch {
+	case a.CreatedAt == nil && b.CreatedAt == nil:
+		return 0
+	case a.CreatedAt == nil:
+		return -1
+	case b.CreatedAt == nil:
+		return 1
+	case a.CreatedAt.Before(*b.CreatedAt):
+		return -1
+	case b.CreatedAt.Before(*a.CreatedAt):
+		return 1
+	}
+	return 0
+}
+
+// compareDueDate は期限で比べます。期限のないタスクどうしは同じとみなします（ないものを最後にするのは SortTasks です）
+func compareDueDate(a, b Task) int {
+	if a.DueDate == nil || b.DueDate == nil {
+		return 0
+	}
+	switch {
+	case a.DueDate.Before(*b.DueDate):
+		return -1
+	case b.DueDate.Before(*a.DueDate):
+		return 1
+	}
+	return 0
+}
+
+// compareInt は a と b の大小を -1 / 0 / 1 で返します
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareBool は false を true より前として比べます
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	}
+	return 1
+}
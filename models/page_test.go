@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortTasks(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	tasks := []Task{
+		{ID: 1, Title: "banana", CreatedAt: day(3), DueDate: day(20)},
+		{ID: 2, Title: "Apple", Completed: true, CreatedAt: day(1)},
+		{ID: 3, Title: "cherry", DueDate: day(10)},
+		{ID: 4, Title: "apple pie", Completed: true, CreatedAt: day(2), DueDate: day(20)},
+	}
+
+	for _, tt := range []struct {
+		key  string
+		want string
+	}{
+		{"", "1,2,3,4"},
+		{"id", "1,2,3,4"},
+		{"-id", "4,3,2,1"},
+		{"created", "3,2,4,1"},
+		{"-created", "1,4,2,3"},
+		{"title", "2,4,1,3"},
+		{"due_date", "3,1,4,2"},
+		{"-due_date", "1,4,3,2"},
+		{"completed", "1,3,2,4"},
+		{"-completed", "2,4,1,3"},
+	} {
+		sorted := append([]Task(nil), tasks...)
+		if err := SortTasks(sorted, tt.key); err != nil {
+			t.Fatalf("SortTasks(%q) failed: %v", tt.key, err)
+		}
+		var ids []string
+		for _, task := range sorted {
+			ids = append(ids, strconv.Itoa(task.ID))
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("SortTasks(%q): expected %s, got %s", tt.key, tt.want, got)
+		}
+	}
+
+	for _, key := range []string{"priority", "--id", "-"} {
+		if err := SortTasks(tasks, key); !errors.Is(err, ErrValidation) {
+			t.Errorf("SortTasks(%q): expected ErrValidation, got %v", key, err)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tasks := []Task{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+
+	for _, tt := range []struct {
+		offset, limit int
+		want          int
+	}{
+		{0, 0, 5},
+		{0, 2, 2},
+		{4, 2, 1},
+		{5, 2, 0},
+		{9, 0, 0},
+	} {
+		page, err := Paginate(tasks, tt.offset, tt.limit)
+		if err != nil {
+			t.Fatalf("Paginate(%d, %d) failed: %v", tt.offset, tt.limit, err)
+		}
+		if len(page.Tasks) != tt.want || page.Total != 5 {
+			t.Errorf("Paginate(%d, %d): expected %d of 5 tasks, got %d of %d", tt.offset, tt.limit, tt.want, len(page.Tasks), page.Total)
+		}
+		if tt.want > 0 && page.Tasks[0].ID != tt.offset+1 {
+			t.Errorf("Paginate(%d, %d): expected the page to start at task %d, got %d", tt.offset, tt.limit, tt.offset+1, page.Tasks[0].ID)
+		}
+	}
+
+	for _, bad := range [][2]int{{-1, 0}, {0, -1}, {0, MaxPageLimit + 1}} {
+		if _, err := Paginate(tasks, bad[0], bad[1]); !errors.Is(err, ErrValidation) {
+			t.Errorf("Paginate(%d, %d): expected ErrValidation, got %v", bad[0], bad[1], err)
+		}
+	}
+}
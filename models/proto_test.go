@@ -0,0 +1,62 @@
+package models
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// protoMessage は .proto の message の中身（フィールドの名前を宣言順に）を返します
+func protoMessage(t *testing.T, source, name string) []string {
+	t.Helper()
+	body := regexp.MustCompile(`(?s)message ` + name + ` \{(.*?)\n\}`).FindStringSubmatch(source)
+	if body == nil {
+		t.Fatalf("message %s not found in the proto file", name)
+	}
+	var fields []string
+	for _, m := range regexp.MustCompile(`(?m)^\s+(?:repeated )?[\w.]+ (\w+) = \d+;`).FindAllStringSubmatch(body[1], -1) {
+		fields = append(fields, m[1])
+	}
+	return fields
+}
+
+// jsonFields は struct の json タグの名前を宣言順に返します
+func jsonFields(v interface{}) []string {
+	var fields []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// TestProtoMatchesModels は proto/todo/v1/todo.proto が Task などの JSON と同じフィールドを持つことを確認します
+func TestProtoMatchesModels(t *testing.T) {
+	data, err := os.ReadFile("../proto/todo/v1/todo.proto")
+	if err != nil {
+		t.Fatalf("Failed to read the proto file: %v", err)
+	}
+	source := string(data)
+
+	for name, model := range map[string]interface{}{"Task": Task{}, "TimeEntry": TimeEntry{}, "Recurrence": Recurrence{}, "Progress": Progress{}, "Comment": Comment{}} {
+		if got, want := protoMessage(t, source, name), jsonFields(model); !reflect.DeepEqual(got, want) {
+			t.Errorf("message %s has fields %v, expected %v (the json tags of models.%s)", name, got, want, name)
+		}
+	}
+
+	var priorities []string
+	for _, p := range Priorities() {
+		priorities = append(priorities, "PRIORITY_"+strings.ToUpper(string(p)))
+	}
+	enum := regexp.MustCompile(`(?m)^\s+(PRIORITY_\w+) = [1-9]\d*;`).FindAllStringSubmatch(source, -1)
+	var got []string
+	for _, m := range enum {
+		got = append(got, m[1])
+	}
+	if !reflect.DeepEqual(got, priorities) {
+		t.Errorf("enum Priority has values %v, expected %v", got, priorities)
+	}
+}
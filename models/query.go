@@ -0,0 +1,274 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query は "priority>=high due<2025-03-01 -completed" のような検索式を読み取った条件の並びです
+// すべての条件に当てはまるタスクだけを選びます（条件がなければすべてのタスク）
+type Query struct {
+	Terms []QueryTerm `json:"terms"`
+}
+
+// QueryTerm は検索式の1つの条件です
+// Field: 比べる項目（title / tag / priority / due / scheduled / created / estimate / status）
+// Op: 比較の方法（: = < <= > >=、title の : は部分一致）
+// Value: 比べる値（日付は YYYY-MM-DD、期限やタグなどがないことは none）
+// Negate: 先頭に - を付けて条件を反転したかどうか
+type QueryTerm struct {
+	Field  string `json:"field"`
+	Op     string `json:"op"`
+	Value  string `json:"value"`
+	Negate bool   `json:"negate,omitempty"`
+
+	// date / number: 読み取り済みの日付・数値（優先度は順位を number に入れます）
+	date   time.Time
+	number int
+}
+
+// queryOps は長いものから順に並べた比較演算子です
+var queryOps = []string{">=", "<=", ":", "=", "<", ">"}
+
+// ParseQuery は検索式を読み取ります。空白で区切った条件はすべて満たす必要があります
+//   - "priority>=high" のような 項目 演算子 値
+//   - "completed" / "open"（完了・未完了）
+//   - それ以外の語や "..." で囲んだ語句はタイトルの部分一致（大文字・小文字を区別しません）
+//
+// 条件の先頭に - を付けると反転します。知らない項目や読めない値は ErrValidation を返します
+func ParseQuery(s string) (Query, error) {
+	words, err := splitQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+
+	query := Query{Terms: []QueryTerm{}}
+	for _, word := range words {
+		term, err := parseQueryTerm(word.text, word.quoted)
+		if err != nil {
+			return Query{}, err
+		}
+		query.Terms = append(query.Terms, term)
+	}
+	return query, nil
+}
+
+// queryWord は検索式を空白で区切った1語です。quoted は語が "..." で始まっていたかどうかです
+type queryWord struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery は検索式を空白で区切ります。"..." で囲んだ部分は空白を含めて1語にします
+func splitQuery(s string) ([]queryWord, error) {
+	var words []queryWord
+	var current strings.Builder
+	inQuotes, quoted, started := false, false, false
+
+	flush := func() {
+		if started {
+			words = append(words, queryWord{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		quoted, started = false, false
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			// 語の先頭の引用符だけが語句全体をタイトルとして扱う印になります（title:"a b" は項目の値）
+			if !inQuotes && (!started || current.String() == "-") {
+				quoted = true
+			}
+			inQuotes = !inQuotes
+			started = true
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("%w: unterminated quote in query", ErrValidation)
+	}
+	flush()
+	return words, nil
+}
+
+// parseQueryTerm は1語を条件として読み取ります
+func parseQueryTerm(word string, quoted bool) (QueryTerm, error) {
+	term := QueryTerm{}
+	if strings.HasPrefix(word, "-") && len(word) > 1 {
+		term.Negate = true
+		word = word[1:]
+	}
+	if quoted {
+		term.Field, term.Op, term.Value = "title", ":", word
+		return term, nil
+	}
+
+	switch strings.ToLower(word) {
+	case "completed", "open":
+		term.Field, term.Op, term.Value = "status", ":", strings.ToLower(word)
+		return term, nil
+	}
+
+	index, op := -1, ""
+	for _, candidate := range queryOps {
+		if i := strings.Index(word, candidate); i > 0 && (index < 0 || i < index || (i == index && len(candidate) > len(op))) {
+			index, op = i, candidate
+		}
+	}
+	if index < 0 {
+		term.Field, term.Op, term.Value = "title", ":", word
+		return term, nil
+	}
+	term.Field, term.Op, term.Value = strings.ToLower(word[:index]), op, word[index+len(op):]
+	if term.Op == "=" {
+		term.Op = ":"
+	}
+
+	invalid := func(format string) (QueryTerm, error) {
+		return QueryTerm{}, fmt.Errorf("%w: invalid value %q for %s (%s)", ErrValidation, term.Value, term.Field, format)
+	}
+	switch term.Field {
+	case "title":
+		if term.Op != ":" {
+			return QueryTerm{}, fmt.Errorf("%w: title only supports title:word", ErrValidation)
+		}
+	case "tag":
+		if term.Op != ":" {
+			return QueryTerm{}, fmt.Errorf("%w: tag only supports tag:name", ErrValidation)
+		}
+		if term.Value == "none" {
+			return term, nil
+		}
+		tag, err := NormalizeTag(term.Value)
+		if err != nil {
+			return QueryTerm{}, err
+		}
+		term.Value = tag
+	case "priority":
+		rank := priorityRank(Priority(term.Value))
+		if term.Value == "none" {
+			rank = 0
+		} else if rank == 0 {
+			return invalid("none, low, medium or high")
+		}
+		term.number = rank
+	case "due", "scheduled", "created":
+		if term.Value == "none" {
+			if term.Op != ":" {
+				return invalid("none can only be used with :")
+			}
+			return term, nil
+		}
+		date, err := time.Parse("2006-01-02", term.Value)
+		if err != nil {
+			return invalid("YYYY-MM-DD or none")
+		}
+		term.date = date
+	case "estimate":
+		minutes, err := strconv.Atoi(term.Value)
+		if err != nil || minutes < 0 {
+			return invalid("minutes")
+		}
+		term.number = minutes
+	default:
+		return QueryTerm{}, fmt.Errorf("%w: unknown field %q in query", ErrValidation, term.Field)
+	}
+	return term, nil
+}
+
+// priorityRank は優先度の順位を返します（未設定は 0、low は 1、high は 3）
+func priorityRank(p Priority) int {
+	for i, known := range Priorities() {
+		if p == known {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Match はタスクがすべての条件に当てはまるかを返します
+func (q Query) Match(task Task) bool {
+	for _, term := range q.Terms {
+		if term.Match(task) == term.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter は tasks のうち条件に当てはまるものを順序を保って返します
+func (q Query) Filter(tasks []Task) []Task {
+	matched := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		if q.Match(task) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// Match はタスクが条件に当てはまるかを返します（Negate は考慮しません）
+// 日付は保存されているタイムゾーンの年月日で比べます
+func (t QueryTerm) Match(task Task) bool {
+	switch t.Field {
+	case "title":
+		return strings.Contains(strings.ToLower(task.Title), strings.ToLower(t.Value))
+	case "tag":
+		if t.Value == "none" {
+			return len(task.Tags) == 0
+		}
+		return task.HasTag(t.Value)
+	case "status":
+		return task.Completed == (t.Value == "completed")
+	case "priority":
+		return compareInts(priorityRank(task.Priority), t.Op, t.number)
+	case "estimate":
+		return compareInts(task.EstimateMinutes, t.Op, t.number)
+	case "due":
+		return t.matchDate(task.DueDate)
+	case "scheduled":
+		return t.matchDate(task.ScheduledDate)
+	case "created":
+		return t.matchDate(task.CreatedAt)
+	}
+	return false
+}
+
+// matchDate は日付の条件を比べます。none は日付がないことを、それ以外は日付がある場合だけ比べます
+func (t QueryTerm) matchDate(value *time.Time) bool {
+	if t.Value == "none" {
+		return value == nil
+	}
+	if value == nil {
+		return false
+	}
+	date := time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case date.Before(t.date):
+		return t.Op == "<" || t.Op == "<="
+	case date.After(t.date):
+		return t.Op == ">" || t.Op == ">="
+	default:
+		return t.Op == ":" || t.Op == "<=" || t.Op == ">="
+	}
+}
+
+func compareInts(a int, op string, b int) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	query, err := ParseQuery(`priority>=high due<2025-03-01 -completed "weekly report" title:"team sync" estimate=30`)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+
+	want := []QueryTerm{
+		{Field: "priority", Op: ">=", Value: "high"},
+		{Field: "due", Op: "<", Value: "2025-03-01"},
+		{Field: "status", Op: ":", Value: "completed", Negate: true},
+		{Field: "title", Op: ":", Value: "weekly report"},
+		{Field: "title", Op: ":", Value: "team sync"},
+		{Field: "estimate", Op: ":", Value: "30"},
+	}
+	if len(query.Terms) != len(want) {
+		t.Fatalf("Expected %d terms, got %+v", len(want), query.Terms)
+	}
+	for i, term := range query.Terms {
+		if term.Field != want[i].Field || term.Op != want[i].Op || term.Value != want[i].Value || term.Negate != want[i].Negate {
+			t.Errorf("Term %d: expected %+v, got %+v", i, want[i], term)
+		}
+	}
+
+	if query, err := ParseQuery("   "); err != nil || len(query.Terms) != 0 {
+		t.Errorf("Expected an empty query, got %+v (%v)", query, err)
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	for _, s := range []string{
+		"list:work",
+		"tag>work",
+		"tag:two,tags",
+		"priority>=urgent",
+		"due<tomorrow",
+		"due<none",
+		"estimate>=-5",
+		"title>abc",
+		`"unterminated`,
+	} {
+		if _, err := ParseQuery(s); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", s, err)
+		}
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	date := func(day int) *time.Time {
+		d := time.Date(2025, 3, day, 18, 0, 0, 0, time.UTC)
+		return &d
+	}
+	tasks := []Task{
+		{ID: 1, Title: "Write weekly report", Priority: PriorityHigh, DueDate: date(1), EstimateMinutes: 60, Tags: []string{"work"}},
+		{ID: 2, Title: "Fix bug", Priority: PriorityMedium, DueDate: date(5), Completed: true, Tags: []string{"work", "urgent"}},
+		{ID: 3, Title: "Read a book", ScheduledDate: date(3)},
+		{ID: 4, Title: "Plan the WEEK", Priority: PriorityLow, DueDate: date(2), EstimateMinutes: 15},
+	}
+
+	testCases := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{"week", []int{1, 4}},
+		{"-week", []int{2, 3}},
+		{"priority>=medium", []int{1, 2}},
+		{"priority<medium", []int{3, 4}},
+		{"priority:none", []int{3}},
+		{"-priority:none -completed", []int{1, 4}},
+		{"open", []int{1, 3, 4}},
+		{"due<2025-03-02", []int{1}},
+		{"due<=2025-03-02", []int{1, 4}},
+		{"due>2025-03-02", []int{2}},
+		{"due:2025-03-05", []int{2}},
+		{"due>=2025-03-02 completed", []int{2}},
+		{"due:none", []int{3}},
+		{"scheduled:2025-03-03", []int{3}},
+		{"created:none", []int{1, 2, 3, 4}},
+		{"estimate>=30", []int{1}},
+		{"estimate<30", []int{2, 3, 4}},
+		{"estimate>0 estimate<=15", []int{4}},
+		{`"read a"`, []int{3}},
+		{"tag:work", []int{1, 2}},
+		{"tag:#Urgent", []int{2}},
+		{"-tag:urgent tag:work", []int{1}},
+		{"tag:none", []int{3, 4}},
+	}
+
+	for _, tc := range testCases {
+		query, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("%q: ParseQuery failed: %v", tc.query, err)
+			continue
+		}
+		got := query.Filter(tasks)
+		ids := make([]int, len(got))
+		for i, task := range got {
+			ids[i] = task.ID
+		}
+		if len(ids) != len(tc.want) {
+			t.Errorf("%q: expected %v, got %v", tc.query, tc.want, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tc.want[i] {
+				t.Errorf("%q: expected %v, got %v", tc.query, tc.want, ids)
+				break
+			}
+		}
+	}
+}
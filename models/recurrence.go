@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Frequency はタスクを繰り返す単位です
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+)
+
+// MaxRecurrenceInterval は繰り返す間隔（Interval）の最大値です
+const MaxRecurrenceInterval = 99
+
+// Recurrence はタスクの繰り返し方です。繰り返すタスクを完了すると、次の回のタスクを作ります（recurrence パッケージ）
+// Frequency: 繰り返す単位（毎日・毎週・毎月）
+// Interval: 何日・何週・何か月ごとに繰り返すか（省略か 0 なら 1）
+type Recurrence struct {
+	Frequency Frequency `json:"frequency"`
+	Interval  int       `json:"interval,omitempty"`
+}
+
+// Validate は単位が定義されたものか、間隔が 0 から MaxRecurrenceInterval までかを確認し、誤りがあれば ErrValidation を返します
+func (r Recurrence) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return fmt.Errorf("%w: unknown recurrence frequency %q (daily, weekly or monthly)", ErrValidation, r.Frequency)
+	}
+	if r.Interval < 0 || r.Interval > MaxRecurrenceInterval {
+		return fmt.Errorf("%w: recurrence interval must be 1 to %d", ErrValidation, MaxRecurrenceInterval)
+	}
+	return nil
+}
+
+// Next は日付 t の次の回の日付を返します
+// 毎月の繰り返しで次の月にその日がなければ（1月31日の1か月後など）、その月の末日にします
+func (r Recurrence) Next(t time.Time) time.Time {
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	switch r.Frequency {
+	case FrequencyWeekly:
+		return t.AddDate(0, 0, 7*interval)
+	case FrequencyMonthly:
+		next := t.AddDate(0, interval, 0)
+		if next.Day() != t.Day() {
+			// AddDate は月をあふれた日を翌月へ繰り越すため、前の月の末日へ戻します
+			next = next.AddDate(0, 0, -next.Day())
+		}
+		return next
+	}
+	return t.AddDate(0, 0, interval)
+}
+
+// NextDueDate は繰り返すタスクを completedAt に完了したときの、次の回の期限を返します
+// 期限があれば期限から、なければ完了した日から繰り返し、完了した日より後になるまで進めます（期限を過ぎてから完了しても、次の回は未来の日付です）
+// 期限と同じく、日付は UTC の 0 時で表します
+func (r Recurrence) NextDueDate(due *time.Time, completedAt time.Time) time.Time {
+	completed := time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(), 0, 0, 0, 0, time.UTC)
+	next := completed
+	if due != nil {
+		next = *due
+	}
+	next = r.Next(next)
+	for !next.After(completed) {
+		next = r.Next(next)
+	}
+	return next
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceValidate(t *testing.T) {
+	for _, r := range []Recurrence{{Frequency: FrequencyDaily}, {Frequency: FrequencyWeekly, Interval: 2}, {Frequency: FrequencyMonthly, Interval: MaxRecurrenceInterval}} {
+		if err := r.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", r, err)
+		}
+	}
+	for _, r := range []Recurrence{{}, {Frequency: "yearly"}, {Frequency: FrequencyDaily, Interval: -1}, {Frequency: FrequencyDaily, Interval: MaxRecurrenceInterval + 1}} {
+		if err := r.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected %+v to be invalid, got %v", r, err)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		recurrence Recurrence
+		from, want time.Time
+	}{
+		{Recurrence{Frequency: FrequencyDaily}, date(2025, 2, 28), date(2025, 3, 1)},
+		{Recurrence{Frequency: FrequencyDaily, Interval: 3}, date(2025, 3, 1), date(2025, 3, 4)},
+		{Recurrence{Frequency: FrequencyWeekly, Interval: 2}, date(2025, 3, 1), date(2025, 3, 15)},
+		{Recurrence{Frequency: FrequencyMonthly}, date(2025, 1, 15), date(2025, 2, 15)},
+		{Recurrence{Frequency: FrequencyMonthly}, date(2025, 1, 31), date(2025, 2, 28)},
+		{Recurrence{Frequency: FrequencyMonthly, Interval: 3}, date(2024, 11, 30), date(2025, 2, 28)},
+	} {
+		if got := tt.recurrence.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%+v.Next(%s): expected %s, got %s", tt.recurrence, tt.from.Format(time.DateOnly), tt.want.Format(time.DateOnly), got.Format(time.DateOnly))
+		}
+	}
+
+	weekly := Recurrence{Frequency: FrequencyWeekly}
+	due := date(2025, 3, 3)
+	if got := weekly.NextDueDate(&due, time.Date(2025, 3, 2, 20, 0, 0, 0, time.UTC)); !got.Equal(date(2025, 3, 10)) {
+		t.Errorf("Expected the next due date one week after the due date, got %s", got)
+	}
+	if got := weekly.NextDueDate(&due, time.Date(2025, 3, 20, 9, 0, 0, 0, time.UTC)); !got.Equal(date(2025, 3, 24)) {
+		t.Errorf("Expected an overdue occurrence to move past the completion date, got %s", got)
+	}
+	if got := weekly.NextDueDate(nil, time.Date(2025, 3, 20, 9, 0, 0, 0, time.UTC)); !got.Equal(date(2025, 3, 27)) {
+		t.Errorf("Expected a task without a due date to repeat from the completion date, got %s", got)
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReminderDue は now の時点でリマインダーを通知する時刻になっているか（まだ通知していない未完了のタスクか）を返します
+func (task Task) ReminderDue(now time.Time) bool {
+	return task.RemindAt != nil && task.RemindedAt == nil && !task.Completed && !task.RemindAt.After(now)
+}
+
+// SetReminder は指定IDのタスクのリマインダーを remindAt に設定します（nil でリマインダーを外します）
+// 通知済みの印（RemindedAt）は外すため、通知した後に設定し直すと、その日時にもう一度通知します
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) SetReminder(ctx context.Context, id int, remindAt *time.Time) error {
+	remindAt = copyTime(remindAt)
+	return app.updateTask(ctx, id, "", func(task *Task) {
+		task.RemindAt = remindAt
+		task.RemindedAt = nil
+	})
+}
+
+// FireReminder は指定IDのタスクのリマインダーを now に通知したものとして RemindedAt を付け、task.reminder のイベントを配信します
+// 通知する時刻になっていなければ（ReminderDue）ErrConflict を、見つからなければ ErrTaskNotFound を返します
+// 確認と書き換えをロックの中でまとめて行うため、同じリマインダーを2回通知することはありません
+func (app *TodoApp) FireReminder(ctx context.Context, id int, now time.Time) error {
+	return app.changeTask(ctx, id, "", EventTaskReminder, func(task *Task) error {
+		if !task.ReminderDue(now) {
+			return fmt.Errorf("%w: the reminder of task %d is not due", ErrConflict, id)
+		}
+		task.RemindedAt = &now
+		return nil
+	})
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// searchGramSize は索引に入れる n-gram の最大の長さ（文字数）です
+const searchGramSize = 3
+
+// searchFuzzyThreshold は3文字より長い語で、語の n-gram のうち一致したものの割合がこれ以上なら一致とみなす値です
+// 「repot」で「report」が見つかるなど、1文字程度の打ち間違いを許します
+const searchFuzzyThreshold = 0.5
+
+// SearchResult は検索に一致したタスクと一致の度合いです
+// Score: 0 より大きく 1 以下。検索語の n-gram をすべて含むタスクは 1 です
+type SearchResult struct {
+	Task  Task    `json:"task"`
+	Score float64 `json:"score"`
+}
+
+// SearchIndex はタスクのタイトルを1〜3文字の n-gram で引ける転置索引です
+// ストアの変更イベントで更新するため、検索のたびにすべてのタスクを調べずに済みます
+// 大文字・小文字は区別せず、空白で区切った語はすべて一致する必要があります
+type SearchIndex struct {
+	mutex    sync.RWMutex
+	tasks    map[int]Task
+	grams    map[int][]string
+	postings map[string]map[int]struct{}
+}
+
+// NewSearchIndex は空の SearchIndex を作成します
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		tasks:    map[int]Task{},
+		grams:    map[int][]string{},
+		postings: map[string]map[int]struct{}{},
+	}
+}
+
+// Watch は store のすべてのタスクを索引に入れ、以降の変更イベントで索引を更新し続けます。購読を解除する関数を返します
+// 読み込みの途中に届いたイベントは読み込みの後に反映するため、読み込みと並行した変更も取りこぼしません
+func (ix *SearchIndex) Watch(store TaskStore) (unsubscribe func()) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	unsubscribe = store.Subscribe(ix.HandleEvent)
+	for _, task := range store.GetTasks(context.Background()) {
+		ix.put(task)
+	}
+	return unsubscribe
+}
+
+// HandleEvent はタスクの変更イベントを索引に反映します
+func (ix *SearchIndex) HandleEvent(event Event) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	if event.Type == EventTaskDeleted {
+		ix.remove(event.Task.ID)
+		return
+	}
+	ix.put(event.Task)
+}
+
+// Len は索引に入っているタスクの数を返します
+func (ix *SearchIndex) Len() int {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+	return len(ix.tasks)
+}
+
+// put は task を索引に入れます（すでにあれば入れ直します）。ロック中に呼び出します
+func (ix *SearchIndex) put(task Task) {
+	ix.remove(task.ID)
+	grams := textGrams(searchText(task))
+	for _, gram := range grams {
+		ids := ix.postings[gram]
+		if ids == nil {
+			ids = map[int]struct{}{}
+			ix.postings[gram] = ids
+		}
+		ids[task.ID] = struct{}{}
+	}
+	ix.tasks[task.ID] = task.clone()
+	ix.grams[task.ID] = grams
+}
+
+// remove は id のタスクを索引から取り除きます。ロック中に呼び出します
+func (ix *SearchIndex) remove(id int) {
+	for _, gram := range ix.grams[id] {
+		delete(ix.postings[gram], id)
+		if len(ix.postings[gram]) == 0 {
+			delete(ix.postings, gram)
+		}
+	}
+	delete(ix.tasks, id)
+	delete(ix.grams, id)
+}
+
+// Search は query の語をすべて含むタスクを、一致の度合いの高い順（同じならID順）に最大 limit 件返します
+// 3文字までの語はそのまま含むものだけに、それより長い語は n-gram の半分以上を含むものにも一致します
+// 語がなければ空のスライスを返します
+func (ix *SearchIndex) Search(query string, limit int) []SearchResult {
+	words := strings.Fields(strings.ToLower(query))
+	results := []SearchResult{}
+	if len(words) == 0 {
+		return results
+	}
+
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	var total map[int]float64
+	for _, word := range words {
+		scores := ix.wordScores(word)
+		if total == nil {
+			total = scores
+			continue
+		}
+		for id, score := range total {
+			if s, ok := scores[id]; ok {
+				total[id] = score + s
+			} else {
+				delete(total, id)
+			}
+		}
+	}
+	for id, score := range total {
+		results = append(results, SearchResult{Task: ix.tasks[id].clone(), Score: score / float64(len(words))})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Task.ID < results[j].Task.ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// wordScores は1つの語に一致するタスクの ID ごとの一致の度合いを返します。ロック中に呼び出します
+func (ix *SearchIndex) wordScores(word string) map[int]float64 {
+	scores := map[int]float64{}
+	if len([]rune(word)) <= searchGramSize {
+		for id := range ix.postings[word] {
+			scores[id] = 1
+		}
+		return scores
+	}
+
+	grams := wordGrams(word)
+	counts := map[int]int{}
+	for _, gram := range grams {
+		for id := range ix.postings[gram] {
+			counts[id]++
+		}
+	}
+	for id, count := range counts {
+		if score := float64(count) / float64(len(grams)); score >= searchFuzzyThreshold {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+// searchText はタスクの検索の対象になる文字列（小文字にしたタイトルと説明）を返します
+func searchText(task Task) string {
+	return strings.ToLower(task.Title + "\n" + task.Description)
+}
+
+// textGrams は text の語ごとの1〜3文字の n-gram を重複なく返します
+func textGrams(text string) []string {
+	seen := map[string]bool{}
+	var grams []string
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for n := 1; n <= searchGramSize; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if !seen[gram] {
+					seen[gram] = true
+					grams = append(grams, gram)
+				}
+			}
+		}
+	}
+	return grams
+}
+
+// wordGrams は3文字より長い語の3文字の n-gram を重複なく返します
+func wordGrams(word string) []string {
+	runes := []rune(word)
+	seen := map[string]bool{}
+	var grams []string
+	for i := 0; i+searchGramSize <= len(runes); i++ {
+		gram := string(runes[i : i+searchGramSize])
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func searchIDs(results []SearchResult) []int {
+	ids := make([]int, len(results))
+	for i, result := range results {
+		ids[i] = result.Task.ID
+	}
+	return ids
+}
+
+func TestSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	app.AddTask(ctx, "Write the weekly report")
+	app.AddTask(ctx, "Buy milk")
+	index := NewSearchIndex()
+	index.Watch(app)
+	app.AddTask(ctx, "牛乳を買う")
+	app.AddTask(ctx, "Report bug to the vendor")
+
+	testCases := []struct {
+		query string
+		want  []int
+	}{
+		{"REPORT", []int{1, 4}},
+		{"repot", []int{1, 4}},
+		{"weekly report", []int{1}},
+		{"milk", []int{2}},
+		{"mi", []int{2}},
+		{"乳を", []int{3}},
+		{"牛乳 買う", []int{3}},
+		{"groceries", []int{}},
+		{"   ", []int{}},
+	}
+	for _, tc := range testCases {
+		got := searchIDs(index.Search(tc.query, 0))
+		if len(got) != len(tc.want) {
+			t.Errorf("%q: expected %v, got %v", tc.query, tc.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%q: expected %v, got %v", tc.query, tc.want, got)
+				break
+			}
+		}
+	}
+
+	// 打ち間違いの一致は、そのまま含むものより後に並びます
+	results := index.Search("repor", 0)
+	if len(results) != 2 || results[0].Score != 1 {
+		t.Errorf("expected exact matches to score 1, got %+v", results)
+	}
+	results = index.Search("vendro", 0)
+	if len(results) != 1 || results[0].Score >= 1 || results[0].Task.ID != 4 {
+		t.Errorf("expected a fuzzy match with a lower score, got %+v", results)
+	}
+	if got := index.Search("report", 1); len(got) != 1 || got[0].Task.ID != 1 {
+		t.Errorf("expected the limit to keep the first result, got %+v", got)
+	}
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	task, _ := app.AddTask(ctx, "Buy milk")
+	index := NewSearchIndex()
+	unsubscribe := index.Watch(app)
+
+	app.SetTitle(ctx, task.ID, "Buy oat milk")
+	if got := index.Search("oat", 0); len(got) != 1 || got[0].Task.Title != "Buy oat milk" {
+		t.Errorf("expected the renamed task, got %+v", got)
+	}
+	description := "from the farmers market"
+	app.UpdateTask(ctx, task.ID, TaskUpdate{Description: &description})
+	if got := index.Search("farmers", 0); len(got) != 1 || got[0].Task.ID != task.ID {
+		t.Errorf("expected the description to be searched, got %+v", got)
+	}
+	app.DeleteTask(ctx, task.ID)
+	if got := index.Search("milk", 0); len(got) != 0 || index.Len() != 0 {
+		t.Errorf("expected the deleted task to leave the index, got %+v", got)
+	}
+	app.ReplaceTasks(ctx, []Task{{ID: 7, Title: "Restored task"}})
+	if got := index.Search("restored", 0); len(got) != 1 || got[0].Task.ID != 7 {
+		t.Errorf("expected the restored task, got %+v", got)
+	}
+
+	unsubscribe()
+	app.AddTask(ctx, "After unsubscribe")
+	if index.Len() != 1 {
+		t.Errorf("expected no updates after unsubscribing, got %d tasks", index.Len())
+	}
+}
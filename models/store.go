@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// TaskStore はハンドラから利用するタスクの保存先です
+// TodoApp（メモリ上）がそのまま実装しており、永続化するストアは
+// TodoApp を包んで変更イベントごとに保存先へ書き出します
+//
+// 各メソッドの ctx はリクエストなど呼び出し元の操作のコンテキストです
+// 外部のデータベースなどを使うストアはキャンセルやタイムアウトに従い、
+// 配信するイベントにも ctx を引き継いで（Event.Context）トレースをつなげます
+//
+// AddTaskWith は説明や期限などの項目を付けたタスクを1回で作成し、task.created のイベントを1つだけ配信します
+// 作成に反応するルールや Webhook が、作成時に指定した項目を見られるようにするためです
+//
+// DeleteTask はタスクをすぐには消さず、ごみ箱（GetTrash）へ移します
+// RestoreTask で元に戻すか、PurgeTrash で古いものを完全に削除します
+//
+// SetReminder でリマインダーを設定すると、その日時を過ぎてから FireReminder が RemindedAt を付け、
+// task.updated の代わりに task.reminder のイベントを配信します
+//
+// 追加・完了状態の切り替え・編集・削除は、ctx のセッション（WithSession）ごとに記録し、Undo で最後のものから取り消せます
+//
+// 失敗した場合は ErrTaskNotFound・ErrValidation・ErrConflict（errors.Is で判定。コメントの操作では ErrCommentNotFound も）か、
+// 保存先のエラーを返します
+type TaskStore interface {
+	AddTask(ctx context.Context, title string) (Task, error)
+	AddTaskWith(ctx context.Context, title string, update TaskUpdate) (Task, error)
+	GetTasks(ctx context.Context) []Task
+	ToggleTask(ctx context.Context, id int) error
+	SetTitle(ctx context.Context, id int, title string) error
+	UpdateTask(ctx context.Context, id int, update TaskUpdate) error
+	ClaimTask(ctx context.Context, id int, claimant string) error
+	ReleaseTask(ctx context.Context, id int, claimant string) error
+	SetDueDate(ctx context.Context, id int, due *time.Time) error
+	SetScheduledDate(ctx context.Context, id int, scheduled *time.Time) error
+	SetPriority(ctx context.Context, id int, priority Priority) error
+	SetEstimate(ctx context.Context, id int, minutes int) error
+	SetTimeEntries(ctx context.Context, id int, entries []TimeEntry) error
+	SetBlindIndex(ctx context.Context, id int, tokens []string) error
+	SetReminder(ctx context.Context, id int, remindAt *time.Time) error
+	FireReminder(ctx context.Context, id int, now time.Time) error
+	AddComment(ctx context.Context, id int, author, body string) (Comment, error)
+	DeleteComment(ctx context.Context, id, commentID int) error
+	DeleteTask(ctx context.Context, id int) error
+	GetTrash(ctx context.Context) []Task
+	RestoreTask(ctx context.Context, id int) (Task, error)
+	PurgeTrash(ctx context.Context, before time.Time) ([]Task, error)
+	Undo(ctx context.Context) (Operation, Task, error)
+	ReplaceTasks(ctx context.Context, tasks []Task) error
+	Subscribe(handler EventHandler) (unsubscribe func())
+}
+
+var _ TaskStore = (*TodoApp)(nil)
@@ -0,0 +1,14 @@
+package models_test
+
+import (
+	"testing"
+
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+func TestTodoAppContract(t *testing.T) {
+	storetest.RunContract(t, func(t *testing.T) models.TaskStore {
+		return models.NewTodoApp()
+	})
+}
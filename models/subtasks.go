@@ -0,0 +1,77 @@
+package models
+
+import "fmt"
+
+// Progress は親タスクのサブタスクの進み具合です（RollUp が求めます）
+// Completed: 完了したサブタスクの数
+// Total: サブタスクの数
+// Percent: 完了した割合（0〜100、切り捨て）
+type Progress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+	Percent   int `json:"percent"`
+}
+
+// RollUp は tasks のうちサブタスクのあるタスクに、サブタスクの進み具合（Progress）を付けます。tasks の要素を書き換えます
+// 絞り込む前のすべてのタスクを渡してください（渡さなかったサブタスクは数えません）
+func RollUp(tasks []Task) {
+	progress := make(map[int]*Progress)
+	for _, task := range tasks {
+		if task.ParentID == 0 {
+			continue
+		}
+		p, ok := progress[task.ParentID]
+		if !ok {
+			p = &Progress{}
+			progress[task.ParentID] = p
+		}
+		p.Total++
+		if task.Completed {
+			p.Completed++
+		}
+	}
+	for i := range tasks {
+		if p, ok := progress[tasks[i].ID]; ok {
+			p.Percent = p.Completed * 100 / p.Total
+			tasks[i].Progress = p
+		}
+	}
+}
+
+// Subtasks は tasks のうち parentID のタスクのサブタスクを、tasks の並びのまま返します
+func Subtasks(tasks []Task, parentID int) []Task {
+	subtasks := []Task{}
+	for _, task := range tasks {
+		if task.ParentID == parentID {
+			subtasks = append(subtasks, task)
+		}
+	}
+	return subtasks
+}
+
+// CheckParent は id のタスク（新しく追加するなら 0）を parentID のタスクのサブタスクにできるかを tasks で確かめます
+// サブタスクは1段だけです。親のタスクがない・自分自身・親がサブタスク・id のタスクにサブタスクがある場合は ErrValidation を返します
+// parentID が 0（親から外す）なら何も確かめません
+func CheckParent(tasks []Task, id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	if parentID == id {
+		return fmt.Errorf("%w: a task cannot be its own parent", ErrValidation)
+	}
+	found := false
+	for _, task := range tasks {
+		switch {
+		case task.ID == parentID && task.ParentID != 0:
+			return fmt.Errorf("%w: task %d is a subtask and cannot have subtasks", ErrValidation, parentID)
+		case task.ID == parentID:
+			found = true
+		case id != 0 && task.ParentID == id:
+			return fmt.Errorf("%w: task %d has subtasks and cannot become a subtask", ErrValidation, id)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: parent_id %d does not exist", ErrValidation, parentID)
+	}
+	return nil
+}
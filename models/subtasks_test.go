@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRollUp(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Title: "Move house"},
+		{ID: 2, Title: "Pack books", ParentID: 1, Completed: true},
+		{ID: 3, Title: "Book a truck", ParentID: 1},
+		{ID: 4, Title: "Cancel the gas", ParentID: 1},
+		{ID: 5, Title: "Water plants"},
+	}
+	RollUp(tasks)
+
+	if p := tasks[0].Progress; p == nil || *p != (Progress{Completed: 1, Total: 3, Percent: 33}) {
+		t.Errorf("Expected 1 of 3 subtasks (33%%) to be rolled up, got %+v", p)
+	}
+	if tasks[1].Progress != nil || tasks[4].Progress != nil {
+		t.Errorf("Expected tasks without subtasks to have no progress, got %+v %+v", tasks[1].Progress, tasks[4].Progress)
+	}
+	if subtasks := Subtasks(tasks, 1); len(subtasks) != 3 || subtasks[0].ID != 2 || subtasks[2].ID != 4 {
+		t.Errorf("Expected the subtasks in order, got %+v", subtasks)
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	tasks := []Task{
+		{ID: 1, Title: "Move house"},
+		{ID: 2, Title: "Pack books", ParentID: 1},
+		{ID: 3, Title: "Water plants"},
+	}
+	testCases := []struct {
+		id, parentID int
+		valid        bool
+	}{
+		{0, 1, true},
+		{3, 1, true},
+		{3, 0, true},
+		{3, 3, false},
+		{0, 9, false},
+		{0, 2, false}, // サブタスクの下にはサブタスクを作れません
+		{1, 3, false}, // サブタスクのあるタスクはサブタスクにできません
+	}
+	for _, tc := range testCases {
+		err := CheckParent(tasks, tc.id, tc.parentID)
+		if tc.valid && err != nil {
+			t.Errorf("Expected task %d to be allowed under %d, got %v", tc.id, tc.parentID, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected task %d under %d to be rejected, got %v", tc.id, tc.parentID, err)
+		}
+	}
+}
@@ -1,89 +1,909 @@
 package models
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 // Task は1件のタスク（やること）を表すデータ構造です
 // ID: 一意に識別する番号
 // Title: タスクの内容
+// Description: タスクの説明（Markdown、なければ空）
 // Completed: 完了しているかどうか
+// DueDate: 期限（未設定なら nil）
+// ScheduledDate: 取りかかる予定の日（未設定なら nil）
+// Priority: 優先度（未設定なら空）
+// ParentID: 親タスクの ID（サブタスクでなければ 0）。サブタスクは1段だけで、親を削除しても残ります
+// Recurrence: 繰り返し方（繰り返さないなら nil）。完了すると次の回のタスクを作り、このタスクからは外します
+// CreatedAt: 作成した日時（記録する前に作られたタスクは nil）
+// CompletedAt: 完了した日時（未完了なら nil）
+// UpdatedAt: 最後に作成・変更した日時（記録する前に作られたタスクは nil）
+// EstimateMinutes: 見積もった作業時間（分、未設定なら 0）
+// TimeEntries: タイマーで記録した作業時間
+// TrackedSeconds: 終了した作業時間の合計（秒）。TimeEntries から求めます
+// Progress: サブタスクの進み具合（サブタスクのないタスクは nil）。保存はせず、一覧を返すときに RollUp で求めます
+// BlindIndex: エンドツーエンド暗号化のときにクライアントが作る検索用のトークン（暗号化しないときは空）
+// ClaimedBy: タスクを担当している人（ClaimTask で設定し、担当していなければ空）
+// ClaimedAt: 担当した日時（担当していなければ nil）
+// RemindAt: 通知する日時（リマインダーがなければ nil）
+// RemindedAt: リマインダーを通知した日時（まだ通知していなければ nil）。止めるか後で通知し直すまで残ります
+// Comments: タスクに付けたコメント（書いた順）
+// DeletedAt: 削除してごみ箱へ移した日時（ごみ箱にないタスクは nil）
 type Task struct {
-	ID        int    `json:"id"`
-	Title     string `json:"title"`
-	Completed bool   `json:"completed"`
+	ID            int         `json:"id"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description,omitempty"`
+	Completed     bool        `json:"completed"`
+	DueDate       *time.Time  `json:"due_date,omitempty"`
+	ScheduledDate *time.Time  `json:"scheduled_date,omitempty"`
+	Priority      Priority    `json:"priority,omitempty"`
+	ListID        int         `json:"list_id,omitempty"`
+	Tags          []string    `json:"tags,omitempty"`
+	ParentID      int         `json:"parent_id,omitempty"`
+	Recurrence    *Recurrence `json:"recurrence,omitempty"`
+	CreatedAt     *time.Time  `json:"created_at,omitempty"`
+	CompletedAt   *time.Time  `json:"completed_at,omitempty"`
+	UpdatedAt     *time.Time  `json:"updated_at,omitempty"`
+
+	EstimateMinutes int         `json:"estimate_minutes,omitempty"`
+	TimeEntries     []TimeEntry `json:"time_entries,omitempty"`
+	TrackedSeconds  int64       `json:"tracked_seconds,omitempty"`
+	Progress        *Progress   `json:"progress,omitempty"`
+	BlindIndex      []string    `json:"blind_index,omitempty"`
+
+	ClaimedBy string     `json:"claimed_by,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+
+	RemindAt   *time.Time `json:"remind_at,omitempty"`
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+
+	Comments []Comment `json:"comments,omitempty"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// HasTag はタスクに tag（NormalizeTag で整えた名前）が付いているかを返します
+func (task Task) HasTag(tag string) bool {
+	for _, t := range task.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// InProgress は担当している人がいる未完了のタスク（作業中）かを返します
+func (task Task) InProgress() bool {
+	return task.ClaimedBy != "" && !task.Completed
+}
+
+// Priority はタスクの優先度です
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+)
+
+// Priorities は設定できる優先度を低い順に返します
+func Priorities() []Priority {
+	return []Priority{PriorityLow, PriorityMedium, PriorityHigh}
+}
+
+// Validate は定義済みの優先度か空（未設定）であることを確認し、それ以外なら ErrValidation を返します
+func (p Priority) Validate() error {
+	if p == "" {
+		return nil
+	}
+	for _, known := range Priorities() {
+		if p == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown priority %q", ErrValidation, p)
+}
+
+// MaxTags は1件のタスクに付けられるタグの最大数です
+const MaxTags = 20
+
+// maxTagLength はタグの名前の最大の長さ（文字数）です
+const maxTagLength = 30
+
+// NormalizeTag はタグの名前の前後の空白と先頭の # を取り除き、小文字にそろえて返します
+// 使える文字は文字・数字・- と _ だけで、空の名前、長すぎる名前、それ以外の文字を含む名前は ErrValidation を返します
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" {
+		return "", fmt.Errorf("%w: tag must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: tag must be at most %d characters", ErrValidation, maxTagLength)
+	}
+	if strings.IndexFunc(tag, invalidTagRune) >= 0 {
+		return "", fmt.Errorf("%w: tag %q may only contain letters, digits, - and _", ErrValidation, tag)
+	}
+	return tag, nil
+}
+
+func invalidTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != '-' && r != '_'
+}
+
+// NormalizeTags は tags をそれぞれ NormalizeTag で整え、重複を除いて順に返します
+// 不正な名前があるか、MaxTags より多ければ ErrValidation を返します
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("%w: a task can have at most %d tags", ErrValidation, MaxTags)
+	}
+	return normalized, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // TodoApp はアプリ全体の状態を管理します
+// メモリ上の操作はすぐに終わるため、各メソッドの ctx はキャンセルを確認せず、配信するイベントに引き継ぐだけです
 // tasks: すべてのタスク一覧
-// nextID: 次に採番するID
+// trash: 削除したタスク（ごみ箱）。削除した順に並べ、RestoreTask で tasks へ戻すか PurgeTrash で完全に削除します
+// nextID: 次に採番するID（ごみ箱のタスクの ID も使いません）
 // mutex: 複数のリクエストから同時に触られても安全にするためのロック
+// events: タスクの変更を購読者に配信するイベントバス
+// journal: セッションごとの最近の操作（Undo で取り消します）
+// startID / maxTasks / now / generateID: Option で変更できる設定
 type TodoApp struct {
-	tasks  []Task
-	nextID int
-	mutex  sync.RWMutex
+	tasks   []Task
+	trash   []Task
+	nextID  int
+	mutex   sync.RWMutex
+	events  eventBus
+	journal Journal
+
+	startID    int
+	maxTasks   int
+	now        func() time.Time
+	generateID func() int
 }
 
 // NewTodoApp は TodoApp の初期化（コンストラクタ）を行います
-func NewTodoApp() *TodoApp {
-	return &TodoApp{
-		tasks:  make([]Task, 0),
-		nextID: 1,
+// options で最初のID・件数の上限・時計・IDの採番方法を変更できます
+func NewTodoApp(options ...Option) *TodoApp {
+	app := &TodoApp{
+		tasks:   make([]Task, 0),
+		startID: 1,
+		now:     time.Now,
+	}
+	for _, option := range options {
+		option(app)
 	}
+	app.nextID = app.startID
+	app.events.now = app.now
+	return app
+}
+
+// NewTodoAppFromTasks は保存済みのタスクから TodoApp を復元します
+// DeletedAt のあるタスクはごみ箱に入れます
+// 次に採番するIDは既存タスク（ごみ箱のタスクを含む）の最大ID+1 になります
+func NewTodoAppFromTasks(tasks []Task, options ...Option) *TodoApp {
+	app := NewTodoApp(options...)
+	for _, task := range tasks {
+		if task.DeletedAt != nil {
+			app.trash = append(app.trash, task.clone())
+		} else {
+			app.tasks = append(app.tasks, task.clone())
+		}
+		if task.ID >= app.nextID {
+			app.nextID = task.ID + 1
+		}
+	}
+	return app
+}
+
+// newID は新しいタスクのIDを採番します。ロック中に呼び出します
+func (app *TodoApp) newID() (int, error) {
+	if app.generateID == nil {
+		id := app.nextID
+		app.nextID++
+		return id, nil
+	}
+
+	id := app.generateID()
+	if id <= 0 {
+		return 0, fmt.Errorf("%w: generated id %d is not positive", ErrConflict, id)
+	}
+	for _, tasks := range [][]Task{app.tasks, app.trash} {
+		for _, task := range tasks {
+			if task.ID == id {
+				return 0, fmt.Errorf("%w: generated id %d is already used", ErrConflict, id)
+			}
+		}
+	}
+	return id, nil
+}
+
+// ReplaceTasks はタスク一覧をまるごと tasks に置き換えます（バックアップからの復元用）
+// 置き換え前のタスクには削除イベントを、置き換え後のタスクには作成イベントを配信します
+// ごみ箱はそのまま残しますが、置き換え後のタスクと ID が重なるものは完全に削除します
+// ID が重複しているか件数の上限を超えていれば ErrConflict を、ID やタイトルが不正なら ErrValidation を返し、何も変更しません
+func (app *TodoApp) ReplaceTasks(ctx context.Context, tasks []Task) error {
+	if err := validateTasks(tasks); err != nil {
+		return err
+	}
+	if app.maxTasks > 0 && len(tasks) > app.maxTasks {
+		return fmt.Errorf("%w: %d tasks exceed the limit of %d", ErrConflict, len(tasks), app.maxTasks)
+	}
+
+	app.mutex.Lock()
+
+	events := make([]Event, 0, len(app.tasks)+len(tasks))
+	for _, task := range app.tasks {
+		events = append(events, app.events.newEvent(ctx, EventTaskDeleted, task))
+	}
+	app.tasks = make([]Task, 0, len(tasks))
+	app.nextID = app.startID
+	ids := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		app.tasks = append(app.tasks, task.clone())
+		ids[task.ID] = true
+		if task.ID >= app.nextID {
+			app.nextID = task.ID + 1
+		}
+		events = append(events, app.events.newEvent(ctx, EventTaskCreated, task.clone()))
+	}
+	trash := app.trash[:0]
+	for _, task := range app.trash {
+		if !ids[task.ID] {
+			trash = append(trash, task)
+			if task.ID >= app.nextID {
+				app.nextID = task.ID + 1
+			}
+		}
+	}
+	app.trash = trash
+	app.mutex.Unlock()
+
+	for _, event := range events {
+		app.events.publish(event)
+	}
+	return nil
 }
 
 // AddTask は新しいタスクを作成して一覧に追加します
 // 排他ロック（書き込み用）を使って安全に配列へ追加します
-func (app *TodoApp) AddTask(title string) Task {
+// タイトルが空なら ErrValidation を、件数の上限に達しているかIDを採番できなければ ErrConflict を返します
+func (app *TodoApp) AddTask(ctx context.Context, title string) (Task, error) {
+	return app.AddTaskWith(ctx, title, TaskUpdate{})
+}
+
+// AddTaskWith は title のタスクを、update の項目（説明・期限・優先度・リスト・タグ・親タスク・繰り返し方など）を付けて作成します
+// すべての項目を確かめてから1回で追加するため、task.created のイベントには項目を付けたタスクが載り、途中まで作ったタスクは残りません
+// update.Title は使いません。入力に誤りがあれば ErrValidation を、件数の上限に達しているかIDを採番できなければ ErrConflict を返します
+func (app *TodoApp) AddTaskWith(ctx context.Context, title string, update TaskUpdate) (Task, error) {
+	if err := validateTitle(title); err != nil {
+		return Task{}, err
+	}
+	update.Title = nil
+	if err := update.Validate(); err != nil {
+		return Task{}, err
+	}
+
 	app.mutex.Lock()
-	defer app.mutex.Unlock()
 
+	if app.maxTasks > 0 && len(app.tasks) >= app.maxTasks {
+		app.mutex.Unlock()
+		return Task{}, fmt.Errorf("%w: task limit of %d reached", ErrConflict, app.maxTasks)
+	}
+	id, err := app.newID()
+	if err != nil {
+		app.mutex.Unlock()
+		return Task{}, err
+	}
+
+	createdAt := app.now()
 	task := Task{
-		ID:        app.nextID,
+		ID:        id,
 		Title:     title,
 		Completed: false,
+		CreatedAt: &createdAt,
+		UpdatedAt: &createdAt,
+	}
+	if err := update.Apply(&task); err != nil {
+		app.mutex.Unlock()
+		return Task{}, err
 	}
 	app.tasks = append(app.tasks, task)
-	app.nextID++
-	return task
+	app.journal.Record(ctx, Operation{Kind: OperationAdd, TaskID: id, Time: createdAt, After: createdAt})
+	event := app.events.newEvent(ctx, EventTaskCreated, task.clone())
+	app.mutex.Unlock()
+
+	app.events.publish(event)
+	return task.clone(), nil
 }
 
 // GetTasks は現在のタスク一覧をコピーして返します
 // 読み取り専用ロックを使い、呼び出し側が書き換えても
-// 元データに影響しないようスライスのコピーを返します
-func (app *TodoApp) GetTasks() []Task {
+// 元データに影響しないようスライスのコピーを返します（期限もコピーします）
+func (app *TodoApp) GetTasks(ctx context.Context) []Task {
 	app.mutex.RLock()
 	defer app.mutex.RUnlock()
 
 	tasksCopy := make([]Task, len(app.tasks))
-	copy(tasksCopy, app.tasks)
+	for i, task := range app.tasks {
+		tasksCopy[i] = task.clone()
+	}
 	return tasksCopy
 }
 
+// clone は日時のポインタを共有しないタスクのコピーを返します
+func (task Task) clone() Task {
+	task.DueDate = copyTime(task.DueDate)
+	task.ScheduledDate = copyTime(task.ScheduledDate)
+	task.CreatedAt = copyTime(task.CreatedAt)
+	task.CompletedAt = copyTime(task.CompletedAt)
+	task.UpdatedAt = copyTime(task.UpdatedAt)
+	task.TimeEntries = copyTimeEntries(task.TimeEntries)
+	task.Tags = copyStrings(task.Tags)
+	task.BlindIndex = copyStrings(task.BlindIndex)
+	task.ClaimedAt = copyTime(task.ClaimedAt)
+	task.RemindAt = copyTime(task.RemindAt)
+	task.RemindedAt = copyTime(task.RemindedAt)
+	task.Comments = copyComments(task.Comments)
+	task.DeletedAt = copyTime(task.DeletedAt)
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		task.Recurrence = &recurrence
+	}
+	if task.Progress != nil {
+		progress := *task.Progress
+		task.Progress = &progress
+	}
+	return task
+}
+
+// copyStrings は文字列のスライスのコピーを返します（nil はそのまま）
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string(nil), values...)
+}
+
+// copyTime は t が指す時刻のコピーを返します（nil はそのまま）
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// SetTitle は指定IDのタスクのタイトルを書き換えます
+// 見つからなければ ErrTaskNotFound を、タイトルが空なら ErrValidation を返します
+func (app *TodoApp) SetTitle(ctx context.Context, id int, title string) error {
+	if err := validateTitle(title); err != nil {
+		return err
+	}
+	return app.updateTask(ctx, id, OperationEdit, func(task *Task) {
+		task.Title = title
+	})
+}
+
+// TaskUpdate は UpdateTask でまとめて変更するタスクの項目です。nil の項目は変更しません
+// Description: 新しい説明（空文字を指すと説明を外します）
+// DueDate / ClearDueDate: 新しい期限。ClearDueDate が true なら期限を外します
+// Priority: 新しい優先度（空文字を指すと優先度を外します）
+// ListID: 新しいリストの ID（0 を指すとどのリストにも入れません。リストがあるかはリストを管理する側で確かめます）
+// Tags: 新しいタグの並び（空のスライスを指すとタグをすべて外します）
+// ParentID: 新しい親タスクの ID（0 を指すと親から外します。親があるか・1段だけかは呼び出し側で CheckParent で確かめます）
+// Recurrence / ClearRecurrence: 新しい繰り返し方。ClearRecurrence が true なら繰り返さないようにします
+// AddTags / RemoveTags: いまのタグに加える・外すタグ（Tags の後に加え、外すほうを後に適用します）
+// BlindIndex: 暗号化したタイトルを検索するためのトークン（空のスライスを指すとトークンを外します）
+// 完了状態はプラグインのフックを通すため、ToggleTask で変更します
+type TaskUpdate struct {
+	Title        *string
+	Description  *string
+	DueDate      *time.Time
+	ClearDueDate bool
+	Priority     *Priority
+	ListID       *int
+	Tags         *[]string
+	ParentID     *int
+	AddTags      []string
+	RemoveTags   []string
+	BlindIndex   *[]string
+
+	Recurrence      *Recurrence
+	ClearRecurrence bool
+}
+
+// Validate は変更するタイトル・説明・優先度・リスト・タグ・親タスク・繰り返し方を確認し、誤りがあれば ErrValidation を返します
+func (u TaskUpdate) Validate() error {
+	if u.Title != nil {
+		if err := validateTitle(*u.Title); err != nil {
+			return err
+		}
+	}
+	if u.Description != nil {
+		if err := ValidateDescription(*u.Description); err != nil {
+			return err
+		}
+	}
+	if u.Priority != nil {
+		if err := u.Priority.Validate(); err != nil {
+			return err
+		}
+	}
+	if u.ListID != nil && *u.ListID < 0 {
+		return fmt.Errorf("%w: list_id must not be negative", ErrValidation)
+	}
+	if u.ParentID != nil && *u.ParentID < 0 {
+		return fmt.Errorf("%w: parent_id must not be negative", ErrValidation)
+	}
+	if u.Tags != nil {
+		if _, err := NormalizeTags(*u.Tags); err != nil {
+			return err
+		}
+	}
+	for _, tags := range [][]string{u.AddTags, u.RemoveTags} {
+		for _, tag := range tags {
+			if _, err := NormalizeTag(tag); err != nil {
+				return err
+			}
+		}
+	}
+	if u.Recurrence != nil && !u.ClearRecurrence {
+		if err := u.Recurrence.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply は task に変更を反映します
+// 加えた後のタグが MaxTags より多くなる場合は ErrValidation を返します（task は途中まで書き換わるため、コピーに適用してください）
+func (u TaskUpdate) Apply(task *Task) error {
+	if u.Title != nil {
+		task.Title = *u.Title
+	}
+	if u.Description != nil {
+		task.Description = *u.Description
+	}
+	if u.ClearDueDate {
+		task.DueDate = nil
+	} else if u.DueDate != nil {
+		task.DueDate = copyTime(u.DueDate)
+	}
+	if u.Priority != nil {
+		task.Priority = *u.Priority
+	}
+	if u.ListID != nil {
+		task.ListID = *u.ListID
+	}
+	if u.ParentID != nil {
+		if *u.ParentID == task.ID {
+			return fmt.Errorf("%w: a task cannot be its own parent", ErrValidation)
+		}
+		task.ParentID = *u.ParentID
+	}
+	if u.Tags != nil {
+		task.Tags, _ = NormalizeTags(*u.Tags)
+	}
+	for _, tag := range u.AddTags {
+		if tag, err := NormalizeTag(tag); err == nil && !task.HasTag(tag) {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+	for _, tag := range u.RemoveTags {
+		tag, _ := NormalizeTag(tag)
+		for i, t := range task.Tags {
+			if t == tag {
+				task.Tags = append(task.Tags[:i:i], task.Tags[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(task.Tags) > MaxTags {
+		return fmt.Errorf("%w: a task can have at most %d tags", ErrValidation, MaxTags)
+	}
+	if len(task.Tags) == 0 {
+		task.Tags = nil
+	}
+	if u.ClearRecurrence {
+		task.Recurrence = nil
+	} else if u.Recurrence != nil {
+		recurrence := *u.Recurrence
+		task.Recurrence = &recurrence
+	}
+	if u.BlindIndex != nil {
+		task.BlindIndex = copyStrings(*u.BlindIndex)
+		if len(task.BlindIndex) == 0 {
+			task.BlindIndex = nil
+		}
+	}
+	return nil
+}
+
+// UpdateTask は指定IDのタスクの項目を update のとおりにまとめて書き換えます
+// 変更は1回の更新として行い、更新イベントも1つだけ配信します
+// 見つからなければ ErrTaskNotFound を、タイトルが空か説明が長すぎるか、優先度が定義されていないか、タグが不正か多すぎれば ErrValidation を返し、何も変更しません
+func (app *TodoApp) UpdateTask(ctx context.Context, id int, update TaskUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	return app.updateTaskIf(ctx, id, OperationEdit, update.Apply)
+}
+
 // ToggleTask は指定IDのタスクの完了フラグを反転（true/false）します
-// 見つかったら true を、見つからなければ false を返します
-func (app *TodoApp) ToggleTask(id int) bool {
+// 完了にしたときは完了日時を記録し、未完了に戻したときは消します
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) ToggleTask(ctx context.Context, id int) error {
+	now := app.now()
+	return app.updateTask(ctx, id, OperationToggle, func(task *Task) {
+		task.Completed = !task.Completed
+		task.CompletedAt = nil
+		if task.Completed {
+			task.CompletedAt = &now
+		}
+	})
+}
+
+// SetDueDate は指定IDのタスクの期限を設定します（nil で期限を外します）
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) SetDueDate(ctx context.Context, id int, due *time.Time) error {
+	due = copyTime(due)
+	return app.updateTask(ctx, id, OperationEdit, func(task *Task) {
+		task.DueDate = due
+	})
+}
+
+// SetScheduledDate は指定IDのタスクに取りかかる予定の日を設定します（nil で予定を外します）
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) SetScheduledDate(ctx context.Context, id int, scheduled *time.Time) error {
+	scheduled = copyTime(scheduled)
+	return app.updateTask(ctx, id, OperationEdit, func(task *Task) {
+		task.ScheduledDate = scheduled
+	})
+}
+
+// SetEstimate は指定IDのタスクの見積もり時間（分）を設定します（0 で見積もりを外します）
+// 見つからなければ ErrTaskNotFound を、負の値なら ErrValidation を返します
+func (app *TodoApp) SetEstimate(ctx context.Context, id int, minutes int) error {
+	if minutes < 0 {
+		return fmt.Errorf("%w: estimate must not be negative", ErrValidation)
+	}
+	return app.updateTask(ctx, id, OperationEdit, func(task *Task) {
+		task.EstimateMinutes = minutes
+	})
+}
+
+// SetBlindIndex は指定IDのタスクの検索用のトークン（ブラインドインデックス）を置き換えます（nil で外します）
+// トークンはクライアントが作るもので、形式の確認は呼び出し元（e2ee.ValidateIndex）が行います
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) SetBlindIndex(ctx context.Context, id int, tokens []string) error {
+	tokens = copyStrings(tokens)
+	if len(tokens) == 0 {
+		tokens = nil
+	}
+	return app.updateTask(ctx, id, "", func(task *Task) {
+		task.BlindIndex = tokens
+	})
+}
+
+// SetTimeEntries は指定IDのタスクの作業記録をまとめて置き換え、合計時間を計算し直します
+// タイマーの開始・停止や記録の編集は StartTimer などで作った一覧をこのメソッドで保存します
+// 見つからなければ ErrTaskNotFound を、記録が不正なら ErrValidation を返します
+func (app *TodoApp) SetTimeEntries(ctx context.Context, id int, entries []TimeEntry) error {
+	if err := validateTimeEntries(entries); err != nil {
+		return err
+	}
+	entries = copyTimeEntries(entries)
+	return app.updateTask(ctx, id, "", func(task *Task) {
+		task.TimeEntries = entries
+		task.TrackedSeconds = TrackedSeconds(entries)
+	})
+}
+
+// SetPriority は指定IDのタスクの優先度を設定します（空文字で優先度を外します）
+// 見つからなければ ErrTaskNotFound を、定義されていない優先度なら ErrValidation を返します
+func (app *TodoApp) SetPriority(ctx context.Context, id int, priority Priority) error {
+	if err := priority.Validate(); err != nil {
+		return err
+	}
+	return app.updateTask(ctx, id, OperationEdit, func(task *Task) {
+		task.Priority = priority
+	})
+}
+
+// ClaimTask は指定IDのタスクを claimant の担当にし、作業中にします
+// 担当しているかの確認と担当の設定はロックの中でまとめて行うため、同時に担当しようとしても成功するのは1人だけです
+// 同じ人がもう一度担当しても成功します（担当した日時は変えません）
+// 見つからなければ ErrTaskNotFound を、ほかの人が担当していれば ErrAlreadyClaimed を、
+// 完了したタスクなら ErrConflict を、名前が空か長すぎれば ErrValidation を返します
+func (app *TodoApp) ClaimTask(ctx context.Context, id int, claimant string) error {
+	if err := validateClaimant(claimant); err != nil {
+		return err
+	}
+	now := app.now()
+	return app.updateTaskIf(ctx, id, "", func(task *Task) error {
+		if err := checkClaim(*task, claimant); err != nil {
+			return err
+		}
+		if task.ClaimedBy != claimant {
+			task.ClaimedBy = claimant
+			task.ClaimedAt = &now
+		}
+		return nil
+	})
+}
+
+// ReleaseTask は claimant が担当しているタスクの担当を外し、未着手に戻します
+// 見つからなければ ErrTaskNotFound を、claimant が担当していなければ ErrConflict を返します
+func (app *TodoApp) ReleaseTask(ctx context.Context, id int, claimant string) error {
+	if err := validateClaimant(claimant); err != nil {
+		return err
+	}
+	return app.updateTaskIf(ctx, id, "", func(task *Task) error {
+		if task.ClaimedBy != claimant {
+			return fmt.Errorf("%w: task %d is not claimed by %s", ErrConflict, id, claimant)
+		}
+		task.ClaimedBy = ""
+		task.ClaimedAt = nil
+		return nil
+	})
+}
+
+// checkClaim は task を claimant が担当できるかを確認します
+func checkClaim(task Task, claimant string) error {
+	if task.Completed {
+		return fmt.Errorf("%w: task %d is already completed", ErrConflict, task.ID)
+	}
+	if task.ClaimedBy != "" && task.ClaimedBy != claimant {
+		return fmt.Errorf("%w: task %d is claimed by %s", ErrAlreadyClaimed, task.ID, task.ClaimedBy)
+	}
+	return nil
+}
+
+// updateTask は指定IDのタスクを update で書き換えて変更日時を記録し、更新イベントを配信します
+// kind が空でなければ、取り消せる操作として記録します（Undo）
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) updateTask(ctx context.Context, id int, kind OperationKind, update func(task *Task)) error {
+	return app.updateTaskIf(ctx, id, kind, func(task *Task) error {
+		update(task)
+		return nil
+	})
+}
+
+// updateTaskIf は updateTask と同じですが、update がエラーを返したらタスクを書き換えずにそのエラーを返します
+// update はロックの中で呼ぶため、現在の状態の確認と書き換えをまとめて行えます
+func (app *TodoApp) updateTaskIf(ctx context.Context, id int, kind OperationKind, update func(task *Task) error) error {
+	return app.changeTask(ctx, id, kind, EventTaskUpdated, update)
+}
+
+// changeTask は updateTaskIf と同じですが、配信するイベントの種類を eventType にします
+func (app *TodoApp) changeTask(ctx context.Context, id int, kind OperationKind, eventType EventType, update func(task *Task) error) error {
+	now := app.now()
 	app.mutex.Lock()
-	defer app.mutex.Unlock()
 
 	for i := range app.tasks {
 		if app.tasks[i].ID == id {
-			app.tasks[i].Completed = !app.tasks[i].Completed
-			return true
+			task := app.tasks[i].clone()
+			if err := update(&task); err != nil {
+				app.mutex.Unlock()
+				return err
+			}
+			before := app.tasks[i].clone()
+			app.tasks[i] = task
+			app.tasks[i].UpdatedAt = &now
+			app.journal.Record(ctx, Operation{Kind: kind, TaskID: id, Time: now, Before: &before, After: now})
+			event := app.events.newEvent(ctx, eventType, app.tasks[i].clone())
+			app.mutex.Unlock()
+
+			app.events.publish(event)
+			return nil
 		}
 	}
-	return false
+	app.mutex.Unlock()
+	return notFound(id)
 }
 
-// DeleteTask は指定IDのタスクを一覧から削除します
-// 見つかったら true を、見つからなければ false を返します
-func (app *TodoApp) DeleteTask(id int) bool {
+// DeleteTask は指定IDのタスクを一覧から削除し、削除した日時（DeletedAt）を付けてごみ箱へ移します
+// 削除イベントのタスクには DeletedAt が付いています
+// 見つからなければ ErrTaskNotFound を返します
+func (app *TodoApp) DeleteTask(ctx context.Context, id int) error {
+	now := app.now()
 	app.mutex.Lock()
-	defer app.mutex.Unlock()
 
 	for i, task := range app.tasks {
 		if task.ID == id {
-			app.tasks = append(app.tasks[:i], app.tasks[i+1:]...)
-			return true
+			before := task.clone()
+			event := app.trashAt(ctx, i, now)
+			app.journal.Record(ctx, Operation{Kind: OperationDelete, TaskID: id, Time: now, Before: &before, After: now})
+			app.mutex.Unlock()
+
+			app.events.publish(event)
+			return nil
 		}
 	}
-	return false
+	app.mutex.Unlock()
+	return notFound(id)
+}
+
+// trashAt は一覧の i 番目のタスクに DeletedAt を付けてごみ箱へ移し、削除イベントを返します。ロック中に呼び出します
+func (app *TodoApp) trashAt(ctx context.Context, i int, now time.Time) Event {
+	task := app.tasks[i]
+	app.tasks = append(app.tasks[:i], app.tasks[i+1:]...)
+	task.DeletedAt = &now
+	app.trash = append(app.trash, task)
+	return app.events.newEvent(ctx, EventTaskDeleted, task.clone())
+}
+
+// GetTrash はごみ箱のタスクを削除した順にコピーして返します
+func (app *TodoApp) GetTrash(ctx context.Context) []Task {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+
+	trash := make([]Task, len(app.trash))
+	for i, task := range app.trash {
+		trash[i] = task.clone()
+	}
+	return trash
+}
+
+// RestoreTask はごみ箱の指定IDのタスクを同じ ID のまま一覧へ戻し、作成イベントを配信します
+// 一覧の ID の順になるよう、元の位置に戻します
+// ごみ箱になければ ErrTaskNotFound を、件数の上限に達しているか同じ ID のタスクが一覧にあれば ErrConflict を返します
+func (app *TodoApp) RestoreTask(ctx context.Context, id int) (Task, error) {
+	now := app.now()
+	app.mutex.Lock()
+
+	for i, task := range app.trash {
+		if task.ID != id {
+			continue
+		}
+		task, event, err := app.restoreAt(ctx, i, now)
+		app.mutex.Unlock()
+		if err != nil {
+			return Task{}, err
+		}
+
+		app.events.publish(event)
+		return task, nil
+	}
+	app.mutex.Unlock()
+	return Task{}, notFound(id)
+}
+
+// restoreAt はごみ箱の i 番目のタスクを一覧の ID の順の位置へ戻し、戻したタスクと作成イベントを返します。ロック中に呼び出します
+func (app *TodoApp) restoreAt(ctx context.Context, i int, now time.Time) (Task, Event, error) {
+	task := app.trash[i]
+	if app.maxTasks > 0 && len(app.tasks) >= app.maxTasks {
+		return Task{}, Event{}, fmt.Errorf("%w: task limit of %d reached", ErrConflict, app.maxTasks)
+	}
+	position := len(app.tasks)
+	for j, existing := range app.tasks {
+		if existing.ID == task.ID {
+			return Task{}, Event{}, fmt.Errorf("%w: task %d already exists", ErrConflict, task.ID)
+		}
+		if existing.ID > task.ID && position == len(app.tasks) {
+			position = j
+		}
+	}
+
+	app.trash = append(app.trash[:i], app.trash[i+1:]...)
+	task.DeletedAt = nil
+	task.UpdatedAt = &now
+	app.tasks = append(app.tasks, Task{})
+	copy(app.tasks[position+1:], app.tasks[position:])
+	app.tasks[position] = task
+	return task.clone(), app.events.newEvent(ctx, EventTaskCreated, task.clone()), nil
+}
+
+// PurgeTrash はごみ箱のうち before より前に削除したタスクを完全に削除し、削除したタスクを返します
+// 一覧のタスクは変わらないため、イベントは配信しません（保存先に書き出すストアは返したタスクで反映します）
+// メモリ上の TodoApp は失敗しません
+func (app *TodoApp) PurgeTrash(ctx context.Context, before time.Time) ([]Task, error) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	var purged []Task
+	kept := app.trash[:0]
+	for _, task := range app.trash {
+		if task.DeletedAt.Before(before) {
+			purged = append(purged, task.clone())
+		} else {
+			kept = append(kept, task)
+		}
+	}
+	app.trash = kept
+	return purged, nil
+}
+
+// Undo はコンテキストのセッション（WithSession）で最後に行った操作を取り消し、取り消した操作とその後のタスクを返します
+// 追加はごみ箱へ移し、削除はごみ箱から戻し、編集と完了状態の切り替えは操作する前の状態に戻します。取り消しそのものは記録しません
+// 取り消せる操作がなければ ErrNothingToUndo を、タスクがもうなければ ErrTaskNotFound を、
+// 操作の後にタスクが変更されているか元に戻せなければ ErrConflict を返します。取り消せなかった操作も記録から外します
+func (app *TodoApp) Undo(ctx context.Context) (Operation, Task, error) {
+	now := app.now()
+	op, err := app.journal.Pop(ctx, now)
+	if err != nil {
+		return Operation{}, Task{}, err
+	}
+	changed := fmt.Errorf("%w: task %d was changed after the %s", ErrConflict, op.TaskID, op.Kind)
+
+	app.mutex.Lock()
+	var task Task
+	var event Event
+	if op.Kind == OperationDelete {
+		i := indexOf(app.trash, op.TaskID)
+		if i < 0 {
+			app.mutex.Unlock()
+			return Operation{}, Task{}, notFound(op.TaskID)
+		}
+		if !app.trash[i].DeletedAt.Equal(op.After) {
+			app.mutex.Unlock()
+			return Operation{}, Task{}, changed
+		}
+		task, event, err = app.restoreAt(ctx, i, now)
+		if err != nil {
+			app.mutex.Unlock()
+			return Operation{}, Task{}, err
+		}
+	} else {
+		i := indexOf(app.tasks, op.TaskID)
+		if i < 0 {
+			app.mutex.Unlock()
+			return Operation{}, Task{}, notFound(op.TaskID)
+		}
+		if app.tasks[i].UpdatedAt == nil || !app.tasks[i].UpdatedAt.Equal(op.After) {
+			app.mutex.Unlock()
+			return Operation{}, Task{}, changed
+		}
+		if op.Kind == OperationAdd {
+			event = app.trashAt(ctx, i, now)
+			task = event.Task
+		} else {
+			task = op.Before.clone()
+			task.UpdatedAt = &now
+			app.tasks[i] = task
+			event = app.events.newEvent(ctx, EventTaskUpdated, task.clone())
+		}
+	}
+	if op.Kind != OperationAdd {
+		app.journal.touch(ctx, op.TaskID, now)
+	}
+	app.mutex.Unlock()
+
+	app.events.publish(event)
+	return op, task.clone(), nil
+}
+
+// indexOf は tasks のうち ID が id のタスクの位置を返します（なければ -1）
+func indexOf(tasks []Task, id int) int {
+	for i, task := range tasks {
+		if task.ID == id {
+			return i
+		}
+	}
+	return -1
 }
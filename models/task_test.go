@@ -1,6 +1,9 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -22,9 +25,10 @@ func TestNewTodoApp(t *testing.T) {
 }
 
 func TestAddTask(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	
-	task := app.AddTask("Test task")
+	task, _ := app.AddTask(ctx, "Test task")
 	
 	if task.ID != 1 {
 		t.Errorf("Expected task ID to be 1, got %d", task.ID)
@@ -38,18 +42,19 @@ func TestAddTask(t *testing.T) {
 		t.Errorf("Expected task to be incomplete, got %v", task.Completed)
 	}
 	
-	task2 := app.AddTask("Second task")
+	task2, _ := app.AddTask(ctx, "Second task")
 	if task2.ID != 2 {
 		t.Errorf("Expected second task ID to be 2, got %d", task2.ID)
 	}
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != 2 {
 		t.Errorf("Expected 2 tasks, got %d", len(tasks))
 	}
 }
 
 func TestAddTaskConcurrency(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	var wg sync.WaitGroup
 	numGoroutines := 100
@@ -58,13 +63,13 @@ func TestAddTaskConcurrency(t *testing.T) {
 	for i := 0; i < numGoroutines; i++ {
 		go func(i int) {
 			defer wg.Done()
-			app.AddTask("Task " + string(rune(i)))
+			app.AddTask(ctx, "Task " + string(rune(i)))
 		}(i)
 	}
 	
 	wg.Wait()
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != numGoroutines {
 		t.Errorf("Expected %d tasks, got %d", numGoroutines, len(tasks))
 	}
@@ -79,62 +84,65 @@ func TestAddTaskConcurrency(t *testing.T) {
 }
 
 func TestGetTasks(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != 0 {
 		t.Errorf("Expected empty tasks slice, got length %d", len(tasks))
 	}
 	
-	app.AddTask("Task 1")
-	app.AddTask("Task 2")
+	app.AddTask(ctx, "Task 1")
+	app.AddTask(ctx, "Task 2")
 	
-	tasks = app.GetTasks()
+	tasks = app.GetTasks(ctx)
 	if len(tasks) != 2 {
 		t.Errorf("Expected 2 tasks, got %d", len(tasks))
 	}
 	
 	tasks[0].Title = "Modified"
-	originalTasks := app.GetTasks()
+	originalTasks := app.GetTasks(ctx)
 	if originalTasks[0].Title == "Modified" {
 		t.Error("GetTasks() should return a copy, not the original slice")
 	}
 }
 
 func TestToggleTask(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	
-	success := app.ToggleTask(999)
-	if success {
-		t.Error("Expected ToggleTask to return false for non-existent task")
+	err := app.ToggleTask(ctx, 999)
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ToggleTask to return ErrTaskNotFound for non-existent task, got %v", err)
 	}
 	
-	task := app.AddTask("Test task")
+	task, _ := app.AddTask(ctx, "Test task")
 	
-	success = app.ToggleTask(task.ID)
-	if !success {
-		t.Error("Expected ToggleTask to return true for existing task")
+	err = app.ToggleTask(ctx, task.ID)
+	if err != nil {
+		t.Errorf("Expected ToggleTask to succeed for existing task, got %v", err)
 	}
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if !tasks[0].Completed {
 		t.Error("Expected task to be completed after toggle")
 	}
 	
-	success = app.ToggleTask(task.ID)
-	if !success {
-		t.Error("Expected ToggleTask to return true for existing task")
+	err = app.ToggleTask(ctx, task.ID)
+	if err != nil {
+		t.Errorf("Expected ToggleTask to succeed for existing task, got %v", err)
 	}
 	
-	tasks = app.GetTasks()
+	tasks = app.GetTasks(ctx)
 	if tasks[0].Completed {
 		t.Error("Expected task to be incomplete after second toggle")
 	}
 }
 
 func TestToggleTaskConcurrency(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
-	task := app.AddTask("Test task")
+	task, _ := app.AddTask(ctx, "Test task")
 	
 	var wg sync.WaitGroup
 	numGoroutines := 100
@@ -143,36 +151,37 @@ func TestToggleTaskConcurrency(t *testing.T) {
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
 			defer wg.Done()
-			app.ToggleTask(task.ID)
+			app.ToggleTask(ctx, task.ID)
 		}()
 	}
 	
 	wg.Wait()
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != 1 {
 		t.Errorf("Expected 1 task, got %d", len(tasks))
 	}
 }
 
 func TestDeleteTask(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	
-	success := app.DeleteTask(999)
-	if success {
-		t.Error("Expected DeleteTask to return false for non-existent task")
+	err := app.DeleteTask(ctx, 999)
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected DeleteTask to return ErrTaskNotFound for non-existent task, got %v", err)
 	}
 	
-	task1 := app.AddTask("Task 1")
-	task2 := app.AddTask("Task 2")
-	task3 := app.AddTask("Task 3")
+	task1, _ := app.AddTask(ctx, "Task 1")
+	task2, _ := app.AddTask(ctx, "Task 2")
+	task3, _ := app.AddTask(ctx, "Task 3")
 	
-	success = app.DeleteTask(task2.ID)
-	if !success {
-		t.Error("Expected DeleteTask to return true for existing task")
+	err = app.DeleteTask(ctx, task2.ID)
+	if err != nil {
+		t.Errorf("Expected DeleteTask to succeed for existing task, got %v", err)
 	}
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != 2 {
 		t.Errorf("Expected 2 tasks after deletion, got %d", len(tasks))
 	}
@@ -203,10 +212,11 @@ func TestDeleteTask(t *testing.T) {
 }
 
 func TestDeleteTaskConcurrency(t *testing.T) {
+	ctx := context.Background()
 	app := NewTodoApp()
 	
 	for i := 0; i < 10; i++ {
-		app.AddTask("Task " + string(rune(i)))
+		app.AddTask(ctx, "Task " + string(rune(i)))
 	}
 	
 	var wg sync.WaitGroup
@@ -216,13 +226,13 @@ func TestDeleteTaskConcurrency(t *testing.T) {
 	for i := 1; i <= numGoroutines; i++ {
 		go func(id int) {
 			defer wg.Done()
-			app.DeleteTask(id)
+			app.DeleteTask(ctx, id)
 		}(i)
 	}
 	
 	wg.Wait()
 	
-	tasks := app.GetTasks()
+	tasks := app.GetTasks(ctx)
 	if len(tasks) != 5 {
 		t.Errorf("Expected 5 tasks remaining, got %d", len(tasks))
 	}
@@ -247,3 +257,120 @@ func TestTaskStruct(t *testing.T) {
 		t.Errorf("Expected Completed to be true, got %v", task.Completed)
 	}
 }
+
+func TestNewTodoAppFromTasks(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoAppFromTasks([]Task{
+		{ID: 3, Title: "Three", Completed: true},
+		{ID: 7, Title: "Seven"},
+	})
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 2 || tasks[0].ID != 3 || !tasks[0].Completed {
+		t.Errorf("Unexpected restored tasks: %+v", tasks)
+	}
+
+	task, _ := app.AddTask(ctx, "Next")
+	if task.ID != 8 {
+		t.Errorf("Expected next ID to be 8, got %d", task.ID)
+	}
+}
+
+func TestReplaceTasks(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	app.AddTask(ctx, "Old 1")
+	app.AddTask(ctx, "Old 2")
+
+	var events []Event
+	app.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	app.ReplaceTasks(ctx, []Task{{ID: 5, Title: "Restored", Completed: true}})
+
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 1 || tasks[0].ID != 5 || !tasks[0].Completed {
+		t.Errorf("Unexpected tasks after replace: %+v", tasks)
+	}
+	if len(events) != 3 || events[0].Type != EventTaskDeleted || events[2].Type != EventTaskCreated {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+	if task, _ := app.AddTask(ctx, "New"); task.ID != 6 {
+		t.Errorf("Expected next ID 6, got %d", task.ID)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	for input, want := range map[string]string{"shopping": "shopping", " #Work ": "work", "買い物": "買い物", "to-do_2": "to-do_2"} {
+		if got, err := NormalizeTag(input); err != nil || got != want {
+			t.Errorf("NormalizeTag(%q): expected %q, got %q (%v)", input, want, got, err)
+		}
+	}
+	for _, input := range []string{"", "#", "two words", "a,b", "a/b", `say"hi"`, "<b>", strings.Repeat("x", 31)} {
+		if _, err := NormalizeTag(input); !errors.Is(err, ErrValidation) {
+			t.Errorf("NormalizeTag(%q): expected ErrValidation, got %v", input, err)
+		}
+	}
+
+	tags, err := NormalizeTags([]string{"Work", "home", "#work"})
+	if err != nil || len(tags) != 2 || tags[0] != "work" || tags[1] != "home" {
+		t.Errorf("expected [work home], got %v (%v)", tags, err)
+	}
+}
+
+func TestRestoreTaskKeepsIDOrder(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	for _, title := range []string{"First", "Second", "Third"} {
+		app.AddTask(ctx, title)
+	}
+	app.DeleteTask(ctx, 2)
+	app.DeleteTask(ctx, 1)
+
+	app.RestoreTask(ctx, 2)
+	app.RestoreTask(ctx, 1)
+	tasks := app.GetTasks(ctx)
+	if len(tasks) != 3 || tasks[0].ID != 1 || tasks[1].ID != 2 || tasks[2].ID != 3 {
+		t.Errorf("Expected restored tasks back in ID order, got %+v", tasks)
+	}
+}
+
+func TestRestoreTaskConflicts(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp(WithMaxTasks(1))
+	task, _ := app.AddTask(ctx, "Deleted")
+	app.DeleteTask(ctx, task.ID)
+	app.AddTask(ctx, "Fills the limit")
+
+	if _, err := app.RestoreTask(ctx, task.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict when the task limit is reached, got %v", err)
+	}
+	if len(app.GetTrash(ctx)) != 1 {
+		t.Error("Expected the task to stay in the trash after a failed restore")
+	}
+}
+
+func TestTrashAcrossReloadAndReplace(t *testing.T) {
+	ctx := context.Background()
+	app := NewTodoApp()
+	app.AddTask(ctx, "Active")
+	deleted, _ := app.AddTask(ctx, "Deleted")
+	app.DeleteTask(ctx, deleted.ID)
+
+	// 保存先から読み込むときは、deleted_at のあるタスクをごみ箱へ入れ、その ID も使いません
+	reloaded := NewTodoAppFromTasks(append(app.GetTasks(ctx), app.GetTrash(ctx)...))
+	if len(reloaded.GetTasks(ctx)) != 1 || len(reloaded.GetTrash(ctx)) != 1 {
+		t.Fatalf("Expected the deleted task to be loaded into the trash, got %+v %+v", reloaded.GetTasks(ctx), reloaded.GetTrash(ctx))
+	}
+	reloaded.ReplaceTasks(ctx, []Task{{ID: 1, Title: "Replaced"}})
+	if task, _ := reloaded.AddTask(ctx, "Next"); task.ID != 3 {
+		t.Errorf("Expected the trashed ID not to be reused, got %d", task.ID)
+	}
+
+	// 置き換え後のタスクと ID が重なるごみ箱のタスクは完全に削除します
+	reloaded.ReplaceTasks(ctx, []Task{{ID: 2, Title: "Reuses the trashed ID"}})
+	if trash := reloaded.GetTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected the colliding trashed task to be dropped, got %+v", trash)
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeEntryNotFound は指定IDの作業記録がタスクにないことを表します
+var ErrTimeEntryNotFound = errors.New("time entry not found")
+
+// TimeEntry はタスクに取り組んだ1回分の作業時間です
+// End: 作業を終えた日時（タイマーが動いている間は nil）
+type TimeEntry struct {
+	ID    int        `json:"id"`
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Running はタイマーが動いているかどうかを返します
+func (entry TimeEntry) Running() bool {
+	return entry.End == nil
+}
+
+// validateTimeEntries は作業記録の ID が正で重複しておらず、終了が開始より前でなく、
+// 動いているタイマーが1つ以下であることを確認します
+func validateTimeEntries(entries []TimeEntry) error {
+	seen := make(map[int]bool, len(entries))
+	running := 0
+	for _, entry := range entries {
+		if entry.ID <= 0 || seen[entry.ID] {
+			return fmt.Errorf("%w: time entry id %d must be positive and unique", ErrValidation, entry.ID)
+		}
+		seen[entry.ID] = true
+		if entry.Start.IsZero() {
+			return fmt.Errorf("%w: time entry %d has no start", ErrValidation, entry.ID)
+		}
+		if entry.End == nil {
+			running++
+		} else if entry.End.Before(entry.Start) {
+			return fmt.Errorf("%w: time entry %d ends before it starts", ErrValidation, entry.ID)
+		}
+	}
+	if running > 1 {
+		return fmt.Errorf("%w: only one timer can run at a time", ErrValidation)
+	}
+	return nil
+}
+
+// TrackedSeconds は終了した作業記録の合計時間（秒）を返します。動いているタイマーは含めません
+func TrackedSeconds(entries []TimeEntry) int64 {
+	var total time.Duration
+	for _, entry := range entries {
+		if entry.End != nil {
+			total += entry.End.Sub(entry.Start)
+		}
+	}
+	return int64(total / time.Second)
+}
+
+// copyTimeEntries は終了日時のポインタも含めて作業記録をコピーします
+func copyTimeEntries(entries []TimeEntry) []TimeEntry {
+	if entries == nil {
+		return nil
+	}
+	copied := make([]TimeEntry, len(entries))
+	for i, entry := range entries {
+		entry.End = copyTime(entry.End)
+		copied[i] = entry
+	}
+	return copied
+}
+
+// StartTimer は entries に now から始まる作業記録を追加したものと、追加した記録を返します
+// すでにタイマーが動いていれば ErrConflict を返します
+func StartTimer(entries []TimeEntry, now time.Time) ([]TimeEntry, TimeEntry, error) {
+	nextID := 1
+	for _, entry := range entries {
+		if entry.Running() {
+			return nil, TimeEntry{}, fmt.Errorf("%w: timer %d is already running", ErrConflict, entry.ID)
+		}
+		if entry.ID >= nextID {
+			nextID = entry.ID + 1
+		}
+	}
+	started := TimeEntry{ID: nextID, Start: now}
+	return append(copyTimeEntries(entries), started), started, nil
+}
+
+// StopTimer は動いているタイマーを now で止めた entries と、止めた記録を返します
+// タイマーが動いていなければ ErrConflict を返します
+func StopTimer(entries []TimeEntry, now time.Time) ([]TimeEntry, TimeEntry, error) {
+	entries = copyTimeEntries(entries)
+	for i := range entries {
+		if entries[i].Running() {
+			if now.Before(entries[i].Start) {
+				now = entries[i].Start
+			}
+			entries[i].End = &now
+			return entries, entries[i], nil
+		}
+	}
+	return nil, TimeEntry{}, fmt.Errorf("%w: no timer is running", ErrConflict)
+}
+
+// EditTimeEntry は entries のうち id の作業記録の開始・終了日時を書き換えたものと、書き換えた記録を返します
+// 見つからなければ ErrTimeEntryNotFound を、書き換えた結果が不正なら ErrValidation を返します
+func EditTimeEntry(entries []TimeEntry, id int, start time.Time, end *time.Time) ([]TimeEntry, TimeEntry, error) {
+	entries = copyTimeEntries(entries)
+	for i := range entries {
+		if entries[i].ID == id {
+			entries[i].Start = start
+			entries[i].End = copyTime(end)
+			if err := validateTimeEntries(entries); err != nil {
+				return nil, TimeEntry{}, err
+			}
+			return entries, entries[i], nil
+		}
+	}
+	return nil, TimeEntry{}, fmt.Errorf("%w: id %d", ErrTimeEntryNotFound, id)
+}
+
+// DeleteTimeEntry は entries から id の作業記録を除いたものを返します
+// 見つからなければ ErrTimeEntryNotFound を返します
+func DeleteTimeEntry(entries []TimeEntry, id int) ([]TimeEntry, error) {
+	for i, entry := range entries {
+		if entry.ID == id {
+			rest := append(copyTimeEntries(entries[:i]), copyTimeEntries(entries[i+1:])...)
+			return rest, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id %d", ErrTimeEntryNotFound, id)
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimerLifecycle(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	entries, started, err := StartTimer(nil, now)
+	if err != nil || started.ID != 1 || !started.Running() || len(entries) != 1 {
+		t.Fatalf("Unexpected start: %+v %+v (%v)", entries, started, err)
+	}
+	if _, _, err := StartTimer(entries, now); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict while a timer is running, got %v", err)
+	}
+
+	stoppedAt := now.Add(30 * time.Minute)
+	stoppedEntries, stopped, err := StopTimer(entries, stoppedAt)
+	if err != nil || stopped.Running() || !stopped.End.Equal(stoppedAt) {
+		t.Fatalf("Unexpected stop: %+v (%v)", stopped, err)
+	}
+	if !entries[0].Running() {
+		t.Error("Expected StopTimer to leave the original entries unchanged")
+	}
+	if _, _, err := StopTimer(stoppedEntries, stoppedAt); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict without a running timer, got %v", err)
+	}
+	if got := TrackedSeconds(stoppedEntries); got != 30*60 {
+		t.Errorf("Expected 1800 tracked seconds, got %d", got)
+	}
+
+	entries, second, _ := StartTimer(stoppedEntries, now.Add(time.Hour))
+	if second.ID != 2 || TrackedSeconds(entries) != 30*60 {
+		t.Errorf("Expected a second entry and running timers to be excluded, got %+v", entries)
+	}
+}
+
+func TestStopTimerNeverEndsBeforeStart(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	entries, _, _ := StartTimer(nil, now)
+	_, stopped, _ := StopTimer(entries, now.Add(-time.Minute))
+	if !stopped.End.Equal(now) {
+		t.Errorf("Expected the end to be clamped to the start, got %v", stopped.End)
+	}
+}
+
+func TestEditAndDeleteTimeEntry(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	entries := []TimeEntry{{ID: 1, Start: start, End: &end}, {ID: 2, Start: end}}
+
+	newEnd := start.Add(2 * time.Hour)
+	edited, entry, err := EditTimeEntry(entries, 1, start, &newEnd)
+	if err != nil || !entry.End.Equal(newEnd) || TrackedSeconds(edited) != 2*60*60 {
+		t.Errorf("Unexpected edit: %+v (%v)", edited, err)
+	}
+	if !entries[0].End.Equal(end) {
+		t.Error("Expected EditTimeEntry to leave the original entries unchanged")
+	}
+	if _, _, err := EditTimeEntry(entries, 1, end, &start); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an entry that ends before it starts, got %v", err)
+	}
+	if _, _, err := EditTimeEntry(entries, 1, start, nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for a second running timer, got %v", err)
+	}
+	if _, _, err := EditTimeEntry(entries, 9, start, &end); !errors.Is(err, ErrTimeEntryNotFound) {
+		t.Errorf("Expected ErrTimeEntryNotFound, got %v", err)
+	}
+
+	rest, err := DeleteTimeEntry(entries, 1)
+	if err != nil || len(rest) != 1 || rest[0].ID != 2 || len(entries) != 2 {
+		t.Errorf("Unexpected delete: %+v (%v)", rest, err)
+	}
+	if _, err := DeleteTimeEntry(entries, 9); !errors.Is(err, ErrTimeEntryNotFound) {
+		t.Errorf("Expected ErrTimeEntryNotFound, got %v", err)
+	}
+}
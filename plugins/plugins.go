@@ -0,0 +1,194 @@
+// Package plugins はタスクの作成・完了・削除にフックする Go のプラグインを登録し、ストアに組み込みます
+//
+// プラグインはコンパイル時に組み込みます。database/sql のドライバと同じく、プラグインのパッケージの
+// init で Register を呼び、main からブランクインポートしてください
+//
+//	import _ "example.com/todo-plugins/ticketprefix"
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"todo-app/models"
+)
+
+// ErrVetoed はプラグインが操作を拒否したことを表します（ErrConflict として扱われます）
+var ErrVetoed = fmt.Errorf("%w: vetoed by plugin", models.ErrConflict)
+
+// Plugin はプラグインです。以下のフックのうち、実装したものだけが呼ばれます
+type Plugin interface {
+	// Name はプラグインの名前です。ログとエラーに使い、登録するプラグインの間で重複できません
+	Name() string
+}
+
+// BeforeCreateHook はタスクを作成する前に呼ばれます
+// 返したタイトルで作成し、エラーを返すと作成を取りやめます
+type BeforeCreateHook interface {
+	BeforeCreate(ctx context.Context, title string) (string, error)
+}
+
+// BeforeCompleteHook は未完了のタスクを完了にする前に呼ばれます。エラーを返すと完了にしません
+type BeforeCompleteHook interface {
+	BeforeComplete(ctx context.Context, task models.Task) error
+}
+
+// AfterCompleteHook はタスクを完了にした後に呼ばれます
+// store はフックを通さない元のストアで、完了したタスクの変更などに使えます
+type AfterCompleteHook interface {
+	AfterComplete(ctx context.Context, store models.TaskStore, task models.Task)
+}
+
+// OnDeleteHook はタスクを削除する前に呼ばれます。エラーを返すと削除しません
+type OnDeleteHook interface {
+	OnDelete(ctx context.Context, task models.Task) error
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Plugin
+)
+
+// Register はプラグインを登録します。プラグインのパッケージの init から呼んでください
+// nil や名前が重複するプラグインを登録すると panic します
+func Register(p Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if p == nil {
+		panic("plugins: Register plugin is nil")
+	}
+	for _, registered := range registry {
+		if registered.Name() == p.Name() {
+			panic("plugins: Register called twice for plugin " + p.Name())
+		}
+	}
+	registry = append(registry, p)
+}
+
+// Registered は登録済みのプラグインを登録した順に返します
+func Registered() []Plugin {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Plugin(nil), registry...)
+}
+
+// Store は TaskStore を包み、AddTask・AddTaskWith・ToggleTask・DeleteTask でプラグインのフックを呼びます
+// フックは登録した順に呼び、最初に拒否したプラグインで止めます
+// 期限や優先度などの変更と、バックアップからの復元（ReplaceTasks）ではフックを呼びません
+type Store struct {
+	models.TaskStore
+	plugins []Plugin
+}
+
+var _ models.TaskStore = (*Store)(nil)
+
+// Wrap は store を plugins のフックを呼ぶストアで包みます。plugins が空なら store をそのまま返します
+func Wrap(store models.TaskStore, plugins ...Plugin) models.TaskStore {
+	if len(plugins) == 0 {
+		return store
+	}
+	return &Store{TaskStore: store, plugins: plugins}
+}
+
+// AddTask は BeforeCreate で変更したタイトルでタスクを作成します
+func (s *Store) AddTask(ctx context.Context, title string) (models.Task, error) {
+	title, err := s.beforeCreate(ctx, title)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return s.TaskStore.AddTask(ctx, title)
+}
+
+// AddTaskWith は BeforeCreate で変更したタイトルで、update の項目を付けたタスクを作成します
+func (s *Store) AddTaskWith(ctx context.Context, title string, update models.TaskUpdate) (models.Task, error) {
+	title, err := s.beforeCreate(ctx, title)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return s.TaskStore.AddTaskWith(ctx, title, update)
+}
+
+// beforeCreate は BeforeCreate を登録した順に呼び、変更したタイトルを返します
+func (s *Store) beforeCreate(ctx context.Context, title string) (string, error) {
+	for _, p := range s.plugins {
+		hook, ok := p.(BeforeCreateHook)
+		if !ok {
+			continue
+		}
+		changed, err := hook.BeforeCreate(ctx, title)
+		if err != nil {
+			return "", vetoed(p, err)
+		}
+		title = changed
+	}
+	return title, nil
+}
+
+// ToggleTask は未完了のタスクを完了にするときだけ BeforeComplete と AfterComplete を呼びます
+func (s *Store) ToggleTask(ctx context.Context, id int) error {
+	task, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.Completed {
+		return s.TaskStore.ToggleTask(ctx, id)
+	}
+
+	for _, p := range s.plugins {
+		if hook, ok := p.(BeforeCompleteHook); ok {
+			if err := hook.BeforeComplete(ctx, task); err != nil {
+				return vetoed(p, err)
+			}
+		}
+	}
+	if err := s.TaskStore.ToggleTask(ctx, id); err != nil {
+		return err
+	}
+
+	completed, err := s.find(ctx, id)
+	if err != nil {
+		return nil
+	}
+	for _, p := range s.plugins {
+		if hook, ok := p.(AfterCompleteHook); ok {
+			hook.AfterComplete(ctx, s.TaskStore, completed)
+		}
+	}
+	return nil
+}
+
+// DeleteTask は OnDelete がすべて許可した場合だけタスクを削除します
+func (s *Store) DeleteTask(ctx context.Context, id int) error {
+	task, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, p := range s.plugins {
+		if hook, ok := p.(OnDeleteHook); ok {
+			if err := hook.OnDelete(ctx, task); err != nil {
+				return vetoed(p, err)
+			}
+		}
+	}
+	return s.TaskStore.DeleteTask(ctx, id)
+}
+
+func (s *Store) find(ctx context.Context, id int) (models.Task, error) {
+	for _, task := range s.TaskStore.GetTasks(ctx) {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return models.Task{}, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+}
+
+// vetoed はフックが返したエラーにプラグインの名前を付けます
+// ErrValidation・ErrConflict はそのまま、それ以外は ErrVetoed として返します
+func vetoed(p Plugin, err error) error {
+	if errors.Is(err, models.ErrValidation) || errors.Is(err, models.ErrConflict) {
+		return fmt.Errorf("plugin %s: %w", p.Name(), err)
+	}
+	return fmt.Errorf("%w: plugin %s: %v", ErrVetoed, p.Name(), err)
+}
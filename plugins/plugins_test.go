@@ -0,0 +1,191 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"todo-app/models"
+)
+
+// testPlugin はフックごとの動作を差し替えられるテスト用のプラグインです
+type testPlugin struct {
+	name           string
+	beforeCreate   func(title string) (string, error)
+	beforeComplete func(task models.Task) error
+	afterComplete  func(store models.TaskStore, task models.Task)
+	onDelete       func(task models.Task) error
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) BeforeCreate(ctx context.Context, title string) (string, error) {
+	if p.beforeCreate == nil {
+		return title, nil
+	}
+	return p.beforeCreate(title)
+}
+
+func (p *testPlugin) BeforeComplete(ctx context.Context, task models.Task) error {
+	if p.beforeComplete == nil {
+		return nil
+	}
+	return p.beforeComplete(task)
+}
+
+func (p *testPlugin) AfterComplete(ctx context.Context, store models.TaskStore, task models.Task) {
+	if p.afterComplete != nil {
+		p.afterComplete(store, task)
+	}
+}
+
+func (p *testPlugin) OnDelete(ctx context.Context, task models.Task) error {
+	if p.onDelete == nil {
+		return nil
+	}
+	return p.onDelete(task)
+}
+
+// namedPlugin はフックを1つも実装しないプラグインです
+type namedPlugin string
+
+func (p namedPlugin) Name() string { return string(p) }
+
+func TestRegister(t *testing.T) {
+	defer func(saved []Plugin) { registry = saved }(registry)
+	registry = nil
+
+	Register(namedPlugin("a"))
+	Register(namedPlugin("b"))
+	got := Registered()
+	if len(got) != 2 || got[0].Name() != "a" || got[1].Name() != "b" {
+		t.Fatalf("Expected plugins a and b in order, got %v", got)
+	}
+
+	for _, p := range []Plugin{nil, namedPlugin("a")} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Register(%v) to panic", p)
+				}
+			}()
+			Register(p)
+		}()
+	}
+}
+
+func TestWrapWithoutPlugins(t *testing.T) {
+	store := models.NewTodoApp()
+	if Wrap(store) != models.TaskStore(store) {
+		t.Error("Expected Wrap without plugins to return the store unchanged")
+	}
+}
+
+func TestBeforeCreate(t *testing.T) {
+	ctx := context.Background()
+	store := Wrap(models.NewTodoApp(),
+		&testPlugin{name: "trim", beforeCreate: func(title string) (string, error) { return strings.TrimSpace(title), nil }},
+		&testPlugin{name: "prefix", beforeCreate: func(title string) (string, error) {
+			if strings.HasPrefix(title, "WIP") {
+				return "", errors.New("drafts are not allowed")
+			}
+			return "[ops] " + title, nil
+		}},
+		namedPlugin("noop"),
+	)
+
+	task, err := store.AddTask(ctx, "  deploy  ")
+	if err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if task.Title != "[ops] deploy" {
+		t.Errorf("Expected the title to be changed by both plugins, got %q", task.Title)
+	}
+
+	_, err = store.AddTask(ctx, "WIP idea")
+	if !errors.Is(err, ErrVetoed) || !errors.Is(err, models.ErrConflict) || !strings.Contains(err.Error(), "prefix") {
+		t.Errorf("Expected a veto from prefix, got %v", err)
+	}
+	if len(store.GetTasks(ctx)) != 1 {
+		t.Errorf("Expected the vetoed task not to be created, got %v", store.GetTasks(ctx))
+	}
+}
+
+func TestVetoKeepsModelErrors(t *testing.T) {
+	store := Wrap(models.NewTodoApp(), &testPlugin{name: "strict", beforeCreate: func(title string) (string, error) {
+		return "", models.ErrValidation
+	}})
+	_, err := store.AddTask(context.Background(), "task")
+	if !errors.Is(err, models.ErrValidation) || errors.Is(err, ErrVetoed) {
+		t.Errorf("Expected the validation error to be kept, got %v", err)
+	}
+}
+
+func TestCompleteHooks(t *testing.T) {
+	ctx := context.Background()
+	inner := models.NewTodoApp()
+	var completed []string
+	store := Wrap(inner, &testPlugin{
+		name: "review",
+		beforeComplete: func(task models.Task) error {
+			if task.Priority == models.PriorityHigh {
+				return errors.New("high priority tasks need a review")
+			}
+			return nil
+		},
+		afterComplete: func(s models.TaskStore, task models.Task) {
+			completed = append(completed, task.Title)
+			s.SetEstimate(ctx, task.ID, 0)
+		},
+	})
+
+	low, _ := store.AddTask(ctx, "low")
+	high, _ := store.AddTask(ctx, "high")
+	store.SetPriority(ctx, high.ID, models.PriorityHigh)
+
+	if err := store.ToggleTask(ctx, low.ID); err != nil {
+		t.Fatalf("ToggleTask failed: %v", err)
+	}
+	if err := store.ToggleTask(ctx, high.ID); !errors.Is(err, ErrVetoed) {
+		t.Errorf("Expected completing a high priority task to be vetoed, got %v", err)
+	}
+	// 完了を取り消すときはフックを呼びません
+	if err := store.ToggleTask(ctx, low.ID); err != nil {
+		t.Fatalf("ToggleTask failed: %v", err)
+	}
+	if err := store.ToggleTask(ctx, 99); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	if len(completed) != 1 || completed[0] != "low" {
+		t.Errorf("Expected AfterComplete once for low, got %v", completed)
+	}
+	for _, task := range store.GetTasks(ctx) {
+		if task.ID == high.ID && task.Completed {
+			t.Error("Expected the vetoed task to stay open")
+		}
+	}
+}
+
+func TestOnDelete(t *testing.T) {
+	ctx := context.Background()
+	store := Wrap(models.NewTodoApp(), &testPlugin{name: "keep", onDelete: func(task models.Task) error {
+		if task.Completed {
+			return nil
+		}
+		return errors.New("only completed tasks can be deleted")
+	}})
+
+	task, _ := store.AddTask(ctx, "task")
+	if err := store.DeleteTask(ctx, task.ID); !errors.Is(err, ErrVetoed) {
+		t.Errorf("Expected deleting an open task to be vetoed, got %v", err)
+	}
+	store.ToggleTask(ctx, task.ID)
+	if err := store.DeleteTask(ctx, task.ID); err != nil {
+		t.Errorf("Expected the completed task to be deleted, got %v", err)
+	}
+	if err := store.DeleteTask(ctx, task.ID); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package pomodoro はタスクに紐づくポモドーロ（時間を区切って集中する作業）のセッションを記録します
+package pomodoro
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// ErrSessionNotFound は指定IDのセッションが存在しないことを表します
+var ErrSessionNotFound = errors.New("pomodoro session not found")
+
+// DefaultMinutes はセッションの長さを省略したときの分数です
+const DefaultMinutes = 25
+
+// maxMinutes は1回のセッションに指定できる最大の分数です
+const maxMinutes = 120
+
+// Status はセッションの状態です
+type Status string
+
+const (
+	// StatusRunning は作業中です
+	StatusRunning Status = "running"
+	// StatusStopped は予定の時間より前に中断しました
+	StatusStopped Status = "stopped"
+	// StatusCompleted は最後まで作業しました
+	StatusCompleted Status = "completed"
+)
+
+// Session は1回のポモドーロです
+// Minutes: 予定した作業時間（分）
+// EndsAt: 予定どおりに終わる日時（タイマーの表示用）
+// EndedAt: 中断・完了した日時（作業中は nil）
+type Session struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	Status    Status     `json:"status"`
+	Minutes   int        `json:"minutes"`
+	StartedAt time.Time  `json:"started_at"`
+	EndsAt    time.Time  `json:"ends_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+// Store はセッションをメモリ上に保持します
+// 同時に作業できるのは1つだけのため、作業中のセッションは常に1件以下です
+type Store struct {
+	sessions []Session
+	nextID   int
+	mutex    sync.RWMutex
+	now      func() time.Time
+}
+
+// NewStore は空の Store を作成します
+func NewStore() *Store {
+	return &Store{sessions: make([]Session, 0), nextID: 1, now: time.Now}
+}
+
+// Start はタスク taskID のセッションを minutes 分の予定で開始します（0 なら DefaultMinutes）
+// 作業中のセッションがあれば ErrConflict を、分数が範囲外なら ErrValidation を返します
+func (s *Store) Start(taskID, minutes int) (Session, error) {
+	if minutes == 0 {
+		minutes = DefaultMinutes
+	}
+	if minutes < 1 || minutes > maxMinutes {
+		return Session{}, fmt.Errorf("%w: minutes must be between 1 and %d", models.ErrValidation, maxMinutes)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, session := range s.sessions {
+		if session.Status == StatusRunning {
+			return Session{}, fmt.Errorf("%w: session %d is still running", models.ErrConflict, session.ID)
+		}
+	}
+
+	now := s.now()
+	session := Session{
+		ID:        s.nextID,
+		TaskID:    taskID,
+		Status:    StatusRunning,
+		Minutes:   minutes,
+		StartedAt: now,
+		EndsAt:    now.Add(time.Duration(minutes) * time.Minute),
+	}
+	s.nextID++
+	s.sessions = append(s.sessions, session)
+	return session, nil
+}
+
+// Stop は作業中のセッションを中断します
+func (s *Store) Stop(id int) (Session, error) {
+	return s.finish(id, StatusStopped)
+}
+
+// Complete は作業中のセッションを完了にします
+func (s *Store) Complete(id int) (Session, error) {
+	return s.finish(id, StatusCompleted)
+}
+
+// finish は作業中のセッションを status にして終了日時を記録します
+// 見つからなければ ErrSessionNotFound を、すでに終わっていれば ErrConflict を返します
+func (s *Store) finish(id int, status Status) (Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.sessions {
+		if s.sessions[i].ID != id {
+			continue
+		}
+		if s.sessions[i].Status != StatusRunning {
+			return Session{}, fmt.Errorf("%w: session %d is already %s", models.ErrConflict, id, s.sessions[i].Status)
+		}
+		now := s.now()
+		s.sessions[i].Status = status
+		s.sessions[i].EndedAt = &now
+		return s.sessions[i].clone(), nil
+	}
+	return Session{}, fmt.Errorf("%w: id %d", ErrSessionNotFound, id)
+}
+
+// ForTask はタスク taskID のセッションを開始した順に返します
+func (s *Store) ForTask(taskID int) []Session {
+	return s.filter(func(session Session) bool {
+		return session.TaskID == taskID
+	})
+}
+
+// OnDate は date の日（date のタイムゾーンでの0時から24時間）に開始したセッションを返します
+func (s *Store) OnDate(date time.Time) []Session {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1)
+	return s.filter(func(session Session) bool {
+		return !session.StartedAt.Before(start) && session.StartedAt.Before(end)
+	})
+}
+
+// filter は match に当てはまるセッションのコピーを返します
+func (s *Store) filter(match func(session Session) bool) []Session {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sessions := make([]Session, 0)
+	for _, session := range s.sessions {
+		if match(session) {
+			sessions = append(sessions, session.clone())
+		}
+	}
+	return sessions
+}
+
+// Completed は sessions のうち完了したセッションの数を返します
+func Completed(sessions []Session) int {
+	count := 0
+	for _, session := range sessions {
+		if session.Status == StatusCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// clone は終了日時のポインタを共有しないコピーを返します
+func (session Session) clone() Session {
+	if session.EndedAt != nil {
+		ended := *session.EndedAt
+		session.EndedAt = &ended
+	}
+	return session
+}
@@ -0,0 +1,88 @@
+package pomodoro
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func newTestStore(now *time.Time) *Store {
+	s := NewStore()
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestStartAndComplete(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(&now)
+
+	session, err := s.Start(1, 0)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if session.ID != 1 || session.TaskID != 1 || session.Status != StatusRunning || session.Minutes != DefaultMinutes {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+	if !session.EndsAt.Equal(now.Add(25 * time.Minute)) {
+		t.Errorf("Expected the session to end after 25 minutes, got %v", session.EndsAt)
+	}
+
+	if _, err := s.Start(2, 25); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected ErrConflict while a session is running, got %v", err)
+	}
+
+	now = now.Add(25 * time.Minute)
+	completed, err := s.Complete(session.ID)
+	if err != nil || completed.Status != StatusCompleted || completed.EndedAt == nil || !completed.EndedAt.Equal(now) {
+		t.Errorf("Unexpected completed session: %+v (%v)", completed, err)
+	}
+	if _, err := s.Stop(session.ID); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a finished session, got %v", err)
+	}
+	if _, err := s.Complete(99); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+
+	if _, err := s.Start(2, 50); err != nil {
+		t.Errorf("Expected a new session after the previous one finished, got %v", err)
+	}
+}
+
+func TestStartValidatesMinutes(t *testing.T) {
+	s := NewStore()
+	for _, minutes := range []int{-1, 121} {
+		if _, err := s.Start(1, minutes); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %d minutes, got %v", minutes, err)
+		}
+	}
+}
+
+func TestHistoryAndDailyCount(t *testing.T) {
+	now := time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC)
+	s := newTestStore(&now)
+
+	first, _ := s.Start(1, 25)
+	s.Complete(first.ID)
+	now = now.Add(2 * time.Hour) // 11日の1時
+	second, _ := s.Start(2, 25)
+	s.Stop(second.ID)
+	third, _ := s.Start(1, 25)
+	s.Complete(third.ID)
+
+	history := s.ForTask(1)
+	if len(history) != 2 || history[0].ID != first.ID || history[1].ID != third.ID {
+		t.Errorf("Unexpected history for task 1: %+v", history)
+	}
+
+	day := s.OnDate(time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC))
+	if len(day) != 2 || Completed(day) != 1 {
+		t.Errorf("Expected 2 sessions and 1 completed on the 11th, got %+v", day)
+	}
+
+	// 東京（UTC+9）では3件とも11日です
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	if day := s.OnDate(time.Date(2025, 3, 11, 0, 0, 0, 0, tokyo)); len(day) != 3 || Completed(day) != 2 {
+		t.Errorf("Expected 3 sessions and 2 completed on the 11th in Tokyo, got %+v", day)
+	}
+}
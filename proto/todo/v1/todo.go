@@ -0,0 +1,151 @@
+package todov1
+
+import (
+	"fmt"
+	"strings"
+
+	"todo-app/accounts"
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+// ContentType は wire 形式のメッセージを返すときの Content-Type です
+const ContentType = "application/x-protobuf"
+
+// priorities は enum Priority の値です（未設定は 0）
+var priorities = map[models.Priority]int64{
+	models.PriorityLow:    1,
+	models.PriorityMedium: 2,
+	models.PriorityHigh:   3,
+}
+
+// frequencies は enum Frequency の値です（未設定は 0）
+var frequencies = map[models.Frequency]int64{
+	models.FrequencyDaily:   1,
+	models.FrequencyWeekly:  2,
+	models.FrequencyMonthly: 3,
+}
+
+// MarshalTask は task を message Task にします
+func MarshalTask(task models.Task) []byte {
+	return appendTask(nil, task)
+}
+
+func appendTask(b []byte, task models.Task) []byte {
+	b = appendInt(b, 1, int64(task.ID))
+	b = appendString(b, 2, task.Title)
+	b = appendString(b, 24, task.Description)
+	b = appendBool(b, 3, task.Completed)
+	b = appendTimestamp(b, 4, task.DueDate)
+	b = appendTimestamp(b, 5, task.ScheduledDate)
+	b = appendInt(b, 6, priorities[task.Priority])
+	b = appendInt(b, 16, int64(task.ListID))
+	b = appendStrings(b, 17, task.Tags)
+	b = appendInt(b, 22, int64(task.ParentID))
+	if task.Recurrence != nil {
+		var r []byte
+		r = appendInt(r, 1, frequencies[task.Recurrence.Frequency])
+		r = appendInt(r, 2, int64(task.Recurrence.Interval))
+		b = appendBytes(b, 19, r)
+	}
+	b = appendTimestamp(b, 7, task.CreatedAt)
+	b = appendTimestamp(b, 8, task.CompletedAt)
+	b = appendTimestamp(b, 9, task.UpdatedAt)
+	b = appendInt(b, 10, int64(task.EstimateMinutes))
+	for _, entry := range task.TimeEntries {
+		var e []byte
+		e = appendInt(e, 1, int64(entry.ID))
+		e = appendTimestamp(e, 2, &entry.Start)
+		e = appendTimestamp(e, 3, entry.End)
+		b = appendBytes(b, 11, e)
+	}
+	b = appendInt(b, 12, task.TrackedSeconds)
+	if task.Progress != nil {
+		var p []byte
+		p = appendInt(p, 1, int64(task.Progress.Completed))
+		p = appendInt(p, 2, int64(task.Progress.Total))
+		p = appendInt(p, 3, int64(task.Progress.Percent))
+		b = appendBytes(b, 23, p)
+	}
+	b = appendStrings(b, 13, task.BlindIndex)
+	b = appendString(b, 14, task.ClaimedBy)
+	b = appendTimestamp(b, 15, task.ClaimedAt)
+	b = appendTimestamp(b, 20, task.RemindAt)
+	b = appendTimestamp(b, 21, task.RemindedAt)
+	for _, comment := range task.Comments {
+		var c []byte
+		c = appendInt(c, 1, int64(comment.ID))
+		c = appendString(c, 2, comment.Author)
+		c = appendString(c, 3, comment.Body)
+		c = appendTimestamp(c, 4, &comment.CreatedAt)
+		b = appendBytes(b, 25, c)
+	}
+	b = appendTimestamp(b, 18, task.DeletedAt)
+	return b
+}
+
+// MarshalList は list を message List にします
+func MarshalList(list lists.List) []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(list.ID))
+	b = appendString(b, 2, list.Name)
+	return appendTimestamp(b, 3, &list.CreatedAt)
+}
+
+// MarshalUser は user を message User にします
+func MarshalUser(user accounts.User) []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(user.ID))
+	b = appendString(b, 2, user.Name)
+	b = appendString(b, 3, user.Email)
+	b = appendBool(b, 4, user.EmailVerified)
+	b = appendBool(b, 5, user.Disabled)
+	return appendTimestamp(b, 6, &user.CreatedAt)
+}
+
+// MarshalTasks は tasks を message ListTasksResponse にします
+func MarshalTasks(tasks []models.Task) []byte {
+	var b []byte
+	for _, task := range tasks {
+		b = appendBytes(b, 1, MarshalTask(task))
+	}
+	return b
+}
+
+// MarshalLists は taskLists を message ListListsResponse にします
+func MarshalLists(taskLists []lists.List) []byte {
+	var b []byte
+	for _, list := range taskLists {
+		b = appendBytes(b, 1, MarshalList(list))
+	}
+	return b
+}
+
+// UnmarshalGetTaskRequest は message GetTaskRequest からタスクの ID を読み取ります
+func UnmarshalGetTaskRequest(b []byte) (int, error) {
+	fields, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	id := 0
+	for _, field := range fields {
+		if field.Number == 1 {
+			if field.Type != wireVarint {
+				return 0, fmt.Errorf("%w: id must be a varint", ErrInvalidMessage)
+			}
+			id = int(int64(field.Varint))
+		}
+	}
+	return id, nil
+}
+
+// Accepts は Accept ヘッダの値が wire 形式（application/x-protobuf か application/protobuf）を求めているかを返します
+func Accepts(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+		if strings.EqualFold(mediaType, ContentType) || strings.EqualFold(mediaType, "application/protobuf") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package todov1
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/accounts"
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+// protoFields は todo.proto の message name のフィールドの名前と番号を宣言順に返します
+func protoFields(t *testing.T, name string) ([]string, []int) {
+	t.Helper()
+	data, err := os.ReadFile("todo.proto")
+	if err != nil {
+		t.Fatalf("Failed to read the proto file: %v", err)
+	}
+	body := regexp.MustCompile(`(?s)message ` + name + ` \{(.*?)\n\}`).FindStringSubmatch(string(data))
+	if body == nil {
+		t.Fatalf("message %s not found in the proto file", name)
+	}
+	var names []string
+	var numbers []int
+	for _, m := range regexp.MustCompile(`(?m)^\s+(?:repeated )?[\w.]+ (\w+) = (\d+);`).FindAllStringSubmatch(body[1], -1) {
+		number, _ := strconv.Atoi(m[2])
+		names = append(names, m[1])
+		numbers = append(numbers, number)
+	}
+	return names, numbers
+}
+
+// fieldNumbers は message b に出てきたフィールドの番号を、重複を除いて小さい順に返します
+func fieldNumbers(t *testing.T, b []byte) []int {
+	t.Helper()
+	fields, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	seen := map[int]bool{}
+	var numbers []int
+	for _, field := range fields {
+		if !seen[field.Number] {
+			seen[field.Number] = true
+			numbers = append(numbers, field.Number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// TestProtoMatchesTypes は message List と User が lists.List と accounts.User の JSON と同じフィールドを持つことを確認します
+func TestProtoMatchesTypes(t *testing.T) {
+	for name, v := range map[string]interface{}{"List": lists.List{}, "User": accounts.User{}} {
+		var want []string
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			want = append(want, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		if got, _ := protoFields(t, name); !reflect.DeepEqual(got, want) {
+			t.Errorf("message %s has fields %v, expected %v", name, got, want)
+		}
+	}
+}
+
+// TestMarshalFieldNumbers はすべての項目を埋めたときに、todo.proto のすべての番号をそのまま使うことを確認します
+func TestMarshalFieldNumbers(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 5, time.UTC)
+	task := models.Task{
+		ID: 1, Title: "Title", Description: "Body", Completed: true, DueDate: &now, ScheduledDate: &now,
+		Priority: models.PriorityHigh, ListID: 2, Tags: []string{"a", "b"}, ParentID: 3,
+		Recurrence: &models.Recurrence{Frequency: models.FrequencyWeekly, Interval: 2},
+		CreatedAt:  &now, CompletedAt: &now, UpdatedAt: &now, EstimateMinutes: 30,
+		TimeEntries: []models.TimeEntry{{ID: 1, Start: now, End: &now}}, TrackedSeconds: 60,
+		Progress: &models.Progress{Completed: 1, Total: 2, Percent: 50}, BlindIndex: []string{"x"},
+		ClaimedBy: "alice", ClaimedAt: &now, RemindAt: &now, RemindedAt: &now,
+		Comments: []models.Comment{{ID: 1, Author: "bob", Body: "Hi", CreatedAt: now}}, DeletedAt: &now,
+	}
+	user := accounts.User{ID: 1, Name: "alice", Email: "a@example.com", EmailVerified: true, Disabled: true, CreatedAt: now}
+
+	for name, message := range map[string][]byte{
+		"Task": MarshalTask(task),
+		"List": MarshalList(lists.List{ID: 1, Name: "Work", CreatedAt: now}),
+		"User": MarshalUser(user),
+	} {
+		_, want := protoFields(t, name)
+		sort.Ints(want)
+		if got := fieldNumbers(t, message); !reflect.DeepEqual(got, want) {
+			t.Errorf("message %s uses field numbers %v, expected %v", name, got, want)
+		}
+	}
+
+	if got := MarshalTask(models.Task{}); len(got) != 0 {
+		t.Errorf("Expected zero values to be omitted, got %x", got)
+	}
+}
+
+func TestMarshalLists(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	fields, err := Parse(MarshalLists([]lists.List{{ID: 1, Name: "Work", CreatedAt: created}, {ID: 2, Name: "Home", CreatedAt: created}}))
+	if err != nil || len(fields) != 2 || fields[0].Number != 1 || fields[0].Type != wireBytes {
+		t.Fatalf("Unexpected ListListsResponse %+v, %v", fields, err)
+	}
+	list, err := Parse(fields[1].Bytes)
+	if err != nil || len(list) != 3 || list[0].Varint != 2 || string(list[1].Bytes) != "Home" {
+		t.Fatalf("Unexpected List %+v, %v", list, err)
+	}
+	timestamp, err := Parse(list[2].Bytes)
+	if err != nil || len(timestamp) != 1 || timestamp[0].Varint != 1700000000 {
+		t.Errorf("Unexpected Timestamp %+v, %v", timestamp, err)
+	}
+}
+
+func TestUnmarshalGetTaskRequest(t *testing.T) {
+	if id, err := UnmarshalGetTaskRequest([]byte{0x08, 0x96, 0x01}); err != nil || id != 150 {
+		t.Errorf("Expected id 150, got %d, %v", id, err)
+	}
+	if id, err := UnmarshalGetTaskRequest(nil); err != nil || id != 0 {
+		t.Errorf("Expected id 0 for an empty request, got %d, %v", id, err)
+	}
+	for _, b := range [][]byte{{0x0a, 0x01, 0x41}, {0x08}, {0x0a, 0x05, 0x41}, {0x0b}} {
+		if _, err := UnmarshalGetTaskRequest(b); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("%x: expected ErrInvalidMessage, got %v", b, err)
+		}
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	testCases := map[string]bool{
+		"application/x-protobuf":                       true,
+		"application/json;q=0.5, application/protobuf": true,
+		"application/json":                             false,
+		"":                                             false,
+	}
+	for accept, want := range testCases {
+		if got := Accepts(accept); got != want {
+			t.Errorf("Accepts(%q) = %v, expected %v", accept, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package todov1 は todo.proto のメッセージを Protocol Buffers の wire 形式で読み書きします
+//
+// protoc の生成するコード（google.golang.org/protobuf が必要です）の代わりに、標準ライブラリだけで
+// models などの型を直接 wire 形式に変換します。フィールドの番号は todo.proto と同じにし、
+// go test ./proto/... で食い違いを確認します
+package todov1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidMessage は wire 形式として読み取れないメッセージのエラーです
+var ErrInvalidMessage = errors.New("invalid protobuf message")
+
+// wire 形式の値の種類（wire type）です
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Field は読み取った1つのフィールドです
+// Varint: wire type が varint のときの値
+// Bytes: wire type が length-delimited のときの中身（文字列・埋め込みのメッセージ・repeated の要素）
+type Field struct {
+	Number int
+	Type   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// Parse はメッセージ b のフィールドを出てきた順に返します（repeated のフィールドは要素ごとに1つです）
+// fixed32 / fixed64 の値は読み飛ばし、知らない wire type や途中で切れたメッセージは ErrInvalidMessage を返します
+func Parse(b []byte) ([]Field, error) {
+	var fields []Field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 || key>>3 == 0 {
+			return nil, fmt.Errorf("%w: bad field key", ErrInvalidMessage)
+		}
+		b = b[n:]
+		field := Field{Number: int(key >> 3), Type: int(key & 7)}
+		switch field.Type {
+		case wireVarint:
+			if field.Varint, n = binary.Uvarint(b); n <= 0 {
+				return nil, fmt.Errorf("%w: bad varint in field %d", ErrInvalidMessage, field.Number)
+			}
+			b = b[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return nil, fmt.Errorf("%w: truncated field %d", ErrInvalidMessage, field.Number)
+			}
+			field.Bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if field.Type == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return nil, fmt.Errorf("%w: truncated field %d", ErrInvalidMessage, field.Number)
+			}
+			b = b[size:]
+		default:
+			return nil, fmt.Errorf("%w: unsupported wire type %d in field %d", ErrInvalidMessage, field.Type, field.Number)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func appendKey(b []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+// appendInt は整数のフィールドを追加します（proto3 なので 0 は省きます。負の数は 10 バイトの varint にします）
+func appendInt(b []byte, number int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendKey(b, number, wireVarint), uint64(v))
+}
+
+func appendBool(b []byte, number int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendInt(b, number, 1)
+}
+
+func appendString(b []byte, number int, v string) []byte {
+	if v == "" {
+		return b
+	}
+	return appendBytes(b, number, []byte(v))
+}
+
+func appendBytes(b []byte, number int, v []byte) []byte {
+	b = binary.AppendUvarint(appendKey(b, number, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendStrings は repeated string のフィールドを要素ごとに追加します（空の文字列の要素も省きません）
+func appendStrings(b []byte, number int, values []string) []byte {
+	for _, v := range values {
+		b = appendBytes(b, number, []byte(v))
+	}
+	return b
+}
+
+// appendTimestamp は google.protobuf.Timestamp（seconds = 1、nanos = 2）のフィールドを追加します。nil なら省きます
+func appendTimestamp(b []byte, number int, t *time.Time) []byte {
+	if t == nil {
+		return b
+	}
+	var ts []byte
+	ts = appendInt(ts, 1, t.Unix())
+	ts = appendInt(ts, 2, int64(t.Nanosecond()))
+	return appendBytes(b, number, ts)
+}
@@ -0,0 +1,272 @@
+// Package qrcode は URL などの短い文字列を QR コード（JIS X 0510 / ISO/IEC 18004）の PNG 画像にします
+// 外部のライブラリを使わずに、バイトモード・誤り訂正レベル M・型番 1〜10 だけに対応しています
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// ErrTooLong は型番 10 の QR コードに収まらない長さの文字列を渡したときのエラーです
+var ErrTooLong = errors.New("qrcode: data is too long")
+
+// MaxBytes は符号化できる最大のバイト数です（型番 10・誤り訂正レベル M）
+const MaxBytes = 213
+
+// quietZone は周囲に空ける余白のモジュール数です
+const quietZone = 4
+
+// Code は符号化した QR コードです。Size × Size のモジュール（黒なら true）からなります
+type Code struct {
+	Version int
+	Size    int
+	Mask    int
+	modules [][]bool
+}
+
+// Dark は x 列 y 行のモジュールが黒かを返します
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode は data をバイトモードで符号化し、収まる最小の型番の QR コードを返します
+// マスクは減点の最も少ないものを選びます
+func Encode(data string) (*Code, error) {
+	version := 0
+	for v := 1; v <= len(blockTable); v++ {
+		if len(data) <= capacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(version, encodeData(version, []byte(data)))
+	best, bestPenalty := (*Code)(nil), -1
+	for mask := 0; mask < 8; mask++ {
+		code := build(version, codewords, mask)
+		if penalty := code.penalty(); best == nil || penalty < bestPenalty {
+			best, bestPenalty = code, penalty
+		}
+	}
+	return best, nil
+}
+
+// Image は1モジュールを scale ピクセルにした、余白付きの白黒画像を返します
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	width := (c.Size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG は data の QR コードを PNG 画像として w に書き出します
+func WritePNG(w io.Writer, data string, scale int) error {
+	code, err := Encode(data)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, code.Image(scale))
+}
+
+// blockInfo は型番ごとの誤り訂正レベル M のブロック構成です
+// ecPerBlock: ブロックごとの誤り訂正コード語の数
+// blocks1 / data1: 1つ目のグループのブロック数とデータコード語の数（2つ目のグループは data1+1 個ずつ）
+type blockInfo struct {
+	ecPerBlock int
+	blocks1    int
+	data1      int
+	blocks2    int
+}
+
+var blockTable = []blockInfo{
+	{10, 1, 16, 0},
+	{16, 1, 28, 0},
+	{26, 1, 44, 0},
+	{18, 2, 32, 0},
+	{24, 2, 43, 0},
+	{16, 4, 27, 0},
+	{18, 4, 31, 0},
+	{22, 2, 38, 2},
+	{22, 3, 36, 2},
+	{26, 4, 43, 1},
+}
+
+// alignmentTable は型番ごとの位置合わせパターンの中心の座標です
+var alignmentTable = [][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// dataCodewords は型番のデータコード語の数を返します
+func dataCodewords(version int) int {
+	info := blockTable[version-1]
+	return info.blocks1*info.data1 + info.blocks2*(info.data1+1)
+}
+
+// countBits は型番の文字数指示子のビット数を返します（バイトモード）
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// capacity は型番に収まる最大のバイト数を返します
+func capacity(version int) int {
+	return (dataCodewords(version)*8 - 4 - countBits(version)) / 8
+}
+
+// encodeData はモード指示子・文字数・データ・終端パターン・埋め草を並べたデータコード語を返します
+func encodeData(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	total := dataCodewords(version) * 8
+	terminator := total - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < total; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// bitBuffer は上位ビットから順に並べたビット列です
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// addErrorCorrection はデータをブロックに分けて誤り訂正コード語を付け、規格の順に交互に並べます
+func addErrorCorrection(version int, data []byte) []byte {
+	info := blockTable[version-1]
+	var blocks, ecBlocks [][]byte
+	offset := 0
+	for i := 0; i < info.blocks1+info.blocks2; i++ {
+		length := info.data1
+		if i >= info.blocks1 {
+			length++
+		}
+		block := data[offset : offset+length]
+		offset += length
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, reedSolomon(block, info.ecPerBlock))
+	}
+
+	result := make([]byte, 0, len(data)+len(blocks)*info.ecPerBlock)
+	for i := 0; i <= info.data1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, ec := range ecBlocks {
+			result = append(result, ec[i])
+		}
+	}
+	return result
+}
+
+// gfExp / gfLog は GF(2^8)（原始多項式 x^8+x^4+x^3+x^2+1）の指数と対数の表です
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// reedSolomon は data の誤り訂正コード語を n 個求めます
+func reedSolomon(data []byte, n int) []byte {
+	// 生成多項式 (x - α^0)(x - α^1)…(x - α^(n-1)) の係数（最高次の 1 は省きます）
+	generator := make([]byte, n)
+	generator[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			generator[j] = gfMul(generator[j], root)
+			if j+1 < n {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+
+	remainder := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[n-1] = 0
+		for i := range remainder {
+			remainder[i] ^= gfMul(generator[i], factor)
+		}
+	}
+	return remainder
+}
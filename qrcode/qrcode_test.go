@@ -0,0 +1,129 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// 型番 1-M の "HELLO WORLD" のデータコード語と誤り訂正コード語（規格の解説でよく使われる例）
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	formats := map[int]int{0: 0x5412, 1: 0x5125, 5: 0x40CE, 7: 0x4AA0}
+	for mask, want := range formats {
+		if got := formatBits(mask); got != want {
+			t.Errorf("Mask %d: expected format bits %015b, got %015b", mask, want, got)
+		}
+	}
+	if got := versionBits(7); got != 0x07C94 {
+		t.Errorf("Expected version bits 000111110010010100, got %018b", got)
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+	got := encodeData(1, []byte("hi"))
+	// 0100 | 00000010 | 01101000 01101001 | 0000 | 埋め草 0xEC 0x11 ...
+	want := []byte{0x40, 0x26, 0x86, 0x90, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %x, got %x", want, got)
+	}
+}
+
+func TestEncodeVersions(t *testing.T) {
+	testCases := []struct {
+		length  int
+		version int
+	}{
+		{1, 1}, {14, 1}, {15, 2}, {106, 6}, {107, 7}, {180, 9}, {MaxBytes, 10},
+	}
+	for _, tc := range testCases {
+		code, err := Encode(strings.Repeat("a", tc.length))
+		if err != nil {
+			t.Errorf("%d bytes: Encode failed: %v", tc.length, err)
+			continue
+		}
+		if code.Version != tc.version || code.Size != tc.version*4+17 {
+			t.Errorf("%d bytes: expected version %d, got %d (size %d)", tc.length, tc.version, code.Version, code.Size)
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("a", MaxBytes+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+}
+
+// TestEncodeReadBack は配置したモジュールをマスクを外して読み戻し、誤り訂正付きのコード語と一致することを確かめます
+func TestEncodeReadBack(t *testing.T) {
+	for _, data := range []string{"https://example.com/t/k7Hq2Rx", strings.Repeat("https://example.com/share/", 7)} {
+		code, err := Encode(data)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		want := addErrorCorrection(code.Version, encodeData(code.Version, []byte(data)))
+
+		m := functionPatterns(code.Version, code.Mask)
+		var bits bitBuffer
+		for right := code.Size - 1; right >= 1; right -= 2 {
+			if right == 6 {
+				right = 5
+			}
+			for vertical := 0; vertical < code.Size; vertical++ {
+				for j := 0; j < 2; j++ {
+					x, y := right-j, vertical
+					if (right+1)&2 == 0 {
+						y = code.Size - 1 - vertical
+					}
+					if !m.function[y][x] {
+						bits = append(bits, code.Dark(x, y) != masked(code.Mask, x, y))
+					}
+				}
+			}
+		}
+		if got := bits.bytes()[:len(want)]; !bytes.Equal(got, want) {
+			t.Errorf("Version %d: read back codewords differ", code.Version)
+		}
+
+		// 機能パターンはマスクの影響を受けません
+		if !code.Dark(0, 0) || code.Dark(1, 1) || !code.Dark(3, 3) || !code.Dark(8, code.Size-8) {
+			t.Errorf("Version %d: unexpected finder pattern", code.Version)
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, "https://example.com", 4); err != nil {
+		t.Fatalf("WritePNG failed: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	// 型番 2（25 モジュール）と両側 4 モジュールの余白
+	if width := img.Bounds().Dx(); width != (25+8)*4 {
+		t.Errorf("Expected width %d, got %d", (25+8)*4, width)
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("Expected a white quiet zone")
+	}
+	if r, _, _, _ := img.At(4*4, 4*4).RGBA(); r != 0 {
+		t.Error("Expected the finder pattern corner to be black")
+	}
+
+	if err := WritePNG(&buf, strings.Repeat("x", MaxBytes+1), 4); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+	if img := (&Code{Size: 1, modules: [][]bool{{true}}}).Image(0); img.Bounds().Dx() != 9 {
+		t.Errorf("Expected scale to default to 1, got width %d", img.Bounds().Dx())
+	}
+}
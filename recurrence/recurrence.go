@@ -0,0 +1,122 @@
+// Package recurrence は繰り返すタスク（models.Recurrence）を完了したとき、次の回のタスクを作ります
+// 次の回のタスクは同じタイトル・優先度・リスト・タグ・見積もりで、期限を繰り返しの間隔だけ進めたものです
+// 完了したタスクは履歴として残し、繰り返し方だけを次の回のタスクへ移します
+package recurrence
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"todo-app/models"
+)
+
+// Schedule は store の完了した繰り返すタスクすべてについて次の回のタスクを作り、作った件数を返します
+func Schedule(ctx context.Context, store models.TaskStore) (int, error) {
+	n := 0
+	for _, task := range store.GetTasks(ctx) {
+		if !task.Completed || task.Recurrence == nil {
+			continue
+		}
+		if _, err := Next(ctx, store, task); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Next は完了した繰り返すタスク task の次の回のタスクを作って返し、task からは繰り返し方を外します
+// 期限は models.Recurrence.NextDueDate で決め、予定日があれば期限と同じだけ進めます
+// 期限のあるタスクのリマインダーも期限と同じだけ進めます（期限がなければリマインダーは引き継ぎません）
+// 次の回を作った後、繰り返し方を外す前に失敗すると、もう一度呼んだときに次の回をもう1つ作ります
+func Next(ctx context.Context, store models.TaskStore, task models.Task) (models.Task, error) {
+	completedAt := time.Now()
+	if task.CompletedAt != nil {
+		completedAt = *task.CompletedAt
+	}
+	due := task.Recurrence.NextDueDate(task.DueDate, completedAt)
+
+	next, err := store.AddTask(ctx, task.Title)
+	if err != nil {
+		return models.Task{}, err
+	}
+	update := models.TaskUpdate{DueDate: &due, Recurrence: task.Recurrence}
+	if task.Description != "" {
+		update.Description = &task.Description
+	}
+	if task.Priority != "" {
+		update.Priority = &task.Priority
+	}
+	if task.ListID != 0 {
+		update.ListID = &task.ListID
+	}
+	if len(task.Tags) > 0 {
+		update.Tags = &task.Tags
+	}
+	steps := []func() error{func() error { return store.UpdateTask(ctx, next.ID, update) }}
+	if task.ScheduledDate != nil {
+		scheduled := task.Recurrence.NextDueDate(task.ScheduledDate, completedAt)
+		if task.DueDate != nil {
+			scheduled = task.ScheduledDate.Add(due.Sub(*task.DueDate))
+		}
+		steps = append(steps, func() error { return store.SetScheduledDate(ctx, next.ID, &scheduled) })
+	}
+	if task.RemindAt != nil && task.DueDate != nil {
+		remindAt := task.RemindAt.Add(due.Sub(*task.DueDate))
+		steps = append(steps, func() error { return store.SetReminder(ctx, next.ID, &remindAt) })
+	}
+	if task.EstimateMinutes > 0 {
+		steps = append(steps, func() error { return store.SetEstimate(ctx, next.ID, task.EstimateMinutes) })
+	}
+	if len(task.BlindIndex) > 0 {
+		steps = append(steps, func() error { return store.SetBlindIndex(ctx, next.ID, task.BlindIndex) })
+	}
+	steps = append(steps, func() error {
+		return store.UpdateTask(ctx, task.ID, models.TaskUpdate{ClearRecurrence: true})
+	})
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	for _, t := range store.GetTasks(ctx) {
+		if t.ID == next.ID {
+			return t, nil
+		}
+	}
+	return next, nil
+}
+
+// Run は ctx がキャンセルされるまで、interval ごとと、繰り返すタスクを完了したイベントを受け取るたびに Schedule を呼び出します
+// 最初の1回は起動してすぐに行います（止まっている間に完了したタスクの分を作ります）
+func Run(ctx context.Context, store models.TaskStore, interval time.Duration) {
+	wake := make(chan struct{}, 1)
+	unsubscribe := store.Subscribe(func(event models.Event) {
+		if event.Type == models.EventTaskUpdated && event.Task.Completed && event.Task.Recurrence != nil {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := Schedule(ctx, store); err != nil {
+			slog.Error("recurrence: failed to schedule the next occurrence", "err", err)
+		} else if n > 0 {
+			slog.Info("recurrence: scheduled the next occurrences", "tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
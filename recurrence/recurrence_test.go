@@ -0,0 +1,85 @@
+package recurrence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSchedule(t *testing.T) {
+	ctx := context.Background()
+	completedAt := time.Date(2025, 3, 12, 18, 0, 0, 0, time.UTC)
+	store := models.NewTodoApp(models.WithClock(func() time.Time { return completedAt }))
+	task, _ := store.AddTask(ctx, "Take out the trash")
+	due, scheduled := date(2025, 3, 3), date(2025, 3, 2)
+	high, tags, description := models.PriorityHigh, []string{"home"}, "Sort the **recycling** first"
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{Description: &description, DueDate: &due, Priority: &high, Tags: &tags, Recurrence: &models.Recurrence{Frequency: models.FrequencyWeekly}})
+	store.SetScheduledDate(ctx, task.ID, &scheduled)
+	store.SetEstimate(ctx, task.ID, 15)
+	remindAt := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	store.SetReminder(ctx, task.ID, &remindAt)
+	store.AddTask(ctx, "Not recurring")
+
+	if n, err := Schedule(ctx, store); err != nil || n != 0 {
+		t.Fatalf("Expected nothing to schedule before completion, got %d %v", n, err)
+	}
+	store.ToggleTask(ctx, task.ID)
+	if n, err := Schedule(ctx, store); err != nil || n != 1 {
+		t.Fatalf("Expected one occurrence to be scheduled, got %d %v", n, err)
+	}
+
+	tasks := store.GetTasks(ctx)
+	if len(tasks) != 3 {
+		t.Fatalf("Expected the next occurrence to be added, got %+v", tasks)
+	}
+	if done := tasks[0]; !done.Completed || done.Recurrence != nil {
+		t.Errorf("Expected the completed task to keep its state without the recurrence, got %+v", done)
+	}
+	// 期限を過ぎてから完了したので、完了した日より後の最初の回（3月17日）まで進めます
+	next := tasks[2]
+	if next.Title != "Take out the trash" || next.Description != description || next.Completed || next.Priority != high || len(next.Tags) != 1 || next.EstimateMinutes != 15 ||
+		next.Recurrence == nil || next.Recurrence.Frequency != models.FrequencyWeekly {
+		t.Errorf("Expected the next occurrence to copy the task, got %+v", next)
+	}
+	if next.DueDate == nil || !next.DueDate.Equal(date(2025, 3, 17)) || next.ScheduledDate == nil || !next.ScheduledDate.Equal(date(2025, 3, 16)) {
+		t.Errorf("Expected the next occurrence on 2025-03-17 (scheduled 2025-03-16), got %v %v", next.DueDate, next.ScheduledDate)
+	}
+	if next.RemindAt == nil || !next.RemindAt.Equal(time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the reminder to move with the due date, got %v", next.RemindAt)
+	}
+
+	if n, _ := Schedule(ctx, store); n != 0 {
+		t.Errorf("Expected the occurrence to be scheduled only once, got %d", n)
+	}
+}
+
+func TestRunSchedulesOnCompletion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Water the plants")
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{Recurrence: &models.Recurrence{Frequency: models.FrequencyDaily, Interval: 2}})
+
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, store, time.Hour)
+		close(done)
+	}()
+	store.ToggleTask(ctx, task.ID)
+	deadline := time.Now().Add(time.Second)
+	for len(store.GetTasks(ctx)) != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	tasks := store.GetTasks(ctx)
+	if len(tasks) != 2 || tasks[1].DueDate == nil || tasks[1].Recurrence == nil || tasks[1].Recurrence.Interval != 2 {
+		t.Fatalf("Expected Run to schedule the next occurrence after completion, got %+v", tasks)
+	}
+}
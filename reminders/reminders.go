@@ -0,0 +1,95 @@
+// Package reminders はリマインダー（models.Task の RemindAt）の日時になったタスクを通知します
+// 通知は models.TaskStore.FireReminder が配信する task.reminder のイベントで、
+// WebSocket・/api/events を購読している画面と、Webhook・コマンドフックへ届きます
+package reminders
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"todo-app/models"
+)
+
+// DefaultSnooze は後で通知し直すとき、時間を指定しなければ待つ時間です
+const DefaultSnooze = 10 * time.Minute
+
+// MaxSnooze は後で通知し直すまでに待てる最長の時間です
+const MaxSnooze = 7 * 24 * time.Hour
+
+// Fire は store のタスクのうち now の時点で通知する時刻になったリマインダーを通知し、通知した件数を返します
+// 調べてから通知するまでにほかで変更された（止めた・後にした・完了した・削除した）タスクは通知しません
+func Fire(ctx context.Context, store models.TaskStore, now time.Time) (int, error) {
+	n := 0
+	for _, task := range store.GetTasks(ctx) {
+		if !task.ReminderDue(now) {
+			continue
+		}
+		err := store.FireReminder(ctx, task.ID, now)
+		if errors.Is(err, models.ErrConflict) || errors.Is(err, models.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// next は tasks のうちまだ通知していないリマインダーで最も早い日時を返します（なければ false）
+func next(tasks []models.Task) (time.Time, bool) {
+	var earliest time.Time
+	found := false
+	for _, task := range tasks {
+		if task.RemindAt == nil || task.RemindedAt != nil || task.Completed {
+			continue
+		}
+		if !found || task.RemindAt.Before(earliest) {
+			earliest, found = *task.RemindAt, true
+		}
+	}
+	return earliest, found
+}
+
+// Run は ctx がキャンセルされるまで、リマインダーの日時になるたびに Fire を呼び出します
+// 次のリマインダーの日時まで待ちますが、待つのは長くても interval までです（時計のずれや、通知に失敗したときのやり直しに備えます）
+// リマインダーを設定・変更したイベントを受け取ったら、待つ時間を決め直します
+func Run(ctx context.Context, store models.TaskStore, interval time.Duration) {
+	wake := make(chan struct{}, 1)
+	unsubscribe := store.Subscribe(func(event models.Event) {
+		if event.Type == models.EventTaskUpdated && event.Task.RemindAt != nil && event.Task.RemindedAt == nil {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		now := time.Now()
+		wait := interval
+		if n, err := Fire(ctx, store, now); err != nil {
+			// 失敗したリマインダーは通知する時刻を過ぎたまま残るため、すぐには繰り返さず interval の後にやり直します
+			slog.Error("reminders: failed to fire", "err", err)
+		} else {
+			if n > 0 {
+				slog.Info("reminders: fired", "tasks", n)
+			}
+			if at, ok := next(store.GetTasks(ctx)); ok && at.Sub(now) < wait {
+				wait = at.Sub(now)
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-wake:
+			timer.Stop()
+		}
+	}
+}
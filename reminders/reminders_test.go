@@ -0,0 +1,67 @@
+package reminders
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestFire(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewTodoApp()
+	at := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	later := at.Add(time.Hour)
+	dentist, _ := store.AddTask(ctx, "Call the dentist")
+	store.SetReminder(ctx, dentist.ID, &at)
+	report, _ := store.AddTask(ctx, "Send the report")
+	store.SetReminder(ctx, report.ID, &later)
+	done, _ := store.AddTask(ctx, "Already done")
+	store.SetReminder(ctx, done.ID, &at)
+	store.ToggleTask(ctx, done.ID)
+
+	var fired []int
+	store.Subscribe(func(event models.Event) {
+		if event.Type == models.EventTaskReminder {
+			fired = append(fired, event.Task.ID)
+		}
+	})
+	if n, err := Fire(ctx, store, at.Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("Expected one reminder to fire, got %d %v", n, err)
+	}
+	if len(fired) != 1 || fired[0] != dentist.ID {
+		t.Errorf("Expected a task.reminder event for task %d only (completed tasks are skipped), got %v", dentist.ID, fired)
+	}
+	if n, _ := Fire(ctx, store, at.Add(2*time.Minute)); n != 0 {
+		t.Errorf("Expected the reminder to fire only once, got %d", n)
+	}
+
+	if at, ok := next(store.GetTasks(ctx)); !ok || !at.Equal(later) {
+		t.Errorf("Expected the next reminder at %v, got %v %v", later, at, ok)
+	}
+}
+
+func TestRunFiresWhenAReminderIsSet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Stretch")
+
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, store, time.Hour)
+		close(done)
+	}()
+	soon := time.Now().Add(50 * time.Millisecond)
+	store.SetReminder(ctx, task.ID, &soon)
+	deadline := time.Now().Add(2 * time.Second)
+	for store.GetTasks(ctx)[0].RemindedAt == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := store.GetTasks(ctx)[0]; got.RemindedAt == nil || got.RemindedAt.Before(soon) {
+		t.Fatalf("Expected Run to fire the reminder at its time, got %+v", got)
+	}
+}
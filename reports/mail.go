@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Mailer は SMTP サーバを通してレポートをメールで送ります
+// Addr: SMTP サーバのアドレス（smtp.example.com:587 など）
+// From: 送信元のメールアドレス
+// Username / Password: 設定したときだけ PLAIN 認証を使います（TLS で接続できないサーバには送りません）
+type Mailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+	// send は送信に使う関数です（テストで差し替えます）
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer は addr の SMTP サーバを使う Mailer を作成します
+func NewMailer(addr, from, username, password string) *Mailer {
+	return &Mailer{Addr: addr, From: from, Username: username, Password: password, send: smtp.SendMail}
+}
+
+// Send はレポートを to へ送ります。CSV のレポートは添付ファイルにします
+func (m *Mailer) Send(to string, report Report) error {
+	msg, err := m.message(to, report)
+	if err != nil {
+		return err
+	}
+	return m.deliver(to, msg)
+}
+
+// SendText は件名 subject と本文 body だけのメールを to へ送ります（メールアドレスの確認など、レポート以外のメールに使います）
+func (m *Mailer) SendText(to, subject, body string) error {
+	var buf bytes.Buffer
+	m.writeHeader(&buf, to, subject, time.Now())
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64(&buf, body)
+	return m.deliver(to, buf.Bytes())
+}
+
+// deliver は組み立てたメール msg を SMTP サーバから to へ送ります
+func (m *Mailer) deliver(to string, msg []byte) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", m.Addr, err)
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return m.send(m.Addr, auth, m.From, []string{to}, msg)
+}
+
+// writeHeader は From・To・Subject・Date と MIME-Version のヘッダを書き込みます
+func (m *Mailer) writeHeader(buf *bytes.Buffer, to, subject string, date time.Time) {
+	fmt.Fprintf(buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(buf, "To: %s\r\n", to)
+	// 長い件名は複数の encoded-word になるため、1行が長くなりすぎないように折り返します
+	fmt.Fprintf(buf, "Subject: %s\r\n", strings.ReplaceAll(mime.BEncoding.Encode("utf-8", subject), "?= =?", "?=\r\n =?"))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+}
+
+// message は report の MIME 形式のメールを組み立てます
+func (m *Mailer) message(to string, report Report) ([]byte, error) {
+	var buf bytes.Buffer
+	m.writeHeader(&buf, to, report.Subject(), report.GeneratedAt)
+
+	if !strings.HasPrefix(report.ContentType, "text/csv") {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, report.Content)
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+	body, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(body, report.Subject()+"のレポートを添付します。\n")
+	attachment, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {report.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": report.Filename})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(attachment, report.Content)
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 は s を76文字ごとに改行した base64 で書き込みます
+func writeBase64(w io.Writer, s string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
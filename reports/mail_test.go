@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMailerSend(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotAuth smtp.Auth
+	var gotMsg []byte
+	mailer := NewMailer("smtp.example.com:587", "todo@example.com", "user", "secret")
+	mailer.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotMsg = addr, auth, from, msg
+		return nil
+	}
+	from := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		Kind: KindCompletedCSV, From: from, To: from.AddDate(0, 0, 7), GeneratedAt: from.AddDate(0, 0, 7),
+		Filename: "todo-completed-20250303.csv", ContentType: "text/csv; charset=utf-8", Content: "id,title\n1,Shipped\n",
+	}
+
+	if err := mailer.Send("alice@example.com", report); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	msg := string(gotMsg)
+	if gotAddr != "smtp.example.com:587" || gotFrom != "todo@example.com" || gotAuth == nil {
+		t.Errorf("unexpected envelope %s %s %v", gotAddr, gotFrom, gotAuth)
+	}
+	for _, want := range []string{
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?b?",
+		"Content-Type: multipart/mixed; boundary=",
+		`Content-Disposition: attachment; filename=todo-completed-20250303.csv`,
+		"aWQsdGl0bGUKMSxTaGlwcGVkCg==",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected the message to contain %q:\n%s", want, msg)
+		}
+	}
+
+	report.ContentType = "text/plain; charset=utf-8"
+	report.Content = strings.Repeat("放置", 40)
+	mailer.Send("alice@example.com", report)
+	if msg := string(gotMsg); strings.Contains(msg, "multipart") || !strings.Contains(msg, "Content-Type: text/plain; charset=utf-8\r\n") {
+		t.Errorf("expected a plain text message:\n%s", msg)
+	}
+	for _, line := range strings.Split(string(gotMsg), "\r\n") {
+		if len(line) > 78 && !strings.HasPrefix(line, "Subject: ") {
+			t.Errorf("expected lines to be wrapped, got %d characters", len(line))
+		}
+	}
+
+	mailer.Addr = "no-port"
+	if err := mailer.Send("alice@example.com", report); err == nil {
+		t.Error("expected an invalid SMTP address to be rejected")
+	}
+}
+
+func TestMailerSendText(t *testing.T) {
+	var gotTo []string
+	var gotMsg []byte
+	mailer := NewMailer("smtp.example.com:587", "todo@example.com", "", "")
+	mailer.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotTo, gotMsg = to, msg
+		return nil
+	}
+
+	if err := mailer.SendText("alice@example.com", "メールアドレスの確認", "https://todo.example.com/verify\n"); err != nil {
+		t.Fatalf("SendText failed: %v", err)
+	}
+	msg := string(gotMsg)
+	if len(gotTo) != 1 || gotTo[0] != "alice@example.com" {
+		t.Errorf("unexpected recipients %v", gotTo)
+	}
+	for _, want := range []string{
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?b?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"aHR0cHM6Ly90b2RvLmV4YW1wbGUuY29tL3ZlcmlmeQo=",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected the message to contain %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package reports は完了したタスクの CSV や放置されているタスクのまとめを定期的に作り、Webhook やメールで届けます
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"todo-app/analytics"
+	"todo-app/models"
+)
+
+// Kind はレポートの種類です
+type Kind string
+
+const (
+	// KindCompletedCSV は期間内に完了したタスクの CSV です
+	KindCompletedCSV Kind = "completed_csv"
+	// KindStaleSummary は staleAfter 以上変更されていない未完了のタスクのまとめです
+	KindStaleSummary Kind = "stale_summary"
+)
+
+// Frequency はレポートを作る間隔です
+type Frequency string
+
+const (
+	// FrequencyWeekly は毎週月曜日の0時に、前の週（月曜日から日曜日）のレポートを作ります
+	FrequencyWeekly Frequency = "weekly"
+	// FrequencyMonthly は毎月1日の0時に、前の月のレポートを作ります
+	FrequencyMonthly Frequency = "monthly"
+)
+
+// staleAfter は放置されているとみなす期間です
+const staleAfter = 30 * 24 * time.Hour
+
+// Schedule は1件の定期的なレポートの設定です
+// Frequency: 省略時は完了したタスクの CSV なら毎週、放置されているタスクのまとめなら毎月
+// TimeZone: 週や月の区切りに使う IANA のタイムゾーン（省略時はサーバのタイムゾーン）
+// Webhook / Email: 届け先。どちらか一方を指定します
+// NextRun: 次にレポートを作る日時
+// LastRun / LastError: 最後にレポートを作った日時と、届けられなかったときのエラー
+type Schedule struct {
+	ID        int        `json:"id"`
+	Report    Kind       `json:"report"`
+	Frequency Frequency  `json:"frequency"`
+	TimeZone  string     `json:"timezone"`
+	Webhook   string     `json:"webhook,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	NextRun   time.Time  `json:"next_run"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// normalize は省略した項目を補い、設定を検証します。誤りがあれば ErrValidation を返します
+func (s *Schedule) normalize() error {
+	switch s.Report {
+	case KindCompletedCSV:
+		if s.Frequency == "" {
+			s.Frequency = FrequencyWeekly
+		}
+	case KindStaleSummary:
+		if s.Frequency == "" {
+			s.Frequency = FrequencyMonthly
+		}
+	default:
+		return fmt.Errorf("%w: unknown report %q (completed_csv or stale_summary)", models.ErrValidation, s.Report)
+	}
+	if s.Frequency != FrequencyWeekly && s.Frequency != FrequencyMonthly {
+		return fmt.Errorf("%w: unknown frequency %q (weekly or monthly)", models.ErrValidation, s.Frequency)
+	}
+
+	loc := time.Local
+	if s.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.TimeZone); err != nil {
+			return fmt.Errorf("%w: unknown time zone %q", models.ErrValidation, s.TimeZone)
+		}
+	}
+	s.TimeZone = loc.String()
+
+	if (s.Webhook == "") == (s.Email == "") {
+		return fmt.Errorf("%w: specify either webhook or email", models.ErrValidation)
+	}
+	if s.Webhook != "" {
+		parsed, err := url.Parse(s.Webhook)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: webhook must be an absolute http(s) URL", models.ErrValidation)
+		}
+	}
+	if s.Email != "" {
+		address, err := mail.ParseAddress(s.Email)
+		if err != nil {
+			return fmt.Errorf("%w: invalid email address %q", models.ErrValidation, s.Email)
+		}
+		s.Email = address.Address
+	}
+	return nil
+}
+
+// location は TimeZone のタイムゾーンを返します。normalize で確認済みのため、読み込めなければサーバのタイムゾーンにします
+func (s Schedule) location() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// periodStart は t を含む週（月曜日始まり）または月の最初の日の0時を返します
+func (s Schedule) periodStart(t time.Time) time.Time {
+	t = t.In(s.location())
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if s.Frequency == FrequencyMonthly {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// shift は区切りの日時 t から n 回分の週または月だけずらした日時を返します
+func (s Schedule) shift(t time.Time, n int) time.Time {
+	if s.Frequency == FrequencyMonthly {
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, 7*n)
+}
+
+// nextRunAfter は now より後の最初の区切り（次の週の月曜日または次の月の1日の0時）を返します
+func (s Schedule) nextRunAfter(now time.Time) time.Time {
+	return s.shift(s.periodStart(now), 1)
+}
+
+// Report は作成したレポートです
+// From / To: 対象の期間（To の日時は含みません）
+type Report struct {
+	Kind        Kind      `json:"report"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Content     string    `json:"content"`
+}
+
+// Subject はメールの件名などに使うレポートの題名を返します
+func (r Report) Subject() string {
+	to := r.To.AddDate(0, 0, -1).Format("2006-01-02")
+	if r.Kind == KindStaleSummary {
+		return fmt.Sprintf("放置されているタスク（%s 時点）", to)
+	}
+	return fmt.Sprintf("完了したタスク（%s 〜 %s）", r.From.Format("2006-01-02"), to)
+}
+
+// Generate は from から to までの期間の kind のレポートを tasks から作成します
+func Generate(kind Kind, tasks []models.Task, from, to, now time.Time) (Report, error) {
+	report := Report{Kind: kind, From: from, To: to, GeneratedAt: now}
+	switch kind {
+	case KindCompletedCSV:
+		content, err := completedCSV(tasks, from, to)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Filename = "todo-completed-" + from.Format("20060102") + ".csv"
+		report.ContentType = "text/csv; charset=utf-8"
+		report.Content = content
+	case KindStaleSummary:
+		report.Filename = "todo-stale-" + to.AddDate(0, 0, -1).Format("20060102") + ".txt"
+		report.ContentType = "text/plain; charset=utf-8"
+		report.Content = staleSummary(analytics.FindStale(tasks, now, staleAfter))
+	default:
+		return Report{}, fmt.Errorf("%w: unknown report %q", models.ErrValidation, kind)
+	}
+	return report, nil
+}
+
+// completedCSV は from から to までに完了したタスクを、完了した日時の順に CSV にします
+func completedCSV(tasks []models.Task, from, to time.Time) (string, error) {
+	var done []models.Task
+	for _, task := range tasks {
+		if task.Completed && task.CompletedAt != nil && !task.CompletedAt.Before(from) && task.CompletedAt.Before(to) {
+			done = append(done, task)
+		}
+	}
+	sort.SliceStable(done, func(i, j int) bool { return done[i].CompletedAt.Before(*done[j].CompletedAt) })
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "title", "completed_at", "priority", "estimate_minutes", "tracked_minutes"})
+	for _, task := range done {
+		w.Write([]string{
+			strconv.Itoa(task.ID),
+			task.Title,
+			task.CompletedAt.In(from.Location()).Format(time.RFC3339),
+			string(task.Priority),
+			strconv.Itoa(task.EstimateMinutes),
+			strconv.FormatInt(task.TrackedSeconds/60, 10),
+		})
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// staleSummary は放置されているタスクを1行ずつ並べた文章にします
+func staleSummary(stale []analytics.StaleTask) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d日以上変更されていない未完了のタスクが %d 件あります。\n", int(staleAfter/(24*time.Hour)), len(stale))
+	for _, item := range stale {
+		fmt.Fprintf(&buf, "- #%d %s（%d日）\n", item.Task.ID, item.Task.Title, item.AgeDays)
+	}
+	return buf.String()
+}
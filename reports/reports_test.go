@@ -0,0 +1,114 @@
+package reports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func at(year int, month time.Month, day, hour int) *time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestScheduleNormalize(t *testing.T) {
+	weekly := Schedule{Report: KindCompletedCSV, Webhook: "https://example.com/hook", TimeZone: "Asia/Tokyo"}
+	if err := weekly.normalize(); err != nil || weekly.Frequency != FrequencyWeekly || weekly.TimeZone != "Asia/Tokyo" {
+		t.Errorf("unexpected schedule %+v, %v", weekly, err)
+	}
+	monthly := Schedule{Report: KindStaleSummary, Email: "Alice <alice@example.com>"}
+	if err := monthly.normalize(); err != nil || monthly.Frequency != FrequencyMonthly || monthly.Email != "alice@example.com" || monthly.TimeZone != "Local" {
+		t.Errorf("unexpected schedule %+v, %v", monthly, err)
+	}
+
+	for _, invalid := range []Schedule{
+		{Report: "burndown", Webhook: "https://example.com"},
+		{Report: KindCompletedCSV, Frequency: "daily", Webhook: "https://example.com"},
+		{Report: KindCompletedCSV, TimeZone: "Mars/Olympus", Webhook: "https://example.com"},
+		{Report: KindCompletedCSV},
+		{Report: KindCompletedCSV, Webhook: "https://example.com", Email: "alice@example.com"},
+		{Report: KindCompletedCSV, Webhook: "ftp://example.com"},
+		{Report: KindCompletedCSV, Email: "alice\r\nBcc: eve@example.com"},
+	} {
+		if err := invalid.normalize(); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("expected ErrValidation for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestSchedulePeriods(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	// UTC の 2025-03-09（日曜日）16:00 は東京では 3月10日（月曜日）です
+	now := time.Date(2025, 3, 9, 16, 0, 0, 0, time.UTC)
+
+	weekly := Schedule{Frequency: FrequencyWeekly, TimeZone: "Asia/Tokyo"}
+	if got := weekly.periodStart(now); !got.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("unexpected week start %v", got)
+	}
+	if got := weekly.nextRunAfter(now); !got.Equal(time.Date(2025, 3, 17, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("unexpected next weekly run %v", got)
+	}
+
+	monthly := Schedule{Frequency: FrequencyMonthly, TimeZone: "UTC"}
+	if got := monthly.nextRunAfter(now); !got.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next monthly run %v", got)
+	}
+	if got := monthly.shift(monthly.periodStart(now), -1); !got.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected previous month %v", got)
+	}
+	if got := (Schedule{TimeZone: "Mars/Olympus"}).location(); got != time.Local {
+		t.Errorf("expected the server time zone for an unknown name, got %v", got)
+	}
+}
+
+func TestGenerateCompletedCSV(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Title: "Later", Completed: true, CompletedAt: at(2025, 3, 5, 9), Priority: models.PriorityHigh, EstimateMinutes: 30, TrackedSeconds: 1800},
+		{ID: 2, Title: "Earlier, with comma", Completed: true, CompletedAt: at(2025, 3, 3, 9)},
+		{ID: 3, Title: "Last week", Completed: true, CompletedAt: at(2025, 3, 2, 23)},
+		{ID: 4, Title: "Reopened", CompletedAt: at(2025, 3, 4, 9)},
+		{ID: 5, Title: "Open"},
+	}
+	from := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	report, err := Generate(KindCompletedCSV, tasks, from, from.AddDate(0, 0, 7), from.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := "id,title,completed_at,priority,estimate_minutes,tracked_minutes\n" +
+		"2,\"Earlier, with comma\",2025-03-03T09:00:00Z,,0,0\n" +
+		"1,Later,2025-03-05T09:00:00Z,high,30,30\n"
+	if report.Content != want {
+		t.Errorf("unexpected CSV:\n%s", report.Content)
+	}
+	if report.Filename != "todo-completed-20250303.csv" || !strings.HasPrefix(report.ContentType, "text/csv") {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if got := report.Subject(); got != "完了したタスク（2025-03-03 〜 2025-03-09）" {
+		t.Errorf("unexpected subject %q", got)
+	}
+}
+
+func TestGenerateStaleSummary(t *testing.T) {
+	now := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 1, Title: "Forgotten", CreatedAt: at(2025, 1, 1, 0)},
+		{ID: 2, Title: "Fresh", CreatedAt: at(2025, 3, 30, 0)},
+	}
+	report, err := Generate(KindStaleSummary, tasks, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), now, now)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if report.Content != "30日以上変更されていない未完了のタスクが 1 件あります。\n- #1 Forgotten（90日）\n" {
+		t.Errorf("unexpected summary:\n%s", report.Content)
+	}
+	if report.Filename != "todo-stale-20250331.txt" || report.Subject() != "放置されているタスク（2025-03-31 時点）" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if _, err := Generate("burndown", nil, now, now, now); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an unknown report, got %v", err)
+	}
+}
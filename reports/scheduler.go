@@ -0,0 +1,201 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// ErrScheduleNotFound は指定した ID のスケジュールがないときのエラーです
+var ErrScheduleNotFound = errors.New("report schedule not found")
+
+// ErrEmailDisabled は SMTP サーバを設定していないのにメールで届けようとしたときのエラーです（ErrValidation として扱われます）
+var ErrEmailDisabled = fmt.Errorf("%w: email delivery is not configured", models.ErrValidation)
+
+// Scheduler はレポートのスケジュールを保持し、時刻が来たものを作って届けます
+type Scheduler struct {
+	mutex      sync.Mutex
+	schedules  []Schedule
+	nextID     int
+	mailer     *Mailer
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewScheduler は空の Scheduler を作成します
+// mailer が nil ならメールでは届けられません。httpClient が nil の場合はタイムアウト10秒のクライアントを使います
+func NewScheduler(mailer *Mailer, httpClient *http.Client) *Scheduler {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Scheduler{nextID: 1, mailer: mailer, httpClient: httpClient, now: time.Now}
+}
+
+// Add はスケジュールを検証して登録し、ID と次にレポートを作る日時を設定したものを返します
+func (s *Scheduler) Add(schedule Schedule) (Schedule, error) {
+	if err := schedule.normalize(); err != nil {
+		return Schedule{}, err
+	}
+	if schedule.Email != "" && s.mailer == nil {
+		return Schedule{}, ErrEmailDisabled
+	}
+	schedule.NextRun = schedule.nextRunAfter(s.now())
+	schedule.LastRun = nil
+	schedule.LastError = ""
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	schedule.ID = s.nextID
+	s.nextID++
+	s.schedules = append(s.schedules, schedule)
+	return schedule, nil
+}
+
+// List は登録済みのスケジュールのコピーを返します
+func (s *Scheduler) List() []Schedule {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Schedule{}, s.schedules...)
+}
+
+// Delete は id のスケジュールを削除します。なければ ErrScheduleNotFound を返します
+func (s *Scheduler) Delete(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, schedule := range s.schedules {
+		if schedule.ID == id {
+			s.schedules = append(s.schedules[:i], s.schedules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: id %d", ErrScheduleNotFound, id)
+}
+
+// RunNow は id のスケジュールの直前の期間（先週または先月）のレポートをすぐに作って届け、結果を記録したスケジュールを返します
+// 次にレポートを作る日時は変えません。届けられなかったときもエラーは LastError に記録します
+func (s *Scheduler) RunNow(ctx context.Context, id int, store models.TaskStore) (Schedule, error) {
+	s.mutex.Lock()
+	var schedule Schedule
+	found := false
+	for _, candidate := range s.schedules {
+		if candidate.ID == id {
+			schedule, found = candidate, true
+		}
+	}
+	s.mutex.Unlock()
+	if !found {
+		return Schedule{}, fmt.Errorf("%w: id %d", ErrScheduleNotFound, id)
+	}
+
+	now := s.now()
+	err := s.deliver(ctx, schedule, store, schedule.periodStart(now), now)
+	return s.record(id, now, schedule.NextRun, err), nil
+}
+
+// RunDue は次にレポートを作る日時を過ぎたスケジュールのレポートを作って届け、作った数を返します
+// サーバが止まっていて何回分か過ぎていた場合も、届けるのは直前の期間の1回分だけです
+func (s *Scheduler) RunDue(ctx context.Context, store models.TaskStore) int {
+	now := s.now()
+	var due []Schedule
+	s.mutex.Lock()
+	for _, schedule := range s.schedules {
+		if !schedule.NextRun.After(now) {
+			due = append(due, schedule)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, schedule := range due {
+		err := s.deliver(ctx, schedule, store, schedule.periodStart(now), now)
+		if err != nil {
+			slog.Error("report schedule failed", "schedule_id", schedule.ID, "err", err)
+		}
+		s.record(schedule.ID, now, schedule.nextRunAfter(now), err)
+	}
+	return len(due)
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに RunDue を呼び出します
+func (s *Scheduler) Run(ctx context.Context, store models.TaskStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := s.RunDue(ctx, store); n > 0 {
+				slog.Info("report schedules run", "count", n)
+			}
+		}
+	}
+}
+
+// record は id のスケジュールに実行した結果を記録し、記録したものを返します（削除されていれば何もしません）
+func (s *Scheduler) record(id int, ran, next time.Time, err error) Schedule {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.schedules {
+		if s.schedules[i].ID != id {
+			continue
+		}
+		s.schedules[i].LastRun = &ran
+		s.schedules[i].NextRun = next
+		s.schedules[i].LastError = ""
+		if err != nil {
+			s.schedules[i].LastError = err.Error()
+		}
+		return s.schedules[i]
+	}
+	return Schedule{}
+}
+
+// deliver は end で終わる1回分の期間のレポートを作り、スケジュールの届け先へ送ります
+func (s *Scheduler) deliver(ctx context.Context, schedule Schedule, store models.TaskStore, end, now time.Time) error {
+	report, err := Generate(schedule.Report, store.GetTasks(ctx), schedule.shift(end, -1), end, now)
+	if err != nil {
+		return err
+	}
+	if schedule.Email != "" {
+		if s.mailer == nil {
+			return ErrEmailDisabled
+		}
+		return s.mailer.Send(schedule.Email, report)
+	}
+	return s.post(ctx, schedule, report)
+}
+
+// post はレポートを {"schedule_id": 1, "report": "completed_csv", "content": "...", ...} の JSON で Webhook へ送ります
+func (s *Scheduler) post(ctx context.Context, schedule Schedule, report Report) error {
+	body, err := json.Marshal(struct {
+		ScheduleID int    `json:"schedule_id"`
+		Subject    string `json:"subject"`
+		Report
+	}{schedule.ID, report.Subject(), report})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, schedule.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// newTestScheduler は時刻を now に固定した Scheduler を作成します
+func newTestScheduler(mailer *Mailer, now *time.Time) *Scheduler {
+	s := NewScheduler(mailer, nil)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestSchedulerAddListDelete(t *testing.T) {
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(nil, &now)
+
+	added, err := s.Add(Schedule{Report: KindCompletedCSV, Webhook: "https://example.com/hook", TimeZone: "UTC", LastError: "ignored"})
+	if err != nil || added.ID != 1 || !added.NextRun.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) || added.LastError != "" {
+		t.Fatalf("unexpected schedule %+v, %v", added, err)
+	}
+	if _, err := s.Add(Schedule{Report: KindStaleSummary, Email: "alice@example.com"}); !errors.Is(err, ErrEmailDisabled) || !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected email to be rejected without a mailer, got %v", err)
+	}
+	if _, err := s.Add(Schedule{Report: "unknown"}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation, got %v", err)
+	}
+	if got := s.List(); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("unexpected list %+v", got)
+	}
+
+	if err := s.Delete(1); err != nil || len(s.List()) != 0 {
+		t.Errorf("expected the schedule to be deleted, got %v", err)
+	}
+	if err := s.Delete(1); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound, got %v", err)
+	}
+}
+
+func TestSchedulerRunDueWebhook(t *testing.T) {
+	var received []map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	store := models.NewTodoAppFromTasks([]models.Task{
+		{ID: 1, Title: "Shipped", Completed: true, CompletedAt: at(2025, 3, 6, 9)},
+	})
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(nil, &now)
+	s.Add(Schedule{Report: KindCompletedCSV, Webhook: server.URL, TimeZone: "UTC"})
+	ctx := context.Background()
+
+	if n := s.RunDue(ctx, store); n != 0 || len(received) != 0 {
+		t.Fatalf("expected nothing to run before the next run, got %d", n)
+	}
+
+	// 2週間止まっていても、届けるのは直前の週の1回分です
+	now = time.Date(2025, 3, 18, 8, 0, 0, 0, time.UTC)
+	if n := s.RunDue(ctx, store); n != 1 || len(received) != 1 {
+		t.Fatalf("expected one report, got %d runs and %d requests", n, len(received))
+	}
+	body := received[0]
+	if body["report"] != "completed_csv" || body["schedule_id"] != float64(1) || body["from"] != "2025-03-10T00:00:00Z" || strings.Contains(body["content"].(string), "Shipped") {
+		t.Errorf("unexpected payload %v", body)
+	}
+	schedule := s.List()[0]
+	if !schedule.NextRun.Equal(time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)) || schedule.LastRun == nil || schedule.LastError != "" {
+		t.Errorf("unexpected schedule after the run %+v", schedule)
+	}
+
+	// 届けられなかったときはエラーを記録し、次の週にまた作ります
+	status = http.StatusInternalServerError
+	now = time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	s.RunDue(ctx, store)
+	if schedule := s.List()[0]; !strings.Contains(schedule.LastError, "500") || !schedule.NextRun.Equal(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the failure to be recorded, got %+v", schedule)
+	}
+}
+
+func TestSchedulerRunNowEmail(t *testing.T) {
+	var sent []string
+	mailer := NewMailer("smtp.example.com:587", "todo@example.com", "", "")
+	mailer.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, strings.Join(to, ","))
+		return nil
+	}
+	store := models.NewTodoAppFromTasks([]models.Task{
+		{ID: 1, Title: "Shipped", Completed: true, CompletedAt: at(2025, 2, 20, 9)},
+	})
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(mailer, &now)
+	added, _ := s.Add(Schedule{Report: KindCompletedCSV, Frequency: FrequencyMonthly, Email: "alice@example.com", TimeZone: "UTC"})
+
+	got, err := s.RunNow(context.Background(), added.ID, store)
+	if err != nil || len(sent) != 1 || sent[0] != "alice@example.com" {
+		t.Fatalf("expected the report to be mailed, got %v, %v", sent, err)
+	}
+	if got.LastRun == nil || !got.LastRun.Equal(now) || !got.NextRun.Equal(added.NextRun) {
+		t.Errorf("expected the run to be recorded without moving the next run, got %+v", got)
+	}
+
+	if _, err := s.RunNow(context.Background(), 99, store); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound, got %v", err)
+	}
+
+	// メールの設定がなくなったスケジュールは、届けられなかったことを記録します
+	s.mailer = nil
+	if got, _ := s.RunNow(context.Background(), added.ID, store); got.LastError == "" {
+		t.Errorf("expected the failure to be recorded, got %+v", got)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	requests := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(nil, &now)
+	s.Add(Schedule{Report: KindStaleSummary, Webhook: server.URL, TimeZone: "UTC"})
+	now = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { s.Run(ctx, models.NewTodoApp(), time.Millisecond); close(done) }()
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Run to deliver the due report")
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+// ruleContextKey はルールが行った変更のコンテキストに付ける印です
+type ruleContextKey struct{}
+
+// Engine はイベントバスの購読者として、当てはまるルールのアクションをタスクに適用します
+// ルールが行った変更のイベントではルールを実行しないため、ルール同士が互いを呼び続けることはありません
+type Engine struct {
+	rules *Store
+	tasks models.TaskStore
+	lists *lists.Store
+	now   func() time.Time
+
+	// completed: タスクごとの直前の完了状態（task.completed を未完了から完了への変化だけで起こすために使います）
+	mutex     sync.Mutex
+	completed map[int]bool
+}
+
+// NewEngine は tasks の変更に rules を適用する Engine を作成します
+// taskLists は move_to_list の移動先があるかを確かめるリストの登録先です（nil なら確かめません）
+// 作成時点のタスクの完了状態を読み込んでおきます
+func NewEngine(rules *Store, tasks models.TaskStore, taskLists *lists.Store) *Engine {
+	e := &Engine{rules: rules, tasks: tasks, lists: taskLists, now: time.Now, completed: make(map[int]bool)}
+	for _, task := range tasks.GetTasks(context.Background()) {
+		e.completed[task.ID] = task.Completed
+	}
+	return e
+}
+
+// HandleEvent はイベントのきっかけに当てはまるルールを登録順に実行します
+func (e *Engine) HandleEvent(event models.Event) {
+	triggers := e.triggers(event)
+	ctx := event.Context()
+	if ctx.Value(ruleContextKey{}) != nil || len(triggers) == 0 {
+		return
+	}
+
+	ctx = context.WithValue(ctx, ruleContextKey{}, true)
+	for _, rule := range e.rules.List() {
+		if !hasTrigger(triggers, rule.Trigger) || !rule.matches(event.Task) {
+			continue
+		}
+		for _, action := range rule.Actions {
+			if err := e.apply(ctx, event.Task.ID, action); err != nil {
+				slog.WarnContext(ctx, "rule failed", "rule_id", rule.ID, "action", action.Type, "task_id", event.Task.ID, "err", err)
+				break
+			}
+		}
+	}
+}
+
+// triggers はイベントから起こるきっかけを返し、タスクの完了状態を記録します
+func (e *Engine) triggers(event models.Event) []Trigger {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	id := event.Task.ID
+	switch event.Type {
+	case models.EventTaskCreated:
+		e.completed[id] = event.Task.Completed
+		return []Trigger{TriggerCreated}
+	case models.EventTaskUpdated:
+		wasCompleted := e.completed[id]
+		e.completed[id] = event.Task.Completed
+		if event.Task.Completed && !wasCompleted {
+			return []Trigger{TriggerUpdated, TriggerCompleted}
+		}
+		return []Trigger{TriggerUpdated}
+	case models.EventTaskDeleted:
+		delete(e.completed, id)
+	}
+	return nil
+}
+
+func hasTrigger(triggers []Trigger, trigger Trigger) bool {
+	for _, t := range triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// apply は1件のアクションをタスクに適用します。値は Rule.Validate で検証済みです
+func (e *Engine) apply(ctx context.Context, id int, action Action) error {
+	switch action.Type {
+	case "set_priority":
+		return e.tasks.SetPriority(ctx, id, models.Priority(action.Value))
+	case "set_due_in_days":
+		days, _ := strconv.Atoi(action.Value)
+		due := e.now().AddDate(0, 0, days)
+		return e.tasks.SetDueDate(ctx, id, &due)
+	case "set_estimate":
+		minutes, _ := strconv.Atoi(action.Value)
+		return e.tasks.SetEstimate(ctx, id, minutes)
+	case "add_tag":
+		return e.tasks.UpdateTask(ctx, id, models.TaskUpdate{AddTags: []string{action.Value}})
+	case "remove_tag":
+		return e.tasks.UpdateTask(ctx, id, models.TaskUpdate{RemoveTags: []string{action.Value}})
+	case "move_to_list":
+		listID, _ := strconv.Atoi(action.Value)
+		// ルールを登録した後でリストが削除されていれば、タスクを存在しないリストに入れないようにします
+		if listID != 0 && e.lists != nil {
+			if _, err := e.lists.Get(listID); err != nil {
+				return err
+			}
+		}
+		return e.tasks.UpdateTask(ctx, id, models.TaskUpdate{ListID: &listID})
+	case "assign":
+		return e.tasks.ClaimTask(ctx, id, action.Value)
+	}
+	return nil
+}
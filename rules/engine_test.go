@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+	"todo-app/lists"
+	"todo-app/models"
+)
+
+func newTestEngine(t *testing.T, tasks *models.TodoApp, rules ...Rule) *Engine {
+	return newTestEngineWithLists(t, tasks, nil, rules...)
+}
+
+func newTestEngineWithLists(t *testing.T, tasks *models.TodoApp, taskLists *lists.Store, rules ...Rule) *Engine {
+	store := NewStore()
+	for _, rule := range rules {
+		if _, err := store.Add(rule); err != nil {
+			t.Fatalf("Failed to add rule: %v", err)
+		}
+	}
+	engine := NewEngine(store, tasks, taskLists)
+	engine.now = func() time.Time { return time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(tasks.Subscribe(engine.HandleEvent))
+	return engine
+}
+
+func findTask(t *testing.T, tasks *models.TodoApp, id int) models.Task {
+	for _, task := range tasks.GetTasks(context.Background()) {
+		if task.ID == id {
+			return task
+		}
+	}
+	t.Fatalf("Task %d not found", id)
+	return models.Task{}
+}
+
+func TestEngineCreated(t *testing.T) {
+	ctx := context.Background()
+	tasks := models.NewTodoApp()
+	newTestEngine(t, tasks, Rule{
+		Trigger:    TriggerCreated,
+		Conditions: []Condition{{Field: "title", Op: "contains", Value: "bug"}},
+		Actions:    []Action{{Type: "set_priority", Value: "high"}, {Type: "set_due_in_days", Value: "2"}},
+	})
+
+	bug, _ := tasks.AddTask(ctx, "Bug: login fails")
+	other, _ := tasks.AddTask(ctx, "Write docs")
+
+	got := findTask(t, tasks, bug.ID)
+	if got.Priority != models.PriorityHigh || got.DueDate == nil || !got.DueDate.Equal(time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the rule to apply to the bug, got %+v", got)
+	}
+	if got := findTask(t, tasks, other.ID); got.Priority != "" || got.DueDate != nil {
+		t.Errorf("Expected the rule to skip other tasks, got %+v", got)
+	}
+}
+
+func TestEngineCompleted(t *testing.T) {
+	ctx := context.Background()
+	tasks := models.NewTodoAppFromTasks([]models.Task{{ID: 1, Title: "Already done", Completed: true}})
+	newTestEngine(t, tasks, Rule{Trigger: TriggerCompleted, Actions: []Action{{Type: "set_priority", Value: "low"}}})
+
+	// 完了済みのタスクを変更しても完了のきっかけにはなりません
+	tasks.SetEstimate(ctx, 1, 10)
+	if got := findTask(t, tasks, 1); got.Priority != "" {
+		t.Errorf("Expected no action for an already completed task, got %+v", got)
+	}
+
+	task, _ := tasks.AddTask(ctx, "Finish me")
+	tasks.SetEstimate(ctx, task.ID, 30)
+	if got := findTask(t, tasks, task.ID); got.Priority != "" {
+		t.Errorf("Expected no action before completion, got %+v", got)
+	}
+	tasks.ToggleTask(ctx, task.ID)
+	if got := findTask(t, tasks, task.ID); got.Priority != models.PriorityLow {
+		t.Errorf("Expected the rule to apply on completion, got %+v", got)
+	}
+}
+
+func TestEngineDoesNotLoop(t *testing.T) {
+	ctx := context.Background()
+	tasks := models.NewTodoApp()
+	newTestEngine(t, tasks, Rule{Trigger: TriggerUpdated, Actions: []Action{{Type: "set_estimate", Value: "15"}}})
+
+	var events int
+	defer tasks.Subscribe(func(models.Event) { events++ })()
+
+	task, _ := tasks.AddTask(ctx, "Task")
+	tasks.SetPriority(ctx, task.ID, models.PriorityMedium)
+
+	// 作成・優先度の変更・ルールによる見積もりの変更の3件だけです
+	if got := findTask(t, tasks, task.ID); got.EstimateMinutes != 15 || events != 3 {
+		t.Errorf("Expected the rule to run once, got %+v after %d events", got, events)
+	}
+}
+
+func TestEngineActionError(t *testing.T) {
+	tasks := models.NewTodoApp()
+	engine := newTestEngine(t, tasks, Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "set_estimate", Value: "5"}}})
+
+	// 存在しないタスクへのアクションはログに残して続けます
+	engine.HandleEvent(models.NewEvent(context.Background(), 1, models.EventTaskCreated, models.Task{ID: 42}))
+	engine.HandleEvent(models.NewEvent(context.Background(), 2, models.EventTaskDeleted, models.Task{ID: 42}))
+}
+
+func TestEngineTagsListsAndAssign(t *testing.T) {
+	ctx := context.Background()
+	taskLists, _ := lists.NewStore("")
+	archive, _ := taskLists.Create("Archive")
+	gone, _ := taskLists.Create("Gone")
+	tasks := models.NewTodoApp()
+	newTestEngineWithLists(t, tasks, taskLists,
+		Rule{
+			Trigger:    TriggerCreated,
+			Conditions: []Condition{{Field: "tag", Op: "has", Value: "bug"}},
+			Actions:    []Action{{Type: "set_priority", Value: "high"}, {Type: "assign", Value: "alice"}, {Type: "add_tag", Value: "triage"}},
+		},
+		Rule{
+			Trigger: TriggerCompleted,
+			Actions: []Action{{Type: "remove_tag", Value: "triage"}, {Type: "move_to_list", Value: strconv.Itoa(archive.ID)}},
+		},
+	)
+
+	tags := []string{"bug"}
+	bug, _ := tasks.AddTaskWith(ctx, "Login fails", models.TaskUpdate{Tags: &tags})
+	other, _ := tasks.AddTask(ctx, "Write docs")
+
+	got := findTask(t, tasks, bug.ID)
+	if got.Priority != models.PriorityHigh || got.ClaimedBy != "alice" || !got.HasTag("triage") {
+		t.Errorf("Expected the tagged task to be triaged, got %+v", got)
+	}
+	if got := findTask(t, tasks, other.ID); got.ClaimedBy != "" || len(got.Tags) != 0 {
+		t.Errorf("Expected the rule to skip untagged tasks, got %+v", got)
+	}
+
+	tasks.ToggleTask(ctx, bug.ID)
+	if got := findTask(t, tasks, bug.ID); got.ListID != archive.ID || got.HasTag("triage") || !got.HasTag("bug") {
+		t.Errorf("Expected the completed task to be archived, got %+v", got)
+	}
+
+	// 削除されたリストへは移しません
+	tasks = models.NewTodoApp()
+	newTestEngineWithLists(t, tasks, taskLists, Rule{Trigger: TriggerCompleted, Actions: []Action{{Type: "move_to_list", Value: strconv.Itoa(gone.ID)}}})
+	taskLists.Delete(gone.ID)
+	other, _ = tasks.AddTask(ctx, "Write docs")
+	tasks.ToggleTask(ctx, other.ID)
+	if got := findTask(t, tasks, other.ID); got.ListID != 0 {
+		t.Errorf("Expected no move to a deleted list, got %+v", got)
+	}
+}
@@ -0,0 +1,215 @@
+// Package rules は利用者が定義した自動化ルール（トリガー・条件・アクション）を、タスクの変更イベントに応じて実行します
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"todo-app/models"
+)
+
+// Trigger はルールを実行するきっかけです
+type Trigger string
+
+const (
+	TriggerCreated   Trigger = "task.created"
+	TriggerUpdated   Trigger = "task.updated"
+	TriggerCompleted Trigger = "task.completed"
+)
+
+// Condition はルールを実行する条件です
+// Field: title（contains / equals）、priority（equals / not_equals）、due_date（is_set / is_not_set）、tag（has / not_has）
+// Value: 比べる値（is_set / is_not_set では使いません。tag ではタグの名前を models.NormalizeTag でそろえて比べます）
+type Condition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value,omitempty"`
+}
+
+// maxAssignee は assign で指定できる名前の最大の長さ（文字数）です（models の担当する人の名前と同じです）
+const maxAssignee = 64
+
+// Action はルールが実行する操作です
+// set_priority: 優先度を Value にします（空文字で外します）
+// set_due_in_days: 期限を Value 日後にします
+// set_estimate: 見積もり時間を Value 分にします
+// add_tag / remove_tag: タグ Value を付ける・外します
+// move_to_list: タスクを ID が Value のリストへ移します（0 でどのリストからも外します）
+// assign: タスクを Value の人の担当にします（ほかの人が担当しているタスクや完了したタスクには失敗します）
+type Action struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Rule は1件の自動化ルールです。Trigger のイベントで Conditions がすべて当てはまると Actions を順に実行します
+type Rule struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Trigger    Trigger     `json:"trigger"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	Actions    []Action    `json:"actions"`
+}
+
+// Validate はルールの設定が正しいかを検証します
+func (r Rule) Validate() error {
+	switch r.Trigger {
+	case TriggerCreated, TriggerUpdated, TriggerCompleted:
+	default:
+		return fmt.Errorf("unknown trigger %q", r.Trigger)
+	}
+	for _, condition := range r.Conditions {
+		if err := condition.validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action is required")
+	}
+	for _, action := range r.Actions {
+		if err := action.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Condition) validate() error {
+	var ops []string
+	switch c.Field {
+	case "title":
+		ops = []string{"contains", "equals"}
+	case "priority":
+		ops = []string{"equals", "not_equals"}
+		if err := models.Priority(c.Value).Validate(); err != nil {
+			return err
+		}
+	case "due_date":
+		ops = []string{"is_set", "is_not_set"}
+	case "tag":
+		ops = []string{"has", "not_has"}
+		if _, err := models.NormalizeTag(c.Value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown condition field %q", c.Field)
+	}
+	for _, op := range ops {
+		if c.Op == op {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operator %q for %s (%s)", c.Op, c.Field, strings.Join(ops, ", "))
+}
+
+func (a Action) validate() error {
+	switch a.Type {
+	case "set_priority":
+		return models.Priority(a.Value).Validate()
+	case "add_tag", "remove_tag":
+		_, err := models.NormalizeTag(a.Value)
+		return err
+	case "assign":
+		if a.Value == "" || utf8.RuneCountInString(a.Value) > maxAssignee {
+			return fmt.Errorf("assign requires a name of 1 to %d characters", maxAssignee)
+		}
+		return nil
+	case "set_due_in_days", "set_estimate", "move_to_list":
+		if n, err := strconv.Atoi(a.Value); err != nil || n < 0 {
+			return fmt.Errorf("%s requires a non-negative number, got %q", a.Type, a.Value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+}
+
+// matches はタスクが条件に当てはまるかを返します
+func (c Condition) matches(task models.Task) bool {
+	switch c.Field + " " + c.Op {
+	case "title contains":
+		return strings.Contains(strings.ToLower(task.Title), strings.ToLower(c.Value))
+	case "title equals":
+		return task.Title == c.Value
+	case "priority equals":
+		return task.Priority == models.Priority(c.Value)
+	case "priority not_equals":
+		return task.Priority != models.Priority(c.Value)
+	case "due_date is_set":
+		return task.DueDate != nil
+	case "due_date is_not_set":
+		return task.DueDate == nil
+	case "tag has":
+		tag, _ := models.NormalizeTag(c.Value)
+		return task.HasTag(tag)
+	case "tag not_has":
+		tag, _ := models.NormalizeTag(c.Value)
+		return !task.HasTag(tag)
+	}
+	return false
+}
+
+// matches はタスクがルールのすべての条件に当てはまるかを返します
+func (r Rule) matches(task models.Task) bool {
+	for _, condition := range r.Conditions {
+		if !condition.matches(task) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store は登録されたルールを保持します
+type Store struct {
+	rules  []Rule
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewStore は空の Store を作成します
+func NewStore() *Store {
+	return &Store{rules: make([]Rule, 0), nextID: 1}
+}
+
+// Add はルールを検証して登録し、IDを振ったものを返します
+func (s *Store) Add(rule Rule) (Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rule.ID = s.nextID
+	s.nextID++
+	s.rules = append(s.rules, rule)
+	return rule, nil
+}
+
+// List は登録済みのルールのコピーを登録順に返します
+func (s *Store) List() []Rule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rules := make([]Rule, len(s.rules))
+	copy(rules, s.rules)
+	return rules
+}
+
+// Delete は指定IDのルールを削除します
+// 見つかったら true を、見つからなければ false を返します
+func (s *Store) Delete(id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, rule := range s.rules {
+		if rule.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
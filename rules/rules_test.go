@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"testing"
+	"todo-app/models"
+)
+
+func TestRuleValidate(t *testing.T) {
+	setHigh := []Action{{Type: "set_priority", Value: "high"}}
+	testCases := []struct {
+		name  string
+		rule  Rule
+		valid bool
+	}{
+		{"created", Rule{Trigger: TriggerCreated, Actions: setHigh}, true},
+		{"conditions", Rule{Trigger: TriggerCompleted, Conditions: []Condition{
+			{Field: "title", Op: "contains", Value: "bug"},
+			{Field: "priority", Op: "not_equals", Value: "low"},
+			{Field: "due_date", Op: "is_not_set"},
+			{Field: "tag", Op: "has", Value: "#Bug"},
+		}, Actions: []Action{{Type: "set_due_in_days", Value: "3"}, {Type: "set_estimate", Value: "30"}}}, true},
+		{"tags and lists", Rule{Trigger: TriggerCompleted, Actions: []Action{
+			{Type: "add_tag", Value: "done"}, {Type: "remove_tag", Value: "wip"},
+			{Type: "move_to_list", Value: "2"}, {Type: "assign", Value: "alice"},
+		}}, true},
+		{"bad trigger", Rule{Trigger: "task.exploded", Actions: setHigh}, false},
+		{"no actions", Rule{Trigger: TriggerCreated}, false},
+		{"bad field", Rule{Trigger: TriggerCreated, Conditions: []Condition{{Field: "list", Op: "equals"}}, Actions: setHigh}, false},
+		{"bad operator", Rule{Trigger: TriggerCreated, Conditions: []Condition{{Field: "title", Op: "is_set"}}, Actions: setHigh}, false},
+		{"bad tag condition", Rule{Trigger: TriggerCreated, Conditions: []Condition{{Field: "tag", Op: "has", Value: "two words"}}, Actions: setHigh}, false},
+		{"bad priority condition", Rule{Trigger: TriggerCreated, Conditions: []Condition{{Field: "priority", Op: "equals", Value: "urgent"}}, Actions: setHigh}, false},
+		{"bad action", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "delete", Value: "1"}}}, false},
+		{"bad list", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "move_to_list", Value: "Archive"}}}, false},
+		{"bad tag", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "add_tag"}}}, false},
+		{"bad assignee", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "assign"}}}, false},
+		{"bad priority", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "set_priority", Value: "urgent"}}}, false},
+		{"bad days", Rule{Trigger: TriggerCreated, Actions: []Action{{Type: "set_due_in_days", Value: "-1"}}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%v, got error %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestConditionMatches(t *testing.T) {
+	task := models.Task{Title: "Fix login BUG", Priority: models.PriorityHigh, Tags: []string{"bug"}}
+	testCases := []struct {
+		condition Condition
+		want      bool
+	}{
+		{Condition{Field: "title", Op: "contains", Value: "bug"}, true},
+		{Condition{Field: "title", Op: "equals", Value: "Fix login"}, false},
+		{Condition{Field: "priority", Op: "equals", Value: "high"}, true},
+		{Condition{Field: "priority", Op: "not_equals", Value: "high"}, false},
+		{Condition{Field: "due_date", Op: "is_set"}, false},
+		{Condition{Field: "due_date", Op: "is_not_set"}, true},
+		{Condition{Field: "tag", Op: "has", Value: "#BUG"}, true},
+		{Condition{Field: "tag", Op: "has", Value: "docs"}, false},
+		{Condition{Field: "tag", Op: "not_has", Value: "docs"}, true},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.condition.matches(task); got != tc.want {
+			t.Errorf("%+v: expected %v, got %v", tc.condition, tc.want, got)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	rule, err := store.Add(Rule{Name: "Bugs first", Trigger: TriggerCreated, Actions: []Action{{Type: "set_priority", Value: "high"}}})
+	if err != nil || rule.ID != 1 {
+		t.Fatalf("Unexpected rule: %+v (%v)", rule, err)
+	}
+	if _, err := store.Add(Rule{Trigger: "task.exploded"}); err == nil {
+		t.Error("Expected an invalid rule to be rejected")
+	}
+	if rules := store.List(); len(rules) != 1 || rules[0].Name != "Bugs first" {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+	if !store.Delete(1) || store.Delete(1) || len(store.List()) != 0 {
+		t.Error("Expected the rule to be deleted exactly once")
+	}
+}
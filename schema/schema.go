@@ -0,0 +1,343 @@
+// Package schema は JSON Schema（draft 2020-12 の一部）で JSON の値を検証します
+// 外部のライブラリを使わずに、API のリクエスト本文の検証に必要なキーワードだけに対応しています
+// type / properties / required / additionalProperties / items / minItems / maxItems / enum /
+// minLength / maxLength / pattern / format（date-time・uri）/ minimum / maximum
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Types は type キーワードの値です。JSON では "string" のような1つの型か ["string", "null"] のような配列で書きます
+type Types []string
+
+// UnmarshalJSON は1つの型と型の配列のどちらも受け付けます
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// MarshalJSON は型が1つなら文字列、複数なら配列で書き出します
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Schema は1つのスキーマです。省略したキーワードは検証しません
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Parse は JSON で書いたスキーマを読み込みます。未知の型や正規表現の誤りはここでエラーにします
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// MustParse は Parse と同じですが、誤りがあれば panic します。埋め込んだスキーマの読み込みに使います
+func MustParse(data []byte) *Schema {
+	s, err := Parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("schema: %v", err))
+	}
+	return s
+}
+
+// compile は型の名前を確認し、pattern を正規表現にします
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "integer", "number", "boolean", "null":
+		default:
+			return fmt.Errorf("%s: unknown type %q", fieldName(path), t)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %v", fieldName(path), err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if err := property.compile(join(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// FieldError は1つの項目の誤りです
+// Field: 誤りのある項目（"title" や "actions[0].type"。本文全体なら空）
+// Message: 誤りの内容（英語）
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError はスキーマに合わなかった項目の一覧です
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fieldName(fe.Field) + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateJSON は data を JSON として読み、スキーマに合うかを検証します
+// 合わなければ *ValidationError を、JSON として読めなければ json パッケージのエラーを返します
+func (s *Schema) ValidateJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after the JSON value")
+	}
+	return s.Validate(value)
+}
+
+// Validate は json.Decoder で読んだ値（UseNumber の有無は問いません）がスキーマに合うかを検証します
+// 誤りはまとめて *ValidationError で返すので、すべての項目を一度に直せます
+func (s *Schema) Validate(value interface{}) error {
+	var errs []FieldError
+	s.validate("", value, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.hasType(value) {
+		fail("must be %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		fail("must be one of %s", s.enumList())
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(path, v, errs)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+		if message := checkFormat(s.Format, v); message != "" {
+			fail("%s", message)
+		}
+	case json.Number, float64:
+		n, _ := toFloat(v)
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) validateObject(path string, object map[string]interface{}, errs *[]FieldError) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, FieldError{Field: join(path, name), Message: "is required"})
+		}
+	}
+
+	// 誤りの順序がリクエストごとに変わらないように、名前の順に検証します
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, FieldError{Field: join(path, name), Message: "is not allowed"})
+			}
+			continue
+		}
+		property.validate(join(path, name), object[name], errs)
+	}
+}
+
+// hasType は value が type のいずれかに当てはまるかを返します
+func (s *Schema) hasType(value interface{}) bool {
+	for _, t := range s.Type {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case json.Number, float64:
+			n, ok := toFloat(v)
+			if t == "number" && ok {
+				return true
+			}
+			if t == "integer" && ok && n == float64(int64(n)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum は value が enum のいずれかと等しいかを返します（数値は値で比べます）
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, candidate := range s.Enum {
+		a, aNumber := toFloat(candidate)
+		b, bNumber := toFloat(value)
+		if aNumber && bNumber {
+			if a == b {
+				return true
+			}
+			continue
+		}
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) enumList() string {
+	values := make([]string, len(s.Enum))
+	for i, v := range s.Enum {
+		encoded, _ := json.Marshal(v)
+		values[i] = string(encoded)
+	}
+	return strings.Join(values, ", ")
+}
+
+// checkFormat は format に合わなければ誤りの内容を、合うか未知の format なら空文字を返します
+func checkFormat(format, value string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time (for example 2025-01-02T15:04:05Z)"
+		}
+	case "uri":
+		if parsed, err := url.Parse(value); err != nil || !parsed.IsAbs() {
+			return "must be an absolute URI"
+		}
+	}
+	return ""
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func fieldName(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
@@ -0,0 +1,157 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ruleSchema = `{
+	"type": "object",
+	"required": ["trigger", "actions"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "maxLength": 5},
+		"trigger": {"enum": ["task.created", "task.completed"]},
+		"when": {"type": ["string", "null"], "format": "date-time"},
+		"url": {"type": "string", "format": "uri"},
+		"slug": {"type": "string", "pattern": "^[a-z]+$", "minLength": 2},
+		"minutes": {"type": "integer", "minimum": 0, "maximum": 120},
+		"ratio": {"type": "number"},
+		"level": {"enum": [1, 2]},
+		"done": {"type": "boolean"},
+		"actions": {
+			"type": "array",
+			"minItems": 1,
+			"maxItems": 2,
+			"items": {
+				"type": "object",
+				"required": ["type"],
+				"properties": {"type": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+func TestValidateJSON(t *testing.T) {
+	s := MustParse([]byte(ruleSchema))
+
+	valid := []string{
+		`{"trigger": "task.created", "actions": [{"type": "set_priority"}]}`,
+		`{"trigger": "task.completed", "actions": [{"type": "a"}], "name": "日本語です", "when": null,
+			"url": "https://example.com/hook", "slug": "ab", "minutes": 120, "ratio": 0.5, "level": 2.0, "done": true}`,
+		`{"trigger": "task.created", "actions": [{"type": "a", "extra": 1}], "when": "2025-01-02T15:04:05+09:00", "minutes": 1e1}`,
+	}
+	for _, body := range valid {
+		if err := s.ValidateJSON([]byte(body)); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", body, err)
+		}
+	}
+
+	tests := []struct {
+		body string
+		want []FieldError
+	}{
+		{`[]`, []FieldError{{"", "must be object"}}},
+		{`{}`, []FieldError{{"trigger", "is required"}, {"actions", "is required"}}},
+		{`{"trigger": "task.deleted", "actions": [], "unknown": 1}`, []FieldError{
+			{"actions", "must have at least 1 items"},
+			{"trigger", `must be one of "task.created", "task.completed"`},
+			{"unknown", "is not allowed"},
+		}},
+		{`{"trigger": "task.created", "actions": [{}, {"type": 3}, {"type": "x"}]}`, []FieldError{
+			{"actions", "must have at most 2 items"},
+			{"actions[0].type", "is required"},
+			{"actions[1].type", "must be string"},
+		}},
+		{`{"trigger": "task.created", "actions": [{"type": "a"}], "name": "toolong", "when": "tomorrow",
+			"url": "/relative", "slug": "A", "minutes": 1.5, "ratio": "x", "level": 3, "done": "yes"}`, []FieldError{
+			{"done", "must be boolean"},
+			{"level", "must be one of 1, 2"},
+			{"minutes", "must be integer"},
+			{"name", "must be at most 5 characters"},
+			{"ratio", "must be number"},
+			{"slug", "must be at least 2 characters"},
+			{"slug", "must match ^[a-z]+$"},
+			{"url", "must be an absolute URI"},
+			{"when", "must be an RFC 3339 date-time (for example 2025-01-02T15:04:05Z)"},
+		}},
+		{`{"trigger": "task.created", "actions": [{"type": "a"}], "minutes": -1}`, []FieldError{{"minutes", "must be at least 0"}}},
+		{`{"trigger": "task.created", "actions": [{"type": "a"}], "minutes": 121}`, []FieldError{{"minutes", "must be at most 120"}}},
+	}
+	for _, tt := range tests {
+		err := s.ValidateJSON([]byte(tt.body))
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a ValidationError, got %v", tt.body, err)
+			continue
+		}
+		if !reflect.DeepEqual(verr.Errors, tt.want) {
+			t.Errorf("%s:\nexpected %+v\ngot      %+v", tt.body, tt.want, verr.Errors)
+		}
+	}
+}
+
+func TestValidateJSONMalformed(t *testing.T) {
+	s := MustParse([]byte(`{"type": "object"}`))
+	for _, body := range []string{``, `{`, `{} {}`} {
+		err := s.ValidateJSON([]byte(body))
+		var verr *ValidationError
+		if err == nil || errors.As(err, &verr) {
+			t.Errorf("%q: expected a JSON error, got %v", body, err)
+		}
+	}
+}
+
+func TestValidateDecodedValue(t *testing.T) {
+	s := MustParse([]byte(`{"type": "object", "properties": {"n": {"type": "integer", "maximum": 3}}}`))
+	var value interface{}
+	json.Unmarshal([]byte(`{"n": 4}`), &value)
+	if err := s.Validate(value); err == nil || err.Error() != "n: must be at most 3" {
+		t.Errorf("Unexpected error for a float64 value: %v", err)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{{"", "must be object"}, {"title", "is required"}}}
+	if got := err.Error(); got != "body: must be object; title: is required" {
+		t.Errorf("Unexpected message %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{"type": "text"}`, `body: unknown type "text"`},
+		{`{"type": 1}`, "type must be a string or an array of strings"},
+		{`{"properties": {"a": {"pattern": "("}}}`, "a: invalid pattern"},
+		{`{"items": {"type": ["string", "date"]}}`, `[]: unknown type "date"`},
+		{`{`, "unexpected end of JSON input"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.schema)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.schema, tt.want, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustParse to panic")
+		}
+	}()
+	MustParse([]byte(`{"type": "text"}`))
+}
+
+func TestTypesJSON(t *testing.T) {
+	for _, schema := range []string{`{"type":"string"}`, `{"type":["string","null"]}`} {
+		s := MustParse([]byte(schema))
+		encoded, _ := json.Marshal(s)
+		if string(encoded) != schema {
+			t.Errorf("Expected %s to round-trip, got %s", schema, encoded)
+		}
+	}
+}
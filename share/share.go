@@ -0,0 +1,187 @@
+// Package share はログインせずにリストのタスクを読み取り専用で見られる共有リンクを管理します
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// Link は1件の共有リンクです
+// Token: URL に含める推測できない文字列（32 バイトの乱数）
+// ListID: 共有するリストの ID。リンクではこのリストのタスクだけを見せます
+// Name: 管理用の名前（共有した相手など）
+type Link struct {
+	Token     string    `json:"token"`
+	ListID    int       `json:"list_id"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store は発行した共有リンクを保持し、path が空でなければ JSON ファイルに保存します
+type Store struct {
+	path  string
+	links map[string]Link
+	mutex sync.RWMutex
+	now   func() time.Time
+}
+
+// NewStore は path のファイルから共有リンクを読み込んで Store を作成します
+// path が空ならメモリ上だけで保持します。ファイルがなければリンクのない Store から始めます
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, links: make(map[string]Link), now: time.Now}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Links []Link `json:"links"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, link := range file.Links {
+		s.links[link.Token] = link
+	}
+	return s, nil
+}
+
+// Create は listID のリストを共有する新しいトークンの共有リンクを発行します
+// リストがあるかはリストを管理する側で確かめます
+func (s *Store) Create(listID int, name string) (Link, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return Link{}, err
+	}
+	link := Link{Token: base64.RawURLEncoding.EncodeToString(buf), ListID: listID, Name: name, CreatedAt: s.now()}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.links[link.Token] = link
+	if err := s.save(); err != nil {
+		delete(s.links, link.Token)
+		return Link{}, err
+	}
+	return link, nil
+}
+
+// Lookup はトークンの共有リンクを返します。取り消したか発行していなければ false を返します
+func (s *Store) Lookup(token string) (Link, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	link, ok := s.links[token]
+	return link, ok
+}
+
+// List は発行済みの共有リンクを古い順に返します
+func (s *Store) List() []Link {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.sorted()
+}
+
+// sorted は共有リンクを古い順に並べて返します。ロック中に呼び出します
+func (s *Store) sorted() []Link {
+	links := make([]Link, 0, len(s.links))
+	for _, link := range s.links {
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CreatedAt.Before(links[j].CreatedAt)
+		}
+		return links[i].Token < links[j].Token
+	})
+	return links
+}
+
+// Revoke は共有リンクを取り消します。見つかったら true を、見つからなければ false を返します
+// 取り消しを保存できなかった場合は取り消さずにエラーを返します
+func (s *Store) Revoke(token string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	link, ok := s.links[token]
+	if !ok {
+		return false, nil
+	}
+	delete(s.links, token)
+	if err := s.save(); err != nil {
+		s.links[token] = link
+		return false, err
+	}
+	return true, nil
+}
+
+// save は共有リンクを一時ファイルに書き出してから置き換えます。ロック中に呼び出します
+// トークンを知っていればリンクを開けるため、ファイルは所有者だけが読めるようにします
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"links": s.sorted(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SharedTask は共有リンクで公開するタスクの項目です。ID や作業記録は含めません
+type SharedTask struct {
+	Title     string          `json:"title"`
+	Completed bool            `json:"completed"`
+	DueDate   *time.Time      `json:"due_date,omitempty"`
+	Priority  models.Priority `json:"priority,omitempty"`
+}
+
+// View は共有リンクで見せる読み取り専用のタスク一覧です
+// Name: リンクの名前（なければ List と同じ） / List: 共有しているリストの名前
+type View struct {
+	Name  string       `json:"name,omitempty"`
+	List  string       `json:"list"`
+	Tasks []SharedTask `json:"tasks"`
+}
+
+// NewView は link で公開する View を、tasks のうち link のリスト（名前は listName）のタスクで作成します
+func NewView(link Link, listName string, tasks []models.Task) View {
+	view := View{Name: link.Name, List: listName, Tasks: []SharedTask{}}
+	if view.Name == "" {
+		view.Name = listName
+	}
+	for _, task := range tasks {
+		if task.ListID != link.ListID {
+			continue
+		}
+		view.Tasks = append(view.Tasks, SharedTask{
+			Title:     task.Title,
+			Completed: task.Completed,
+			DueDate:   task.DueDate,
+			Priority:  task.Priority,
+		})
+	}
+	return view
+}
@@ -0,0 +1,95 @@
+package share
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+func TestStore(t *testing.T) {
+	store, _ := NewStore("")
+	clock := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+
+	first, err := store.Create(1, "Family")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, _ := store.Create(2, "")
+	if len(first.Token) != 43 || first.Token == second.Token {
+		t.Errorf("Expected distinct 32-byte tokens, got %q and %q", first.Token, second.Token)
+	}
+
+	if link, ok := store.Lookup(first.Token); !ok || link.Name != "Family" || link.ListID != 1 {
+		t.Errorf("Expected to find the first link, got %+v", link)
+	}
+	if links := store.List(); len(links) != 2 || links[0].Token != first.Token {
+		t.Errorf("Expected links in creation order, got %+v", links)
+	}
+
+	if ok, err := store.Revoke(first.Token); !ok || err != nil {
+		t.Errorf("Expected the link to be revoked, got %v %v", ok, err)
+	}
+	if ok, _ := store.Revoke(first.Token); ok {
+		t.Error("Expected the link to be revoked exactly once")
+	}
+	if _, ok := store.Lookup(first.Token); ok {
+		t.Error("Expected a revoked link not to be found")
+	}
+}
+
+func TestStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shares.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	kept, _ := store.Create(1, "Family")
+	revoked, _ := store.Create(2, "")
+	store.Revoke(revoked.Token)
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be readable only by the owner, got %v %v", info, err)
+	}
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	if link, ok := reopened.Lookup(kept.Token); !ok || link.ListID != 1 || link.Name != "Family" {
+		t.Errorf("Expected the link to survive a restart, got %+v %v", link, ok)
+	}
+	if _, ok := reopened.Lookup(revoked.Token); ok {
+		t.Error("Expected the revoked link to stay revoked")
+	}
+
+	os.WriteFile(path, []byte("{"), 0600)
+	if _, err := NewStore(path); err == nil {
+		t.Error("Expected an error for a broken file")
+	}
+}
+
+func TestNewView(t *testing.T) {
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 7, Title: "Buy milk", Completed: true, DueDate: &due, Priority: models.PriorityHigh, EstimateMinutes: 10, ListID: 2},
+		{ID: 8, Title: "Write report", ListID: 1},
+		{ID: 9, Title: "Not in a list"},
+	}
+
+	view := NewView(Link{ListID: 2, Name: "Family"}, "買い物", tasks)
+	if view.Name != "Family" || view.List != "買い物" || len(view.Tasks) != 1 {
+		t.Fatalf("Unexpected view: %+v", view)
+	}
+	if unnamed := NewView(Link{ListID: 1}, "仕事", tasks); unnamed.Name != "仕事" || len(unnamed.Tasks) != 1 || unnamed.Tasks[0].Title != "Write report" {
+		t.Errorf("Expected the list name and only its tasks, got %+v", unnamed)
+	}
+	got := view.Tasks[0]
+	if got.Title != "Buy milk" || !got.Completed || got.DueDate == nil || got.Priority != models.PriorityHigh {
+		t.Errorf("Unexpected shared task: %+v", got)
+	}
+}
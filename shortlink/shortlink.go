@@ -0,0 +1,75 @@
+// Package shortlink はタスクをチャットやコミットメッセージで参照するための短いコードを発行します
+package shortlink
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// alphabet は短いコードに使う文字です。読み違えやすい 0 / O / 1 / l / I を除いています
+const alphabet = "23456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// codeLength は短いコードの文字数です（57^7 ≒ 1.9兆通り）
+const codeLength = 7
+
+// maxAttempts は既存のコードと重なったときに作り直す回数の上限です
+const maxAttempts = 10
+
+// Store はタスクのIDと短いコードの対応を保持します。1件のタスクには1つのコードだけを発行します
+type Store struct {
+	byCode map[string]int
+	byTask map[int]string
+	mutex  sync.Mutex
+}
+
+// NewStore は空の Store を作成します
+func NewStore() *Store {
+	return &Store{byCode: make(map[string]int), byTask: make(map[int]string)}
+}
+
+// CodeFor はタスクの短いコードを返します。まだなければ発行します
+func (s *Store) CodeFor(taskID int) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if code, ok := s.byTask[taskID]; ok {
+		return code, nil
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		code, err := newCode()
+		if err != nil {
+			return "", err
+		}
+		if _, used := s.byCode[code]; used {
+			continue
+		}
+		s.byCode[code] = taskID
+		s.byTask[taskID] = code
+		return code, nil
+	}
+	return "", errors.New("shortlink: could not generate a unique code")
+}
+
+// Resolve は短いコードのタスクのIDを返します。発行していなければ false を返します
+func (s *Store) Resolve(code string) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	taskID, ok := s.byCode[code]
+	return taskID, ok
+}
+
+// newCode は暗号学的な乱数から短いコードを作ります
+func newCode() (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, codeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
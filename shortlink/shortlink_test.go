@@ -0,0 +1,33 @@
+package shortlink
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+
+	code, err := store.CodeFor(1)
+	if err != nil || len(code) != codeLength {
+		t.Fatalf("Unexpected code %q (%v)", code, err)
+	}
+	for _, c := range code {
+		if !strings.ContainsRune(alphabet, c) {
+			t.Errorf("Unexpected character %q in %q", c, code)
+		}
+	}
+	if again, _ := store.CodeFor(1); again != code {
+		t.Errorf("Expected the same code for the same task, got %q and %q", code, again)
+	}
+	if other, _ := store.CodeFor(2); other == code {
+		t.Errorf("Expected a different code for another task, got %q", other)
+	}
+
+	if id, ok := store.Resolve(code); !ok || id != 1 {
+		t.Errorf("Expected %q to resolve to task 1, got %d %v", code, id, ok)
+	}
+	if _, ok := store.Resolve("unknown"); ok {
+		t.Error("Expected an unknown code not to resolve")
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"todo-app/config"
+)
+
+// staticFiles は static/ の CSS・JavaScript・アイコンと静的な画面の HTML です。バイナリだけで画面を返せるよう埋め込みます
+//
+//go:embed static
+var staticFiles embed.FS
+
+// staticFS はサーバが返す静的ファイルを返します
+// 開発モードでは nil を返し、サーバに static_dir のディレクトリから毎回読み込ませます（ブラウザを再読み込みするだけで変更が反映されます）
+func staticFS(cfg config.Config) fs.FS {
+	if cfg.Dev {
+		return nil
+	}
+	files, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+	return files
+}
@@ -0,0 +1,71 @@
+document.addEventListener('DOMContentLoaded', function() {
+    loadCompletions();
+    loadHeatmap();
+});
+
+// 最近30日の作成数と完了数を日ごとの棒グラフで表示します
+function loadCompletions() {
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    fetch(basePath + '/api/analytics/completions?range=30d&bucket=day&tz=' + encodeURIComponent(tz))
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                renderChart(data.completions.buckets);
+            }
+        })
+        .catch(error => {
+            console.error('Error loading analytics:', error);
+        });
+}
+
+function renderChart(buckets) {
+    const chart = document.getElementById('completionChart');
+    chart.innerHTML = '';
+
+    const max = Math.max(1, ...buckets.map(b => Math.max(b.created, b.completed)));
+    buckets.forEach(bucket => {
+        const column = document.createElement('div');
+        column.className = 'chart-column';
+        column.title = `${bucket.start}: 作成 ${bucket.created} / 完了 ${bucket.completed}`;
+        column.innerHTML = `
+            <div class="bar created" style="height: ${bucket.created / max * 100}%"></div>
+            <div class="bar completed" style="height: ${bucket.completed / max * 100}%"></div>
+        `;
+        chart.appendChild(column);
+    });
+}
+
+// 今年の日ごとの完了数を、日曜日から始まる週ごとの列に並べたヒートマップで表示します
+function loadHeatmap() {
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    fetch(basePath + '/api/analytics/heatmap?tz=' + encodeURIComponent(tz))
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                renderHeatmap(data.heatmap.days);
+            }
+        })
+        .catch(error => {
+            console.error('Error loading heatmap:', error);
+        });
+}
+
+function renderHeatmap(days) {
+    const heatmap = document.getElementById('completionHeatmap');
+    heatmap.innerHTML = '';
+    if (days.length === 0) {
+        return;
+    }
+
+    // 1月1日の曜日の分だけ、最初の列を空けます
+    const offset = new Date(days[0].date + 'T00:00:00').getDay();
+    for (let i = 0; i < offset; i++) {
+        heatmap.appendChild(document.createElement('div'));
+    }
+    days.forEach(day => {
+        const cell = document.createElement('div');
+        cell.className = 'heatmap-day level-' + day.level;
+        cell.title = `${day.date}: 完了 ${day.count}`;
+        heatmap.appendChild(cell);
+    });
+}
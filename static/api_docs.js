@@ -0,0 +1,6 @@
+// API の画面（/api/docs）で Swagger UI を表示します。OpenAPI の文書の URL は #swagger-ui の data-url にあります
+const swaggerUI = document.getElementById('swagger-ui');
+window.ui = SwaggerUIBundle({
+    url: swaggerUI.dataset.url,
+    dom_id: '#swagger-ui',
+});
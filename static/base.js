@@ -0,0 +1,52 @@
+// ワークスペース（/w/{slug}/）の画面では、API もそのワークスペースの URL で呼び出します
+const basePath = (window.location.pathname.match(/^\/w\/[^/]+/) || [''])[0];
+
+// ユーザーアカウントを有効にしたサーバでは、ログインのセッションが切れると API が 401 を返すため、ログイン画面へ移ります
+const originalFetch = window.fetch;
+window.fetch = function(...args) {
+    return originalFetch.apply(this, args).then(response => {
+        const url = String(args[0]);
+        if (response.status === 401 && !url.includes('/api/auth/') && !url.includes('/api/admin/')) {
+            window.location.href = '/login';
+        }
+        return response;
+    });
+};
+
+// apiErrorMessage は API のエラーエンベロープ（{"success": false, "error": {...}}）から画面に出すメッセージを作ります
+// withDetail が true なら原因の詳しい説明も付けます。リクエストの ID は、問い合わせのときにサーバのログと突き合わせるために添えます
+function apiErrorMessage(data, withDetail) {
+    if (!data || !data.error) {
+        return 'unknown error';
+    }
+    let message = data.error.message;
+    if (withDetail && data.error.detail) {
+        message += '（' + data.error.detail + '）';
+    }
+    if (data.error.request_id) {
+        message += ' [ID: ' + data.error.request_id + ']';
+    }
+    return message;
+}
+
+// ログインしていればユーザー名とログアウトのリンクを表示します（ユーザーアカウントが無効なら何も表示しません）
+document.addEventListener('DOMContentLoaded', function() {
+    const account = document.getElementById('account');
+    if (!account) {
+        return;
+    }
+    fetch('/api/auth/me')
+        .then(response => response.ok ? response.json() : null)
+        .then(data => {
+            if (data && data.success) {
+                document.getElementById('accountName').textContent = data.user.name;
+                account.style.display = '';
+            }
+        })
+        .catch(() => {});
+});
+
+function logout() {
+    fetch('/api/auth/logout', {method: 'POST'})
+        .then(() => { window.location.href = '/login'; });
+}
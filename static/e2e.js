@@ -0,0 +1,168 @@
+// タイトルと説明をブラウザで暗号化するモード（サーバを E2E_KEY_FILE 付きで起動したとき）の処理です
+// 鍵はパスフレーズから導出してこのページのメモリ上だけに持ち、サーバには暗号文と検索用のトークンだけを送ります
+// 方式は e2ee パッケージ（e2ee/e2ee.go）の説明と同じです
+const e2e = (function() {
+    const prefix = 'e2e:v1:';
+    const checkText = 'todo-app e2e check';
+    const iterations = 310000;
+    const maxTokens = 64;
+    const encoder = new TextEncoder();
+    const decoder = new TextDecoder();
+
+    let state = null;
+
+    function toBase64Url(bytes) {
+        let binary = '';
+        bytes.forEach(b => { binary += String.fromCharCode(b); });
+        return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+    }
+
+    function fromBase64Url(text) {
+        const binary = atob(text.replace(/-/g, '+').replace(/_/g, '/'));
+        return Uint8Array.from(binary, c => c.charCodeAt(0));
+    }
+
+    // PBKDF2 で 64 バイトを導出し、前半を AES-GCM、後半を HMAC の鍵にします
+    async function deriveKeys(passphrase, keys) {
+        const material = await crypto.subtle.importKey('raw', encoder.encode(passphrase), 'PBKDF2', false, ['deriveBits']);
+        const bits = new Uint8Array(await crypto.subtle.deriveBits(
+            { name: 'PBKDF2', hash: 'SHA-256', salt: fromBase64Url(keys.salt), iterations: keys.iterations },
+            material, 512));
+        return {
+            aes: await crypto.subtle.importKey('raw', bits.slice(0, 32), 'AES-GCM', false, ['encrypt', 'decrypt']),
+            hmac: await crypto.subtle.importKey('raw', bits.slice(32), { name: 'HMAC', hash: 'SHA-256' }, false, ['sign']),
+        };
+    }
+
+    async function encryptWith(aes, text) {
+        const nonce = crypto.getRandomValues(new Uint8Array(12));
+        const sealed = new Uint8Array(await crypto.subtle.encrypt({ name: 'AES-GCM', iv: nonce }, aes, encoder.encode(text)));
+        const data = new Uint8Array(nonce.length + sealed.length);
+        data.set(nonce);
+        data.set(sealed, nonce.length);
+        return prefix + toBase64Url(data);
+    }
+
+    async function decryptWith(aes, text) {
+        const data = fromBase64Url(text.slice(prefix.length));
+        const plain = await crypto.subtle.decrypt({ name: 'AES-GCM', iv: data.slice(0, 12) }, aes, data.slice(12));
+        return decoder.decode(plain);
+    }
+
+    // 初回は鍵の情報を作って登録し、2回目以降は確認用の暗号文でパスフレーズが正しいかを確かめます
+    async function unlock(keys) {
+        for (;;) {
+            const passphrase = window.prompt(keys
+                ? 'タスクを復号するパスフレーズを入力してください'
+                : '暗号化に使うパスフレーズを決めてください（忘れるとタスクを読めなくなります）');
+            if (passphrase === null || passphrase === '') {
+                throw new Error('passphrase is required');
+            }
+            if (keys) {
+                const derived = await deriveKeys(passphrase, keys);
+                try {
+                    if (await decryptWith(derived.aes, keys.check) === checkText) {
+                        return derived;
+                    }
+                } catch (error) {
+                    // 鍵が違うと復号に失敗します
+                }
+                alert('パスフレーズが違います');
+                continue;
+            }
+            const created = { kdf: 'PBKDF2-SHA256', iterations: iterations, salt: toBase64Url(crypto.getRandomValues(new Uint8Array(16))) };
+            const derived = await deriveKeys(passphrase, created);
+            created.check = await encryptWith(derived.aes, checkText);
+            const response = await fetch(basePath + '/api/e2e/keys', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(created),
+            });
+            const data = await response.json();
+            if (!data.success) {
+                // 別の画面が先に登録したときは、その鍵の情報で入力し直してもらいます
+                keys = (await (await fetch(basePath + '/api/e2e')).json()).keys;
+                if (!keys) {
+                    throw new Error(apiErrorMessage(data));
+                }
+                continue;
+            }
+            return derived;
+        }
+    }
+
+    // ready はモードが有効かを調べ、有効ならパスフレーズから鍵を準備します。結果はページを開いている間使い回します
+    function ready() {
+        if (!state) {
+            state = fetch(basePath + '/api/e2e')
+                .then(response => response.json())
+                .then(async data => {
+                    if (!data.enabled) {
+                        return { enabled: false };
+                    }
+                    return Object.assign({ enabled: true }, await unlock(data.keys));
+                });
+            state.catch(() => { state = null; });
+        }
+        return state;
+    }
+
+    // encryptTitle はモードが有効ならタイトルを暗号化し、無効ならそのまま返します
+    async function encryptTitle(title) {
+        const keys = await ready();
+        return keys.enabled ? encryptWith(keys.aes, title) : title;
+    }
+
+    // decryptTitle は暗号化したタイトルを復号します。暗号化していないタイトルはそのまま返します
+    async function decryptTitle(title) {
+        const keys = await ready();
+        if (!keys.enabled || !title.startsWith(prefix)) {
+            return title;
+        }
+        try {
+            return await decryptWith(keys.aes, title);
+        } catch (error) {
+            return '（復号できないタスク）';
+        }
+    }
+
+    // encryptDescription はモードが有効なら説明をタイトルと同じ形式で暗号化します。空の説明（説明なし）とモードが無効なときはそのまま返します
+    async function encryptDescription(description) {
+        const keys = await ready();
+        return keys.enabled && description !== '' ? encryptWith(keys.aes, description) : description;
+    }
+
+    // decryptDescription は暗号化した説明を復号します。暗号化していない説明はそのまま返します
+    async function decryptDescription(description) {
+        const keys = await ready();
+        if (!keys.enabled || !description.startsWith(prefix)) {
+            return description;
+        }
+        try {
+            return await decryptWith(keys.aes, description);
+        } catch (error) {
+            return '（復号できない説明）';
+        }
+    }
+
+    // decryptTasks はタスクの一覧のタイトルをすべて復号します
+    function decryptTasks(tasks) {
+        return Promise.all(tasks.map(async task => Object.assign({}, task, { title: await decryptTitle(task.title) })));
+    }
+
+    // indexTokens は空白で区切った単語ごとのトークン（HMAC の先頭 16 バイトの16進数）を返します。モードが無効なら空です
+    async function indexTokens(text) {
+        const keys = await ready();
+        if (!keys.enabled) {
+            return [];
+        }
+        const words = Array.from(new Set(text.normalize('NFKC').toLowerCase().split(/\s+/).filter(word => word)));
+        const tokens = await Promise.all(words.slice(0, maxTokens).map(async word => {
+            const mac = new Uint8Array(await crypto.subtle.sign('HMAC', keys.hmac, encoder.encode(word)));
+            return Array.from(mac.slice(0, 16), b => b.toString(16).padStart(2, '0')).join('');
+        }));
+        return tokens;
+    }
+
+    return { ready, encryptTitle, decryptTitle, encryptDescription, decryptDescription, decryptTasks, indexTokens };
+})();
@@ -0,0 +1,84 @@
+document.addEventListener('DOMContentLoaded', function() {
+    document.getElementById('loginForm').addEventListener('submit', function(event) {
+        event.preventDefault();
+        // 押したボタンでログインか登録かを選びます
+        const action = event.submitter ? event.submitter.dataset.action : 'login';
+        authenticate(action);
+    });
+    document.getElementById('resendButton').addEventListener('click', resendVerification);
+
+    // メールの確認用のリンクから戻ってきたときは結果を表示します
+    const verified = new URLSearchParams(window.location.search).get('verified');
+    if (verified === '1') {
+        showNotice('メールアドレスを確認しました。ログインしてください。');
+    } else if (verified === '0') {
+        document.getElementById('loginError').textContent = '確認用のリンクが正しくないか、期限が切れています。ログインして確認メールを送り直してください。';
+    }
+});
+
+function authenticate(action) {
+    const error = document.getElementById('loginError');
+    error.textContent = '';
+    showNotice('');
+    document.getElementById('resendButton').hidden = true;
+    const body = {
+        name: document.getElementById('nameInput').value.trim(),
+        password: document.getElementById('passwordInput').value,
+        remember: document.getElementById('rememberInput').checked
+    };
+    const email = document.getElementById('emailInput').value.trim();
+    if (action === 'register' && email) {
+        body.email = email;
+    }
+    // 招待のリンク（/login?invite=...）から開いたときは、登録で招待コードを送ります
+    const invite = new URLSearchParams(window.location.search).get('invite');
+    if (action === 'register' && invite) {
+        body.invite = invite;
+    }
+    fetch('/api/auth/' + action, {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify(body)
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                if (data.error && data.error.code === 'email_not_verified') {
+                    document.getElementById('resendButton').hidden = false;
+                }
+                throw new Error(data.error ? data.error.message : 'unknown error');
+            }
+            if (data.verification_required) {
+                showNotice('確認メールを送りました。メールのリンクを開いてからログインしてください。');
+                return;
+            }
+            window.location.href = '/';
+        })
+        .catch(err => {
+            error.textContent = err.message;
+        });
+}
+
+// resendVerification は入力したユーザーに確認メールを送り直します
+function resendVerification() {
+    fetch('/api/auth/verify/resend', {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify({name: document.getElementById('nameInput').value.trim()})
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(data.error ? data.error.message : 'unknown error');
+            }
+            document.getElementById('loginError').textContent = '';
+            showNotice('確認メールを送り直しました。届かないときは少し待ってからもう一度お試しください。');
+        })
+        .catch(err => {
+            document.getElementById('loginError').textContent = err.message;
+        });
+}
+
+function showNotice(message) {
+    document.getElementById('loginNotice').textContent = message;
+}
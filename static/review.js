@@ -0,0 +1,64 @@
+document.addEventListener('DOMContentLoaded', function() {
+    const input = document.getElementById('weekInput');
+    input.addEventListener('change', function() {
+        loadReview(input.value);
+    });
+    loadReview('');
+});
+
+// week が空なら今週の振り返りを読み込みます（input type="week" の値は "2025-W07" の形式です）
+function loadReview(week) {
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    let url = basePath + '/api/review?tz=' + encodeURIComponent(tz);
+    if (week) {
+        url += '&week=' + encodeURIComponent(week);
+    }
+    fetch(url)
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            const review = data.review;
+            return Promise.all([e2e.decryptTasks(review.completed), e2e.decryptTasks(review.carried_over), e2e.decryptTasks(review.created)])
+                .then(([completed, carriedOver, created]) => renderReview(Object.assign({}, review, { completed: completed, carried_over: carriedOver, created: created })));
+        })
+        .catch(error => {
+            console.error('Error loading review:', error);
+            alert('週の振り返りの読み込みに失敗しました');
+        });
+}
+
+function renderReview(review) {
+    document.getElementById('weekInput').value = review.week;
+    document.getElementById('reviewRange').textContent = `${review.week}: ${review.from} 〜 ${review.to}（${review.timezone}）`;
+    renderList('completedList', review.completed, '完了したタスクはありません');
+    renderList('carriedOverList', review.carried_over, '持ち越したタスクはありません');
+    renderList('createdList', review.created, '新しく作成したタスクはありません');
+}
+
+function renderList(listId, tasks, emptyMessage) {
+    const list = document.getElementById(listId);
+    list.innerHTML = '';
+
+    if (tasks.length === 0) {
+        const li = document.createElement('li');
+        li.className = 'agenda-empty';
+        li.textContent = emptyMessage;
+        list.appendChild(li);
+        return;
+    }
+
+    tasks.forEach(task => {
+        const li = document.createElement('li');
+        li.className = `task-item ${task.completed ? 'completed' : ''}`;
+        li.innerHTML = `<span class="task-title">${escapeHtml(task.title)}</span>`;
+        list.appendChild(li);
+    });
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
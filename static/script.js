@@ -1,12 +1,134 @@
+// LLM を設定しているときだけ、タスクを小さな作業に分ける 💡 ボタンを出します
+let suggestionsEnabled = false;
+
 document.addEventListener('DOMContentLoaded', function() {
-    loadTasks();
+    fetch(basePath + '/api/suggestions')
+        .then(response => response.json())
+        .then(data => { suggestionsEnabled = data.enabled === true; })
+        .catch(() => { suggestionsEnabled = false; })
+        .then(loadLists)
+        .then(loadTasks);
+
+    // タイトルを暗号化しているときは、サーバが PDF を作ったりキーワードで検索したりタイトルで並べたりできないため隠します
+    e2e.ready()
+        .then(keys => {
+            document.getElementById('pdfLink').hidden = keys.enabled;
+            if (keys.enabled) {
+                document.getElementById('keywordSearch').hidden = true;
+            }
+            document.querySelector('#sortSelect option[value="title"]').hidden = keys.enabled;
+        })
+        .catch(() => {});
+
+    // フォーカスの欄は開いたときだけ読み込み、開いているかどうかをブラウザに覚えておきます
+    const focus = document.getElementById('focusSection');
+    focus.open = localStorage.getItem('focusOpen') === 'true';
+    focus.addEventListener('toggle', function() {
+        localStorage.setItem('focusOpen', focus.open);
+        loadFocus();
+    });
+
+    // 入力が落ち着いてから検索式で絞り込みます
+    let searchTimer;
+    document.getElementById('searchInput').addEventListener('input', function() {
+        clearTimeout(searchTimer);
+        searchTimer = setTimeout(loadTasks, 300);
+    });
+    document.getElementById('keywordInput').addEventListener('input', function() {
+        clearTimeout(searchTimer);
+        searchTimer = setTimeout(loadTasks, 300);
+    });
+
+    document.getElementById('priorityFilter').addEventListener('change', loadTasks);
+
+    // 表示しているリストはブラウザに覚えておきます
+    const listSelect = document.getElementById('listSelect');
+    listSelect.addEventListener('change', function() {
+        localStorage.setItem('taskList', listSelect.value);
+        updateListButtons();
+        loadTasks();
+    });
+
+    // 並べ替えはブラウザに覚えておきます
+    const sortSelect = document.getElementById('sortSelect');
+    sortSelect.value = localStorage.getItem('taskSort') || '';
+    sortSelect.addEventListener('change', function() {
+        localStorage.setItem('taskSort', sortSelect.value);
+        loadTasks();
+    });
+
+    // 設定で止められている機能は、入力欄を隠したり接続しなかったりします
+    fetch(basePath + '/api/features')
+        .then(response => response.json())
+        .then(features => {
+            if (features.search === false) {
+                document.getElementById('keywordSearch').hidden = true;
+            }
+            if (features.live_sync !== false) {
+                connectLiveSync(1000);
+            }
+        })
+        .catch(() => connectLiveSync(1000));
 });
 
+// connectLiveSync はサーバからタスクの変更を受け取り、ほかのタブや端末での変更を一覧に反映します
+// 切断したら retryDelay ミリ秒（最大30秒まで倍に延ばします）待って接続し直し、その間の変更を取りこぼさないよう一覧を読み込み直します
+function connectLiveSync(retryDelay) {
+    if (!window.WebSocket) {
+        return;
+    }
+    const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const socket = new WebSocket(protocol + '//' + location.host + basePath + '/ws');
+    let reloadTimer;
+    socket.addEventListener('open', function() {
+        retryDelay = 1000;
+    });
+    // 続けて届いた変更はまとめて1回だけ読み込みます。リマインダーの通知はすぐに表示します
+    socket.addEventListener('message', function(message) {
+        const event = JSON.parse(message.data);
+        if (event.type === 'task.reminder') {
+            e2e.decryptTasks([event.task]).then(([task]) => showReminder(task));
+        }
+        clearTimeout(reloadTimer);
+        reloadTimer = setTimeout(loadTasks, 200);
+    });
+    socket.addEventListener('close', function() {
+        setTimeout(function() {
+            connectLiveSync(Math.min(retryDelay * 2, 30000));
+            loadTasks();
+        }, retryDelay);
+    });
+}
+
 function loadTasks() {
-    fetch('/api/tasks')
+    const keyword = document.getElementById('keywordInput').value.trim();
+    if (keyword) {
+        searchTasks(keyword);
+        return;
+    }
+    const query = document.getElementById('searchInput').value.trim();
+    const searchError = document.getElementById('searchError');
+    const options = [
+        ['list', document.getElementById('listSelect').value],
+        ['priority', document.getElementById('priorityFilter').value],
+        ['tag', encodeURIComponent(selectedTag)],
+        ['sort', document.getElementById('sortSelect').value]
+    ].filter(([, value]) => value);
+    searchParams(query)
+        .then(params => options.reduce((params, [name, value]) => params + (params ? '&' : '?') + name + '=' + value, params))
+        .then(params => fetch(basePath + '/api/tasks' + params))
         .then(response => response.json())
-        .then(tasks => {
-            renderTasks(tasks);
+        .then(data => {
+            // 検索式の誤りはエラーエンベロープで返ります。どこが誤りかは detail にあります
+            if (!Array.isArray(data)) {
+                searchError.textContent = data.error
+                    ? apiErrorMessage(data, true)
+                    : '絞り込みに失敗しました';
+                return;
+            }
+            searchError.textContent = '';
+            loadFocus();
+            return e2e.decryptTasks(data).then(renderTasks);
         })
         .catch(error => {
             console.error('Error loading tasks:', error);
@@ -14,11 +136,96 @@ function loadTasks() {
         });
 }
 
+// searchTasks はキーワードでタスクをあいまい検索し、一致の度合いの高い順に表示します（絞り込みと並べ替えは使いません）
+function searchTasks(keyword) {
+    const searchError = document.getElementById('searchError');
+    fetch(basePath + '/api/tasks/search?q=' + encodeURIComponent(keyword))
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                searchError.textContent = data.error ? apiErrorMessage(data) : '検索に失敗しました';
+                return;
+            }
+            searchError.textContent = '';
+            renderTasks(data.results.map(result => result.task));
+        })
+        .catch(error => {
+            console.error('Error searching tasks:', error);
+            alert('タスクの検索に失敗しました');
+        });
+}
+
+// 暗号化するモードではサーバがタイトルを読めないため、検索式の代わりに単語のトークンで絞り込みます
+function searchParams(query) {
+    if (!query) {
+        return Promise.resolve('');
+    }
+    return e2e.ready().then(keys => keys.enabled
+        ? e2e.indexTokens(query).then(tokens => '?index=' + tokens.join(','))
+        : '?q=' + encodeURIComponent(query));
+}
+
+// focusReasons は提案の理由の表示名です
+const focusReasons = {
+    overdue: '期限切れ',
+    due_today: '今日が期限',
+    due_soon: 'もうすぐ期限',
+    high_priority: '優先度 高',
+    old: '30日以上前に作成',
+    quick_win: 'すぐ終わる'
+};
+
+// loadFocus は期限・優先度・作成からの日数・見積もりで選んだ、今取りかかるとよいタスクを表示します
+function loadFocus() {
+    const focus = document.getElementById('focusSection');
+    if (!focus.open) {
+        return;
+    }
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    fetch(basePath + '/api/tasks/suggested?limit=3&tz=' + encodeURIComponent(tz))
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            return e2e.decryptTasks(data.suggestions.map(s => s.task))
+                .then(tasks => renderFocus(tasks, data.suggestions));
+        })
+        .catch(error => console.error('Error loading focus:', error));
+}
+
+function renderFocus(tasks, suggestions) {
+    const list = document.getElementById('focusList');
+    if (tasks.length === 0) {
+        list.innerHTML = '<li class="focus-empty">未完了のタスクはありません</li>';
+        return;
+    }
+    list.innerHTML = tasks.map((task, i) => `
+        <li>
+            <a href="#task-${task.id}">${escapeHtml(task.title)}</a>
+            ${suggestions[i].reasons.map(reason => `<span class="focus-reason">${focusReasons[reason] || reason}</span>`).join('')}
+        </li>
+    `).join('');
+}
+
+// shownTasks は表示中のタスク（復号したタイトル）を ID ごとに持ちます。編集するときに今の値を使います
+let shownTasks = {};
+
 function renderTasks(tasks) {
     const taskList = document.getElementById('taskList');
     const emptyState = document.getElementById('emptyState');
     
     taskList.innerHTML = '';
+
+    // 昨日までに完了したタスクは履歴（archive）に回し、一覧を短く保ちます。絞り込んでいるときはすべて表示します
+    const startOfToday = new Date();
+    startOfToday.setHours(0, 0, 0, 0);
+    const searching = document.getElementById('searchInput').value.trim() !== '' || document.getElementById('keywordInput').value.trim() !== '';
+    const archived = searching ? [] : tasks.filter(task => task.completed && task.completed_at && new Date(task.completed_at) < startOfToday);
+    const archivedNote = document.getElementById('archivedNote');
+    archivedNote.style.display = archived.length > 0 ? 'block' : 'none';
+    document.getElementById('archivedCount').textContent = archived.length;
+    tasks = tasks.filter(task => !archived.includes(task));
     
     if (tasks.length === 0) {
         emptyState.style.display = 'block';
@@ -26,30 +233,511 @@ function renderTasks(tasks) {
     }
     
     emptyState.style.display = 'none';
+
+    // 期限は日付だけなので、今日の日付（YYYY-MM-DD）と文字列で比べます
+    const today = localDate(new Date());
     
-    tasks.forEach(task => {
+    // サブタスクは親のタスクのすぐ下に並べます。親を表示していないサブタスクは、そのままの位置に表示します
+    const shownIDs = new Set(tasks.map(task => task.id));
+    const ordered = [];
+    tasks.filter(task => !shownIDs.has(task.parent_id)).forEach(task => {
+        ordered.push(task);
+        ordered.push(...tasks.filter(subtask => subtask.parent_id === task.id));
+    });
+
+    shownTasks = {};
+    ordered.forEach(task => {
+        shownTasks[task.id] = task;
+        const nested = shownIDs.has(task.parent_id);
+        const due = task.due_date ? task.due_date.slice(0, 10) : '';
+        const overdue = due !== '' && !task.completed && due < today;
         const li = document.createElement('li');
-        li.className = `task-item ${task.completed ? 'completed' : ''}`;
+        li.className = `task-item ${task.completed ? 'completed' : ''} ${overdue ? 'overdue' : ''} ${nested ? 'subtask' : ''}`;
+        li.id = `task-${task.id}`;
         
         li.innerHTML = `
             <input type="checkbox" class="task-checkbox" ${task.completed ? 'checked' : ''} 
                    onchange="toggleTask(${task.id})">
-            <span class="task-title">${escapeHtml(task.title)}</span>
+            <span class="task-title" ondblclick="editTask(${task.id})" title="ダブルクリックでタイトルを編集">${escapeHtml(task.title)}</span>
+            <button class="priority-badge priority-${task.priority || 'none'}" onclick="cyclePriority(${task.id}, '${task.priority || ''}')"
+                    title="クリックで優先度を変更">${priorityLabels[task.priority || '']}</button>
+            ${task.list_id && listNames[task.list_id] && !selectedListID() ? `<span class="task-list-name">📂 ${escapeHtml(listNames[task.list_id])}</span>` : ''}
+            ${(task.tags || []).map(tag => `<span class="tag-chip${tag === selectedTag ? ' selected' : ''}"><button class="tag-name" data-tag="${escapeHtml(tag)}" onclick="filterByTag(this.dataset.tag)" title="このタグで絞り込み">#${escapeHtml(tag)}</button><button class="tag-remove" data-tag="${escapeHtml(tag)}" onclick="removeTag(${task.id}, this.dataset.tag)" title="タグを外す">×</button></span>`).join('')}
+            ${task.progress ? `<span class="task-progress" title="完了したサブタスク"><progress max="${task.progress.total}" value="${task.progress.completed}"></progress> ${task.progress.completed}/${task.progress.total}</span>` : ''}
+            ${due ? `<span class="task-due" title="${overdue ? '期限切れ' : '期限'}">📅 ${due}</span>` : ''}
+            ${reminderButton(task)}
+            <button class="link-btn recurrence-badge" onclick="cycleRecurrence(${task.id}, '${task.recurrence ? task.recurrence.frequency : ''}')"
+                    title="クリックで繰り返しを変更">🔁${task.recurrence ? ' ' + recurrenceLabel(task.recurrence) : ''}</button>
+            ${task.parent_id ? '' : `<button class="link-btn" onclick="addSubtask(${task.id})" title="サブタスクを追加">＋</button>`}
+            ${suggestionsEnabled && !task.completed ? `<button class="link-btn" onclick="suggestSubtasks(${task.id})" title="小さな作業に分ける案を作る">💡</button>` : ''}
+            <button class="link-btn description-btn${task.description ? ' has-description' : ''}" onclick="toggleDescription(${task.id})" title="説明">📝</button>
+            <button class="link-btn" onclick="addTag(${task.id})" title="タグを付ける">🏷️</button>
+            <button class="link-btn" onclick="editTask(${task.id})" title="タイトルを編集">✏️</button>
+            <button class="link-btn" onclick="copyShortLink(${task.id})" title="短いリンクをコピー">🔗</button>
+            <a class="link-btn" href="${basePath}/tasks/${task.id}" title="詳細を開く">🔍</a>
             <button class="delete-btn" onclick="deleteTask(${task.id})">削除</button>
         `;
         
         taskList.appendChild(li);
     });
+
+    // 一覧を作り直しても、開いていた説明は開いたままにします
+    openDescriptions.forEach(id => {
+        if (shownTasks[id]) {
+            loadDescription(id);
+        }
+    });
+
+    // URL に #task-{id} を付けて開いたときは、そのタスクまでスクロールします
+    const target = window.location.hash && document.querySelector(window.location.hash);
+    if (target) {
+        target.scrollIntoView();
+    }
+}
+
+// listNames はリストの ID ごとの名前です（すべてのタスクを表示しているときに、タスクのリストを示すのに使います）
+let listNames = {};
+
+// loadLists はリストを読み込んで切り替えの選択肢を作り直し、URL の list か覚えておいたリストを選びます
+function loadLists() {
+    return fetch(basePath + '/api/lists')
+        .then(response => response.json())
+        .then(data => {
+            const select = document.getElementById('listSelect');
+            // リストの QR コード（/?list={id}）から開いたときは、そのリストを選びます
+            const saved = new URLSearchParams(location.search).get('list') || localStorage.getItem('taskList') || '';
+            select.querySelectorAll('option[data-list]').forEach(option => option.remove());
+            listNames = {};
+            (data.lists || []).forEach(list => {
+                listNames[list.id] = list.name;
+                const option = document.createElement('option');
+                option.value = String(list.id);
+                option.textContent = list.name;
+                option.dataset.list = 'true';
+                select.appendChild(option);
+            });
+            select.value = Array.from(select.options).some(option => option.value === saved) ? saved : '';
+            updateListButtons();
+        })
+        .catch(error => console.error('Error loading lists:', error));
+}
+
+// selectedListID は表示しているリストの ID を返します（すべて・リストなしのときは 0）
+function selectedListID() {
+    return Number(document.getElementById('listSelect').value) || 0;
+}
+
+// updateListButtons は名前の変更と削除のボタンを、リストを表示しているときだけ出します
+function updateListButtons() {
+    const hidden = selectedListID() === 0;
+    document.getElementById('renameListButton').hidden = hidden;
+    document.getElementById('deleteListButton').hidden = hidden;
+}
+
+// showList は id のリストを表示します
+function showList(id) {
+    localStorage.setItem('taskList', String(id));
+    return loadLists().then(loadTasks);
 }
 
+function createList() {
+    const name = (prompt('新しいリストの名前') || '').trim();
+    if (!name) {
+        return;
+    }
+    fetch(basePath + '/api/lists', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name: name })
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            return showList(data.list.id);
+        })
+        .catch(error => alert('リストを作成できませんでした: ' + error.message));
+}
+
+function renameList() {
+    const id = selectedListID();
+    const name = id && (prompt('リストの新しい名前', listNames[id]) || '').trim();
+    if (!name || name === listNames[id]) {
+        return;
+    }
+    fetch(basePath + '/api/lists/' + id, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name: name })
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            return loadLists();
+        })
+        .catch(error => alert('リストの名前を変更できませんでした: ' + error.message));
+}
+
+// deleteList は表示しているリストを削除します。入っていたタスクはリストなしに戻ります
+function deleteList() {
+    const id = selectedListID();
+    if (!id || !confirm(`リスト「${listNames[id]}」を削除しますか？（タスクは「リストなし」に残ります）`)) {
+        return;
+    }
+    fetch(basePath + '/api/lists/' + id, { method: 'DELETE' })
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            return showList('');
+        })
+        .catch(error => alert('リストを削除できませんでした: ' + error.message));
+}
+
+// priorityLabels は優先度のバッジの表示名です
+const priorityLabels = { '': '優先度 -', low: '優先度 低', medium: '優先度 中', high: '優先度 高' };
+
+// nextPriority はバッジをクリックしたときに切り替える次の優先度です（なし → 低 → 中 → 高 → なし）
+const nextPriority = { '': 'low', low: 'medium', medium: 'high', high: '' };
+
+function cyclePriority(id, current) {
+    fetch(basePath + '/api/tasks/' + id + '/priority', {
+        method: 'PATCH',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ priority: nextPriority[current] })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('優先度を変更できませんでした');
+    });
+}
+
+// frequencyLabels は繰り返す単位の表示名です
+const frequencyLabels = { daily: '毎日', weekly: '毎週', monthly: '毎月' };
+
+// recurrenceLabel は繰り返し方の表示名（「毎週」「2週ごと」など）を返します
+function recurrenceLabel(recurrence) {
+    const interval = recurrence.interval || 1;
+    if (interval === 1) {
+        return frequencyLabels[recurrence.frequency];
+    }
+    const units = { daily: '日', weekly: '週', monthly: 'か月' };
+    return interval + units[recurrence.frequency] + 'ごと';
+}
+
+// nextFrequency は繰り返しのボタンをクリックしたときに切り替える次の単位です（なし → 毎日 → 毎週 → 毎月 → なし）
+const nextFrequency = { '': 'daily', daily: 'weekly', weekly: 'monthly', monthly: '' };
+
+// cycleRecurrence は繰り返しのボタンをクリックしたときに、タスクの繰り返し方を次の単位に変えます
+function cycleRecurrence(id, current) {
+    const frequency = nextFrequency[current];
+    fetch(basePath + '/api/tasks/' + id, {
+        method: 'PATCH',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ recurrence: frequency ? { frequency: frequency } : null })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('繰り返しを変更できませんでした');
+    });
+}
+
+// selectedTag は一覧を絞り込んでいるタグです（空なら絞り込みません）
+let selectedTag = '';
+
+// filterByTag はタグのチップをクリックしたときに、そのタグの付いたタスクだけを表示します。もう一度クリックすると解除します
+function filterByTag(tag) {
+    selectedTag = tag === selectedTag ? '' : tag;
+    document.getElementById('tagFilter').hidden = !selectedTag;
+    document.getElementById('tagFilterName').textContent = selectedTag;
+    loadTasks();
+}
+
+function addTag(id) {
+    const tag = (prompt('付けるタグ（例: shopping）') || '').trim();
+    if (!tag) {
+        return;
+    }
+    updateTags(fetch(basePath + '/api/tasks/' + id + '/tags', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ tag: tag })
+    }), 'タグを付けられませんでした');
+}
+
+function removeTag(id, tag) {
+    updateTags(fetch(basePath + '/api/tasks/' + id + '/tags/' + encodeURIComponent(tag), { method: 'DELETE' }), 'タグを外せませんでした');
+}
+
+// updateTags はタグを付け外しした結果を確かめて一覧を更新します。失敗したら理由を表示します
+function updateTags(request, message) {
+    request
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data, true));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert(message + '\n' + error.message);
+    });
+}
+
+// localDate は date をブラウザのタイムゾーンの YYYY-MM-DD にします
+function localDate(date) {
+    const pad = n => String(n).padStart(2, '0');
+    return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}`;
+}
+
+function copyShortLink(id) {
+    fetch(basePath + '/api/tasks/' + id + '/shortlink', {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        const url = window.location.origin + data.url;
+        return navigator.clipboard.writeText(url).then(() => alert('コピーしました: ' + url));
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('短いリンクを作成できませんでした');
+    });
+}
+
+// suggestSubtasks はタスクを分けた案をタスクの下に並べ、選んだものをタスクとして追加できるようにします
+// openDescriptions は説明を開いているタスクの ID です
+const openDescriptions = new Set();
+
+// toggleDescription はタスクの下に説明を開き、もう一度押すと閉じます
+function toggleDescription(id) {
+    if (openDescriptions.has(id)) {
+        openDescriptions.delete(id);
+        const panel = document.querySelector('#task-' + id + ' .description');
+        if (panel) {
+            panel.remove();
+        }
+        return;
+    }
+    openDescriptions.add(id);
+    loadDescription(id);
+}
+
+// loadDescription はタスクの説明を Markdown から作った HTML で表示します
+// HTML はサーバが書かれた HTML をエスケープし、安全なリンクだけを残したものです
+// 暗号化するモードではサーバが説明を読めないため、ブラウザで復号した説明を Markdown のまま表示します
+function loadDescription(id) {
+    const li = document.getElementById('task-' + id);
+    let panel = li.querySelector('.description');
+    if (!panel) {
+        panel = document.createElement('div');
+        panel.className = 'description';
+        panel.textContent = '読み込んでいます…';
+        li.appendChild(panel);
+    }
+
+    fetch(basePath + '/api/tasks/' + id + '?render=html')
+    .then(response => response.json())
+    .then(async data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        const description = await e2e.decryptDescription(data.task.description || '');
+        let body = '<p class="description-empty">説明はありません</p>';
+        if (description && data.description_html !== undefined) {
+            body = data.description_html;
+        } else if (description) {
+            body = `<p class="description-plain">${escapeHtml(description)}</p>`;
+        }
+        panel.dataset.description = description;
+        panel.innerHTML = `
+            <div class="description-body">${body}</div>
+            <button onclick="editDescription(${id})">編集</button>
+        `;
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        panel.textContent = '説明を読み込めませんでした: ' + error.message;
+    });
+}
+
+// editDescription は説明を Markdown のまま編集する欄に切り替えます
+function editDescription(id) {
+    const panel = document.querySelector('#task-' + id + ' .description');
+    panel.innerHTML = `
+        <textarea rows="6" placeholder="Markdown で書けます（- [ ] でチェックリスト、**太字**、[リンク](https://example.com)）"></textarea>
+        <button onclick="saveDescription(${id})">保存</button>
+        <button onclick="loadDescription(${id})">キャンセル</button>
+    `;
+    const textarea = panel.querySelector('textarea');
+    textarea.value = panel.dataset.description;
+    textarea.focus();
+}
+
+// saveDescription は編集した説明を保存します（空にすると説明を外します）。暗号化するモードでは暗号化して送ります
+function saveDescription(id) {
+    const description = document.querySelector('#task-' + id + ' .description textarea').value;
+    e2e.encryptDescription(description.trim() === '' ? '' : description)
+    .then(encrypted => fetch(basePath + '/api/tasks/' + id, {
+        method: 'PATCH',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ description: encrypted })
+    }))
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('説明を保存できませんでした: ' + error.message);
+    });
+}
+
+// addSubtask はタイトルを入力してもらい、タスクにサブタスクを追加します
+function addSubtask(id) {
+    const title = (prompt('サブタスクの内容') || '').trim();
+    if (!title) {
+        return;
+    }
+    Promise.all([e2e.encryptTitle(title), e2e.indexTokens(title)])
+    .then(([encrypted, index]) => fetch(basePath + '/api/tasks/' + id + '/subtasks', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(index.length ? { title: encrypted, index: index } : { title: encrypted })
+    }))
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('サブタスクを追加できませんでした: ' + error.message);
+    });
+}
+
+function suggestSubtasks(id) {
+    const li = document.getElementById('task-' + id);
+    const existing = li.querySelector('.suggestions');
+    if (existing) {
+        existing.remove();
+        return;
+    }
+    const panel = document.createElement('div');
+    panel.className = 'suggestions';
+    panel.textContent = '案を作っています…';
+    li.appendChild(panel);
+
+    fetch(basePath + '/api/tasks/' + id + '/suggest-subtasks', {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        // 案は LLM が作った文字列のため、HTML として組み立てずに値とテキストとして設定します
+        panel.textContent = '';
+        data.suggestions.forEach(title => {
+            const label = document.createElement('label');
+            const input = document.createElement('input');
+            input.type = 'checkbox';
+            input.checked = true;
+            input.value = title;
+            label.append(input, ' ' + title);
+            panel.appendChild(label);
+        });
+        const button = document.createElement('button');
+        button.textContent = '選んだものを追加';
+        button.addEventListener('click', () => acceptSubtasks(id));
+        panel.appendChild(button);
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        panel.textContent = '案を作れませんでした: ' + error.message;
+    });
+}
+
+function acceptSubtasks(id) {
+    const checked = document.querySelectorAll('#task-' + id + ' .suggestions input:checked');
+    const subtasks = Array.from(checked).map(input => input.value);
+    if (subtasks.length === 0) {
+        alert('追加する案を選んでください');
+        return;
+    }
+    fetch(basePath + '/api/tasks/' + id + '/suggest-subtasks/accept', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ subtasks: subtasks })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadTasks();
+        } else {
+            alert('タスクの追加に失敗しました');
+        }
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('エラーが発生しました');
+    });
+}
+
+// escapeHtml は text を HTML の本文と属性の値に埋め込めるようにエスケープします（引用符も含めます）
 function escapeHtml(text) {
-    const div = document.createElement('div');
-    div.textContent = text;
-    return div.innerHTML;
+    return String(text)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
 }
 
 function addTask() {
     const input = document.getElementById('taskInput');
+    const dueInput = document.getElementById('dueInput');
+    const priorityInput = document.getElementById('priorityInput');
+    const recurrenceInput = document.getElementById('recurrenceInput');
     const title = input.value.trim();
     
     if (!title) {
@@ -57,17 +745,39 @@ function addTask() {
         return;
     }
     
-    fetch('/api/tasks', {
-        method: 'POST',
-        headers: {
-            'Content-Type': 'application/json',
-        },
-        body: JSON.stringify({ title: title })
+    // 暗号化するモードでは暗号文と検索用のトークンだけを送ります
+    Promise.all([e2e.encryptTitle(title), e2e.indexTokens(title)])
+    .then(([encrypted, index]) => {
+        const body = index.length ? { title: encrypted, index: index } : { title: encrypted };
+        if (dueInput.value) {
+            body.due_date = dueInput.value;
+        }
+        if (priorityInput.value) {
+            body.priority = priorityInput.value;
+        }
+        if (recurrenceInput.value) {
+            body.recurrence = { frequency: recurrenceInput.value };
+        }
+        // リストを表示しているときは、そのリストに追加します
+        const listID = selectedListID();
+        if (listID) {
+            body.list_id = listID;
+        }
+        return fetch(basePath + '/api/tasks', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify(body)
+        });
     })
     .then(response => response.json())
     .then(data => {
         if (data.success) {
             input.value = '';
+            dueInput.value = '';
+            priorityInput.value = '';
+            recurrenceInput.value = '';
             loadTasks(); // 画面を更新して最新の一覧を表示
         } else {
             alert('タスクの追加に失敗しました');
@@ -79,8 +789,37 @@ function addTask() {
     });
 }
 
+// editTask はタスクのタイトルを入力し直して保存します。PATCH なのでほかの項目は変わりません
+function editTask(id) {
+    const task = shownTasks[id];
+    const title = task && (prompt('新しいタイトル', task.title) || '').trim();
+    if (!title || title === task.title) {
+        return;
+    }
+
+    Promise.all([e2e.encryptTitle(title), e2e.indexTokens(title)])
+    .then(([encrypted, index]) => fetch(basePath + '/api/tasks/' + id, {
+        method: 'PATCH',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(index.length ? { title: encrypted, index: index } : { title: encrypted })
+    }))
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('タイトルを変更できませんでした');
+    });
+}
+
 function toggleTask(id) {
-    fetch('/api/tasks/' + id + '/toggle', {
+    fetch(basePath + '/api/tasks/' + id + '/toggle', {
         method: 'PUT'
     })
     .then(response => response.json())
@@ -97,24 +836,144 @@ function toggleTask(id) {
     });
 }
 
+// 削除はごみ箱へ移すだけなので確かめずに行い、しばらく「元に戻す」を表示します
 function deleteTask(id) {
-    if (confirm('このタスクを削除しますか？')) {
-        fetch('/api/tasks/' + id, {
-            method: 'DELETE'
-        })
-        .then(response => response.json())
-        .then(data => {
-            if (data.success) {
-                loadTasks();
-            } else {
-                alert('タスクの削除に失敗しました');
-            }
-        })
-        .catch(error => {
-            console.error('Error:', error);
-            alert('エラーが発生しました');
-        });
+    fetch(basePath + '/api/tasks/' + id, {
+        method: 'DELETE'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadTasks();
+            showUndoToast('タスクをごみ箱へ移しました');
+        } else {
+            alert('タスクの削除に失敗しました');
+        }
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('エラーが発生しました');
+    });
+}
+
+// reminderButton はタスクのリマインダーのボタンを返します。通知した後、止めるまでは 🔔 を揺らして表示します
+function reminderButton(task) {
+    if (task.reminded_at && !task.completed) {
+        return `<button class="link-btn reminder-btn ringing" onclick="dismissReminder(${task.id})" title="リマインダーを止める">🔔</button>`;
+    }
+    const label = task.remind_at ? ' ' + new Date(task.remind_at).toLocaleString([], { month: 'numeric', day: 'numeric', hour: '2-digit', minute: '2-digit' }) : '';
+    return `<button class="link-btn reminder-btn" onclick="editReminder(${task.id})" title="リマインダーを設定">⏰${label}</button>`;
+}
+
+// editReminder は通知する日時を尋ねてリマインダーを設定します（空にすると止めます）
+// はじめて設定するときに、ブラウザの通知を許可してもらいます
+function editReminder(id) {
+    const task = shownTasks[id];
+    const current = task && task.remind_at ? localDateTime(new Date(task.remind_at)) : localDateTime(new Date(Date.now() + 60 * 60 * 1000));
+    const input = prompt('通知する日時（YYYY-MM-DD HH:MM、空にすると止めます）', current);
+    if (input === null) {
+        return;
+    }
+    if (!input.trim()) {
+        dismissReminder(id);
+        return;
+    }
+    const remindAt = new Date(input.trim().replace(' ', 'T'));
+    if (isNaN(remindAt)) {
+        alert('日時は YYYY-MM-DD HH:MM の形で入力してください');
+        return;
+    }
+    if (window.Notification && Notification.permission === 'default') {
+        Notification.requestPermission();
+    }
+    reminderRequest(id, 'PUT', '/reminder', { remind_at: remindAt.toISOString() });
+}
+
+// localDateTime は日時をこの端末のタイムゾーンの YYYY-MM-DD HH:MM で返します
+function localDateTime(date) {
+    return localDate(date) + ' ' + String(date.getHours()).padStart(2, '0') + ':' + String(date.getMinutes()).padStart(2, '0');
+}
+
+// reminderTaskID は表示しているリマインダーのタスクの ID です
+let reminderTaskID = 0;
+
+// showReminder はリマインダーの時刻になったタスクを知らせます
+// 通知を許可されていればブラウザの通知を、そうでなくても画面の上に「10分後」「止める」と一緒に表示します
+function showReminder(task) {
+    if (window.Notification && Notification.permission === 'granted') {
+        new Notification('リマインダー', { body: task.title, tag: 'reminder-' + task.id });
+    }
+    reminderTaskID = task.id;
+    document.getElementById('reminderMessage').textContent = '⏰ ' + task.title;
+    document.getElementById('reminderToast').hidden = false;
+}
+
+function snoozeReminder(id) {
+    reminderRequest(id, 'POST', '/reminder/snooze', { minutes: 10 });
+}
+
+function dismissReminder(id) {
+    reminderRequest(id, 'DELETE', '/reminder');
+}
+
+// reminderRequest はリマインダーの API を呼び、表示しているリマインダーを閉じて一覧を読み込み直します
+function reminderRequest(id, method, path, body) {
+    if (id === reminderTaskID) {
+        document.getElementById('reminderToast').hidden = true;
     }
+    const options = { method: method };
+    if (body) {
+        options.headers = { 'Content-Type': 'application/json' };
+        options.body = JSON.stringify(body);
+    }
+    fetch(basePath + '/api/tasks/' + id + path, options)
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            throw new Error(apiErrorMessage(data));
+        }
+        loadTasks();
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('リマインダーを変更できませんでした: ' + error.message);
+    });
+}
+
+// undoToastTimer は「元に戻す」の表示を消すタイマーです
+let undoToastTimer = null;
+
+// showUndoToast は message と「元に戻す」ボタンを数秒だけ表示します
+function showUndoToast(message) {
+    document.getElementById('undoMessage').textContent = message;
+    document.getElementById('undoToast').hidden = false;
+    clearTimeout(undoToastTimer);
+    undoToastTimer = setTimeout(hideUndoToast, 6000);
+}
+
+function hideUndoToast() {
+    clearTimeout(undoToastTimer);
+    document.getElementById('undoToast').hidden = true;
+}
+
+// undoLastOperation はこのブラウザで最後に行った操作（追加・完了の切り替え・編集・削除）を取り消します
+function undoLastOperation() {
+    hideUndoToast();
+    fetch(basePath + '/api/undo', {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadTasks();
+        } else {
+            alert('元に戻せませんでした: ' + apiErrorMessage(data));
+        }
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('エラーが発生しました');
+    });
 }
 
 document.getElementById('taskInput').addEventListener('keypress', function(e) {
@@ -0,0 +1,43 @@
+document.addEventListener('DOMContentLoaded', function() {
+    // URL の /share/{token} をそのまま使い、同じ URL から JSON を読み込みます
+    fetch(window.location.pathname + '?format=json')
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(data.error ? data.error.message : 'unknown error');
+            }
+            renderShare(data.share);
+        })
+        .catch(error => {
+            console.error('Error loading shared tasks:', error);
+            document.getElementById('shareName').textContent = 'このリンクは無効になっているか、取り消されています';
+        });
+});
+
+function renderShare(view) {
+    document.getElementById('shareName').textContent = view.name || '';
+    const list = document.getElementById('sharedList');
+    list.innerHTML = '';
+
+    if (view.tasks.length === 0) {
+        const li = document.createElement('li');
+        li.className = 'agenda-empty';
+        li.textContent = 'タスクはありません';
+        list.appendChild(li);
+        return;
+    }
+
+    view.tasks.forEach(task => {
+        const li = document.createElement('li');
+        li.className = `task-item ${task.completed ? 'completed' : ''}`;
+        const due = task.due_date ? `<span class="task-date">期限: ${task.due_date.slice(0, 10)}</span>` : '';
+        li.innerHTML = `<span class="task-title">${escapeHtml(task.title)}</span>${due}`;
+        list.appendChild(li);
+    });
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
@@ -0,0 +1,60 @@
+// タスクの詳細画面（/tasks/{id}）の編集とコメントの操作です。保存したら画面を読み込み直して、サーバ側で描画した内容を表示します
+const taskDetail = document.querySelector('.task-detail');
+const taskId = taskDetail.dataset.taskId;
+
+// sendTask はタスクの API にリクエストを送り、失敗したら action の名前でメッセージを表示します
+function sendTask(path, method, body, action) {
+    const options = {method: method};
+    if (body !== undefined) {
+        options.headers = {'Content-Type': 'application/json'};
+        options.body = JSON.stringify(body);
+    }
+    fetch(basePath + '/api/tasks/' + taskId + path, options)
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            window.location.reload();
+        })
+        .catch(error => {
+            console.error('Error:', error);
+            alert(action + 'できませんでした: ' + error.message);
+        });
+}
+
+const taskForm = document.getElementById('taskForm');
+if (taskForm) {
+    taskForm.addEventListener('submit', function(event) {
+        event.preventDefault();
+        const title = taskForm.elements.title.value.trim();
+        if (!title) {
+            return;
+        }
+        sendTask('', 'PATCH', {
+            title: title,
+            description: taskForm.elements.description.value.trim() === '' ? '' : taskForm.elements.description.value,
+            completed: taskForm.elements.completed.checked,
+        }, 'タスクを保存');
+    });
+}
+
+const commentForm = document.getElementById('commentForm');
+if (commentForm) {
+    commentForm.addEventListener('submit', function(event) {
+        event.preventDefault();
+        const body = commentForm.elements.body.value;
+        if (!body.trim()) {
+            return;
+        }
+        sendTask('/comments', 'POST', {author: commentForm.elements.author.value.trim(), body: body}, 'コメント');
+    });
+}
+
+document.querySelectorAll('[data-comment-id]').forEach(button => {
+    button.addEventListener('click', function() {
+        if (confirm('このコメントを削除しますか？')) {
+            sendTask('/comments/' + button.dataset.commentId, 'DELETE', undefined, 'コメントを削除');
+        }
+    });
+});
@@ -0,0 +1,82 @@
+document.addEventListener('DOMContentLoaded', function() {
+    loadAgenda();
+});
+
+// ブラウザのタイムゾーンを送り、利用者にとっての「今日」で計算してもらいます
+function loadAgenda() {
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    fetch(basePath + '/api/agenda?tz=' + encodeURIComponent(tz))
+        .then(response => response.json())
+        .then(data => {
+            if (!data.success) {
+                throw new Error(apiErrorMessage(data));
+            }
+            const agenda = data.agenda;
+            return Promise.all([e2e.decryptTasks(agenda.overdue), e2e.decryptTasks(agenda.due_today), e2e.decryptTasks(agenda.scheduled)])
+                .then(([overdue, dueToday, scheduled]) => renderAgenda(Object.assign({}, agenda, { overdue: overdue, due_today: dueToday, scheduled: scheduled })));
+        })
+        .catch(error => {
+            console.error('Error loading agenda:', error);
+            alert('今日のタスクの読み込みに失敗しました');
+        });
+}
+
+function renderAgenda(agenda) {
+    document.getElementById('agendaDate').textContent = agenda.date + '（' + agenda.timezone + '）';
+    renderSection('overdueList', agenda.overdue, 'due_date', '期限切れのタスクはありません');
+    renderSection('dueTodayList', agenda.due_today, null, '今日が期限のタスクはありません');
+    renderSection('scheduledList', agenda.scheduled, 'scheduled_date', '今日の予定はありません');
+}
+
+// dateField を指定すると、その日付をタイトルの横に表示します
+function renderSection(listId, tasks, dateField, emptyMessage) {
+    const list = document.getElementById(listId);
+    list.innerHTML = '';
+
+    if (tasks.length === 0) {
+        const li = document.createElement('li');
+        li.className = 'agenda-empty';
+        li.textContent = emptyMessage;
+        list.appendChild(li);
+        return;
+    }
+
+    tasks.forEach(task => {
+        const li = document.createElement('li');
+        li.className = `task-item ${task.completed ? 'completed' : ''}`;
+        const date = dateField && task[dateField] ? task[dateField].slice(0, 10) : '';
+
+        li.innerHTML = `
+            <input type="checkbox" class="task-checkbox" ${task.completed ? 'checked' : ''}
+                   onchange="toggleTask(${task.id})">
+            <span class="task-title">${escapeHtml(task.title)}</span>
+            <span class="task-date">${escapeHtml(date)}</span>
+        `;
+
+        list.appendChild(li);
+    });
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+function toggleTask(id) {
+    fetch(basePath + '/api/tasks/' + id + '/toggle', {
+        method: 'PUT'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            loadAgenda();
+        } else {
+            alert('タスクの更新に失敗しました');
+        }
+    })
+    .catch(error => {
+        console.error('Error:', error);
+        alert('エラーが発生しました');
+    });
+}
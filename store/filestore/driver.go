@@ -0,0 +1,21 @@
+package filestore
+
+import (
+	"todo-app/models"
+	"todo-app/store"
+)
+
+// DriverName は store.Open で JSON ファイルの保存先を選ぶ名前です
+const DriverName = "file"
+
+// init は JSON ファイルのドライバを登録します。DSN はタスクを保存するファイルのパスです
+func init() {
+	store.Register(DriverName, func(config store.Config) (models.TaskStore, error) {
+		s, err := Open(config.DSN, config.Options...)
+		if err != nil {
+			// nil の *Store を TaskStore として返すと nil にならないため、明示的に nil を返します
+			return nil, err
+		}
+		return s, nil
+	})
+}
@@ -0,0 +1,32 @@
+package filestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-app/store"
+)
+
+func TestDriver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	s, err := store.Open(DriverName, store.Config{DSN: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*Store); !ok {
+		t.Fatalf("Expected a *Store, got %T", s)
+	}
+	if _, err := s.AddTask(context.Background(), "Saved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the tasks file to be written, got %v", err)
+	}
+
+	// パスを指定しなければ nil のストアとエラーを返します
+	if s, err := store.Open(DriverName, store.Config{}); err == nil || s != nil {
+		t.Errorf("Expected a nil store and an error, got %v, %v", s, err)
+	}
+}
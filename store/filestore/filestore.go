@@ -0,0 +1,112 @@
+// Package filestore はタスクの一覧を1つの JSON ファイルに保存するストアです
+// Git もデータベースも使わずに、再起動してもタスクを残したい小さな環境向けです
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// Store は TodoApp を包み、変更イベントのたびにタスクの一覧をファイルに書き出します
+// ごみ箱のタスクも deleted_at を付けて同じファイルに書き出します
+type Store struct {
+	*models.TodoApp
+
+	path  string
+	mutex sync.Mutex
+}
+
+// Open は path のファイルからタスクを読み込んで Store を作成します
+// ファイルがなければ空の一覧から始め、最初の変更のときに作成します
+func Open(path string, options ...models.Option) (*Store, error) {
+	if path == "" {
+		return nil, errors.New("filestore: path is empty")
+	}
+	tasks, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path}
+	s.TodoApp = models.NewTodoAppFromTasks(tasks, options...)
+	s.TodoApp.Subscribe(s.handleEvent)
+	return s, nil
+}
+
+// load は path のファイルのタスクをID順に読み込みます
+func load(path string) ([]models.Task, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tasks []models.Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("filestore: %s: %v", path, err)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// PurgeTrash はごみ箱の古いタスクを完全に削除し、削除したものがあればファイルに書き出します
+func (s *Store) PurgeTrash(ctx context.Context, before time.Time) ([]models.Task, error) {
+	purged, err := s.TodoApp.PurgeTrash(ctx, before)
+	if err != nil || len(purged) == 0 {
+		return purged, err
+	}
+	return purged, s.save(ctx)
+}
+
+// handleEvent は変更イベントを受け取って、その時点のタスクの一覧をファイルに書き出します
+// 購読者はエラーを返せず、メモリ上の変更はすでに終わっているため、失敗はログに記録します
+func (s *Store) handleEvent(event models.Event) {
+	if err := s.save(event.Context()); err != nil {
+		slog.ErrorContext(event.Context(), "filestore: failed to persist", "event", event.Type, "task_id", event.Task.ID, "err", err)
+	}
+}
+
+// save はタスクの一覧を一時ファイルに書き出してから置き換えます
+// 書き出しの途中でサーバが止まっても、前回の内容か今回の内容のどちらかが残ります
+// 一覧はロック中に読むため、イベントが並んで届いても最後に書き出すのは最新の一覧です
+func (s *Store) save(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tasks := append(s.TodoApp.GetTasks(ctx), s.TodoApp.GetTrash(ctx)...)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
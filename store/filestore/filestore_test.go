@@ -0,0 +1,82 @@
+package filestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+func TestContract(t *testing.T) {
+	storetest.RunContract(t, func(t *testing.T) models.TaskStore {
+		s, err := Open(filepath.Join(t.TempDir(), "tasks.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestPersistAndReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data", "tasks.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.GetTasks(ctx)) != 0 {
+		t.Fatal("Expected a missing file to start empty")
+	}
+
+	first, _ := s.AddTask(ctx, "Buy milk")
+	second, _ := s.AddTask(ctx, "Write report")
+	s.ToggleTask(ctx, first.ID)
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	s.SetDueDate(ctx, second.ID, &due)
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := reopened.GetTasks(ctx)
+	if len(tasks) != 2 || !tasks[0].Completed || tasks[1].DueDate == nil || !tasks[1].DueDate.Equal(due) {
+		t.Fatalf("Expected the tasks to survive a reload, got %+v", tasks)
+	}
+	if task, _ := reopened.AddTask(ctx, "Next"); task.ID != 3 {
+		t.Errorf("Expected IDs to continue after a reload, got %d", task.ID)
+	}
+
+	reopened.DeleteTask(ctx, first.ID)
+	again, _ := Open(path)
+	if tasks := again.GetTasks(ctx); len(tasks) != 2 || tasks[0].ID != second.ID {
+		t.Errorf("Expected the deleted task to be removed from the file, got %+v", tasks)
+	}
+	if trash := again.GetTrash(ctx); len(trash) != 1 || trash[0].ID != first.ID || trash[0].DeletedAt == nil {
+		t.Errorf("Expected the deleted task to stay in the trash after a reload, got %+v", trash)
+	}
+	again.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	if purged, _ := Open(path); len(purged.GetTrash(ctx)) != 0 {
+		t.Errorf("Expected the purged task to be removed from the file, got %+v", purged.GetTrash(ctx))
+	}
+
+	// 一時ファイルは残しません
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the tasks file in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(""); err == nil {
+		t.Error("Expected an error for an empty path")
+	}
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	os.WriteFile(path, []byte("not json"), 0644)
+	if _, err := Open(path); err == nil {
+		t.Error("Expected an error for a broken file")
+	}
+}
@@ -0,0 +1,26 @@
+package gitstore
+
+import (
+	"todo-app/models"
+	"todo-app/store"
+)
+
+// DriverName は store.Open で Git リポジトリの保存先を選ぶ名前です
+const DriverName = "git"
+
+// init は Git リポジトリのドライバを登録します
+// DSN はリポジトリのディレクトリ、Params の "remote" と "branch" は push するリモートとブランチです
+func init() {
+	store.Register(DriverName, func(config store.Config) (models.TaskStore, error) {
+		s, err := Open(config.DSN, Options{
+			Remote:      config.Params["remote"],
+			Branch:      config.Params["branch"],
+			TodoOptions: config.Options,
+		})
+		if err != nil {
+			// nil の *Store を TaskStore として返すと nil にならないため、明示的に nil を返します
+			return nil, err
+		}
+		return s, nil
+	})
+}
@@ -0,0 +1,34 @@
+package gitstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-app/store"
+)
+
+func TestDriver(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.Open(DriverName, store.Config{DSN: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*Store); !ok {
+		t.Fatalf("Expected a *Store, got %T", s)
+	}
+	if _, err := s.AddTask(context.Background(), "Committed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tasks", "1.json")); err != nil {
+		t.Errorf("Expected the task file to be written, got %v", err)
+	}
+
+	// リポジトリを作れない場所では nil のストアとエラーを返します
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+	if s, err := store.Open(DriverName, store.Config{DSN: file}); err == nil || s != nil {
+		t.Errorf("Expected a nil store and an error, got %v, %v", s, err)
+	}
+}
@@ -0,0 +1,318 @@
+// Package gitstore はタスクを1件1ファイルとしてローカルの Git リポジトリに保存するストアです
+// 変更のたびにコミットするため、履歴・差分・バックアップを Git の機能でそのまま扱えます
+package gitstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// tasksDir はリポジトリ内でタスクのファイルを置くディレクトリです
+const tasksDir = "tasks"
+
+// Options は Store の設定です
+// Remote: コミットのたびに push するリモート名（空なら push しない）
+// Branch: push するブランチ（省略時は現在のブランチ）
+// AuthorName / AuthorEmail: コミットの作成者（省略時は "todo-app"）
+// TodoOptions: 読み込んだタスクから TodoApp を作るときに渡すオプション（件数の上限など）
+type Options struct {
+	Remote      string
+	Branch      string
+	AuthorName  string
+	AuthorEmail string
+	TodoOptions []models.Option
+}
+
+// Store は TodoApp を包み、変更イベントごとにタスクのファイルを書き出してコミットします
+// ごみ箱へ移したタスクのファイルは deleted_at を付けて残し、PurgeTrash で完全に削除したときに取り除きます
+// イベントはタスクのファイルと同じコミットで outbox に書き出し、コミットできたものだけを購読者へ配信します
+// 書き出しかコミットに失敗すると、その後の変更でリポジトリ全体をメモリ上のタスクにそろえ直すまで Check がエラーを返します
+type Store struct {
+	*models.TodoApp
+
+	dir     string
+	options Options
+	mutex   sync.Mutex
+	outbox  outbox
+	failed  error
+}
+
+// Open は dir のリポジトリからタスクを読み込んで Store を作成します
+// dir が Git リポジトリでなければ初期化します
+func Open(dir string, options Options) (*Store, error) {
+	if options.AuthorName == "" {
+		options.AuthorName = "todo-app"
+	}
+	if options.AuthorEmail == "" {
+		options.AuthorEmail = "todo-app@localhost"
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, tasksDir), 0755); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, options: options}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := s.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadOutbox(); err != nil {
+		return nil, err
+	}
+	s.TodoApp = models.NewTodoAppFromTasks(tasks, options.TodoOptions...)
+	s.TodoApp.Subscribe(s.handleEvent)
+	return s, nil
+}
+
+// load は tasks ディレクトリのファイルをID順に読み込みます
+func (s *Store) load() ([]models.Task, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, tasksDir))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.Task, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, tasksDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var task models.Task
+		if err := json.Unmarshal(data, &task); err != nil {
+			return nil, fmt.Errorf("gitstore: %s: %v", entry.Name(), err)
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// handleEvent は変更イベントを受け取ってファイルに反映し、コミットできたら購読者へ配信します
+// 購読者はエラーを返せず、メモリ上の変更はすでに終わっているため、失敗はログに記録して Check で返します
+func (s *Store) handleEvent(event models.Event) {
+	committed, err := s.persist(event)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "gitstore: failed to persist", "event", event.Type, "task_id", event.Task.ID, "err", err)
+		return
+	}
+	s.publish(committed)
+}
+
+// persist はイベントのタスクと outbox のイベントをファイルに書き出して1つのコミットにし、設定があれば push します
+// タスクはロック中にメモリ上から読み直すため、イベントが並んで届いても最後に書き出すのは最新の内容です
+// 前の書き出しかコミットに失敗していれば、すべてのタスクのファイルを書き出し直して同じコミットに含めます
+// コミットしたイベント（ID は outbox の番号）を返します。push の失敗はコミットを取り消さないため、ログに記録するだけです
+func (s *Store) persist(event models.Event) (models.Event, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ctx := event.Context()
+	path := filepath.Join(tasksDir, strconv.Itoa(event.Task.ID)+".json")
+	var err error
+	if s.failed != nil {
+		path = tasksDir
+		err = s.writeAll(ctx)
+	} else {
+		err = s.writeTask(ctx, event.Task.ID)
+	}
+	if err == nil {
+		if event, err = s.writeOutbox(event); err == nil {
+			err = s.commit(event, path)
+		}
+		if err != nil {
+			// コミットできなかったイベントは配信しません。番号は飛ばし、後続のイベントの記録が止まらないようにします
+			os.Remove(filepath.Join(s.dir, outboxFile(event.ID)))
+			s.markPublished(event.ID)
+		}
+	}
+	if err != nil {
+		s.failed = err
+		return event, err
+	}
+	s.failed = nil
+
+	if s.options.Remote != "" {
+		args := []string{"push", "--quiet", s.options.Remote}
+		if s.options.Branch != "" {
+			args = append(args, "HEAD:"+s.options.Branch)
+		}
+		if _, err := s.git(args...); err != nil {
+			slog.ErrorContext(event.Context(), "gitstore: failed to push", "event", event.Type, "task_id", event.Task.ID, "err", err)
+		}
+	}
+	return event, nil
+}
+
+// Check は書き出しかコミットに失敗したまま、リポジトリがメモリ上のタスクに追いついていなければそのエラーを返します
+// 失敗していれば、すべてのタスクのファイルを書き出し直してコミットし、できたら nil を返します
+func (s *Store) Check(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failed == nil {
+		return nil
+	}
+	err := s.writeAll(ctx)
+	if err == nil {
+		_, err = s.git("add", "--all", "--", tasksDir)
+	}
+	if err == nil {
+		_, err = s.git("commit", "--quiet", "--allow-empty", "-m", "resync: tasks")
+	}
+	if err != nil {
+		s.failed = err
+		return err
+	}
+	s.failed = nil
+	return nil
+}
+
+// current は id のタスクの今の内容をメモリ上から探します（ごみ箱のタスクを含みます）
+func (s *Store) current(ctx context.Context, id int) (models.Task, bool) {
+	for _, task := range append(s.TodoApp.GetTasks(ctx), s.TodoApp.GetTrash(ctx)...) {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	return models.Task{}, false
+}
+
+// writeTask は id のタスクの今の内容をファイルに書き出します
+// 一覧にもごみ箱にもなければ（完全に削除したか、復元で置き換えた）ファイルを取り除きます
+func (s *Store) writeTask(ctx context.Context, id int) error {
+	path := filepath.Join(s.dir, tasksDir, strconv.Itoa(id)+".json")
+	task, ok := s.current(ctx, id)
+	if !ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return s.writeFile(task)
+}
+
+// writeFile は task をそのタスクのファイルに書き出します
+func (s *Store) writeFile(task models.Task) error {
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, tasksDir, strconv.Itoa(task.ID)+".json"), append(data, '\n'), 0644)
+}
+
+// writeAll はすべてのタスクのファイルを書き出し、メモリ上にないタスクのファイルを取り除きます
+func (s *Store) writeAll(ctx context.Context) error {
+	keep := map[string]bool{}
+	for _, task := range append(s.TodoApp.GetTasks(ctx), s.TodoApp.GetTrash(ctx)...) {
+		keep[strconv.Itoa(task.ID)+".json"] = true
+		if err := s.writeFile(task); err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(s.dir, tasksDir))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && !keep[entry.Name()] {
+			if err := os.Remove(filepath.Join(s.dir, tasksDir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PurgeTrash はごみ箱の古いタスクを完全に削除し、そのファイルを取り除いて1つのコミットにします
+// 一覧は変わらないためイベントは配信しません。push の失敗はログに記録するだけです
+func (s *Store) PurgeTrash(ctx context.Context, before time.Time) ([]models.Task, error) {
+	purged, err := s.TodoApp.PurgeTrash(ctx, before)
+	if err != nil || len(purged) == 0 {
+		return purged, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.purgeFiles(purged); err != nil {
+		s.failed = err
+		return purged, err
+	}
+	if s.options.Remote != "" {
+		args := []string{"push", "--quiet", s.options.Remote}
+		if s.options.Branch != "" {
+			args = append(args, "HEAD:"+s.options.Branch)
+		}
+		if _, err := s.git(args...); err != nil {
+			slog.ErrorContext(ctx, "gitstore: failed to push", "purged", len(purged), "err", err)
+		}
+	}
+	return purged, nil
+}
+
+// purgeFiles は完全に削除したタスクのファイルを取り除いてコミットします
+func (s *Store) purgeFiles(purged []models.Task) error {
+	paths := make([]string, len(purged))
+	for i, task := range purged {
+		paths[i] = filepath.Join(tasksDir, strconv.Itoa(task.ID)+".json")
+		if err := os.Remove(filepath.Join(s.dir, paths[i])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if _, err := s.git(append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := s.git("commit", "--quiet", "-m", fmt.Sprintf("trash.purged: %d tasks", len(purged)))
+	return err
+}
+
+// commit はタスクのファイル path と outbox の変更をまとめてコミットします
+func (s *Store) commit(event models.Event, path string) error {
+	if _, err := s.git("add", "--all", "--", path, outboxDir); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("%s #%d: %s", event.Type, event.Task.ID, event.Task.Title)
+	_, err := s.git("commit", "--quiet", "--allow-empty", "-m", message)
+	return err
+}
+
+// git はリポジトリのディレクトリで git コマンドを実行します
+func (s *Store) git(args ...string) (string, error) {
+	subcommand := args[0]
+	args = append([]string{
+		"-c", "user.name=" + s.options.AuthorName,
+		"-c", "user.email=" + s.options.AuthorEmail,
+		"-c", "commit.gpgsign=false",
+	}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", subcommand, err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,235 @@
+package gitstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/models"
+	"todo-app/store/storetest"
+)
+
+func requireGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+}
+
+func gitLog(t *testing.T, dir string) []string {
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%s").Output()
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestStorePersistsAndCommits(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	dir := t.TempDir()
+
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	task, _ := store.AddTask(ctx, "Write report")
+	store.ToggleTask(ctx, task.ID)
+	second, _ := store.AddTask(ctx, "Throw away")
+	store.DeleteTask(ctx, second.ID)
+
+	data, err := os.ReadFile(filepath.Join(dir, "tasks", "1.json"))
+	if err != nil {
+		t.Fatalf("Expected task file: %v", err)
+	}
+	if !strings.Contains(string(data), `"completed": true`) {
+		t.Errorf("Expected completed task in file, got %s", data)
+	}
+	// 削除したタスクはごみ箱に入り、完全に削除するまでファイルを残します
+	data, err = os.ReadFile(filepath.Join(dir, "tasks", "2.json"))
+	if err != nil || !strings.Contains(string(data), `"deleted_at"`) {
+		t.Errorf("Expected the trashed task file to be kept with deleted_at, got %v %s", err, data)
+	}
+	reopened, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if trash := reopened.GetTrash(ctx); len(trash) != 1 || trash[0].ID != second.ID || len(reopened.GetTasks(ctx)) != 1 {
+		t.Errorf("Expected the trash to survive a reopen, got %+v", trash)
+	}
+
+	if purged, err := store.PurgeTrash(ctx, time.Now().Add(time.Minute)); err != nil || len(purged) != 1 {
+		t.Fatalf("Expected the trashed task to be purged, got %v %v", purged, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tasks", "2.json")); !os.IsNotExist(err) {
+		t.Error("Expected the purged task file to be removed")
+	}
+
+	log := gitLog(t, dir)
+	expected := []string{
+		"trash.purged: 1 tasks",
+		"task.deleted #2: Throw away",
+		"task.created #2: Throw away",
+		"task.updated #1: Write report",
+		"task.created #1: Write report",
+	}
+	if strings.Join(log, "|") != strings.Join(expected, "|") {
+		t.Errorf("Unexpected commit log: %v", log)
+	}
+}
+
+func TestStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	dir := t.TempDir()
+
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(ctx, "First")
+	task, _ := store.AddTask(ctx, "Second")
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	store.SetDueDate(ctx, task.ID, &due)
+
+	reopened, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	tasks := reopened.GetTasks(ctx)
+	if len(tasks) != 2 || tasks[1].Title != "Second" || tasks[1].DueDate == nil || !tasks[1].DueDate.Equal(due) {
+		t.Errorf("Unexpected tasks after reopen: %+v", tasks)
+	}
+	if next, _ := reopened.AddTask(ctx, "Third"); next.ID != 3 {
+		t.Errorf("Expected next ID 3, got %d", next.ID)
+	}
+}
+
+func TestStorePersistWritesCurrentTask(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	task, _ := store.AddTask(ctx, "Old title")
+	store.SetTitle(ctx, task.ID, "New title")
+
+	// 遅れて届いた古いイベントでも、ファイルには今の内容を書き出します
+	if _, err := store.persist(models.NewEvent(ctx, 1, models.EventTaskUpdated, task)); err != nil {
+		t.Fatalf("persist failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "tasks", "1.json"))
+	if !strings.Contains(string(data), "New title") {
+		t.Errorf("Expected the current title to be written, got %s", data)
+	}
+}
+
+func TestStoreCheckRecoversFromFailedCommit(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(ctx, "Saved")
+	if err := store.Check(ctx); err != nil {
+		t.Fatalf("Expected no error before a failure, got %v", err)
+	}
+
+	// ほかの git が index を使っている間はコミットできません
+	lock := filepath.Join(dir, ".git", "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	store.AddTask(ctx, "Not committed")
+	if err := store.Check(ctx); err == nil {
+		t.Fatal("Expected the failed commit to be reported")
+	}
+
+	os.Remove(lock)
+	if err := store.Check(ctx); err != nil {
+		t.Fatalf("Expected the store to recover, got %v", err)
+	}
+	if out, err := exec.Command("git", "-C", dir, "status", "--porcelain", "tasks").Output(); err != nil || len(out) != 0 {
+		t.Errorf("Expected every task file to be committed, got %q %v", out, err)
+	}
+	if log := gitLog(t, dir); log[0] != "resync: tasks" {
+		t.Errorf("Expected a resync commit, got %v", log)
+	}
+}
+
+func TestStoreTodoOptions(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := Open(dir, Options{TodoOptions: []models.Option{models.WithMaxTasks(1)}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(ctx, "Only")
+	if _, err := store.AddTask(ctx, "Too many"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected ErrConflict from the task limit, got %v", err)
+	}
+}
+
+func TestStorePushesToRemote(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	remote := t.TempDir()
+	if err := exec.Command("git", "init", "--quiet", "--bare", remote).Run(); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	store, err := Open(dir, Options{Remote: "origin", Branch: "main"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := store.git("remote", "add", "origin", remote); err != nil {
+		t.Fatalf("git remote add failed: %v", err)
+	}
+
+	store.AddTask(ctx, "Backed up")
+
+	out, err := exec.Command("git", "-C", remote, "log", "--format=%s", "main").Output()
+	if err != nil {
+		t.Fatalf("Expected pushed branch: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "task.created #1: Backed up" {
+		t.Errorf("Unexpected remote log: %s", out)
+	}
+}
+
+func TestOpenInvalidTaskFile(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tasks"), 0755)
+	os.WriteFile(filepath.Join(dir, "tasks", "1.json"), []byte("{"), 0644)
+
+	if _, err := Open(dir, Options{}); err == nil {
+		t.Error("Expected error for invalid task file")
+	}
+}
+
+func TestStoreImplementsTaskStore(t *testing.T) {
+	var _ models.TaskStore = (*Store)(nil)
+}
+
+func TestStoreContract(t *testing.T) {
+	requireGit(t)
+	storetest.RunContract(t, func(t *testing.T) models.TaskStore {
+		store, err := Open(t.TempDir(), Options{})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		return store
+	})
+}
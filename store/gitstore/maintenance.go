@@ -0,0 +1,91 @@
+package gitstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"todo-app/models"
+)
+
+// Problem は Verify が見つけたタスクのファイルの不整合です
+type Problem struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return p.File + ": " + p.Message
+}
+
+// Verify はサーバを止めた状態で dir のタスクのファイルを検査し、見つけた不整合を返します
+// 読めない JSON・ファイル名と ID の食い違い・空のタイトル・完了状態と完了日時の食い違い・コミットされていない変更を調べます
+// リポジトリ自体が読めない場合だけエラーを返します
+func Verify(dir string) ([]Problem, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, tasksDir))
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []Problem{}
+	add := func(file, format string, args ...interface{}) {
+		problems = append(problems, Problem{File: filepath.ToSlash(filepath.Join(tasksDir, file)), Message: fmt.Sprintf(format, args...)})
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		id, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || err != nil || id <= 0 {
+			add(name, "unexpected file (task files are named <id>.json)")
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, tasksDir, name))
+		if err != nil {
+			return nil, err
+		}
+		var task models.Task
+		if err := json.Unmarshal(data, &task); err != nil {
+			add(name, "invalid JSON: %v", err)
+			continue
+		}
+		if task.ID != id {
+			add(name, "id %d does not match the file name", task.ID)
+		}
+		if strings.TrimSpace(task.Title) == "" {
+			add(name, "title is empty")
+		}
+		if task.Completed && task.CompletedAt == nil {
+			add(name, "completed task has no completed_at")
+		}
+		if !task.Completed && task.CompletedAt != nil {
+			add(name, "open task has completed_at")
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		problems = append(problems, Problem{File: ".git", Message: "not a Git repository"})
+		return problems, nil
+	}
+	s := &Store{dir: dir}
+	status, err := s.git("status", "--porcelain", "--", tasksDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(strings.TrimRight(status, "\n"), "\n") {
+		if len(line) > 3 {
+			problems = append(problems, Problem{File: line[3:], Message: "uncommitted change (" + strings.TrimSpace(line[:2]) + ")"})
+		}
+	}
+	return problems, nil
+}
+
+// Compact は dir のリポジトリの履歴を git gc でまとめ、不要になったオブジェクトを削除します
+// 変更のたびにコミットするため、長く使ったリポジトリはこれで小さくなります
+func Compact(dir string) error {
+	s := &Store{dir: dir}
+	_, err := s.git("gc", "--quiet", "--prune=now")
+	return err
+}
@@ -0,0 +1,89 @@
+package gitstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	ctx := context.Background()
+	store.AddTask(ctx, "Healthy")
+	done, _ := store.AddTask(ctx, "Done")
+	store.ToggleTask(ctx, done.ID)
+
+	problems, err := Verify(dir)
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("Expected no problems, got %v (%v)", problems, err)
+	}
+
+	tasks := filepath.Join(dir, "tasks")
+	os.WriteFile(filepath.Join(tasks, "3.json"), []byte(`{"id": 4, "title": " ", "completed": true}`), 0644)
+	os.WriteFile(filepath.Join(tasks, "5.json"), []byte(`{"id": 5, "title": "a", "completed_at": "2025-01-01T00:00:00Z"}`), 0644)
+	os.WriteFile(filepath.Join(tasks, "6.json"), []byte(`{`), 0644)
+	os.WriteFile(filepath.Join(tasks, "notes.txt"), []byte(`hello`), 0644)
+
+	problems, err = Verify(dir)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.String())
+	}
+	report := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"tasks/3.json: id 4 does not match the file name",
+		"tasks/3.json: title is empty",
+		"tasks/3.json: completed task has no completed_at",
+		"tasks/5.json: open task has completed_at",
+		"tasks/6.json: invalid JSON",
+		"tasks/notes.txt: unexpected file",
+		"tasks/3.json: uncommitted change (??)",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in the report:\n%s", want, report)
+		}
+	}
+}
+
+func TestVerifyWithoutRepository(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Verify(dir); err == nil {
+		t.Error("Expected an error without a tasks directory")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "tasks"), 0755)
+	problems, err := Verify(dir)
+	if err != nil || len(problems) != 1 || problems[0].Message != "not a Git repository" {
+		t.Errorf("Expected a missing repository to be reported, got %v (%v)", problems, err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.AddTask(context.Background(), "Task")
+
+	if err := Compact(dir); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if problems, _ := Verify(dir); len(problems) != 0 {
+		t.Errorf("Expected the repository to stay consistent, got %v", problems)
+	}
+	if err := Compact(t.TempDir()); err == nil {
+		t.Error("Expected an error outside a repository")
+	}
+}
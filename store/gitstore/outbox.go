@@ -0,0 +1,208 @@
+package gitstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"todo-app/models"
+)
+
+// outboxDir はリポジトリ内で配信前のイベントを置くディレクトリです
+const outboxDir = "outbox"
+
+// publishedFile は配信を終えたイベントの番号を記録するファイルです
+// コミットせずに .git の中に置き、配信済みのイベントのファイルは次のコミットで取り除きます
+const publishedFile = "todo-outbox-published"
+
+// outbox はイベントをタスクの変更と同じコミットに書き出してから購読者へ配信します（transactional outbox）
+// コミットに失敗したイベントは配信しないため、保存されていない変更のイベントを購読者が受け取ることはありません
+// 配信の途中で止まっても、次に起動したときに Relay が配信していないイベントを配信し直します（at-least-once）
+// イベントの ID は再起動しても続く通し番号にするため、購読者は ID で重複を取り除けます
+type outbox struct {
+	mutex       sync.Mutex
+	lastSeq     int64
+	published   int64
+	done        map[int64]bool
+	subscribers map[int]models.EventHandler
+	nextSubID   int
+}
+
+// outboxFile は配信前のイベントのファイルのパス（リポジトリからの相対パス）を返します
+func outboxFile(seq int64) string {
+	return filepath.Join(outboxDir, strconv.FormatInt(seq, 10)+".json")
+}
+
+// loadOutbox は配信済みの番号を読み込み、次に振る番号を決めます
+func (s *Store) loadOutbox() error {
+	s.outbox.done = map[int64]bool{}
+	s.outbox.subscribers = map[int]models.EventHandler{}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, ".git", publishedFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		published, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("gitstore: %s: %v", publishedFile, err)
+		}
+		s.outbox.published = published
+	}
+
+	seqs, err := s.outboxSeqs()
+	if err != nil {
+		return err
+	}
+	s.outbox.lastSeq = s.outbox.published
+	if len(seqs) > 0 && seqs[len(seqs)-1] > s.outbox.lastSeq {
+		s.outbox.lastSeq = seqs[len(seqs)-1]
+	}
+	return nil
+}
+
+// outboxSeqs は outbox ディレクトリにあるイベントの番号を小さい順に返します
+func (s *Store) outboxSeqs() ([]int64, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, outboxDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int64
+	for _, entry := range entries {
+		seq, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), ".json"), 10, 64)
+		if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// writeOutbox は event に番号を振って outbox に書き出し、配信済みのイベントのファイルを取り除きます
+// persist のロック中に呼び出し、書き出したファイルはタスクのファイルと同じコミットに含めます
+func (s *Store) writeOutbox(event models.Event) (models.Event, error) {
+	s.outbox.mutex.Lock()
+	s.outbox.lastSeq++
+	event.ID = s.outbox.lastSeq
+	published := s.outbox.published
+	s.outbox.mutex.Unlock()
+
+	seqs, err := s.outboxSeqs()
+	if err != nil {
+		return event, err
+	}
+	for _, seq := range seqs {
+		if seq <= published {
+			if err := os.Remove(filepath.Join(s.dir, outboxFile(seq))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return event, err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return event, err
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, outboxDir), 0755); err != nil {
+		return event, err
+	}
+	return event, os.WriteFile(filepath.Join(s.dir, outboxFile(event.ID)), append(data, '\n'), 0644)
+}
+
+// Subscribe はコミットを終えたタスクの変更イベントを購読し、購読を解除する関数を返します
+// TodoApp の Subscribe と違い、リポジトリに保存できた変更のイベントだけを受け取ります
+func (s *Store) Subscribe(handler models.EventHandler) (unsubscribe func()) {
+	s.outbox.mutex.Lock()
+	defer s.outbox.mutex.Unlock()
+
+	id := s.outbox.nextSubID
+	s.outbox.nextSubID++
+	s.outbox.subscribers[id] = handler
+	return func() {
+		s.outbox.mutex.Lock()
+		defer s.outbox.mutex.Unlock()
+		delete(s.outbox.subscribers, id)
+	}
+}
+
+// publish は購読者にイベントを配信し、配信済みとして記録します
+// 購読者が Store を操作できるよう、ロックを外してから呼び出します
+func (s *Store) publish(event models.Event) {
+	s.outbox.mutex.Lock()
+	handlers := make([]models.EventHandler, 0, len(s.outbox.subscribers))
+	for _, handler := range s.outbox.subscribers {
+		handlers = append(handlers, handler)
+	}
+	s.outbox.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+	if err := s.markPublished(event.ID); err != nil {
+		slog.ErrorContext(event.Context(), "gitstore: failed to record published event", "event_id", event.ID, "err", err)
+	}
+}
+
+// markPublished は seq を配信済みにし、途切れずに配信を終えた番号までを記録します
+// 配信が前後しても、まだ配信していないイベントを配信済みと記録することはありません
+func (s *Store) markPublished(seq int64) error {
+	s.outbox.mutex.Lock()
+	defer s.outbox.mutex.Unlock()
+
+	if seq <= s.outbox.published {
+		return nil
+	}
+	s.outbox.done[seq] = true
+	advanced := false
+	for s.outbox.done[s.outbox.published+1] {
+		delete(s.outbox.done, s.outbox.published+1)
+		s.outbox.published++
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(s.dir, ".git", publishedFile), []byte(strconv.FormatInt(s.outbox.published, 10)+"\n"), 0644)
+}
+
+// Relay は前回の終了までに配信を終えていない outbox のイベントを、番号の順に購読者へ配信し、その件数を返します
+// 起動後、購読者をすべて登録してから一度呼び出します
+func (s *Store) Relay() (int, error) {
+	s.outbox.mutex.Lock()
+	published := s.outbox.published
+	s.outbox.mutex.Unlock()
+
+	seqs, err := s.outboxSeqs()
+	if err != nil {
+		return 0, err
+	}
+	relayed := 0
+	for _, seq := range seqs {
+		if seq <= published {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, outboxFile(seq)))
+		if err != nil {
+			return relayed, err
+		}
+		var event models.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return relayed, fmt.Errorf("gitstore: %s: %v", outboxFile(seq), err)
+		}
+		event.ID = seq
+		s.publish(event)
+		relayed++
+	}
+	return relayed, nil
+}
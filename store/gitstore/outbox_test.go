@@ -0,0 +1,210 @@
+package gitstore
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"todo-app/models"
+)
+
+// recorder は購読者として受け取ったイベントを記録します
+type recorder struct {
+	mutex  sync.Mutex
+	events []models.Event
+}
+
+func (r *recorder) handle(event models.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) ids() []int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ids := []int64{}
+	for _, event := range r.events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func changedFiles(t *testing.T, dir string) string {
+	out, err := exec.Command("git", "-C", dir, "show", "--name-status", "--format=", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git show failed: %v", err)
+	}
+	return strings.Join(strings.Fields(string(out)), " ")
+}
+
+func readPublished(dir string) string {
+	data, _ := os.ReadFile(filepath.Join(dir, ".git", publishedFile))
+	return strings.TrimSpace(string(data))
+}
+
+func openStore(t *testing.T, dir string, options Options) (*Store, *recorder) {
+	t.Helper()
+	store, err := Open(dir, options)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	events := &recorder{}
+	store.Subscribe(events.handle)
+	return store, events
+}
+
+func TestOutboxCommitsEventsWithTasks(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	dir := t.TempDir()
+	store, events := openStore(t, dir, Options{})
+
+	task, _ := store.AddTask(ctx, "Write report")
+	if got := changedFiles(t, dir); got != "A outbox/1.json A tasks/1.json" {
+		t.Errorf("Expected the event to be committed with the task, got %q", got)
+	}
+	store.ToggleTask(ctx, task.ID)
+	if got := changedFiles(t, dir); got != "D outbox/1.json A outbox/2.json M tasks/1.json" {
+		t.Errorf("Expected published events to be removed in the next commit, got %q", got)
+	}
+	if ids := events.ids(); !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("Expected events 1 and 2, got %v", ids)
+	}
+	if got := readPublished(dir); got != "2" {
+		t.Errorf("Expected event 2 to be recorded as published, got %q", got)
+	}
+
+	// 再起動してもイベントの ID は続きます
+	reopened, events := openStore(t, dir, Options{})
+	if n, err := reopened.Relay(); n != 0 || err != nil {
+		t.Errorf("Expected nothing to relay, got %d, %v", n, err)
+	}
+	reopened.DeleteTask(ctx, task.ID)
+	if ids := events.ids(); !reflect.DeepEqual(ids, []int64{3}) {
+		t.Errorf("Expected event 3 after reopening, got %v", ids)
+	}
+}
+
+func TestOutboxRelaysUnpublishedEvents(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	dir := t.TempDir()
+	store, _ := openStore(t, dir, Options{})
+	store.AddTask(ctx, "First")
+	store.AddTask(ctx, "Second")
+
+	// コミットした後、配信を記録する前に止まった場合と同じ状態にします
+	os.WriteFile(filepath.Join(dir, ".git", publishedFile), []byte("1\n"), 0644)
+
+	reopened, events := openStore(t, dir, Options{})
+	n, err := reopened.Relay()
+	if n != 1 || err != nil {
+		t.Fatalf("Expected one event to be relayed, got %d, %v", n, err)
+	}
+	if len(events.events) != 1 || events.events[0].ID != 2 || events.events[0].Task.Title != "Second" || events.events[0].Type != models.EventTaskCreated {
+		t.Errorf("Unexpected relayed events %+v", events.events)
+	}
+	if n, _ := reopened.Relay(); n != 0 {
+		t.Errorf("Expected relayed events not to be relayed again, got %d", n)
+	}
+	if task, _ := reopened.AddTask(ctx, "Third"); task.ID != 3 || !reflect.DeepEqual(events.ids(), []int64{2, 3}) {
+		t.Errorf("Expected numbering to continue after the relayed events, got %v", events.ids())
+	}
+}
+
+func TestOutboxSkipsEventsThatFailToCommit(t *testing.T) {
+	ctx := context.Background()
+	requireGit(t)
+	dir := t.TempDir()
+	store, events := openStore(t, dir, Options{})
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	os.MkdirAll(filepath.Dir(hook), 0755)
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store.AddTask(ctx, "Not committed")
+	if len(events.events) != 0 {
+		t.Fatalf("Expected no event for a change that was not committed, got %+v", events.events)
+	}
+	if _, err := os.Stat(filepath.Join(dir, outboxFile(1))); !os.IsNotExist(err) {
+		t.Error("Expected the outbox file of the failed commit to be removed")
+	}
+
+	os.Remove(hook)
+	store.AddTask(ctx, "Committed")
+	if ids := events.ids(); !reflect.DeepEqual(ids, []int64{2}) || readPublished(dir) != "2" {
+		t.Errorf("Expected the failed event to be skipped, got %v (published %q)", ids, readPublished(dir))
+	}
+}
+
+func TestOutboxPublishesWhenPushFails(t *testing.T) {
+	requireGit(t)
+	store, events := openStore(t, t.TempDir(), Options{Remote: "missing"})
+	store.AddTask(context.Background(), "Local only")
+	if ids := events.ids(); !reflect.DeepEqual(ids, []int64{1}) {
+		t.Errorf("Expected committed changes to be published even if push fails, got %v", ids)
+	}
+}
+
+func TestOutboxMarkPublishedInOrder(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	store, _ := openStore(t, dir, Options{})
+
+	// 後のイベントが先に配信を終えても、前のイベントが終わるまでは記録を進めません
+	store.markPublished(2)
+	if got := readPublished(dir); got != "" {
+		t.Errorf("Expected nothing to be recorded yet, got %q", got)
+	}
+	store.markPublished(1)
+	if got := readPublished(dir); got != "2" {
+		t.Errorf("Expected events up to 2 to be recorded, got %q", got)
+	}
+	store.markPublished(1)
+	if got := readPublished(dir); got != "2" {
+		t.Errorf("Expected an old event not to change the record, got %q", got)
+	}
+}
+
+func TestOutboxErrors(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	store, _ := openStore(t, dir, Options{})
+	store.AddTask(context.Background(), "First")
+
+	os.WriteFile(filepath.Join(dir, ".git", publishedFile), []byte("many\n"), 0644)
+	if _, err := Open(dir, Options{}); err == nil {
+		t.Error("Expected an error for a broken published file")
+	}
+
+	os.WriteFile(filepath.Join(dir, ".git", publishedFile), []byte("0\n"), 0644)
+	os.WriteFile(filepath.Join(dir, outboxFile(1)), []byte("{"), 0644)
+	reopened, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := reopened.Relay(); err == nil {
+		t.Error("Expected an error for a broken outbox file")
+	}
+}
+
+func TestOutboxUnsubscribe(t *testing.T) {
+	requireGit(t)
+	store, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	events := &recorder{}
+	unsubscribe := store.Subscribe(events.handle)
+	unsubscribe()
+	store.AddTask(context.Background(), "Unheard")
+	if len(events.events) != 0 {
+		t.Errorf("Expected no events after unsubscribing, got %+v", events.events)
+	}
+}
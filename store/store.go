@@ -0,0 +1,100 @@
+// Package store はタスクの保存先のドライバを名前で登録し、設定に応じて開きます
+//
+// ドライバはコンパイル時に組み込みます。plugins と同じく、ドライバのパッケージの init で Register を呼び、
+// main からブランクインポートしてください。外部のモジュールが必要な重いドライバは、ビルドタグを付けたファイルで
+// インポートすると、タグを付けずにビルドしたバイナリには含まれません
+//
+//	//go:build postgres
+//
+//	package main
+//
+//	import _ "example.com/todo-drivers/postgres"
+//
+// タグで除いたドライバの代わりには RegisterStub でスタブを登録し、開こうとしたときに除いたタグを示します
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"todo-app/models"
+)
+
+// ErrUnknownDriver は登録されていない（コンパイル時に組み込まれていない）ドライバを開こうとしたことを表します
+var ErrUnknownDriver = errors.New("unknown storage driver")
+
+// ErrDriverExcluded はビルドタグでバイナリから除いたドライバを開こうとしたことを表します
+var ErrDriverExcluded = errors.New("storage driver excluded from this build")
+
+// Config はドライバに渡す設定です
+// DSN: 保存先（ディレクトリや接続文字列など。意味はドライバごとに決まります）
+// Params: ドライバごとの追加の設定（git の remote など）
+// Options: タスクを読み込んで TodoApp を作るときに渡すオプション（件数の上限など）
+type Config struct {
+	DSN     string
+	Params  map[string]string
+	Options []models.Option
+}
+
+// OpenFunc は config の保存先を開くドライバの関数です
+type OpenFunc func(config Config) (models.TaskStore, error)
+
+var (
+	driversMu sync.Mutex
+	drivers   = map[string]OpenFunc{}
+)
+
+func init() {
+	Register("memory", func(config Config) (models.TaskStore, error) {
+		return models.NewTodoApp(config.Options...), nil
+	})
+}
+
+// Register は name のドライバを登録します。ドライバのパッケージの init から呼んでください
+// open が nil の場合や、名前が重複するドライバを登録すると panic します
+func Register(name string, open OpenFunc) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if open == nil {
+		panic("store: Register driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("store: Register called twice for driver " + name)
+	}
+	drivers[name] = open
+}
+
+// RegisterStub は tag のビルドタグで除いたドライバの代わりに、name のスタブを登録します
+// スタブを開こうとすると、除いたタグを含む ErrDriverExcluded を返します
+func RegisterStub(name, tag string) {
+	Register(name, func(Config) (models.TaskStore, error) {
+		return nil, fmt.Errorf("%w: %q (built with -tags %s)", ErrDriverExcluded, name, tag)
+	})
+}
+
+// Drivers は登録済みのドライバの名前を名前の順に返します
+func Drivers() []string {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open は name のドライバで config の保存先を開きます
+// 登録されていないドライバの場合は、組み込まれているドライバの名前を含む ErrUnknownDriver を返します
+func Open(name string, config Config) (models.TaskStore, error) {
+	driversMu.Lock()
+	open, ok := drivers[name]
+	driversMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (compiled in: %s)", ErrUnknownDriver, name, strings.Join(Drivers(), ", "))
+	}
+	return open(config)
+}
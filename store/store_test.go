@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"todo-app/models"
+)
+
+func TestOpenMemory(t *testing.T) {
+	s, err := Open("memory", Config{Options: []models.Option{models.WithMaxTasks(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.AddTask(ctx, "Only")
+	if _, err := s.AddTask(ctx, "Too many"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected the options to be applied, got %v", err)
+	}
+}
+
+func TestOpenUnknownDriver(t *testing.T) {
+	_, err := Open("postgres", Config{DSN: "postgres://localhost/todo"})
+	if !errors.Is(err, ErrUnknownDriver) || !strings.Contains(err.Error(), "compiled in: memory") {
+		t.Errorf("Expected an unknown driver error listing the compiled-in drivers, got %v", err)
+	}
+}
+
+func TestRegisterStub(t *testing.T) {
+	defer func(saved map[string]OpenFunc) { drivers = saved }(drivers)
+	drivers = map[string]OpenFunc{}
+
+	RegisterStub("git", "nogit")
+	_, err := Open("git", Config{DSN: "/var/lib/todo"})
+	if !errors.Is(err, ErrDriverExcluded) || !strings.Contains(err.Error(), "-tags nogit") {
+		t.Errorf("Expected an excluded driver error naming the tag, got %v", err)
+	}
+	if !reflect.DeepEqual(Drivers(), []string{"git"}) {
+		t.Errorf("Expected the stub to be listed, got %v", Drivers())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func(saved map[string]OpenFunc) { drivers = saved }(drivers)
+	drivers = map[string]OpenFunc{}
+
+	var received Config
+	Register("fake", func(config Config) (models.TaskStore, error) {
+		received = config
+		return models.NewTodoApp(), nil
+	})
+	Register("another", func(Config) (models.TaskStore, error) { return nil, errors.New("unreachable") })
+	if got := Drivers(); !reflect.DeepEqual(got, []string{"another", "fake"}) {
+		t.Errorf("Expected the drivers in name order, got %v", got)
+	}
+
+	if _, err := Open("fake", Config{DSN: "somewhere", Params: map[string]string{"a": "b"}}); err != nil || received.DSN != "somewhere" || received.Params["a"] != "b" {
+		t.Errorf("Expected the config to be passed to the driver, got %+v, %v", received, err)
+	}
+	if _, err := Open("another", Config{}); err == nil || err.Error() != "unreachable" {
+		t.Errorf("Expected the driver's error, got %v", err)
+	}
+
+	fake := func(Config) (models.TaskStore, error) { return models.NewTodoApp(), nil }
+	for name, open := range map[string]OpenFunc{"nil": nil, "fake": fake} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Register(%q) to panic", name)
+				}
+			}()
+			Register(name, open)
+		}()
+	}
+}
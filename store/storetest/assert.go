@@ -0,0 +1,153 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// AssertTasks はストアのタスク一覧が want と一致することを確認します
+// 期限は時刻として比較するため、タイムゾーンの表現が違っても同じ時刻なら一致とみなします
+func AssertTasks(t testing.TB, store models.TaskStore, want []models.Task) {
+	t.Helper()
+	got := store.GetTasks(context.Background())
+	if len(got) != len(want) {
+		t.Errorf("expected %d tasks, got %d: %+v", len(want), len(got), got)
+		return
+	}
+	for i := range want {
+		if !EqualTask(got[i], want[i]) {
+			t.Errorf("task %d: expected %s, got %s", i, formatTask(want[i]), formatTask(got[i]))
+		}
+	}
+}
+
+// AssertTitles はストアのタスクのタイトルが順番どおり want と一致することを確認します
+func AssertTitles(t testing.TB, store models.TaskStore, want ...string) {
+	t.Helper()
+	tasks := store.GetTasks(context.Background())
+	got := make([]string, len(tasks))
+	for i, task := range tasks {
+		got[i] = task.Title
+	}
+	if len(want) == 0 {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected titles %q, got %q", want, got)
+	}
+}
+
+// FindTask は指定IDのタスクを返します
+func FindTask(store models.TaskStore, id int) (models.Task, bool) {
+	for _, task := range store.GetTasks(context.Background()) {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	return models.Task{}, false
+}
+
+// EqualTask は2つのタスクが同じ内容かどうかを返します
+// 作成・完了日時は実行した時刻で変わるため比較しません
+func EqualTask(a, b models.Task) bool {
+	if a.ID != b.ID || a.Title != b.Title || a.Completed != b.Completed || a.Priority != b.Priority {
+		return false
+	}
+	if a.EstimateMinutes != b.EstimateMinutes || a.TrackedSeconds != b.TrackedSeconds || len(a.TimeEntries) != len(b.TimeEntries) {
+		return false
+	}
+	for i := range a.TimeEntries {
+		x, y := a.TimeEntries[i], b.TimeEntries[i]
+		if x.ID != y.ID || !x.Start.Equal(y.Start) || !equalTime(x.End, y.End) {
+			return false
+		}
+	}
+	return equalTime(a.DueDate, b.DueDate) && equalTime(a.ScheduledDate, b.ScheduledDate)
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func formatTask(task models.Task) string {
+	return fmt.Sprintf("{ID:%d Title:%q Completed:%t DueDate:%s ScheduledDate:%s Priority:%q EstimateMinutes:%d TimeEntries:%d TrackedSeconds:%d}",
+		task.ID, task.Title, task.Completed, formatTime(task.DueDate), formatTime(task.ScheduledDate), task.Priority,
+		task.EstimateMinutes, len(task.TimeEntries), task.TrackedSeconds)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "none"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Recorder はストアのイベントを記録します
+type Recorder struct {
+	mutex       sync.Mutex
+	events      []models.Event
+	unsubscribe func()
+}
+
+// Record は store のイベントの記録を開始します。記録はテスト終了時に停止します
+func Record(t testing.TB, store models.TaskStore) *Recorder {
+	r := &Recorder{}
+	r.unsubscribe = store.Subscribe(func(event models.Event) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.events = append(r.events, event)
+	})
+	t.Cleanup(r.unsubscribe)
+	return r
+}
+
+// Events は記録したイベントを返します
+func (r *Recorder) Events() []models.Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]models.Event(nil), r.events...)
+}
+
+// Reset は記録したイベントを消去します
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = nil
+}
+
+// AssertEvents は記録したイベントの種類とタスクIDが順番どおり want と一致することを確認します
+func (r *Recorder) AssertEvents(t testing.TB, want ...ExpectedEvent) {
+	t.Helper()
+	events := r.Events()
+	got := make([]ExpectedEvent, len(events))
+	for i, event := range events {
+		got[i] = ExpectedEvent{Type: event.Type, TaskID: event.Task.ID}
+	}
+	if len(want) == 0 {
+		want = []ExpectedEvent{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+}
+
+// ExpectedEvent は AssertEvents で確認するイベントです
+type ExpectedEvent struct {
+	Type   models.EventType
+	TaskID int
+}
+
+// Created / Updated / Deleted / Reminded は ExpectedEvent を作成する短縮形です
+func Created(id int) ExpectedEvent  { return ExpectedEvent{models.EventTaskCreated, id} }
+func Updated(id int) ExpectedEvent  { return ExpectedEvent{models.EventTaskUpdated, id} }
+func Deleted(id int) ExpectedEvent  { return ExpectedEvent{models.EventTaskDeleted, id} }
+func Reminded(id int) ExpectedEvent { return ExpectedEvent{models.EventTaskReminder, id} }
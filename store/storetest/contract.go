@@ -0,0 +1,947 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+// RunContract は models.TaskStore の実装が満たすべき振る舞いをサブテストとして実行します
+// newStore はサブテストごとに空のストアを作成して返します
+// 新しいストアを追加するときは、そのパッケージのテストから呼び出してください
+//
+//	func TestContract(t *testing.T) {
+//		storetest.RunContract(t, func(t *testing.T) models.TaskStore {
+//			return mystore.Open(t.TempDir())
+//		})
+//	}
+func RunContract(t *testing.T, newStore func(t *testing.T) models.TaskStore) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store models.TaskStore)
+	}{
+		{"StartsEmpty", testStartsEmpty},
+		{"AddTaskAssignsSequentialIDs", testAddTask},
+		{"AddTaskWith", testAddTaskWith},
+		{"GetTasksReturnsCopy", testGetTasksReturnsCopy},
+		{"ToggleTask", testToggleTask},
+		{"SetTitle", testSetTitle},
+		{"UpdateTask", testUpdateTask},
+		{"ClaimTask", testClaimTask},
+		{"ClaimTaskIsAtomic", testClaimTaskIsAtomic},
+		{"Timestamps", testTimestamps},
+		{"SetDueDate", testSetDueDate},
+		{"SetScheduledDate", testSetScheduledDate},
+		{"SetPriority", testSetPriority},
+		{"Reminders", testReminders},
+		{"SetEstimate", testSetEstimate},
+		{"SetTimeEntries", testSetTimeEntries},
+		{"Comments", testComments},
+		{"SetBlindIndex", testSetBlindIndex},
+		{"DeleteTask", testDeleteTask},
+		{"IDsAreNotReused", testIDsAreNotReused},
+		{"Trash", testTrash},
+		{"PurgeTrash", testPurgeTrash},
+		{"Undo", testUndo},
+		{"ReplaceTasks", testReplaceTasks},
+		{"ValidationErrors", testValidationErrors},
+		{"ReplaceTasksRejectsDuplicateIDs", testReplaceTasksRejectsDuplicateIDs},
+		{"Events", testEvents},
+		{"EventsCarryContext", testEventsCarryContext},
+		{"Unsubscribe", testUnsubscribe},
+		{"SubscriberCanModifyStore", testSubscriberCanModifyStore},
+		{"Concurrency", testConcurrency},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore(t))
+		})
+	}
+}
+
+func testStartsEmpty(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	if tasks := store.GetTasks(ctx); len(tasks) != 0 {
+		t.Errorf("expected a new store to be empty, got %+v", tasks)
+	}
+}
+
+func testAddTask(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	first, err := store.AddTask(ctx, "First")
+	if err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	second, _ := store.AddTask(ctx, "Second")
+
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+	if first.Title != "First" || first.Completed || first.DueDate != nil {
+		t.Errorf("unexpected new task: %+v", first)
+	}
+	AssertTitles(t, store, "First", "Second")
+}
+
+func testAddTaskWith(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	description, priority, listID := "Details", models.Priority("high"), 3
+	tags, index := []string{"Home", "errand"}, []string{"token"}
+
+	recorder := Record(t, store)
+	task, err := store.AddTaskWith(ctx, "Planned", models.TaskUpdate{
+		Description: &description,
+		DueDate:     &due,
+		Priority:    &priority,
+		ListID:      &listID,
+		Tags:        &tags,
+		BlindIndex:  &index,
+		Recurrence:  &models.Recurrence{Frequency: models.FrequencyWeekly, Interval: 1},
+	})
+	if err != nil {
+		t.Fatalf("AddTaskWith failed: %v", err)
+	}
+	// 作成のイベントは1つだけで、指定した項目がすべて載っていること
+	recorder.AssertEvents(t, Created(task.ID))
+	events := recorder.Events()
+	for _, got := range []models.Task{task, events[0].Task} {
+		if got.Title != "Planned" || got.Description != description || got.DueDate == nil || !got.DueDate.Equal(due) ||
+			got.Priority != priority || got.ListID != listID || !reflect.DeepEqual(got.Tags, []string{"home", "errand"}) ||
+			!reflect.DeepEqual(got.BlindIndex, index) || got.Recurrence == nil || got.CreatedAt == nil {
+			t.Errorf("expected the task to be created with every field, got %+v", got)
+		}
+	}
+
+	// 誤りがあればタスクを作らないこと
+	recorder.Reset()
+	invalid := models.Priority("urgent")
+	if _, err := store.AddTaskWith(ctx, "Broken", models.TaskUpdate{DueDate: &due, Priority: &invalid}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an unknown priority, got %v", err)
+	}
+	if _, err := store.AddTaskWith(ctx, "", models.TaskUpdate{}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an empty title, got %v", err)
+	}
+	recorder.AssertEvents(t)
+	AssertTitles(t, store, "Planned")
+}
+
+func testGetTasksReturnsCopy(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	Seed(store)
+	tasks := store.GetTasks(ctx)
+	tasks[0].Title = "changed"
+	*tasks[1].DueDate = tasks[1].DueDate.AddDate(1, 0, 0)
+
+	AssertTasks(t, store, Fixtures())
+}
+
+func testToggleTask(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Toggle me")
+
+	if err := store.ToggleTask(ctx, task.ID); err != nil {
+		t.Fatalf("expected ToggleTask to find the task: %v", err)
+	}
+	if got, _ := FindTask(store, task.ID); !got.Completed {
+		t.Error("expected task to be completed")
+	}
+	store.ToggleTask(ctx, task.ID)
+	if got, _ := FindTask(store, task.ID); got.Completed {
+		t.Error("expected task to be reopened")
+	}
+	if err := store.ToggleTask(ctx, 999); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected ToggleTask to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testTimestamps(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	before := time.Now().Add(-time.Second)
+	task, _ := store.AddTask(ctx, "Timed")
+	if task.CreatedAt == nil || task.CreatedAt.Before(before) || task.CompletedAt != nil {
+		t.Errorf("expected a creation time and no completion time, got %v and %v", task.CreatedAt, task.CompletedAt)
+	}
+
+	if task.UpdatedAt == nil || !task.UpdatedAt.Equal(*task.CreatedAt) {
+		t.Errorf("expected a new task to be updated when it was created, got %v", task.UpdatedAt)
+	}
+
+	store.ToggleTask(ctx, task.ID)
+	got, _ := FindTask(store, task.ID)
+	if got.CompletedAt == nil || got.CompletedAt.Before(*task.CreatedAt) {
+		t.Errorf("expected a completion time after the creation time, got %v", got.CompletedAt)
+	}
+	if got.UpdatedAt == nil || got.UpdatedAt.Before(*task.CreatedAt) {
+		t.Errorf("expected the update time to move forward, got %v", got.UpdatedAt)
+	}
+
+	store.ToggleTask(ctx, task.ID)
+	if got, _ := FindTask(store, task.ID); got.CompletedAt != nil || got.CreatedAt == nil {
+		t.Errorf("expected the completion time to be cleared and the creation time kept, got %v and %v", got.CompletedAt, got.CreatedAt)
+	}
+
+	// 復元したタスクの日時はそのまま保ちます
+	createdAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(time.Hour)
+	store.ReplaceTasks(ctx, []models.Task{{ID: 1, Title: "Restored", Completed: true, CreatedAt: &createdAt, CompletedAt: &completedAt}})
+	if got, _ := FindTask(store, 1); got.CreatedAt == nil || !got.CreatedAt.Equal(createdAt) || got.CompletedAt == nil || !got.CompletedAt.Equal(completedAt) {
+		t.Errorf("expected restored timestamps to be kept, got %v and %v", got.CreatedAt, got.CompletedAt)
+	}
+}
+
+func testSetDueDate(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Due soon")
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := store.SetDueDate(ctx, task.ID, &due); err != nil {
+		t.Fatalf("expected SetDueDate to find the task: %v", err)
+	}
+	due = due.AddDate(1, 0, 0)
+	got, _ := FindTask(store, task.ID)
+	if got.DueDate == nil || !got.DueDate.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the store to keep its own copy of the due date, got %v", got.DueDate)
+	}
+
+	store.SetDueDate(ctx, task.ID, nil)
+	if got, _ := FindTask(store, task.ID); got.DueDate != nil {
+		t.Errorf("expected due date to be cleared, got %v", got.DueDate)
+	}
+	if err := store.SetDueDate(ctx, 999, &due); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetDueDate to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testSetScheduledDate(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Start tomorrow")
+	scheduled := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	if err := store.SetScheduledDate(ctx, task.ID, &scheduled); err != nil {
+		t.Fatalf("expected SetScheduledDate to find the task: %v", err)
+	}
+	scheduled = scheduled.AddDate(1, 0, 0)
+	got, _ := FindTask(store, task.ID)
+	if got.ScheduledDate == nil || !got.ScheduledDate.Equal(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the store to keep its own copy of the scheduled date, got %v", got.ScheduledDate)
+	}
+	if got.DueDate != nil {
+		t.Errorf("expected the due date to stay unset, got %v", got.DueDate)
+	}
+
+	store.SetScheduledDate(ctx, task.ID, nil)
+	if got, _ := FindTask(store, task.ID); got.ScheduledDate != nil {
+		t.Errorf("expected scheduled date to be cleared, got %v", got.ScheduledDate)
+	}
+	if err := store.SetScheduledDate(ctx, 999, &scheduled); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetScheduledDate to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testReminders(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Call the dentist")
+	remindAt := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	if err := store.SetReminder(ctx, task.ID, &remindAt); err != nil {
+		t.Fatalf("expected SetReminder to find the task: %v", err)
+	}
+	remindAt = remindAt.Add(time.Hour)
+	if got, _ := FindTask(store, task.ID); got.RemindAt == nil || !got.RemindAt.Equal(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)) || got.RemindedAt != nil {
+		t.Errorf("expected the store to keep its own copy of the reminder, got %v %v", got.RemindAt, got.RemindedAt)
+	}
+
+	recorder := Record(t, store)
+	if err := store.FireReminder(ctx, task.ID, time.Date(2025, 6, 2, 8, 59, 0, 0, time.UTC)); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected a reminder before its time to conflict, got %v", err)
+	}
+	firedAt := time.Date(2025, 6, 2, 9, 0, 30, 0, time.UTC)
+	if err := store.FireReminder(ctx, task.ID, firedAt); err != nil {
+		t.Fatalf("expected the due reminder to fire: %v", err)
+	}
+	if err := store.FireReminder(ctx, task.ID, firedAt.Add(time.Minute)); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected a reminder to fire only once, got %v", err)
+	}
+	recorder.AssertEvents(t, Reminded(task.ID))
+	if got, _ := FindTask(store, task.ID); got.RemindedAt == nil || !got.RemindedAt.Equal(firedAt) {
+		t.Errorf("expected the reminder to be marked as fired, got %v", got.RemindedAt)
+	}
+
+	// 設定し直すと、通知済みの印を外してもう一度通知します
+	store.SetReminder(ctx, task.ID, &remindAt)
+	if got, _ := FindTask(store, task.ID); got.RemindedAt != nil || !got.ReminderDue(remindAt) {
+		t.Errorf("expected the snoozed reminder to be due again, got %+v", got)
+	}
+	store.SetReminder(ctx, task.ID, nil)
+	if got, _ := FindTask(store, task.ID); got.RemindAt != nil || got.RemindedAt != nil {
+		t.Errorf("expected the reminder to be cleared, got %v %v", got.RemindAt, got.RemindedAt)
+	}
+	if err := store.SetReminder(ctx, 999, &remindAt); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetReminder to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+	if err := store.FireReminder(ctx, 999, firedAt); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected FireReminder to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testSetEstimate(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Estimated")
+
+	if err := store.SetEstimate(ctx, task.ID, 90); err != nil {
+		t.Fatalf("expected SetEstimate to find the task: %v", err)
+	}
+	if got, _ := FindTask(store, task.ID); got.EstimateMinutes != 90 {
+		t.Errorf("expected an estimate of 90 minutes, got %d", got.EstimateMinutes)
+	}
+	if err := store.SetEstimate(ctx, task.ID, -1); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for a negative estimate, got %v", err)
+	}
+	if err := store.SetEstimate(ctx, 999, 30); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetEstimate to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+
+	store.SetEstimate(ctx, task.ID, 0)
+	if got, _ := FindTask(store, task.ID); got.EstimateMinutes != 0 {
+		t.Errorf("expected the estimate to be cleared, got %d", got.EstimateMinutes)
+	}
+}
+
+func testSetTimeEntries(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Tracked")
+	start := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Minute)
+	entries := []models.TimeEntry{{ID: 1, Start: start, End: &end}, {ID: 2, Start: end.Add(time.Hour)}}
+
+	if err := store.SetTimeEntries(ctx, task.ID, entries); err != nil {
+		t.Fatalf("expected SetTimeEntries to find the task: %v", err)
+	}
+	*entries[0].End = end.Add(time.Hour)
+	got, _ := FindTask(store, task.ID)
+	if len(got.TimeEntries) != 2 || !got.TimeEntries[0].End.Equal(start.Add(90*time.Minute)) || got.TrackedSeconds != 90*60 {
+		t.Errorf("expected the store to keep its own copy and count 5400 seconds, got %+v (%d)", got.TimeEntries, got.TrackedSeconds)
+	}
+
+	end = start.Add(90 * time.Minute)
+	running := []models.TimeEntry{{ID: 1, Start: start}, {ID: 2, Start: end}}
+	if err := store.SetTimeEntries(ctx, task.ID, running); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for two running timers, got %v", err)
+	}
+	backwards := []models.TimeEntry{{ID: 1, Start: end, End: &start}}
+	if err := store.SetTimeEntries(ctx, task.ID, backwards); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an entry that ends before it starts, got %v", err)
+	}
+	if err := store.SetTimeEntries(ctx, 999, nil); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetTimeEntries to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+
+	store.SetTimeEntries(ctx, task.ID, nil)
+	if got, _ := FindTask(store, task.ID); len(got.TimeEntries) != 0 || got.TrackedSeconds != 0 {
+		t.Errorf("expected time entries to be cleared, got %+v", got)
+	}
+}
+
+func testComments(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Discussed")
+
+	recorder := Record(t, store)
+	first, err := store.AddComment(ctx, task.ID, "  alice ", "Looks **good**")
+	if err != nil {
+		t.Fatalf("expected AddComment to find the task: %v", err)
+	}
+	if first.ID != 1 || first.Author != "alice" || first.Body != "Looks **good**" || first.CreatedAt.IsZero() {
+		t.Errorf("expected comment 1 by alice with a creation time, got %+v", first)
+	}
+	second, _ := store.AddComment(ctx, task.ID, "", "Second")
+	recorder.AssertEvents(t, Updated(task.ID), Updated(task.ID))
+	got, _ := FindTask(store, task.ID)
+	if len(got.Comments) != 2 || got.Comments[1].ID != 2 || got.Comments[1].Author != "" {
+		t.Fatalf("expected two comments in order, got %+v", got.Comments)
+	}
+	got.Comments[0].Body = "changed"
+	if again, _ := FindTask(store, task.ID); again.Comments[0].Body != "Looks **good**" {
+		t.Errorf("expected the store to keep its own copy of the comments, got %+v", again.Comments)
+	}
+
+	if _, err := store.AddComment(ctx, task.ID, "", "  "); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an empty comment, got %v", err)
+	}
+	if _, err := store.AddComment(ctx, task.ID, "", strings.Repeat("a", models.MaxCommentLength+1)); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for a too long comment, got %v", err)
+	}
+	if _, err := store.AddComment(ctx, 999, "", "Hello"); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected AddComment to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+
+	if err := store.DeleteComment(ctx, task.ID, first.ID); err != nil {
+		t.Fatalf("expected DeleteComment to find the comment: %v", err)
+	}
+	if err := store.DeleteComment(ctx, task.ID, first.ID); !errors.Is(err, models.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound for a deleted comment, got %v", err)
+	}
+	if err := store.DeleteComment(ctx, 999, 1); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected DeleteComment to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+	// 削除したコメントの ID は使い回しません
+	third, _ := store.AddComment(ctx, task.ID, "", "Third")
+	if got, _ := FindTask(store, task.ID); third.ID != 3 || len(got.Comments) != 2 || got.Comments[0].ID != second.ID {
+		t.Errorf("expected comments 2 and 3 to remain, got %+v", got.Comments)
+	}
+}
+
+func testSetBlindIndex(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "e2e:v1:AAAA")
+
+	tokens := []string{"0123456789abcdef0123456789abcdef"}
+	if err := store.SetBlindIndex(ctx, task.ID, tokens); err != nil {
+		t.Fatalf("expected SetBlindIndex to find the task: %v", err)
+	}
+	tokens[0] = "changed"
+	got, _ := FindTask(store, task.ID)
+	if len(got.BlindIndex) != 1 || got.BlindIndex[0] != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("expected the stored index to be a copy, got %v", got.BlindIndex)
+	}
+	got.BlindIndex[0] = "modified"
+	if again, _ := FindTask(store, task.ID); again.BlindIndex[0] == "modified" {
+		t.Error("expected GetTasks to return a copy of the index")
+	}
+	if err := store.SetBlindIndex(ctx, 999, tokens); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetBlindIndex to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+
+	store.SetBlindIndex(ctx, task.ID, nil)
+	if got, _ := FindTask(store, task.ID); got.BlindIndex != nil {
+		t.Errorf("expected the index to be cleared, got %v", got.BlindIndex)
+	}
+}
+
+func testSetTitle(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Draft")
+
+	if err := store.SetTitle(ctx, task.ID, "Final"); err != nil {
+		t.Fatalf("expected SetTitle to find the task: %v", err)
+	}
+	if got, _ := FindTask(store, task.ID); got.Title != "Final" {
+		t.Errorf("expected title Final, got %q", got.Title)
+	}
+	if err := store.SetTitle(ctx, task.ID, ""); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected SetTitle to reject an empty title, got %v", err)
+	}
+	if err := store.SetTitle(ctx, 999, "Missing"); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetTitle to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testClaimTask(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Shared queue item")
+
+	if err := store.ClaimTask(ctx, task.ID, "alice"); err != nil {
+		t.Fatalf("expected ClaimTask to succeed: %v", err)
+	}
+	got, _ := FindTask(store, task.ID)
+	if got.ClaimedBy != "alice" || got.ClaimedAt == nil || !got.InProgress() {
+		t.Fatalf("expected the task to be claimed by alice, got %+v", got)
+	}
+	claimedAt := *got.ClaimedAt
+	if err := store.ClaimTask(ctx, task.ID, "alice"); err != nil {
+		t.Errorf("expected claiming again by the same claimant to succeed, got %v", err)
+	}
+	if again, _ := FindTask(store, task.ID); !again.ClaimedAt.Equal(claimedAt) {
+		t.Errorf("expected the claim time to stay %v, got %v", claimedAt, again.ClaimedAt)
+	}
+	if err := store.ClaimTask(ctx, task.ID, "bob"); !errors.Is(err, models.ErrAlreadyClaimed) || !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected ErrAlreadyClaimed for another claimant, got %v", err)
+	}
+	if err := store.ReleaseTask(ctx, task.ID, "bob"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected ReleaseTask by another claimant to return ErrConflict, got %v", err)
+	}
+	if err := store.ReleaseTask(ctx, task.ID, "alice"); err != nil {
+		t.Fatalf("expected ReleaseTask to succeed: %v", err)
+	}
+	if got, _ := FindTask(store, task.ID); got.ClaimedBy != "" || got.ClaimedAt != nil || got.InProgress() {
+		t.Errorf("expected the claim to be released, got %+v", got)
+	}
+	if err := store.ReleaseTask(ctx, task.ID, "alice"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected releasing an unclaimed task to return ErrConflict, got %v", err)
+	}
+
+	if err := store.ClaimTask(ctx, task.ID, ""); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ClaimTask to reject an empty claimant, got %v", err)
+	}
+	if err := store.ReleaseTask(ctx, task.ID, ""); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ReleaseTask to reject an empty claimant, got %v", err)
+	}
+	if err := store.ClaimTask(ctx, 999, "alice"); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected ClaimTask to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+	if err := store.ReleaseTask(ctx, 999, "alice"); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected ReleaseTask to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+
+	store.ToggleTask(ctx, task.ID)
+	if err := store.ClaimTask(ctx, task.ID, "alice"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected claiming a completed task to return ErrConflict, got %v", err)
+	}
+}
+
+func testClaimTaskIsAtomic(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Only one worker")
+
+	const workers = 8
+	results := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			results <- store.ClaimTask(ctx, task.ID, fmt.Sprintf("worker-%d", i))
+		}(i)
+	}
+	succeeded := 0
+	for i := 0; i < workers; i++ {
+		err := <-results
+		if err == nil {
+			succeeded++
+		} else if !errors.Is(err, models.ErrAlreadyClaimed) {
+			t.Errorf("expected ErrAlreadyClaimed for the losers, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one claim to succeed, got %d", succeeded)
+	}
+}
+
+func testSetPriority(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Important")
+
+	if err := store.SetPriority(ctx, task.ID, models.PriorityHigh); err != nil {
+		t.Fatalf("expected SetPriority to find the task: %v", err)
+	}
+	if got, _ := FindTask(store, task.ID); got.Priority != models.PriorityHigh {
+		t.Errorf("expected priority high, got %q", got.Priority)
+	}
+	store.SetPriority(ctx, task.ID, "")
+	if got, _ := FindTask(store, task.ID); got.Priority != "" {
+		t.Errorf("expected priority to be cleared, got %q", got.Priority)
+	}
+	if err := store.SetPriority(ctx, 999, models.PriorityLow); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected SetPriority to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testUpdateTask(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	task, _ := store.AddTask(ctx, "Draft")
+	store.SetEstimate(ctx, task.ID, 30)
+
+	title, priority := "Final", models.PriorityHigh
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var events []models.Event
+	var mutex sync.Mutex
+	unsubscribe := store.Subscribe(func(event models.Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	})
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{Title: &title, DueDate: &due, Priority: &priority}); err != nil {
+		t.Fatalf("expected UpdateTask to find the task: %v", err)
+	}
+	unsubscribe()
+	got, _ := FindTask(store, task.ID)
+	if got.Title != "Final" || got.DueDate == nil || !got.DueDate.Equal(due) || got.Priority != models.PriorityHigh || got.EstimateMinutes != 30 {
+		t.Errorf("expected the given fields to change and the others to stay, got %+v", got)
+	}
+	mutex.Lock()
+	if len(events) != 1 || events[0].Type != models.EventTaskUpdated {
+		t.Errorf("expected a single update event, got %+v", events)
+	}
+	mutex.Unlock()
+
+	// 指定しない項目は変わらず、ClearDueDate で期限を外せます
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{ClearDueDate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FindTask(store, task.ID); got.DueDate != nil || got.Title != "Final" || got.Priority != models.PriorityHigh {
+		t.Errorf("expected only the due date to be cleared, got %+v", got)
+	}
+
+	// リストに入れ、0 でリストから外せます
+	list, none, empty := 3, 0, ""
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{ListID: &list}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FindTask(store, task.ID); got.ListID != 3 || got.Title != "Final" {
+		t.Errorf("expected the task to move to list 3, got %+v", got)
+	}
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{ListID: &none})
+	if got, _ := FindTask(store, task.ID); got.ListID != 0 {
+		t.Errorf("expected the task to leave its list, got %+v", got)
+	}
+
+	// 親タスクを付け、0 で外せます。自分自身は親にできません
+	parent, _ := store.AddTask(ctx, "Parent")
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{ParentID: &parent.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FindTask(store, task.ID); got.ParentID != parent.ID {
+		t.Errorf("expected the task to become a subtask of %d, got %+v", parent.ID, got)
+	}
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{ParentID: &task.ID}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected a task not to become its own parent, got %v", err)
+	}
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{ParentID: &none})
+	if got, _ := FindTask(store, task.ID); got.ParentID != 0 {
+		t.Errorf("expected the task to leave its parent, got %+v", got)
+	}
+
+	// 説明を付け、空文字で外せます
+	description := "- [ ] call the **landlord**"
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FindTask(store, task.ID); got.Description != description || got.Title != "Final" {
+		t.Errorf("expected the description to be set, got %+v", got)
+	}
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{Description: &empty})
+	if got, _ := FindTask(store, task.ID); got.Description != "" {
+		t.Errorf("expected the description to be removed, got %+v", got)
+	}
+
+	// タグは整えた名前で重複なく加え、外せます。Tags で並びごと置き換えます
+	if err := store.UpdateTask(ctx, task.ID, models.TaskUpdate{AddTags: []string{"#Shopping", "work", "shopping"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := FindTask(store, task.ID); !reflect.DeepEqual(got.Tags, []string{"shopping", "work"}) {
+		t.Errorf("expected tags [shopping work], got %+v", got.Tags)
+	}
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{RemoveTags: []string{"Shopping"}})
+	if got, _ := FindTask(store, task.ID); !reflect.DeepEqual(got.Tags, []string{"work"}) {
+		t.Errorf("expected tags [work] after removing shopping, got %+v", got.Tags)
+	}
+	noTags := []string{}
+	store.UpdateTask(ctx, task.ID, models.TaskUpdate{Tags: &noTags})
+	if got, _ := FindTask(store, task.ID); got.Tags != nil {
+		t.Errorf("expected all tags to be removed, got %+v", got.Tags)
+	}
+
+	tooMany := make([]string, models.MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+	unknown, negative := models.Priority("urgent"), -1
+	tooLong := strings.Repeat("あ", models.MaxDescriptionLength+1)
+	for _, update := range []models.TaskUpdate{{Title: &empty}, {Title: &title, Priority: &unknown}, {ListID: &negative}, {AddTags: []string{"two words"}}, {AddTags: tooMany}, {Description: &tooLong}} {
+		if err := store.UpdateTask(ctx, task.ID, update); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("expected ErrValidation for %+v, got %v", update, err)
+		}
+	}
+	if err := store.UpdateTask(ctx, 999, models.TaskUpdate{Title: &title}); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected UpdateTask to return ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+func testDeleteTask(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	store.AddTask(ctx, "Keep")
+	task, _ := store.AddTask(ctx, "Delete")
+	store.AddTask(ctx, "Keep too")
+
+	if err := store.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("expected DeleteTask to find the task: %v", err)
+	}
+	AssertTitles(t, store, "Keep", "Keep too")
+	if err := store.DeleteTask(ctx, task.ID); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected DeleteTask to return ErrTaskNotFound for an already deleted task, got %v", err)
+	}
+}
+
+func testIDsAreNotReused(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	store.AddTask(ctx, "First")
+	second, _ := store.AddTask(ctx, "Second")
+	store.DeleteTask(ctx, second.ID)
+
+	if third, _ := store.AddTask(ctx, "Third"); third.ID != 3 {
+		t.Errorf("expected ID 3 after deleting the last task, got %d", third.ID)
+	}
+}
+
+func testTrash(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	store.AddTask(ctx, "Keep")
+	task, _ := store.AddTask(ctx, "Trash me")
+	recorder := Record(t, store)
+
+	store.DeleteTask(ctx, task.ID)
+	trash := store.GetTrash(ctx)
+	if len(trash) != 1 || trash[0].ID != task.ID || trash[0].Title != "Trash me" || trash[0].DeletedAt == nil {
+		t.Fatalf("expected the deleted task in the trash with deleted_at, got %+v", trash)
+	}
+	if events := recorder.Events(); len(events) != 1 || events[0].Task.DeletedAt == nil {
+		t.Errorf("expected the delete event to carry deleted_at, got %+v", events)
+	}
+
+	restored, err := store.RestoreTask(ctx, task.ID)
+	if err != nil || restored.ID != task.ID || restored.DeletedAt != nil {
+		t.Fatalf("expected the task to be restored with the same ID, got %+v %v", restored, err)
+	}
+	AssertTitles(t, store, "Keep", "Trash me")
+	if trash := store.GetTrash(ctx); len(trash) != 0 {
+		t.Errorf("expected the trash to be empty after restoring, got %+v", trash)
+	}
+	recorder.AssertEvents(t, Deleted(task.ID), Created(task.ID))
+
+	if _, err := store.RestoreTask(ctx, task.ID); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound for a task not in the trash, got %v", err)
+	}
+}
+
+func testPurgeTrash(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	first, _ := store.AddTask(ctx, "First")
+	second, _ := store.AddTask(ctx, "Second")
+	store.DeleteTask(ctx, first.ID)
+	cutoff := time.Now().Add(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	store.DeleteTask(ctx, second.ID)
+	recorder := Record(t, store)
+
+	purged, err := store.PurgeTrash(ctx, cutoff)
+	if err != nil || len(purged) != 1 || purged[0].ID != first.ID {
+		t.Fatalf("expected only the older task to be purged, got %+v %v", purged, err)
+	}
+	if trash := store.GetTrash(ctx); len(trash) != 1 || trash[0].ID != second.ID {
+		t.Errorf("expected the newer task to stay in the trash, got %+v", trash)
+	}
+	if _, err := store.RestoreTask(ctx, first.ID); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected a purged task to be gone for good, got %v", err)
+	}
+	recorder.AssertEvents(t)
+
+	// 完全に削除したタスクの ID も使い直しません
+	if third, _ := store.AddTask(ctx, "Third"); third.ID != 3 {
+		t.Errorf("expected ID 3 after purging, got %d", third.ID)
+	}
+}
+
+func testUndo(t *testing.T, store models.TaskStore) {
+	// request はセッション "alice" の新しいリクエストのコンテキストです
+	request := func() context.Context { return models.WithSession(context.Background(), "alice") }
+	if _, _, err := store.Undo(request()); !errors.Is(err, models.ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo before any operation, got %v", err)
+	}
+
+	ctx := request()
+	task, _ := store.AddTask(ctx, "Report")
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	store.SetDueDate(ctx, task.ID, &due)
+	store.ToggleTask(request(), task.ID)
+	store.SetTitle(request(), task.ID, "Final report")
+	other, _ := store.AddTask(context.Background(), "Not journaled")
+	store.DeleteTask(request(), other.ID)
+
+	if _, _, err := store.Undo(models.WithSession(context.Background(), "bob")); !errors.Is(err, models.ErrNothingToUndo) {
+		t.Errorf("expected another session to have nothing to undo, got %v", err)
+	}
+
+	op, restored, err := store.Undo(request())
+	if err != nil || op.Kind != models.OperationDelete || op.TaskID != other.ID || restored.DeletedAt != nil {
+		t.Fatalf("expected the delete to be undone first, got %+v %+v %v", op, restored, err)
+	}
+	if op, task, err := store.Undo(request()); err != nil || op.Kind != models.OperationEdit || task.Title != "Report" || !task.Completed {
+		t.Errorf("expected the title edit to be undone, got %+v %+v %v", op, task, err)
+	}
+	if op, task, err := store.Undo(request()); err != nil || op.Kind != models.OperationToggle || task.Completed || task.DueDate == nil {
+		t.Errorf("expected the toggle to be undone, got %+v %+v %v", op, task, err)
+	}
+
+	// 追加と同じリクエストで付けた期限は、追加と一緒に取り消します
+	recorder := Record(t, store)
+	op, trashed, err := store.Undo(request())
+	if err != nil || op.Kind != models.OperationAdd || trashed.ID != task.ID || trashed.DeletedAt == nil {
+		t.Fatalf("expected the add to be undone by moving the task to the trash, got %+v %+v %v", op, trashed, err)
+	}
+	recorder.AssertEvents(t, Deleted(task.ID))
+	AssertTitles(t, store, "Not journaled")
+	if _, _, err := store.Undo(request()); !errors.Is(err, models.ErrNothingToUndo) {
+		t.Errorf("expected nothing left to undo, got %v", err)
+	}
+}
+
+func testReplaceTasks(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	store.AddTask(ctx, "Old")
+	fixtures := Seed(store)
+	AssertTasks(t, store, fixtures)
+
+	// フィクスチャの最大ID（5）の次から採番されます
+	if task, _ := store.AddTask(ctx, "Next"); task.ID != 6 {
+		t.Errorf("expected ID 6 after ReplaceTasks, got %d", task.ID)
+	}
+
+	store.ReplaceTasks(ctx, nil)
+	AssertTitles(t, store)
+}
+
+func testValidationErrors(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	recorder := Record(t, store)
+
+	if _, err := store.AddTask(ctx, ""); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an empty title, got %v", err)
+	}
+	task, _ := store.AddTask(ctx, "Valid")
+	if err := store.SetPriority(ctx, task.ID, "urgent"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for an unknown priority, got %v", err)
+	}
+
+	// 失敗した操作はタスクを変更せず、イベントも配信しません
+	AssertTasks(t, store, []models.Task{{ID: 1, Title: "Valid"}})
+	recorder.AssertEvents(t, Created(1))
+}
+
+func testReplaceTasksRejectsDuplicateIDs(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	store.AddTask(ctx, "Kept")
+
+	err := store.ReplaceTasks(ctx, []models.Task{{ID: 1, Title: "A"}, {ID: 1, Title: "B"}})
+	if !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected ErrConflict for duplicate IDs, got %v", err)
+	}
+	if err := store.ReplaceTasks(ctx, []models.Task{{ID: 0, Title: "No ID"}}); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected ErrValidation for a missing ID, got %v", err)
+	}
+	AssertTitles(t, store, "Kept")
+}
+
+func testEvents(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	recorder := Record(t, store)
+
+	task, _ := store.AddTask(ctx, "Evented")
+	store.ToggleTask(ctx, task.ID)
+	store.SetDueDate(ctx, task.ID, nil)
+	store.DeleteTask(ctx, task.ID)
+	store.ToggleTask(ctx, task.ID)
+	store.ReplaceTasks(ctx, []models.Task{{ID: 7, Title: "Restored"}})
+
+	recorder.AssertEvents(t, Created(1), Updated(1), Updated(1), Deleted(1), Created(7))
+
+	events := recorder.Events()
+	for i := 1; i < len(events); i++ {
+		if events[i].ID <= events[i-1].ID {
+			t.Errorf("expected increasing event IDs, got %d after %d", events[i].ID, events[i-1].ID)
+		}
+	}
+	if events[1].Task.Completed != true || events[3].Task.Title != "Evented" {
+		t.Errorf("expected events to carry the changed task, got %+v", events)
+	}
+}
+
+// contextKey は EventsCarryContext で操作のコンテキストに載せる値のキーです
+type contextKey struct{}
+
+func testEventsCarryContext(t *testing.T, store models.TaskStore) {
+	recorder := Record(t, store)
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "request-1")
+	task, _ := store.AddTask(ctx, "Traced")
+	store.ToggleTask(ctx, task.ID)
+	store.SetDueDate(ctx, task.ID, nil)
+	store.SetPriority(ctx, task.ID, models.PriorityHigh)
+	store.DeleteTask(ctx, task.ID)
+	store.ReplaceTasks(ctx, []models.Task{{ID: 1, Title: "Restored"}})
+
+	events := recorder.Events()
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if got := event.Context().Value(contextKey{}); got != "request-1" {
+			t.Errorf("expected %s event to carry the caller's context, got value %v", event.Type, got)
+		}
+	}
+}
+
+func testUnsubscribe(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	received := 0
+	unsubscribe := store.Subscribe(func(models.Event) { received++ })
+
+	store.AddTask(ctx, "First")
+	unsubscribe()
+	store.AddTask(ctx, "Second")
+
+	if received != 1 {
+		t.Errorf("expected 1 event before unsubscribing, got %d", received)
+	}
+}
+
+func testSubscriberCanModifyStore(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	// 購読者の中からストアを操作してもデッドロックしないこと
+	store.Subscribe(func(event models.Event) {
+		if event.Type == models.EventTaskCreated && event.Task.Title == "Trigger" {
+			store.ToggleTask(ctx, event.Task.ID)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		store.AddTask(ctx, "Trigger")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("store deadlocked when a subscriber modified it")
+	}
+
+	if task, _ := FindTask(store, 1); !task.Completed {
+		t.Error("expected the subscriber's change to be applied")
+	}
+}
+
+func testConcurrency(t *testing.T, store models.TaskStore) {
+	ctx := context.Background()
+	const workers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, _ := store.AddTask(ctx, "Concurrent")
+			store.ToggleTask(ctx, task.ID)
+			store.GetTasks(ctx)
+		}()
+	}
+	wg.Wait()
+
+	tasks := store.GetTasks(ctx)
+	if len(tasks) != workers {
+		t.Fatalf("expected %d tasks, got %d", workers, len(tasks))
+	}
+	seen := make(map[int]bool)
+	for _, task := range tasks {
+		if seen[task.ID] {
+			t.Errorf("duplicate task ID %d", task.ID)
+		}
+		seen[task.ID] = true
+		if !task.Completed {
+			t.Errorf("expected task %d to be completed", task.ID)
+		}
+	}
+}
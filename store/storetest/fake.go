@@ -0,0 +1,552 @@
+// Package storetest は models.TaskStore を使うコードのテスト用ヘルパーです
+// メモリ上のフェイクストア、共通のフィクスチャ、アサーション、
+// およびすべてのストア実装が満たすべき契約テスト（RunContract）を提供します
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// Fake は models.TaskStore のメモリ上の実装です
+// TodoApp とは独立した最小限の実装で、呼び出されたメソッドを Calls で確認できます
+type Fake struct {
+	mutex       sync.Mutex
+	tasks       []models.Task
+	trash       []models.Task
+	nextID      int
+	lastEventID int64
+	calls       []string
+	journal     models.Journal
+
+	subscribers map[int]models.EventHandler
+	nextSubID   int
+}
+
+var _ models.TaskStore = (*Fake)(nil)
+
+// NewFake は tasks を初期状態とする Fake を作成します
+func NewFake(tasks ...models.Task) *Fake {
+	f := &Fake{subscribers: make(map[int]models.EventHandler)}
+	f.load(tasks)
+	return f
+}
+
+// load はタスク一覧を置き換えて次のIDを求めます。ロック中に呼び出します
+func (f *Fake) load(tasks []models.Task) {
+	f.tasks = make([]models.Task, 0, len(tasks))
+	f.nextID = 1
+	for _, task := range tasks {
+		f.tasks = append(f.tasks, copyTask(task))
+		if task.ID >= f.nextID {
+			f.nextID = task.ID + 1
+		}
+	}
+}
+
+// Calls はこれまでに呼び出されたメソッドを "AddTask(title)" のような形式で返します
+func (f *Fake) Calls() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *Fake) AddTask(ctx context.Context, title string) (models.Task, error) {
+	return f.add(ctx, fmt.Sprintf("AddTask(%s)", title), title, models.TaskUpdate{})
+}
+
+func (f *Fake) AddTaskWith(ctx context.Context, title string, update models.TaskUpdate) (models.Task, error) {
+	return f.add(ctx, fmt.Sprintf("AddTaskWith(%s)", title), title, update)
+}
+
+// add は update の項目を付けたタスクを作成し、call を呼び出しとして記録します
+func (f *Fake) add(ctx context.Context, call, title string, update models.TaskUpdate) (models.Task, error) {
+	f.mutex.Lock()
+	f.calls = append(f.calls, call)
+	if title == "" {
+		f.mutex.Unlock()
+		return models.Task{}, fmt.Errorf("%w: title is required", models.ErrValidation)
+	}
+	update.Title = nil
+	if err := update.Validate(); err != nil {
+		f.mutex.Unlock()
+		return models.Task{}, err
+	}
+	createdAt := time.Now()
+	task := models.Task{ID: f.nextID, Title: title, CreatedAt: &createdAt, UpdatedAt: &createdAt}
+	if err := update.Apply(&task); err != nil {
+		f.mutex.Unlock()
+		return models.Task{}, err
+	}
+	f.nextID++
+	f.tasks = append(f.tasks, task)
+	f.journal.Record(ctx, models.Operation{Kind: models.OperationAdd, TaskID: task.ID, Time: createdAt, After: createdAt})
+	event := f.newEvent(ctx, models.EventTaskCreated, copyTask(task))
+	f.mutex.Unlock()
+
+	f.publish(event)
+	return copyTask(task), nil
+}
+
+func (f *Fake) GetTasks(ctx context.Context) []models.Task {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, "GetTasks()")
+
+	tasks := make([]models.Task, len(f.tasks))
+	for i, task := range f.tasks {
+		tasks[i] = copyTask(task)
+	}
+	return tasks
+}
+
+func (f *Fake) ToggleTask(ctx context.Context, id int) error {
+	now := time.Now()
+	return f.update(ctx, models.OperationToggle, fmt.Sprintf("ToggleTask(%d)", id), id, func(task *models.Task) {
+		task.Completed = !task.Completed
+		task.CompletedAt = nil
+		if task.Completed {
+			task.CompletedAt = &now
+		}
+	})
+}
+
+func (f *Fake) SetTitle(ctx context.Context, id int, title string) error {
+	if title == "" {
+		return fmt.Errorf("%w: title is required", models.ErrValidation)
+	}
+	return f.update(ctx, models.OperationEdit, fmt.Sprintf("SetTitle(%d, %s)", id, title), id, func(task *models.Task) {
+		task.Title = title
+	})
+}
+
+func (f *Fake) UpdateTask(ctx context.Context, id int, update models.TaskUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	return f.updateIf(ctx, models.OperationEdit, fmt.Sprintf("UpdateTask(%d)", id), id, update.Apply)
+}
+
+func (f *Fake) ClaimTask(ctx context.Context, id int, claimant string) error {
+	if claimant == "" {
+		return fmt.Errorf("%w: claimant is required", models.ErrValidation)
+	}
+	now := time.Now()
+	return f.updateIf(ctx, "", fmt.Sprintf("ClaimTask(%d, %s)", id, claimant), id, func(task *models.Task) error {
+		if task.Completed {
+			return fmt.Errorf("%w: task is completed", models.ErrConflict)
+		}
+		if task.ClaimedBy != "" && task.ClaimedBy != claimant {
+			return fmt.Errorf("%w: claimed by %s", models.ErrAlreadyClaimed, task.ClaimedBy)
+		}
+		if task.ClaimedBy != claimant {
+			task.ClaimedBy = claimant
+			task.ClaimedAt = &now
+		}
+		return nil
+	})
+}
+
+func (f *Fake) ReleaseTask(ctx context.Context, id int, claimant string) error {
+	if claimant == "" {
+		return fmt.Errorf("%w: claimant is required", models.ErrValidation)
+	}
+	return f.updateIf(ctx, "", fmt.Sprintf("ReleaseTask(%d, %s)", id, claimant), id, func(task *models.Task) error {
+		if task.ClaimedBy != claimant {
+			return fmt.Errorf("%w: not claimed by %s", models.ErrConflict, claimant)
+		}
+		task.ClaimedBy = ""
+		task.ClaimedAt = nil
+		return nil
+	})
+}
+
+func (f *Fake) SetDueDate(ctx context.Context, id int, due *time.Time) error {
+	call := fmt.Sprintf("SetDueDate(%d, nil)", id)
+	if due != nil {
+		call = fmt.Sprintf("SetDueDate(%d, %s)", id, due.Format(time.RFC3339))
+	}
+	return f.update(ctx, models.OperationEdit, call, id, func(task *models.Task) {
+		task.DueDate = copyTime(due)
+	})
+}
+
+func (f *Fake) SetScheduledDate(ctx context.Context, id int, scheduled *time.Time) error {
+	call := fmt.Sprintf("SetScheduledDate(%d, nil)", id)
+	if scheduled != nil {
+		call = fmt.Sprintf("SetScheduledDate(%d, %s)", id, scheduled.Format(time.RFC3339))
+	}
+	return f.update(ctx, models.OperationEdit, call, id, func(task *models.Task) {
+		task.ScheduledDate = copyTime(scheduled)
+	})
+}
+
+func (f *Fake) SetPriority(ctx context.Context, id int, priority models.Priority) error {
+	if err := priority.Validate(); err != nil {
+		return err
+	}
+	return f.update(ctx, models.OperationEdit, fmt.Sprintf("SetPriority(%d, %s)", id, priority), id, func(task *models.Task) {
+		task.Priority = priority
+	})
+}
+
+func (f *Fake) SetEstimate(ctx context.Context, id int, minutes int) error {
+	if minutes < 0 {
+		return fmt.Errorf("%w: negative estimate", models.ErrValidation)
+	}
+	return f.update(ctx, models.OperationEdit, fmt.Sprintf("SetEstimate(%d, %d)", id, minutes), id, func(task *models.Task) {
+		task.EstimateMinutes = minutes
+	})
+}
+
+func (f *Fake) SetBlindIndex(ctx context.Context, id int, tokens []string) error {
+	tokens = append([]string(nil), tokens...)
+	return f.update(ctx, "", fmt.Sprintf("SetBlindIndex(%d, %d tokens)", id, len(tokens)), id, func(task *models.Task) {
+		if len(tokens) == 0 {
+			tokens = nil
+		}
+		task.BlindIndex = tokens
+	})
+}
+
+func (f *Fake) SetReminder(ctx context.Context, id int, remindAt *time.Time) error {
+	call := fmt.Sprintf("SetReminder(%d, nil)", id)
+	if remindAt != nil {
+		call = fmt.Sprintf("SetReminder(%d, %s)", id, remindAt.Format(time.RFC3339))
+	}
+	return f.update(ctx, "", call, id, func(task *models.Task) {
+		task.RemindAt = copyTime(remindAt)
+		task.RemindedAt = nil
+	})
+}
+
+func (f *Fake) FireReminder(ctx context.Context, id int, now time.Time) error {
+	return f.change(ctx, "", models.EventTaskReminder, fmt.Sprintf("FireReminder(%d)", id), id, func(task *models.Task) error {
+		if !task.ReminderDue(now) {
+			return fmt.Errorf("%w: reminder of %d is not due", models.ErrConflict, id)
+		}
+		task.RemindedAt = &now
+		return nil
+	})
+}
+
+func (f *Fake) AddComment(ctx context.Context, id int, author, body string) (models.Comment, error) {
+	var added models.Comment
+	err := f.updateIf(ctx, "", fmt.Sprintf("AddComment(%d, %q)", id, author), id, func(task *models.Task) error {
+		comments, comment, err := models.AppendComment(task.Comments, author, body, time.Now())
+		if err != nil {
+			return err
+		}
+		task.Comments, added = comments, comment
+		return nil
+	})
+	return added, err
+}
+
+func (f *Fake) DeleteComment(ctx context.Context, id, commentID int) error {
+	return f.updateIf(ctx, "", fmt.Sprintf("DeleteComment(%d, %d)", id, commentID), id, func(task *models.Task) error {
+		comments, err := models.RemoveComment(task.Comments, commentID)
+		if err != nil {
+			return err
+		}
+		task.Comments = comments
+		return nil
+	})
+}
+
+func (f *Fake) SetTimeEntries(ctx context.Context, id int, entries []models.TimeEntry) error {
+	for i, entry := range entries {
+		if entry.ID <= 0 || entry.Start.IsZero() || (entry.End != nil && entry.End.Before(entry.Start)) {
+			return fmt.Errorf("%w: invalid time entry %d", models.ErrValidation, entry.ID)
+		}
+		for _, other := range entries[:i] {
+			if other.ID == entry.ID || (other.End == nil && entry.End == nil) {
+				return fmt.Errorf("%w: conflicting time entry %d", models.ErrValidation, entry.ID)
+			}
+		}
+	}
+	copied := copyTask(models.Task{TimeEntries: entries}).TimeEntries
+	return f.update(ctx, "", fmt.Sprintf("SetTimeEntries(%d, %d)", id, len(entries)), id, func(task *models.Task) {
+		task.TimeEntries = copied
+		task.TrackedSeconds = models.TrackedSeconds(copied)
+	})
+}
+
+func (f *Fake) update(ctx context.Context, kind models.OperationKind, call string, id int, update func(task *models.Task)) error {
+	return f.updateIf(ctx, kind, call, id, func(task *models.Task) error {
+		update(task)
+		return nil
+	})
+}
+
+// updateIf は update がエラーを返したらタスクを書き換えずにそのエラーを返します
+func (f *Fake) updateIf(ctx context.Context, kind models.OperationKind, call string, id int, update func(task *models.Task) error) error {
+	return f.change(ctx, kind, models.EventTaskUpdated, call, id, update)
+}
+
+// change は updateIf と同じですが、配信するイベントの種類を eventType にします
+func (f *Fake) change(ctx context.Context, kind models.OperationKind, eventType models.EventType, call string, id int, update func(task *models.Task) error) error {
+	now := time.Now()
+	f.mutex.Lock()
+	f.calls = append(f.calls, call)
+	for i := range f.tasks {
+		if f.tasks[i].ID == id {
+			task := copyTask(f.tasks[i])
+			if err := update(&task); err != nil {
+				f.mutex.Unlock()
+				return err
+			}
+			before := copyTask(f.tasks[i])
+			f.tasks[i] = task
+			f.tasks[i].UpdatedAt = &now
+			f.journal.Record(ctx, models.Operation{Kind: kind, TaskID: id, Time: now, Before: &before, After: now})
+			event := f.newEvent(ctx, eventType, copyTask(f.tasks[i]))
+			f.mutex.Unlock()
+
+			f.publish(event)
+			return nil
+		}
+	}
+	f.mutex.Unlock()
+	return fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+}
+
+func (f *Fake) DeleteTask(ctx context.Context, id int) error {
+	now := time.Now()
+	f.mutex.Lock()
+	f.calls = append(f.calls, fmt.Sprintf("DeleteTask(%d)", id))
+	for i, task := range f.tasks {
+		if task.ID == id {
+			before := copyTask(task)
+			f.tasks = append(f.tasks[:i], f.tasks[i+1:]...)
+			task.DeletedAt = &now
+			f.trash = append(f.trash, task)
+			f.journal.Record(ctx, models.Operation{Kind: models.OperationDelete, TaskID: id, Time: now, Before: &before, After: now})
+			event := f.newEvent(ctx, models.EventTaskDeleted, copyTask(task))
+			f.mutex.Unlock()
+
+			f.publish(event)
+			return nil
+		}
+	}
+	f.mutex.Unlock()
+	return fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+}
+
+func (f *Fake) GetTrash(ctx context.Context) []models.Task {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, "GetTrash()")
+
+	trash := make([]models.Task, len(f.trash))
+	for i, task := range f.trash {
+		trash[i] = copyTask(task)
+	}
+	return trash
+}
+
+// RestoreTask はごみ箱のタスクを一覧の末尾に戻します（TodoApp と違い、ID の順には並べ直しません）
+func (f *Fake) RestoreTask(ctx context.Context, id int) (models.Task, error) {
+	f.mutex.Lock()
+	f.calls = append(f.calls, fmt.Sprintf("RestoreTask(%d)", id))
+	for i, task := range f.trash {
+		if task.ID == id {
+			f.trash = append(f.trash[:i], f.trash[i+1:]...)
+			task.DeletedAt = nil
+			f.tasks = append(f.tasks, task)
+			event := f.newEvent(ctx, models.EventTaskCreated, copyTask(task))
+			f.mutex.Unlock()
+
+			f.publish(event)
+			return copyTask(task), nil
+		}
+	}
+	f.mutex.Unlock()
+	return models.Task{}, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+}
+
+func (f *Fake) PurgeTrash(ctx context.Context, before time.Time) ([]models.Task, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf("PurgeTrash(%s)", before.Format(time.RFC3339)))
+
+	var purged []models.Task
+	kept := f.trash[:0]
+	for _, task := range f.trash {
+		if task.DeletedAt.Before(before) {
+			purged = append(purged, copyTask(task))
+		} else {
+			kept = append(kept, task)
+		}
+	}
+	f.trash = kept
+	return purged, nil
+}
+
+// Undo はセッションの最後の操作を取り消します
+// TodoApp と違い、操作の後にタスクが変更されていないかは確かめません。削除の取り消しは RestoreTask と同じく末尾に戻します
+func (f *Fake) Undo(ctx context.Context) (models.Operation, models.Task, error) {
+	now := time.Now()
+	op, err := f.journal.Pop(ctx, now)
+	if err != nil {
+		return models.Operation{}, models.Task{}, err
+	}
+
+	f.mutex.Lock()
+	f.calls = append(f.calls, "Undo()")
+	var task models.Task
+	var event models.Event
+	switch op.Kind {
+	case models.OperationDelete:
+		for i, trashed := range f.trash {
+			if trashed.ID == op.TaskID {
+				f.trash = append(f.trash[:i], f.trash[i+1:]...)
+				task = trashed
+				task.DeletedAt = nil
+				f.tasks = append(f.tasks, task)
+				event = f.newEvent(ctx, models.EventTaskCreated, copyTask(task))
+			}
+		}
+	case models.OperationAdd:
+		for i, added := range f.tasks {
+			if added.ID == op.TaskID {
+				f.tasks = append(f.tasks[:i], f.tasks[i+1:]...)
+				task = added
+				task.DeletedAt = &now
+				f.trash = append(f.trash, task)
+				event = f.newEvent(ctx, models.EventTaskDeleted, copyTask(task))
+			}
+		}
+	default:
+		for i := range f.tasks {
+			if f.tasks[i].ID == op.TaskID {
+				task = copyTask(*op.Before)
+				task.UpdatedAt = &now
+				f.tasks[i] = task
+				event = f.newEvent(ctx, models.EventTaskUpdated, copyTask(task))
+			}
+		}
+	}
+	f.mutex.Unlock()
+
+	if task.ID == 0 {
+		return models.Operation{}, models.Task{}, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, op.TaskID)
+	}
+	f.publish(event)
+	return op, copyTask(task), nil
+}
+
+func (f *Fake) ReplaceTasks(ctx context.Context, tasks []models.Task) error {
+	f.mutex.Lock()
+	f.calls = append(f.calls, fmt.Sprintf("ReplaceTasks(%d)", len(tasks)))
+	seen := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		if task.ID <= 0 || task.Title == "" {
+			f.mutex.Unlock()
+			return fmt.Errorf("%w: invalid task %d", models.ErrValidation, task.ID)
+		}
+		if seen[task.ID] {
+			f.mutex.Unlock()
+			return fmt.Errorf("%w: duplicate id %d", models.ErrConflict, task.ID)
+		}
+		seen[task.ID] = true
+	}
+	events := make([]models.Event, 0, len(f.tasks)+len(tasks))
+	for _, task := range f.tasks {
+		events = append(events, f.newEvent(ctx, models.EventTaskDeleted, task))
+	}
+	f.load(tasks)
+	for _, task := range f.tasks {
+		events = append(events, f.newEvent(ctx, models.EventTaskCreated, copyTask(task)))
+	}
+	f.mutex.Unlock()
+
+	for _, event := range events {
+		f.publish(event)
+	}
+	return nil
+}
+
+func (f *Fake) Subscribe(handler models.EventHandler) (unsubscribe func()) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id := f.nextSubID
+	f.nextSubID++
+	f.subscribers[id] = handler
+	return func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(f.subscribers, id)
+	}
+}
+
+// newEvent は番号を振ったイベントを作成します。ロック中に呼び出します
+func (f *Fake) newEvent(ctx context.Context, eventType models.EventType, task models.Task) models.Event {
+	f.lastEventID++
+	return models.NewEvent(ctx, f.lastEventID, eventType, task)
+}
+
+// publish は購読者にイベントを配信します。購読者がストアを操作できるようロックの外で呼び出します
+func (f *Fake) publish(event models.Event) {
+	f.mutex.Lock()
+	handlers := make([]models.EventHandler, 0, len(f.subscribers))
+	for _, handler := range f.subscribers {
+		handlers = append(handlers, handler)
+	}
+	f.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func copyTask(task models.Task) models.Task {
+	task.DueDate = copyTime(task.DueDate)
+	task.ScheduledDate = copyTime(task.ScheduledDate)
+	task.CreatedAt = copyTime(task.CreatedAt)
+	task.CompletedAt = copyTime(task.CompletedAt)
+	task.UpdatedAt = copyTime(task.UpdatedAt)
+	if task.TimeEntries != nil {
+		entries := make([]models.TimeEntry, len(task.TimeEntries))
+		for i, entry := range task.TimeEntries {
+			entry.End = copyTime(entry.End)
+			entries[i] = entry
+		}
+		task.TimeEntries = entries
+	}
+	task.ClaimedAt = copyTime(task.ClaimedAt)
+	task.RemindAt = copyTime(task.RemindAt)
+	if task.Comments != nil {
+		task.Comments = append([]models.Comment(nil), task.Comments...)
+	}
+	task.RemindedAt = copyTime(task.RemindedAt)
+	task.DeletedAt = copyTime(task.DeletedAt)
+	if task.Tags != nil {
+		task.Tags = append([]string(nil), task.Tags...)
+	}
+	if task.BlindIndex != nil {
+		task.BlindIndex = append([]string(nil), task.BlindIndex...)
+	}
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		task.Recurrence = &recurrence
+	}
+	if task.Progress != nil {
+		progress := *task.Progress
+		task.Progress = &progress
+	}
+	return task
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,114 @@
+package storetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestFakeContract(t *testing.T) {
+	RunContract(t, func(t *testing.T) models.TaskStore {
+		return NewFake()
+	})
+}
+
+func TestFakeCalls(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake(Fixtures()...)
+	due := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	fake.AddTask(ctx, "New")
+	fake.ToggleTask(ctx, 1)
+	fake.SetDueDate(ctx, 2, &due)
+	fake.SetDueDate(ctx, 2, nil)
+	fake.DeleteTask(ctx, 3)
+	fake.GetTasks(ctx)
+
+	expected := "AddTask(New)|ToggleTask(1)|SetDueDate(2, 2025-05-01T00:00:00Z)|SetDueDate(2, nil)|DeleteTask(3)|GetTasks()"
+	if got := strings.Join(fake.Calls(), "|"); got != expected {
+		t.Errorf("Unexpected calls: %s", got)
+	}
+}
+
+func TestNewFakeWithTasks(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake(Fixtures()...)
+	AssertTasks(t, fake, Fixtures())
+
+	if task, _ := fake.AddTask(ctx, "Next"); task.ID != 6 {
+		t.Errorf("Expected next ID 6, got %d", task.ID)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	tasks := Fixtures()
+	if len(tasks) != 4 || tasks[1].DueDate == nil || !tasks[2].Completed {
+		t.Errorf("Unexpected fixtures: %+v", tasks)
+	}
+
+	// 呼び出しごとに独立したコピーを返します
+	tasks[0].Title = "changed"
+	if Fixtures()[0].Title == "changed" {
+		t.Error("Expected Fixtures to return a fresh copy")
+	}
+}
+
+func TestEqualTask(t *testing.T) {
+	due := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	sameInstant := due.In(time.FixedZone("JST", 9*60*60))
+	other := due.Add(time.Hour)
+
+	tests := []struct {
+		a, b  models.Task
+		equal bool
+	}{
+		{models.Task{ID: 1, Title: "a"}, models.Task{ID: 1, Title: "a"}, true},
+		{models.Task{ID: 1, Title: "a"}, models.Task{ID: 1, Title: "b"}, false},
+		{models.Task{ID: 1, DueDate: &due}, models.Task{ID: 1, DueDate: &sameInstant}, true},
+		{models.Task{ID: 1, DueDate: &due}, models.Task{ID: 1, DueDate: &other}, false},
+		{models.Task{ID: 1, DueDate: &due}, models.Task{ID: 1}, false},
+	}
+	for i, tt := range tests {
+		if got := EqualTask(tt.a, tt.b); got != tt.equal {
+			t.Errorf("case %d: expected %v, got %v", i, tt.equal, got)
+		}
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddTask(ctx, "Only")
+	recorder := Record(t, fake)
+	fake.ToggleTask(ctx, 1)
+
+	// 失敗を記録するだけの testing.TB で、アサーションが差分を検出することを確認します
+	checks := []func(tb testing.TB){
+		func(tb testing.TB) { AssertTitles(tb, fake, "Other") },
+		func(tb testing.TB) { AssertTasks(tb, fake, nil) },
+		func(tb testing.TB) { AssertTasks(tb, fake, []models.Task{{ID: 1, Title: "Only"}}) },
+		func(tb testing.TB) { recorder.AssertEvents(tb, Deleted(1)) },
+	}
+	for i, check := range checks {
+		recording := &failRecorder{TB: t}
+		check(recording)
+		if !recording.failed {
+			t.Errorf("check %d: expected assertion to fail", i)
+		}
+	}
+
+	recorder.Reset()
+	recorder.AssertEvents(t)
+}
+
+// failRecorder は失敗を記録するだけの testing.TB です
+type failRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failRecorder) Helper()                       {}
+func (r *failRecorder) Errorf(string, ...interface{}) { r.failed = true }
@@ -0,0 +1,65 @@
+package storetest
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-app/models"
+)
+
+//go:embed testdata/tasks.json
+var fixtureJSON []byte
+
+// update が指定されると Golden は期待値のファイルを書き換えます（go test ./handlers -update など）
+var update = flag.Bool("update", false, "update golden files")
+
+// Fixtures は共通のフィクスチャとなるタスク一覧を返します
+// 未完了・完了済み・期限付き・日本語のタイトルを含み、ID は連番ではありません（4 は欠番）
+func Fixtures() []models.Task {
+	var tasks []models.Task
+	if err := json.Unmarshal(fixtureJSON, &tasks); err != nil {
+		panic("storetest: invalid fixture: " + err.Error())
+	}
+	return tasks
+}
+
+// Seed はストアの内容をフィクスチャで置き換え、そのタスク一覧を返します
+// フィクスチャは正しいデータなので、置き換えに失敗した場合はストアの不具合として panic します
+func Seed(store models.TaskStore) []models.Task {
+	tasks := Fixtures()
+	if err := store.ReplaceTasks(context.Background(), tasks); err != nil {
+		panic("storetest: failed to seed fixtures: " + err.Error())
+	}
+	return tasks
+}
+
+// Golden は got を testdata/<name>.golden の内容と比較します
+// -update を付けて実行すると、ファイルを got の内容で作成・更新します
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match golden file\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
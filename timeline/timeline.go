@@ -0,0 +1,338 @@
+// Package timeline はタスクを予定日から期限までの棒に並べた、ガントチャート向けのタイムラインを作ります
+//
+// タスク同士の依存関係（あるタスクが終わってから取りかかるタスク）を保持し、
+// クリティカルパス法で余裕のない（遅れると全体の終わりが遅れる）タスクの並びを求めます
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// maxDependencies は1件のタスクが依存できるタスクの最大数です
+const maxDependencies = 50
+
+// Timeline はガントチャートに描くタスクの棒と、クリティカルパスです
+// Start / End: すべての棒の最初の日と最後の日（YYYY-MM-DD、棒がなければ空）
+// Bars: 依存するタスクが先に来る順（同じなら開始日・ID の順）に並べた棒
+// CriticalPath: 最も長い依存の連なりのタスクの ID を、取りかかる順に並べたもの
+type Timeline struct {
+	Start        string `json:"start,omitempty"`
+	End          string `json:"end,omitempty"`
+	Bars         []Bar  `json:"bars"`
+	CriticalPath []int  `json:"critical_path"`
+}
+
+// Bar はタイムラインの1件のタスクの棒です
+// Start / End: 予定日と期限（YYYY-MM-DD）。片方しかなければその日だけの棒にします
+// Days: 棒の日数（Start と End を含みます）
+// DependsOn: 先に終える必要があるタスクの ID（タイムラインにあるものだけ）
+// SlackDays: 全体の終わりを遅らせずに遅れてよい日数。0 ならクリティカルパス上のタスクです
+type Bar struct {
+	TaskID    int    `json:"task_id"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Days      int    `json:"days"`
+	DependsOn []int  `json:"depends_on"`
+	SlackDays int    `json:"slack_days"`
+	Critical  bool   `json:"critical"`
+}
+
+// Store はタスクの依存関係を保持します
+type Store struct {
+	mutex sync.Mutex
+	// dependsOn はタスクの ID ごとに、先に終える必要があるタスクの ID を昇順に持ちます
+	dependsOn map[int][]int
+}
+
+// NewStore は依存関係のない Store を作成します
+func NewStore() *Store {
+	return &Store{dependsOn: map[int][]int{}}
+}
+
+// DependsOn は taskID のタスクが依存するタスクの ID を返します
+func (s *Store) DependsOn(taskID int) []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]int{}, s.dependsOn[taskID]...)
+}
+
+// SetDependencies は taskID のタスクが依存するタスクを dependsOn に置き換え、重複を除いて昇順にしたものを返します
+// tasks は今あるタスクで、ないタスクを指定すると ErrTaskNotFound、依存が循環すると ErrConflict を返します
+func (s *Store) SetDependencies(taskID int, dependsOn []int, tasks []models.Task) ([]int, error) {
+	if len(dependsOn) > maxDependencies {
+		return nil, fmt.Errorf("%w: a task can depend on at most %d tasks", models.ErrValidation, maxDependencies)
+	}
+	exists := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		exists[task.ID] = true
+	}
+	if !exists[taskID] {
+		return nil, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, taskID)
+	}
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, id := range dependsOn {
+		if id == taskID {
+			return nil, fmt.Errorf("%w: a task cannot depend on itself", models.ErrValidation)
+		}
+		if !exists[id] {
+			return nil, fmt.Errorf("%w: id %d", models.ErrTaskNotFound, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(exists)
+	for _, id := range ids {
+		if s.reaches(id, taskID) {
+			return nil, fmt.Errorf("%w: task %d already depends on task %d", models.ErrConflict, id, taskID)
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.dependsOn, taskID)
+	} else {
+		s.dependsOn[taskID] = ids
+	}
+	return append([]int{}, ids...), nil
+}
+
+// reaches は from のタスクが依存をたどって to のタスクに依存しているか（from == to を含む）を返します
+func (s *Store) reaches(from, to int) bool {
+	visited := map[int]bool{}
+	stack := []int{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, s.dependsOn[id]...)
+	}
+	return false
+}
+
+// prune は削除されたタスクの依存関係を忘れます
+func (s *Store) prune(exists map[int]bool) {
+	for id, ids := range s.dependsOn {
+		if !exists[id] {
+			delete(s.dependsOn, id)
+			continue
+		}
+		kept := ids[:0]
+		for _, dep := range ids {
+			if exists[dep] {
+				kept = append(kept, dep)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.dependsOn, id)
+		} else {
+			s.dependsOn[id] = kept
+		}
+	}
+}
+
+// Timeline は tasks のうち予定日か期限のあるタスクを棒にしたタイムラインを作ります
+// 予定日か期限のないタスクと、それへの依存はタイムラインに含めません
+// 期限が予定日より前なら、予定日だけの棒にします
+func (s *Store) Timeline(tasks []models.Task) Timeline {
+	return s.timeline(tasks, func(models.Task) bool { return true })
+}
+
+// ListTimeline は tasks のうちリスト listID に入っているタスクだけのタイムラインを作ります
+// ほかのリストのタスクへの依存はタイムラインに含めません（依存関係そのものは残します）
+func (s *Store) ListTimeline(tasks []models.Task, listID int) Timeline {
+	return s.timeline(tasks, func(task models.Task) bool { return task.ListID == listID })
+}
+
+// timeline はすべてのタスク tasks のうち include が true のタスクでタイムラインを作ります
+// 削除されたタスクの依存関係は tasks をもとに忘れるため、tasks にはすべてのタスクを渡します
+func (s *Store) timeline(tasks []models.Task, include func(models.Task) bool) Timeline {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exists := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		exists[task.ID] = true
+	}
+	s.prune(exists)
+
+	bars := map[int]*Bar{}
+	starts := map[int]time.Time{}
+	var ids []int
+	for _, task := range tasks {
+		if !include(task) {
+			continue
+		}
+		start, end, ok := span(task)
+		if !ok {
+			continue
+		}
+		bars[task.ID] = &Bar{
+			TaskID:    task.ID,
+			Title:     task.Title,
+			Completed: task.Completed,
+			Start:     start.Format("2006-01-02"),
+			End:       end.Format("2006-01-02"),
+			Days:      int(end.Sub(start).Hours()/24) + 1,
+			DependsOn: []int{},
+		}
+		starts[task.ID] = start
+		ids = append(ids, task.ID)
+	}
+	for _, id := range ids {
+		for _, dep := range s.dependsOn[id] {
+			if bars[dep] != nil {
+				bars[id].DependsOn = append(bars[id].DependsOn, dep)
+			}
+		}
+	}
+
+	order := topologicalOrder(ids, bars, starts)
+	timeline := Timeline{Bars: make([]Bar, 0, len(order)), CriticalPath: criticalPath(order, bars)}
+	for _, id := range order {
+		bar := *bars[id]
+		timeline.Bars = append(timeline.Bars, bar)
+		if timeline.Start == "" || bar.Start < timeline.Start {
+			timeline.Start = bar.Start
+		}
+		if bar.End > timeline.End {
+			timeline.End = bar.End
+		}
+	}
+	return timeline
+}
+
+// span は task の棒の最初の日と最後の日を返します。予定日も期限もなければ ok が false です
+func span(task models.Task) (start, end time.Time, ok bool) {
+	switch {
+	case task.ScheduledDate != nil && task.DueDate != nil:
+		start, end = dateOf(*task.ScheduledDate), dateOf(*task.DueDate)
+		if end.Before(start) {
+			end = start
+		}
+	case task.ScheduledDate != nil:
+		start = dateOf(*task.ScheduledDate)
+		end = start
+	case task.DueDate != nil:
+		start = dateOf(*task.DueDate)
+		end = start
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// dateOf は t の保存されているタイムゾーンでの年月日を、UTC の 0 時として返します
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// topologicalOrder は依存するタスクが先に来るように ids を並べます。並べられる中では開始日・ID の順にします
+func topologicalOrder(ids []int, bars map[int]*Bar, starts map[int]time.Time) []int {
+	remaining := map[int]int{}
+	dependents := map[int][]int{}
+	for _, id := range ids {
+		remaining[id] = len(bars[id].DependsOn)
+		for _, dep := range bars[id].DependsOn {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+	var ready, order []int
+	for _, id := range ids {
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			if !starts[ready[i]].Equal(starts[ready[j]]) {
+				return starts[ready[i]].Before(starts[ready[j]])
+			}
+			return ready[i] < ready[j]
+		})
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, next := range dependents[id] {
+			remaining[next]--
+			if remaining[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	return order
+}
+
+// criticalPath はクリティカルパス法で各棒の余裕の日数を求め、最も長い依存の連なりを返します
+// order は topologicalOrder で並べた ID です
+func criticalPath(order []int, bars map[int]*Bar) []int {
+	earliestStart := map[int]int{}
+	earliestFinish := map[int]int{}
+	finish := 0
+	for _, id := range order {
+		for _, dep := range bars[id].DependsOn {
+			if earliestFinish[dep] > earliestStart[id] {
+				earliestStart[id] = earliestFinish[dep]
+			}
+		}
+		earliestFinish[id] = earliestStart[id] + bars[id].Days
+		if earliestFinish[id] > finish {
+			finish = earliestFinish[id]
+		}
+	}
+
+	latestFinish := map[int]int{}
+	for _, id := range order {
+		latestFinish[id] = finish
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		latestStart := latestFinish[id] - bars[id].Days
+		bars[id].SlackDays = latestStart - earliestStart[id]
+		bars[id].Critical = bars[id].SlackDays == 0
+		for _, dep := range bars[id].DependsOn {
+			if latestStart < latestFinish[dep] {
+				latestFinish[dep] = latestStart
+			}
+		}
+	}
+
+	// 全体の終わりに最初に届いた棒から、余裕のない依存を ID の小さい順にさかのぼります
+	path := []int{}
+	current := 0
+	for _, id := range order {
+		if earliestFinish[id] == finish {
+			current = id
+			break
+		}
+	}
+	for current != 0 {
+		path = append([]int{current}, path...)
+		next := 0
+		for _, dep := range bars[current].DependsOn {
+			if bars[dep].Critical && earliestFinish[dep] == earliestStart[current] {
+				next = dep
+				break
+			}
+		}
+		current = next
+	}
+	return path
+}
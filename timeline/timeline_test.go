@@ -0,0 +1,137 @@
+package timeline
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func barIDs(bars []Bar) []int {
+	ids := []int{}
+	for _, bar := range bars {
+		ids = append(ids, bar.TaskID)
+	}
+	return ids
+}
+
+func TestSetDependencies(t *testing.T) {
+	s := NewStore()
+	tasks := []models.Task{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	got, err := s.SetDependencies(3, []int{2, 1, 2}, tasks)
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("expected sorted unique dependencies, got %v, %v", got, err)
+	}
+	if got := s.DependsOn(3); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("unexpected DependsOn %v", got)
+	}
+
+	if _, err := s.SetDependencies(1, []int{3}, tasks); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("expected a cycle to be rejected, got %v", err)
+	}
+	if _, err := s.SetDependencies(1, []int{1}, tasks); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected a self dependency to be rejected, got %v", err)
+	}
+	if _, err := s.SetDependencies(1, []int{9}, tasks); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected an unknown dependency to be rejected, got %v", err)
+	}
+	if _, err := s.SetDependencies(9, nil, tasks); !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("expected an unknown task to be rejected, got %v", err)
+	}
+	if _, err := s.SetDependencies(1, make([]int, maxDependencies+1), tasks); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected too many dependencies to be rejected, got %v", err)
+	}
+
+	// 削除されたタスクの依存関係は忘れるため、循環にはなりません
+	if _, err := s.SetDependencies(1, []int{2}, tasks[:2]); err != nil {
+		t.Fatalf("SetDependencies failed: %v", err)
+	}
+	if got := s.DependsOn(3); len(got) != 0 {
+		t.Errorf("expected the deleted task's dependencies to be forgotten, got %v", got)
+	}
+	if got, _ := s.SetDependencies(1, nil, tasks); len(got) != 0 || len(s.DependsOn(1)) != 0 {
+		t.Errorf("expected dependencies to be cleared, got %v", s.DependsOn(1))
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	s := NewStore()
+	tasks := []models.Task{
+		{ID: 1, Title: "Design", ScheduledDate: date("2025-03-03"), DueDate: date("2025-03-05")},
+		{ID: 2, Title: "Build API", ScheduledDate: date("2025-03-06"), DueDate: date("2025-03-12")},
+		{ID: 3, Title: "Write docs", ScheduledDate: date("2025-03-01"), DueDate: date("2025-03-02")},
+		{ID: 4, Title: "Release", DueDate: date("2025-03-14")},
+		{ID: 5, Title: "Someday"},
+		{ID: 6, Title: "Backwards", ScheduledDate: date("2025-03-20"), DueDate: date("2025-03-10"), Completed: true},
+	}
+	s.SetDependencies(2, []int{1}, tasks)
+	s.SetDependencies(4, []int{2, 3, 5}, tasks)
+
+	got := s.Timeline(tasks)
+	if got.Start != "2025-03-01" || got.End != "2025-03-20" {
+		t.Errorf("unexpected range %s to %s", got.Start, got.End)
+	}
+	if ids := barIDs(got.Bars); !reflect.DeepEqual(ids, []int{3, 1, 2, 4, 6}) {
+		t.Errorf("expected dependencies before dependents, got %v", ids)
+	}
+	if !reflect.DeepEqual(got.CriticalPath, []int{1, 2, 4}) {
+		t.Errorf("unexpected critical path %v", got.CriticalPath)
+	}
+
+	bars := map[int]Bar{}
+	for _, bar := range got.Bars {
+		bars[bar.TaskID] = bar
+	}
+	if bar := bars[2]; bar.Days != 7 || bar.Start != "2025-03-06" || bar.End != "2025-03-12" || !bar.Critical {
+		t.Errorf("unexpected bar %+v", bar)
+	}
+	if bar := bars[4]; !reflect.DeepEqual(bar.DependsOn, []int{2, 3}) || bar.Days != 1 {
+		t.Errorf("expected dependencies on tasks without dates to be dropped, got %+v", bar)
+	}
+	// 公開の前までに設計と API で 3+7 = 10 日かかり、ドキュメントは 2 日なので 8 日の余裕があります
+	if bar := bars[3]; bar.SlackDays != 8 || bar.Critical {
+		t.Errorf("unexpected slack for docs %+v", bar)
+	}
+	if bar := bars[6]; bar.Days != 1 || bar.End != "2025-03-20" || bar.SlackDays != 10 || !bar.Completed {
+		t.Errorf("expected a one-day bar on the scheduled date, got %+v", bar)
+	}
+}
+
+func TestTimelineEmpty(t *testing.T) {
+	got := NewStore().Timeline([]models.Task{{ID: 1}})
+	if got.Start != "" || len(got.Bars) != 0 || len(got.CriticalPath) != 0 || got.Bars == nil || got.CriticalPath == nil {
+		t.Errorf("expected an empty timeline, got %+v", got)
+	}
+}
+
+func TestListTimeline(t *testing.T) {
+	s := NewStore()
+	tasks := []models.Task{
+		{ID: 1, Title: "Design", ListID: 1, ScheduledDate: date("2025-03-03"), DueDate: date("2025-03-05")},
+		{ID: 2, Title: "Build API", ListID: 1, ScheduledDate: date("2025-03-06"), DueDate: date("2025-03-12")},
+		{ID: 3, Title: "Buy milk", ListID: 2, DueDate: date("2025-03-04")},
+	}
+	s.SetDependencies(2, []int{1, 3}, tasks)
+
+	got := s.ListTimeline(tasks, 1)
+	if ids := barIDs(got.Bars); !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("expected only the tasks in the list, got %v", ids)
+	}
+	if !reflect.DeepEqual(got.Bars[1].DependsOn, []int{1}) {
+		t.Errorf("expected dependencies on other lists to be dropped, got %v", got.Bars[1].DependsOn)
+	}
+	if got := s.DependsOn(2); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("expected the dependencies to be kept, got %v", got)
+	}
+}
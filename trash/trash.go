@@ -0,0 +1,40 @@
+// Package trash はごみ箱に入れたタスクのうち、保持する期間を過ぎたものを定期的に完全に削除します
+// ごみ箱そのものは models.TaskStore が持ちます（DeleteTask・GetTrash・RestoreTask・PurgeTrash）
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"todo-app/models"
+)
+
+// DefaultRetention はごみ箱のタスクを保持する既定の期間です
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Purge は store のごみ箱から、now より retention 以上前に削除したタスクを完全に削除し、その件数を返します
+func Purge(ctx context.Context, store models.TaskStore, retention time.Duration, now time.Time) (int, error) {
+	purged, err := store.PurgeTrash(ctx, now.Add(-retention))
+	return len(purged), err
+}
+
+// Run は ctx がキャンセルされるまで interval ごとに Purge を呼び出します。最初の1回は起動してすぐに行います
+func Run(ctx context.Context, store models.TaskStore, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := Purge(ctx, store, retention, time.Now()); err != nil {
+			slog.Error("trash: failed to purge", "err", err)
+		} else if n > 0 {
+			slog.Info("trash: purged expired tasks", "tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	deletedAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := models.NewTodoApp(models.WithClock(func() time.Time { return deletedAt }))
+	task, _ := store.AddTask(ctx, "Old")
+	store.DeleteTask(ctx, task.ID)
+
+	if n, err := Purge(ctx, store, DefaultRetention, deletedAt.Add(DefaultRetention-time.Second)); err != nil || n != 0 {
+		t.Errorf("Expected nothing to be purged before the retention has passed, got %d %v", n, err)
+	}
+	if n, err := Purge(ctx, store, DefaultRetention, deletedAt.Add(DefaultRetention+time.Second)); err != nil || n != 1 {
+		t.Errorf("Expected the task to be purged after the retention, got %d %v", n, err)
+	}
+	if trash := store.GetTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", trash)
+	}
+}
+
+func TestRunPurgesImmediately(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := models.NewTodoApp()
+	task, _ := store.AddTask(ctx, "Trash")
+	store.DeleteTask(ctx, task.ID)
+
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, store, time.Nanosecond, time.Hour)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(store.GetTrash(ctx)) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if trash := store.GetTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected Run to purge on start, got %+v", trash)
+	}
+}
@@ -0,0 +1,311 @@
+package tsgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// object は OpenAPI の文書の JSON オブジェクトです。json.Marshal がキーを並べ替えるので、生成する文書は毎回同じになります
+type object = map[string]interface{}
+
+// errorSchema はすべての API が失敗したときに返すエンベロープ（{"success": false, "error": {...}}）のスキーマです
+var errorSchema = object{
+	"type":     "object",
+	"required": []string{"success", "error"},
+	"properties": object{
+		"success": object{"type": "boolean", "enum": []bool{false}},
+		"error": object{
+			"type":     "object",
+			"required": []string{"code", "message"},
+			"properties": object{
+				"code":    object{"type": "string", "description": "エラーの種類（not_found・invalid・unprocessable・unauthorized・conflict・internal など）"},
+				"message": object{"type": "string", "description": "利用者に見せるメッセージ（Accept-Language の言語）"},
+				"detail":  object{"type": "string", "description": "原因の詳しい説明（英語。想定外のエラーでは返しません）"},
+				"fields": object{
+					"type":        "array",
+					"description": "リクエスト本文がスキーマに合わないときの項目ごとの誤り",
+					"items": object{
+						"type":     "object",
+						"required": []string{"field", "message"},
+						"properties": object{
+							"field":   object{"type": "string"},
+							"message": object{"type": "string"},
+						},
+					},
+				},
+				"request_id": object{"type": "string", "description": "リクエストの ID（応答の X-Request-ID と同じ）"},
+			},
+		},
+	},
+}
+
+// OpenAPI は登録した型とエンドポイントを OpenAPI 3.0 の文書（JSON）にします
+// 登録した型は components.schemas に置いて $ref で参照し、失敗したときの応答はすべて components.responses.Error で表します
+// タグにはパスの /api/ の次の部分（tasks・lists など）を使います
+func (g *Generator) OpenAPI(title, version, description string) ([]byte, error) {
+	schemas := object{"Error": errorSchema}
+	for _, t := range g.order {
+		if values, ok := g.enums[t]; ok {
+			schemas[g.names[t]] = object{"type": "string", "enum": values}
+			continue
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tsgen: %s is not a struct", t)
+		}
+		schema, err := g.objectSchema(t, nil)
+		if err != nil {
+			return nil, err
+		}
+		schemas[g.names[t]] = schema
+	}
+
+	paths := object{}
+	for _, e := range g.endpoints {
+		operation, err := g.operation(e)
+		if err != nil {
+			return nil, err
+		}
+		item, ok := paths[e.Path].(object)
+		if !ok {
+			item = object{}
+			paths[e.Path] = item
+		}
+		item[strings.ToLower(e.Method)] = operation
+	}
+
+	document := object{
+		"openapi": "3.0.3",
+		"info":    object{"title": title, "version": version, "description": description},
+		"paths":   paths,
+		// API キーはユーザーアカウントが有効なときだけ必要なため、認証なしでも呼び出せるものとします
+		"security": []object{{}, {"bearer": []string{}}},
+		"components": object{
+			"schemas": schemas,
+			"responses": object{
+				"Error": object{
+					"description": "失敗したときの応答です。HTTP の状態コードは error.code の種類によります",
+					"content":     object{"application/json": object{"schema": ref("Error")}},
+				},
+			},
+			"securitySchemes": object{
+				"bearer": object{"type": "http", "scheme": "bearer", "description": "ユーザーの API キー、または管理用トークン"},
+			},
+		},
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// operation はエンドポイント e の Operation Object を返します
+func (g *Generator) operation(e Endpoint) (object, error) {
+	tag := strings.TrimPrefix(e.Path, "/api/")
+	tag, _, _ = strings.Cut(tag, "/")
+	operation := object{
+		"operationId": e.Name,
+		"tags":        []string{tag},
+	}
+
+	var parameters []object
+	for _, m := range pathParam.FindAllStringSubmatch(e.Path, -1) {
+		kind := "string"
+		if strings.HasSuffix(m[1], "id") || strings.HasSuffix(m[1], "ID") {
+			kind = "integer"
+		}
+		parameters = append(parameters, object{"name": m[1], "in": "path", "required": true, "schema": object{"type": kind}})
+	}
+	for _, name := range e.Query {
+		parameters = append(parameters, object{"name": name, "in": "query", "schema": object{"type": "string"}})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if e.Body != nil {
+		t := reflect.TypeOf(e.Body)
+		var body object
+		var err error
+		if len(e.BodyOmit) > 0 && t.Kind() == reflect.Struct {
+			body, err = g.objectSchema(t, e.BodyOmit)
+		} else {
+			body, err = g.endpointSchema(t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tsgen: %s body: %w", e.Name, err)
+		}
+		operation["requestBody"] = object{
+			"required": !e.BodyOptional,
+			"content":  object{"application/json": object{"schema": body}},
+		}
+	}
+
+	ok := object{"description": "成功したときの応答です"}
+	if e.Response != nil {
+		response, err := g.endpointSchema(reflect.TypeOf(e.Response))
+		if err != nil {
+			return nil, fmt.Errorf("tsgen: %s response: %w", e.Name, err)
+		}
+		ok["content"] = object{"application/json": object{"schema": response}}
+	}
+	operation["responses"] = object{
+		"200":     ok,
+		"default": object{"$ref": "#/components/responses/Error"},
+	}
+	return operation, nil
+}
+
+// endpointSchema はリクエスト本文やレスポンスのスキーマを返します
+// endpointType と同じく、登録していない struct はその場でオブジェクトのスキーマに展開します
+func (g *Generator) endpointSchema(t reflect.Type) (object, error) {
+	if _, ok := g.names[t]; ok || t.Kind() != reflect.Struct || t == timeType {
+		return g.schema(t)
+	}
+	return g.objectSchema(t, nil)
+}
+
+// objectSchema は struct t のオブジェクトのスキーマを返します。omit のフィールドは入れません
+// omitempty のないフィールドは必ずある（required）ものとします
+func (g *Generator) objectSchema(t reflect.Type, omit []string) (object, error) {
+	properties := object{}
+	var required []string
+	if err := g.properties(t, omit, properties, &required); err != nil {
+		return nil, err
+	}
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// properties は struct t のフィールドを properties に入れます（埋め込んだ struct は展開します）
+func (g *Generator) properties(t reflect.Type, omit []string, properties object, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, opts := f.Name, ""
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ = strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			if err := g.properties(f.Type, omit, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if contains(omit, name) {
+			continue
+		}
+
+		fieldType := f.Type
+		optional := strings.Contains(","+opts+",", ",omitempty,")
+		nullable := false
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			nullable = !optional
+		}
+		schema, err := g.schema(fieldType)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", g.typeName(t), f.Name, err)
+		}
+		if nullable {
+			schema = nullableSchema(schema)
+		}
+		properties[name] = schema
+		if !optional {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// schema は Go の型 t に対応するスキーマを返します。tsType と同じ規則で変換します
+func (g *Generator) schema(t reflect.Type) (object, error) {
+	if name, ok := g.names[t]; ok {
+		return ref(name), nil
+	}
+	switch t {
+	case timeType:
+		return object{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return object{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}, nil
+	case reflect.String:
+		return object{"type": "string"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}, nil
+	case reflect.Interface:
+		return object{}, nil
+	case reflect.Ptr:
+		elem, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullableSchema(elem), nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return object{"type": "string", "format": "byte"}, nil
+		}
+		elem, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "array", "items": elem}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key %s", t.Key())
+		}
+		elem, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "object", "additionalProperties": elem}, nil
+	case reflect.Struct:
+		if t.Name() != "" {
+			return nil, fmt.Errorf("%s is not registered", t)
+		}
+		return g.objectSchema(t, nil)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// ref は components.schemas の name を参照するスキーマです
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// nullableSchema は schema に null も許します。OpenAPI 3.0 では $ref に nullable を並べられないため allOf で包みます
+func nullableSchema(schema object) object {
+	if _, ok := schema["$ref"]; ok {
+		return object{"allOf": []object{schema}, "nullable": true}
+	}
+	nullable := object{"nullable": true}
+	for key, value := range schema {
+		nullable[key] = value
+	}
+	return nullable
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
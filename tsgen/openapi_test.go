@@ -0,0 +1,106 @@
+package tsgen
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	type envelope struct {
+		Success bool  `json:"success"`
+		Item    inner `json:"item"`
+	}
+	g := New()
+	g.Enum("Color", color("red"), color("blue"))
+	g.Type("Inner", inner{})
+	g.Type("Sample", sample{})
+	g.Endpoint(Endpoint{Name: "listItems", Method: "GET", Path: "/api/items", Query: []string{"q"}, Response: []inner{}})
+	g.Endpoint(Endpoint{Name: "addItem", Method: "POST", Path: "/api/items/{slug}/children/{childID}", Body: sample{}, BodyOmit: []string{"id", "title"}, Response: envelope{}})
+	g.Endpoint(Endpoint{Name: "ping", Method: "POST", Path: "/api/ping", Body: struct {
+		N int `json:"n,omitempty"`
+	}{}, BodyOptional: true})
+
+	data, err := g.OpenAPI("Test API", "1", "For tests.")
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	var document struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Info       map[string]string                            `json:"info"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if document.OpenAPI != "3.0.3" || document.Info["title"] != "Test API" || document.Info["version"] != "1" {
+		t.Errorf("Unexpected header: %s %v", document.OpenAPI, document.Info)
+	}
+
+	schemas := document.Components.Schemas
+	if got := schemas["Color"]["enum"]; !reflect.DeepEqual(got, []interface{}{"red", "blue"}) {
+		t.Errorf("Expected the enum values, got %v", got)
+	}
+	if _, ok := schemas["Error"]; !ok {
+		t.Error("Expected the error envelope schema")
+	}
+	properties := schemas["Sample"]["properties"].(map[string]interface{})
+	for name, want := range map[string]string{
+		"id":       `{"type":"integer"}`,
+		"color":    `{"$ref":"#/components/schemas/Color"}`,
+		"due":      `{"format":"date-time","type":"string"}`,
+		"parent":   `{"allOf":[{"$ref":"#/components/schemas/Inner"}],"nullable":true}`,
+		"children": `{"items":{"$ref":"#/components/schemas/Inner"},"type":"array"}`,
+		"labels":   `{"additionalProperties":{"type":"integer"},"type":"object"}`,
+		"extra":    `{}`,
+		"data":     `{"format":"byte","type":"string"}`,
+		"maybe":    `{"items":{"nullable":true,"type":"integer"},"type":"array"}`,
+		"score":    `{"type":"number"}`,
+		"nested":   `{"properties":{"X":{"type":"integer"}},"required":["X"],"type":"object"}`,
+	} {
+		if got, _ := json.Marshal(properties[name]); string(got) != want {
+			t.Errorf("Sample.%s: expected %s, got %s", name, want, got)
+		}
+	}
+	if got, _ := json.Marshal(schemas["Sample"]["required"]); strings.Contains(string(got), `"done"`) || !strings.Contains(string(got), `"title"`) {
+		t.Errorf("Expected omitempty fields to be optional, got %s", got)
+	}
+
+	add := document.Paths["/api/items/{slug}/children/{childID}"]["post"]
+	if got, _ := json.Marshal(add["parameters"]); string(got) != `[{"in":"path","name":"slug","required":true,"schema":{"type":"string"}},{"in":"path","name":"childID","required":true,"schema":{"type":"integer"}}]` {
+		t.Errorf("Unexpected path parameters: %s", got)
+	}
+	body, _ := json.Marshal(add["requestBody"])
+	if strings.Contains(string(body), `"title"`) || strings.Contains(string(body), `"id"`) || !strings.Contains(string(body), `"required":true`) {
+		t.Errorf("Expected the omitted fields to be left out of a required body, got %s", body)
+	}
+	if got, _ := json.Marshal(add["responses"]); !strings.Contains(string(got), `"item":{"$ref":"#/components/schemas/Inner"}`) || !strings.Contains(string(got), `"default":{"$ref":"#/components/responses/Error"}`) {
+		t.Errorf("Unexpected responses: %s", got)
+	}
+	if got := add["tags"]; !reflect.DeepEqual(got, []interface{}{"items"}) {
+		t.Errorf("Expected the tag to come from the path, got %v", got)
+	}
+
+	if got, _ := json.Marshal(document.Paths["/api/items"]["get"]["parameters"]); string(got) != `[{"in":"query","name":"q","schema":{"type":"string"}}]` {
+		t.Errorf("Unexpected query parameters: %s", got)
+	}
+	ping := document.Paths["/api/ping"]["post"]
+	if got, _ := json.Marshal(ping["requestBody"]); !strings.Contains(string(got), `"required":false`) {
+		t.Errorf("Expected an optional body, got %s", got)
+	}
+	if got, _ := json.Marshal(ping["responses"]); strings.Contains(string(got), `"content"`) {
+		t.Errorf("Expected no response schema, got %s", got)
+	}
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	g := New()
+	g.Endpoint(Endpoint{Name: "get", Method: "GET", Path: "/", Response: sample{}})
+	if _, err := g.OpenAPI("", "", ""); err == nil || err.Error() != "tsgen: get response: sample.Parent: tsgen.inner is not registered" {
+		t.Errorf("Expected the unregistered type to be reported, got %v", err)
+	}
+}
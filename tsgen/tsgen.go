@@ -0,0 +1,392 @@
+// Package tsgen は Go の型（struct のタグ）から TypeScript の型定義と、API を呼び出す薄い fetch クライアントを生成します
+// 生成するファイルは cmd/tsgen がまとめて書き出すので、Task などにフィールドを足したら go generate ./... で作り直してください
+package tsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Endpoint はクライアントのメソッドにする API です
+// Name: メソッド名（listTasks など）
+// Method / Path: HTTP メソッドとパス。{id} のような部分は引数になります（名前が id か ID で終わるなら number、それ以外は string）
+// Query: 省略できるクエリパラメータの名前
+// Body: リクエスト本文の型の見本（nil なら本文なし）。BodyOptional なら省略でき、BodyOmit のフィールドは送りません
+// Response: レスポンスの型の見本（nil なら unknown）
+type Endpoint struct {
+	Name         string
+	Method       string
+	Path         string
+	Query        []string
+	Body         interface{}
+	BodyOptional bool
+	BodyOmit     []string
+	Response     interface{}
+}
+
+// Generator は登録した型とエンドポイントから TypeScript のソースを組み立てます
+type Generator struct {
+	names     map[reflect.Type]string
+	order     []reflect.Type
+	enums     map[reflect.Type][]string
+	endpoints []Endpoint
+}
+
+// New は空の Generator を作成します
+func New() *Generator {
+	return &Generator{names: map[reflect.Type]string{}, enums: map[reflect.Type][]string{}}
+}
+
+// Type は v の型（struct）を name の interface として登録します。ほかの型のフィールドからは name で参照します
+func (g *Generator) Type(name string, v interface{}) {
+	t := reflect.TypeOf(v)
+	g.names[t] = name
+	g.order = append(g.order, t)
+}
+
+// Enum は values と同じ型（string を元にした型）を、name の文字列リテラルの union として登録します
+func (g *Generator) Enum(name string, values ...interface{}) {
+	if len(values) == 0 {
+		panic("tsgen: Enum requires at least one value")
+	}
+	t := reflect.TypeOf(values[0])
+	for _, v := range values {
+		g.enums[t] = append(g.enums[t], reflect.ValueOf(v).String())
+	}
+	g.names[t] = name
+	g.order = append(g.order, t)
+}
+
+// Endpoint はクライアントのメソッドを登録します。メソッドは登録した順に並べます
+func (g *Generator) Endpoint(e Endpoint) {
+	g.endpoints = append(g.endpoints, e)
+}
+
+// Generate は TypeScript のソースを返します。登録していない名前付きの struct を使っているとエラーになります
+// header はファイルの先頭に入れるコメントです（生成元のコマンドなど）
+func (g *Generator) Generate(header string) ([]byte, error) {
+	var b bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSpace(header), "\n") {
+		b.WriteString(strings.TrimSpace("// "+line) + "\n")
+	}
+
+	for _, t := range g.order {
+		b.WriteString("\n")
+		if values, ok := g.enums[t]; ok {
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = quote(v)
+			}
+			fmt.Fprintf(&b, "export type %s = %s;\n", g.names[t], strings.Join(quoted, " | "))
+			continue
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tsgen: %s is not a struct", t)
+		}
+		fmt.Fprintf(&b, "export interface %s {\n", g.names[t])
+		fields, err := g.fields(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			fmt.Fprintf(&b, "  %s;\n", field)
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString(runtime)
+
+	b.WriteString("\nexport class TodoClient extends BaseClient {\n")
+	for i, e := range g.endpoints {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		method, err := g.method(e)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(method)
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// pathParam は Path の {name} です
+var pathParam = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// method はエンドポイント e のメソッドを返します
+func (g *Generator) method(e Endpoint) (string, error) {
+	var params []string
+	for _, m := range pathParam.FindAllStringSubmatch(e.Path, -1) {
+		kind := "string"
+		if strings.HasSuffix(m[1], "id") || strings.HasSuffix(m[1], "ID") {
+			kind = "number"
+		}
+		params = append(params, m[1]+": "+kind)
+	}
+	path := pathParam.ReplaceAllString(e.Path, "$${encodeURIComponent(String(${1}))}")
+
+	body := "undefined"
+	if e.Body != nil {
+		bodyType, err := g.endpointType(reflect.TypeOf(e.Body))
+		if err != nil {
+			return "", fmt.Errorf("tsgen: %s body: %w", e.Name, err)
+		}
+		if len(e.BodyOmit) > 0 {
+			quoted := make([]string, len(e.BodyOmit))
+			for i, name := range e.BodyOmit {
+				quoted[i] = quote(name)
+			}
+			bodyType = fmt.Sprintf("Omit<%s, %s>", bodyType, strings.Join(quoted, " | "))
+		}
+		if e.BodyOptional {
+			params = append(params, "body?: "+bodyType)
+		} else {
+			params = append(params, "body: "+bodyType)
+		}
+		body = "body"
+	}
+
+	query := "undefined"
+	if len(e.Query) > 0 {
+		fields := make([]string, len(e.Query))
+		for i, name := range e.Query {
+			fields[i] = name + "?: string"
+		}
+		params = append(params, "query: { "+strings.Join(fields, "; ")+" } = {}")
+		query = "query"
+	}
+
+	response := "unknown"
+	if e.Response != nil {
+		var err error
+		if response, err = g.endpointType(reflect.TypeOf(e.Response)); err != nil {
+			return "", fmt.Errorf("tsgen: %s response: %w", e.Name, err)
+		}
+	}
+
+	return fmt.Sprintf("  /** %s %s */\n  %s(%s): Promise<%s> {\n    return this.request<%s>(%s, `%s`, %s, %s);\n  }\n",
+		e.Method, e.Path, e.Name, strings.Join(params, ", "), response, response, quote(e.Method), path, query, body), nil
+}
+
+// endpointType はリクエスト本文やレスポンスの型を返します
+// 登録していない struct（レスポンスのエンベロープなど）は、名前が付いていてもその場でオブジェクトの型に展開します
+func (g *Generator) endpointType(t reflect.Type) (string, error) {
+	if _, ok := g.names[t]; ok || t.Kind() != reflect.Struct || t == timeType {
+		return g.tsType(t)
+	}
+	fields, err := g.fields(t)
+	if err != nil {
+		return "", err
+	}
+	return "{ " + strings.Join(fields, "; ") + " }", nil
+}
+
+// fields は struct t のフィールドを TypeScript のプロパティにします（埋め込んだ struct は展開します）
+func (g *Generator) fields(t reflect.Type) ([]string, error) {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, opts := f.Name, ""
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ = strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			embedded, err := g.fields(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+
+		fieldType := f.Type
+		optional := strings.Contains(","+opts+",", ",omitempty,")
+		nullable := false
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			nullable = !optional
+		}
+		tsType, err := g.tsType(fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", g.typeName(t), f.Name, err)
+		}
+		if nullable {
+			tsType += " | null"
+		}
+		if optional {
+			name += "?"
+		}
+		fields = append(fields, name+": "+tsType)
+	}
+	return fields, nil
+}
+
+// typeName はエラーに出す型の名前です（登録した名前、Go の型名、無名の struct なら "struct" の順）
+func (g *Generator) typeName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return "struct"
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// tsType は Go の型 t に対応する TypeScript の型を返します
+func (g *Generator) tsType(t reflect.Type) (string, error) {
+	if name, ok := g.names[t]; ok {
+		return name, nil
+	}
+	switch t {
+	case timeType:
+		return "string", nil
+	case rawMessageType:
+		return "unknown", nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.Interface:
+		return "unknown", nil
+	case reflect.Ptr:
+		elem, err := g.tsType(t.Elem())
+		return elem + " | null", err
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string", nil
+		}
+		elem, err := g.tsType(t.Elem())
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]", err
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", fmt.Errorf("unsupported map key %s", t.Key())
+		}
+		elem, err := g.tsType(t.Elem())
+		return "Record<string, " + elem + ">", err
+	case reflect.Struct:
+		if t.Name() != "" {
+			return "", fmt.Errorf("%s is not registered", t)
+		}
+		fields, err := g.fields(t)
+		if err != nil {
+			return "", err
+		}
+		return "{ " + strings.Join(fields, "; ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+func quote(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// runtime はエラーの変換とリクエストの送信を行う、生成しない部分です
+const runtime = `
+/** API が返したエラー（{"success": false, "error": {...}}）です */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly detail?: string,
+    readonly fields?: { field: string; message: string }[],
+    /** サーバのログと突き合わせるためのリクエストの ID（X-Request-ID） */
+    readonly requestId?: string,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+/** TodoClient の設定です */
+export interface ClientOptions {
+  /** API の起点（ワークスペースなら "/w/family"。省略時は同じオリジンのルート） */
+  baseUrl?: string;
+  /** 管理用エンドポイントの Bearer トークン */
+  token?: string;
+  /** 差し替え用の fetch（テストなど） */
+  fetch?: typeof fetch;
+}
+
+class BaseClient {
+  private readonly baseUrl: string;
+  private readonly token?: string;
+  private readonly fetchImpl: typeof fetch;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseUrl = options.baseUrl ?? "";
+    this.token = options.token;
+    this.fetchImpl = options.fetch ?? ((input, init) => fetch(input, init));
+  }
+
+  protected async request<T>(
+    method: string,
+    path: string,
+    query?: Record<string, string | undefined>,
+    body?: unknown,
+  ): Promise<T> {
+    let url = this.baseUrl + path;
+    if (query) {
+      const params = new URLSearchParams();
+      for (const [key, value] of Object.entries(query)) {
+        if (value !== undefined && value !== "") {
+          params.set(key, value);
+        }
+      }
+      const search = params.toString();
+      if (search) {
+        url += "?" + search;
+      }
+    }
+
+    const headers: Record<string, string> = {};
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    if (this.token) {
+      headers["Authorization"] = "Bearer " + this.token;
+    }
+    const response = await this.fetchImpl(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    const data = await response.json().catch(() => undefined);
+    if (!response.ok || (data && data.success === false)) {
+      const error = data && data.error ? data.error : { code: "internal", message: response.statusText };
+      throw new ApiError(response.status, error.code, error.message, error.detail, error.fields, error.request_id ?? response.headers.get("X-Request-ID") ?? undefined);
+    }
+    return data as T;
+  }
+}
+`
@@ -0,0 +1,167 @@
+package tsgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type color string
+
+type inner struct {
+	Name string `json:"name"`
+}
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type sample struct {
+	base
+	Title     string          `json:"title"`
+	Done      bool            `json:"done,omitempty"`
+	Color     color           `json:"color"`
+	Due       *time.Time      `json:"due,omitempty"`
+	Parent    *inner          `json:"parent"`
+	Children  []inner         `json:"children"`
+	Labels    map[string]int  `json:"labels"`
+	Extra     interface{}     `json:"extra"`
+	Raw       json.RawMessage `json:"raw"`
+	Data      []byte          `json:"data"`
+	Maybe     []*int          `json:"maybe"`
+	Score     float64         `json:"score"`
+	NoTag     string
+	Skipped   string            `json:"-"`
+	Renamed   string            `json:",omitempty"`
+	Nested    struct{ X uint8 } `json:"nested"`
+	unexposed string
+}
+
+func TestGenerateTypes(t *testing.T) {
+	g := New()
+	g.Enum("Color", color("red"), color("blue"))
+	g.Type("Inner", inner{})
+	g.Type("Sample", sample{})
+
+	source, err := g.Generate("Generated for tests.\n\nSecond paragraph.")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := `// Generated for tests.
+//
+// Second paragraph.
+
+export type Color = "red" | "blue";
+
+export interface Inner {
+  name: string;
+}
+
+export interface Sample {
+  id: number;
+  title: string;
+  done?: boolean;
+  color: Color;
+  due?: string;
+  parent: Inner | null;
+  children: Inner[];
+  labels: Record<string, number>;
+  extra: unknown;
+  raw: unknown;
+  data: string;
+  maybe: (number | null)[];
+  score: number;
+  NoTag: string;
+  Renamed?: string;
+  nested: { X: number };
+}
+`
+	if got := string(source); !strings.HasPrefix(got, want) {
+		t.Errorf("Unexpected types:\n%s", got[:len(want)])
+	}
+	if !strings.Contains(string(source), "export class ApiError extends Error") {
+		t.Error("Expected the runtime to be included")
+	}
+}
+
+func TestGenerateEndpoints(t *testing.T) {
+	type envelope struct {
+		Success bool  `json:"success"`
+		Item    inner `json:"item"`
+	}
+	g := New()
+	g.Type("Inner", inner{})
+	g.Endpoint(Endpoint{Name: "listItems", Method: "GET", Path: "/api/items", Query: []string{"q", "tz"}, Response: []inner{}})
+	g.Endpoint(Endpoint{Name: "addItem", Method: "POST", Path: "/api/items/{slug}/children/{childID}", Body: inner{}, BodyOmit: []string{"name"}, Response: envelope{}})
+	g.Endpoint(Endpoint{Name: "ping", Method: "POST", Path: "/api/ping", Body: struct {
+		N int `json:"n,omitempty"`
+	}{}, BodyOptional: true})
+
+	source, err := g.Generate("header")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := "export class TodoClient extends BaseClient {\n" +
+		"  /** GET /api/items */\n" +
+		"  listItems(query: { q?: string; tz?: string } = {}): Promise<Inner[]> {\n" +
+		"    return this.request<Inner[]>(\"GET\", `/api/items`, query, undefined);\n" +
+		"  }\n\n" +
+		"  /** POST /api/items/{slug}/children/{childID} */\n" +
+		"  addItem(slug: string, childID: number, body: Omit<Inner, \"name\">): Promise<{ success: boolean; item: Inner }> {\n" +
+		"    return this.request<{ success: boolean; item: Inner }>(\"POST\", `/api/items/${encodeURIComponent(String(slug))}/children/${encodeURIComponent(String(childID))}`, undefined, body);\n" +
+		"  }\n\n" +
+		"  /** POST /api/ping */\n" +
+		"  ping(body?: { n?: number }): Promise<unknown> {\n" +
+		"    return this.request<unknown>(\"POST\", `/api/ping`, undefined, body);\n" +
+		"  }\n" +
+		"}\n"
+	if got := string(source); !strings.HasSuffix(got, want) {
+		t.Errorf("Unexpected client:\n%s", got[strings.Index(got, "export class TodoClient"):])
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(g *Generator)
+		want  string
+	}{
+		{"unregistered field type", func(g *Generator) {
+			g.Type("Sample", sample{})
+		}, "Sample.Parent: tsgen.inner is not registered"},
+		{"not a struct", func(g *Generator) {
+			g.Type("Name", "text")
+		}, "tsgen: string is not a struct"},
+		{"map key", func(g *Generator) {
+			g.Type("Counts", struct {
+				M map[int]string `json:"m"`
+			}{})
+		}, "Counts.M: unsupported map key int"},
+		{"unsupported kind", func(g *Generator) {
+			g.Type("Funcs", struct {
+				F func() `json:"f"`
+			}{})
+		}, "Funcs.F: unsupported type func()"},
+		{"body", func(g *Generator) {
+			g.Endpoint(Endpoint{Name: "add", Method: "POST", Path: "/", Body: []inner{}})
+		}, "tsgen: add body: tsgen.inner is not registered"},
+		{"response", func(g *Generator) {
+			g.Endpoint(Endpoint{Name: "get", Method: "GET", Path: "/", Response: sample{}})
+		}, "tsgen: get response: sample.Parent: tsgen.inner is not registered"},
+	}
+	for _, tt := range tests {
+		g := New()
+		tt.setup(g)
+		if _, err := g.Generate(""); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Enum without values to panic")
+		}
+	}()
+	New().Enum("Empty")
+}
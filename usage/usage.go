@@ -0,0 +1,210 @@
+// Package usage は API のリクエストをエンドポイントごとに記録し、件数・エラー率・応答時間を集計します
+// Prometheus などのメトリクスの仕組みがなくても、管理用エンドポイントから使われ方を確認できるようにするためのものです
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// 既定の設定です
+// DefaultMaxRecords: 保持するリクエストの記録の上限（超えると古いものから捨てます）
+// DefaultWindow: 期間を省略したときに集計する直近の時間
+// MaxWindow: 一度に集計できる期間の上限
+const (
+	DefaultMaxRecords = 100000
+	DefaultWindow     = 24 * time.Hour
+	MaxWindow         = 31 * 24 * time.Hour
+)
+
+// record は1件のリクエストの記録です
+type record struct {
+	at       time.Time
+	endpoint string
+	status   int
+	duration time.Duration
+}
+
+// Store はリクエストの記録をメモリ上に保持します
+// 記録は MaxRecords 件の環状バッファに入れるため、リクエストが多いと集計できる期間は短くなります
+type Store struct {
+	MaxRecords int
+
+	now     func() time.Time
+	mutex   sync.Mutex
+	records []record
+	// oldest は records が一杯になった後の、最も古い記録の位置です
+	oldest int
+}
+
+// NewStore は既定の設定の Store を作成します
+func NewStore() *Store {
+	return &Store{MaxRecords: DefaultMaxRecords, now: time.Now}
+}
+
+// Record は endpoint（"GET /api/tasks/{id}" など）へのリクエストの応答のステータスと処理にかかった時間を記録します
+func (s *Store) Record(endpoint string, status int, duration time.Duration) {
+	rec := record{at: s.now(), endpoint: endpoint, status: status, duration: duration}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.records) < s.MaxRecords {
+		s.records = append(s.records, rec)
+		return
+	}
+	s.records[s.oldest] = rec
+	s.oldest = (s.oldest + 1) % len(s.records)
+}
+
+// ParseWindow は ?from=...&to=... の RFC 3339 の日時から集計する期間を読み取ります
+// to を省略すると now、from を省略すると to の DefaultWindow 前にします
+func ParseWindow(from, to string, now time.Time) (time.Time, time.Time, error) {
+	end := now
+	if to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid time %q (RFC 3339, for example 2025-03-01T00:00:00+09:00)", models.ErrValidation, to)
+		}
+		end = parsed
+	}
+	start := end.Add(-DefaultWindow)
+	if from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid time %q (RFC 3339, for example 2025-03-01T00:00:00+09:00)", models.ErrValidation, from)
+		}
+		start = parsed
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from %s is not before to %s", models.ErrValidation, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	if end.Sub(start) > MaxWindow {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period is longer than %d days", models.ErrValidation, int(MaxWindow/(24*time.Hour)))
+	}
+	return start, end, nil
+}
+
+// Latency は応答時間の分布です（ミリ秒）
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Endpoint は1つのエンドポイントの集計です
+// ClientErrors / ServerErrors: 4xx と 5xx の応答の数
+// ErrorRate: 4xx と 5xx を合わせた応答の割合（0〜1）
+type Endpoint struct {
+	Endpoint     string  `json:"endpoint"`
+	Requests     int     `json:"requests"`
+	ClientErrors int     `json:"client_errors"`
+	ServerErrors int     `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	LatencyMS    Latency `json:"latency_ms"`
+}
+
+// Summary は期間内のリクエストの集計です
+// RecordedSince: 保持している最も古い記録の日時。From がこれより前なら、期間の最初の部分は上限を超えて捨てた後です
+// Endpoints: リクエストの多い順
+type Summary struct {
+	From          time.Time  `json:"from"`
+	To            time.Time  `json:"to"`
+	RecordedSince *time.Time `json:"recorded_since,omitempty"`
+	Requests      int        `json:"requests"`
+	ClientErrors  int        `json:"client_errors"`
+	ServerErrors  int        `json:"server_errors"`
+	ErrorRate     float64    `json:"error_rate"`
+	LatencyMS     Latency    `json:"latency_ms"`
+	Endpoints     []Endpoint `json:"endpoints"`
+}
+
+// Summarize は from 以降 to より前のリクエストをエンドポイントごとに集計します
+// endpoint を指定すると、そのエンドポイントだけを集計します
+func (s *Store) Summarize(from, to time.Time, endpoint string) Summary {
+	summary := Summary{From: from, To: to, Endpoints: []Endpoint{}}
+	var all []time.Duration
+	durations := make(map[string][]time.Duration)
+	byEndpoint := make(map[string]*Endpoint)
+
+	s.mutex.Lock()
+	if len(s.records) > 0 {
+		since := s.records[s.oldest].at
+		summary.RecordedSince = &since
+	}
+	for _, rec := range s.records {
+		if rec.at.Before(from) || !rec.at.Before(to) || (endpoint != "" && rec.endpoint != endpoint) {
+			continue
+		}
+		stats, ok := byEndpoint[rec.endpoint]
+		if !ok {
+			stats = &Endpoint{Endpoint: rec.endpoint}
+			byEndpoint[rec.endpoint] = stats
+		}
+		stats.Requests++
+		switch {
+		case rec.status >= 500:
+			stats.ServerErrors++
+		case rec.status >= 400:
+			stats.ClientErrors++
+		}
+		durations[rec.endpoint] = append(durations[rec.endpoint], rec.duration)
+		all = append(all, rec.duration)
+	}
+	s.mutex.Unlock()
+
+	for name, stats := range byEndpoint {
+		stats.ErrorRate = errorRate(stats.ClientErrors+stats.ServerErrors, stats.Requests)
+		stats.LatencyMS = latency(durations[name])
+		summary.Requests += stats.Requests
+		summary.ClientErrors += stats.ClientErrors
+		summary.ServerErrors += stats.ServerErrors
+		summary.Endpoints = append(summary.Endpoints, *stats)
+	}
+	summary.ErrorRate = errorRate(summary.ClientErrors+summary.ServerErrors, summary.Requests)
+	summary.LatencyMS = latency(all)
+	sort.Slice(summary.Endpoints, func(i, j int) bool {
+		a, b := summary.Endpoints[i], summary.Endpoints[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Endpoint < b.Endpoint
+	})
+	return summary
+}
+
+// errorRate は requests のうち errors の割合を返します（requests が 0 なら 0）
+func errorRate(errors, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(errors) / float64(requests)
+}
+
+// latency は durations の分布を返します。百分位数は最近傍順位法（小さい方から ceil(p × n) 番目）で求めます
+func latency(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentile := func(p int) float64 {
+		rank := (p*len(durations) + 99) / 100
+		return milliseconds(durations[rank-1])
+	}
+	return Latency{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: milliseconds(durations[len(durations)-1]),
+	}
+}
+
+// milliseconds は d をミリ秒にします
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,109 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"todo-app/models"
+)
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore()
+	store.now = func() time.Time { return now }
+
+	// 1〜100ms の GET を100件、うち 2 件は 404 と 500
+	for i := 1; i <= 100; i++ {
+		status := 200
+		switch i {
+		case 10:
+			status = 404
+		case 20:
+			status = 500
+		}
+		store.Record("GET /api/tasks", status, time.Duration(i)*time.Millisecond)
+	}
+	store.Record("POST /api/tasks", 400, 5*time.Millisecond)
+	now = now.Add(time.Hour)
+	store.Record("DELETE /api/tasks/{id}", 200, 3*time.Millisecond)
+
+	summary := store.Summarize(now.Add(-2*time.Hour), now.Add(time.Second), "")
+	if summary.Requests != 102 || summary.ClientErrors != 2 || summary.ServerErrors != 1 {
+		t.Errorf("Unexpected totals: %+v", summary)
+	}
+	if summary.RecordedSince == nil || !summary.RecordedSince.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected recorded_since: %v", summary.RecordedSince)
+	}
+	if len(summary.Endpoints) != 3 || summary.Endpoints[0].Endpoint != "GET /api/tasks" {
+		t.Fatalf("Unexpected endpoints: %+v", summary.Endpoints)
+	}
+	get := summary.Endpoints[0]
+	if get.Requests != 100 || get.ErrorRate != 0.02 {
+		t.Errorf("Unexpected GET stats: %+v", get)
+	}
+	if get.LatencyMS != (Latency{P50: 50, P90: 90, P99: 99, Max: 100}) {
+		t.Errorf("Unexpected latency: %+v", get.LatencyMS)
+	}
+	if summary.Endpoints[1].Endpoint != "DELETE /api/tasks/{id}" || summary.Endpoints[2].ErrorRate != 1 {
+		t.Errorf("Expected ties to be sorted by name: %+v", summary.Endpoints)
+	}
+
+	// to より前、from 以降だけを数えます
+	if got := store.Summarize(now.Add(-30*time.Minute), now.Add(time.Second), ""); got.Requests != 1 {
+		t.Errorf("Expected only the last request, got %d", got.Requests)
+	}
+	if got := store.Summarize(now.Add(-2*time.Hour), now, "DELETE /api/tasks/{id}"); got.Requests != 0 {
+		t.Errorf("Expected to to be exclusive, got %d", got.Requests)
+	}
+	if got := store.Summarize(now.Add(-2*time.Hour), now, "POST /api/tasks"); got.Requests != 1 || len(got.Endpoints) != 1 {
+		t.Errorf("Expected the endpoint filter to apply, got %+v", got)
+	}
+
+	empty := NewStore().Summarize(now.Add(-time.Hour), now, "")
+	if empty.Requests != 0 || empty.RecordedSince != nil || empty.Endpoints == nil || empty.LatencyMS != (Latency{}) {
+		t.Errorf("Unexpected empty summary: %+v", empty)
+	}
+}
+
+func TestRecordDropsOldest(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := NewStore()
+	store.MaxRecords = 3
+	store.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		store.Record("GET /api/tasks", 200, time.Duration(i)*time.Millisecond)
+		now = now.Add(time.Minute)
+	}
+
+	summary := store.Summarize(now.Add(-time.Hour), now, "")
+	if summary.Requests != 3 || summary.LatencyMS.Max != 4 || summary.LatencyMS.P50 != 3 {
+		t.Errorf("Expected the 3 newest records, got %+v", summary)
+	}
+	if !summary.RecordedSince.Equal(now.Add(-3 * time.Minute)) {
+		t.Errorf("Unexpected recorded_since: %v", summary.RecordedSince)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	from, to, err := ParseWindow("", "", now)
+	if err != nil || !to.Equal(now) || !from.Equal(now.Add(-DefaultWindow)) {
+		t.Errorf("Unexpected default window %v - %v: %v", from, to, err)
+	}
+	from, to, err = ParseWindow("2025-02-01T00:00:00+09:00", "2025-02-02T00:00:00+09:00", now)
+	if err != nil || to.Sub(from) != 24*time.Hour || from.UTC().Day() != 31 {
+		t.Errorf("Unexpected window %v - %v: %v", from, to, err)
+	}
+
+	for _, tt := range [][2]string{
+		{"yesterday", ""},
+		{"", "2025-03-01"},
+		{"2025-03-01T12:00:00Z", ""},
+		{"2025-01-01T00:00:00Z", "2025-03-01T00:00:00Z"},
+	} {
+		if _, _, err := ParseWindow(tt[0], tt[1], now); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("ParseWindow(%q, %q): expected a validation error, got %v", tt[0], tt[1], err)
+		}
+	}
+}
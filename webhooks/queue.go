@@ -0,0 +1,343 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// ErrDeliveryNotFound は指定した ID の配信がキューにないときのエラーです
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// maxDeadLetters は保持する配信できなかったものの最大件数です。超えたら古いものから捨てます
+const maxDeadLetters = 1000
+
+// RetryPolicy は送信に失敗した配信を再送する間隔と回数です
+// MaxAttempts: 最初の送信を含めた試行回数の上限。超えたら配信できなかったもの（dead letter）にします
+// BaseDelay: 1回目の再送までの間隔。失敗するたびに2倍にします
+// MaxDelay: 再送の間隔の上限
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy は再送の既定の設定です（30秒から最大1時間の間隔で、8回まで試します）
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 8, BaseDelay: 30 * time.Second, MaxDelay: time.Hour}
+
+// delay は attempts 回失敗した後、次に送るまでの間隔を返します
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Delivery は1件の Webhook へ1件のイベントを届ける配信です
+// Attempts: これまでに送信を試みた回数
+// NextAttempt: 次に送信する時刻（配信できなかったものでは空）
+// LastError: 最後に失敗したときの理由
+// FailedAt: 再送をあきらめて配信できなかったものにした時刻
+type Delivery struct {
+	ID          int          `json:"id"`
+	WebhookID   int          `json:"webhook_id"`
+	Event       models.Event `json:"event"`
+	Attempts    int          `json:"attempts"`
+	NextAttempt *time.Time   `json:"next_attempt,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FailedAt    *time.Time   `json:"failed_at,omitempty"`
+}
+
+// EndpointStatus は Webhook ごとの送信の成否の記録です
+// ConsecutiveFailures: 最後に成功してから続けて失敗した回数
+// TotalFailures: これまでに失敗した回数
+type EndpointStatus struct {
+	WebhookID           int        `json:"webhook_id"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	TotalFailures       int        `json:"total_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+}
+
+// queueFile はキューを保存するファイルの内容です
+type queueFile struct {
+	NextID    int              `json:"next_id"`
+	Pending   []Delivery       `json:"pending"`
+	Dead      []Delivery       `json:"dead"`
+	Endpoints []EndpointStatus `json:"endpoints"`
+}
+
+// Queue は送信待ちの配信と配信できなかったもの、Webhook ごとの成否を保持します
+// path を指定すると変更のたびにファイルへ書き出し、再起動しても送信待ちの配信を失いません
+type Queue struct {
+	path   string
+	policy RetryPolicy
+	now    func() time.Time
+
+	mutex     sync.Mutex
+	nextID    int
+	pending   []Delivery
+	dead      []Delivery
+	endpoints map[int]*EndpointStatus
+	inFlight  map[int]bool
+}
+
+// NewQueue は policy で再送する Queue を作成します
+// path が空ならメモリ上だけに保持し、指定したファイルがあれば読み込みます
+func NewQueue(path string, policy RetryPolicy) (*Queue, error) {
+	q := &Queue{
+		path:      path,
+		policy:    policy,
+		now:       time.Now,
+		nextID:    1,
+		endpoints: map[int]*EndpointStatus{},
+		inFlight:  map[int]bool{},
+	}
+	if path == "" {
+		return q, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file queueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if file.NextID > q.nextID {
+		q.nextID = file.NextID
+	}
+	q.pending = file.Pending
+	q.dead = file.Dead
+	for i := range file.Endpoints {
+		status := file.Endpoints[i]
+		q.endpoints[status.WebhookID] = &status
+	}
+	return q, nil
+}
+
+// Enqueue は webhookID の Webhook へ event を届ける配信を追加し、すぐに送信できる状態で返します
+func (q *Queue) Enqueue(webhookID int, event models.Event) (Delivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := q.now()
+	delivery := Delivery{ID: q.nextID, WebhookID: webhookID, Event: event, NextAttempt: &now, CreatedAt: now}
+	q.nextID++
+	q.pending = append(q.pending, delivery)
+	return delivery, q.save()
+}
+
+// claim は id の配信が送信待ちで、ほかで送信中でなければ送信中にして返します
+func (q *Queue) claim(id int) (Delivery, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	i := indexOf(q.pending, id)
+	if i < 0 || q.inFlight[id] {
+		return Delivery{}, false
+	}
+	q.inFlight[id] = true
+	return q.pending[i], true
+}
+
+// claimDue は送信する時刻になった配信をすべて送信中にして返します
+func (q *Queue) claimDue() []Delivery {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := q.now()
+	var due []Delivery
+	for _, delivery := range q.pending {
+		if q.inFlight[delivery.ID] || delivery.NextAttempt == nil || delivery.NextAttempt.After(now) {
+			continue
+		}
+		q.inFlight[delivery.ID] = true
+		due = append(due, delivery)
+	}
+	return due
+}
+
+// finish は送信中の配信の結果を記録します
+// 成功したらキューから取り除き、失敗したら次に送る時刻を決めるか、試行回数が上限に達していれば配信できなかったものにします
+func (q *Queue) finish(id int, sendErr error) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.inFlight, id)
+	i := indexOf(q.pending, id)
+	if i < 0 {
+		return nil
+	}
+	delivery := q.pending[i]
+	now := q.now()
+	status := q.endpoint(delivery.WebhookID)
+	if sendErr == nil {
+		status.ConsecutiveFailures = 0
+		status.LastSuccess = &now
+		q.pending = append(q.pending[:i], q.pending[i+1:]...)
+		return q.save()
+	}
+
+	status.ConsecutiveFailures++
+	status.TotalFailures++
+	status.LastError = sendErr.Error()
+	status.LastFailure = &now
+
+	delivery.Attempts++
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts < q.policy.MaxAttempts {
+		next := now.Add(q.policy.delay(delivery.Attempts))
+		delivery.NextAttempt = &next
+		q.pending[i] = delivery
+		return q.save()
+	}
+	delivery.NextAttempt = nil
+	delivery.FailedAt = &now
+	q.pending = append(q.pending[:i], q.pending[i+1:]...)
+	q.dead = append(q.dead, delivery)
+	if len(q.dead) > maxDeadLetters {
+		q.dead = q.dead[len(q.dead)-maxDeadLetters:]
+	}
+	return q.save()
+}
+
+// drop は送信中の配信を、送らずにキューから取り除きます（Webhook が削除された場合など）
+func (q *Queue) drop(id int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.inFlight, id)
+	if i := indexOf(q.pending, id); i >= 0 {
+		q.pending = append(q.pending[:i], q.pending[i+1:]...)
+	}
+	return q.save()
+}
+
+// endpoint は webhookID の成否の記録を返します。なければ作成します
+func (q *Queue) endpoint(webhookID int) *EndpointStatus {
+	status, ok := q.endpoints[webhookID]
+	if !ok {
+		status = &EndpointStatus{WebhookID: webhookID}
+		q.endpoints[webhookID] = status
+	}
+	return status
+}
+
+// Replay は配信できなかったものを試行回数を0に戻して送信待ちに戻し、戻した配信を返します
+// 見つからなければ ErrDeliveryNotFound を返します
+func (q *Queue) Replay(id int) (Delivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	i := indexOf(q.dead, id)
+	if i < 0 {
+		return Delivery{}, fmt.Errorf("%w: id %d", ErrDeliveryNotFound, id)
+	}
+	delivery := q.dead[i]
+	now := q.now()
+	delivery.Attempts = 0
+	delivery.NextAttempt = &now
+	delivery.FailedAt = nil
+	q.dead = append(q.dead[:i], q.dead[i+1:]...)
+	q.pending = append(q.pending, delivery)
+	return delivery, q.save()
+}
+
+// Discard は配信できなかったものを捨てます。見つからなければ ErrDeliveryNotFound を返します
+func (q *Queue) Discard(id int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	i := indexOf(q.dead, id)
+	if i < 0 {
+		return fmt.Errorf("%w: id %d", ErrDeliveryNotFound, id)
+	}
+	q.dead = append(q.dead[:i], q.dead[i+1:]...)
+	return q.save()
+}
+
+// Pending は送信待ちの配信のコピーを返します
+func (q *Queue) Pending() []Delivery {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return append([]Delivery{}, q.pending...)
+}
+
+// Dead は配信できなかったもののコピーを返します
+func (q *Queue) Dead() []Delivery {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return append([]Delivery{}, q.dead...)
+}
+
+// Endpoints は Webhook ごとの成否の記録を Webhook の ID の順に返します
+func (q *Queue) Endpoints() []EndpointStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.endpointList()
+}
+
+func (q *Queue) endpointList() []EndpointStatus {
+	endpoints := make([]EndpointStatus, 0, len(q.endpoints))
+	for _, status := range q.endpoints {
+		endpoints = append(endpoints, *status)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].WebhookID < endpoints[j].WebhookID })
+	return endpoints
+}
+
+// save はキューをファイルへ書き出します。path が空なら何もしません
+// 書き出しの途中で止まっても壊れたファイルを残さないよう、一時ファイルを書いてから置き換えます
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(queueFile{
+		NextID:    q.nextID,
+		Pending:   q.pending,
+		Dead:      q.dead,
+		Endpoints: q.endpointList(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".webhook-queue-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
+
+func indexOf(deliveries []Delivery, id int) int {
+	for i, delivery := range deliveries {
+		if delivery.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,298 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todo-app/models"
+)
+
+// fakeClock はテストで進められる時計です
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestQueue(t *testing.T, path string, policy RetryPolicy) (*Queue, *fakeClock) {
+	t.Helper()
+	q, err := NewQueue(path, policy)
+	if err != nil {
+		t.Fatalf("NewQueue failed: %v", err)
+	}
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}
+	q.now = clock.Now
+	return q, clock
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, expected := range want {
+		if got := policy.delay(i + 1); got != expected {
+			t.Errorf("delay(%d) = %v, expected %v", i+1, got, expected)
+		}
+	}
+}
+
+func TestQueueRetriesAndDeadLetters(t *testing.T) {
+	q, clock := newTestQueue(t, "", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	delivery, _ := q.Enqueue(7, models.Event{Type: models.EventTaskCreated})
+
+	if due := q.claimDue(); len(due) != 1 || due[0].ID != delivery.ID {
+		t.Fatalf("Expected the new delivery to be due, got %+v", due)
+	}
+	if _, ok := q.claim(delivery.ID); ok {
+		t.Error("Expected a delivery in flight not to be claimed twice")
+	}
+	q.finish(delivery.ID, errors.New("unexpected status 502 Bad Gateway"))
+
+	pending := q.Pending()
+	if len(pending) != 1 || pending[0].Attempts != 1 || !pending[0].NextAttempt.Equal(clock.now.Add(time.Minute)) {
+		t.Fatalf("Expected a retry after 1 minute, got %+v", pending)
+	}
+	if due := q.claimDue(); len(due) != 0 {
+		t.Errorf("Expected no deliveries before the backoff, got %+v", due)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	q.claimDue()
+	q.finish(delivery.ID, errors.New("timeout"))
+	if next := q.Pending()[0].NextAttempt; !next.Equal(clock.now.Add(2 * time.Minute)) {
+		t.Errorf("Expected the backoff to double, got %v", next)
+	}
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	q.claimDue()
+	q.finish(delivery.ID, errors.New("timeout"))
+	dead := q.Dead()
+	if len(q.Pending()) != 0 || len(dead) != 1 || dead[0].Attempts != 3 || dead[0].NextAttempt != nil || !dead[0].FailedAt.Equal(clock.now) {
+		t.Fatalf("Expected the delivery to be dead-lettered after 3 attempts, got pending %+v dead %+v", q.Pending(), dead)
+	}
+
+	endpoints := q.Endpoints()
+	if len(endpoints) != 1 || endpoints[0].WebhookID != 7 || endpoints[0].ConsecutiveFailures != 3 || endpoints[0].TotalFailures != 3 || endpoints[0].LastError != "timeout" {
+		t.Errorf("Unexpected endpoint status %+v", endpoints)
+	}
+
+	replayed, err := q.Replay(delivery.ID)
+	if err != nil || replayed.Attempts != 0 || replayed.FailedAt != nil || replayed.LastError != "timeout" {
+		t.Fatalf("Unexpected replay %+v, %v", replayed, err)
+	}
+	if _, err := q.Replay(delivery.ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Expected a replayed delivery to leave the dead letters, got %v", err)
+	}
+	q.claim(delivery.ID)
+	q.finish(delivery.ID, nil)
+	if len(q.Pending()) != 0 || len(q.Dead()) != 0 {
+		t.Error("Expected a successful delivery to leave the queue")
+	}
+	if status := q.Endpoints()[0]; status.ConsecutiveFailures != 0 || status.TotalFailures != 3 || !status.LastSuccess.Equal(clock.now) {
+		t.Errorf("Expected the success to reset consecutive failures, got %+v", status)
+	}
+
+	// 結果の記録は、キューにない配信では何もしません
+	if err := q.finish(99, nil); err != nil {
+		t.Errorf("Expected finishing an unknown delivery to be ignored, got %v", err)
+	}
+}
+
+func TestQueueDiscardAndLimit(t *testing.T) {
+	q, _ := newTestQueue(t, "", RetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second})
+	for i := 0; i < maxDeadLetters+1; i++ {
+		delivery, _ := q.Enqueue(1, models.Event{})
+		q.claim(delivery.ID)
+		q.finish(delivery.ID, errors.New("failed"))
+	}
+	dead := q.Dead()
+	if len(dead) != maxDeadLetters || dead[0].ID != 2 {
+		t.Fatalf("Expected the oldest dead letter to be dropped, got %d starting at %d", len(dead), dead[0].ID)
+	}
+	if err := q.Discard(2); err != nil {
+		t.Errorf("Discard failed: %v", err)
+	}
+	if err := q.Discard(2); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Expected ErrDeliveryNotFound, got %v", err)
+	}
+	if len(q.Dead()) != maxDeadLetters-1 {
+		t.Errorf("Expected the discarded delivery to be removed")
+	}
+}
+
+func TestQueuePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, _ := newTestQueue(t, path, DefaultRetryPolicy)
+	first, _ := q.Enqueue(1, models.Event{ID: 10, Type: models.EventTaskCreated, Task: models.Task{ID: 3, Title: "Buy milk"}})
+	q.Enqueue(2, models.Event{ID: 11, Type: models.EventTaskDeleted})
+	q.claim(first.ID)
+	q.finish(first.ID, errors.New("refused"))
+
+	reopened, err := NewQueue(path, DefaultRetryPolicy)
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	pending := reopened.Pending()
+	if len(pending) != 2 || pending[0].Event.Task.Title != "Buy milk" || pending[0].Attempts != 1 || pending[0].LastError != "refused" {
+		t.Fatalf("Unexpected pending deliveries after reopening: %+v", pending)
+	}
+	if endpoints := reopened.Endpoints(); len(endpoints) != 1 || endpoints[0].TotalFailures != 1 {
+		t.Errorf("Unexpected endpoints after reopening: %+v", endpoints)
+	}
+	if next, _ := reopened.Enqueue(1, models.Event{}); next.ID != 3 {
+		t.Errorf("Expected IDs to continue after reopening, got %d", next.ID)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected only the queue file, got %d entries", len(entries))
+	}
+}
+
+func TestQueueErrors(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte("{"), 0600)
+	if _, err := NewQueue(broken, DefaultRetryPolicy); err == nil {
+		t.Error("Expected an error for a broken file")
+	}
+	if _, err := NewQueue(dir, DefaultRetryPolicy); err == nil {
+		t.Error("Expected an error when the path is a directory")
+	}
+
+	q, _ := newTestQueue(t, filepath.Join(dir, "missing", "queue.json"), DefaultRetryPolicy)
+	if _, err := q.Enqueue(1, models.Event{}); err == nil {
+		t.Error("Expected an error when the queue cannot be saved")
+	}
+	if len(q.Pending()) != 1 {
+		t.Error("Expected the delivery to be kept in memory even if saving fails")
+	}
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := NewStore()
+	store.Add(Webhook{URL: server.URL})
+	q, clock := newTestQueue(t, "", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	dispatcher := NewDispatcher(store, q, server.Client())
+
+	dispatcher.HandleEvent(models.Event{Type: models.EventTaskCreated})
+	dispatcher.Wait()
+	if pending := q.Pending(); len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("Expected the failed delivery to wait for a retry, got %+v", pending)
+	}
+
+	dispatcher.RetryDue()
+	dispatcher.Wait()
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected no retry before the backoff, got %d calls", calls)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	dispatcher.RetryDue()
+	dispatcher.Wait()
+	if atomic.LoadInt32(&calls) != 2 || len(q.Pending()) != 0 || len(q.Dead()) != 0 {
+		t.Errorf("Expected the retry to succeed, got %d calls, pending %+v", calls, q.Pending())
+	}
+}
+
+func TestDispatcherReplay(t *testing.T) {
+	var fail int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	store := NewStore()
+	store.Add(Webhook{URL: server.URL})
+	q, _ := newTestQueue(t, "", RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	dispatcher := NewDispatcher(store, q, server.Client())
+	if dispatcher.Queue() != q {
+		t.Fatal("Expected the dispatcher to use the given queue")
+	}
+
+	dispatcher.HandleEvent(models.Event{Type: models.EventTaskCreated})
+	dispatcher.Wait()
+	dead := q.Dead()
+	if len(dead) != 1 || dead[0].LastError != "unexpected status 500 Internal Server Error" {
+		t.Fatalf("Expected a dead letter, got %+v", dead)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	if _, err := dispatcher.Replay(dead[0].ID); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	dispatcher.Wait()
+	if len(q.Pending()) != 0 || len(q.Dead()) != 0 {
+		t.Errorf("Expected the replayed delivery to succeed, got pending %+v dead %+v", q.Pending(), q.Dead())
+	}
+	if _, err := dispatcher.Replay(dead[0].ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Expected ErrDeliveryNotFound, got %v", err)
+	}
+}
+
+func TestDispatcherDropsDeletedWebhooks(t *testing.T) {
+	store := NewStore()
+	webhook, _ := store.Add(Webhook{URL: "http://127.0.0.1:1/hook"})
+	q, clock := newTestQueue(t, "", DefaultRetryPolicy)
+	dispatcher := NewDispatcher(store, q, nil)
+
+	dispatcher.HandleEvent(models.Event{Type: models.EventTaskCreated})
+	dispatcher.Wait()
+	if len(q.Pending()) != 1 {
+		t.Fatalf("Expected the refused delivery to be retried later, got %+v", q.Pending())
+	}
+
+	store.Delete(webhook.ID)
+	clock.now = clock.now.Add(time.Hour)
+	dispatcher.RetryDue()
+	dispatcher.Wait()
+	if len(q.Pending()) != 0 || len(q.Dead()) != 0 {
+		t.Errorf("Expected deliveries for a deleted webhook to be dropped, got %+v", q.Pending())
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	// ファイルに残っていた送信待ちの配信は、Run を始めるとすぐに送ります
+	store := NewStore()
+	webhook, _ := store.Add(Webhook{URL: server.URL})
+	q, _ := newTestQueue(t, "", DefaultRetryPolicy)
+	q.Enqueue(webhook.ID, models.Event{Type: models.EventTaskCreated})
+	dispatcher := NewDispatcher(store, q, server.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx, time.Hour)
+		close(done)
+	}()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to send the pending delivery")
+	}
+	cancel()
+	<-done
+	dispatcher.Wait()
+	if len(q.Pending()) != 0 {
+		t.Errorf("Expected the delivery to leave the queue, got %+v", q.Pending())
+	}
+}
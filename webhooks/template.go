@@ -0,0 +1,93 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"todo-app/models"
+)
+
+// プリセット名
+const (
+	PresetJSON    = "json"
+	PresetSlack   = "slack"
+	PresetDiscord = "discord"
+	PresetTeams   = "teams"
+)
+
+// presets は主要なチャットサービス向けのペイロードテンプレートです
+var presets = map[string]string{
+	PresetJSON:    `{{json .Event}}`,
+	PresetSlack:   `{"text": {{json .Message}}}`,
+	PresetDiscord: `{"content": {{json .Message}}}`,
+	PresetTeams:   `{"@type": "MessageCard", "@context": "http://schema.org/extensions", "summary": {{json .Message}}, "text": {{json .Message}}}`,
+}
+
+// templateFuncs はテンプレート内で使える関数です
+// json: 値を JSON としてエスケープして埋め込みます（文字列の埋め込みにも使います）
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Payload はテンプレートに渡すデータです
+// Event: 元のイベント（.Event.Task.Title のように参照できます）
+// Message: 人が読むための1行の説明文
+type Payload struct {
+	Event   models.Event
+	Message string
+}
+
+// Presets は利用できるプリセット名の一覧を返します
+func Presets() []string {
+	return []string{PresetJSON, PresetSlack, PresetDiscord, PresetTeams}
+}
+
+// parseTemplate はプリセット名またはテンプレート文字列からテンプレートを作成します
+// 両方が空の場合は json プリセットを使います
+func parseTemplate(preset, text string) (*template.Template, error) {
+	if text == "" {
+		if preset == "" {
+			preset = PresetJSON
+		}
+		var ok bool
+		if text, ok = presets[preset]; !ok {
+			return nil, fmt.Errorf("unknown preset %q", preset)
+		}
+	}
+	return template.New("payload").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Render はイベントをテンプレートに当てはめてリクエストボディを作成します
+func Render(preset, text string, event models.Event) ([]byte, error) {
+	tmpl, err := parseTemplate(preset, text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, Payload{Event: event, Message: message(event)}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// message はイベントの説明文を作成します
+func message(event models.Event) string {
+	switch event.Type {
+	case models.EventTaskCreated:
+		return fmt.Sprintf("タスク「%s」が追加されました", event.Task.Title)
+	case models.EventTaskDeleted:
+		return fmt.Sprintf("タスク「%s」が削除されました", event.Task.Title)
+	case models.EventTaskReminder:
+		return fmt.Sprintf("リマインダー: タスク「%s」の時刻になりました", event.Task.Title)
+	default:
+		if event.Task.Completed {
+			return fmt.Sprintf("タスク「%s」が完了しました", event.Task.Title)
+		}
+		return fmt.Sprintf("タスク「%s」が更新されました", event.Task.Title)
+	}
+}
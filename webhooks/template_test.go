@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+	"todo-app/models"
+)
+
+func sampleEvent(eventType models.EventType, title string, completed bool) models.Event {
+	return models.Event{
+		ID:   1,
+		Type: eventType,
+		Task: models.Task{ID: 7, Title: title, Completed: completed},
+	}
+}
+
+func TestRenderPresets(t *testing.T) {
+	event := sampleEvent(models.EventTaskUpdated, `Say "hi"`, true)
+
+	testCases := []struct {
+		preset string
+		field  string
+	}{
+		{PresetSlack, "text"},
+		{PresetDiscord, "content"},
+		{PresetTeams, "text"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.preset, func(t *testing.T) {
+			body, err := Render(tc.preset, "", event)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			var payload map[string]interface{}
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatalf("Expected valid JSON, got %s: %v", body, err)
+			}
+			if payload[tc.field] != `タスク「Say "hi"」が完了しました` {
+				t.Errorf("Unexpected %s: %v", tc.field, payload[tc.field])
+			}
+		})
+	}
+}
+
+func TestRenderDefaultJSON(t *testing.T) {
+	body, err := Render("", "", sampleEvent(models.EventTaskCreated, "Task", false))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	var event models.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("Expected event JSON, got %s: %v", body, err)
+	}
+	if event.Type != models.EventTaskCreated || event.Task.ID != 7 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestRenderCustomTemplate(t *testing.T) {
+	text := `{"id": {{.Event.Task.ID}}, "title": {{json .Event.Task.Title}}, "kind": "{{.Event.Type}}"}`
+
+	body, err := Render("", text, sampleEvent(models.EventTaskDeleted, "Old", false))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if string(body) != `{"id": 7, "title": "Old", "kind": "task.deleted"}` {
+		t.Errorf("Unexpected body: %s", body)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Render("unknown", "", sampleEvent(models.EventTaskCreated, "Task", false)); err == nil {
+		t.Error("Expected error for unknown preset")
+	}
+	if _, err := Render("", "{{.Event.Nope}}", sampleEvent(models.EventTaskCreated, "Task", false)); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	testCases := []struct {
+		event    models.Event
+		expected string
+	}{
+		{sampleEvent(models.EventTaskCreated, "A", false), "タスク「A」が追加されました"},
+		{sampleEvent(models.EventTaskUpdated, "A", false), "タスク「A」が更新されました"},
+		{sampleEvent(models.EventTaskUpdated, "A", true), "タスク「A」が完了しました"},
+		{sampleEvent(models.EventTaskDeleted, "A", false), "タスク「A」が削除されました"},
+		{sampleEvent(models.EventTaskReminder, "A", false), "リマインダー: タスク「A」の時刻になりました"},
+	}
+
+	for _, tc := range testCases {
+		if got := message(tc.event); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package webhooks はタスクの変更イベントを外部の URL へ通知します
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// Webhook は1件の通知先の設定です
+// Events: 通知するイベントの種類（空ならすべて）
+// Preset: ペイロードのプリセット名（json / slack / discord / teams）
+// Template: ペイロードを組み立てる Go テンプレート（指定時は Preset より優先）
+// ContentType: 送信する Content-Type（省略時は application/json）
+type Webhook struct {
+	ID          int                `json:"id"`
+	URL         string             `json:"url"`
+	Events      []models.EventType `json:"events,omitempty"`
+	Preset      string             `json:"preset,omitempty"`
+	Template    string             `json:"template,omitempty"`
+	ContentType string             `json:"content_type,omitempty"`
+}
+
+// Validate は Webhook の設定が正しいかを検証します
+func (w Webhook) Validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	for _, eventType := range w.Events {
+		switch eventType {
+		case models.EventTaskCreated, models.EventTaskUpdated, models.EventTaskDeleted, models.EventTaskReminder:
+		default:
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	if _, err := parseTemplate(w.Preset, w.Template); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	return nil
+}
+
+// matches は Webhook がイベントの種類を購読しているかを返します
+func (w Webhook) matches(eventType models.EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Store は登録された Webhook を保持します
+type Store struct {
+	webhooks []Webhook
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewStore は空の Store を作成します
+func NewStore() *Store {
+	return &Store{webhooks: make([]Webhook, 0), nextID: 1}
+}
+
+// Add は Webhook を検証して登録し、IDを振ったものを返します
+func (s *Store) Add(webhook Webhook) (Webhook, error) {
+	if err := webhook.Validate(); err != nil {
+		return Webhook{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	webhook.ID = s.nextID
+	s.nextID++
+	s.webhooks = append(s.webhooks, webhook)
+	return webhook, nil
+}
+
+// List は登録済みの Webhook のコピーを返します
+func (s *Store) List() []Webhook {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	webhooks := make([]Webhook, len(s.webhooks))
+	copy(webhooks, s.webhooks)
+	return webhooks
+}
+
+// Delete は指定IDの Webhook を削除します
+// 見つかったら true を、見つからなければ false を返します
+func (s *Store) Delete(id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, webhook := range s.webhooks {
+		if webhook.ID == id {
+			s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get は指定IDの Webhook を返します。見つからなければ false を返します
+func (s *Store) Get(id int) (Webhook, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, webhook := range s.webhooks {
+		if webhook.ID == id {
+			return webhook, true
+		}
+	}
+	return Webhook{}, false
+}
+
+// Dispatcher はイベントバスの購読者として、該当する Webhook へペイロードを送信します
+// 配信はいったん Queue に積んでから送り、失敗したものは Run が RetryPolicy に従って再送します
+type Dispatcher struct {
+	store      *Store
+	queue      *Queue
+	httpClient *http.Client
+	wg         sync.WaitGroup
+}
+
+// NewDispatcher は Dispatcher を作成します
+// queue が nil の場合はメモリ上の Queue（DefaultRetryPolicy）を、
+// httpClient が nil の場合はタイムアウト10秒のクライアントを使います
+func NewDispatcher(store *Store, queue *Queue, httpClient *http.Client) *Dispatcher {
+	if queue == nil {
+		queue, _ = NewQueue("", DefaultRetryPolicy)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Dispatcher{store: store, queue: queue, httpClient: httpClient}
+}
+
+// Queue は配信を積んでいる Queue を返します
+func (d *Dispatcher) Queue() *Queue {
+	return d.queue
+}
+
+// HandleEvent はイベントを購読している Webhook ごとに配信をキューに積み、非同期に送信します
+func (d *Dispatcher) HandleEvent(event models.Event) {
+	for _, webhook := range d.store.List() {
+		if !webhook.matches(event.Type) {
+			continue
+		}
+		delivery, err := d.queue.Enqueue(webhook.ID, event)
+		if err != nil {
+			slog.Error("webhook queue: failed to save delivery", "delivery_id", delivery.ID, "err", err)
+		}
+		d.send(delivery.ID)
+	}
+}
+
+// Wait は送信中の Webhook がすべて終わるまで待ちます
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Run は ctx がキャンセルされるまで、interval ごとに再送する時刻になった配信を送信します
+// 起動時にも一度送るので、ファイルに残っていた送信待ちの配信は再起動後すぐに送ります
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.RetryDue()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetryDue は再送する時刻になった配信をすべて非同期に送信します
+func (d *Dispatcher) RetryDue() {
+	for _, delivery := range d.queue.claimDue() {
+		d.wg.Add(1)
+		go func(delivery Delivery) {
+			defer d.wg.Done()
+			d.attempt(delivery)
+		}(delivery)
+	}
+}
+
+// Replay は配信できなかったものを送信待ちに戻し、すぐに送信します
+// 見つからなければ ErrDeliveryNotFound を返します
+func (d *Dispatcher) Replay(id int) (Delivery, error) {
+	delivery, err := d.queue.Replay(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	d.send(delivery.ID)
+	return delivery, nil
+}
+
+// send は id の配信がほかで送信中でなければ、非同期に送信します
+func (d *Dispatcher) send(id int) {
+	delivery, ok := d.queue.claim(id)
+	if !ok {
+		return
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.attempt(delivery)
+	}()
+}
+
+// attempt は送信中にした配信を1回送り、結果をキューに記録します
+// Webhook がすでに削除されていれば、送らずにキューから取り除きます
+func (d *Dispatcher) attempt(delivery Delivery) {
+	webhook, ok := d.store.Get(delivery.WebhookID)
+	if !ok {
+		if err := d.queue.drop(delivery.ID); err != nil {
+			slog.Error("webhook queue", "err", err)
+		}
+		return
+	}
+	err := d.Deliver(webhook, delivery.Event)
+	if err != nil {
+		slog.Warn("webhook delivery failed", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempt", delivery.Attempts+1, "err", err)
+	}
+	if err := d.queue.finish(delivery.ID, err); err != nil {
+		slog.Error("webhook queue", "err", err)
+	}
+}
+
+// Deliver はイベントを1件の Webhook へ同期的に送信します
+func (d *Dispatcher) Deliver(webhook Webhook, event models.Event) error {
+	body, err := Render(webhook.Preset, webhook.Template, event)
+	if err != nil {
+		return err
+	}
+
+	contentType := webhook.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	resp, err := d.httpClient.Post(webhook.URL, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"todo-app/models"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		webhook Webhook
+		valid   bool
+	}{
+		{"preset", Webhook{URL: "https://hooks.slack.com/x", Preset: PresetSlack}, true},
+		{"template", Webhook{URL: "http://localhost/hook", Template: `{"t": {{json .Message}}}`}, true},
+		{"relative url", Webhook{URL: "/hook"}, false},
+		{"bad scheme", Webhook{URL: "ftp://example.com"}, false},
+		{"bad event", Webhook{URL: "https://example.com", Events: []models.EventType{"task.exploded"}}, false},
+		{"bad preset", Webhook{URL: "https://example.com", Preset: "irc"}, false},
+		{"bad template", Webhook{URL: "https://example.com", Template: "{{"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.webhook.Validate()
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%v, got error %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.Add(Webhook{URL: "invalid"}); err == nil {
+		t.Error("Expected invalid webhook to be rejected")
+	}
+
+	first, err := store.Add(Webhook{URL: "https://example.com/1"})
+	if err != nil || first.ID != 1 {
+		t.Fatalf("Unexpected result: %+v, %v", first, err)
+	}
+	second, _ := store.Add(Webhook{URL: "https://example.com/2"})
+	if second.ID != 2 {
+		t.Errorf("Expected ID 2, got %d", second.ID)
+	}
+
+	if len(store.List()) != 2 {
+		t.Errorf("Expected 2 webhooks, got %d", len(store.List()))
+	}
+	if got, ok := store.Get(second.ID); !ok || got.URL != "https://example.com/2" {
+		t.Errorf("Expected Get to find webhook 2, got %+v", got)
+	}
+	if !store.Delete(first.ID) || store.Delete(first.ID) {
+		t.Error("Expected Delete to succeed once")
+	}
+	if len(store.List()) != 1 {
+		t.Errorf("Expected 1 webhook, got %d", len(store.List()))
+	}
+	if _, ok := store.Get(first.ID); ok {
+		t.Error("Expected Get not to find a deleted webhook")
+	}
+}
+
+func TestDispatcherDeliversMatchingWebhooks(t *testing.T) {
+	ctx := context.Background()
+	var mutex sync.Mutex
+	received := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		received[r.URL.Path] = string(body)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	store := NewStore()
+	store.Add(Webhook{URL: server.URL + "/slack", Preset: PresetSlack})
+	store.Add(Webhook{URL: server.URL + "/deletes", Events: []models.EventType{models.EventTaskDeleted}})
+	store.Add(Webhook{URL: server.URL + "/custom", Template: "{{.Event.Task.Title}}", ContentType: "text/plain"})
+
+	app := models.NewTodoApp()
+	dispatcher := NewDispatcher(store, nil, server.Client())
+	app.Subscribe(dispatcher.HandleEvent)
+
+	app.AddTask(ctx, "Buy milk")
+	dispatcher.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if received["/slack"] != `{"text": "タスク「Buy milk」が追加されました"}` {
+		t.Errorf("Unexpected slack payload: %q", received["/slack"])
+	}
+	if _, ok := received["/deletes"]; ok {
+		t.Error("Webhook filtered to deletes should not receive create events")
+	}
+	if received["/custom"] != "Buy milk" {
+		t.Errorf("Unexpected custom payload: %q", received["/custom"])
+	}
+}
+
+func TestDeliverErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(NewStore(), nil, nil)
+	err := dispatcher.Deliver(Webhook{URL: server.URL}, models.Event{Type: models.EventTaskCreated})
+	if err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
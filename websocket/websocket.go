@@ -0,0 +1,297 @@
+// Package websocket は WebSocket（RFC 6455）のサーバ側の最小限の実装です
+// 外部のライブラリを使わずに、ハンドシェイク・テキストメッセージの送受信・ping と close への応答だけに対応しています
+// 拡張（圧縮など）とサブプロトコルには対応していません
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBadHandshake は WebSocket のハンドシェイクではないリクエストを Upgrade に渡したときのエラーです
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// ErrCrossOrigin は Origin ヘッダがリクエストのホストと違う（ほかのサイトのページから開いた）ときのエラーです
+// ブラウザは Cookie を付けて接続するため、ほかのサイトからログイン中のユーザーのタスクを読まれないように断ります
+var ErrCrossOrigin = errors.New("websocket: cross-origin request")
+
+// ErrMessageTooLarge はクライアントから MaxMessageBytes を超えるメッセージが届いたときのエラーです
+var ErrMessageTooLarge = errors.New("websocket: message too large")
+
+// MaxMessageBytes はクライアントから受け取るメッセージの大きさの上限です
+const MaxMessageBytes = 64 << 10
+
+// WriteTimeout は1つのフレームを書き込むまでの時間です。受け取らなくなったクライアントに書き込みが止まらないようにします
+const WriteTimeout = 10 * time.Second
+
+// acceptGUID は Sec-WebSocket-Accept を計算するために RFC 6455 が定める値です
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// フレームの種類（opcode）です
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// 接続を閉じる理由のコードです
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseTooLarge        = 1009
+)
+
+// Conn はハンドシェイクを終えた WebSocket の接続です
+// Read は1つのゴルーチンから、WriteText・Ping・Close はどのゴルーチンからでも呼び出せます
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+}
+
+// Upgrade は r のハンドシェイクを確かめ、101 Switching Protocols を返して WebSocket の接続にします
+// ハンドシェイクでなければ ErrBadHandshake を、ほかのサイトからの接続なら ErrCrossOrigin を返し、w には何も書き込みません
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet:
+		return nil, fmt.Errorf("%w: method must be GET", ErrBadHandshake)
+	case !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket"):
+		return nil, fmt.Errorf("%w: Connection: Upgrade and Upgrade: websocket are required", ErrBadHandshake)
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return nil, fmt.Errorf("%w: Sec-WebSocket-Version must be 13", ErrBadHandshake)
+	case !validKey(key):
+		return nil, fmt.Errorf("%w: Sec-WebSocket-Key is missing or malformed", ErrBadHandshake)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(u.Host, r.Host) {
+			return nil, fmt.Errorf("%w: origin %q does not match host %q", ErrCrossOrigin, origin, r.Host)
+		}
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: the response writer does not support hijacking")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+	// http.Server のタイムアウトで付いた期限を外し、接続を開いたままにできるようにします
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake write failed: %w", err)
+	}
+	conn.SetWriteDeadline(time.Time{})
+	return &Conn{conn: conn, reader: rw.Reader}, nil
+}
+
+// AcceptKey はクライアントの Sec-WebSocket-Key に対する Sec-WebSocket-Accept の値を返します
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Read は次のテキストかバイナリのメッセージを返します
+// 途中に届いた ping には pong で応え、close を受け取ったら close を返して io.EOF を返します
+func (c *Conn) Read() ([]byte, error) {
+	var message []byte
+	started := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrMessageTooLarge) {
+				c.closeWith(CloseTooLarge)
+			} else if !errors.Is(err, io.EOF) && !isNetClosed(err) {
+				c.closeWith(CloseProtocolError)
+			}
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.closeWith(CloseNormal)
+			return nil, io.EOF
+		case opText, opBinary:
+			if started {
+				c.closeWith(CloseProtocolError)
+				return nil, errors.New("websocket: new message before the previous one finished")
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				c.closeWith(CloseProtocolError)
+				return nil, errors.New("websocket: continuation without a message")
+			}
+		default:
+			c.closeWith(CloseProtocolError)
+			return nil, fmt.Errorf("websocket: unknown opcode %#x", opcode)
+		}
+
+		if len(message)+len(payload) > MaxMessageBytes {
+			c.closeWith(CloseTooLarge)
+			return nil, ErrMessageTooLarge
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// WriteText はテキストメッセージを1つのフレームで送ります
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping は ping を送ります。応答のない接続は、次の書き込みが WriteTimeout で失敗することで分かります
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// Close は code（CloseNormal など）で close を送ってから接続を閉じます。何度呼び出してもかまいません
+func (c *Conn) Close(code int) error {
+	return c.closeWith(code)
+}
+
+// closeWith は一度だけ close を送って接続を閉じます
+func (c *Conn) closeWith(code int) error {
+	var err error
+	c.closeOnce.Do(func() {
+		payload := make([]byte, 2)
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		c.writeFrame(opClose, payload)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// readFrame はクライアントから1つのフレームを読み取り、マスクを外した内容を返します
+// クライアントのフレームは必ずマスクされているため、マスクのないフレームは誤りにします
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, errors.New("websocket: reserved bits are set")
+	}
+	opcode = header[0] & 0x0F
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, errors.New("websocket: client frames must be masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= opClose && (length > 125 || !fin) {
+		return false, 0, nil, errors.New("websocket: control frames must be short and unfragmented")
+	}
+	if length > MaxMessageBytes {
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame は payload を1つのフレーム（マスクなし）で送ります
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, 127), ext[:]...)
+	}
+	frame = append(frame, payload...)
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// headerContains はカンマ区切りのヘッダ name に token が含まれるかどうかを返します（大文字・小文字を区別しません）
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validKey は Sec-WebSocket-Key が16バイトの値を base64 にしたものかどうかを返します
+func validKey(key string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	return err == nil && len(decoded) == 16
+}
+
+// isNetClosed は err が閉じた接続を読み書きしたときのエラーかどうかを返します
+func isNetClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
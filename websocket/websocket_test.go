@@ -0,0 +1,179 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 の 1.3 節の例です
+	if got := AcceptKey(testKey); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected the accept key from RFC 6455, got %s", got)
+	}
+}
+
+func TestUpgradeRejectsBadHandshakes(t *testing.T) {
+	for name, tt := range map[string]struct {
+		method string
+		header map[string]string
+		want   error
+	}{
+		"post":         {"POST", nil, ErrBadHandshake},
+		"no upgrade":   {"GET", map[string]string{"Upgrade": ""}, ErrBadHandshake},
+		"old version":  {"GET", map[string]string{"Sec-WebSocket-Version": "8"}, ErrBadHandshake},
+		"short key":    {"GET", map[string]string{"Sec-WebSocket-Key": "c2hvcnQ="}, ErrBadHandshake},
+		"cross origin": {"GET", map[string]string{"Origin": "https://evil.example"}, ErrCrossOrigin},
+	} {
+		r := httptest.NewRequest(tt.method, "http://todo.example/ws", nil)
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", testKey)
+		for key, value := range tt.header {
+			r.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		if _, err := Upgrade(rr, r); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, err)
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("%s: expected nothing to be written, got %q", name, rr.Body.String())
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		message, err := conn.Read()
+		if err != nil {
+			t.Errorf("Read failed: %v", err)
+			return
+		}
+		received <- string(message)
+		conn.WriteText([]byte("echo: " + string(message)))
+		if _, err := conn.Read(); err != io.EOF {
+			t.Errorf("Expected io.EOF after close, got %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client, reader := dialTest(t, server.URL)
+	defer client.Close()
+
+	// 2つのフレームに分けたメッセージの間に ping を挟みます
+	writeTestFrame(t, client, opText, false, "hello, ")
+	writeTestFrame(t, client, opPing, true, "are you there?")
+	writeTestFrame(t, client, opContinuation, true, "world")
+
+	if opcode, payload := readTestFrame(t, reader); opcode != opPong || payload != "are you there?" {
+		t.Errorf("Expected a pong with the ping payload, got %#x %q", opcode, payload)
+	}
+	if message := <-received; message != "hello, world" {
+		t.Errorf("Expected the fragmented message to be joined, got %q", message)
+	}
+	if opcode, payload := readTestFrame(t, reader); opcode != opText || payload != "echo: hello, world" {
+		t.Errorf("Expected the echoed text, got %#x %q", opcode, payload)
+	}
+
+	writeTestFrame(t, client, opClose, true, "\x03\xe8")
+	if opcode, payload := readTestFrame(t, reader); opcode != opClose || binary.BigEndian.Uint16([]byte(payload)) != CloseNormal {
+		t.Errorf("Expected a normal close in reply, got %#x %q", opcode, payload)
+	}
+}
+
+func TestConnRejectsUnmaskedFrames(t *testing.T) {
+	done := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		_, err = conn.Read()
+		done <- err
+	}))
+	defer server.Close()
+
+	client, reader := dialTest(t, server.URL)
+	defer client.Close()
+	client.Write([]byte{0x81, 0x02, 'h', 'i'})
+
+	if err := <-done; err == nil {
+		t.Error("Expected an unmasked frame to be rejected")
+	}
+	if opcode, payload := readTestFrame(t, reader); opcode != opClose || binary.BigEndian.Uint16([]byte(payload)) != CloseProtocolError {
+		t.Errorf("Expected a protocol error close, got %#x %q", opcode, payload)
+	}
+}
+
+// dialTest は rawURL のサーバに接続して WebSocket のハンドシェイクを行います
+func dialTest(t *testing.T, rawURL string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(rawURL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	request := "GET /ws HTTP/1.1\r\nHost: " + strings.TrimPrefix(rawURL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: " + testKey + "\r\nOrigin: " + rawURL + "\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != AcceptKey(testKey) {
+		t.Fatalf("Expected 101 with the accept key, got %d %v", response.StatusCode, response.Header)
+	}
+	return conn, reader
+}
+
+// writeTestFrame はクライアントとしてマスクしたフレームを送ります
+func writeTestFrame(t *testing.T, conn net.Conn, opcode byte, fin bool, payload string) {
+	t.Helper()
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{first, 0x80 | byte(len(payload))}, mask...)
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestFrame はサーバから届いた短いフレームを読み取ります
+func readTestFrame(t *testing.T, reader *bufio.Reader) (byte, string) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, header[1]&0x7F)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, string(payload)
+}
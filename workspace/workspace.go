@@ -0,0 +1,167 @@
+// Package workspace は1つのサーバで複数のチームや家族のタスクを分けて扱うワークスペースを管理します
+// ワークスペースごとに独立した http.Handler（タスクの保存先・Webhook・ルールなどを別々に持つサーバ）を作り、
+// /w/{slug}/ 以下のリクエストをそのハンドラへ渡します
+package workspace
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"todo-app/models"
+)
+
+// Workspace は1件のワークスペースです
+// Slug: URL（/w/{slug}/）に使う識別子。英小文字・数字・- の 1〜40 文字
+// Name: 表示用の名前
+// Restricted / Members: ID プロバイダのグループ（SCIM）から同期したワークスペースでは、メンバー（ユーザーの ID）だけが使えます
+type Workspace struct {
+	Slug       string    `json:"slug"`
+	Name       string    `json:"name"`
+	Restricted bool      `json:"restricted,omitempty"`
+	Members    []int     `json:"members,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Path は ワークスペースの URL のパスの接頭辞（/w/{slug}）を返します
+func (w Workspace) Path() string {
+	return "/w/" + w.Slug
+}
+
+// Allows は userID のユーザーがワークスペースを使えるかを返します（メンバーを限っていなければ誰でも使えます）
+func (w Workspace) Allows(userID int) bool {
+	if !w.Restricted {
+		return true
+	}
+	for _, id := range w.Members {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// slugPattern は Slug に使える文字列です
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// ValidateSlug は slug がワークスペースの識別子として使えるかを確かめます
+func ValidateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("%w: slug %q must be 1-40 lowercase letters, digits or hyphens", models.ErrValidation, slug)
+	}
+	return nil
+}
+
+// HandlerFunc はワークスペースのリクエストを処理する http.Handler を作る関数です
+// ハンドラはワークスペースの接頭辞を取り除いたパス（/api/tasks など）でリクエストを受け取ります
+type HandlerFunc func(Workspace) (http.Handler, error)
+
+// Store は作成したワークスペースとそのハンドラを保持します
+type Store struct {
+	newHandler HandlerFunc
+	now        func() time.Time
+
+	mutex      sync.RWMutex
+	workspaces map[string]entry
+}
+
+type entry struct {
+	workspace Workspace
+	handler   http.Handler
+}
+
+// NewStore はワークスペースごとのハンドラを newHandler で作る空の Store を作成します
+func NewStore(newHandler HandlerFunc) *Store {
+	return &Store{newHandler: newHandler, now: time.Now, workspaces: make(map[string]entry)}
+}
+
+// Create はワークスペースを作成し、そのハンドラを準備します
+// slug が不正なら ErrValidation を、作成済みなら ErrConflict を返します。name が空なら slug を名前にします
+func (s *Store) Create(slug, name string) (Workspace, error) {
+	if err := ValidateSlug(slug); err != nil {
+		return Workspace{}, err
+	}
+	if name == "" {
+		name = slug
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.workspaces[slug]; ok {
+		return Workspace{}, fmt.Errorf("%w: workspace %q already exists", models.ErrConflict, slug)
+	}
+	workspace := Workspace{Slug: slug, Name: name, CreatedAt: s.now()}
+	handler, err := s.newHandler(workspace)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("workspace %s: %w", slug, err)
+	}
+	s.workspaces[slug] = entry{workspace: workspace, handler: handler}
+	return workspace, nil
+}
+
+// List は作成済みのワークスペースを slug の順に返します
+func (s *Store) List() []Workspace {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	workspaces := make([]Workspace, 0, len(s.workspaces))
+	for _, e := range s.workspaces {
+		workspaces = append(workspaces, e.workspace)
+	}
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].Slug < workspaces[j].Slug })
+	return workspaces
+}
+
+// Lookup は slug のワークスペースとそのハンドラを返します。なければ false を返します
+func (s *Store) Lookup(slug string) (Workspace, http.Handler, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	e, ok := s.workspaces[slug]
+	return e.workspace, e.handler, ok
+}
+
+// SetMembers はワークスペースを使えるユーザーを members（ユーザーの ID）に限ります。見つからなければ false を返します
+// name が空でなければ表示用の名前も変えます
+func (s *Store) SetMembers(slug, name string, members []int) (Workspace, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.workspaces[slug]
+	if !ok {
+		return Workspace{}, false
+	}
+	if name != "" {
+		e.workspace.Name = name
+	}
+	e.workspace.Restricted = true
+	e.workspace.Members = uniqueMembers(members)
+	s.workspaces[slug] = e
+	return e.workspace, true
+}
+
+// uniqueMembers は重複を除いて小さい順に並べたユーザーの ID を返します
+func uniqueMembers(members []int) []int {
+	unique := make([]int, 0, len(members))
+	seen := make(map[int]bool, len(members))
+	for _, id := range members {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Ints(unique)
+	return unique
+}
+
+// Delete は slug のワークスペースを削除します。見つからなければ false を返します
+// メモリ上のタスクはワークスペースとともに消えます
+func (s *Store) Delete(slug string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.workspaces[slug]; !ok {
+		return false
+	}
+	delete(s.workspaces, slug)
+	return true
+}
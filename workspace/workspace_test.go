@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/models"
+)
+
+// echoHandler はワークスペースの slug とパスを返すハンドラを作ります
+func echoHandler(ws Workspace) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ws.Slug + " " + r.URL.Path))
+	}), nil
+}
+
+func TestValidateSlug(t *testing.T) {
+	for _, slug := range []string{"family", "team-1", "a"} {
+		if err := ValidateSlug(slug); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", slug, err)
+		}
+	}
+	for _, slug := range []string{"", "-team", "Team", "a/b", "チーム", "abcdefghijabcdefghijabcdefghijabcdefghijk"} {
+		if err := ValidateSlug(slug); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("Expected %q to be invalid, got %v", slug, err)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(echoHandler)
+
+	team, err := s.Create("team", "開発チーム")
+	if err != nil || team.Name != "開発チーム" || team.Path() != "/w/team" {
+		t.Fatalf("Unexpected workspace %+v (%v)", team, err)
+	}
+	family, _ := s.Create("family", "")
+	if family.Name != "family" {
+		t.Errorf("Expected the slug as the default name, got %q", family.Name)
+	}
+	if _, err := s.Create("team", "again"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate slug, got %v", err)
+	}
+	if _, err := s.Create("Bad Slug", ""); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an invalid slug, got %v", err)
+	}
+
+	list := s.List()
+	if len(list) != 2 || list[0].Slug != "family" || list[1].Slug != "team" {
+		t.Errorf("Expected workspaces sorted by slug, got %+v", list)
+	}
+
+	_, handler, ok := s.Lookup("team")
+	if !ok {
+		t.Fatal("Expected team to be found")
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Body.String() != "team /api/tasks" {
+		t.Errorf("Unexpected response %q", rr.Body.String())
+	}
+
+	if !s.Delete("team") || s.Delete("team") {
+		t.Error("Expected team to be deleted once")
+	}
+	if _, _, ok := s.Lookup("team"); ok {
+		t.Error("Expected team to be gone")
+	}
+}
+
+func TestSetMembers(t *testing.T) {
+	s := NewStore(echoHandler)
+	team, _ := s.Create("team", "")
+	if !team.Allows(1) {
+		t.Error("Expected a workspace without members to allow everyone")
+	}
+
+	team, ok := s.SetMembers("team", "開発チーム", []int{3, 1, 3})
+	if !ok || !team.Restricted || team.Name != "開発チーム" || len(team.Members) != 2 || team.Members[0] != 1 || team.Members[1] != 3 {
+		t.Fatalf("Unexpected workspace %+v", team)
+	}
+	if !team.Allows(3) || team.Allows(2) {
+		t.Errorf("Expected only members to be allowed, got %+v", team)
+	}
+	if team, _ = s.SetMembers("team", "", nil); team.Name != "開発チーム" || team.Allows(1) {
+		t.Errorf("Expected an empty name to keep the name and no members to allow no one, got %+v", team)
+	}
+	if _, ok := s.SetMembers("missing", "", nil); ok {
+		t.Error("Expected SetMembers to fail for a missing workspace")
+	}
+}
+
+func TestCreateHandlerError(t *testing.T) {
+	s := NewStore(func(Workspace) (http.Handler, error) { return nil, errors.New("disk full") })
+	if _, err := s.Create("team", ""); err == nil {
+		t.Error("Expected the handler error to be returned")
+	}
+	if len(s.List()) != 0 {
+		t.Error("Expected the workspace not to be created")
+	}
+}